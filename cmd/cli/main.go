@@ -21,7 +21,7 @@ import (
 	"commit_craft_reborn/internal/tui/styles"
 )
 
-var version = "v0.69.0"
+var version = "v0.94.15"
 
 func main() {
 	// Headless subcommand path: when the first positional arg is "ai",
@@ -31,6 +31,11 @@ func main() {
 	if len(os.Args) > 1 && os.Args[1] == "ai" {
 		os.Exit(aicli.Dispatch(os.Args[2:]))
 	}
+	// `hook` is the git hook manager; the installed hook scripts call
+	// back into `hook run`, so it must stay as light as the ai path.
+	if len(os.Args) > 1 && os.Args[1] == "hook" {
+		os.Exit(aicli.DispatchHook(os.Args[2:]))
+	}
 
 	log := logger.New()
 	log.Info("Starting Commit Crafter application...")
@@ -62,6 +67,21 @@ func main() {
 	registerCommitTypePalettes(globalCfg.CommitFormat.CommitTypePalettes)
 	config.ResolveReleaseConfig(&globalCfg, localCfg)
 	config.ResolveTUIConfig(&globalCfg, localCfg)
+	config.ResolveCommitFormatConfig(&globalCfg, localCfg)
+	config.ResolveGitCommitConfig(&globalCfg, localCfg)
+	config.ResolveHooksConfig(&globalCfg, localCfg)
+	config.ResolveTrailersConfig(&globalCfg, localCfg)
+	config.ResolveDiffConfig(&globalCfg, localCfg)
+	config.ResolveScopeConfig(&globalCfg, localCfg)
+	config.ResolveFewShotConfig(&globalCfg, localCfg)
+	if err := git.SetDiffFilter(git.DiffFilter{
+		Ignore:     globalCfg.Diff.Ignore,
+		Keep:       globalCfg.Diff.Keep,
+		Builtin:    globalCfg.Diff.BuiltinRules,
+		Attributes: globalCfg.Diff.GitAttributes,
+	}); err != nil {
+		log.Fatal("Invalid [diff] configuration", "error", err)
+	}
 
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -152,6 +172,8 @@ func main() {
 				short = short[:7]
 			}
 			fmt.Fprintf(os.Stderr, "Reword cancelled — commit %s left unchanged.\n", short)
+		case m.CommittedHash != "":
+			fmt.Fprintf(os.Stderr, "Committed %s.\n", m.CommittedHash[:7])
 		case m.FinalMessage != "":
 			fmt.Print(m.FinalMessage)
 		}
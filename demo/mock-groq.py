@@ -10,9 +10,12 @@ It answers the two endpoints the app uses:
   GET  /openai/v1/models             → a one-model catalogue
   POST /openai/v1/chat/completions   → a canned completion, branched by the
                                        pipeline stage detected in the prompt.
+                                       Honors `"stream": true` with an SSE
+                                       reply shaped like Groq's.
 """
 
 import json
+import re
 import time
 from http.server import BaseHTTPRequestHandler, HTTPServer
 
@@ -36,6 +39,12 @@ BODY = (
 )
 TITLE = "retry transient Groq errors with backoff"
 
+USAGE = {
+    "prompt_tokens": 420, "completion_tokens": 88, "total_tokens": 508,
+    "queue_time": 0.012, "prompt_time": 0.031,
+    "completion_time": 0.144, "total_time": 0.175,
+}
+
 
 def pick_content(payload: str) -> str:
     text = payload.lower()
@@ -65,6 +74,29 @@ class Handler(BaseHTTPRequestHandler):
         self.end_headers()
         self.wfile.write(data)
 
+    def _stream(self, content):
+        self.send_response(200)
+        self.send_header("Content-Type", "text/event-stream")
+        self.send_header("x-request-id", "demo-mock-0001")
+        self.send_header("x-ratelimit-limit-requests", "1000")
+        self.send_header("x-ratelimit-remaining-requests", "999")
+        self.end_headers()
+
+        def event(obj):
+            self.wfile.write(b"data: " + json.dumps(obj).encode() + b"\n\n")
+            self.wfile.flush()
+
+        for piece in re.findall(r"\S+\s*", content):
+            event({"model": MODEL, "choices": [{"index": 0, "delta": {"content": piece}}]})
+            time.sleep(0.03)
+        event({
+            "model": MODEL,
+            "choices": [{"index": 0, "delta": {}, "finish_reason": "stop"}],
+            "x_groq": {"id": "demo-mock-0001", "usage": USAGE},
+        })
+        self.wfile.write(b"data: [DONE]\n\n")
+        self.wfile.flush()
+
     def do_GET(self):
         if self.path.endswith("/models"):
             self._json(200, {
@@ -83,14 +115,17 @@ class Handler(BaseHTTPRequestHandler):
         # A touch of latency so the multi-stage pipeline animation is visible.
         time.sleep(0.6)
         content = pick_content(payload)
+        try:
+            streaming = bool(json.loads(payload).get("stream"))
+        except ValueError:
+            streaming = False
+        if streaming:
+            self._stream(content)
+            return
         self._json(200, {
             "model": MODEL,
             "choices": [{"index": 0, "message": {"role": "assistant", "content": content}}],
-            "usage": {
-                "prompt_tokens": 420, "completion_tokens": 88, "total_tokens": 508,
-                "queue_time": 0.012, "prompt_time": 0.031,
-                "completion_time": 0.144, "total_time": 0.175,
-            },
+            "usage": USAGE,
         })
 
 
@@ -0,0 +1,111 @@
+package aiengine
+
+import (
+	"errors"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/storage"
+)
+
+// AttemptCalls projects the failed attempts of one stage into ai_calls
+// rows, numbered from 1 in the order they happened. Callers insert them
+// before the served row (which takes Attempt len(attempts)+1) so a plain
+// ORDER BY id reload replays the stage in order. ownerID lands in
+// CommitID, which doubles as the release id for release_ai_calls.
+func AttemptCalls(ownerID int, stage, provider string, attempts []api.CallAttempt) []storage.AICall {
+	out := make([]storage.AICall, 0, len(attempts))
+	for i, a := range attempts {
+		call := storage.AICall{
+			CommitID: ownerID,
+			Stage:    stage,
+			Provider: provider,
+			Model:    a.Model,
+			Attempt:  i + 1,
+			Outcome:  a.Outcome,
+			Error:    a.Error,
+		}
+		if a.Stats != nil {
+			call.RequestID = a.Stats.RequestID
+			call.TPMLimitAtCall = a.Stats.RateLimits.LimitTokens
+		}
+		out = append(out, call)
+	}
+	return out
+}
+
+// AttemptFromCall is the reload-side inverse of AttemptCalls. Only
+// meaningful for rows where Served() is false.
+func AttemptFromCall(c storage.AICall) api.CallAttempt {
+	return api.CallAttempt{
+		Model:   c.Model,
+		Outcome: c.Outcome,
+		Error:   c.Error,
+	}
+}
+
+// CreateStageCalls inserts the attempt rows for a stage followed by its
+// served row through create (db.CreateAICall or db.CreateReleaseAICall).
+func CreateStageCalls(
+	create func(storage.AICall) (int64, error),
+	served storage.AICall,
+	attempts []api.CallAttempt,
+) error {
+	for _, c := range AttemptCalls(served.CommitID, served.Stage, served.Provider, attempts) {
+		c.ChunkIndex, c.ChunkLabel = served.ChunkIndex, served.ChunkLabel
+		if _, err := create(c); err != nil {
+			return err
+		}
+	}
+	served.Attempt = len(attempts) + 1
+	served.Outcome = storage.AICallOutcomeOK
+	_, err := create(served)
+	return err
+}
+
+// AttemptsError is returned by a stage call no target served. Attempts
+// holds every failed try, oldest first, so callers can record them the
+// way they record the failed tries of a served call.
+type AttemptsError struct {
+	Provider string
+	Attempts []api.CallAttempt
+	Err      error
+}
+
+func (e *AttemptsError) Error() string { return e.Err.Error() }
+
+func (e *AttemptsError) Unwrap() error { return e.Err }
+
+// withAttempts wraps err with the attempts made so far; err is returned
+// unchanged when there are none.
+func withAttempts(provider api.Provider, attempts []api.CallAttempt, err error) error {
+	if len(attempts) == 0 {
+		return err
+	}
+	return &AttemptsError{Provider: provider.Name(), Attempts: attempts, Err: err}
+}
+
+// FailedAttempts returns the provider and attempts carried by err, or
+// nil when err holds no *AttemptsError.
+func FailedAttempts(err error) (string, []api.CallAttempt) {
+	var ae *AttemptsError
+	if !errors.As(err, &ae) {
+		return "", nil
+	}
+	return ae.Provider, ae.Attempts
+}
+
+// CreateFailedStageCalls inserts the attempt rows of a stage no call
+// served, so a failed run still records what was tried.
+func CreateFailedStageCalls(
+	create func(storage.AICall) (int64, error),
+	ownerID int,
+	stage, provider string,
+	attempts []api.CallAttempt,
+) error {
+	for _, c := range AttemptCalls(ownerID, stage, provider, attempts) {
+		if _, err := create(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
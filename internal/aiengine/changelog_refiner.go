@@ -1,6 +1,7 @@
 package aiengine
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"commit_craft_reborn/internal/changelog"
+	"commit_craft_reborn/internal/config"
 )
 
 // changelogRefinerOutput mirrors the JSON contract documented in
@@ -25,8 +27,9 @@ type changelogRefinerOutput struct {
 // the results onto out. Best-effort: any failure is logged and the run
 // continues with empty changelog fields. Reads out.Body and out.Title
 // as input, so callers re-running only this stage can populate those
-// from an existing draft.
-func RunChangelogRefiner(deps Deps, out *Output) {
+// from an existing draft. A cancelled ctx ends the stage like any other
+// failure; callers that care check ctx.Err() afterwards.
+func RunChangelogRefiner(ctx context.Context, deps Deps, out *Output) {
 	out.ChangelogEntry = ""
 	out.ChangelogMentionLine = ""
 
@@ -67,8 +70,11 @@ func RunChangelogRefiner(deps Deps, out *Output) {
 		bulletHint,
 	)
 
-	response, stats, err := SendIaMessage(deps, prompt, userInput, cfg.PromptModel)
+	response, stats, err := SendIaMessage(
+		ctx, deps, config.StageChangelog, prompt, userInput, cfg.PromptModel,
+	)
 	if err != nil {
+		RecordFailedStage(out, StageChangelog, cfg.PromptModel, err)
 		if deps.Log != nil {
 			deps.Log.Warn("Changelog refiner call failed", "error", err)
 		}
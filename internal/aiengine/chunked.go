@@ -0,0 +1,478 @@
+package aiengine
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// Map-reduce Change Analyzer. When the staged diff does not fit the
+// change analyzer model's context window (or chunking is forced with
+// change_analyzer_chunking = "always"), the diff is split per directory
+// or per file, every chunk is summarized by the regular change-analyzer
+// prompt (the map calls) and the partial summaries are merged by the
+// change-analyzer-reduce prompt into the usual two-paragraph summary.
+// Stage 1's stats are the reduce call's; the map calls travel in
+// StageStats.Chunks.
+
+// ChunkStats is the telemetry of one map call of a chunked Change
+// Analyzer run. Index counts from 1; Label is the directory or file
+// (or a short list of them) the chunk covers. Files and DiffChars are
+// only known for the run that produced them; reloaded rows leave them
+// empty.
+type ChunkStats struct {
+	Index     int
+	Label     string
+	Files     []string
+	DiffChars int
+	Stats     StageStats
+}
+
+// DiffChunk is one slice of a budgeted diff handed to a map call.
+type DiffChunk struct {
+	Label string
+	Files []string
+	Diff  string
+}
+
+const (
+	// defaultChunkChars sizes chunks when neither the context window nor
+	// change_analyzer_max_diff_size says how much a call can take.
+	defaultChunkChars = 32000
+	// minChunkChars keeps a tiny window from degenerating into one call
+	// per handful of lines.
+	minChunkChars = 2000
+	// chunkReserveTokens is left free in the window for the completion.
+	chunkReserveTokens = 1024
+)
+
+// RunChangeAnalyzer runs stage 1 of the pipeline on diff and records its
+// stats in out. It makes the single CallChangeAnalyzer call unless
+// PlanChunks splits the diff; then it runs the map calls and the reduce
+// call instead. live says diff was just read from the staged index of
+// deps.Pwd, so the chunked path may re-read the index without the diff
+// budget and analyze every file in full.
+func RunChangeAnalyzer(
+	ctx context.Context,
+	deps Deps,
+	keyPoints []string,
+	diff string,
+	live bool,
+	out *Output,
+) (string, error) {
+	pc := deps.Cfg.Prompts
+	mode := config.NormalizeChunking(pc.ChangeAnalyzerChunking)
+	window := contextWindowFor(deps, pc.ChangeAnalyzerPromptModel)
+	full := diff
+	if live && (mode == config.ChunkingAlways || (mode == config.ChunkingAuto && window > 0)) {
+		if b, err := git.BudgetStagedDiffAt(deps.Pwd, 0); err == nil && strings.TrimSpace(b.Diff) != "" {
+			full = b.Diff
+		} else if err != nil && deps.Log != nil {
+			deps.Log.Warn("Change Analyzer: could not read the full staged diff", "error", err)
+		}
+	}
+
+	if chunks := PlanChunks(deps.Cfg, full, keyPoints, window); chunks != nil {
+		return runChunkedAnalyzer(ctx, deps, keyPoints, full, chunks, out)
+	}
+
+	summary, stats, err := CallChangeAnalyzer(ctx, deps, keyPoints, diff)
+	if err != nil {
+		return "", err
+	}
+	RecordStage(out, StageSummary, pc.ChangeAnalyzerPromptModel, stats)
+	return summary, nil
+}
+
+// PlanChunks returns the chunks a Change Analyzer run over diff would
+// map, or nil when it would make a single call: chunking is off, the
+// payload fits window (0 = unknown, which only "always" chunks) or the
+// diff does not split into more than one chunk.
+func PlanChunks(cfg config.Config, diff string, keyPoints []string, window int) []DiffChunk {
+	mode := config.NormalizeChunking(cfg.Prompts.ChangeAnalyzerChunking)
+	if mode == config.ChunkingOff || (mode == config.ChunkingAuto && window <= 0) {
+		return nil
+	}
+	est := EstimateChangeAnalyzer(cfg, diff, keyPoints)
+	if mode == config.ChunkingAuto && est.EstTokens <= window {
+		return nil
+	}
+	chunks := ChunkDiff(diff, config.NormalizeChunkBy(cfg.Prompts.ChangeAnalyzerChunkBy),
+		chunkBudget(cfg, keyPoints, est, window))
+	if len(chunks) < 2 {
+		return nil
+	}
+	return chunks
+}
+
+// chunkBudget is the diff size, in characters, one map call may carry:
+// what is left of the context window after the system prompt, the
+// developer points and a completion reserve, capped by
+// change_analyzer_max_diff_size. Tokens convert to characters at the
+// ratio the tokenizer measured on the whole diff (est).
+func chunkBudget(cfg config.Config, keyPoints []string, est ChangeAnalyzerEstimate, window int) int {
+	budget := cfg.Prompts.ChangeAnalyzerMaxDiffSize
+	if window > 0 {
+		charsPerToken := 4.0
+		if est.EstTokens > 0 {
+			charsPerToken = float64(est.TotalChars) / float64(est.EstTokens)
+		}
+		overhead := EstimateChangeAnalyzer(cfg, "", keyPoints).TotalChars
+		fit := int(float64(window-chunkReserveTokens)*charsPerToken) - overhead
+		if budget <= 0 || fit < budget {
+			budget = fit
+		}
+	}
+	if budget <= 0 {
+		budget = defaultChunkChars
+	}
+	return max(budget, minChunkChars)
+}
+
+// contextWindowFor returns the cached context window of modelID, or 0
+// when there is no DB, the cache is empty or the model is not in it.
+func contextWindowFor(deps Deps, modelID string) int {
+	if deps.DB == nil || modelID == "" {
+		return 0
+	}
+	models, _, err := deps.DB.LoadModelsCache()
+	if err != nil {
+		return 0
+	}
+	for _, m := range models {
+		if m.ID == modelID {
+			return m.ContextWindow
+		}
+	}
+	return 0
+}
+
+// runChunkedAnalyzer makes one map call per chunk, then the reduce call.
+// Map calls are not streamed (their text is intermediate); the reduce
+// call streams as StageChangeAnalyzerReduce.
+func runChunkedAnalyzer(
+	ctx context.Context,
+	deps Deps,
+	keyPoints []string,
+	full string,
+	chunks []DiffChunk,
+	out *Output,
+) (string, error) {
+	pc := deps.Cfg.Prompts
+	if strings.TrimSpace(pc.ChangeAnalyzerReducePrompt) == "" {
+		return "", fmt.Errorf("change analyzer reduce prompt is empty")
+	}
+	mapDeps := deps
+	mapDeps.OnChunk = nil
+
+	stats := make([]ChunkStats, 0, len(chunks))
+	var partials strings.Builder
+	for i, c := range chunks {
+		if deps.Log != nil {
+			deps.Log.Debug("Change Analyzer chunk",
+				"chunk", i+1, "of", len(chunks), "label", c.Label, "chars", len(c.Diff))
+		}
+		summary, callStats, err := CallChangeAnalyzer(ctx, mapDeps, keyPoints, c.Diff)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d/%d (%s): %w", i+1, len(chunks), c.Label, err)
+		}
+		stats = append(stats, ChunkStats{
+			Index:     i + 1,
+			Label:     c.Label,
+			Files:     c.Files,
+			DiffChars: len(c.Diff),
+			Stats:     newStageStats(StageSummary, pc.ChangeAnalyzerPromptModel, callStats),
+		})
+		fmt.Fprintf(&partials, "=== chunk %d/%d: %s (%s) ===\n%s\n\n",
+			i+1, len(chunks), c.Label, plural(len(c.Files), "file", "files"),
+			strings.TrimSpace(summary))
+	}
+
+	input := fmt.Sprintf("DEVELOPER_POINTS:\n%s\nPARTIAL_SUMMARIES:\n%s",
+		stripMentions(strings.Join(keyPoints, "\n")), partials.String())
+	if _, omitted := git.SplitOmittedSection(full); len(omitted) > 0 {
+		var b strings.Builder
+		for _, f := range omitted {
+			fmt.Fprintf(&b, "%s +%d -%d (%s)\n", f.Path, max(f.Adds, 0), max(f.Dels, 0), f.Reason)
+		}
+		input += "OMITTED_FILES:\n" + b.String()
+	}
+	result, reduceStats, err := SendIaMessage(
+		ctx,
+		deps,
+		config.StageChangeAnalyzerReduce,
+		pc.ChangeAnalyzerReducePrompt,
+		input,
+		pc.ChangeAnalyzerReducePromptModel,
+	)
+	if err != nil {
+		return "", fmt.Errorf("reduce: %w", err)
+	}
+	if deps.Log != nil {
+		deps.Log.Debug("Change Analyzer reduce output", "chunks", len(chunks), "result", result)
+	}
+	RecordStage(out, StageSummary, pc.ChangeAnalyzerReducePromptModel, reduceStats)
+	out.Stages[StageSummary].Chunks = stats
+	return result, nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// ChunkDiff splits a budgeted diff ("=== path ===" blocks, optionally
+// followed by the omitted section, which is dropped here) into chunks
+// of at most maxChars characters. by is config.ChunkByDirectory or
+// config.ChunkByFile: each group (a directory's files, or one file)
+// stays in one chunk when it fits, and consecutive small groups share a
+// chunk. A group too big for one chunk is split between files, and a
+// single file too big for one chunk is split between lines into parts.
+func ChunkDiff(diff, by string, maxChars int) []DiffChunk {
+	blocks, _ := git.SplitOmittedSection(diff)
+	files := parseDiffBlocks(blocks)
+	if len(files) == 0 {
+		return nil
+	}
+	if maxChars <= 0 {
+		maxChars = defaultChunkChars
+	}
+
+	type group struct {
+		label string
+		files []diffBlock
+	}
+	var groups []group
+	for _, f := range files {
+		key := f.path
+		if by != config.ChunkByFile {
+			key = path.Dir(f.path)
+		}
+		if n := len(groups); n > 0 && groups[n-1].label == key {
+			groups[n-1].files = append(groups[n-1].files, f)
+			continue
+		}
+		groups = append(groups, group{label: key, files: []diffBlock{f}})
+	}
+
+	var chunks []DiffChunk
+	var cur chunkBuilder
+	flush := func() {
+		if c, ok := cur.build(); ok {
+			chunks = append(chunks, c)
+		}
+		cur = chunkBuilder{}
+	}
+	for _, g := range groups {
+		size := 0
+		for _, f := range g.files {
+			size += len(f.text)
+		}
+		if size <= maxChars {
+			if cur.size+size > maxChars {
+				flush()
+			}
+			cur.add(g.label, g.files...)
+			continue
+		}
+		// The group alone is too big: give it chunks of its own.
+		flush()
+		for _, f := range g.files {
+			if len(f.text) > maxChars {
+				flush()
+				chunks = append(chunks, splitDiffBlock(f, maxChars)...)
+				continue
+			}
+			if cur.size+len(f.text) > maxChars {
+				flush()
+			}
+			cur.add(g.label, f)
+		}
+		flush()
+	}
+	flush()
+	return chunks
+}
+
+// diffBlock is one "=== path ===" block of a budgeted diff, header
+// included.
+type diffBlock struct {
+	path string
+	text string
+}
+
+// parseDiffBlocks splits the blocks section of a budgeted diff back into
+// per-file blocks, in order.
+func parseDiffBlocks(diff string) []diffBlock {
+	var out []diffBlock
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		trimmed := strings.TrimRight(line, "\n")
+		if strings.HasPrefix(trimmed, "=== ") && strings.HasSuffix(trimmed, " ===") && len(trimmed) > 8 {
+			out = append(out, diffBlock{path: trimmed[4 : len(trimmed)-4], text: line})
+			continue
+		}
+		if len(out) == 0 {
+			continue
+		}
+		out[len(out)-1].text += line
+	}
+	return out
+}
+
+// splitDiffBlock cuts one oversized file block into parts of at most
+// maxChars characters at line boundaries, each starting with the file's
+// header so the model knows what it is reading.
+func splitDiffBlock(f diffBlock, maxChars int) []DiffChunk {
+	lines := strings.SplitAfter(strings.TrimPrefix(f.text, fmt.Sprintf("=== %s ===\n", f.path)), "\n")
+	var parts []string
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && b.Len()+len(line) > maxChars-len(f.path)-32 {
+			parts = append(parts, b.String())
+			b.Reset()
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		parts = append(parts, b.String())
+	}
+	out := make([]DiffChunk, 0, len(parts))
+	for i, p := range parts {
+		label := fmt.Sprintf("%s (part %d/%d)", f.path, i+1, len(parts))
+		out = append(out, DiffChunk{
+			Label: label,
+			Files: []string{f.path},
+			Diff:  fmt.Sprintf("=== %s ===\n%s", label, p),
+		})
+	}
+	return out
+}
+
+// chunkBuilder accumulates groups into one chunk.
+type chunkBuilder struct {
+	labels []string
+	files  []string
+	diff   strings.Builder
+	size   int
+}
+
+func (c *chunkBuilder) add(label string, files ...diffBlock) {
+	if n := len(c.labels); n == 0 || c.labels[n-1] != label {
+		c.labels = append(c.labels, label)
+	}
+	for _, f := range files {
+		c.files = append(c.files, f.path)
+		c.diff.WriteString(f.text)
+		c.size += len(f.text)
+	}
+}
+
+func (c *chunkBuilder) build() (DiffChunk, bool) {
+	if len(c.files) == 0 {
+		return DiffChunk{}, false
+	}
+	return DiffChunk{Label: chunkLabel(c.labels), Files: c.files, Diff: c.diff.String()}, true
+}
+
+// chunkLabel names a chunk after the groups it holds: up to three are
+// listed, longer runs keep the first two plus a count.
+func chunkLabel(labels []string) string {
+	if len(labels) <= 3 {
+		return strings.Join(labels, ", ")
+	}
+	return fmt.Sprintf("%s, %s +%d more", labels[0], labels[1], len(labels)-2)
+}
+
+// CreateChunkCalls inserts the ai_calls rows of the map calls of a
+// chunked Change Analyzer run through create, each chunk's failed
+// attempts first. Callers write them right before the stage 1 rows.
+func CreateChunkCalls(
+	create func(storage.AICall) (int64, error),
+	ownerID int,
+	chunks []ChunkStats,
+) error {
+	for _, c := range chunks {
+		s := c.Stats
+		err := CreateStageCalls(create, storage.AICall{
+			CommitID:         ownerID,
+			Stage:            storage.AICallStageSummaryChunk,
+			Provider:         s.Provider,
+			Model:            firstNonEmptyString(s.StatsModel, s.Model),
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			TotalTokens:      s.TotalTokens,
+			QueueTimeMs:      int(s.QueueTime.Milliseconds()),
+			PromptTimeMs:     int(s.PromptTime.Milliseconds()),
+			CompletionTimeMs: int(s.CompletionTime.Milliseconds()),
+			TotalTimeMs:      int(s.APITotalTime.Milliseconds()),
+			RequestID:        s.RequestID,
+			TPMLimitAtCall:   s.TPMLimitAtCall,
+			ChunkIndex:       c.Index,
+			ChunkLabel:       c.Label,
+		}, s.Attempts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChunksFromCalls is the reload-side inverse of CreateChunkCalls: it
+// rebuilds the chunk list from the AICallStageSummaryChunk rows of
+// calls (other rows are ignored), ordered by chunk index.
+func ChunksFromCalls(calls []storage.AICall) []ChunkStats {
+	byIndex := map[int]*ChunkStats{}
+	for _, c := range calls {
+		if c.Stage != storage.AICallStageSummaryChunk {
+			continue
+		}
+		ch, ok := byIndex[c.ChunkIndex]
+		if !ok {
+			ch = &ChunkStats{Index: c.ChunkIndex, Label: c.ChunkLabel}
+			ch.Stats.ID = StageSummary
+			byIndex[c.ChunkIndex] = ch
+		}
+		if !c.Served() {
+			ch.Stats.Attempts = append(ch.Stats.Attempts, AttemptFromCall(c))
+			continue
+		}
+		ch.Stats.HasStats = true
+		ch.Stats.Provider = c.Provider
+		ch.Stats.Model = c.Model
+		ch.Stats.StatsModel = c.Model
+		ch.Stats.PromptTokens = c.PromptTokens
+		ch.Stats.CompletionTokens = c.CompletionTokens
+		ch.Stats.TotalTokens = c.TotalTokens
+		ch.Stats.QueueTime = time.Duration(c.QueueTimeMs) * time.Millisecond
+		ch.Stats.PromptTime = time.Duration(c.PromptTimeMs) * time.Millisecond
+		ch.Stats.CompletionTime = time.Duration(c.CompletionTimeMs) * time.Millisecond
+		ch.Stats.APITotalTime = time.Duration(c.TotalTimeMs) * time.Millisecond
+		ch.Stats.RequestID = c.RequestID
+		ch.Stats.TPMLimitAtCall = c.TPMLimitAtCall
+	}
+	if len(byIndex) == 0 {
+		return nil
+	}
+	out := make([]ChunkStats, 0, len(byIndex))
+	for _, ch := range byIndex {
+		out = append(out, *ch)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
+
+func firstNonEmptyString(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
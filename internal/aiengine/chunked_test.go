@@ -0,0 +1,223 @@
+package aiengine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+func diffBlockText(path string, lines int) string {
+	var b strings.Builder
+	b.WriteString("=== " + path + " ===\n@@ -0,0 +1 @@\n")
+	for i := 0; i < lines; i++ {
+		b.WriteString("+line of " + path + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func TestChunkDiffGroupsByDirectory(t *testing.T) {
+	diff := diffBlockText("cmd/main.go", 2) +
+		diffBlockText("internal/git/a.go", 20) +
+		diffBlockText("internal/git/b.go", 20) +
+		diffBlockText("internal/tui/view.go", 20) +
+		git.OmittedDiffHeader + "\ngo.sum +10 -2 (lockfile)\n"
+
+	gitDir := len(diffBlockText("internal/git/a.go", 20)) * 2
+	chunks := ChunkDiff(diff, config.ChunkByDirectory, gitDir+len(diffBlockText("cmd/main.go", 2)))
+
+	var got [][]string
+	var labels []string
+	for _, c := range chunks {
+		got = append(got, c.Files)
+		labels = append(labels, c.Label)
+		if strings.Contains(c.Diff, "go.sum") {
+			t.Errorf("chunk %q carries the omitted section", c.Label)
+		}
+	}
+	want := [][]string{
+		{"cmd/main.go", "internal/git/a.go", "internal/git/b.go"},
+		{"internal/tui/view.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("chunk files = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(labels, []string{"cmd, internal/git", "internal/tui"}) {
+		t.Errorf("labels = %v", labels)
+	}
+}
+
+func TestChunkDiffByFileSplitsOversizedFiles(t *testing.T) {
+	big := diffBlockText("internal/big.go", 200)
+	diff := diffBlockText("a.go", 2) + big
+	limit := len(big) / 3
+
+	chunks := ChunkDiff(diff, config.ChunkByFile, limit)
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks, want a.go plus at least 3 parts of big.go", len(chunks))
+	}
+	if chunks[0].Label != "a.go" {
+		t.Errorf("first chunk = %q, want a.go", chunks[0].Label)
+	}
+	var rebuilt strings.Builder
+	for i, c := range chunks[1:] {
+		if len(c.Diff) > limit {
+			t.Errorf("part %d is %d chars, over the %d budget", i+1, len(c.Diff), limit)
+		}
+		if !strings.HasPrefix(c.Label, "internal/big.go (part ") {
+			t.Errorf("part label = %q", c.Label)
+		}
+		_, body, _ := strings.Cut(c.Diff, "===\n")
+		rebuilt.WriteString(body)
+	}
+	if want := strings.TrimPrefix(big, "=== internal/big.go ===\n"); rebuilt.String() != want {
+		t.Error("parts do not add up to the original file diff")
+	}
+}
+
+func TestChunksFromCallsRoundTrip(t *testing.T) {
+	var rows []storage.AICall
+	create := func(c storage.AICall) (int64, error) {
+		rows = append(rows, c)
+		return int64(len(rows)), nil
+	}
+	in := []ChunkStats{
+		{Index: 1, Label: "internal/git", Stats: StageStats{HasStats: true, Model: "m", TotalTokens: 10}},
+		{Index: 2, Label: "internal/tui", Stats: StageStats{HasStats: true, Model: "m", TotalTokens: 20}},
+	}
+	if err := CreateChunkCalls(create, 7, in); err != nil {
+		t.Fatal(err)
+	}
+	rows = append(rows, storage.AICall{CommitID: 7, Stage: "summary", Model: "r"})
+
+	got := ChunksFromCalls(rows)
+	if len(got) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(got))
+	}
+	for i, c := range got {
+		if c.Index != in[i].Index || c.Label != in[i].Label ||
+			c.Stats.TotalTokens != in[i].Stats.TotalTokens || !c.Stats.HasStats {
+			t.Errorf("chunk %d = %+v, want %+v", i, c, in[i])
+		}
+	}
+}
+
+func TestPlanChunks(t *testing.T) {
+	diff := diffBlockText("cmd/main.go", 120) +
+		diffBlockText("internal/git/a.go", 120) +
+		diffBlockText("internal/tui/view.go", 120)
+	small := diffBlockText("a.go", 2)
+	est := EstimateChangeAnalyzer(config.NewDefaultConfig(), diff, nil).EstTokens
+
+	tests := []struct {
+		name       string
+		mode       string
+		maxDiff    int
+		diff       string
+		window     int
+		wantChunks bool
+	}{
+		{"off never chunks", config.ChunkingOff, 0, diff, 1, false},
+		{"auto without a known window", config.ChunkingAuto, 0, diff, 0, false},
+		{"auto when the payload fits", config.ChunkingAuto, 0, diff, est, false},
+		{"auto over the window", config.ChunkingAuto, 0, diff, est / 2, true},
+		{"always under the diff budget", config.ChunkingAlways, 0, diff, 0, false},
+		{"always over max diff size", config.ChunkingAlways, 4000, diff, 0, true},
+		{"always with a single chunk", config.ChunkingAlways, 4000, small, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			cfg.Prompts.ChangeAnalyzerChunking = tt.mode
+			cfg.Prompts.ChangeAnalyzerChunkBy = config.ChunkByDirectory
+			cfg.Prompts.ChangeAnalyzerMaxDiffSize = tt.maxDiff
+
+			chunks := PlanChunks(cfg, tt.diff, nil, tt.window)
+			if got := chunks != nil; got != tt.wantChunks {
+				t.Fatalf("PlanChunks chunked = %v (%d chunks), want %v", got, len(chunks), tt.wantChunks)
+			}
+			if chunks != nil && len(chunks) < 2 {
+				t.Fatalf("got %d chunks, a plan needs at least 2", len(chunks))
+			}
+		})
+	}
+}
+
+func TestChunkBudget(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	overhead := EstimateChangeAnalyzer(cfg, "", nil).TotalChars
+	fourCharsPerToken := ChangeAnalyzerEstimate{TotalChars: 8000, EstTokens: 2000}
+
+	tests := []struct {
+		name    string
+		maxDiff int
+		window  int
+		want    int
+	}{
+		{"no window and no cap", 0, 0, defaultChunkChars},
+		{"no window uses the cap", 5000, 0, 5000},
+		{"tiny cap is floored", 100, 0, minChunkChars},
+		{"cap below the window fit", 5000, 100000, 5000},
+		{"window fit below the cap", 1 << 30, 100000, (100000-chunkReserveTokens)*4 - overhead},
+		{"window fit without a cap", 0, 100000, (100000-chunkReserveTokens)*4 - overhead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			c.Prompts.ChangeAnalyzerMaxDiffSize = tt.maxDiff
+			if got := chunkBudget(c, nil, fourCharsPerToken, tt.window); got != tt.want {
+				t.Fatalf("chunkBudget = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkDiffGroupings(t *testing.T) {
+	diff := diffBlockText("internal/git/a.go", 10) +
+		diffBlockText("internal/git/b.go", 10) +
+		diffBlockText("README.md", 10)
+	block := len(diffBlockText("internal/git/a.go", 10))
+
+	tests := []struct {
+		name   string
+		by     string
+		budget int
+		want   []string
+	}{
+		{
+			"directory packs a directory together", config.ChunkByDirectory, block * 2,
+			[]string{"internal/git", "."},
+		},
+		{
+			"directory splits a directory over budget", config.ChunkByDirectory, block,
+			[]string{"internal/git", "internal/git", "."},
+		},
+		{
+			"file packs files up to the budget", config.ChunkByFile, block * 2,
+			[]string{"internal/git/a.go, internal/git/b.go", "README.md"},
+		},
+		{
+			"file at one file per chunk", config.ChunkByFile, block,
+			[]string{"internal/git/a.go", "internal/git/b.go", "README.md"},
+		},
+		{
+			"everything fits one chunk", config.ChunkByDirectory, block * 10,
+			[]string{"internal/git, ."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var labels []string
+			for _, c := range ChunkDiff(diff, tt.by, tt.budget) {
+				labels = append(labels, c.Label)
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Fatalf("labels = %q, want %q", labels, tt.want)
+			}
+		})
+	}
+}
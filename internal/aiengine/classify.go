@@ -0,0 +1,255 @@
+package aiengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/commit"
+	"commit_craft_reborn/internal/config"
+)
+
+// TagSuggestion is a proposed commit type. Confidence runs from 0 to 1;
+// Source is "heuristic" when only the file rules decided and "ai" when
+// the tag classifier stage answered. Warning says why a configured
+// classifier call was not used.
+type TagSuggestion struct {
+	Tag        string         `json:"tag"`
+	Confidence float64        `json:"confidence"`
+	Reason     string         `json:"reason"`
+	Source     string         `json:"source"`
+	Model      string         `json:"model,omitempty"`
+	Warning    string         `json:"warning,omitempty"`
+	Stats      *api.CallStats `json:"-"`
+}
+
+const (
+	TagSourceHeuristic = "heuristic"
+	TagSourceAI        = "ai"
+)
+
+// Percent is Confidence rounded to a whole percentage, for display.
+func (s TagSuggestion) Percent() int {
+	return int(s.Confidence*100 + 0.5)
+}
+
+// tagClassifierMaxDiff caps the diff sent to the classifier; the file
+// list and key points carry most of the signal and the default model
+// is a small one.
+const tagClassifierMaxDiff = 12000
+
+// tagRule is one file-level classification rule: when every file
+// matches, the first of tags present in the configured types wins with
+// the rule's confidence.
+type tagRule struct {
+	match      func(SplitFile) bool
+	tags       []string
+	confidence float64
+	reason     string
+}
+
+var tagRules = []tagRule{
+	{
+		match:      func(f SplitFile) bool { return splitClusterKey(f.Path) == "docs" },
+		tags:       []string{"DOC", "DOCS"},
+		confidence: 0.9,
+		reason:     "only documentation changed",
+	},
+	{
+		match:      func(f SplitFile) bool { return isTestPath(f.Path) },
+		tags:       []string{"TEST", "TESTS"},
+		confidence: 0.9,
+		reason:     "only tests changed",
+	},
+	{
+		match:      func(f SplitFile) bool { return f.Status == "D" },
+		tags:       []string{"REM", "REMOVE"},
+		confidence: 0.85,
+		reason:     "every staged file is deleted",
+	},
+	{
+		match:      func(f SplitFile) bool { return splitClusterKey(f.Path) == "ci" },
+		tags:       []string{"CI"},
+		confidence: 0.85,
+		reason:     "only CI configuration changed",
+	},
+	{
+		match:      func(f SplitFile) bool { return splitClusterKey(f.Path) == "deps" },
+		tags:       []string{"BUILD", "CHORE"},
+		confidence: 0.7,
+		reason:     "only dependency manifests changed",
+	},
+	{
+		match:      func(f SplitFile) bool { return f.Status == "A" },
+		tags:       []string{"ADD", "FEAT"},
+		confidence: 0.6,
+		reason:     "every staged file is new",
+	},
+}
+
+// HeuristicTag classifies files with the file-level rules. Mixed
+// changes no rule covers get the generic improvement tag at low
+// confidence. An empty file list gives an empty suggestion.
+func HeuristicTag(files []SplitFile, types []commit.CommitType) TagSuggestion {
+	if len(files) == 0 {
+		return TagSuggestion{}
+	}
+	for _, r := range tagRules {
+		tag, ok := findTag(types, r.tags...)
+		if !ok {
+			continue
+		}
+		all := true
+		for _, f := range files {
+			if !r.match(f) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return TagSuggestion{Tag: tag, Confidence: r.confidence, Reason: r.reason, Source: TagSourceHeuristic}
+		}
+	}
+	return TagSuggestion{
+		Tag:        pickSplitTag(types, "IMP", "REF", "FEAT", "CHORE"),
+		Confidence: 0.3,
+		Reason:     "mixed changes; no file rule applies",
+		Source:     TagSourceHeuristic,
+	}
+}
+
+// tagClassifierOutput mirrors the JSON contract documented in
+// prompts/tag_classifier.prompt.tmpl.
+type tagClassifierOutput struct {
+	Tag        string  `json:"tag"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+// ClassifyTag proposes the commit type of the staged change. The file
+// rules always run; when [tag_inference].use_model is on, the tag
+// classifier stage gets the rules' answer, the key points and the diff
+// and its answer wins (keeping the higher confidence when both agree).
+// Best-effort like PlanSplit: a failed call or unusable answer keeps
+// the heuristic suggestion with a Warning, and only a cancelled ctx is
+// returned as an error.
+func ClassifyTag(
+	ctx context.Context,
+	deps Deps,
+	files []SplitFile,
+	diff string,
+	keyPoints []string,
+	types []commit.CommitType,
+) (TagSuggestion, error) {
+	if len(files) == 0 {
+		return TagSuggestion{}, errors.New("no staged changes to classify")
+	}
+	rules := HeuristicTag(files, types)
+	pc := deps.Cfg.Prompts
+	if !deps.Cfg.TagInference.UseModel {
+		return rules, nil
+	}
+	if strings.TrimSpace(pc.TagClassifierPrompt) == "" {
+		rules.Warning = "tag classifier prompt is empty; using the file rules"
+		return rules, nil
+	}
+	response, stats, err := SendIaMessage(
+		ctx,
+		deps,
+		config.StageTagClassifier,
+		pc.TagClassifierPrompt,
+		tagClassifierInput(files, diff, keyPoints, types, rules),
+		pc.TagClassifierPromptModel,
+	)
+	rules.Stats = stats
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return rules, ctxErr
+		}
+		if deps.Log != nil {
+			deps.Log.Warn("Tag classifier call failed, using file rules", "error", err)
+		}
+		rules.Warning = fmt.Sprintf("tag classifier failed (%v); using the file rules", err)
+		return rules, nil
+	}
+	parsed, err := parseTagClassifierJSON(response, types)
+	if err != nil {
+		if deps.Log != nil {
+			deps.Log.Warn("Tag classifier JSON parse failed, using file rules", "error", err)
+		}
+		rules.Warning = fmt.Sprintf("tag classifier answer unusable (%v); using the file rules", err)
+		return rules, nil
+	}
+	out := TagSuggestion{
+		Tag:        parsed.Tag,
+		Confidence: parsed.Confidence,
+		Reason:     parsed.Reason,
+		Source:     TagSourceAI,
+		Model:      pc.TagClassifierPromptModel,
+		Stats:      stats,
+	}
+	if out.Tag == rules.Tag {
+		out.Confidence = max(out.Confidence, rules.Confidence)
+	}
+	return out, nil
+}
+
+func tagClassifierInput(
+	files []SplitFile,
+	diff string,
+	keyPoints []string,
+	types []commit.CommitType,
+	rules TagSuggestion,
+) string {
+	var b strings.Builder
+	b.WriteString("ALLOWED_TAGS:\n")
+	for _, t := range types {
+		fmt.Fprintf(&b, "%s: %s\n", t.Tag, t.Description)
+	}
+	b.WriteString("KEY_POINTS:\n")
+	for _, kp := range keyPoints {
+		b.WriteString(kp + "\n")
+	}
+	b.WriteString("FILES:\n")
+	for _, f := range files {
+		if f.Adds < 0 {
+			fmt.Fprintf(&b, "%s %s (binary)\n", f.Status, f.Path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s (+%d -%d)\n", f.Status, f.Path, f.Adds, f.Dels)
+	}
+	fmt.Fprintf(&b, "RULES: %s (%.2f, %s)\n", rules.Tag, rules.Confidence, rules.Reason)
+	b.WriteString("DIFF:\n")
+	if len(diff) > tagClassifierMaxDiff {
+		diff = diff[:tagClassifierMaxDiff] + "\n… (diff truncated)"
+	}
+	b.WriteString(diff)
+	return b.String()
+}
+
+// parseTagClassifierJSON extracts the classifier's JSON payload,
+// tolerating prose or a code fence around it, and normalises the tag to
+// the configured spelling and the confidence to [0, 1].
+func parseTagClassifierJSON(raw string, types []commit.CommitType) (tagClassifierOutput, error) {
+	var out tagClassifierOutput
+	trimmed := strings.TrimSpace(raw)
+	if i := strings.Index(trimmed, "{"); i >= 0 {
+		if j := strings.LastIndex(trimmed, "}"); j > i {
+			trimmed = trimmed[i : j+1]
+		}
+	}
+	if err := json.Unmarshal([]byte(trimmed), &out); err != nil {
+		return out, err
+	}
+	tag, ok := findTag(types, strings.TrimSpace(out.Tag))
+	if !ok {
+		return out, fmt.Errorf("unknown tag %q", out.Tag)
+	}
+	out.Tag = tag
+	out.Confidence = min(max(out.Confidence, 0), 1)
+	out.Reason = strings.TrimSuffix(strings.TrimSpace(out.Reason), ".")
+	return out, nil
+}
@@ -0,0 +1,182 @@
+package aiengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/commit"
+	"commit_craft_reborn/internal/config"
+)
+
+func TestHeuristicTag(t *testing.T) {
+	types := commit.GetDefaultCommitTypes()
+	cases := []struct {
+		name  string
+		files []SplitFile
+		tag   string
+		conf  float64
+	}{
+		{"docs", []SplitFile{{Path: "README.md", Status: "M"}, {Path: "docs/usage.md", Status: "A"}}, "DOC", 0.9},
+		{"tests", []SplitFile{{Path: "internal/git/git_test.go", Status: "M"}}, "TEST", 0.9},
+		{"deleted", []SplitFile{{Path: "internal/old/old.go", Status: "D"}, {Path: "internal/old/util.go", Status: "D"}}, "REM", 0.85},
+		{"ci", []SplitFile{{Path: ".github/workflows/go.yml", Status: "M"}}, "CI", 0.85},
+		{"deps", []SplitFile{{Path: "go.mod", Status: "M"}, {Path: "go.sum", Status: "M"}}, "BUILD", 0.7},
+		{"added", []SplitFile{{Path: "internal/scope/infer.go", Status: "A"}}, "ADD", 0.6},
+		{"mixed", []SplitFile{{Path: "README.md", Status: "M"}, {Path: "main.go", Status: "M"}}, "IMP", 0.3},
+		{"new docs prefer doc over add", []SplitFile{{Path: "docs/new.md", Status: "A"}}, "DOC", 0.9},
+		{"deleted tests prefer test over rem", []SplitFile{{Path: "x_test.go", Status: "D"}}, "TEST", 0.9},
+		{"added and deleted", []SplitFile{{Path: "a.go", Status: "A"}, {Path: "b.go", Status: "D"}}, "IMP", 0.3},
+	}
+	for _, c := range cases {
+		got := HeuristicTag(c.files, types)
+		if got.Tag != c.tag || got.Confidence != c.conf || got.Source != TagSourceHeuristic {
+			t.Errorf("%s: got %s %.2f (%s), want %s %.2f", c.name, got.Tag, got.Confidence, got.Source, c.tag, c.conf)
+		}
+	}
+}
+
+func TestHeuristicTagSkipsUnconfiguredTags(t *testing.T) {
+	types := []commit.CommitType{{Tag: "feat"}, {Tag: "docs"}, {Tag: "chore"}}
+	got := HeuristicTag([]SplitFile{{Path: "README.md", Status: "M"}}, types)
+	if got.Tag != "docs" {
+		t.Errorf("docs-only change = %q, want the configured spelling %q", got.Tag, "docs")
+	}
+	got = HeuristicTag([]SplitFile{{Path: "a_test.go", Status: "M"}}, types)
+	if got.Tag != "feat" || got.Confidence != 0.3 {
+		t.Errorf("tests without a TEST tag = %s %.2f, want the fallback feat 0.30", got.Tag, got.Confidence)
+	}
+	if got := HeuristicTag(nil, types); got.Tag != "" {
+		t.Errorf("no files = %q, want an empty suggestion", got.Tag)
+	}
+}
+
+func TestParseTagClassifierJSON(t *testing.T) {
+	types := commit.GetDefaultCommitTypes()
+	got, err := parseTagClassifierJSON("Sure:\n```json\n{\"tag\": \"fix\", \"confidence\": 1.4, \"reason\": \"Guards empty input.\"}\n```", types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Tag != "FIX" || got.Confidence != 1 || got.Reason != "Guards empty input" {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := parseTagClassifierJSON(`{"tag": "NOPE", "confidence": 0.9}`, types); err == nil {
+		t.Error("unknown tag accepted")
+	}
+	if _, err := parseTagClassifierJSON("no json here", types); err == nil {
+		t.Error("prose without JSON accepted")
+	}
+}
+
+// classifierServer answers every chat completion with reply, or with
+// a 400 when reply is empty.
+func classifierServer(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reply == "" {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		body := api.ResponseBody{Model: "tiny", Choices: []api.Choice{{Message: api.Message{Role: "assistant", Content: reply}}}}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClassifyTag(t *testing.T) {
+	types := commit.GetDefaultCommitTypes()
+	docs := []SplitFile{{Path: "README.md", Status: "M"}}
+	cases := []struct {
+		name     string
+		useModel bool
+		prompt   string
+		reply    string
+		tag      string
+		conf     float64
+		source   string
+		warning  string
+	}{
+		{"model off", false, "classify", `{"tag":"FIX","confidence":0.8}`, "DOC", 0.9, TagSourceHeuristic, ""},
+		{"empty prompt", true, "  ", `{"tag":"FIX","confidence":0.8}`, "DOC", 0.9, TagSourceHeuristic, "prompt is empty"},
+		{"ai agrees keeps higher", true, "classify", `{"tag":"doc","confidence":0.6,"reason":"readme"}`, "DOC", 0.9, TagSourceAI, ""},
+		{"ai agrees with more", true, "classify", `{"tag":"DOC","confidence":0.95}`, "DOC", 0.95, TagSourceAI, ""},
+		{"ai disagrees wins", true, "classify", `{"tag":"FIX","confidence":0.4}`, "FIX", 0.4, TagSourceAI, ""},
+		{"unknown tag", true, "classify", `{"tag":"NOPE","confidence":0.9}`, "DOC", 0.9, TagSourceHeuristic, "answer unusable"},
+		{"call fails", true, "classify", "", "DOC", 0.9, TagSourceHeuristic, "classifier failed"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := classifierServer(t, c.reply)
+			cfg := config.Config{}
+			cfg.TagInference.UseModel = c.useModel
+			cfg.Retry.MaxAttempts = 1
+			cfg.Prompts.TagClassifierPrompt = c.prompt
+			cfg.Prompts.TagClassifierPromptModel = "tiny"
+			cfg.Prompts.TagClassifierPromptProvider = api.ProviderOpenAI
+			cfg.Providers.OpenAI = config.ProviderEndpoint{BaseURL: srv.URL, APIKey: "k"}
+
+			got, err := ClassifyTag(context.Background(), Deps{Cfg: cfg}, docs, "diff", nil, types)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Tag != c.tag || got.Confidence != c.conf || got.Source != c.source {
+				t.Errorf("got %s %.2f (%s), want %s %.2f (%s)", got.Tag, got.Confidence, got.Source, c.tag, c.conf, c.source)
+			}
+			if (c.warning == "") != (got.Warning == "") || !strings.Contains(got.Warning, c.warning) {
+				t.Errorf("warning = %q, want it to mention %q", got.Warning, c.warning)
+			}
+			if c.source == TagSourceAI && got.Model != "tiny" {
+				t.Errorf("model = %q, want the classifier model", got.Model)
+			}
+		})
+	}
+}
+
+func TestClassifyTagNoFiles(t *testing.T) {
+	if _, err := ClassifyTag(context.Background(), Deps{}, nil, "", nil, commit.GetDefaultCommitTypes()); err == nil {
+		t.Error("empty file list accepted")
+	}
+}
+
+func TestClassifyTagCancelled(t *testing.T) {
+	srv := classifierServer(t, `{"tag":"FIX","confidence":0.8}`)
+	cfg := config.Config{}
+	cfg.TagInference.UseModel = true
+	cfg.Prompts.TagClassifierPrompt = "classify"
+	cfg.Prompts.TagClassifierPromptProvider = api.ProviderOpenAI
+	cfg.Providers.OpenAI = config.ProviderEndpoint{BaseURL: srv.URL, APIKey: "k"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := ClassifyTag(ctx, Deps{Cfg: cfg}, []SplitFile{{Path: "a_test.go", Status: "M"}}, "", nil, commit.GetDefaultCommitTypes())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if got.Tag != "TEST" {
+		t.Errorf("cancelled call = %q, want the rules' suggestion", got.Tag)
+	}
+}
+
+func TestTagSuggestionPercent(t *testing.T) {
+	cases := []struct {
+		conf float64
+		want int
+	}{
+		{0, 0},
+		{0.3, 30},
+		{0.855, 86},
+		{0.004, 0},
+		{0.995, 100},
+		{1, 100},
+	}
+	for _, c := range cases {
+		if got := (TagSuggestion{Confidence: c.conf}).Percent(); got != c.want {
+			t.Errorf("Percent(%v) = %d, want %d", c.conf, got, c.want)
+		}
+	}
+}
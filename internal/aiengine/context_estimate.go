@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/tokenizer"
 )
 
 // ChangeAnalyzerEstimate is the pre-flight payload measurement for stage 1
@@ -12,14 +13,24 @@ import (
 // the DB so the CLI and (future) TUI indicator can probe context-window
 // usage cheaply before triggering CallChangeAnalyzer.
 //
-// EstTokens uses the standard chars/4 heuristic. It is intentionally an
-// approximation — typically within ~15% of the real Groq tokenizer for
-// English + code, more than enough to detect imminent context overflow.
+// EstTokens is counted with the change analyzer model's BPE vocabulary
+// when one is installed (see TokenizerFor) and with the chars/4 heuristic
+// otherwise; Tokenizer names whichever was used. Chat-template tokens are
+// not included, so the count is a few tokens short of what the provider
+// bills.
 type ChangeAnalyzerEstimate struct {
 	SystemPromptChars int
 	UserInputChars    int
 	TotalChars        int
 	EstTokens         int
+	Tokenizer         string
+}
+
+// TokenizerFor returns the tokenizer for modelID under cfg's [tokenizer]
+// settings, falling back to the heuristic when no vocabulary is
+// available. Vocabularies are loaded once per process.
+func TokenizerFor(cfg config.Config, modelID string) tokenizer.Tokenizer {
+	return tokenizer.For(cfg.Tokenizer.ResolvedDir, cfg.Tokenizer.Models, modelID)
 }
 
 // EstimateChangeAnalyzer reproduces the exact payload that CallChangeAnalyzer
@@ -40,15 +51,15 @@ func EstimateChangeAnalyzer(
 		gitChanges,
 	)
 
+	tok := TokenizerFor(cfg, cfg.Prompts.ChangeAnalyzerPromptModel)
 	sysChars := len(systemPrompt)
 	usrChars := len(userInput)
-	total := sysChars + usrChars
-	estTokens := (total + 3) / 4 // ceil(total/4)
 
 	return ChangeAnalyzerEstimate{
 		SystemPromptChars: sysChars,
 		UserInputChars:    usrChars,
-		TotalChars:        total,
-		EstTokens:         estTokens,
+		TotalChars:        sysChars + usrChars,
+		EstTokens:         tok.Count(systemPrompt) + tok.Count(userInput),
+		Tokenizer:         tok.Name(),
 	}
 }
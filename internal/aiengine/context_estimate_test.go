@@ -0,0 +1,77 @@
+package aiengine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/tokenizer"
+)
+
+// writeTiktoken writes a byte-level vocabulary with the given merges
+// (ranked after the 256 single bytes) as a tiktoken rank file.
+func writeTiktoken(t *testing.T, path string, merges ...string) {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEstimateChangeAnalyzerUsesModelVocabulary(t *testing.T) {
+	dir := t.TempDir()
+	writeTiktoken(t, filepath.Join(dir, "llama3.tiktoken"),
+		"ll", "he", "hell", "hello", " w", "or", " wor", "ld", " world")
+
+	cfg := config.NewDefaultConfig()
+	cfg.Tokenizer.ResolvedDir = dir
+	cfg.Prompts.ChangeAnalyzerPromptModel = "llama-3.3-70b-versatile"
+	cfg.Prompts.ChangeAnalyzerPrompt = "hello world"
+
+	tok := TokenizerFor(cfg, cfg.Prompts.ChangeAnalyzerPromptModel)
+	if tok.Name() != "bpe:llama3" {
+		t.Fatalf("tokenizer = %q, want bpe:llama3", tok.Name())
+	}
+	// "hello" | " world" | "\n\n" | " " | " hello": the newline run
+	// stops at its last newline and the trailing space of a whitespace
+	// run is left to prefix the next word.
+	if got := tok.Count("hello world\n\n  hello"); got != 7 {
+		t.Errorf("Count = %d, want 7", got)
+	}
+
+	est := EstimateChangeAnalyzer(cfg, "", nil)
+	if est.Tokenizer != "bpe:llama3" {
+		t.Errorf("estimate tokenizer = %q", est.Tokenizer)
+	}
+	userInput := "DEVELOPER_POINTS:\n\nGIT_CHANGES:\n"
+	if want := 2 + tok.Count(userInput); est.EstTokens != want {
+		t.Errorf("EstTokens = %d, want %d", est.EstTokens, want)
+	}
+}
+
+func TestEstimateChangeAnalyzerFallsBackToHeuristic(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Tokenizer.ResolvedDir = t.TempDir()
+	cfg.Prompts.ChangeAnalyzerPromptModel = "mistral-saba-24b"
+	cfg.Prompts.ChangeAnalyzerPrompt = strings.Repeat("x", 10)
+
+	est := EstimateChangeAnalyzer(cfg, "+added line", []string{"note"})
+	if est.Tokenizer != tokenizer.HeuristicName {
+		t.Fatalf("tokenizer = %q, want heuristic", est.Tokenizer)
+	}
+	userInput := "DEVELOPER_POINTS:\nnote\nGIT_CHANGES:\n+added line"
+	want := (10+3)/4 + (len(userInput)+3)/4
+	if est.EstTokens != want || est.TotalChars != 10+len(userInput) {
+		t.Errorf("estimate = %+v, want %d tokens", est, want)
+	}
+}
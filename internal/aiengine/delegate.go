@@ -1,6 +1,7 @@
 package aiengine
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -92,13 +93,30 @@ const (
 // BuildCommitBundle assembles the delegate bundle for the commit pipeline.
 // strategy is "single" (unified prompt) or "staged" (per-stage prompts);
 // action is "generate" or "regenerate"; id is the draft id for regenerate
-// (0 for a fresh generate). No Groq call.
-func BuildCommitBundle(deps Deps, in Input, strategy, action string, id int) DelegateBundle {
+// (0 for a fresh generate). No Groq call; ctx only bounds the local
+// CHANGELOG detection, and a done ctx yields ctx.Err() instead of a
+// bundle.
+func BuildCommitBundle(
+	ctx context.Context,
+	deps Deps,
+	in Input,
+	strategy, action string,
+	id int,
+) (DelegateBundle, error) {
+	if err := ctx.Err(); err != nil {
+		return DelegateBundle{}, err
+	}
 	strategy = config.NormalizeAgentStrategy(strategy)
 	pc := deps.Cfg.Prompts
 	developerPoints := stripMentions(strings.Join(in.KeyPoints, "\n"))
+	if len(deps.FewShotPaths) == 0 {
+		deps.FewShotPaths = DiffPaths(in.Diff)
+	}
 
 	clog := changelogContext(deps, in.ChangelogActive)
+	if err := ctx.Err(); err != nil {
+		return DelegateBundle{}, err
+	}
 
 	b := DelegateBundle{
 		Mode:     config.AgentModeDelegate,
@@ -117,8 +135,8 @@ func BuildCommitBundle(deps Deps, in Input, strategy, action string, id int) Del
 	}
 
 	if strategy == config.AgentStrategyStaged {
-		b.Stages = commitStages(pc, deps.Cfg.Changelog, in, developerPoints, clog)
-		return b
+		b.Stages = commitStages(deps, in, developerPoints, clog)
+		return b, nil
 	}
 
 	user := fmt.Sprintf(
@@ -129,23 +147,24 @@ func BuildCommitBundle(deps Deps, in Input, strategy, action string, id int) Del
 		user += "\nCHANGELOG_CONTEXT:\n" + clog
 	}
 	b.Unified = &DelegateStage{
-		Stage:  "commit",
-		System: pc.AgentCommitPrompt,
-		User:   user,
+		Stage: "commit",
+		System: pc.AgentCommitPrompt +
+			profileGuidance(deps.Cfg.CommitFormat, StageBody) +
+			profileGuidance(deps.Cfg.CommitFormat, StageTitle) +
+			styleGuidance(deps, StageBody, StageTitle) +
+			// Title-stage examples carry both the body and the title.
+			fewShotGuidance(deps, StageTitle, in.Type, in.Scope),
+		User: user,
 	}
-	return b
+	return b, nil
 }
 
 // commitStages fills the original per-stage prompts. Stage inputs that depend
 // on the agent's own upstream output carry an explicit placeholder note rather
 // than a real value, since those values don't exist until the agent produces
 // them.
-func commitStages(
-	pc config.PromptsConfig,
-	clogCfg config.ChangelogConfig,
-	in Input,
-	developerPoints, clog string,
-) []DelegateStage {
+func commitStages(deps Deps, in Input, developerPoints, clog string) []DelegateStage {
+	pc, cf := deps.Cfg.Prompts, deps.Cfg.CommitFormat
 	stages := []DelegateStage{
 		{
 			Stage:  "summary",
@@ -156,16 +175,20 @@ func commitStages(
 			),
 		},
 		{
-			Stage:  "body",
-			System: pc.CommitBodyGeneratorPrompt,
+			Stage: "body",
+			System: pc.CommitBodyGeneratorPrompt + profileGuidance(cf, StageBody) +
+				styleGuidance(deps, StageBody) +
+				fewShotGuidance(deps, StageBody, in.Type, in.Scope),
 			User: fmt.Sprintf(
 				"TAG:\n%s\nMODULE:\n%s\nSUMMARY_PARAGRAPHS:\n<your stage-1 (summary) output>",
 				in.Type, in.Scope,
 			),
 		},
 		{
-			Stage:  "title",
-			System: pc.CommitTitleGeneratorPrompt,
+			Stage: "title",
+			System: pc.CommitTitleGeneratorPrompt + profileGuidance(cf, StageTitle) +
+				styleGuidance(deps, StageTitle) +
+				fewShotGuidance(deps, StageTitle, in.Type, in.Scope),
 			User: fmt.Sprintf(
 				"TAG:\n%s\nMODULE:\n%s\nCOMMIT_BODY:\n<your stage-2 (body) output>",
 				in.Type, in.Scope,
@@ -175,7 +198,7 @@ func commitStages(
 	if clog != "" {
 		stages = append(stages, DelegateStage{
 			Stage:  "changelog",
-			System: clogCfg.Prompt,
+			System: deps.Cfg.Changelog.Prompt,
 			User: fmt.Sprintf(
 				"%s\nSTAGE2_BODY:\n<your stage-2 (body) output>\nSTAGE3_TITLE:\n<your stage-3 (title) output>",
 				clog,
@@ -186,14 +209,19 @@ func commitStages(
 }
 
 // BuildReleaseBundle assembles the delegate bundle for the release pipeline
-// (merge/release). releaseType is "MERGE" or "RELEASE". No Groq call.
+// (merge/release). releaseType is "MERGE" or "RELEASE". No Groq call;
+// a done ctx yields ctx.Err() instead of a bundle.
 func BuildReleaseBundle(
+	ctx context.Context,
 	deps Deps,
 	in ReleaseInput,
 	releaseType, strategy, action string,
 	id int,
 	branch, version, commitList string,
-) DelegateBundle {
+) (DelegateBundle, error) {
+	if err := ctx.Err(); err != nil {
+		return DelegateBundle{}, err
+	}
 	strategy = config.NormalizeAgentStrategy(strategy)
 	pc := deps.Cfg.Prompts
 	commitsBlob := formatReleaseCommits(in.Commits)
@@ -231,7 +259,7 @@ func BuildReleaseBundle(
 				User:   "TITLE:\n<your stage-2 (title) output>\n\nBODY:\n<your stage-1 (body) output>",
 			},
 		}
-		return b
+		return b, nil
 	}
 
 	b.Unified = &DelegateStage{
@@ -244,7 +272,7 @@ func BuildReleaseBundle(
 			commitsBlob,
 		),
 	}
-	return b
+	return b, nil
 }
 
 func releaseScopeValue(branch, version string) string {
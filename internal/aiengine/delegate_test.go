@@ -1,6 +1,7 @@
 package aiengine
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -30,7 +31,12 @@ func sampleInput() Input {
 }
 
 func TestBuildCommitBundle_Single(t *testing.T) {
-	b := BuildCommitBundle(testDeps(), sampleInput(), config.AgentStrategySingle, "generate", 0)
+	b, err := BuildCommitBundle(
+		context.Background(), testDeps(), sampleInput(), config.AgentStrategySingle, "generate", 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if b.Mode != config.AgentModeDelegate {
 		t.Fatalf("mode = %q, want delegate", b.Mode)
@@ -64,7 +70,12 @@ func TestBuildCommitBundle_Single(t *testing.T) {
 }
 
 func TestBuildCommitBundle_Staged(t *testing.T) {
-	b := BuildCommitBundle(testDeps(), sampleInput(), config.AgentStrategyStaged, "generate", 0)
+	b, err := BuildCommitBundle(
+		context.Background(), testDeps(), sampleInput(), config.AgentStrategyStaged, "generate", 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if b.Unified != nil {
 		t.Fatal("staged strategy must not populate Unified")
@@ -88,7 +99,8 @@ func TestBuildCommitBundle_Staged(t *testing.T) {
 
 func TestBuildReleaseBundle(t *testing.T) {
 	in := ReleaseInput{Commits: []ReleaseCommit{{Subject: "init", Date: "2026-01-01"}}}
-	b := BuildReleaseBundle(
+	b, err := BuildReleaseBundle(
+		context.Background(),
 		testDeps(),
 		in,
 		"MERGE",
@@ -99,6 +111,9 @@ func TestBuildReleaseBundle(t *testing.T) {
 		"",
 		"abc init",
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if b.Kind != "release" || b.Action != "merge" {
 		t.Fatalf("kind/action = %q/%q", b.Kind, b.Action)
@@ -9,6 +9,7 @@
 package aiengine
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -35,6 +36,7 @@ const (
 // so callers can persist ai_calls rows or render the per-stage card.
 type StageStats struct {
 	ID               StageID
+	Provider         string
 	Model            string
 	HasStats         bool
 	PromptTokens     int
@@ -47,27 +49,59 @@ type StageStats struct {
 	RequestID        string
 	StatsModel       string
 	TPMLimitAtCall   int
+	// Attempts are the failed tries that preceded the successful call
+	// (retries and fallbacks), oldest first.
+	Attempts []api.CallAttempt
+	// Failed says no call served the stage on this run; Attempts then
+	// holds every try (see RecordFailedStage).
+	Failed bool
+	// Chunks are the map calls of a chunked Change Analyzer run (stage 1
+	// only, see RunChangeAnalyzer); the fields above then describe the
+	// reduce call that merged them.
+	Chunks []ChunkStats
 }
 
 // Input is the per-run user-supplied data: keypoints + tag + scope, the
 // staged diff (left empty to let Run read it via git.GetStagedDiffSummary),
 // and a flag controlling the optional changelog refiner stage.
+//
+// LiveDiff says a non-empty Diff was just read from the staged index of
+// Deps.Pwd (rather than loaded from a stored snapshot), so a chunked
+// Change Analyzer run may re-read the index in full. It is implied when
+// Diff is empty.
 type Input struct {
 	KeyPoints       []string
 	Type            string
 	Scope           string
 	Diff            string
+	LiveDiff        bool
 	ChangelogActive bool
 }
 
 // Deps groups the long-lived services the pipeline depends on. DB is
 // optional — only used to persist rate-limit snapshots; nil is OK and
 // rate-limit persistence becomes a no-op.
+//
+// OnChunk is optional too. When set, every stage call streams its
+// response and OnChunk receives each text delta tagged with the stage
+// that produced it; the stage's return value and stats are unchanged.
+// It runs on the goroutine executing the pipeline.
+//
+// OnRetry, also optional, is told about every failed attempt, the last
+// one included (its Wait is zero when nothing follows). Streaming
+// callers use it to discard the partial text of the failed attempt.
+//
+// FewShotPaths, optional, are the files of the change being written;
+// the body and title stages rank past commits by them for their few-shot
+// examples. Run fills it from the diff; empty means the staged files.
 type Deps struct {
-	Cfg config.Config
-	DB  *storage.DB
-	Log *logger.Logger
-	Pwd string
+	Cfg          config.Config
+	DB           *storage.DB
+	Log          *logger.Logger
+	Pwd          string
+	OnChunk      func(stage config.ModelStage, delta string)
+	OnRetry      func(stage config.ModelStage, attempt api.CallAttempt)
+	FewShotPaths []string
 }
 
 // Output bundles every text artifact the pipeline produced plus the
@@ -89,7 +123,9 @@ type Output struct {
 // Run executes stages 1–3 and, when in.ChangelogActive is true and the
 // project has a CHANGELOG, the refiner stage. Errors from stages 1–3
 // abort the run; the refiner is best-effort and logs warnings instead.
-func Run(deps Deps, in Input) (Output, error) {
+// Cancelling ctx aborts the stage in flight and Run returns ctx.Err()
+// (wrapped with the stage label), even when the refiner was running.
+func Run(ctx context.Context, deps Deps, in Input) (Output, error) {
 	out := Output{Stages: make([]StageStats, 4)}
 	for i := range out.Stages {
 		out.Stages[i].ID = StageID(i)
@@ -104,30 +140,38 @@ func Run(deps Deps, in Input) (Output, error) {
 		}
 	}
 	out.Diff = diff
+	if len(deps.FewShotPaths) == 0 {
+		deps.FewShotPaths = DiffPaths(diff)
+	}
 
-	summary, sumStats, err := CallChangeAnalyzer(deps, in.KeyPoints, diff)
+	summary, err := RunChangeAnalyzer(ctx, deps, in.KeyPoints, diff, in.Diff == "" || in.LiveDiff, &out)
 	if err != nil {
+		RecordFailedStage(&out, StageSummary, deps.Cfg.Prompts.ChangeAnalyzerPromptModel, err)
 		return out, fmt.Errorf("stage 1 (change analyzer): %w", err)
 	}
-	RecordStage(&out, StageSummary, deps.Cfg.Prompts.ChangeAnalyzerPromptModel, sumStats)
 	out.Summary = summary
 
-	body, bodyStats, err := CallCommitBody(deps, in.Type, in.Scope, summary)
+	body, bodyStats, err := CallCommitBody(ctx, deps, in.Type, in.Scope, summary)
 	if err != nil {
+		RecordFailedStage(&out, StageBody, deps.Cfg.Prompts.CommitBodyGeneratorPromptModel, err)
 		return out, fmt.Errorf("stage 2 (commit body): %w", err)
 	}
 	RecordStage(&out, StageBody, deps.Cfg.Prompts.CommitBodyGeneratorPromptModel, bodyStats)
 	out.Body = body
 
-	title, titleStats, err := CallCommitTitle(deps, in.Type, in.Scope, body)
+	title, titleStats, err := CallCommitTitle(ctx, deps, in.Type, in.Scope, body)
 	if err != nil {
+		RecordFailedStage(&out, StageTitle, deps.Cfg.Prompts.CommitTitleGeneratorPromptModel, err)
 		return out, fmt.Errorf("stage 3 (commit title): %w", err)
 	}
 	RecordStage(&out, StageTitle, deps.Cfg.Prompts.CommitTitleGeneratorPromptModel, titleStats)
 	out.Title = title
 
 	if in.ChangelogActive && deps.Cfg.Changelog.Enabled {
-		RunChangelogRefiner(deps, &out)
+		RunChangelogRefiner(ctx, deps, &out)
+		if err := ctx.Err(); err != nil {
+			return out, fmt.Errorf("stage 4 (changelog refiner): %w", err)
+		}
 	}
 
 	out.FinalMessage = ComposeFinalMessage(out.Title, out.Body, out.ChangelogMentionLine)
@@ -145,12 +189,36 @@ func RecordStage(out *Output, id StageID, modelName string, stats *api.CallStats
 	if int(id) < 0 || int(id) >= len(out.Stages) {
 		return
 	}
-	st := &out.Stages[id]
-	st.ID = id
-	st.Model = modelName
-	if stats == nil {
+	out.Stages[id] = newStageStats(id, modelName, stats)
+}
+
+// RecordFailedStage keeps the attempts of a stage no call served on
+// out.Stages[id] (HasStats stays false), so callers persist and show
+// what was tried. A no-op when err carries no attempts.
+func RecordFailedStage(out *Output, id StageID, modelName string, err error) {
+	provider, attempts := FailedAttempts(err)
+	if len(attempts) == 0 || int(id) < 0 || int(id) >= len(out.Stages) {
 		return
 	}
+	// Replace whatever an earlier run left in the slot; only the chunk
+	// calls the failed stage made on this run are kept.
+	out.Stages[id] = StageStats{
+		ID:       id,
+		Model:    modelName,
+		Provider: provider,
+		Attempts: attempts,
+		Failed:   true,
+		Chunks:   out.Stages[id].Chunks,
+	}
+}
+
+// newStageStats is the value RecordStage stores: stats projected into a
+// StageStats for stage id, stamped with modelName.
+func newStageStats(id StageID, modelName string, stats *api.CallStats) StageStats {
+	st := StageStats{ID: id, Model: modelName}
+	if stats == nil {
+		return st
+	}
 	st.HasStats = true
 	st.PromptTokens = stats.PromptTokens
 	st.CompletionTokens = stats.CompletionTokens
@@ -161,44 +229,53 @@ func RecordStage(out *Output, id StageID, modelName string, stats *api.CallStats
 	st.APITotalTime = stats.TotalTime
 	st.RequestID = stats.RequestID
 	st.StatsModel = stats.Model
+	st.Provider = stats.Provider
 	st.TPMLimitAtCall = stats.RateLimits.LimitTokens
+	st.Attempts = stats.Attempts
+	return st
 }
 
 // SendIaMessage is the package-level analogue of the TUI's
-// createAndSendIaMessage: identical Groq call, identical rate-limit
-// recording. DB persistence of rate-limits is best-effort and silent
-// when deps.DB is nil (headless callers without a DB still work).
+// createAndSendIaMessage: one chat completion against the provider
+// configured for stage (streamed when deps.OnChunk is set), with
+// identical rate-limit recording. Transient failures are retried and
+// fallback models tried per deps.Cfg.Retry (see callWithRetry). DB
+// persistence of rate-limits is best-effort and silent when deps.DB is
+// nil (headless callers without a DB still work).
 func SendIaMessage(
+	ctx context.Context,
 	deps Deps,
+	stage config.ModelStage,
 	systemPrompt, userInput, iaModel string,
 ) (string, *api.CallStats, error) {
-	if iaModel == "" {
+	provider, apiKey, err := resolveProvider(deps, stage)
+	if err != nil {
+		return "", nil, err
+	}
+	if iaModel == "" && provider.Name() == api.ProviderGroq {
 		iaModel = "llama-3.1-8b-instant"
 	}
-	apiKey := deps.Cfg.TUI.GroqAPIKey
 	messages := []api.Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userInput},
 	}
-	response, stats, err := api.GetGroqChatCompletion(apiKey, iaModel, messages)
+	targets := stageTargets(deps, stage, provider.Name(), iaModel, apiKey)
+	response, stats, err := callWithRetry(ctx, deps, stage, provider, targets, messages)
 	if err != nil {
 		return "", stats, fmt.Errorf(
-			"call failed (model=%s): %w", iaModel, err,
+			"call failed (provider=%s, model=%s): %w", provider.Name(), iaModel, err,
 		)
 	}
-	if stats != nil {
-		api.RecordRateLimits(iaModel, stats.RateLimits)
-		persistRateLimits(deps, iaModel, stats.RateLimits)
-	}
 	return response, stats, nil
 }
 
-func persistRateLimits(deps Deps, modelID string, rl api.RateLimits) {
+func persistRateLimits(deps Deps, provider, modelID string, rl api.RateLimits) {
 	if deps.DB == nil || modelID == "" {
 		return
 	}
 	row := storage.ModelRateLimits{
 		ModelID:           modelID,
+		Provider:          provider,
 		LimitRequests:     rl.LimitRequests,
 		RemainingRequests: rl.RemainingRequests,
 		ResetRequestsMs:   int(rl.ResetRequests / time.Millisecond),
@@ -219,6 +296,7 @@ func persistRateLimits(deps Deps, modelID string, rl api.RateLimits) {
 // CallChangeAnalyzer runs stage 1: feeds keypoints + staged diff to the
 // change-analyzer prompt and returns the summary text + per-call stats.
 func CallChangeAnalyzer(
+	ctx context.Context,
 	deps Deps,
 	keyPoints []string,
 	gitChanges string,
@@ -230,7 +308,9 @@ func CallChangeAnalyzer(
 			"developerPoints", developerPoints, "gitChanges", gitChanges)
 	}
 	result, stats, err := SendIaMessage(
+		ctx,
 		deps,
+		config.StageChangeAnalyzer,
 		pc.ChangeAnalyzerPrompt,
 		fmt.Sprintf("DEVELOPER_POINTS:\n%s\nGIT_CHANGES:\n%s", developerPoints, gitChanges),
 		pc.ChangeAnalyzerPromptModel,
@@ -247,13 +327,17 @@ func CallChangeAnalyzer(
 // CallCommitBody runs stage 2: feeds tag/scope/summary to the
 // commit-body-generator prompt and returns the body text + stats.
 func CallCommitBody(
+	ctx context.Context,
 	deps Deps,
 	commitType, commitScope, summaryParagraphs string,
 ) (string, *api.CallStats, error) {
 	pc := deps.Cfg.Prompts
 	result, stats, err := SendIaMessage(
+		ctx,
 		deps,
-		pc.CommitBodyGeneratorPrompt,
+		config.StageCommitBody,
+		pc.CommitBodyGeneratorPrompt+profileGuidance(deps.Cfg.CommitFormat, StageBody)+
+			styleGuidance(deps, StageBody)+fewShotGuidance(deps, StageBody, commitType, commitScope),
 		fmt.Sprintf("TAG:\n%s\nMODULE:\n%s\nSUMMARY_PARAGRAPHS:\n%s",
 			commitType, commitScope, summaryParagraphs),
 		pc.CommitBodyGeneratorPromptModel,
@@ -270,13 +354,17 @@ func CallCommitBody(
 // CallCommitTitle runs stage 3: feeds tag/scope/body to the
 // commit-title-generator prompt and returns the trimmed title + stats.
 func CallCommitTitle(
+	ctx context.Context,
 	deps Deps,
 	commitType, commitScope, commitBody string,
 ) (string, *api.CallStats, error) {
 	pc := deps.Cfg.Prompts
 	result, stats, err := SendIaMessage(
+		ctx,
 		deps,
-		pc.CommitTitleGeneratorPrompt,
+		config.StageCommitTitle,
+		pc.CommitTitleGeneratorPrompt+profileGuidance(deps.Cfg.CommitFormat, StageTitle)+
+			styleGuidance(deps, StageTitle)+fewShotGuidance(deps, StageTitle, commitType, commitScope),
 		fmt.Sprintf("TAG:\n%s\nMODULE:\n%s\nCOMMIT_BODY:\n%s",
 			commitType, commitScope, commitBody),
 		pc.CommitTitleGeneratorPromptModel,
@@ -290,6 +378,29 @@ func CallCommitTitle(
 	return strings.TrimSpace(result), stats, nil
 }
 
+// profileGuidance is appended to the body and title system prompts when
+// the conventional profile is active, so the model writes footers the
+// way Conventional Commits tooling parses them and leaves the
+// `type(scope): ` prefix to FinalMessage. Empty for the bracket profile,
+// keeping the configured prompts byte-identical.
+func profileGuidance(cf config.CommitFormatConfig, stage StageID) string {
+	if !cf.MessageFormat().IsConventional() {
+		return ""
+	}
+	switch stage {
+	case StageBody:
+		return "\n\nMESSAGE FORMAT: Conventional Commits. If the change breaks " +
+			"backwards compatibility, end the body with a separate footer paragraph " +
+			"`BREAKING CHANGE: <what breaks and how to migrate>` (upper-case token). " +
+			"Write any other footer as `Token: value`, one per line."
+	case StageTitle:
+		return "\n\nMESSAGE FORMAT: Conventional Commits. The `type(scope): ` prefix " +
+			"is added for you; output only the description that follows it, starting " +
+			"with a lower-case imperative verb and without a trailing period."
+	}
+	return ""
+}
+
 // ComposeFinalMessage builds the user-visible commit message: title +
 // blank line + body, with the optional changelog mention line appended
 // to the body when present. Pure string formatting; no I/O.
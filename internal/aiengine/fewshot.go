@@ -0,0 +1,236 @@
+package aiengine
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// FewShotQuery describes the commit being written: its tag, scope (one
+// scope per line, as Input.Scope) and touched paths.
+type FewShotQuery struct {
+	Type  string
+	Scope string
+	Paths []string
+}
+
+// FewShotExample is one completed commit picked as an example, with the
+// title and body the user accepted.
+type FewShotExample struct {
+	ID        int
+	Type      string
+	Scope     string
+	KeyPoints []string
+	Title     string
+	Body      string
+	Score     float64
+}
+
+// Similarity weights. Paths dominate: two commits touching the same files
+// are the best hint of how this change should read; tag and scope break
+// ties between equally close commits and rescue path-less queries.
+const (
+	fewShotFileWeight  = 3.0
+	fewShotDirWeight   = 2.0
+	fewShotTagWeight   = 1.5
+	fewShotScopeWeight = 1.5
+)
+
+// DiffPaths lists the files of a budgeted diff: the "=== path ==="
+// blocks and the omitted section.
+func DiffPaths(diff string) []string {
+	blocks, omitted := git.SplitOmittedSection(diff)
+	var paths []string
+	for _, b := range parseDiffBlocks(blocks) {
+		paths = append(paths, b.path)
+	}
+	for _, f := range omitted {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// RankFewShotExamples scores candidates against q and returns the best n
+// with a positive score, most similar first (newer first on ties, as
+// candidates come newest first). Commits without a usable message and
+// repeated titles are skipped.
+func RankFewShotExamples(q FewShotQuery, candidates []storage.Commit, n int) []FewShotExample {
+	files := stringSet(q.Paths, func(p string) string { return p })
+	dirs := stringSet(q.Paths, path.Dir)
+	scopes := stringSet(strings.Split(q.Scope, "\n"), strings.ToLower)
+	var out []FewShotExample
+	for _, c := range candidates {
+		title, body := acceptedMessage(c)
+		if title == "" {
+			continue
+		}
+		paths := c.Files
+		if len(paths) == 0 {
+			paths = DiffPaths(c.Diff_code)
+		}
+		score := fewShotFileWeight*jaccard(files, stringSet(paths, func(p string) string { return p })) +
+			fewShotDirWeight*jaccard(dirs, stringSet(paths, path.Dir))
+		if q.Type != "" && strings.EqualFold(q.Type, c.Type) {
+			score += fewShotTagWeight
+		}
+		if overlaps(scopes, stringSet(strings.Split(c.Scope, "\n"), strings.ToLower)) {
+			score += fewShotScopeWeight
+		}
+		if score <= 0 {
+			continue
+		}
+		out = append(out, FewShotExample{
+			ID: c.ID, Type: c.Type, Scope: c.Scope, KeyPoints: c.KeyPoints,
+			Title: title, Body: body, Score: score,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	seen := map[string]bool{}
+	picked := out[:0]
+	for _, e := range out {
+		if len(picked) == n {
+			break
+		}
+		if key := strings.ToLower(e.Title); !seen[key] {
+			seen[key] = true
+			picked = append(picked, e)
+		}
+	}
+	return picked
+}
+
+// acceptedMessage splits the final message the user kept into title and
+// body, falling back to the generated title and body.
+func acceptedMessage(c storage.Commit) (title, body string) {
+	msg := strings.TrimSpace(c.MessageEN)
+	if msg == "" {
+		return strings.TrimSpace(c.IaTitle), strings.TrimSpace(c.IaCommitRaw)
+	}
+	title, body, _ = strings.Cut(msg, "\n")
+	return strings.TrimSpace(title), strings.TrimSpace(body)
+}
+
+func stringSet(items []string, key func(string) string) map[string]bool {
+	set := map[string]bool{}
+	for _, it := range items {
+		if it = strings.TrimSpace(it); it != "" {
+			set[key(it)] = true
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for k := range a {
+		if b[k] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+func overlaps(a, b map[string]bool) bool {
+	for k := range a {
+		if b[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// renderFewShot formats examples for stage under a token budget counted
+// with count: examples are added most similar first until the next one
+// would not fit. Empty when none fits.
+func renderFewShot(examples []FewShotExample, stage StageID, maxTokens int, count func(string) int) string {
+	header := "\n\nEXAMPLES of accepted commits from this repository, most similar first. " +
+		"Match their tone, length and structure, never their content:"
+	var b strings.Builder
+	used := count(header)
+	n := 0
+	for _, e := range examples {
+		block := fewShotBlock(e, stage, n+1)
+		cost := count(block)
+		if used+cost > maxTokens {
+			break
+		}
+		b.WriteString(block)
+		used += cost
+		n++
+	}
+	if n == 0 {
+		return ""
+	}
+	return header + b.String()
+}
+
+// fewShotBlock renders one example in the shape of the stage's own input
+// and output: key points → body for the body stage, body → title for
+// the title stage.
+func fewShotBlock(e FewShotExample, stage StageID, i int) string {
+	scope := strings.ReplaceAll(e.Scope, "\n", ", ")
+	if stage == StageTitle {
+		return fmt.Sprintf("\n--- Example %d\nTAG: %s\nMODULE: %s\nCOMMIT_BODY:\n%s\nTITLE: %s",
+			i, e.Type, scope, e.Body, e.Title)
+	}
+	return fmt.Sprintf("\n--- Example %d\nTAG: %s\nMODULE: %s\nKEY_POINTS:\n- %s\nBODY:\n%s",
+		i, e.Type, scope, strings.Join(e.KeyPoints, "\n- "), e.Body)
+}
+
+// fewShotGuidance is appended to the body and title system prompts when
+// [few_shot] is on: the completed commits of this workspace most similar
+// to the one being written (deps.FewShotPaths, or the staged files when
+// unset), within [few_shot].max_tokens for the stage's model. Any error
+// leaves the prompt unchanged.
+func fewShotGuidance(deps Deps, stage StageID, commitType, commitScope string) string {
+	fs := deps.Cfg.FewShot
+	if !fs.Enabled() || deps.DB == nil || fs.MaxExamples <= 0 || fs.MaxTokens <= 0 {
+		return ""
+	}
+	examples, err := SelectFewShotExamples(deps, FewShotQuery{
+		Type:  commitType,
+		Scope: commitScope,
+		Paths: deps.FewShotPaths,
+	})
+	if err != nil {
+		if deps.Log != nil {
+			deps.Log.Debug("few-shot retrieval failed", "error", err)
+		}
+		return ""
+	}
+	model := deps.Cfg.Prompts.CommitBodyGeneratorPromptModel
+	if stage == StageTitle {
+		model = deps.Cfg.Prompts.CommitTitleGeneratorPromptModel
+	}
+	return renderFewShot(examples, stage, fs.MaxTokens, TokenizerFor(deps.Cfg, model).Count)
+}
+
+// SelectFewShotExamples loads the completed commits of the worktree
+// containing deps.Pwd (the directory itself outside git) and ranks them
+// against q; empty q.Paths falls back to the staged files.
+func SelectFewShotExamples(deps Deps, q FewShotQuery) ([]FewShotExample, error) {
+	if len(q.Paths) == 0 {
+		q.Paths, _ = git.StagedFilesAt(deps.Pwd, false)
+	}
+	if err := deps.DB.ResolveWorkspaceIDs(git.WorkspaceKeys); err != nil {
+		return nil, err
+	}
+	var candidates []storage.Commit
+	var err error
+	if worktree, _ := git.WorkspaceKeys(deps.Pwd); worktree != "" {
+		candidates, err = deps.DB.GetCommitsByWorktree(worktree, "completed")
+	} else {
+		candidates, err = deps.DB.GetCommits(deps.Pwd, "completed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return RankFewShotExamples(q, candidates, deps.Cfg.FewShot.MaxExamples), nil
+}
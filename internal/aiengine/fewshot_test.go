@@ -0,0 +1,67 @@
+package aiengine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+func TestDiffPaths(t *testing.T) {
+	diff := "=== internal/git/git.go ===\n@@ -1 +1 @@\n-a\n+b\n=== README.md ===\n+docs\n" +
+		git.OmittedDiffHeader + "\ngo.sum +3 -1 (lockfile)\n"
+	got := DiffPaths(diff)
+	want := []string{"internal/git/git.go", "README.md", "go.sum"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPaths = %v, want %v", got, want)
+	}
+}
+
+func TestRankFewShotExamples(t *testing.T) {
+	candidates := []storage.Commit{
+		{ID: 1, Type: "FIX", Scope: "tui", MessageEN: "Keep the cursor on filter reset\n\n- Restore the index", Files: []string{"internal/tui/list.go"}},
+		{ID: 2, Type: "IMP", Scope: "git", MessageEN: "Read the log in one call\n\n- Batch the reads", Diff_code: "=== internal/git/git.go ===\n+x\n"},
+		{ID: 3, Type: "FIX", Scope: "git", MessageEN: "Guard empty ranges\n\n- Return early", Diff_code: "=== internal/git/git.go ===\n+y\n"},
+		{ID: 4, Type: "DOC", Scope: "docs", MessageEN: "Document the hooks", Files: []string{"README.md"}},
+		{ID: 5, Type: "FIX", Scope: "git", MessageEN: "guard empty ranges\n\n- Duplicate", Files: []string{"internal/git/git.go"}},
+		{ID: 6, Type: "FIX", Scope: "git"},
+	}
+	q := FewShotQuery{Type: "FIX", Scope: "git", Paths: []string{"internal/git/git.go"}}
+	got := RankFewShotExamples(q, candidates, 3)
+	var ids []int
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	// 3 matches path, tag and scope; 2 path and scope; 1 only the tag.
+	// 5 repeats 3's title, 4 shares nothing, 6 has no message.
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ranked ids = %v, want %v", ids, want)
+	}
+	if got[0].Title != "Guard empty ranges" || got[0].Body != "- Return early" {
+		t.Errorf("accepted message = %q / %q", got[0].Title, got[0].Body)
+	}
+}
+
+func TestRenderFewShotBudget(t *testing.T) {
+	examples := []FewShotExample{
+		{Type: "FIX", Scope: "git", KeyPoints: []string{"guard ranges"}, Title: "Guard empty ranges", Body: "- Return early"},
+		{Type: "IMP", Scope: "git", KeyPoints: []string{"batch"}, Title: "Read the log once", Body: strings.Repeat("- Batch the reads\n", 40)},
+	}
+	words := func(s string) int { return len(strings.Fields(s)) }
+	got := renderFewShot(examples, StageBody, 60, words)
+	if !strings.Contains(got, "Example 1") || strings.Contains(got, "Example 2") {
+		t.Errorf("budget should keep only the first example:\n%s", got)
+	}
+	if !strings.Contains(got, "KEY_POINTS:\n- guard ranges\nBODY:\n- Return early") {
+		t.Errorf("body block shape:\n%s", got)
+	}
+	title := renderFewShot(examples[:1], StageTitle, 1000, words)
+	if !strings.Contains(title, "COMMIT_BODY:\n- Return early\nTITLE: Guard empty ranges") {
+		t.Errorf("title block shape:\n%s", title)
+	}
+	if got := renderFewShot(examples, StageBody, 5, words); got != "" {
+		t.Errorf("nothing fits, want empty, got %q", got)
+	}
+}
@@ -0,0 +1,44 @@
+package aiengine
+
+import (
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+)
+
+// resolveProvider builds the api.Provider configured for stage and
+// returns it with the API key it must be called with. Groq keeps reading
+// its key from the active two-slot resolution (TUI.GroqAPIKey); the other
+// backends read theirs from Cfg.Providers.
+func resolveProvider(deps Deps, stage config.ModelStage) (api.Provider, string, error) {
+	return StageProvider(deps.Cfg, stage)
+}
+
+// StageProvider is resolveProvider for callers without Deps, such as the
+// TUI model picker listing the catalogue of a stage's provider.
+func StageProvider(cfg config.Config, stage config.ModelStage) (api.Provider, string, error) {
+	name := api.NormalizeProvider(config.ProviderForStage(cfg, stage))
+	ep := providerEndpoint(cfg, name)
+	p, err := api.NewProvider(name, ep.BaseURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, ep.APIKey, nil
+}
+
+// providerEndpoint returns the base URL and key configured for the
+// normalised provider name; unknown names get an empty endpoint.
+func providerEndpoint(cfg config.Config, name string) config.ProviderEndpoint {
+	var ep config.ProviderEndpoint
+	switch name {
+	case api.ProviderGroq:
+		ep = cfg.Providers.Groq
+		ep.APIKey = cfg.TUI.GroqAPIKey
+	case api.ProviderOpenAI:
+		ep = cfg.Providers.OpenAI
+	case api.ProviderOllama:
+		ep = cfg.Providers.Ollama
+	case api.ProviderAnthropic:
+		ep = cfg.Providers.Anthropic
+	}
+	return ep
+}
@@ -6,10 +6,12 @@
 package aiengine
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
 )
 
 // ReleaseStageID labels the three release pipeline stages so callers
@@ -50,11 +52,13 @@ type ReleaseOutput struct {
 // RunReleaseBody executes stage 1 alone: selected commits → release
 // body. Independent so retry-from-stage callers can re-run just the
 // body and feed its output into the downstream cascade.
-func RunReleaseBody(deps Deps, in ReleaseInput) (string, *api.CallStats, error) {
+func RunReleaseBody(ctx context.Context, deps Deps, in ReleaseInput) (string, *api.CallStats, error) {
 	pc := deps.Cfg.Prompts
 	commitsBlob := formatReleaseCommits(in.Commits)
 	text, stats, err := SendIaMessage(
+		ctx,
 		deps,
+		config.StageReleaseBody,
 		pc.ReleaseBodyPrompt,
 		commitsBlob,
 		pc.ReleaseBodyPromptModel,
@@ -68,12 +72,19 @@ func RunReleaseBody(deps Deps, in ReleaseInput) (string, *api.CallStats, error)
 // RunReleaseTitle executes stage 2 alone: existing body + commits →
 // release title. Caller passes the body produced by stage 1 (or the
 // cached body from a prior run when retrying from stage 2 only).
-func RunReleaseTitle(deps Deps, body string, in ReleaseInput) (string, *api.CallStats, error) {
+func RunReleaseTitle(
+	ctx context.Context,
+	deps Deps,
+	body string,
+	in ReleaseInput,
+) (string, *api.CallStats, error) {
 	pc := deps.Cfg.Prompts
 	commitsBlob := formatReleaseCommits(in.Commits)
 	titleInput := fmt.Sprintf("BODY:\n%s\n\nCOMMITS:\n%s", body, commitsBlob)
 	text, stats, err := SendIaMessage(
+		ctx,
 		deps,
+		config.StageReleaseTitle,
 		pc.ReleaseTitlePrompt,
 		titleInput,
 		pc.ReleaseTitlePromptModel,
@@ -87,11 +98,13 @@ func RunReleaseTitle(deps Deps, body string, in ReleaseInput) (string, *api.Call
 // RunReleaseRefine executes stage 3 alone: existing body + title →
 // final polished release note. Caller passes the upstream outputs;
 // retry-from-stage-3 reuses both verbatim.
-func RunReleaseRefine(deps Deps, body, title string) (string, *api.CallStats, error) {
+func RunReleaseRefine(ctx context.Context, deps Deps, body, title string) (string, *api.CallStats, error) {
 	pc := deps.Cfg.Prompts
 	refineInput := fmt.Sprintf("TITLE:\n%s\n\nBODY:\n%s", title, body)
 	text, stats, err := SendIaMessage(
+		ctx,
 		deps,
+		config.StageReleaseRefine,
 		pc.ReleaseRefinePrompt,
 		refineInput,
 		pc.ReleaseRefinePromptModel,
@@ -106,29 +119,32 @@ func RunReleaseRefine(deps Deps, body, title string) (string, *api.CallStats, er
 // using the per-stage primitives above. Errors abort the run and
 // propagate to the caller; partial telemetry already recorded survives
 // in out.Stages.
-func RunRelease(deps Deps, in ReleaseInput) (ReleaseOutput, error) {
+func RunRelease(ctx context.Context, deps Deps, in ReleaseInput) (ReleaseOutput, error) {
 	pc := deps.Cfg.Prompts
 	out := ReleaseOutput{}
 	for i := range out.Stages {
 		out.Stages[i].ID = StageID(i)
 	}
 
-	body, bodyStats, err := RunReleaseBody(deps, in)
+	body, bodyStats, err := RunReleaseBody(ctx, deps, in)
 	if err != nil {
+		recordFailedReleaseStage(&out, ReleaseStageBody, pc.ReleaseBodyPromptModel, err)
 		return out, err
 	}
 	recordReleaseStage(&out, ReleaseStageBody, pc.ReleaseBodyPromptModel, bodyStats)
 	out.Body = body
 
-	title, titleStats, err := RunReleaseTitle(deps, body, in)
+	title, titleStats, err := RunReleaseTitle(ctx, deps, body, in)
 	if err != nil {
+		recordFailedReleaseStage(&out, ReleaseStageTitle, pc.ReleaseTitlePromptModel, err)
 		return out, err
 	}
 	recordReleaseStage(&out, ReleaseStageTitle, pc.ReleaseTitlePromptModel, titleStats)
 	out.Title = title
 
-	final, finalStats, err := RunReleaseRefine(deps, body, title)
+	final, finalStats, err := RunReleaseRefine(ctx, deps, body, title)
 	if err != nil {
+		recordFailedReleaseStage(&out, ReleaseStageRefine, pc.ReleaseRefinePromptModel, err)
 		return out, err
 	}
 	recordReleaseStage(&out, ReleaseStageRefine, pc.ReleaseRefinePromptModel, finalStats)
@@ -178,5 +194,17 @@ func recordReleaseStage(
 	st.APITotalTime = stats.TotalTime
 	st.RequestID = stats.RequestID
 	st.StatsModel = stats.Model
+	st.Provider = stats.Provider
 	st.TPMLimitAtCall = stats.RateLimits.LimitTokens
+	st.Attempts = stats.Attempts
+}
+
+// recordFailedReleaseStage is RecordFailedStage for the release stages.
+func recordFailedReleaseStage(out *ReleaseOutput, id ReleaseStageID, modelName string, err error) {
+	provider, attempts := FailedAttempts(err)
+	if len(attempts) == 0 || int(id) < 0 || int(id) >= len(out.Stages) {
+		return
+	}
+	st := &out.Stages[id]
+	st.ID, st.Model, st.Provider, st.Attempts = StageID(id), modelName, provider, attempts
 }
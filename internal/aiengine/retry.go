@@ -0,0 +1,243 @@
+package aiengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+)
+
+// retrySleep is the wait primitive used between attempts. It returns
+// early with ctx.Err() when ctx is done. Tests swap it for a recorder so
+// they don't actually sleep.
+var retrySleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryPolicy is config.RetryConfig resolved into durations, with
+// non-positive values clamped to safe floors.
+type retryPolicy struct {
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	maxWait     time.Duration
+}
+
+func newRetryPolicy(rc config.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		maxAttempts: rc.MaxAttempts,
+		baseBackoff: time.Duration(rc.BaseBackoffMs) * time.Millisecond,
+		maxBackoff:  time.Duration(rc.MaxBackoffMs) * time.Millisecond,
+		maxWait:     time.Duration(rc.MaxWaitSeconds) * time.Second,
+	}
+	if p.maxAttempts < 1 {
+		p.maxAttempts = 1
+	}
+	if p.baseBackoff <= 0 {
+		p.baseBackoff = time.Second
+	}
+	if p.maxBackoff < p.baseBackoff {
+		p.maxBackoff = p.baseBackoff
+	}
+	return p
+}
+
+// backoff is the exponential wait after the n-th failed try (n >= 1).
+func (p retryPolicy) backoff(n int) time.Duration {
+	d := p.baseBackoff
+	for i := 1; i < n && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.maxBackoff)
+}
+
+// callTarget is one (model, key) pair a stage call may be served by.
+type callTarget struct {
+	model   string
+	apiKey  string
+	keySlot string
+}
+
+// stageTargets returns the ordered chain a stage call walks through:
+// the configured model, then the stage's fallback models, all on the
+// active key; with UseOtherKeySlot (Groq only, and only when the other
+// slot holds a key) the same chain repeats on the inactive slot.
+func stageTargets(
+	deps Deps,
+	stage config.ModelStage,
+	providerName, model, apiKey string,
+) []callTarget {
+	models := []string{model}
+	for _, m := range deps.Cfg.Retry.FallbackModels[string(stage)] {
+		if m != "" && m != model {
+			models = append(models, m)
+		}
+	}
+	slot := ""
+	if providerName == api.ProviderGroq {
+		slot = config.NormalizeKeySlot(deps.Cfg.TUI.ActiveKeySlot)
+	}
+	targets := make([]callTarget, 0, len(models)*2)
+	for _, m := range models {
+		targets = append(targets, callTarget{model: m, apiKey: apiKey, keySlot: slot})
+	}
+	other := deps.Cfg.TUI.GroqInactiveAPIKey
+	if providerName == api.ProviderGroq && deps.Cfg.Retry.UseOtherKeySlot && other != "" {
+		otherSlot := config.KeySlotAI
+		if slot == config.KeySlotAI {
+			otherSlot = config.KeySlotUser
+		}
+		for _, m := range models {
+			targets = append(targets, callTarget{model: m, apiKey: other, keySlot: otherSlot})
+		}
+	}
+	return targets
+}
+
+// rateLimitWait derives how long to wait after a 429 from the reset
+// headers of the rejected response (and Retry-After when present). The
+// rate-limit snapshot is also recorded so the quota bars reflect the
+// exhausted bucket.
+func rateLimitWait(deps Deps, provider api.Provider, model string, err error) time.Duration {
+	var se *api.StatusError
+	if !errors.As(err, &se) {
+		return 0
+	}
+	wait := se.RetryAfter()
+	rl := provider.ParseRateLimits(se.Header)
+	if rl.RequestsParsed || rl.TokensParsed {
+		api.RecordRateLimits(model, rl)
+		persistRateLimits(deps, provider.Name(), model, rl)
+	}
+	return max(wait, rl.ResetRequests, rl.ResetTokens)
+}
+
+// callWithRetry runs one stage call under the retry policy. Transient
+// failures (429, 5xx, network) are retried on the same target up to
+// maxAttempts; a 429 whose reset exceeds maxWait, a non-transient error,
+// or an exhausted target moves on to the next target. Every failed try
+// is reported through deps.OnRetry and returned on the successful
+// call's CallStats.Attempts, or, when no target served the call, on the
+// returned *AttemptsError. Each try runs under the stage's per-attempt
+// timeout (a timeout is transient, like a 5xx); once ctx itself is done
+// the loop stops and returns ctx.Err(), carrying the attempts made so
+// far.
+func callWithRetry(
+	ctx context.Context,
+	deps Deps,
+	stage config.ModelStage,
+	provider api.Provider,
+	targets []callTarget,
+	messages []api.Message,
+) (string, *api.CallStats, error) {
+	policy := newRetryPolicy(deps.Cfg.Retry)
+	var attempts []api.CallAttempt
+	var lastErr error
+	for _, t := range targets {
+		for n := 1; n <= policy.maxAttempts; n++ {
+			response, stats, err := sendOnce(ctx, deps, stage, provider, t, messages)
+			if err == nil {
+				if stats != nil {
+					stats.Attempts = attempts
+				}
+				return response, stats, nil
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return "", nil, withAttempts(provider, attempts, ctxErr)
+			}
+			lastErr = err
+			a := api.CallAttempt{
+				Model:   t.model,
+				KeySlot: t.keySlot,
+				Outcome: api.ClassifyError(err),
+				Error:   err.Error(),
+				Stats:   stats,
+			}
+			retrySame := n < policy.maxAttempts
+			switch a.Outcome {
+			case api.OutcomeRateLimited:
+				a.Wait = rateLimitWait(deps, provider, t.model, err)
+				if a.Wait > policy.maxWait {
+					a.Wait, retrySame = 0, false
+				} else if a.Wait == 0 {
+					a.Wait = policy.backoff(n)
+				}
+			case api.OutcomeServerError, api.OutcomeNetwork, api.OutcomeTimeout:
+				a.Wait = policy.backoff(n)
+			default:
+				retrySame = false
+			}
+			if !retrySame {
+				a.Wait = 0
+			}
+			attempts = append(attempts, a)
+			if deps.OnRetry != nil {
+				deps.OnRetry(stage, a)
+			}
+			if deps.Log != nil {
+				deps.Log.Warn("AI call attempt failed",
+					"stage", stage, "model", t.model, "slot", t.keySlot,
+					"outcome", a.Outcome, "wait", a.Wait, "error", err)
+			}
+			if !retrySame {
+				break
+			}
+			if err := retrySleep(ctx, a.Wait); err != nil {
+				return "", nil, withAttempts(provider, attempts, err)
+			}
+		}
+	}
+	if len(attempts) > 1 {
+		lastErr = fmt.Errorf("%d attempts failed, last: %w", len(attempts), lastErr)
+	}
+	return "", nil, withAttempts(provider, attempts, lastErr)
+}
+
+// sendOnce performs a single (optionally streamed) completion against
+// target t, bounded by the stage's configured timeout, and records the
+// returned rate-limit snapshot.
+func sendOnce(
+	ctx context.Context,
+	deps Deps,
+	stage config.ModelStage,
+	provider api.Provider,
+	t callTarget,
+	messages []api.Message,
+) (string, *api.CallStats, error) {
+	attemptCtx := ctx
+	timeout := deps.Cfg.Timeouts.ForStage(stage)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var response string
+	var stats *api.CallStats
+	var err error
+	if deps.OnChunk != nil {
+		response, stats, err = provider.ChatCompletionStream(
+			attemptCtx, t.apiKey, t.model, messages,
+			func(delta string) { deps.OnChunk(stage, delta) },
+		)
+	} else {
+		response, stats, err = provider.ChatCompletion(attemptCtx, t.apiKey, t.model, messages)
+	}
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("no response within %s: %w", timeout, context.DeadlineExceeded)
+	}
+	if stats != nil {
+		api.RecordRateLimits(t.model, stats.RateLimits)
+		persistRateLimits(deps, provider.Name(), t.model, stats.RateLimits)
+	}
+	return response, stats, err
+}
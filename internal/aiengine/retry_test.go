@@ -0,0 +1,274 @@
+package aiengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/storage"
+)
+
+// errHang makes scriptedProvider block until the call's ctx is done.
+var errHang = errors.New("hang")
+
+// scriptedProvider answers ChatCompletion from a per-model queue of
+// errors; an exhausted queue means success.
+type scriptedProvider struct {
+	errs  map[string][]error
+	calls []string
+}
+
+func (p *scriptedProvider) Name() string { return api.ProviderGroq }
+
+func (p *scriptedProvider) ChatCompletion(
+	ctx context.Context,
+	apiKey, model string,
+	_ []api.Message,
+) (string, *api.CallStats, error) {
+	p.calls = append(p.calls, model+"@"+apiKey)
+	if q := p.errs[model]; len(q) > 0 {
+		p.errs[model] = q[1:]
+		if q[0] == errHang {
+			<-ctx.Done()
+			return "", nil, ctx.Err()
+		}
+		return "", nil, q[0]
+	}
+	return "ok:" + model, &api.CallStats{Model: model}, nil
+}
+
+func (p *scriptedProvider) ChatCompletionStream(
+	ctx context.Context,
+	apiKey, model string,
+	msgs []api.Message,
+	_ api.ChunkFunc,
+) (string, *api.CallStats, error) {
+	return p.ChatCompletion(ctx, apiKey, model, msgs)
+}
+
+func (p *scriptedProvider) ListModels(string) ([]api.GroqModel, error) { return nil, nil }
+
+func (p *scriptedProvider) ParseRateLimits(h http.Header) api.RateLimits {
+	rl := api.RateLimits{}
+	if v := h.Get("x-reset"); v != "" {
+		rl.ResetTokens, _ = time.ParseDuration(v)
+		rl.TokensParsed = true
+	}
+	return rl
+}
+
+func statusErr(status int, reset string) error {
+	h := http.Header{}
+	if reset != "" {
+		h.Set("x-reset", reset)
+	}
+	return &api.StatusError{Status: status, Header: h}
+}
+
+func withSleepRecorder(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	prev := retrySleep
+	retrySleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	t.Cleanup(func() { retrySleep = prev })
+	return &waits
+}
+
+func retryDeps(rc config.RetryConfig) Deps {
+	cfg := config.NewDefaultConfig()
+	cfg.Retry = rc
+	return Deps{Cfg: cfg}
+}
+
+func TestCallWithRetry_ServerErrorBacksOff(t *testing.T) {
+	waits := withSleepRecorder(t)
+	p := &scriptedProvider{errs: map[string][]error{
+		"m1": {statusErr(503, ""), statusErr(502, "")},
+	}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 3, BaseBackoffMs: 100, MaxBackoffMs: 1000})
+	targets := []callTarget{{model: "m1", apiKey: "k"}}
+
+	text, stats, err := callWithRetry(context.Background(), deps, config.StageCommitBody, p, targets, nil)
+	if err != nil || text != "ok:m1" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if len(stats.Attempts) != 2 {
+		t.Fatalf("expected 2 failed attempts, got %+v", stats.Attempts)
+	}
+	if stats.Attempts[0].Outcome != api.OutcomeServerError {
+		t.Fatalf("unexpected outcome %q", stats.Attempts[0].Outcome)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	if len(*waits) != 2 || (*waits)[0] != want[0] || (*waits)[1] != want[1] {
+		t.Fatalf("unexpected waits %v", *waits)
+	}
+}
+
+func TestCallWithRetry_RateLimitHonorsResetThenFallsBack(t *testing.T) {
+	waits := withSleepRecorder(t)
+	p := &scriptedProvider{errs: map[string][]error{
+		"m1": {statusErr(429, "2s"), statusErr(429, "90s")},
+	}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 3, MaxWaitSeconds: 10})
+	targets := []callTarget{{model: "m1", apiKey: "k"}, {model: "m2", apiKey: "k"}}
+
+	var notified int
+	deps.OnRetry = func(config.ModelStage, api.CallAttempt) { notified++ }
+	text, stats, err := callWithRetry(context.Background(), deps, config.StageCommitTitle, p, targets, nil)
+	if err != nil || text != "ok:m2" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 2*time.Second {
+		t.Fatalf("expected a single 2s wait, got %v", *waits)
+	}
+	if len(stats.Attempts) != 2 || notified != 2 {
+		t.Fatalf("attempts=%d notified=%d", len(stats.Attempts), notified)
+	}
+}
+
+func TestCallWithRetry_FatalErrorKeepsRateLimitSentinel(t *testing.T) {
+	withSleepRecorder(t)
+	p := &scriptedProvider{errs: map[string][]error{
+		"m1": {statusErr(429, "5m")},
+	}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 2, MaxWaitSeconds: 10})
+	_, _, err := callWithRetry(
+		context.Background(), deps, config.StageChangeAnalyzer, p, []callTarget{{model: "m1"}}, nil,
+	)
+	if !errors.Is(err, api.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestCallWithRetry_ExhaustedKeepsEveryAttempt(t *testing.T) {
+	withSleepRecorder(t)
+	p := &scriptedProvider{errs: map[string][]error{
+		"m1": {statusErr(503, ""), statusErr(503, "")},
+		"m2": {statusErr(503, ""), statusErr(503, "")},
+	}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 2, BaseBackoffMs: 1, MaxBackoffMs: 1})
+	var notified []api.CallAttempt
+	deps.OnRetry = func(_ config.ModelStage, a api.CallAttempt) { notified = append(notified, a) }
+	targets := []callTarget{{model: "m1", apiKey: "k"}, {model: "m2", apiKey: "k"}}
+
+	_, _, err := callWithRetry(context.Background(), deps, config.StageCommitBody, p, targets, nil)
+	provider, attempts := FailedAttempts(err)
+	if provider != api.ProviderGroq || len(attempts) != 4 {
+		t.Fatalf("provider=%q attempts=%+v (err %v)", provider, attempts, err)
+	}
+	if attempts[3].Model != "m2" || attempts[3].Outcome != api.OutcomeServerError {
+		t.Fatalf("last attempt: %+v", attempts[3])
+	}
+	if len(notified) != 4 || notified[3].Wait != 0 {
+		t.Fatalf("expected the last attempt reported with no wait, got %+v", notified)
+	}
+
+	out := Output{Stages: make([]StageStats, 4)}
+	RecordFailedStage(&out, StageBody, "m1", err)
+	if st := out.Stages[StageBody]; !st.Failed || st.HasStats || len(st.Attempts) != 4 {
+		t.Fatalf("recorded stage: %+v", st)
+	}
+	var rows []storage.AICall
+	create := func(c storage.AICall) (int64, error) {
+		rows = append(rows, c)
+		return int64(len(rows)), nil
+	}
+	if err := CreateFailedStageCalls(create, 7, "body", provider, attempts); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[3].Served() || rows[3].Attempt != 4 {
+		t.Fatalf("rows: %+v", rows)
+	}
+}
+
+func TestStageTargets_OtherKeySlot(t *testing.T) {
+	deps := retryDeps(config.RetryConfig{
+		UseOtherKeySlot: true,
+		FallbackModels:  map[string][]string{"commit_body": {"m2", "m1"}},
+	})
+	deps.Cfg.TUI.ActiveKeySlot = config.KeySlotUser
+	deps.Cfg.TUI.GroqInactiveAPIKey = "other"
+
+	got := stageTargets(deps, config.StageCommitBody, api.ProviderGroq, "m1", "mine")
+	want := []callTarget{
+		{"m1", "mine", "user"}, {"m2", "mine", "user"},
+		{"m1", "other", "ai"}, {"m2", "other", "ai"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("target %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCreateStageCalls_AttemptsBeforeServed(t *testing.T) {
+	var rows []storage.AICall
+	create := func(c storage.AICall) (int64, error) {
+		rows = append(rows, c)
+		return int64(len(rows)), nil
+	}
+	served := storage.AICall{CommitID: 7, Stage: "body", Provider: "groq", Model: "m2"}
+	attempts := []api.CallAttempt{
+		{Model: "m1", Outcome: api.OutcomeRateLimited, Error: "429"},
+	}
+	if err := CreateStageCalls(create, served, attempts); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	if rows[0].Served() || rows[0].Attempt != 1 || rows[0].Model != "m1" || rows[0].CommitID != 7 {
+		t.Fatalf("attempt row: %+v", rows[0])
+	}
+	if !rows[1].Served() || rows[1].Attempt != 2 || rows[1].Model != "m2" {
+		t.Fatalf("served row: %+v", rows[1])
+	}
+	if got := AttemptFromCall(rows[0]); got.Outcome != api.OutcomeRateLimited || got.Model != "m1" {
+		t.Fatalf("reloaded attempt: %+v", got)
+	}
+}
+
+func TestCallWithRetry_TimeoutIsRetried(t *testing.T) {
+	withSleepRecorder(t)
+	p := &scriptedProvider{errs: map[string][]error{
+		"m1": {fmt.Errorf("no response within 1s: %w", context.DeadlineExceeded)},
+	}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 2})
+
+	text, stats, err := callWithRetry(
+		context.Background(), deps, config.StageCommitBody, p, []callTarget{{model: "m1"}}, nil,
+	)
+	if err != nil || text != "ok:m1" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if len(stats.Attempts) != 1 || stats.Attempts[0].Outcome != api.OutcomeTimeout {
+		t.Fatalf("unexpected attempts %+v", stats.Attempts)
+	}
+}
+
+func TestCallWithRetry_CancelStopsChain(t *testing.T) {
+	p := &scriptedProvider{errs: map[string][]error{"m1": {errHang}}}
+	deps := retryDeps(config.RetryConfig{MaxAttempts: 3})
+	targets := []callTarget{{model: "m1"}, {model: "m2"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, _, err := callWithRetry(ctx, deps, config.StageCommitBody, p, targets, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(p.calls) != 1 {
+		t.Fatalf("cancelled run must not try other targets, calls=%v", p.calls)
+	}
+}
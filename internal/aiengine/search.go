@@ -0,0 +1,327 @@
+package aiengine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/embedding"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// SearchDoc is one commit or release as the search index sees it: Text
+// is what gets embedded, the other fields are echoed back in hits.
+type SearchDoc struct {
+	Kind      string
+	ID        int
+	Title     string
+	Text      string
+	Type      string
+	Scope     string
+	Status    string
+	CreatedAt time.Time
+}
+
+// SearchHit is a ranked SearchDoc. Score is the cosine similarity
+// between the query and the document, from 0 to 1 in practice.
+type SearchHit struct {
+	Kind      string    `json:"kind"`
+	ID        int       `json:"id"`
+	Score     float64   `json:"score"`
+	Title     string    `json:"title"`
+	Type      string    `json:"type,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchResult is a ranked search. Embedder names the vector space used;
+// Warning says why the configured embedder was replaced by the hashing
+// one. Embedded counts the documents whose vectors were (re)computed for
+// this search.
+type SearchResult struct {
+	Embedder string      `json:"embedder"`
+	Warning  string      `json:"warning,omitempty"`
+	Embedded int         `json:"embedded"`
+	Hits     []SearchHit `json:"hits"`
+}
+
+// searchMinScore drops hits that share no more than hash collisions with
+// the query.
+const searchMinScore = 0.05
+
+// searchMaxText caps the embedded text so a long body never exceeds a
+// remote model's input window.
+const searchMaxText = 8000
+
+// CommitSearchDoc is the searchable text of a commit: the accepted title
+// and body (or the generated ones), the key points, tag and scope.
+func CommitSearchDoc(c storage.Commit) SearchDoc {
+	title, body := acceptedMessage(c)
+	if title == "" && len(c.KeyPoints) > 0 {
+		title = c.KeyPoints[0]
+	}
+	parts := []string{title, body, strings.Join(c.KeyPoints, "\n"), c.Type, strings.ReplaceAll(c.Scope, "\n", " ")}
+	return SearchDoc{
+		Kind:      storage.EmbeddingKindCommit,
+		ID:        c.ID,
+		Title:     title,
+		Text:      joinSearchText(parts),
+		Type:      c.Type,
+		Scope:     c.Scope,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// ReleaseSearchDoc is the searchable text of a release: version, title,
+// body and type.
+func ReleaseSearchDoc(r storage.Release) SearchDoc {
+	title := strings.TrimSpace(r.Title)
+	if r.Version != "" {
+		title = strings.TrimSpace(r.Version + " " + title)
+	}
+	return SearchDoc{
+		Kind:      storage.EmbeddingKindRelease,
+		ID:        r.ID,
+		Title:     title,
+		Text:      joinSearchText([]string{title, r.Body, r.Type}),
+		Type:      r.Type,
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func joinSearchText(parts []string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	text := strings.Join(kept, "\n")
+	if len(text) > searchMaxText {
+		text = text[:searchMaxText]
+	}
+	return text
+}
+
+// NewEmbedder builds the embedder [embeddings] asks for: the hashing one
+// for "hash" or an empty provider, otherwise the provider's embeddings
+// endpoint with its [providers] base URL and key.
+func NewEmbedder(cfg config.Config) (embedding.Embedder, error) {
+	ec := cfg.Embeddings
+	name := strings.ToLower(strings.TrimSpace(ec.Provider))
+	if name == "" || name == config.EmbeddingsProviderHash {
+		return embedding.Hash{Dims: ec.Dims}, nil
+	}
+	if ec.Model == "" {
+		return nil, fmt.Errorf("[embeddings] provider %q needs a model", name)
+	}
+	ep := providerEndpoint(cfg, name)
+	p, err := api.NewProvider(name, ep.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.(api.EmbeddingProvider); !ok {
+		return nil, fmt.Errorf("provider %q has no embeddings endpoint", name)
+	}
+	return embedding.Remote{Provider: p, APIKey: ep.APIKey, Model: ec.Model}, nil
+}
+
+// searchContentHash fingerprints an embedded text.
+func searchContentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+type searchKey struct {
+	kind string
+	id   int
+}
+
+// IndexSearchDocs returns the vector of every doc under e, reusing the
+// stored ones whose text is unchanged and embedding (then storing) the
+// rest in one batch. embedded counts the docs that were embedded.
+func IndexSearchDocs(
+	ctx context.Context,
+	db *storage.DB,
+	e embedding.Embedder,
+	docs []SearchDoc,
+) (vectors map[searchKey][]float32, embedded int, err error) {
+	vectors = make(map[searchKey][]float32, len(docs))
+	stored := map[string]map[int]storage.Embedding{}
+	var stale []SearchDoc
+	for _, d := range docs {
+		rows, ok := stored[d.Kind]
+		if !ok {
+			if rows, err = db.GetEmbeddings(d.Kind, e.Name()); err != nil {
+				return nil, 0, err
+			}
+			stored[d.Kind] = rows
+		}
+		row, ok := rows[d.ID]
+		if ok && row.ContentHash == searchContentHash(d.Text) {
+			if v, err := embedding.Decode(row.Vector); err == nil {
+				vectors[searchKey{d.Kind, d.ID}] = v
+				continue
+			}
+		}
+		stale = append(stale, d)
+	}
+	if len(stale) == 0 {
+		return vectors, 0, nil
+	}
+	texts := make([]string, len(stale))
+	for i, d := range stale {
+		texts[i] = d.Text
+	}
+	fresh, err := e.Embed(ctx, texts)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := make([]storage.Embedding, len(stale))
+	for i, d := range stale {
+		vectors[searchKey{d.Kind, d.ID}] = fresh[i]
+		rows[i] = storage.Embedding{
+			Kind:        d.Kind,
+			ItemID:      d.ID,
+			Embedder:    e.Name(),
+			ContentHash: searchContentHash(d.Text),
+			Vector:      embedding.Encode(fresh[i]),
+		}
+	}
+	if err := db.SaveEmbeddings(rows); err != nil {
+		return nil, 0, err
+	}
+	return vectors, len(stale), nil
+}
+
+// RankSearchDocs scores docs against the query vector and returns the
+// best limit hits above searchMinScore, most similar first (input order
+// on ties). limit <= 0 keeps every hit.
+func RankSearchDocs(query []float32, docs []SearchDoc, vectors map[searchKey][]float32, limit int) []SearchHit {
+	var hits []SearchHit
+	for _, d := range docs {
+		score := embedding.Cosine(query, vectors[searchKey{d.Kind, d.ID}])
+		if score < searchMinScore {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			Kind: d.Kind, ID: d.ID, Score: score, Title: d.Title,
+			Type: d.Type, Scope: d.Scope, Status: d.Status, CreatedAt: d.CreatedAt,
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// SearchDocs ranks docs by similarity to query with the configured
+// embedder, indexing whatever is new or changed first. When that
+// embedder cannot be built or fails, the hashing embedder takes over and
+// the result carries a Warning; only a cancelled ctx or a database error
+// is returned.
+func SearchDocs(ctx context.Context, deps Deps, docs []SearchDoc, query string, limit int) (SearchResult, error) {
+	e, err := NewEmbedder(deps.Cfg)
+	if err == nil {
+		var res SearchResult
+		res, err = searchWith(ctx, deps.DB, e, docs, query, limit)
+		if err == nil {
+			return res, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return SearchResult{}, ctxErr
+		}
+	}
+	if deps.Log != nil {
+		deps.Log.Warn("Embeddings provider failed, using the hashing embedder", "error", err)
+	}
+	res, hashErr := searchWith(ctx, deps.DB, embedding.Hash{Dims: deps.Cfg.Embeddings.Dims}, docs, query, limit)
+	res.Warning = fmt.Sprintf("embeddings provider failed (%v); using the hashing embedder", err)
+	return res, hashErr
+}
+
+func searchWith(
+	ctx context.Context,
+	db *storage.DB,
+	e embedding.Embedder,
+	docs []SearchDoc,
+	query string,
+	limit int,
+) (SearchResult, error) {
+	vectors, embedded, err := IndexSearchDocs(ctx, db, e, docs)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	q, err := e.Embed(ctx, []string{query})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return SearchResult{
+		Embedder: e.Name(),
+		Embedded: embedded,
+		Hits:     RankSearchDocs(q[0], docs, vectors, limit),
+	}, nil
+}
+
+// SearchScopeDocs collects the documents `ai search` looks at: commits
+// (drafts and completed) written inside the worktree containing deps.Pwd
+// and releases written from it, or with allWorktrees those of every
+// worktree of the repository. Outside git, the rows of deps.Pwd itself.
+// kind is storage.EmbeddingKindCommit, storage.EmbeddingKindRelease or
+// "" for both.
+func SearchScopeDocs(deps Deps, kind string, allWorktrees bool) ([]SearchDoc, error) {
+	var docs []SearchDoc
+	if err := deps.DB.ResolveWorkspaceIDs(git.WorkspaceKeys); err != nil {
+		return nil, err
+	}
+	worktree, repoID := git.WorkspaceKeys(deps.Pwd)
+	if kind != storage.EmbeddingKindRelease {
+		for _, status := range []string{"completed", "draft"} {
+			var commits []storage.Commit
+			var err error
+			switch {
+			case worktree == "":
+				commits, err = deps.DB.GetCommits(deps.Pwd, status)
+			case allWorktrees:
+				commits, err = deps.DB.GetCommitsByRepo(repoID, status)
+			default:
+				commits, err = deps.DB.GetCommitsByWorktree(worktree, status)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range commits {
+				docs = append(docs, CommitSearchDoc(c))
+			}
+		}
+	}
+	if kind != storage.EmbeddingKindCommit {
+		dirs := []string{deps.Pwd}
+		if allWorktrees && worktree != "" {
+			if paths, err := git.WorktreePaths(worktree); err == nil {
+				dirs = paths
+			}
+		}
+		for _, dir := range dirs {
+			releases, err := deps.DB.GetReleases(dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range releases {
+				docs = append(docs, ReleaseSearchDoc(r))
+			}
+		}
+	}
+	return docs, nil
+}
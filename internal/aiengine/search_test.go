@@ -0,0 +1,64 @@
+package aiengine
+
+import (
+	"context"
+	"testing"
+
+	"commit_craft_reborn/internal/embedding"
+	"commit_craft_reborn/internal/storage"
+)
+
+func TestRankSearchDocs(t *testing.T) {
+	docs := []SearchDoc{
+		CommitSearchDoc(storage.Commit{ID: 1, Type: "FIX", MessageEN: "Guard empty diff ranges\n\n- Return early when the range is empty"}),
+		CommitSearchDoc(storage.Commit{ID: 2, Type: "ADD", MessageEN: "Load BPE tokenizers from local files\n\n- Count tokens with the model vocabulary"}),
+		ReleaseSearchDoc(storage.Release{ID: 7, Version: "v1.2.0", Title: "Tokenizer support", Body: "Token counts use real vocabularies"}),
+		CommitSearchDoc(storage.Commit{ID: 3, Type: "DOC", KeyPoints: []string{"document the release hooks"}}),
+	}
+	h := embedding.Hash{}
+	vectors := map[searchKey][]float32{}
+	for _, d := range docs {
+		vectors[searchKey{d.Kind, d.ID}] = h.Vector(d.Text)
+	}
+	hits := RankSearchDocs(h.Vector("tokenizer vocabulary"), docs, vectors, 10)
+	if len(hits) != 2 || hits[0].ID != 2 || hits[1].ID != 7 {
+		t.Fatalf("hits = %+v, want commit 2 then release 7", hits)
+	}
+	if hits[1].Title != "v1.2.0 Tokenizer support" {
+		t.Errorf("release title = %q", hits[1].Title)
+	}
+	if docs[3].Title != "document the release hooks" {
+		t.Errorf("draft without message should be titled by its first key point, got %q", docs[3].Title)
+	}
+	if len(RankSearchDocs(h.Vector("tokenizer vocabulary"), docs, vectors, 1)) != 1 {
+		t.Error("limit not applied")
+	}
+}
+
+func TestIndexSearchDocsReusesVectors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := storage.InitDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	docs := []SearchDoc{
+		{Kind: storage.EmbeddingKindCommit, ID: 1, Text: "guard empty ranges"},
+		{Kind: storage.EmbeddingKindRelease, ID: 1, Text: "v1.0.0 first release"},
+	}
+	ctx := context.Background()
+	h := embedding.Hash{Dims: 64}
+	for i, want := range []int{2, 0} {
+		vectors, embedded, err := IndexSearchDocs(ctx, db, h, docs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if embedded != want || len(vectors) != 2 {
+			t.Errorf("run %d: embedded %d of %d vectors, want %d", i, embedded, len(vectors), want)
+		}
+	}
+	docs[0].Text = "guard empty diff ranges"
+	if _, embedded, _ := IndexSearchDocs(ctx, db, h, docs); embedded != 1 {
+		t.Errorf("edited doc: embedded %d, want 1", embedded)
+	}
+}
@@ -0,0 +1,474 @@
+package aiengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/commit"
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/scope"
+)
+
+// SplitFile is one staged path with its status letter (A, M, D, T) and
+// `+N -M` counts; binary files carry -1 for both.
+type SplitFile struct {
+	Path   string
+	Status string
+	Adds   int
+	Dels   int
+}
+
+// SplitGroup is one suggested commit: the files it stages plus the
+// tag/scope/key points the regular pipeline is seeded with.
+type SplitGroup struct {
+	Files     []string
+	Tag       string
+	Scope     string
+	KeyPoints []string
+}
+
+// SplitPlan is the split planner's proposal. Source is "ai" when the
+// planner stage answered, "heuristic" when the deterministic clustering
+// was used as is (single file, failed call or unparseable answer;
+// Warning says why) and "plan" when the caller supplied the groups.
+type SplitPlan struct {
+	Groups  []SplitGroup
+	Source  string
+	Warning string
+	Model   string
+	Stats   *api.CallStats
+}
+
+const (
+	SplitSourceAI        = "ai"
+	SplitSourceHeuristic = "heuristic"
+	SplitSourcePlan      = "plan"
+)
+
+// StagedSplitFiles reads the staged file list of the current directory
+// from GetGitDiffNameStatus and GetStagedNumstat, sorted by path.
+func StagedSplitFiles() ([]SplitFile, error) {
+	status, err := git.GetGitDiffNameStatus()
+	if err != nil {
+		return nil, err
+	}
+	numstat, err := git.GetStagedNumstat()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]SplitFile, 0, len(status))
+	for p, st := range status {
+		ns := numstat[p]
+		files = append(files, SplitFile{Path: p, Status: st, Adds: ns.Adds, Dels: ns.Dels})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// manifestFiles are dependency and build manifests; they cluster
+// together regardless of the directory they live in.
+var manifestFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.toml": true, "Cargo.lock": true, "requirements.txt": true, "poetry.lock": true,
+	"pyproject.toml": true, "Gemfile": true, "Gemfile.lock": true,
+	"composer.json": true, "composer.lock": true,
+}
+
+// splitClusterKey assigns path to its candidate group.
+func splitClusterKey(p string) string {
+	base := path.Base(p)
+	dir := path.Dir(p)
+	segs := strings.Split(dir, "/")
+	switch {
+	case manifestFiles[base]:
+		return "deps"
+	case segs[0] == ".github" || segs[0] == ".gitlab" || base == ".gitlab-ci.yml":
+		return "ci"
+	case segs[0] == "docs" || segs[0] == "doc" || strings.EqualFold(path.Ext(base), ".md"):
+		return "docs"
+	case dir == ".":
+		return "root"
+	case scope.ContainerDirs[segs[0]] && len(segs) > 1:
+		return segs[0] + "/" + segs[1]
+	}
+	return segs[0]
+}
+
+// ClusterSplitFiles groups files deterministically — by module
+// directory, with docs, CI and dependency manifests split out — and
+// fills every group with a heuristic tag, scope and key points picked
+// from types. Groups keep the order their first file appears in.
+func ClusterSplitFiles(files []SplitFile, types []commit.CommitType) []SplitGroup {
+	var keys []string
+	byKey := map[string][]SplitFile{}
+	for _, f := range files {
+		k := splitClusterKey(f.Path)
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], f)
+	}
+	groups := make([]SplitGroup, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, heuristicSplitGroup(k, byKey[k], types))
+	}
+	return groups
+}
+
+func heuristicSplitGroup(key string, files []SplitFile, types []commit.CommitType) SplitGroup {
+	g := SplitGroup{Scope: path.Base(key)}
+	if key == "root" {
+		g.Scope = strings.TrimSuffix(path.Base(files[0].Path), path.Ext(files[0].Path))
+	}
+	allAdded, allDeleted, allTests := true, true, true
+	for _, f := range files {
+		g.Files = append(g.Files, f.Path)
+		allAdded = allAdded && f.Status == "A"
+		allDeleted = allDeleted && f.Status == "D"
+		allTests = allTests && isTestPath(f.Path)
+	}
+	switch {
+	case key == "docs":
+		g.Tag = pickSplitTag(types, "DOC", "DOCS")
+	case key == "ci":
+		g.Tag = pickSplitTag(types, "CI", "CHORE")
+	case key == "deps":
+		g.Tag = pickSplitTag(types, "BUILD", "CHORE")
+	case allTests:
+		g.Tag = pickSplitTag(types, "TEST")
+	case allAdded:
+		g.Tag = pickSplitTag(types, "ADD", "FEAT")
+	case allDeleted:
+		g.Tag = pickSplitTag(types, "REM", "REMOVE")
+	default:
+		g.Tag = pickSplitTag(types, "IMP", "REF", "FEAT", "CHORE")
+	}
+	g.KeyPoints = heuristicKeyPoints(files)
+	return g
+}
+
+func isTestPath(p string) bool {
+	base := path.Base(p)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(p, "test/") || strings.HasPrefix(p, "tests/") ||
+		strings.Contains(p, "/testdata/")
+}
+
+// pickSplitTag returns the first preferred tag present in types, in the
+// spelling the config uses, or the first configured tag.
+func pickSplitTag(types []commit.CommitType, preferred ...string) string {
+	if tag, ok := findTag(types, preferred...); ok {
+		return tag
+	}
+	if len(types) > 0 {
+		return types[0].Tag
+	}
+	return preferred[0]
+}
+
+// findTag returns the first preferred tag present in types, in the
+// spelling the config uses.
+func findTag(types []commit.CommitType, preferred ...string) (string, bool) {
+	for _, want := range preferred {
+		for _, t := range types {
+			if strings.EqualFold(t.Tag, want) {
+				return t.Tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// heuristicKeyPoints lists the first files of a group as key points so
+// the change analyzer has something to anchor on.
+func heuristicKeyPoints(files []SplitFile) []string {
+	const maxPoints = 4
+	var points []string
+	for i, f := range files {
+		if i == maxPoints {
+			points = append(points, fmt.Sprintf("Touch %d more files", len(files)-maxPoints))
+			break
+		}
+		verb := "Update"
+		switch f.Status {
+		case "A":
+			verb = "Add"
+		case "D":
+			verb = "Remove"
+		}
+		points = append(points, verb+" "+f.Path)
+	}
+	return points
+}
+
+// splitPlannerOutput mirrors the JSON contract documented in
+// prompts/split_planner.prompt.tmpl.
+type splitPlannerOutput struct {
+	Groups []struct {
+		Files     []string `json:"files"`
+		Tag       string   `json:"tag"`
+		Scope     string   `json:"scope"`
+		KeyPoints []string `json:"keypoints"`
+	} `json:"groups"`
+}
+
+// PlanSplit runs the split planner stage: the deterministic clusters,
+// the staged diff and the allowed tags go to the planner prompt, which
+// may merge clusters and proposes tag/scope/key points per group. The
+// answer is validated against the staged files; anything it drops is
+// kept in its heuristic group. Best-effort like the changelog refiner:
+// a failed call or unparseable answer falls back to the clusters and
+// only a cancelled ctx is returned as an error.
+func PlanSplit(
+	ctx context.Context,
+	deps Deps,
+	files []SplitFile,
+	diff string,
+	types []commit.CommitType,
+) (SplitPlan, error) {
+	if len(files) == 0 {
+		return SplitPlan{}, fmt.Errorf("no staged changes to split")
+	}
+	clusters := ClusterSplitFiles(files, types)
+	plan := SplitPlan{Groups: clusters, Source: SplitSourceHeuristic}
+	if len(files) == 1 {
+		return plan, nil
+	}
+
+	pc := deps.Cfg.Prompts
+	if pc.SplitPlannerPrompt == "" {
+		plan.Warning = "split planner prompt is empty; using the directory clusters"
+		return plan, nil
+	}
+	response, stats, err := SendIaMessage(
+		ctx,
+		deps,
+		config.StageSplitPlanner,
+		pc.SplitPlannerPrompt,
+		splitPlannerInput(clusters, files, diff, types),
+		pc.SplitPlannerPromptModel,
+	)
+	plan.Stats = stats
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return plan, ctxErr
+		}
+		if deps.Log != nil {
+			deps.Log.Warn("Split planner call failed, using clusters", "error", err)
+		}
+		plan.Warning = fmt.Sprintf("split planner failed (%v); using the directory clusters", err)
+		return plan, nil
+	}
+	plan.Model = pc.SplitPlannerPromptModel
+
+	parsed, err := parseSplitPlannerJSON(response)
+	if err != nil {
+		if deps.Log != nil {
+			deps.Log.Warn("Split planner JSON parse failed, using clusters", "error", err)
+		}
+		plan.Warning = fmt.Sprintf("split planner answer unusable (%v); using the directory clusters", err)
+		return plan, nil
+	}
+	plan.Groups = reconcileSplitGroups(parsed, clusters, files, types)
+	plan.Source = SplitSourceAI
+	if deps.Log != nil {
+		deps.Log.Debug("Split planner output", "groups", len(plan.Groups))
+	}
+	return plan, nil
+}
+
+func splitPlannerInput(
+	clusters []SplitGroup,
+	files []SplitFile,
+	diff string,
+	types []commit.CommitType,
+) string {
+	byPath := make(map[string]SplitFile, len(files))
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+	var b strings.Builder
+	b.WriteString("ALLOWED_TAGS:\n")
+	for _, t := range types {
+		fmt.Fprintf(&b, "%s: %s\n", t.Tag, t.Description)
+	}
+	b.WriteString("GROUPS:\n")
+	for i, g := range clusters {
+		fmt.Fprintf(&b, "## group %d\n", i+1)
+		for _, p := range g.Files {
+			f := byPath[p]
+			if f.Adds < 0 {
+				fmt.Fprintf(&b, "%s %s (binary)\n", f.Status, p)
+				continue
+			}
+			fmt.Fprintf(&b, "%s %s (+%d -%d)\n", f.Status, p, f.Adds, f.Dels)
+		}
+	}
+	b.WriteString("DIFF:\n")
+	b.WriteString(diff)
+	return b.String()
+}
+
+// parseSplitPlannerJSON extracts the planner's JSON payload, tolerating
+// prose or a markdown code fence around it.
+func parseSplitPlannerJSON(raw string) (splitPlannerOutput, error) {
+	var out splitPlannerOutput
+	trimmed := strings.TrimSpace(raw)
+	if i := strings.Index(trimmed, "{"); i >= 0 {
+		if j := strings.LastIndex(trimmed, "}"); j > i {
+			trimmed = trimmed[i : j+1]
+		}
+	}
+	if err := json.Unmarshal([]byte(trimmed), &out); err != nil {
+		return out, err
+	}
+	if len(out.Groups) == 0 {
+		return out, errors.New("missing groups field")
+	}
+	return out, nil
+}
+
+// reconcileSplitGroups turns the planner's answer into a valid plan:
+// unknown or repeated paths are dropped, unknown tags and empty fields
+// take the heuristic value of the group's first file, and staged files
+// the planner left out stay in their heuristic clusters, appended last.
+func reconcileSplitGroups(
+	parsed splitPlannerOutput,
+	clusters []SplitGroup,
+	files []SplitFile,
+	types []commit.CommitType,
+) []SplitGroup {
+	clusterOf := map[string]int{}
+	for i, g := range clusters {
+		for _, p := range g.Files {
+			clusterOf[p] = i
+		}
+	}
+	placed := map[string]bool{}
+	var groups []SplitGroup
+	for _, pg := range parsed.Groups {
+		var g SplitGroup
+		for _, p := range pg.Files {
+			p = strings.TrimSpace(p)
+			if _, staged := clusterOf[p]; !staged || placed[p] {
+				continue
+			}
+			placed[p] = true
+			g.Files = append(g.Files, p)
+		}
+		if len(g.Files) == 0 {
+			continue
+		}
+		fallback := clusters[clusterOf[g.Files[0]]]
+		g.Tag = fallback.Tag
+		for _, t := range types {
+			if strings.EqualFold(t.Tag, strings.TrimSpace(pg.Tag)) {
+				g.Tag = t.Tag
+				break
+			}
+		}
+		g.Scope = strings.ToLower(strings.Join(strings.Fields(pg.Scope), "-"))
+		if g.Scope == "" {
+			g.Scope = fallback.Scope
+		}
+		for _, kp := range pg.KeyPoints {
+			if kp = strings.TrimSpace(kp); kp != "" {
+				g.KeyPoints = append(g.KeyPoints, kp)
+			}
+		}
+		if len(g.KeyPoints) == 0 {
+			g.KeyPoints = fallback.KeyPoints
+		}
+		groups = append(groups, g)
+	}
+
+	var left []SplitFile
+	for _, f := range files {
+		if !placed[f.Path] {
+			left = append(left, f)
+		}
+	}
+	return append(groups, ClusterSplitFiles(left, types)...)
+}
+
+// SplitGroupResult is handed to RunSplit's callback for every group:
+// the diff snapshot taken while only that group was staged and the
+// pipeline output generated from it.
+type SplitGroupResult struct {
+	Index  int
+	Group  SplitGroup
+	Diff   string
+	Output Output
+}
+
+// RunSplit accepts a plan: it stages each group in turn (as a patch of
+// what was staged, so partially staged files keep their hunks), runs
+// the regular pipeline seeded with the group's tag/scope/key points and
+// passes the result to onGroup, which persists the draft. The original
+// index is restored before returning, also on error or cancellation,
+// so every group is still staged for the commits that follow.
+func RunSplit(
+	ctx context.Context,
+	deps Deps,
+	plan SplitPlan,
+	changelogActive bool,
+	onGroup func(SplitGroupResult) error,
+) (err error) {
+	ws := deps.Pwd
+	full, err := git.StagedPatchAt(ws, nil)
+	if err != nil {
+		return fmt.Errorf("snapshot staged changes: %w", err)
+	}
+	patches := make([]string, len(plan.Groups))
+	for i, g := range plan.Groups {
+		if patches[i], err = git.StagedPatchAt(ws, g.Files); err != nil {
+			return fmt.Errorf("group %d: %w", i+1, err)
+		}
+	}
+	defer func() {
+		rerr := git.ResetIndexAt(ws)
+		if rerr == nil {
+			rerr = git.ApplyCachedPatchAt(ws, full)
+		}
+		if rerr != nil {
+			err = errors.Join(err, fmt.Errorf("restoring the staged changes: %w", rerr))
+		}
+	}()
+
+	for i, g := range plan.Groups {
+		if err := git.ResetIndexAt(ws); err != nil {
+			return fmt.Errorf("group %d: %w", i+1, err)
+		}
+		if err := git.ApplyCachedPatchAt(ws, patches[i]); err != nil {
+			return fmt.Errorf("group %d: staging: %w", i+1, err)
+		}
+		diff, err := git.GetStagedDiffSummaryAt(ws, deps.Cfg.Prompts.ChangeAnalyzerMaxDiffSize)
+		if err != nil {
+			return fmt.Errorf("group %d: %w", i+1, err)
+		}
+		out, err := Run(ctx, deps, Input{
+			KeyPoints:       g.KeyPoints,
+			Type:            g.Tag,
+			Scope:           g.Scope,
+			Diff:            diff,
+			LiveDiff:        true,
+			ChangelogActive: changelogActive,
+		})
+		if err != nil {
+			return fmt.Errorf("group %d: %w", i+1, err)
+		}
+		if err := onGroup(SplitGroupResult{Index: i, Group: g, Diff: diff, Output: out}); err != nil {
+			return fmt.Errorf("group %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package aiengine
+
+import (
+	"reflect"
+	"testing"
+
+	"commit_craft_reborn/internal/commit"
+)
+
+func TestClusterSplitFiles(t *testing.T) {
+	types := commit.GetDefaultCommitTypes()
+	files := []SplitFile{
+		{Path: "README.md", Status: "M"},
+		{Path: "go.mod", Status: "M"},
+		{Path: "internal/git/git.go", Status: "M"},
+		{Path: "internal/git/index.go", Status: "A"},
+		{Path: "internal/tui/view.go", Status: "M"},
+		{Path: "internal/tui/view_test.go", Status: "A"},
+		{Path: "main.go", Status: "M"},
+	}
+	got := ClusterSplitFiles(files, types)
+
+	want := []struct {
+		files []string
+		tag   string
+		scope string
+	}{
+		{[]string{"README.md"}, "DOC", "docs"},
+		{[]string{"go.mod"}, "BUILD", "deps"},
+		{[]string{"internal/git/git.go", "internal/git/index.go"}, "IMP", "git"},
+		{[]string{"internal/tui/view.go", "internal/tui/view_test.go"}, "IMP", "tui"},
+		{[]string{"main.go"}, "IMP", "main"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d groups, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if !reflect.DeepEqual(g.Files, w.files) || g.Tag != w.tag || g.Scope != w.scope {
+			t.Errorf("group %d = %v [%s] %s, want %v [%s] %s",
+				i, g.Files, g.Tag, g.Scope, w.files, w.tag, w.scope)
+		}
+		if len(g.KeyPoints) == 0 {
+			t.Errorf("group %d has no key points", i)
+		}
+	}
+}
+
+func TestReconcileSplitGroups(t *testing.T) {
+	types := commit.GetDefaultCommitTypes()
+	files := []SplitFile{
+		{Path: "docs/usage.md", Status: "M"},
+		{Path: "internal/git/git.go", Status: "M"},
+		{Path: "internal/git/git_test.go", Status: "M"},
+		{Path: "internal/tui/view.go", Status: "M"},
+	}
+	clusters := ClusterSplitFiles(files, types)
+	parsed, err := parseSplitPlannerJSON("```json\n" + `{"groups": [
+		{"files": ["internal/git/git.go", "docs/usage.md", "ghost.go"], "tag": "fix", "scope": "Git Index", "keypoints": ["Fix index reset"]},
+		{"files": ["internal/git/git.go"], "tag": "NOPE", "scope": "", "keypoints": []}
+	]}` + "\n```")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := reconcileSplitGroups(parsed, clusters, files, types)
+
+	if len(got) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(got), got)
+	}
+	first := got[0]
+	if !reflect.DeepEqual(first.Files, []string{"internal/git/git.go", "docs/usage.md"}) {
+		t.Errorf("first group files = %v (unknown path must be dropped)", first.Files)
+	}
+	if first.Tag != "FIX" || first.Scope != "git-index" {
+		t.Errorf("first group = [%s] %s, want [FIX] git-index", first.Tag, first.Scope)
+	}
+	// The second planner group only repeated a placed path, so it is
+	// dropped; the files the planner left out keep their clusters.
+	if !reflect.DeepEqual(got[1].Files, []string{"internal/git/git_test.go"}) ||
+		!reflect.DeepEqual(got[2].Files, []string{"internal/tui/view.go"}) {
+		t.Errorf("leftover groups = %v, %v", got[1].Files, got[2].Files)
+	}
+}
+
+func TestParseSplitPlannerJSONRejectsEmpty(t *testing.T) {
+	if _, err := parseSplitPlannerJSON(`{"groups": []}`); err == nil {
+		t.Error("expected an error for a plan without groups")
+	}
+	if _, err := parseSplitPlannerJSON("no json here"); err == nil {
+		t.Error("expected an error for prose")
+	}
+}
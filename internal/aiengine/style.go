@@ -0,0 +1,387 @@
+package aiengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"commit_craft_reborn/internal/commit"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// StyleShare is one entry of a vocabulary mined from history: a tag or
+// scope and the number of commits using it.
+type StyleShare struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// StyleProfile is the house style mined from a repository's recent
+// commits. Ratios run from 0 to 1; Capitalized and Lowercase split the
+// titles by their first character (neither when it is not a letter).
+// Title lengths measure the text after the tag and scope, which is what
+// the title stage writes. Bullet is the marker most bodies use ("" when
+// most bodies are prose) and BulletShare the fraction of bodies using it.
+type StyleProfile struct {
+	Commits        int          `json:"commits"`
+	Head           string       `json:"head,omitempty"`
+	Format         string       `json:"format"`
+	Tags           []StyleShare `json:"tags"`
+	Scopes         []StyleShare `json:"scopes"`
+	TitleMedian    int          `json:"title_median"`
+	TitleP90       int          `json:"title_p90"`
+	Capitalized    float64      `json:"capitalized"`
+	Lowercase      float64      `json:"lowercase"`
+	TrailingPeriod float64      `json:"trailing_period"`
+	WithBody       float64      `json:"with_body"`
+	Bullet         string       `json:"bullet"`
+	BulletShare    float64      `json:"bullet_share"`
+	Language       string       `json:"language,omitempty"`
+}
+
+// Header formats a StyleProfile can report; StyleFormatFree means most
+// subjects follow neither profile.
+const (
+	StyleFormatBracket      = "bracket"
+	StyleFormatConventional = "conventional"
+	StyleFormatFree         = "free"
+)
+
+const (
+	// minStyleCommits is the history below which a profile is too thin
+	// to steer the prompts; Guide returns "" for it.
+	minStyleCommits = 5
+	maxStyleTags    = 6
+	maxStyleScopes  = 12
+)
+
+var numberedBulletPattern = regexp.MustCompile(`^\d+[.)]\s`)
+
+// bulletMarker returns the list marker line starts with, or "".
+func bulletMarker(line string) string {
+	line = strings.TrimLeft(line, " \t")
+	for _, m := range []string{"- ", "* ", "• "} {
+		if strings.HasPrefix(line, m) {
+			return m
+		}
+	}
+	if numberedBulletPattern.MatchString(line) {
+		return "1. "
+	}
+	return ""
+}
+
+// BuildStyleProfile mines msgs (newest first, as `git log` lists them).
+func BuildStyleProfile(msgs []git.CommitMessage) StyleProfile {
+	p := StyleProfile{Commits: len(msgs), Format: StyleFormatFree}
+	if len(msgs) == 0 {
+		return p
+	}
+	tags := map[string]int{}
+	scopes := map[string]int{}
+	formats := map[string]int{}
+	bodyStyles := map[string]int{}
+	var lengths []int
+	var capitalized, lowercase, period, bodies int
+	var text strings.Builder
+	for _, m := range msgs {
+		desc := strings.TrimSpace(m.Subject)
+		if h, ok := commit.ParseHeader(m.Subject); ok {
+			tags[strings.ToUpper(h.Type)]++
+			if h.Scope != "" {
+				scopes[h.Scope]++
+			}
+			formats[string(h.Profile)]++
+			desc = strings.TrimSpace(h.Description)
+		}
+		if desc == "" {
+			continue
+		}
+		lengths = append(lengths, utf8.RuneCountInString(desc))
+		switch r, _ := utf8.DecodeRuneInString(desc); {
+		case unicode.IsUpper(r):
+			capitalized++
+		case unicode.IsLower(r):
+			lowercase++
+		}
+		if strings.HasSuffix(desc, ".") {
+			period++
+		}
+		text.WriteString(desc + "\n")
+		if body := styleBody(m.Body); body != "" {
+			bodies++
+			bodyStyles[dominantBullet(body)]++
+			text.WriteString(body + "\n")
+		}
+	}
+	if len(lengths) == 0 {
+		return p
+	}
+	sort.Ints(lengths)
+	p.TitleMedian = lengths[len(lengths)/2]
+	p.TitleP90 = lengths[(len(lengths)*9)/10]
+	p.Capitalized = ratio(capitalized, len(lengths))
+	p.Lowercase = ratio(lowercase, len(lengths))
+	p.TrailingPeriod = ratio(period, len(lengths))
+	p.WithBody = ratio(bodies, len(lengths))
+	p.Tags = topShares(tags, maxStyleTags)
+	p.Scopes = topShares(scopes, maxStyleScopes)
+	if top := topShares(formats, 1); len(top) == 1 && top[0].Count*2 > len(msgs) {
+		p.Format = top[0].Name
+	}
+	if top := topShares(bodyStyles, 1); len(top) == 1 {
+		p.Bullet = top[0].Name
+		p.BulletShare = ratio(top[0].Count, bodies)
+	}
+	p.Language = detectLanguage(text.String())
+	return p
+}
+
+// styleBody drops trailer lines (Signed-off-by, Co-authored-by, …) so
+// they don't count as body text.
+func styleBody(body string) string {
+	var kept []string
+	for _, line := range strings.Split(body, "\n") {
+		if _, ok := commit.ParseTrailer(line); ok {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// dominantBullet returns the marker most of body's list lines use, or ""
+// when the body has no list lines.
+func dominantBullet(body string) string {
+	counts := map[string]int{}
+	for _, line := range strings.Split(body, "\n") {
+		if m := bulletMarker(line); m != "" {
+			counts[m]++
+		}
+	}
+	if top := topShares(counts, 1); len(top) == 1 {
+		return top[0].Name
+	}
+	return ""
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// topShares sorts counts by count (ties by name) and keeps the first n.
+func topShares(counts map[string]int, n int) []StyleShare {
+	out := make([]StyleShare, 0, len(counts))
+	for name, c := range counts {
+		out = append(out, StyleShare{Name: name, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// styleStopwords are frequent function words per language. Counting them
+// is enough to tell the handful of languages commit logs are usually
+// written in apart.
+var styleStopwords = map[string][]string{
+	"English":    {"the", "and", "to", "of", "for", "in", "with", "is", "on", "from", "when", "it", "this", "be", "now"},
+	"Spanish":    {"el", "la", "los", "las", "del", "que", "para", "con", "por", "una", "se", "al", "cuando", "es", "y"},
+	"Portuguese": {"o", "os", "da", "do", "das", "dos", "para", "com", "uma", "não", "em", "ao", "quando", "é", "e"},
+	"French":     {"le", "les", "des", "du", "et", "pour", "dans", "une", "est", "au", "avec", "sur", "quand", "qui", "ne"},
+	"German":     {"der", "die", "das", "und", "mit", "für", "nicht", "ist", "den", "von", "im", "zu", "wenn", "auf", "ein"},
+}
+
+// detectLanguage names the language with the most stopword hits in
+// text, or "" when there are too few hits to tell.
+func detectLanguage(text string) string {
+	words := map[string]int{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		words[w]++
+	}
+	best, bestHits, total := "", 0, 0
+	for lang, stops := range styleStopwords {
+		hits := 0
+		for _, s := range stops {
+			hits += words[s]
+		}
+		total += hits
+		if hits > bestHits || (hits == bestHits && lang < best) {
+			best, bestHits = lang, hits
+		}
+	}
+	if bestHits < 10 || bestHits*2 < total {
+		return ""
+	}
+	return best
+}
+
+// Guide renders the profile as a compact block appended to the system
+// prompt of the given stages (StageBody, StageTitle or both for a
+// unified delegate prompt). Empty when the history is too thin to learn
+// from.
+func (p StyleProfile) Guide(stages ...StageID) string {
+	if p.Commits < minStyleCommits || p.TitleMedian == 0 {
+		return ""
+	}
+	var lines []string
+	for _, stage := range stages {
+		switch stage {
+		case StageTitle:
+			lines = append(lines, p.titleGuide()...)
+		case StageBody:
+			lines = append(lines, p.bodyGuide()...)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if p.Language != "" {
+		lines = append(lines, "Language: "+p.Language+".")
+	}
+	header := fmt.Sprintf("REPOSITORY STYLE (learned from the last %d commits", p.Commits)
+	if len(p.Tags) > 0 {
+		shares := make([]string, 0, 3)
+		for _, t := range p.Tags[:min(3, len(p.Tags))] {
+			shares = append(shares, fmt.Sprintf("%s %d%%", t.Name, int(ratio(t.Count, p.Commits)*100+0.5)))
+		}
+		header += "; most used tags " + strings.Join(shares, ", ")
+	}
+	header += "). Follow it where the rules above leave room:"
+	return "\n\n" + header + "\n- " + strings.Join(lines, "\n- ")
+}
+
+func (p StyleProfile) titleGuide() []string {
+	lines := []string{fmt.Sprintf(
+		"Title length: about %d characters, rarely over %d.", p.TitleMedian, p.TitleP90)}
+	switch {
+	case p.Capitalized >= 0.8:
+		lines = append(lines, "Start the title with an upper-case letter.")
+	case p.Lowercase >= 0.8:
+		lines = append(lines, "Start the title with a lower-case letter.")
+	}
+	switch {
+	case p.TrailingPeriod <= 0.2:
+		lines = append(lines, "No trailing period on the title.")
+	case p.TrailingPeriod >= 0.8:
+		lines = append(lines, "End the title with a period.")
+	}
+	if len(p.Scopes) > 0 {
+		names := make([]string, len(p.Scopes))
+		for i, s := range p.Scopes {
+			names[i] = s.Name
+		}
+		lines = append(lines, "Module names in use: "+strings.Join(names, ", ")+".")
+	}
+	return lines
+}
+
+func (p StyleProfile) bodyGuide() []string {
+	switch {
+	case p.WithBody < 0.2:
+		return []string{"Most commits have no body; keep it to a sentence or two."}
+	case p.Bullet != "" && p.BulletShare >= 0.5:
+		return []string{fmt.Sprintf("Body: bullet points starting with %q (%d%% of bodies).",
+			p.Bullet, int(p.BulletShare*100+0.5))}
+	case p.Bullet == "" && p.BulletShare >= 0.5:
+		return []string{"Body: prose paragraphs, no bullet points."}
+	}
+	return nil
+}
+
+// errNoStyleRepo is returned when Pwd is not inside a git repository:
+// there is no history to learn from.
+var errNoStyleRepo = errors.New("not inside a git repository")
+
+// RefreshStyleProfile mines the last [style].commits commits of the
+// repository containing deps.Pwd and stores the profile for it (shared
+// by every worktree of the repository).
+func RefreshStyleProfile(deps Deps) (StyleProfile, error) {
+	if deps.DB == nil {
+		return StyleProfile{}, errors.New("style profile needs a database")
+	}
+	id, err := git.ResolveWorkspace(deps.Pwd)
+	if err != nil {
+		return StyleProfile{}, errNoStyleRepo
+	}
+	n := deps.Cfg.Style.Commits
+	if n <= 0 {
+		n = 200
+	}
+	msgs, err := git.RecentCommitMessages(id.Worktree, n)
+	if err != nil {
+		return StyleProfile{}, err
+	}
+	p := BuildStyleProfile(msgs)
+	if head, err := git.ResolveCommitHashAt(id.Worktree, "HEAD"); err == nil {
+		p.Head = head
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return p, err
+	}
+	row := storage.StyleProfile{RepoID: id.RepoID, Profile: string(raw), Commits: p.Commits, Head: p.Head}
+	if err := deps.DB.SaveStyleProfile(&row); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// LoadStyleProfile returns the stored profile of the repository
+// containing deps.Pwd. found is false when it has none yet.
+func LoadStyleProfile(deps Deps) (p StyleProfile, found bool, err error) {
+	if deps.DB == nil {
+		return p, false, nil
+	}
+	id, err := git.ResolveWorkspace(deps.Pwd)
+	if err != nil {
+		return p, false, errNoStyleRepo
+	}
+	row, found, err := deps.DB.GetStyleProfile(id.RepoID)
+	if err != nil || !found {
+		return p, false, err
+	}
+	if err := json.Unmarshal([]byte(row.Profile), &p); err != nil {
+		return p, false, fmt.Errorf("decode style profile: %w", err)
+	}
+	return p, true, nil
+}
+
+// styleGuidance is appended to the body and title system prompts after
+// profileGuidance when [style].enabled is on; stages picks the Guide
+// sections. The profile is mined on
+// first use; afterwards it only changes on `ai style refresh`. Outside
+// git, without a database or on any error the prompts stay unchanged.
+func styleGuidance(deps Deps, stages ...StageID) string {
+	if !deps.Cfg.Style.Enabled || deps.DB == nil {
+		return ""
+	}
+	p, found, err := LoadStyleProfile(deps)
+	if err == nil && !found {
+		p, err = RefreshStyleProfile(deps)
+	}
+	if err != nil {
+		if deps.Log != nil && !errors.Is(err, errNoStyleRepo) {
+			deps.Log.Debug("style profile unavailable", "error", err)
+		}
+		return ""
+	}
+	return p.Guide(stages...)
+}
@@ -0,0 +1,203 @@
+package aiengine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/git"
+)
+
+func TestBuildStyleProfile(t *testing.T) {
+	msgs := []git.CommitMessage{
+		{Subject: "[FIX] tui: Guard the popup against empty lists", Body: "- Check the list length\n- Add a fallback row\n\nSigned-off-by: A <a@b.c>"},
+		{Subject: "[IMP] aiengine: Cache the profile for the current repository", Body: "- Load it once per run"},
+		{Subject: "[FIX] tui: Keep the cursor when the filter is cleared"},
+		{Subject: "[ADD] git: Read recent commit messages from the log", Body: "The log is read with one git call."},
+		{Subject: "Merge branch 'main' of the upstream repository"},
+	}
+	p := BuildStyleProfile(msgs)
+	if p.Commits != 5 || p.Format != StyleFormatBracket {
+		t.Errorf("commits/format = %d %q, want 5 %q", p.Commits, p.Format, StyleFormatBracket)
+	}
+	if len(p.Tags) == 0 || p.Tags[0] != (StyleShare{Name: "FIX", Count: 2}) {
+		t.Errorf("tags = %+v, want FIX first with 2", p.Tags)
+	}
+	if len(p.Scopes) == 0 || p.Scopes[0] != (StyleShare{Name: "tui", Count: 2}) {
+		t.Errorf("scopes = %+v, want tui first with 2", p.Scopes)
+	}
+	if p.Capitalized != 1 || p.TrailingPeriod != 0 {
+		t.Errorf("capitalized/period = %.2f %.2f, want 1 0", p.Capitalized, p.TrailingPeriod)
+	}
+	if p.Bullet != "- " || p.BulletShare < 0.6 || p.WithBody != 0.6 {
+		t.Errorf("bullet = %q %.2f, with body %.2f", p.Bullet, p.BulletShare, p.WithBody)
+	}
+	if p.TitleMedian == 0 || p.TitleP90 < p.TitleMedian {
+		t.Errorf("title lengths = %d / %d", p.TitleMedian, p.TitleP90)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	es := strings.Repeat("Corrige el error de la lista cuando el filtro está vacío y se borra para los usuarios\n", 3)
+	if got := detectLanguage(es); got != "Spanish" {
+		t.Errorf("Spanish text = %q", got)
+	}
+	en := strings.Repeat("Fix the crash in the list when the filter is empty and it is cleared for the user\n", 3)
+	if got := detectLanguage(en); got != "English" {
+		t.Errorf("English text = %q", got)
+	}
+	if got := detectLanguage("Bump deps"); got != "" {
+		t.Errorf("too little text = %q, want empty", got)
+	}
+}
+
+func TestStyleProfileGuide(t *testing.T) {
+	p := StyleProfile{
+		Commits:     40,
+		Tags:        []StyleShare{{Name: "IMP", Count: 20}},
+		Scopes:      []StyleShare{{Name: "tui", Count: 12}, {Name: "git", Count: 8}},
+		TitleMedian: 48, TitleP90: 64,
+		Lowercase: 0.9, WithBody: 0.8, Bullet: "- ", BulletShare: 0.75,
+		Language: "English",
+	}
+	title := p.Guide(StageTitle)
+	for _, want := range []string{"last 40 commits", "IMP 50%", "about 48 characters", "lower-case", "tui, git", "Language: English"} {
+		if !strings.Contains(title, want) {
+			t.Errorf("title guide missing %q:\n%s", want, title)
+		}
+	}
+	body := p.Guide(StageBody)
+	if !strings.Contains(body, `starting with "- " (75% of bodies)`) || strings.Contains(body, "Title length") {
+		t.Errorf("body guide:\n%s", body)
+	}
+	if both := p.Guide(StageBody, StageTitle); strings.Count(both, "REPOSITORY STYLE") != 1 {
+		t.Errorf("unified guide should carry one header:\n%s", both)
+	}
+	if thin := (StyleProfile{Commits: 2, TitleMedian: 30}).Guide(StageTitle); thin != "" {
+		t.Errorf("thin history produced a guide: %q", thin)
+	}
+}
+
+func TestBuildStyleProfileFormat(t *testing.T) {
+	subjects := func(s ...string) []git.CommitMessage {
+		out := make([]git.CommitMessage, len(s))
+		for i, v := range s {
+			out[i] = git.CommitMessage{Subject: v}
+		}
+		return out
+	}
+	cases := []struct {
+		name   string
+		msgs   []git.CommitMessage
+		format string
+		tags   []StyleShare
+		scopes []StyleShare
+	}{
+		{"empty", nil, StyleFormatFree, nil, nil},
+		{
+			"conventional",
+			subjects("feat(tui): add a popup", "fix: guard nil", "fix(git)!: drop the old flag"),
+			StyleFormatConventional,
+			[]StyleShare{{Name: "FIX", Count: 2}, {Name: "FEAT", Count: 1}},
+			[]StyleShare{{Name: "git", Count: 1}, {Name: "tui", Count: 1}},
+		},
+		{
+			"bracket",
+			subjects("[ADD] scope: Add scopes", "[FIX] Guard nil", "Update README"),
+			StyleFormatBracket,
+			[]StyleShare{{Name: "ADD", Count: 1}, {Name: "FIX", Count: 1}},
+			[]StyleShare{{Name: "scope", Count: 1}},
+		},
+		{
+			"no majority is free",
+			subjects("[FIX] Guard nil", "feat: add a popup", "Update README", "Bump deps"),
+			StyleFormatFree,
+			[]StyleShare{{Name: "FEAT", Count: 1}, {Name: "FIX", Count: 1}},
+			[]StyleShare{},
+		},
+		{"free text", subjects("Update README", "Bump deps"), StyleFormatFree, []StyleShare{}, []StyleShare{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := BuildStyleProfile(c.msgs)
+			if p.Format != c.format || p.Commits != len(c.msgs) {
+				t.Errorf("format/commits = %q %d, want %q %d", p.Format, p.Commits, c.format, len(c.msgs))
+			}
+			if !reflect.DeepEqual(p.Tags, c.tags) {
+				t.Errorf("tags = %+v, want %+v", p.Tags, c.tags)
+			}
+			if !reflect.DeepEqual(p.Scopes, c.scopes) {
+				t.Errorf("scopes = %+v, want %+v", p.Scopes, c.scopes)
+			}
+		})
+	}
+}
+
+func TestBuildStyleProfileTitles(t *testing.T) {
+	msgs := []git.CommitMessage{
+		{Subject: "fix: guard nil."},
+		{Subject: "fix: Keep the cursor"},
+		{Subject: "fix: 2 fewer calls"},
+		{Subject: "  "},
+	}
+	p := BuildStyleProfile(msgs)
+	// The blank subject is skipped, so ratios are over three titles.
+	if p.Capitalized != 1.0/3 || p.Lowercase != 1.0/3 || p.TrailingPeriod != 1.0/3 {
+		t.Errorf("capitalized/lowercase/period = %.2f %.2f %.2f, want a third each", p.Capitalized, p.Lowercase, p.TrailingPeriod)
+	}
+	if p.TitleMedian != len("2 fewer calls") || p.TitleP90 != len("Keep the cursor") {
+		t.Errorf("title median/p90 = %d %d", p.TitleMedian, p.TitleP90)
+	}
+	if p.WithBody != 0 || p.Bullet != "" {
+		t.Errorf("bodiless history: with body %.2f, bullet %q", p.WithBody, p.Bullet)
+	}
+}
+
+func TestBulletMarker(t *testing.T) {
+	cases := []struct {
+		line, want string
+	}{
+		{"- item", "- "},
+		{"  * item", "* "},
+		{"• item", "• "},
+		{"1. first", "1. "},
+		{"12) twelfth", "1. "},
+		{"-item", ""},
+		{"2024 was a year", ""},
+		{"plain prose", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := bulletMarker(c.line); got != c.want {
+			t.Errorf("bulletMarker(%q) = %q, want %q", c.line, got, c.want)
+		}
+	}
+}
+
+func TestStyleBody(t *testing.T) {
+	cases := []struct {
+		name, body, want, bullet string
+	}{
+		{"trailers only", "Signed-off-by: A <a@b.c>\nCo-authored-by: B <b@c.d>", "", ""},
+		{"prose", "Reads the log once.\n\nRefs: #12", "Reads the log once.", ""},
+		{"mixed markers", "* one\n- two\n- three", "* one\n- two\n- three", "- "},
+		{"numbered", "1. one\n2. two\nSigned-off-by: A <a@b.c>", "1. one\n2. two", "1. "},
+	}
+	for _, c := range cases {
+		got := styleBody(c.body)
+		if got != c.want {
+			t.Errorf("%s: styleBody = %q, want %q", c.name, got, c.want)
+		}
+		if b := dominantBullet(got); b != c.bullet {
+			t.Errorf("%s: dominantBullet = %q, want %q", c.name, b, c.bullet)
+		}
+	}
+}
+
+func TestTopShares(t *testing.T) {
+	got := topShares(map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}, 3)
+	want := []StyleShare{{Name: "c", Count: 5}, {Name: "a", Count: 2}, {Name: "b", Count: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("topShares = %+v, want %+v", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package aiengine
 import (
 	"regexp"
 	"strings"
+
+	"commit_craft_reborn/internal/commit"
 )
 
 // VerifyFinding is a single rule violation found in a draft's
@@ -86,25 +88,52 @@ var genericTitleVerbs = map[string]bool{
 	"modify": true, "cleanup": true, "delete": true, "rename": true,
 }
 
+// conventionalTitlePattern is the Conventional Commits counterpart of
+// titleTagPattern: a type token followed by an optional `(scope)`, an
+// optional `!` and the `: ` separator.
+var conventionalTitlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*(\([^()]*\))?!?: \S`)
+
+// conventionalEmptyScopePattern matches a `()` scope with nothing in it.
+// Leaving the scope out is fine (`feat: ...`); an empty pair is not.
+var conventionalEmptyScopePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*\(\s*\)`)
+
 // VerifyFinalMessage runs the deterministic rule set against a
 // composed final_message (the same text that would go into
 // `git commit`). Rules never call Groq and never read the diff — they
 // catch the kind of defects that show up in the message text itself.
+// It checks the default bracket profile; see VerifyFinalMessageFor.
 func VerifyFinalMessage(finalMessage string) VerifyReport {
+	return VerifyFinalMessageFor(commit.ProfileBracket, finalMessage)
+}
+
+// VerifyFinalMessageFor is VerifyFinalMessage under a message-format
+// profile. The conventional profile swaps the `[TAG] scope:` title rules
+// for `type(scope): ` ones and adds footer checks; every other rule is
+// shared.
+func VerifyFinalMessageFor(profile, finalMessage string) VerifyReport {
 	title, body := splitTitleBody(finalMessage)
+	conventional := commit.MessageFormat{Profile: profile}.IsConventional()
 
 	var findings []VerifyFinding
 
 	findings = appendIf(findings, checkEmptyTitle(title))
-	findings = appendIf(findings, checkTitleFormat(title)...)
+	if conventional {
+		findings = appendIf(findings, checkConventionalTitleFormat(title)...)
+	} else {
+		findings = appendIf(findings, checkTitleFormat(title)...)
+	}
 	findings = appendIf(findings, checkTitleLength(title)...)
-	findings = appendIf(findings, checkGenericTitle(title))
+	findings = appendIf(findings, checkGenericTitle(title, conventional))
 	findings = appendIf(findings, checkEmptyBody(title, body))
 	findings = appendIf(findings, checkTitleEqualsBody(title, body))
 	findings = appendIf(findings, checkCodeFence(title, body)...)
 	findings = appendIf(findings, checkAIResidue(title, body)...)
 	findings = appendIf(findings, checkTemplatePlaceholders(title, body)...)
 	findings = appendIf(findings, checkDuplicateLines(body)...)
+	findings = appendIf(findings, checkTrailers(body)...)
+	if conventional {
+		findings = appendIf(findings, checkConventionalFooters(body)...)
+	}
 
 	report := VerifyReport{Findings: findings}
 	for _, f := range findings {
@@ -198,16 +227,147 @@ func checkTitleLength(title string) []*VerifyFinding {
 	return nil
 }
 
+// checkConventionalTitleFormat enforces `type(scope): ` on the first
+// line, where `(scope)` is optional. A missing type is an error; an
+// empty `()` or an upper-case type (the spec allows it, most tooling
+// doesn't) only warns.
+func checkConventionalTitleFormat(title string) []*VerifyFinding {
+	if title == "" {
+		return nil
+	}
+	if !conventionalTitlePattern.MatchString(title) {
+		return []*VerifyFinding{{
+			Rule:     "title_format_missing_type",
+			Severity: severityError,
+			Message:  "Title does not start with `type(scope): ` (e.g. `feat(api): ...`).",
+			Location: "title",
+		}}
+	}
+	var out []*VerifyFinding
+	if conventionalEmptyScopePattern.MatchString(title) {
+		out = append(out, &VerifyFinding{
+			Rule:     "title_format_missing_scope",
+			Severity: severityWarning,
+			Message:  "Title has an empty `()` scope; fill it in or drop it.",
+			Location: "title",
+		})
+	}
+	if h, ok := commit.ParseHeader(title); ok && h.Type != strings.ToLower(h.Type) {
+		out = append(out, &VerifyFinding{
+			Rule:     "title_type_case",
+			Severity: severityWarning,
+			Message:  "Type `" + h.Type + "` is not lower-case.",
+			Location: "title",
+		})
+	}
+	return out
+}
+
+// checkConventionalFooters flags a lower-case `breaking change:` footer
+// in the trailer block as an error: release tooling only recognises the
+// upper-case token, so the break would ship unannounced. Syntax of the
+// other footers is checkTrailers' job.
+func checkConventionalFooters(body string) []*VerifyFinding {
+	block, offset, _ := lastParagraph(body)
+	for i, raw := range strings.Split(block, "\n") {
+		lower := strings.ToLower(strings.TrimSpace(raw))
+		if !strings.HasPrefix(lower, "breaking change:") && !strings.HasPrefix(lower, "breaking-change:") {
+			continue
+		}
+		if commit.HasBreakingChange(strings.TrimSpace(raw)) {
+			continue
+		}
+		return []*VerifyFinding{{
+			Rule:     "breaking_change_footer_case",
+			Severity: severityError,
+			Message:  "Breaking-change footer must be upper-case `BREAKING CHANGE: <description>`.",
+			Location: lineLoc(offset + i),
+		}}
+	}
+	return nil
+}
+
+// checkTrailers validates the trailer block — the body's last paragraph
+// when at least half of its lines are `Token: value`, which is roughly
+// where `git interpret-trailers` starts treating it as trailers. A body
+// that is a single paragraph only counts when every line is a trailer,
+// so prose opening with "Note: …" isn't mistaken for one. Inside
+// the block, a line that isn't a trailer (or an indented continuation)
+// warns because git stops parsing the block there; an identity trailer
+// (Co-authored-by, Signed-off-by, …) without `Name <email>` is an error
+// since GitHub and shortlog silently drop it.
+func checkTrailers(body string) []*VerifyFinding {
+	block, offset, only := lastParagraph(body)
+	if block == "" {
+		return nil
+	}
+	lines := strings.Split(block, "\n")
+	valid := 0
+	for _, line := range lines {
+		if _, ok := commit.ParseTrailer(line); ok {
+			valid++
+		}
+	}
+	if valid == 0 || valid*2 < len(lines) || (only && valid < len(lines)) {
+		return nil
+	}
+	var out []*VerifyFinding
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		t, ok := commit.ParseTrailer(line)
+		if !ok {
+			if lower := strings.ToLower(line); strings.HasPrefix(lower, "breaking change:") ||
+				strings.HasPrefix(lower, "breaking-change:") {
+				continue // reported by checkConventionalFooters
+			}
+			out = append(out, &VerifyFinding{
+				Rule:     "trailer_syntax",
+				Severity: severityWarning,
+				Message:  "Trailer line is not `Token: value`: " + truncate(strings.TrimSpace(line), 80),
+				Location: lineLoc(offset + i),
+			})
+			continue
+		}
+		if commit.IsIdentityTrailer(t.Token) && !commit.IsIdentityValue(t.Value) {
+			out = append(out, &VerifyFinding{
+				Rule:     "trailer_identity",
+				Severity: severityError,
+				Message:  t.Token + " needs a `Name <email>` value, got: " + truncate(t.Value, 60),
+				Location: lineLoc(offset + i),
+			})
+		}
+	}
+	return out
+}
+
+// lastParagraph returns the body's last blank-line-separated paragraph,
+// the zero-based body line it starts on, and whether it is the only
+// paragraph.
+func lastParagraph(body string) (string, int, bool) {
+	if body == "" {
+		return "", 0, false
+	}
+	paragraphs := strings.Split(body, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	return last, strings.Count(body, "\n") - strings.Count(last, "\n"), len(paragraphs) == 1
+}
+
 // checkGenericTitle warns when the title text (the portion after
-// `[TAG] scope: `) is ≤ 3 words and starts with a generic action verb.
-// Titles like "update docs" or "fix bug" carry near-zero information —
-// the model likely ignored the keypoints.
-func checkGenericTitle(title string) *VerifyFinding {
-	m := titleTextPattern.FindStringSubmatch(title)
-	if m == nil {
+// `[TAG] scope: ` or `type(scope): `) is ≤ 3 words and starts with a
+// generic action verb. Titles like "update docs" or "fix bug" carry
+// near-zero information — the model likely ignored the keypoints.
+func checkGenericTitle(title string, conventional bool) *VerifyFinding {
+	text := ""
+	if m := titleTextPattern.FindStringSubmatch(title); m != nil {
+		text = m[1]
+	} else if h, ok := commit.ParseHeader(title); conventional && ok && h.Profile == commit.ProfileConventional {
+		text = h.Description
+	} else {
 		return nil // malformed title already caught by titleFormat rule
 	}
-	words := strings.Fields(m[1])
+	words := strings.Fields(text)
 	if len(words) > 3 || len(words) == 0 {
 		return nil
 	}
@@ -217,7 +377,7 @@ func checkGenericTitle(title string) *VerifyFinding {
 	return &VerifyFinding{
 		Rule:     "generic_title",
 		Severity: severityWarning,
-		Message:  "Title text is likely too generic (\"" + m[1] + "\"). Add specifics about what changed.",
+		Message:  "Title text is likely too generic (\"" + text + "\"). Add specifics about what changed.",
 		Location: "title",
 	}
 }
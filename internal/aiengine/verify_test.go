@@ -135,6 +135,90 @@ func TestVerifyFinalMessage_GenericTitle_NotFlagged(t *testing.T) {
 	}
 }
 
+func TestVerifyFinalMessageFor_ConventionalClean(t *testing.T) {
+	msg := "feat(ai)!: introduce verify subcommand\n\nDocuments the deterministic AI residue check.\n\nBREAKING CHANGE: drops the old --check flag\nRefs: #42"
+	r := VerifyFinalMessageFor("conventional", msg)
+	if r.HasErrors || r.HasWarnings {
+		t.Fatalf("expected clean report, got %+v", r)
+	}
+}
+
+func TestVerifyFinalMessageFor_ConventionalMissingType(t *testing.T) {
+	r := VerifyFinalMessageFor("conventional", "[ADD] ai: introduce verify subcommand\n\nbody")
+	if !findRule(r, "title_format_missing_type") || !r.HasErrors {
+		t.Fatalf("expected title_format_missing_type error, got %+v", r.Findings)
+	}
+}
+
+func TestVerifyFinalMessageFor_ConventionalScope(t *testing.T) {
+	cases := []struct {
+		title      string
+		emptyScope bool
+		typeCase   bool
+	}{
+		{"feat: introduce the verify subcommand", false, false},
+		{"feat!: drop the old check flag entirely", false, false},
+		{"feat(): introduce the verify subcommand", true, false},
+		{"Feat: introduce the verify subcommand", false, true},
+	}
+	for _, c := range cases {
+		r := VerifyFinalMessageFor("conventional", c.title+"\n\nbody text here")
+		if findRule(r, "title_format_missing_scope") != c.emptyScope || findRule(r, "title_type_case") != c.typeCase {
+			t.Errorf("%q: findings %+v, want empty-scope %v and type-case %v", c.title, r.Findings, c.emptyScope, c.typeCase)
+		}
+		if r.HasErrors {
+			t.Errorf("%q: scope and type case should only warn, got %+v", c.title, r.Findings)
+		}
+	}
+}
+
+func TestVerifyFinalMessageFor_ConventionalGenericTitle(t *testing.T) {
+	r := VerifyFinalMessageFor("conventional", "docs(readme): update docs\n\nbody text here")
+	if !findRule(r, "generic_title") {
+		t.Fatalf("expected generic_title warning, got %+v", r.Findings)
+	}
+}
+
+func TestVerifyFinalMessageFor_ConventionalFooters(t *testing.T) {
+	msg := "feat(api): accept batch uploads\n\nAdds the batch endpoint.\n\nbreaking change: old endpoint removed\nRefs: #12"
+	r := VerifyFinalMessageFor("conventional", msg)
+	if !findRule(r, "breaking_change_footer_case") || !r.HasErrors {
+		t.Fatalf("expected breaking_change_footer_case error, got %+v", r.Findings)
+	}
+	if findRule(r, "trailer_syntax") {
+		t.Fatalf("breaking-change case should not double as trailer_syntax, got %+v", r.Findings)
+	}
+}
+
+func TestVerifyFinalMessage_Trailers(t *testing.T) {
+	msg := "[ADD] ai: accept batch uploads in the api\n\nAdds the batch endpoint.\n\n" +
+		"Co-authored-by: Ana Diaz <ana@example.com>\nRefs: #42\nSigned-off-by: Bob <bob@example.com>"
+	r := VerifyFinalMessage(msg)
+	if r.HasErrors || r.HasWarnings {
+		t.Fatalf("expected clean report, got %+v", r)
+	}
+}
+
+func TestVerifyFinalMessage_TrailerSyntax(t *testing.T) {
+	msg := "[ADD] ai: accept batch uploads in the api\n\nAdds the batch endpoint.\n\n" +
+		"Co-authored-by: ana\nRefs: #42\nReviewed by Bob"
+	r := VerifyFinalMessage(msg)
+	if !findRule(r, "trailer_identity") || !r.HasErrors {
+		t.Fatalf("expected trailer_identity error, got %+v", r.Findings)
+	}
+	if !findRule(r, "trailer_syntax") {
+		t.Fatalf("expected trailer_syntax warning, got %+v", r.Findings)
+	}
+}
+
+func TestVerifyFinalMessage_TrailerProseNotFlagged(t *testing.T) {
+	msg := "[ADD] ai: accept batch uploads in the api\n\nNote: the endpoint is new.\nIt replaces the loop in the client."
+	r := VerifyFinalMessage(msg)
+	if findRule(r, "trailer_syntax") {
+		t.Fatalf("single prose paragraph should not be read as trailers, got %+v", r.Findings)
+	}
+}
+
 func findRule(r VerifyReport, rule string) bool {
 	for _, f := range r.Findings {
 		if f.Rule == rule {
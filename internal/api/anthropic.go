@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultAnthropicBaseURL is the Anthropic Messages API root.
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	// anthropicVersion pins the Messages API revision we decode.
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens caps each completion. The Messages API makes
+	// max_tokens mandatory; commit messages and release notes never
+	// come close to this.
+	anthropicMaxTokens = 4096
+)
+
+// anthropicProvider speaks the Anthropic Messages API. System messages
+// are lifted into the top-level `system` field because the API rejects
+// them inside `messages`.
+type anthropicProvider struct {
+	baseURL string
+}
+
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// anthropicStreamEvent is the union of the SSE payloads we read from a
+// streamed /v1/messages call: message_start (id, model, input tokens),
+// content_block_delta (text), message_delta (output tokens) and error.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicModelsResponse struct {
+	Data []struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	} `json:"data"`
+}
+
+func (p *anthropicProvider) Name() string { return ProviderAnthropic }
+
+func (p *anthropicProvider) headers(apiKey string) map[string]string {
+	return map[string]string{
+		"x-api-key":         apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// ListModels fetches GET {base}/v1/models.
+func (p *anthropicProvider) ListModels(apiKey string) ([]GroqModel, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Anthropic API key was not provided")
+	}
+	body, header, status, err := doJSON(context.Background(), "GET", p.baseURL+"/v1/models", p.headers(apiKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, body, header)
+	}
+	var parsed anthropicModelsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	out := make([]GroqModel, 0, len(parsed.Data))
+	for _, m := range parsed.Data {
+		out = append(out, GroqModel{ID: m.ID, OwnedBy: ProviderAnthropic, Active: true})
+	}
+	return out, nil
+}
+
+// ChatCompletion posts to {base}/v1/messages.
+func (p *anthropicProvider) ChatCompletion(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+) (string, *CallStats, error) {
+	if err := validateChatArgs("Anthropic", apiKey, modelName, messages, true); err != nil {
+		return "", nil, err
+	}
+	req := newAnthropicRequest(modelName, messages)
+
+	started := time.Now()
+	body, header, status, err := doJSON(
+		ctx, "POST", p.baseURL+"/v1/messages", p.headers(apiKey), req,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != http.StatusOK {
+		return "", nil, statusError(status, body, header)
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	requestID := header.Get("request-id")
+	if requestID == "" {
+		requestID = resp.ID
+	}
+	// The Messages API reports no server-side timings, so TotalTime is
+	// the wall-clock round-trip.
+	stats := &CallStats{
+		Provider:         ProviderAnthropic,
+		Model:            resp.Model,
+		RequestID:        requestID,
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+		TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		TotalTime:        time.Since(started),
+		RateLimits:       p.ParseRateLimits(header),
+	}
+	if stats.Model == "" {
+		stats.Model = modelName
+	}
+
+	var text strings.Builder
+	for _, c := range resp.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", stats, fmt.Errorf(
+			"anthropic returned 200 OK but no text content (model=%s)",
+			stats.Model,
+		)
+	}
+	return text.String(), stats, nil
+}
+
+// newAnthropicRequest lifts system messages out of messages into the
+// top-level `system` field.
+func newAnthropicRequest(modelName string, messages []Message) anthropicRequest {
+	req := anthropicRequest{Model: modelName, MaxTokens: anthropicMaxTokens}
+	var system []string
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		req.Messages = append(req.Messages, m)
+	}
+	req.System = strings.Join(system, "\n\n")
+	return req
+}
+
+// ChatCompletionStream posts to {base}/v1/messages with `stream: true`
+// and forwards every text_delta to onChunk.
+func (p *anthropicProvider) ChatCompletionStream(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+	onChunk ChunkFunc,
+) (string, *CallStats, error) {
+	if err := validateChatArgs("Anthropic", apiKey, modelName, messages, true); err != nil {
+		return "", nil, err
+	}
+	req := newAnthropicRequest(modelName, messages)
+	req.Stream = true
+
+	started := time.Now()
+	resp, err := openStream(ctx, p.baseURL+"/v1/messages", p.headers(apiKey), req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	stats := &CallStats{
+		Provider:   ProviderAnthropic,
+		Model:      modelName,
+		RequestID:  resp.Header.Get("request-id"),
+		RateLimits: p.ParseRateLimits(resp.Header),
+	}
+	var text strings.Builder
+	err = readSSE(resp.Body, func(_, data string) error {
+		var ev anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("error decoding stream event: %w", err)
+		}
+		switch ev.Type {
+		case "message_start":
+			if ev.Message.Model != "" {
+				stats.Model = ev.Message.Model
+			}
+			if stats.RequestID == "" {
+				stats.RequestID = ev.Message.ID
+			}
+			stats.PromptTokens = ev.Message.Usage.InputTokens
+		case "content_block_delta":
+			if ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
+				text.WriteString(ev.Delta.Text)
+				if onChunk != nil {
+					onChunk(ev.Delta.Text)
+				}
+			}
+		case "message_delta":
+			stats.CompletionTokens = ev.Usage.OutputTokens
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			return fmt.Errorf("anthropic stream error (%s): %s", ev.Error.Type, ev.Error.Message)
+		}
+		return nil
+	})
+	stats.TotalTokens = stats.PromptTokens + stats.CompletionTokens
+	stats.TotalTime = time.Since(started)
+	if err != nil {
+		return "", stats, err
+	}
+	if text.Len() == 0 {
+		return "", stats, fmt.Errorf(
+			"anthropic stream ended without text content (model=%s)",
+			stats.Model,
+		)
+	}
+	return text.String(), stats, nil
+}
+
+// ParseRateLimits reads the anthropic-ratelimit-* headers. Reset values
+// are RFC 3339 timestamps, converted here into wait durations so they
+// line up with the Groq semantics of RateLimits.
+func (p *anthropicProvider) ParseRateLimits(h http.Header) RateLimits {
+	now := time.Now()
+	rl := RateLimits{CapturedAt: now}
+	atoi := func(name string) (int, bool) {
+		v := h.Get(name)
+		if v == "" {
+			return 0, false
+		}
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	reset := func(name string) time.Duration {
+		t, err := time.Parse(time.RFC3339, h.Get(name))
+		if err != nil || t.Before(now) {
+			return 0
+		}
+		return t.Sub(now)
+	}
+
+	var limitReqOK, remainingReqOK, limitTokOK, remainingTokOK bool
+	rl.LimitRequests, limitReqOK = atoi("anthropic-ratelimit-requests-limit")
+	rl.RemainingRequests, remainingReqOK = atoi("anthropic-ratelimit-requests-remaining")
+	rl.ResetRequests = reset("anthropic-ratelimit-requests-reset")
+	rl.LimitTokens, limitTokOK = atoi("anthropic-ratelimit-tokens-limit")
+	rl.RemainingTokens, remainingTokOK = atoi("anthropic-ratelimit-tokens-remaining")
+	rl.ResetTokens = reset("anthropic-ratelimit-tokens-reset")
+	rl.RequestsParsed = limitReqOK && remainingReqOK
+	rl.TokensParsed = limitTokOK && remainingTokOK
+	return rl
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// EmbeddingProvider is implemented by the backends that expose an
+// embeddings endpoint (OpenAI-compatible `/embeddings`, Ollama's
+// `/api/embed`). Embed returns one vector per input, in input order.
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, apiKey, modelName string, inputs []string) ([][]float32, error)
+}
+
+// Embed asks p for the embeddings of inputs, or fails when the backend
+// has no embeddings endpoint (Anthropic).
+func Embed(ctx context.Context, p Provider, apiKey, modelName string, inputs []string) ([][]float32, error) {
+	ep, ok := p.(EmbeddingProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q has no embeddings endpoint", p.Name())
+	}
+	if modelName == "" {
+		return nil, fmt.Errorf("embeddings model name was not provided")
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	return ep.Embed(ctx, apiKey, modelName, inputs)
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed posts to {base}/embeddings.
+func (p *openAICompatProvider) Embed(
+	ctx context.Context,
+	apiKey, modelName string,
+	inputs []string,
+) ([][]float32, error) {
+	if p.needsKey && apiKey == "" {
+		return nil, fmt.Errorf("%s API key was not provided", p.label)
+	}
+	body, header, status, err := doJSON(ctx, "POST", p.baseURL+"/embeddings", p.authHeaders(apiKey),
+		embeddingsRequest{Model: modelName, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, body, header)
+	}
+	var parsed openAIEmbeddingsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	out := make([][]float32, len(inputs))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(out) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, checkEmbeddings(out)
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// Embed posts to {base}/api/embed, which takes a batch of inputs.
+func (p *ollamaProvider) Embed(
+	ctx context.Context,
+	_ string,
+	modelName string,
+	inputs []string,
+) ([][]float32, error) {
+	body, header, status, err := doJSON(ctx, "POST", p.baseURL+"/api/embed", nil,
+		embeddingsRequest{Model: modelName, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, body, header)
+	}
+	var parsed ollamaEmbedResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	if len(parsed.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(parsed.Embeddings), len(inputs))
+	}
+	return parsed.Embeddings, checkEmbeddings(parsed.Embeddings)
+}
+
+// checkEmbeddings rejects a reply with a missing vector or vectors of
+// different lengths, which would make the index inconsistent.
+func checkEmbeddings(vectors [][]float32) error {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return fmt.Errorf("no embedding returned for input %d", i)
+		}
+		if len(v) != len(vectors[0]) {
+			return fmt.Errorf("embedding %d has %d dimensions, expected %d", i, len(v), len(vectors[0]))
+		}
+	}
+	return nil
+}
@@ -1,11 +1,8 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -13,10 +10,11 @@ import (
 	"time"
 )
 
-// ErrRateLimited is wrapped into the error returned by GetGroqChatCompletion
-// when Groq responds with HTTP 429. Callers detect it via errors.Is to give
-// the user a "swap your API key slot" hint instead of a generic API error.
-var ErrRateLimited = errors.New("groq rate limit (429)")
+// ErrRateLimited is wrapped into the error returned by every Provider's
+// ChatCompletion when the backend responds with HTTP 429. Callers detect it
+// via errors.Is to give the user a "swap your API key slot" hint instead of
+// a generic API error.
+var ErrRateLimited = errors.New("provider rate limit (429)")
 
 // defaultGroqBaseURL is the Groq OpenAI-compatible API root used when no
 // override is configured.
@@ -40,8 +38,16 @@ type Message struct {
 	Content string `json:"content"`
 }
 type RequestBody struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions asks an OpenAI-compatible server to append a final chunk
+// carrying the `usage` block to a streamed response.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type Choice struct {
@@ -94,8 +100,11 @@ type RateLimits struct {
 }
 
 // CallStats bundles every per-call metric we surface in the UI and
-// persist to the ai_calls table.
+// persist to the ai_calls table. Provider is the backend that served the
+// call ("groq", "openai", …) so telemetry stays attributable once stages
+// are spread across several providers.
 type CallStats struct {
+	Provider         string
 	Model            string
 	RequestID        string
 	PromptTokens     int
@@ -106,10 +115,38 @@ type CallStats struct {
 	CompletionTime   time.Duration
 	TotalTime        time.Duration
 	RateLimits       RateLimits
+	// Attempts lists the failed tries that preceded this successful call
+	// when a retry policy was in effect, oldest first. Empty when the
+	// first try succeeded.
+	Attempts []CallAttempt
+}
+
+// Attempt outcomes recorded on CallAttempt.Outcome.
+const (
+	OutcomeRateLimited = "rate_limited"
+	OutcomeServerError = "server_error"
+	OutcomeNetwork     = "network_error"
+	OutcomeTimeout     = "timeout"
+	OutcomeCancelled   = "cancelled"
+	OutcomeError       = "error"
+)
+
+// CallAttempt is one failed try of a stage call: which model / key slot
+// was used, why it failed, and how long the caller waited before the
+// next try. Stats is set when the backend answered at all.
+type CallAttempt struct {
+	Model   string
+	KeySlot string
+	Outcome string
+	Error   string
+	Wait    time.Duration
+	Stats   *CallStats
 }
 
 // GroqModel mirrors a single entry from GET /openai/v1/models. Only the
-// fields we currently surface are decoded; extra fields are ignored.
+// fields we currently surface are decoded; extra fields are ignored. The
+// other providers project their own catalogue entries onto this shape so
+// the model picker and the cache stay provider-agnostic.
 type GroqModel struct {
 	ID            string `json:"id"`
 	OwnedBy       string `json:"owned_by"`
@@ -124,141 +161,23 @@ type modelsListResponse struct {
 
 // ListGroqModels fetches the catalogue of models the API key can address.
 // The endpoint does not flag free-tier vs paid models; callers filter the
-// result via the curated allowlist in internal/config.
+// result via the curated allowlist in internal/config. Thin wrapper over
+// the Groq Provider kept for the model picker.
 func ListGroqModels(apiKey string) ([]GroqModel, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("Groq API key was not provided")
-	}
-
-	req, err := http.NewRequest("GET", groqBaseURL()+"/models", nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-
-	resp, err := (&http.Client{}).Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"API returned a non-success status: %d, %s",
-			resp.StatusCode, string(body),
-		)
-	}
-
-	var parsed modelsListResponse
-	if err := json.Unmarshal(body, &parsed); err != nil {
-		return nil, fmt.Errorf("error decoding response JSON: %w", err)
-	}
-	return parsed.Data, nil
+	return newGroqProvider("").ListModels(apiKey)
 }
 
 // GetGroqChatCompletion is a generic function to interact with the Groq Chat API.
 // It returns the assistant message content, the parsed call stats (tokens,
 // timing, rate-limit headers) and any error. Callers that don't need the
-// stats can ignore the second return value.
+// stats can ignore the second return value. Equivalent to calling
+// ChatCompletion on the Groq Provider; ctx cancels the request.
 func GetGroqChatCompletion(
+	ctx context.Context,
 	apiKey, modelName string,
 	messages []Message,
 ) (string, *CallStats, error) {
-	if apiKey == "" {
-		return "", nil, fmt.Errorf("Groq API key was not provided")
-	}
-	if modelName == "" {
-		return "", nil, fmt.Errorf("model name was not provided")
-	}
-	if len(messages) == 0 {
-		return "", nil, fmt.Errorf("at least one message is required")
-	}
-
-	requestData := RequestBody{
-		Model:    modelName,
-		Messages: messages,
-	}
-
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return "", nil, fmt.Errorf("error encoding JSON: %w", err)
-	}
-
-	url := groqBaseURL() + "/chat/completions"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", nil, fmt.Errorf("error creating request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", nil, fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return "", nil, fmt.Errorf(
-			"API returned 429: %s: %w", string(body), ErrRateLimited)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return "", nil, fmt.Errorf(
-			"API returned a non-success status: %d, %s",
-			resp.StatusCode,
-			string(body),
-		)
-	}
-
-	var responseBody ResponseBody
-	if err := json.Unmarshal(body, &responseBody); err != nil {
-		return "", nil, fmt.Errorf("error decoding response JSON: %w", err)
-	}
-
-	stats := &CallStats{
-		Model:            responseBody.Model,
-		RequestID:        resp.Header.Get("x-request-id"),
-		PromptTokens:     responseBody.Usage.PromptTokens,
-		CompletionTokens: responseBody.Usage.CompletionTokens,
-		TotalTokens:      responseBody.Usage.TotalTokens,
-		QueueTime:        secondsToDuration(responseBody.Usage.QueueTime),
-		PromptTime:       secondsToDuration(responseBody.Usage.PromptTime),
-		CompletionTime:   secondsToDuration(responseBody.Usage.CompletionTime),
-		TotalTime:        secondsToDuration(responseBody.Usage.TotalTime),
-		RateLimits:       parseRateLimitHeaders(resp.Header),
-	}
-	if stats.Model == "" {
-		stats.Model = modelName
-	}
-
-	if len(responseBody.Choices) > 0 && responseBody.Choices[0].Message.Content != "" {
-		return responseBody.Choices[0].Message.Content, stats, nil
-	}
-
-	// Groq occasionally returns 200 OK with an empty `choices` array (or a
-	// choice whose message.content is the empty string) — typically on
-	// long prompts or when an upstream filter trips. Surface that
-	// explicitly so the caller can show a "retry the stage" hint instead
-	// of treating it as a hard failure.
-	if len(responseBody.Choices) == 0 {
-		return "", stats, fmt.Errorf(
-			"groq returned 200 OK but no choices (empty response, model=%s)",
-			stats.Model,
-		)
-	}
-	return "", stats, fmt.Errorf(
-		"groq returned 200 OK but choice content was empty (model=%s)",
-		stats.Model,
-	)
+	return newGroqProvider("").ChatCompletion(ctx, apiKey, modelName, messages)
 }
 
 // secondsToDuration converts a Groq "seconds as float64" timing field into
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOllamaBaseURL is where a stock `ollama serve` listens.
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// ollamaProvider speaks Ollama's native `/api/chat` + `/api/tags`
+// endpoints. Ollama needs no API key and sends no rate-limit headers;
+// token counts and timings come from the eval counters in the response.
+type ollamaProvider struct {
+	baseURL string
+}
+
+type ollamaChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+}
+
+// ollamaChatResponse mirrors the non-streaming /api/chat reply. Duration
+// fields are nanoseconds.
+type ollamaChatResponse struct {
+	Model              string  `json:"model"`
+	Message            Message `json:"message"`
+	Done               bool    `json:"done"`
+	TotalDuration      int64   `json:"total_duration"`
+	LoadDuration       int64   `json:"load_duration"`
+	PromptEvalCount    int     `json:"prompt_eval_count"`
+	PromptEvalDuration int64   `json:"prompt_eval_duration"`
+	EvalCount          int     `json:"eval_count"`
+	EvalDuration       int64   `json:"eval_duration"`
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
+func (p *ollamaProvider) Name() string { return ProviderOllama }
+
+// ListModels fetches GET {base}/api/tags. Ollama doesn't report a
+// context window per tag, so ContextWindow stays 0 ("unknown").
+func (p *ollamaProvider) ListModels(_ string) ([]GroqModel, error) {
+	body, header, status, err := doJSON(context.Background(), "GET", p.baseURL+"/api/tags", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, body, header)
+	}
+	var parsed ollamaTagsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	out := make([]GroqModel, 0, len(parsed.Models))
+	for _, m := range parsed.Models {
+		id := m.Name
+		if id == "" {
+			id = m.Model
+		}
+		out = append(out, GroqModel{ID: id, OwnedBy: ProviderOllama, Active: true})
+	}
+	return out, nil
+}
+
+// ChatCompletion posts a non-streaming request to {base}/api/chat.
+func (p *ollamaProvider) ChatCompletion(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+) (string, *CallStats, error) {
+	if err := validateChatArgs("Ollama", apiKey, modelName, messages, false); err != nil {
+		return "", nil, err
+	}
+	body, header, status, err := doJSON(
+		ctx, "POST", p.baseURL+"/api/chat", nil,
+		ollamaChatRequest{Model: modelName, Messages: messages},
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != http.StatusOK {
+		return "", nil, statusError(status, body, header)
+	}
+
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	stats := p.callStats(resp, modelName, header)
+	if resp.Message.Content == "" {
+		return "", stats, fmt.Errorf(
+			"ollama returned 200 OK but message content was empty (model=%s)",
+			stats.Model,
+		)
+	}
+	return resp.Message.Content, stats, nil
+}
+
+// ChatCompletionStream posts to {base}/api/chat with `stream: true`.
+// Ollama answers with newline-delimited JSON objects rather than SSE;
+// each carries a message delta and the last one (done=true) carries the
+// eval counters.
+func (p *ollamaProvider) ChatCompletionStream(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+	onChunk ChunkFunc,
+) (string, *CallStats, error) {
+	if err := validateChatArgs("Ollama", apiKey, modelName, messages, false); err != nil {
+		return "", nil, err
+	}
+	resp, err := openStream(
+		ctx, p.baseURL+"/api/chat", nil,
+		ollamaChatRequest{Model: modelName, Messages: messages, Stream: true},
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var final ollamaChatResponse
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var part ollamaChatResponse
+		if err := dec.Decode(&part); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", p.callStats(final, modelName, resp.Header), fmt.Errorf(
+				"error decoding stream chunk: %w", err)
+		}
+		if part.Message.Content != "" {
+			text.WriteString(part.Message.Content)
+			if onChunk != nil {
+				onChunk(part.Message.Content)
+			}
+		}
+		if part.Done {
+			final = part
+			break
+		}
+	}
+	stats := p.callStats(final, modelName, resp.Header)
+	if text.Len() == 0 {
+		return "", stats, fmt.Errorf(
+			"ollama stream ended without content (model=%s)",
+			stats.Model,
+		)
+	}
+	return text.String(), stats, nil
+}
+
+// callStats projects the eval counters of a (final) /api/chat reply onto
+// CallStats.
+func (p *ollamaProvider) callStats(
+	resp ollamaChatResponse,
+	modelName string,
+	header http.Header,
+) *CallStats {
+	stats := &CallStats{
+		Provider:         ProviderOllama,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		QueueTime:        time.Duration(resp.LoadDuration),
+		PromptTime:       time.Duration(resp.PromptEvalDuration),
+		CompletionTime:   time.Duration(resp.EvalDuration),
+		TotalTime:        time.Duration(resp.TotalDuration),
+		RateLimits:       p.ParseRateLimits(header),
+	}
+	if stats.Model == "" {
+		stats.Model = modelName
+	}
+	return stats
+}
+
+// ParseRateLimits returns an empty snapshot: a local Ollama server has no
+// rate limits, and the zero Parsed flags make the bars render "no data".
+func (p *ollamaProvider) ParseRateLimits(_ http.Header) RateLimits {
+	return RateLimits{CapturedAt: time.Now()}
+}
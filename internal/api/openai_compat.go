@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultOpenAIBaseURL is the OpenAI API root used when the provider has
+// no base_url configured. Any OpenAI-compatible gateway (LiteLLM, vLLM,
+// OpenRouter, LM Studio, …) can be addressed by overriding it.
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAICompatProvider talks to any backend exposing the OpenAI
+// `/chat/completions` + `/models` contract. Groq is one of them, so the
+// Groq provider is just this type with Groq's name, base URL and
+// wording.
+type openAICompatProvider struct {
+	name     string
+	label    string
+	baseURL  string
+	needsKey bool
+}
+
+// newGroqProvider returns the Groq flavor of openAICompatProvider. An
+// empty baseURL resolves through groqBaseURL so the
+// COMMITCRAFT_GROQ_BASE_URL override keeps working.
+func newGroqProvider(baseURL string) *openAICompatProvider {
+	if baseURL == "" {
+		baseURL = groqBaseURL()
+	}
+	return &openAICompatProvider{
+		name:     ProviderGroq,
+		label:    "Groq",
+		baseURL:  baseURL,
+		needsKey: true,
+	}
+}
+
+func (p *openAICompatProvider) Name() string { return p.name }
+
+// authHeaders returns the bearer header when a key is present. Local
+// OpenAI-compatible servers usually accept anonymous calls, so an empty
+// key simply sends no Authorization header.
+func (p *openAICompatProvider) authHeaders(apiKey string) map[string]string {
+	if apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + apiKey}
+}
+
+// ListModels fetches GET {base}/models.
+func (p *openAICompatProvider) ListModels(apiKey string) ([]GroqModel, error) {
+	if p.needsKey && apiKey == "" {
+		return nil, fmt.Errorf("%s API key was not provided", p.label)
+	}
+	body, header, status, err := doJSON(context.Background(), "GET", p.baseURL+"/models", p.authHeaders(apiKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, body, header)
+	}
+
+	var parsed modelsListResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	// Plain OpenAI doesn't send `active`; treat every listed model as
+	// usable there. Groq does send it, so its value is kept as-is.
+	if p.name != ProviderGroq {
+		for i := range parsed.Data {
+			parsed.Data[i].Active = true
+		}
+	}
+	return parsed.Data, nil
+}
+
+// ChatCompletion posts to {base}/chat/completions.
+func (p *openAICompatProvider) ChatCompletion(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+) (string, *CallStats, error) {
+	if err := validateChatArgs(p.label, apiKey, modelName, messages, p.needsKey); err != nil {
+		return "", nil, err
+	}
+
+	requestData := RequestBody{
+		Model:    modelName,
+		Messages: messages,
+	}
+	body, header, status, err := doJSON(
+		ctx, "POST", p.baseURL+"/chat/completions", p.authHeaders(apiKey), requestData,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != http.StatusOK {
+		return "", nil, statusError(status, body, header)
+	}
+
+	var responseBody ResponseBody
+	if err := json.Unmarshal(body, &responseBody); err != nil {
+		return "", nil, fmt.Errorf("error decoding response JSON: %w", err)
+	}
+
+	stats := &CallStats{
+		Provider:         p.name,
+		Model:            responseBody.Model,
+		RequestID:        header.Get("x-request-id"),
+		PromptTokens:     responseBody.Usage.PromptTokens,
+		CompletionTokens: responseBody.Usage.CompletionTokens,
+		TotalTokens:      responseBody.Usage.TotalTokens,
+		QueueTime:        secondsToDuration(responseBody.Usage.QueueTime),
+		PromptTime:       secondsToDuration(responseBody.Usage.PromptTime),
+		CompletionTime:   secondsToDuration(responseBody.Usage.CompletionTime),
+		TotalTime:        secondsToDuration(responseBody.Usage.TotalTime),
+		RateLimits:       p.ParseRateLimits(header),
+	}
+	if stats.Model == "" {
+		stats.Model = modelName
+	}
+
+	if len(responseBody.Choices) > 0 && responseBody.Choices[0].Message.Content != "" {
+		return responseBody.Choices[0].Message.Content, stats, nil
+	}
+
+	// Groq occasionally returns 200 OK with an empty `choices` array (or a
+	// choice whose message.content is the empty string) — typically on
+	// long prompts or when an upstream filter trips. Surface that
+	// explicitly so the caller can show a "retry the stage" hint instead
+	// of treating it as a hard failure.
+	if len(responseBody.Choices) == 0 {
+		return "", stats, fmt.Errorf(
+			"%s returned 200 OK but no choices (empty response, model=%s)",
+			p.name, stats.Model,
+		)
+	}
+	return "", stats, fmt.Errorf(
+		"%s returned 200 OK but choice content was empty (model=%s)",
+		p.name, stats.Model,
+	)
+}
+
+// ParseRateLimits reads the x-ratelimit-* headers. Groq and OpenAI use
+// the same header names, so both flavors share parseRateLimitHeaders.
+func (p *openAICompatProvider) ParseRateLimits(h http.Header) RateLimits {
+	return parseRateLimitHeaders(h)
+}
+
+// streamChunk is one `data:` payload of a streamed chat completion. Groq
+// reports the final usage under `x_groq.usage`; OpenAI (with
+// stream_options.include_usage) sends it top-level on the last chunk.
+type streamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+	XGroq *struct {
+		ID    string `json:"id"`
+		Usage *Usage `json:"usage"`
+	} `json:"x_groq"`
+}
+
+// ChatCompletionStream posts to {base}/chat/completions with
+// `stream: true` and forwards every content delta to onChunk.
+func (p *openAICompatProvider) ChatCompletionStream(
+	ctx context.Context,
+	apiKey, modelName string,
+	messages []Message,
+	onChunk ChunkFunc,
+) (string, *CallStats, error) {
+	if err := validateChatArgs(p.label, apiKey, modelName, messages, p.needsKey); err != nil {
+		return "", nil, err
+	}
+	requestData := RequestBody{
+		Model:    modelName,
+		Messages: messages,
+		Stream:   true,
+	}
+	// Groq always reports usage on the last chunk; plain OpenAI only
+	// does when asked.
+	if p.name != ProviderGroq {
+		requestData.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	resp, err := openStream(ctx, p.baseURL+"/chat/completions", p.authHeaders(apiKey), requestData)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	stats := &CallStats{
+		Provider:   p.name,
+		Model:      modelName,
+		RequestID:  resp.Header.Get("x-request-id"),
+		RateLimits: p.ParseRateLimits(resp.Header),
+	}
+	var text strings.Builder
+	var usage *Usage
+	err = readSSE(resp.Body, func(_, data string) error {
+		if data == "[DONE]" {
+			return errStreamDone
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("error decoding stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			stats.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.XGroq != nil && chunk.XGroq.Usage != nil {
+			usage = chunk.XGroq.Usage
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(c.Delta.Content)
+			if onChunk != nil {
+				onChunk(c.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if usage != nil {
+		stats.PromptTokens = usage.PromptTokens
+		stats.CompletionTokens = usage.CompletionTokens
+		stats.TotalTokens = usage.TotalTokens
+		stats.QueueTime = secondsToDuration(usage.QueueTime)
+		stats.PromptTime = secondsToDuration(usage.PromptTime)
+		stats.CompletionTime = secondsToDuration(usage.CompletionTime)
+		stats.TotalTime = secondsToDuration(usage.TotalTime)
+	}
+	if err != nil {
+		return "", stats, err
+	}
+	if text.Len() == 0 {
+		return "", stats, fmt.Errorf(
+			"%s stream ended without content (empty response, model=%s)",
+			p.name, stats.Model,
+		)
+	}
+	return text.String(), stats, nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListModelsStatusError(t *testing.T) {
+	cases := []struct {
+		status     int
+		outcome    string
+		retryAfter time.Duration
+	}{
+		{http.StatusUnauthorized, OutcomeError, 0},
+		{http.StatusTooManyRequests, OutcomeRateLimited, 7 * time.Second},
+		{http.StatusServiceUnavailable, OutcomeServerError, 0},
+	}
+	for _, name := range []string{ProviderGroq, ProviderOpenAI, ProviderOllama, ProviderAnthropic} {
+		for _, c := range cases {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(c.status)
+				fmt.Fprint(w, `{"error":"nope"}`)
+			}))
+			p, err := NewProvider(name, srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.ListModels("k")
+			srv.Close()
+			var se *StatusError
+			if !errors.As(err, &se) || se.Status != c.status || se.Body != `{"error":"nope"}` {
+				t.Errorf("%s %d: err = %v, want a *StatusError with the body", name, c.status, err)
+				continue
+			}
+			if got := ClassifyError(err); got != c.outcome {
+				t.Errorf("%s %d: ClassifyError = %q, want %q", name, c.status, got, c.outcome)
+			}
+			if c.status == http.StatusTooManyRequests {
+				if !errors.Is(err, ErrRateLimited) || se.RetryAfter() != c.retryAfter {
+					t.Errorf("%s 429: errors.Is(ErrRateLimited) = %v, RetryAfter = %v",
+						name, errors.Is(err, ErrRateLimited), se.RetryAfter())
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAICompatListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"a","active":false},{"id":"b","active":true}]}`)
+	}))
+	defer srv.Close()
+	cases := []struct {
+		name   string
+		active []bool
+	}{
+		{ProviderGroq, []bool{false, true}},
+		{ProviderOpenAI, []bool{true, true}},
+	}
+	for _, c := range cases {
+		p, err := NewProvider(c.name, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		models, err := p.ListModels("k")
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(models) != 2 || models[0].Active != c.active[0] || models[1].Active != c.active[1] {
+			t.Errorf("%s: models = %+v, want active %v", c.name, models, c.active)
+		}
+	}
+}
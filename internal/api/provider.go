@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider names accepted by NewProvider and the `*_prompt_provider` keys
+// in the config. Groq stays the default so an empty value keeps the
+// historical behavior.
+const (
+	ProviderGroq      = "groq"
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+)
+
+// Provider is one LLM backend able to serve a pipeline stage. Every
+// implementation returns the same CallStats shape (with Provider set to
+// Name()) so the per-stage cards, the ai_calls table and the rate-limit
+// cache stay backend-agnostic.
+type Provider interface {
+	// Name returns the canonical provider id (one of the Provider*
+	// constants).
+	Name() string
+	// ChatCompletion sends messages to modelName and returns the
+	// assistant text plus the parsed call stats. A 429 is wrapped with
+	// ErrRateLimited. The request is aborted when ctx is done.
+	ChatCompletion(
+		ctx context.Context,
+		apiKey, modelName string,
+		messages []Message,
+	) (string, *CallStats, error)
+	// ChatCompletionStream is ChatCompletion with incremental delivery:
+	// onChunk receives each text delta as it arrives, and the returned
+	// text and stats are identical in shape to the blocking call (usage
+	// is taken from the stream's final event).
+	ChatCompletionStream(
+		ctx context.Context,
+		apiKey, modelName string,
+		messages []Message,
+		onChunk ChunkFunc,
+	) (string, *CallStats, error)
+	// ListModels returns the catalogue of models the key can address,
+	// projected onto GroqModel. Bounded by the shared client timeout.
+	ListModels(apiKey string) ([]GroqModel, error)
+	// ParseRateLimits extracts the backend's rate-limit headers. Backends
+	// without rate limits return a zero RateLimits stamped with
+	// CapturedAt.
+	ParseRateLimits(h http.Header) RateLimits
+}
+
+// NormalizeProvider maps arbitrary input to a known provider id,
+// defaulting to Groq for empty input. Unknown names are returned
+// lower-cased so NewProvider can report them verbatim.
+func NormalizeProvider(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ProviderGroq
+	}
+	return name
+}
+
+// NewProvider builds the Provider for name. baseURL overrides the
+// backend's default API root; empty keeps the default (for Groq that
+// also honors COMMITCRAFT_GROQ_BASE_URL).
+func NewProvider(name, baseURL string) (Provider, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	switch NormalizeProvider(name) {
+	case ProviderGroq:
+		return newGroqProvider(baseURL), nil
+	case ProviderOpenAI:
+		if baseURL == "" {
+			baseURL = defaultOpenAIBaseURL
+		}
+		return &openAICompatProvider{
+			name:     ProviderOpenAI,
+			label:    "OpenAI",
+			baseURL:  baseURL,
+			needsKey: true,
+		}, nil
+	case ProviderOllama:
+		if baseURL == "" {
+			baseURL = defaultOllamaBaseURL
+		}
+		return &ollamaProvider{baseURL: baseURL}, nil
+	case ProviderAnthropic:
+		if baseURL == "" {
+			baseURL = defaultAnthropicBaseURL
+		}
+		return &anthropicProvider{baseURL: baseURL}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q", name)
+}
+
+// validateChatArgs runs the argument checks shared by every
+// ChatCompletion implementation.
+func validateChatArgs(label, apiKey, modelName string, messages []Message, needsKey bool) error {
+	if needsKey && apiKey == "" {
+		return fmt.Errorf("%s API key was not provided", label)
+	}
+	if modelName == "" {
+		return fmt.Errorf("model name was not provided")
+	}
+	if len(messages) == 0 {
+		return fmt.Errorf("at least one message is required")
+	}
+	return nil
+}
+
+// defaultHTTPTimeout caps a blocking request end to end. It is only a
+// backstop for callers passing context.Background(); the engine bounds
+// every stage call with its own (usually shorter) deadline.
+const defaultHTTPTimeout = 5 * time.Minute
+
+// streamHeaderTimeout caps how long a streamed request may wait for the
+// response headers. The body itself has no overall limit, since a
+// stream is meant to run as long as the model keeps writing; the
+// caller's context ends it.
+const streamHeaderTimeout = 2 * time.Minute
+
+var (
+	httpClient   = &http.Client{Timeout: defaultHTTPTimeout}
+	streamClient = &http.Client{Transport: newStreamTransport()}
+)
+
+func newStreamTransport() http.RoundTripper {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	t = t.Clone()
+	t.ResponseHeaderTimeout = streamHeaderTimeout
+	return t
+}
+
+// doJSON performs one HTTP round-trip and returns the raw body, the
+// response headers and the status code. A nil payload sends no body.
+// Non-2xx statuses are not treated as errors here so each provider can
+// map them (429 → ErrRateLimited) with its own wording.
+func doJSON(
+	ctx context.Context,
+	method, url string,
+	headers map[string]string,
+	payload any,
+) ([]byte, http.Header, int, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("error encoding JSON: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error creating request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, resp.StatusCode, fmt.Errorf(
+			"error reading response body: %w", err)
+	}
+	return body, resp.Header, resp.StatusCode, nil
+}
+
+// StatusError is the error every provider returns for a non-success HTTP
+// status. Header is kept so callers can read the backend's rate-limit
+// headers (via Provider.ParseRateLimits) and Retry-After on a 429.
+// Unwraps to ErrRateLimited for 429 so errors.Is keeps working.
+type StatusError struct {
+	Status int
+	Body   string
+	Header http.Header
+}
+
+func (e *StatusError) Error() string {
+	if e.Status == http.StatusTooManyRequests {
+		return fmt.Sprintf("API returned 429: %s: %v", e.Body, ErrRateLimited)
+	}
+	return fmt.Sprintf("API returned a non-success status: %d, %s", e.Status, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	if e.Status == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// RetryAfter returns the Retry-After header as a duration (seconds form
+// only), or 0 when absent.
+func (e *StatusError) RetryAfter() time.Duration {
+	n, err := strconv.Atoi(strings.TrimSpace(e.Header.Get("Retry-After")))
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// statusError maps a non-success HTTP status onto a *StatusError.
+func statusError(status int, body []byte, h http.Header) error {
+	return &StatusError{Status: status, Body: string(body), Header: h}
+}
+
+// ClassifyError maps an error returned by a Provider call onto one of the
+// Outcome* constants so retry policies can decide whether waiting and
+// trying again makes sense.
+func ClassifyError(err error) string {
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch {
+		case se.Status == http.StatusTooManyRequests:
+			return OutcomeRateLimited
+		case se.Status >= 500:
+			return OutcomeServerError
+		}
+		return OutcomeError
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return OutcomeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		if ue.Timeout() {
+			return OutcomeTimeout
+		}
+		return OutcomeNetwork
+	}
+	return OutcomeError
+}
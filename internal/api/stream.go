@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ChunkFunc receives each incremental piece of assistant text while a
+// streamed completion is in flight. It runs on the goroutine performing
+// the HTTP call, so implementations must be safe for that.
+type ChunkFunc func(delta string)
+
+// maxSSELine bounds a single SSE line. Chunks are tiny; the cap only
+// guards against a misbehaving server.
+const maxSSELine = 1 << 20
+
+// openStream performs a POST whose response body is consumed
+// incrementally. A non-200 status is drained and mapped through
+// statusError so streamed and blocking calls fail the same way. The
+// caller owns closing the returned body.
+func openStream(
+	ctx context.Context,
+	url string,
+	headers map[string]string,
+	payload any,
+) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding JSON: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp.StatusCode, body, resp.Header)
+	}
+	return resp, nil
+}
+
+// readSSE walks a server-sent-events stream and calls fn once per event
+// with its `event:` name (empty when absent) and the joined `data:`
+// lines. Comment lines and unknown fields are ignored. Returning a
+// non-nil error from fn stops the walk and is passed through;
+// errStreamDone stops it cleanly.
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxSSELine)
+	var event string
+	var data []string
+	flush := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return stopErr(err)
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %w", err)
+	}
+	return stopErr(flush())
+}
+
+// errStreamDone is returned by SSE handlers on a terminal event
+// (`[DONE]`, `message_stop`) so readSSE stops without reporting an
+// error.
+var errStreamDone = errors.New("stream done")
+
+func stopErr(err error) error {
+	if errors.Is(err, errStreamDone) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadSSE(t *testing.T) {
+	stop := errors.New("stop")
+	cases := []struct {
+		name    string
+		stream  string
+		stopAt  string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "one data line per event",
+			stream: "data: a\n\ndata: b\n\n",
+			want:   []string{"|a", "|b"},
+		},
+		{
+			name:   "multi-line data is joined with newlines",
+			stream: "data: first\ndata: second\ndata:third\n\n",
+			want:   []string{"|first\nsecond\nthird"},
+		},
+		{
+			name:   "event names",
+			stream: "event: content_block_delta\ndata: {}\n\nevent: message_stop\ndata: {}\n\n",
+			want:   []string{"content_block_delta|{}", "message_stop|{}"},
+		},
+		{
+			name:   "comments and unknown fields are ignored",
+			stream: ": keep-alive\n\nid: 1\nretry: 10\ndata: x\n: mid-event comment\n\n",
+			want:   []string{"|x"},
+		},
+		{
+			name:   "an event without data is dropped with its name",
+			stream: "event: ping\n\ndata: y\n\n",
+			want:   []string{"|y"},
+		},
+		{
+			name:   "done sentinel stops cleanly",
+			stream: "data: a\n\ndata: [DONE]\n\ndata: after\n\n",
+			stopAt: "[DONE]",
+			want:   []string{"|a", "|[DONE]"},
+		},
+		{
+			name:   "a stream ending mid-event still delivers it",
+			stream: "data: a\n\nevent: message_delta\ndata: tail",
+			want:   []string{"|a", "message_delta|tail"},
+		},
+		{
+			name:    "handler errors pass through",
+			stream:  "data: a\n\ndata: b\n\n",
+			stopAt:  "a",
+			want:    []string{"|a"},
+			wantErr: stop,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []string
+			err := readSSE(strings.NewReader(c.stream), func(event, data string) error {
+				got = append(got, event+"|"+data)
+				if data == c.stopAt {
+					if c.wantErr != nil {
+						return c.wantErr
+					}
+					return errStreamDone
+				}
+				return nil
+			})
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("err = %v, want %v", err, c.wantErr)
+			}
+			if !slices.Equal(got, c.want) {
+				t.Errorf("events = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+// sseServer answers every request with status and, on 200, body as an
+// event stream.
+func sseServer(t *testing.T, status int, body string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"error":"busy"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("x-request-id", "req-1")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	openAI := "data: {\"model\":\"gpt-x\",\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		": ping\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n" +
+		"data: [DONE]\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\" ignored\"}}]}\n\n"
+	anthropic := "event: message_start\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-x\",\"usage\":{\"input_tokens\":4}}}\n\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n" +
+		"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n" +
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":3}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" ignored\"}}\n\n"
+	ollama := "{\"model\":\"llama\",\"message\":{\"content\":\"Hel\"}}\n" +
+		"{\"message\":{\"content\":\"lo\"}}\n" +
+		"{\"model\":\"llama\",\"message\":{\"content\":\"\"},\"done\":true,\"prompt_eval_count\":6,\"eval_count\":2}\n"
+
+	cases := []struct {
+		name     string
+		provider string
+		body     string
+		model    string
+		tokens   [2]int
+		wantErr  string
+	}{
+		{name: "openai", provider: ProviderOpenAI, body: openAI, model: "gpt-x", tokens: [2]int{5, 2}},
+		{name: "anthropic", provider: ProviderAnthropic, body: anthropic, model: "claude-x", tokens: [2]int{4, 3}},
+		{name: "ollama", provider: ProviderOllama, body: ollama, model: "llama", tokens: [2]int{6, 2}},
+		{
+			name: "openai cut mid-event", provider: ProviderOpenAI,
+			body:    "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\ndata: {\"choices\":[{\"del",
+			wantErr: "error decoding stream chunk",
+		},
+		{
+			name: "anthropic cut mid-event", provider: ProviderAnthropic,
+			body:    "event: content_block_delta\ndata: {\"type\":\"content_blo",
+			wantErr: "error decoding stream event",
+		},
+		{
+			name: "anthropic error event", provider: ProviderAnthropic,
+			body:    "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+			wantErr: "anthropic stream error (overloaded_error): Overloaded",
+		},
+		{name: "openai empty stream", provider: ProviderOpenAI, body: "data: [DONE]\n\n", wantErr: "ended without content"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := NewProvider(c.provider, sseServer(t, http.StatusOK, c.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var chunks []string
+			text, stats, err := p.ChatCompletionStream(
+				context.Background(), "k", "model", []Message{{Role: "user", Content: "hi"}},
+				func(delta string) { chunks = append(chunks, delta) },
+			)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != "Hello" || !slices.Equal(chunks, []string{"Hel", "lo"}) {
+				t.Errorf("text = %q from chunks %q, want Hello from Hel, lo", text, chunks)
+			}
+			if stats.Provider != c.provider || stats.Model != c.model {
+				t.Errorf("stats = %s/%s, want %s/%s", stats.Provider, stats.Model, c.provider, c.model)
+			}
+			if stats.PromptTokens != c.tokens[0] || stats.CompletionTokens != c.tokens[1] ||
+				stats.TotalTokens != c.tokens[0]+c.tokens[1] {
+				t.Errorf("tokens = %d+%d=%d, want %v", stats.PromptTokens, stats.CompletionTokens, stats.TotalTokens, c.tokens)
+			}
+		})
+	}
+}
+
+func TestChatCompletionStreamStatusError(t *testing.T) {
+	cases := []struct {
+		status  int
+		outcome string
+	}{
+		{http.StatusTooManyRequests, OutcomeRateLimited},
+		{http.StatusBadGateway, OutcomeServerError},
+		{http.StatusUnauthorized, OutcomeError},
+	}
+	for _, name := range []string{ProviderGroq, ProviderOpenAI, ProviderAnthropic, ProviderOllama} {
+		for _, c := range cases {
+			p, err := NewProvider(name, sseServer(t, c.status, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = p.ChatCompletionStream(
+				context.Background(), "k", "model", []Message{{Role: "user", Content: "hi"}}, nil,
+			)
+			var se *StatusError
+			if !errors.As(err, &se) || se.Status != c.status || se.Body != `{"error":"busy"}` {
+				t.Errorf("%s %d: err = %v, want a *StatusError with the body", name, c.status, err)
+				continue
+			}
+			if got := ClassifyError(err); got != c.outcome {
+				t.Errorf("%s %d: ClassifyError = %q, want %q", name, c.status, got, c.outcome)
+			}
+			if c.status == http.StatusTooManyRequests && se.RetryAfter() == 0 {
+				t.Errorf("%s 429: Retry-After was dropped", name)
+			}
+		}
+	}
+}
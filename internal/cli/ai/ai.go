@@ -5,12 +5,15 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"commit_craft_reborn/internal/aiengine"
@@ -19,6 +22,7 @@ import (
 	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/logger"
+	"commit_craft_reborn/internal/scope"
 	"commit_craft_reborn/internal/storage"
 )
 
@@ -27,21 +31,27 @@ const usage = `Usage: commitcraft ai <subcommand> [flags]
 Subcommands:
   generate     Generate a commit message from --keypoint/--tag/--scope and persist as draft.
                Add --agent to emit a delegate prompt bundle instead of calling Groq (see 'submit').
+               Add --stream to receive stage output as NDJSON while it is generated.
   regenerate   Re-run the pipeline on an existing draft (--id), reusing stored inputs and diff.
   submit       Persist an agent-produced message (delegate mode) read as JSON from stdin, verify it, and save the draft.
-  edit         Patch a draft's title/body/changelog/tag/scope directly without re-running stages.
+  edit         Patch a draft's title/body/changelog/tag/scope/trailers directly without re-running stages.
   show         Print the JSON for a draft/commit by --id.
   list         List drafts/commits in the current workspace.
   promote      Mark a draft as completed (--id). Does not run git commit.
+  split        Cluster the staged files into suggested commits (tag/scope/keypoints per group); --accept creates one draft per group.
+  commit       Promote a draft (--id), run git commit with its final_message (sign-off/signing/--no-verify), and link the new hash.
   list-tags          List the commit-type tags accepted by 'generate' (default + global + local) as JSON.
   list-addable-tags  List builtin tags known to the code but not yet in the local config.
   add-tag            Append one or more builtin tags to the local .commitcraft.toml.
   context            Estimate the Change Analyzer payload size against the staged diff and the configured model's context window (offline, no Groq call).
   verify             Run deterministic checks against a draft's final_message (AI residue, title format, duplicates). Exit 4 when errors are present.
   merge              Generate a [MERGE] draft from the commits in <into>..<branch> using the release pipeline.
-  release            Generate a [RELEASE] draft from the commits in <from>..<to>. Drafting only — publishing (gh) is a separate follow-up.
+  release            Generate a [RELEASE] draft from the commits in <from>..<to>.
+                     'release publish --id N' tags and creates the hosted release; --push pushes the tag first (--dry-run prints the plan).
   link-commit        Associate a draft id with a git commit hash so 'ai show --commit <hash>' works after the fact.
   key                Manage the two Groq API key slots (user/ai): show state, set a slot's key, swap the active slot.
+  style              Show or refresh the repository style profile mined from git log (tags, scopes, title length, bullets, language).
+  search             Rank the stored commits and releases by semantic similarity to "<query>" (--limit, --kind, --all-worktrees).
 
 Run 'commitcraft ai <subcommand> -h' for the flags of each subcommand.
 `
@@ -69,6 +79,10 @@ func Dispatch(args []string) int {
 		return runList(rest)
 	case "promote":
 		return runPromote(rest)
+	case "split":
+		return runSplit(rest)
+	case "commit":
+		return runCommit(rest)
 	case "list-tags":
 		return runListTags(rest)
 	case "list-addable-tags":
@@ -87,6 +101,10 @@ func Dispatch(args []string) int {
 		return runLinkCommit(rest)
 	case "key":
 		return runKey(rest)
+	case "style":
+		return runStyle(rest)
+	case "search":
+		return runSearch(rest)
 	case "-h", "--help", "help":
 		fmt.Fprint(os.Stdout, usage)
 		return 0
@@ -132,6 +150,21 @@ func loadBootstrap() (*bootstrap, error) {
 	config.PopulateCommitTypePalettes(&globalCfg, finalTypes)
 	config.ResolveReleaseConfig(&globalCfg, localCfg)
 	config.ResolveTUIConfig(&globalCfg, localCfg)
+	config.ResolveCommitFormatConfig(&globalCfg, localCfg)
+	config.ResolveGitCommitConfig(&globalCfg, localCfg)
+	config.ResolveHooksConfig(&globalCfg, localCfg)
+	config.ResolveTrailersConfig(&globalCfg, localCfg)
+	config.ResolveDiffConfig(&globalCfg, localCfg)
+	config.ResolveScopeConfig(&globalCfg, localCfg)
+	config.ResolveFewShotConfig(&globalCfg, localCfg)
+	if err := git.SetDiffFilter(git.DiffFilter{
+		Ignore:     globalCfg.Diff.Ignore,
+		Keep:       globalCfg.Diff.Keep,
+		Builtin:    globalCfg.Diff.BuiltinRules,
+		Attributes: globalCfg.Diff.GitAttributes,
+	}); err != nil {
+		return nil, fmt.Errorf("[diff]: %w", err)
+	}
 
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -200,6 +233,8 @@ type commitJSON struct {
 	Title          string      `json:"title"`
 	ChangelogEntry string      `json:"changelog_entry,omitempty"`
 	ChangelogLine  string      `json:"changelog_mention,omitempty"`
+	Trailers       []string    `json:"trailers,omitempty"`
+	Files          []string    `json:"files,omitempty"`
 	FinalMessage   string      `json:"final_message"`
 	Workspace      string      `json:"workspace"`
 	Source         string      `json:"source,omitempty"`
@@ -211,17 +246,101 @@ type commitJSON struct {
 	// quality report in the same response. Nil (omitted) for every other
 	// subcommand.
 	Verify *aiengine.VerifyReport `json:"verify,omitempty"`
+	// ScopeInference is set by `ai generate --scope auto`: the packages
+	// the staged files touch and why Scope was picked.
+	ScopeInference *scope.Inference `json:"scope_inference,omitempty"`
+	// TagInference is set by `ai generate --tag auto`: the proposed type,
+	// its confidence and whether the file rules or the model picked it.
+	TagInference *aiengine.TagSuggestion `json:"tag_inference,omitempty"`
 }
 
 type stageJSON struct {
 	ID               int    `json:"id"`
 	Stage            string `json:"stage"`
+	Provider         string `json:"provider,omitempty"`
 	Model            string `json:"model"`
 	PromptTokens     int    `json:"prompt_tokens"`
 	CompletionTokens int    `json:"completion_tokens"`
 	TotalTokens      int    `json:"total_tokens"`
 	TotalTimeMs      int    `json:"total_time_ms"`
 	RequestID        string `json:"request_id,omitempty"`
+	// Attempts lists the failed tries (retries / fallbacks) that came
+	// before the call that served the stage.
+	Attempts []attemptJSON `json:"attempts,omitempty"`
+	// Chunks lists the map calls of a chunked Change Analyzer run
+	// (summary stage only); the stage's own numbers are then the reduce
+	// call's.
+	Chunks []chunkJSON `json:"chunks,omitempty"`
+}
+
+// chunkJSON is one map call of a chunked Change Analyzer run. Files and
+// DiffChars are only known for the run that produced them; reloaded
+// rows omit them.
+type chunkJSON struct {
+	Index            int           `json:"index"`
+	Label            string        `json:"label"`
+	Files            []string      `json:"files,omitempty"`
+	DiffChars        int           `json:"diff_chars,omitempty"`
+	Provider         string        `json:"provider,omitempty"`
+	Model            string        `json:"model"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	TotalTokens      int           `json:"total_tokens"`
+	TotalTimeMs      int           `json:"total_time_ms"`
+	RequestID        string        `json:"request_id,omitempty"`
+	Attempts         []attemptJSON `json:"attempts,omitempty"`
+}
+
+func chunksToJSON(chunks []aiengine.ChunkStats) []chunkJSON {
+	if len(chunks) == 0 {
+		return nil
+	}
+	out := make([]chunkJSON, 0, len(chunks))
+	for _, c := range chunks {
+		s := c.Stats
+		out = append(out, chunkJSON{
+			Index:            c.Index,
+			Label:            c.Label,
+			Files:            c.Files,
+			DiffChars:        c.DiffChars,
+			Provider:         s.Provider,
+			Model:            firstNonEmpty(s.StatsModel, s.Model),
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			TotalTokens:      s.TotalTokens,
+			TotalTimeMs:      int(s.APITotalTime.Milliseconds()),
+			RequestID:        s.RequestID,
+			Attempts:         attemptsToJSON(s.Attempts),
+		})
+	}
+	return out
+}
+
+// attemptJSON is one failed try of a stage call. KeySlot and WaitMs are
+// only known for the run that produced them; reloaded rows omit them.
+type attemptJSON struct {
+	Model   string `json:"model"`
+	KeySlot string `json:"key_slot,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	WaitMs  int    `json:"wait_ms,omitempty"`
+}
+
+func attemptsToJSON(attempts []api.CallAttempt) []attemptJSON {
+	if len(attempts) == 0 {
+		return nil
+	}
+	out := make([]attemptJSON, 0, len(attempts))
+	for _, a := range attempts {
+		out = append(out, attemptJSON{
+			Model:   a.Model,
+			KeySlot: a.KeySlot,
+			Outcome: a.Outcome,
+			Error:   a.Error,
+			WaitMs:  int(a.Wait.Milliseconds()),
+		})
+	}
+	return out
 }
 
 var stageNames = [...]string{"summary", "body", "title", "changelog"}
@@ -229,9 +348,9 @@ var stageNames = [...]string{"summary", "body", "title", "changelog"}
 func commitToJSON(
 	c storage.Commit,
 	stages []aiengine.StageStats,
-	typeFormat string,
+	fm commit.MessageFormat,
 ) (commitJSON, error) {
-	final, err := commit.FormatFinalMessage(typeFormat, c.Type, c.Scope, c.MessageEN)
+	final, err := composeCommitFinalMessage(c, fm)
 	if err != nil {
 		return commitJSON{}, err
 	}
@@ -246,6 +365,8 @@ func commitToJSON(
 		Body:           c.IaCommitRaw,
 		Title:          c.IaTitle,
 		ChangelogEntry: c.IaChangelog,
+		Trailers:       c.Trailers,
+		Files:          c.Files,
 		FinalMessage:   final,
 		Workspace:      c.Workspace,
 		Source:         c.Source,
@@ -263,12 +384,15 @@ func commitToJSON(
 		cj.Stages = append(cj.Stages, stageJSON{
 			ID:               i,
 			Stage:            name,
+			Provider:         s.Provider,
 			Model:            firstNonEmpty(s.StatsModel, s.Model),
 			PromptTokens:     s.PromptTokens,
 			CompletionTokens: s.CompletionTokens,
 			TotalTokens:      s.TotalTokens,
 			TotalTimeMs:      int(s.APITotalTime.Milliseconds()),
 			RequestID:        s.RequestID,
+			Attempts:         attemptsToJSON(s.Attempts),
+			Chunks:           chunksToJSON(s.Chunks),
 		})
 	}
 	return cj, nil
@@ -284,13 +408,25 @@ func releaseScope(r storage.Release) string {
 	return r.Version
 }
 
+// composeCommitFinalMessage is the text `git commit` receives for a
+// commit row: the profile-formatted header + MessageEN, with the row's
+// trailers appended as a git trailer block.
+func composeCommitFinalMessage(c storage.Commit, fm commit.MessageFormat) (string, error) {
+	final, err := fm.FinalMessage(c.Type, c.Scope, c.MessageEN)
+	if err != nil {
+		return "", err
+	}
+	return commit.AppendTrailers(final, c.Trailers), nil
+}
+
 // composeReleaseFinalMessage builds the `[TYPE] scope: title\n\nbody`
-// shape from a release row using FormatFinalMessage. Used by both
+// shape (or its conventional equivalent) from a release row using the
+// configured MessageFormat. Used by both
 // `ai show` (for the JSON envelope) and `ai verify` (as input to the
 // rule set).
-func composeReleaseFinalMessage(r storage.Release, typeFormat string) (string, error) {
+func composeReleaseFinalMessage(r storage.Release, fm commit.MessageFormat) (string, error) {
 	msg := aiengine.ComposeFinalMessage(r.Title, r.Body, "")
-	return commit.FormatFinalMessage(typeFormat, r.Type, releaseScope(r), msg)
+	return fm.FinalMessage(r.Type, releaseScope(r), msg)
 }
 
 // releaseToJSON projects a release row into the same commitJSON shape
@@ -298,8 +434,8 @@ func composeReleaseFinalMessage(r storage.Release, typeFormat string) (string, e
 // Branch / Version fields. Stages stay empty until per-release
 // telemetry persistence lands (a future unit; the release pipeline
 // currently doesn't write to ai_calls).
-func releaseToJSON(r storage.Release, typeFormat string) (commitJSON, error) {
-	final, err := composeReleaseFinalMessage(r, typeFormat)
+func releaseToJSON(r storage.Release, fm commit.MessageFormat) (commitJSON, error) {
+	final, err := composeReleaseFinalMessage(r, fm)
 	if err != nil {
 		return commitJSON{}, err
 	}
@@ -346,8 +482,13 @@ func loadStagesForCommit(db *storage.DB, commitID int) []aiengine.StageStats {
 		if idx < 0 || idx >= len(out) {
 			continue
 		}
+		if !c.Served() {
+			out[idx].Attempts = append(out[idx].Attempts, aiengine.AttemptFromCall(c))
+			continue
+		}
 		out[idx].HasStats = true
 		out[idx].StatsModel = c.Model
+		out[idx].Provider = c.Provider
 		out[idx].Model = c.Model
 		out[idx].PromptTokens = c.PromptTokens
 		out[idx].CompletionTokens = c.CompletionTokens
@@ -356,6 +497,7 @@ func loadStagesForCommit(db *storage.DB, commitID int) []aiengine.StageStats {
 		out[idx].RequestID = c.RequestID
 		out[idx].TPMLimitAtCall = c.TPMLimitAtCall
 	}
+	out[aiengine.StageSummary].Chunks = aiengine.ChunksFromCalls(calls)
 	return out
 }
 
@@ -370,7 +512,10 @@ func dbStageIndex(label string) int {
 
 // persistAICalls flushes the per-stage telemetry produced by an engine
 // run to the ai_calls table, replacing any existing rows for the given
-// commit so iterative regenerations don't accumulate orphan data.
+// commit so iterative regenerations don't accumulate orphan data. Failed
+// attempts of a stage are written before the call that served it (a stage
+// no call served keeps only its attempts), and the map calls of a chunked
+// Change Analyzer run before the summary row.
 func persistAICalls(db *storage.DB, commitID int, stages []aiengine.StageStats) error {
 	if commitID <= 0 || db == nil {
 		return nil
@@ -379,20 +524,29 @@ func persistAICalls(db *storage.DB, commitID int, stages []aiengine.StageStats)
 		return err
 	}
 	for i, s := range stages {
-		if !s.HasStats {
-			continue
-		}
 		stageName := ""
 		if i >= 0 && i < len(stageNames) {
 			stageName = stageNames[i]
 		}
+		if !s.HasStats {
+			if err := aiengine.CreateFailedStageCalls(
+				db.CreateAICall, commitID, stageName, s.Provider, s.Attempts,
+			); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := aiengine.CreateChunkCalls(db.CreateAICall, commitID, s.Chunks); err != nil {
+			return err
+		}
 		modelName := s.StatsModel
 		if modelName == "" {
 			modelName = s.Model
 		}
-		_, err := db.CreateAICall(storage.AICall{
+		err := aiengine.CreateStageCalls(db.CreateAICall, storage.AICall{
 			CommitID:         commitID,
 			Stage:            stageName,
+			Provider:         s.Provider,
 			Model:            modelName,
 			PromptTokens:     s.PromptTokens,
 			CompletionTokens: s.CompletionTokens,
@@ -403,7 +557,7 @@ func persistAICalls(db *storage.DB, commitID int, stages []aiengine.StageStats)
 			TotalTimeMs:      int(s.APITotalTime.Milliseconds()),
 			RequestID:        s.RequestID,
 			TPMLimitAtCall:   s.TPMLimitAtCall,
-		})
+		}, s.Attempts)
 		if err != nil {
 			return err
 		}
@@ -411,6 +565,24 @@ func persistAICalls(db *storage.DB, commitID int, stages []aiengine.StageStats)
 	return nil
 }
 
+// persistFailedAttempts appends the attempts of the stage a failed
+// regenerate run could not serve to the draft's ai_calls. The rows of
+// the previous run stay: the draft keeps its previous text, and the
+// failed tries are recorded next to the calls that produced it.
+func persistFailedAttempts(db *storage.DB, commitID int, stages []aiengine.StageStats) {
+	for i, s := range stages {
+		if !s.Failed || i >= len(stageNames) {
+			continue
+		}
+		if err := aiengine.CreateFailedStageCalls(
+			db.CreateAICall, commitID, stageNames[i], s.Provider, s.Attempts,
+		); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ai_calls persistence failed: %v\n", err)
+			return
+		}
+	}
+}
+
 // printErrorJSON writes a structured error to stderr. The shell exit
 // code is the caller's responsibility (usually 1 for runtime errors,
 // 2 for usage errors).
@@ -420,21 +592,46 @@ func printErrorJSON(code, msg string) {
 	_ = enc.Encode(map[string]string{"error": msg, "code": code})
 }
 
+// signalContext returns the context every AI-calling subcommand runs
+// under: Ctrl-C or SIGTERM cancels the request in flight instead of
+// leaving the process blocked on the network.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
 // printAIRunError maps an error returned by aiengine.Run / RunRelease to a
 // structured stderr payload. A Groq 429 (api.ErrRateLimited, wrapped through
 // the engine with %w) becomes a "rate_limited" code with a hint naming the
 // active key slot and the swap command, so the agent can switch slots and
-// retry instead of treating it as an opaque api_error. Everything else stays
-// "api_error".
+// retry instead of treating it as an opaque api_error. An interrupted run is
+// "cancelled" and a stage that ran out of its [timeouts] budget on every
+// attempt is "timeout". Everything else stays "api_error". When the failed
+// stage went through retries or fallbacks, its attempts are listed too.
 func printAIRunError(bs *bootstrap, err error) {
-	if errors.Is(err, api.ErrRateLimited) {
-		printErrorJSON("rate_limited",
-			fmt.Sprintf("Groq rate-limited the active key (slot=%s). "+
-				"Run `commitcraft ai key swap` to switch slots, then retry. (%v)",
-				bs.cfg.TUI.ActiveKeySlot, err))
+	code, msg := "api_error", err.Error()
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = "cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		code = "timeout"
+	case errors.Is(err, api.ErrRateLimited):
+		code = "rate_limited"
+		msg = fmt.Sprintf("Groq rate-limited the active key (slot=%s). "+
+			"Run `commitcraft ai key swap` to switch slots, then retry. (%v)",
+			bs.cfg.TUI.ActiveKeySlot, err)
+	}
+	_, attempts := aiengine.FailedAttempts(err)
+	if len(attempts) == 0 {
+		printErrorJSON(code, msg)
 		return
 	}
-	printErrorJSON("api_error", err.Error())
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(struct {
+		Error    string        `json:"error"`
+		Code     string        `json:"code"`
+		Attempts []attemptJSON `json:"attempts"`
+	}{msg, code, attemptsToJSON(attempts)})
 }
 
 // printCommitJSON writes a commitJSON to stdout, indented for human
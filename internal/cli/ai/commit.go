@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"commit_craft_reborn/internal/git"
+)
+
+// runCommit is `ai promote` + `git commit` + `ai link-commit` in one
+// step: the draft is finalized (changelog entry written and staged as
+// usual), its final_message is committed in the draft's workspace with
+// the [git_commit] options, and the new hash is linked to the row.
+// Prints the updated commit JSON, whose commit_hash carries the result.
+//
+// Only commit rows are accepted — releases are published, not
+// committed. A row that already carries a hash is rejected so a retry
+// after a partial failure can't create a second commit.
+func runCommit(args []string) int {
+	fs := flagSet("ai commit")
+	id := fs.Int("id", 0, "Draft ID to commit (required)")
+	signOff := fs.Bool(
+		"signoff",
+		false,
+		"Add a Signed-off-by trailer (git commit --signoff). Defaults to [git_commit].sign_off",
+	)
+	sign := fs.String(
+		"sign",
+		"",
+		"Sign the commit: 'gpg' | 'ssh'. Defaults to [git_commit].sign",
+	)
+	signingKey := fs.String(
+		"signing-key",
+		"",
+		"Key id (gpg) or key path (ssh) overriding user.signingkey. Defaults to [git_commit].signing_key",
+	)
+	noVerify := fs.Bool(
+		"no-verify",
+		false,
+		"Skip the pre-commit and commit-msg hooks. Defaults to [git_commit].no_verify",
+	)
+	noChangelogWrite := fs.Bool(
+		"no-changelog-write",
+		false,
+		"Skip writing/staging CHANGELOG.md even when the draft has a changelog entry",
+	)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *id <= 0 {
+		printErrorJSON("invalid_input", "--id is required")
+		return 2
+	}
+
+	bs, err := loadBootstrap()
+	if err != nil {
+		printErrorJSON("bootstrap_error", err.Error())
+		return 1
+	}
+	defer bs.db.Close()
+
+	// Flags left unset fall back to the resolved [git_commit] config so
+	// `--signoff=false` can still opt out of a configured default.
+	opts := git.CommitOptions{
+		SignOff:    bs.cfg.GitCommit.SignOff,
+		Sign:       bs.cfg.GitCommit.Sign,
+		SigningKey: bs.cfg.GitCommit.SigningKey,
+		NoVerify:   bs.cfg.GitCommit.NoVerify,
+	}
+	if set["signoff"] {
+		opts.SignOff = *signOff
+	}
+	if set["sign"] {
+		opts.Sign = strings.TrimSpace(*sign)
+	}
+	if set["signing-key"] {
+		opts.SigningKey = strings.TrimSpace(*signingKey)
+	}
+	if set["no-verify"] {
+		opts.NoVerify = *noVerify
+	}
+
+	res, err := dispatchByID(bs.db, *id, kindCommit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			printErrorJSON("not_found", fmt.Sprintf("no commit with id=%d", *id))
+			return 1
+		}
+		printErrorJSON("db_error", err.Error())
+		return 1
+	}
+	c := *res.Commit
+	if c.CommitHash != "" {
+		printErrorJSON(
+			"already_committed",
+			fmt.Sprintf("draft id=%d is already linked to %s", c.ID, shortHash(c.CommitHash)),
+		)
+		return 1
+	}
+
+	final, err := composeCommitFinalMessage(c, bs.cfg.CommitFormat.MessageFormat())
+	if err != nil {
+		printErrorJSON("incomplete_commit", err.Error())
+		return 1
+	}
+	ws := strings.TrimSpace(c.Workspace)
+	if ws == "" {
+		ws = bs.pwd
+	}
+	// A draft from a split plan commits only its own files. The other
+	// groups are unstaged before promoting (so the CHANGELOG entry the
+	// promotion stages stays in this commit) and re-staged afterwards.
+	if len(c.Files) > 0 {
+		rest, err := git.IsolateStagedAt(ws, c.Files)
+		if err != nil {
+			printErrorJSON("git_error", err.Error())
+			return 1
+		}
+		defer func() {
+			if err := git.ApplyCachedPatchAt(ws, rest); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: re-staging the other split groups failed: %v\n", err)
+			}
+		}()
+	}
+
+	// A completed row already had its changelog entry written when it
+	// was promoted; promoting again would prepend it twice.
+	if c.Status != "completed" {
+		if code := promoteCommit(bs, c, *noChangelogWrite); code != 0 {
+			return code
+		}
+	}
+
+	hash, err := git.CommitAt(ws, final, opts)
+	if err != nil {
+		printErrorJSON("git_commit_error", err.Error())
+		return 1
+	}
+	if err := bs.db.LinkCommitHash(c.ID, hash); err != nil {
+		// The commit exists in git at this point; say which hash to
+		// link by hand instead of failing silently.
+		printErrorJSON("db_error", fmt.Sprintf(
+			"committed %s but linking it failed: %v (run `ai link-commit --id %d --hash %s`)",
+			shortHash(hash), err, c.ID, hash,
+		))
+		return 1
+	}
+
+	saved, err := bs.db.GetCommitByID(c.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-commit reload failed: %v\n", err)
+		saved = c
+		saved.Status = "completed"
+		saved.CommitHash = hash
+	}
+	cj, err := commitToJSON(saved, loadStagesForCommit(bs.db, saved.ID), bs.cfg.CommitFormat.MessageFormat())
+	if err != nil {
+		printErrorJSON("format_error", err.Error())
+		return 1
+	}
+	printCommitJSON(cj)
+	return 0
+}
@@ -5,8 +5,12 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"strings"
 
 	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/tokenizer"
 )
 
 // contextJSON is the wire shape for `commitcraft ai context`. ContextWindow
@@ -14,23 +18,37 @@ import (
 // present in the groq_models_cache table — the caller (typically an agent)
 // can then decide whether to refresh the cache or skip the check.
 type contextJSON struct {
-	Model             string   `json:"model"`
-	ContextWindow     int      `json:"context_window"`
-	SystemPromptChars int      `json:"system_prompt_chars"`
-	UserInputChars    int      `json:"user_input_chars"`
-	TotalChars        int      `json:"total_chars"`
-	EstTokens         int      `json:"est_tokens"`
-	UsagePct          *float64 `json:"usage_pct"`
-	Fits              *bool    `json:"fits"`
-	DiffTruncated     bool     `json:"diff_truncated"`
-	DiffMaxBytes      int      `json:"diff_max_bytes"`
+	Model             string `json:"model"`
+	ContextWindow     int    `json:"context_window"`
+	SystemPromptChars int    `json:"system_prompt_chars"`
+	UserInputChars    int    `json:"user_input_chars"`
+	TotalChars        int    `json:"total_chars"`
+	EstTokens         int    `json:"est_tokens"`
+	// Tokenizer names what counted EstTokens: "bpe:<family>" when the
+	// model's vocabulary is installed, "heuristic" (chars/4) otherwise.
+	// TokenizerError explains a vocabulary file that failed to load.
+	Tokenizer      string   `json:"tokenizer"`
+	TokenizerError string   `json:"tokenizer_error,omitempty"`
+	UsagePct       *float64 `json:"usage_pct"`
+	Fits           *bool    `json:"fits"`
+	DiffTruncated  bool     `json:"diff_truncated"`
+	DiffMaxBytes   int      `json:"diff_max_bytes"`
+	// IncludedFiles / OmittedFiles say exactly which staged files the
+	// Change Analyzer sees in full and which only by name + numstat.
+	IncludedFiles []string          `json:"included_files"`
+	OmittedFiles  []git.OmittedFile `json:"omitted_files"`
+	// Chunking is the configured change_analyzer_chunking mode; Chunks
+	// is the number of map calls a live run would make over the full
+	// staged diff (0 = a single Change Analyzer call).
+	Chunking string `json:"chunking"`
+	Chunks   int    `json:"chunks"`
 }
 
 // runContext computes the pre-flight payload size for stage 1 (Change
 // Analyzer) against the currently staged diff and the configured model,
-// and prints the JSON breakdown on stdout. It mirrors the diff fetch and
-// cap that the real pipeline applies, so callers see the same truncation
-// behaviour they would hit on a live `ai generate`.
+// and prints the JSON breakdown on stdout. It runs the same diff budget
+// the real pipeline applies, so callers see exactly which files a live
+// `ai generate` would leave out.
 func runContext(args []string) int {
 	fs := flagSet("ai context")
 	strict := fs.Bool(
@@ -59,19 +77,25 @@ func runContext(args []string) int {
 	defer boot.db.Close()
 
 	maxBytes := boot.cfg.Prompts.ChangeAnalyzerMaxDiffSize
-	diff, err := validateAndStageDiff(maxBytes)
+	budget, err := git.BudgetStagedDiff(maxBytes)
 	if err != nil {
 		printErrorJSON("no_staged_diff", err.Error())
 		return 1
 	}
+	if strings.TrimSpace(budget.Diff) == "" {
+		printErrorJSON("no_staged_diff", "no staged changes — run `git add` before invoking ai context")
+		return 1
+	}
 
-	est := aiengine.EstimateChangeAnalyzer(boot.cfg, diff, nil)
-
-	model := boot.cfg.Prompts.ChangeAnalyzerPromptModel
+	// --model also picks the tokenizer, so the estimate is counted the
+	// way the overriding model would count it.
+	cfg := boot.cfg
 	if *modelOverride != "" {
-		model = *modelOverride
+		cfg.Prompts.ChangeAnalyzerPromptModel = *modelOverride
 	}
+	model := cfg.Prompts.ChangeAnalyzerPromptModel
 	contextWindow := lookupContextWindow(boot, model)
+	est := aiengine.EstimateChangeAnalyzer(cfg, budget.Diff, nil)
 
 	out := contextJSON{
 		Model:             model,
@@ -80,8 +104,29 @@ func runContext(args []string) int {
 		UserInputChars:    est.UserInputChars,
 		TotalChars:        est.TotalChars,
 		EstTokens:         est.EstTokens,
-		DiffTruncated:     maxBytes > 0 && len(diff) >= maxBytes,
+		Tokenizer:         est.Tokenizer,
+		DiffTruncated:     budget.Truncated(),
 		DiffMaxBytes:      maxBytes,
+		IncludedFiles:     budget.Included,
+		OmittedFiles:      budget.Omitted,
+		Chunking:          config.NormalizeChunking(cfg.Prompts.ChangeAnalyzerChunking),
+	}
+	if _, err := tokenizer.Lookup(cfg.Tokenizer.ResolvedDir, cfg.Tokenizer.Models, model); err != nil {
+		out.TokenizerError = err.Error()
+	}
+	if out.IncludedFiles == nil {
+		out.IncludedFiles = []string{}
+	}
+	if out.OmittedFiles == nil {
+		out.OmittedFiles = []git.OmittedFile{}
+	}
+
+	if out.Chunking != config.ChunkingOff {
+		full := budget.Diff
+		if all, err := git.BudgetStagedDiff(0); err == nil {
+			full = all.Diff
+		}
+		out.Chunks = len(aiengine.PlanChunks(cfg, full, nil, contextWindow))
 	}
 
 	if contextWindow > 0 {
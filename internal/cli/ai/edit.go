@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/storage"
 )
 
@@ -26,6 +28,11 @@ import (
 // the buffered content). Empty strings ("") are rejected for
 // title/body/tag/scope since FormatFinalMessage needs them populated;
 // --changelog accepts the sentinel "CLEAR" to drop the entry.
+//
+// --trailer (repeatable) adds git trailers to a commit draft; see
+// config.TrailersConfig.ExpandTrailer for the accepted shorthands.
+// --clear-trailers drops the stored ones first, so
+// `--clear-trailers --trailer …` replaces the whole set.
 func runEdit(args []string) int {
 	fs := flagSet("ai edit")
 	id := fs.Int("id", 0, "Draft ID to edit (required)")
@@ -38,6 +45,10 @@ func runEdit(args []string) int {
 			"or 'CLEAR' to drop the entry.")
 	tag := fs.String("tag", "", "Override the commit type tag")
 	scope := fs.String("scope", "", "Override the commit scope")
+	var trailers stringSlice
+	fs.Var(&trailers, "trailer",
+		"Add a trailer (repeatable): 'Token: value', 'Co-authored-by: <alias>', '#42' or 'signoff'")
+	clearTrailers := fs.Bool("clear-trailers", false, "Drop the stored trailers before adding --trailer ones")
 	kind := fs.String(
 		"kind",
 		"",
@@ -69,9 +80,10 @@ func runEdit(args []string) int {
 		printErrorJSON("invalid_input", "--id is required")
 		return 2
 	}
-	if !titleSet && !bodySet && !changelogSet && !tagSet && !scopeSet {
+	trailersSet := len(trailers) > 0 || *clearTrailers
+	if !titleSet && !bodySet && !changelogSet && !tagSet && !scopeSet && !trailersSet {
 		printErrorJSON("invalid_input",
-			"nothing to edit — pass at least one of --title/--body/--changelog/--tag/--scope")
+			"nothing to edit — pass at least one of --title/--body/--changelog/--tag/--scope/--trailer")
 		return 2
 	}
 
@@ -93,10 +105,10 @@ func runEdit(args []string) int {
 	}
 
 	if res.Kind == kindRelease {
-		if changelogSet || tagSet || scopeSet {
+		if changelogSet || tagSet || scopeSet || trailersSet {
 			printErrorJSON(
 				"unsupported_field_for_release",
-				"release drafts only accept --title and --body (got --changelog/--tag/--scope)",
+				"release drafts only accept --title and --body (got --changelog/--tag/--scope/--trailer)",
 			)
 			return 2
 		}
@@ -194,6 +206,14 @@ func runEdit(args []string) int {
 		c.Scope = s
 	}
 
+	if trailersSet {
+		next, code := applyTrailerEdits(bs, c, trailers, *clearTrailers)
+		if code != 0 {
+			return code
+		}
+		c.Trailers = next
+	}
+
 	// Recompose MessageEN, preserving the changelog mention line that
 	// the refiner appended on the previous run when possible.
 	mention := extractMentionLine(oldFinal, oldTitle, oldBody)
@@ -209,7 +229,7 @@ func runEdit(args []string) int {
 		saved = c
 	}
 	stages := loadStagesForCommit(bs.db, saved.ID)
-	cj, err := commitToJSON(saved, stages, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := commitToJSON(saved, stages, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_commit", err.Error())
 		return 1
@@ -218,6 +238,48 @@ func runEdit(args []string) int {
 	return 0
 }
 
+// applyTrailerEdits returns c's trailer list after --clear-trailers and
+// the expanded --trailer values. Duplicates (same token and value) are
+// dropped so re-running the same edit is a no-op.
+func applyTrailerEdits(bs *bootstrap, c storage.Commit, raw []string, clear bool) ([]string, int) {
+	var out []string
+	if !clear {
+		out = append(out, c.Trailers...)
+	}
+	var identity string
+	for _, r := range raw {
+		if identity == "" && isSignOffShorthand(r) {
+			ws := strings.TrimSpace(c.Workspace)
+			if ws == "" {
+				ws = bs.pwd
+			}
+			id, err := git.IdentityAt(ws)
+			if err != nil {
+				printErrorJSON("git_error", err.Error())
+				return nil, 1
+			}
+			identity = id
+		}
+		line, err := bs.cfg.Trailers.ExpandTrailer(r, identity)
+		if err != nil {
+			printErrorJSON("invalid_input", fmt.Sprintf("--trailer: %s", err.Error()))
+			return nil, 2
+		}
+		if !slices.Contains(out, line) {
+			out = append(out, line)
+		}
+	}
+	return out, 0
+}
+
+func isSignOffShorthand(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "signoff", "sign-off":
+		return true
+	}
+	return false
+}
+
 // editRelease applies --title / --body overrides to a release draft.
 // Other edit flags are rejected upstream because they don't make
 // sense for release rows (scope is derived from branch/version,
@@ -282,7 +344,7 @@ func editRelease(
 	if err != nil {
 		saved = r
 	}
-	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_release", err.Error())
 		return 1
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/scope"
 	"commit_craft_reborn/internal/storage"
 )
 
@@ -18,10 +20,10 @@ func runGenerate(args []string) int {
 	fs := flagSet("ai generate")
 	var keypoints stringSlice
 	var scopes stringSlice
-	tag := fs.String("tag", "", "Commit type tag (validated against the resolved type list)")
+	tag := fs.String("tag", "", "Commit type tag (validated against the resolved type list); 'auto' infers it from the staged diff")
 	fs.Var(&keypoints, "keypoint", "Add a keypoint (repeatable)")
 	fs.Var(&keypoints, "k", "Shorthand for --keypoint")
-	fs.Var(&scopes, "scope", "Add a scope (repeatable, joined with newlines for the AI)")
+	fs.Var(&scopes, "scope", "Add a scope (repeatable, joined with newlines for the AI); 'auto' infers it from the staged files")
 	fs.Var(&scopes, "s", "Shorthand for --scope")
 	fs.StringVar(tag, "t", *tag, "Shorthand for --tag")
 	noChangelog := fs.Bool(
@@ -34,6 +36,11 @@ func runGenerate(args []string) int {
 		false,
 		"Run the AI pipeline without persisting a draft row. Returns the same JSON with id=0 and status=dry_run.",
 	)
+	stream := fs.Bool(
+		"stream",
+		false,
+		"Emit NDJSON on stdout: one {\"type\":\"chunk\"} line per streamed delta, then a {\"type\":\"result\"} line with the commit JSON.",
+	)
 	af := registerAgentFlags(fs)
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
@@ -48,11 +55,11 @@ func runGenerate(args []string) int {
 		return 2
 	}
 	if *tag == "" {
-		printErrorJSON("invalid_input", "--tag is required")
+		printErrorJSON("invalid_input", "--tag is required (or --tag auto)")
 		return 2
 	}
 	if len(scopes) == 0 {
-		printErrorJSON("invalid_input", "at least one --scope is required")
+		printErrorJSON("invalid_input", "at least one --scope is required (or --scope auto)")
 		return 2
 	}
 
@@ -63,7 +70,8 @@ func runGenerate(args []string) int {
 	}
 	defer bs.db.Close()
 
-	if !tagIsKnown(*tag, bs.finalCommitTypes) {
+	autoTag := strings.EqualFold(*tag, tagAuto)
+	if !autoTag && !tagIsKnown(*tag, bs.finalCommitTypes) {
 		printErrorJSON("invalid_input",
 			fmt.Sprintf("unknown tag %q — run `commitcraft ai list-tags` to see valid tags", *tag))
 		return 2
@@ -75,31 +83,74 @@ func runGenerate(args []string) int {
 		return 1
 	}
 
+	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}
+	ctx, stop := signalContext()
+	defer stop()
+
+	delegate, err := resolveAgentMode(bs.cfg, af)
+	if err != nil {
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	if delegate && *stream {
+		printErrorJSON("invalid_input", "--stream cannot be combined with delegate mode")
+		return 2
+	}
+
+	var inferred *scope.Inference
+	if len(scopes) == 1 && strings.EqualFold(scopes[0], scopeAuto) {
+		inf, err := inferStagedScope(bs)
+		if err != nil {
+			printErrorJSON("scope_inference_error", err.Error())
+			return 1
+		}
+		warnMultiPackage(inf)
+		inferred = &inf
+		scopes = stringSlice{inf.Scope}
+	}
+
+	var tagSuggestion *aiengine.TagSuggestion
+	if autoTag {
+		sug, err := inferStagedTag(ctx, deps, bs, diff, keypoints, delegate)
+		if err != nil {
+			printErrorJSON("tag_inference_error", err.Error())
+			return 1
+		}
+		warnLowConfidence(sug, bs.cfg.TagInference.MinConfidence)
+		tagSuggestion = &sug
+		*tag = sug.Tag
+	}
+
 	scope := strings.Join(scopes, "\n")
 	in := aiengine.Input{
 		KeyPoints:       keypoints,
 		Type:            *tag,
 		Scope:           scope,
 		Diff:            diff,
+		LiveDiff:        true,
 		ChangelogActive: !*noChangelog && bs.cfg.Changelog.Enabled,
 	}
 
-	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}
-
-	delegate, err := resolveAgentMode(bs.cfg, af)
-	if err != nil {
-		printErrorJSON("invalid_input", err.Error())
-		return 2
-	}
 	if delegate {
 		// Delegate mode: emit the prompt bundle for the agent to fulfill.
 		// No Groq call, no draft — the agent returns the result via
 		// `ai submit`.
-		printJSON(aiengine.BuildCommitBundle(deps, in, resolveStrategy(bs.cfg, af), "generate", 0))
+		b, err := aiengine.BuildCommitBundle(
+			ctx, deps, in, resolveStrategy(bs.cfg, af), "generate", 0,
+		)
+		if err != nil {
+			printAIRunError(bs, err)
+			return 1
+		}
+		printJSON(b)
 		return 0
 	}
 
-	out, err := aiengine.Run(deps, in)
+	if *stream {
+		deps.OnChunk = streamChunkSink
+		deps.OnRetry = streamRetrySink
+	}
+	out, err := aiengine.Run(ctx, deps, in)
 	if err != nil {
 		printAIRunError(bs, err)
 		return 1
@@ -123,12 +174,14 @@ func runGenerate(args []string) int {
 		// Skip all DB writes. Return the pipeline output with id=0 so the
 		// caller can inspect final_message without polluting the drafts list.
 		c.Status = "dry_run"
-		cj, err := commitToJSON(c, out.Stages, bs.cfg.CommitFormat.TypeFormat)
+		cj, err := commitToJSON(c, out.Stages, bs.cfg.CommitFormat.MessageFormat())
 		if err != nil {
 			printErrorJSON("incomplete_commit", err.Error())
 			return 1
 		}
-		printCommitJSON(cj)
+		cj.ScopeInference = inferred
+		cj.TagInference = tagSuggestion
+		printCommitResult(cj, *stream)
 		return 0
 	}
 
@@ -149,11 +202,83 @@ func runGenerate(args []string) int {
 		saved = c
 		saved.Status = "draft"
 	}
-	cj, err := commitToJSON(saved, out.Stages, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := commitToJSON(saved, out.Stages, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_commit", err.Error())
 		return 1
 	}
-	printCommitJSON(cj)
+	cj.ScopeInference = inferred
+	cj.TagInference = tagSuggestion
+	printCommitResult(cj, *stream)
 	return 0
 }
+
+// scopeAuto is the --scope value that asks for an inferred scope.
+const scopeAuto = "auto"
+
+// inferStagedScope proposes a scope for the staged files from the
+// repository's package layout and the resolved [scope] config.
+func inferStagedScope(bs *bootstrap) (scope.Inference, error) {
+	inf, err := scope.Staged(bs.pwd, scope.Options{
+		Manifests: bs.cfg.Scope.Manifests,
+		Map:       bs.cfg.Scope.Map,
+	})
+	if err != nil {
+		return inf, err
+	}
+	if inf.Scope == "" {
+		return inf, errors.New("no staged files to infer a scope from")
+	}
+	return inf, nil
+}
+
+// warnMultiPackage flags a commit that spans several packages on stderr;
+// the JSON carries the full candidate list.
+func warnMultiPackage(inf scope.Inference) {
+	if inf.Multi {
+		fmt.Fprintf(os.Stderr,
+			"warning: staged files span several packages (%s); consider `commitcraft ai split`\n",
+			inf.Summary())
+	}
+}
+
+// tagAuto is the --tag value that asks for an inferred commit type.
+const tagAuto = "auto"
+
+// inferStagedTag proposes a commit type for the staged files. Delegate
+// mode keeps to the file rules so the command makes no model call; the
+// agent can still override the tag before submitting.
+func inferStagedTag(
+	ctx context.Context,
+	deps aiengine.Deps,
+	bs *bootstrap,
+	diff string,
+	keypoints []string,
+	delegate bool,
+) (aiengine.TagSuggestion, error) {
+	files, err := aiengine.StagedSplitFiles()
+	if err != nil {
+		return aiengine.TagSuggestion{}, err
+	}
+	if delegate {
+		deps.Cfg.TagInference.UseModel = false
+	}
+	sug, err := aiengine.ClassifyTag(ctx, deps, files, diff, keypoints, bs.finalCommitTypes)
+	if err != nil {
+		return sug, err
+	}
+	if sug.Warning != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", sug.Warning)
+	}
+	return sug, nil
+}
+
+// warnLowConfidence flags an inferred tag below [tag_inference]
+// min_confidence on stderr; the JSON carries the suggestion either way.
+func warnLowConfidence(sug aiengine.TagSuggestion, minConfidence float64) {
+	if sug.Confidence < minConfidence {
+		fmt.Fprintf(os.Stderr,
+			"warning: inferred tag %s has low confidence (%d%%, %s); pass --tag explicitly if it is wrong\n",
+			sug.Tag, sug.Percent(), sug.Reason)
+	}
+}
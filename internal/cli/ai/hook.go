@@ -0,0 +1,512 @@
+package ai
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+const hookUsage = `Usage: commitcraft hook <subcommand> [flags]
+
+Subcommands:
+  install     Install the prepare-commit-msg and commit-msg hooks into the repo.
+  uninstall   Remove the hooks installed by 'install' and restore any backed-up ones.
+  run         Hook entry point called by git: run <prepare-commit-msg|commit-msg> <args…>.
+
+Run 'commitcraft hook <subcommand> -h' for the flags of each subcommand.
+`
+
+// Hook names managed by `commitcraft hook`.
+const (
+	hookPrepareCommitMsg = "prepare-commit-msg"
+	hookCommitMsg        = "commit-msg"
+)
+
+var managedHooks = []string{hookPrepareCommitMsg, hookCommitMsg}
+
+// hookMarker identifies scripts written by `hook install` so uninstall
+// (and a re-install) never touches a hook the user wrote themselves.
+const hookMarker = "# Installed by `commitcraft hook install`"
+
+// hookBackupSuffix is appended to a pre-existing hook moved aside by
+// `hook install --force`; the shim keeps running it and uninstall moves
+// it back.
+const hookBackupSuffix = ".commitcraft-backup"
+
+// DispatchHook is the entry point for `commitcraft hook …`, invoked from
+// cmd/cli/main.go. Unlike the `ai` subcommands its output is plain text:
+// it is read by a person in a terminal or in git's hook output, not by
+// an agent. Returns the process exit code.
+func DispatchHook(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, hookUsage)
+		return 2
+	}
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "install":
+		return runHookInstall(rest)
+	case "uninstall":
+		return runHookUninstall(rest)
+	case "run":
+		return runHookRun(rest)
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, hookUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n\n%s", sub, hookUsage)
+		return 2
+	}
+}
+
+// hookScript is the shell shim git executes. A hook moved aside by
+// `hook install --force` runs first and its failure fails the hook, so
+// installing never drops the repo's own checks. The shim then calls the
+// binary that ran `hook install` by absolute path so the hook works from
+// GUI clients with a minimal PATH, falls back to PATH when that binary
+// moved, and lets the commit through (with a warning) when commitcraft
+// is gone altogether rather than blocking every commit in the repo.
+func hookScript(exe, name string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s; remove with `+"`commitcraft hook uninstall`"+`.
+backup="$(dirname "$0")/%s%s"
+if [ -x "$backup" ]; then
+	"$backup" "$@" || exit $?
+fi
+commitcraft=%q
+if [ ! -x "$commitcraft" ]; then
+	commitcraft=$(command -v commitcraft) || {
+		echo "commitcraft not found; skipping the %s hook" >&2
+		exit 0
+	}
+fi
+exec "$commitcraft" hook run %s "$@"
+`, hookMarker, name, hookBackupSuffix, exe, name, name)
+}
+
+// isManagedHook reports whether the file at path was written by
+// `hook install`.
+func isManagedHook(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), hookMarker)
+}
+
+func runHookInstall(args []string) int {
+	fs := flagSet("hook install")
+	force := fs.Bool(
+		"force",
+		false,
+		"Move existing hooks aside (to <hook>"+hookBackupSuffix+", still run first) instead of refusing to install",
+	)
+	workspace := fs.String(
+		"workspace",
+		"",
+		"Repo to install into. Defaults to the current directory.",
+	)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	dir, err := git.HooksDirAt(strings.TrimSpace(*workspace))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not resolve the commitcraft binary: %v\n", err)
+		return 1
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	// Check every hook before writing any so a refusal leaves the repo
+	// untouched.
+	for _, name := range managedHooks {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !isManagedHook(path) && !*force {
+			fmt.Fprintf(os.Stderr,
+				"%s already exists and was not installed by commitcraft; re-run with --force to back it up and replace it\n",
+				path)
+			return 1
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "could not create %s: %v\n", dir, err)
+		return 1
+	}
+	for _, name := range managedHooks {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !isManagedHook(path) {
+			if err := os.Rename(path, path+hookBackupSuffix); err != nil {
+				fmt.Fprintf(os.Stderr, "could not back up %s: %v\n", path, err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "backed up %s to %s\n", name, name+hookBackupSuffix)
+		}
+		if err := os.WriteFile(path, []byte(hookScript(exe, name)), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "installed %s\n", path)
+	}
+	return 0
+}
+
+func runHookUninstall(args []string) int {
+	fs := flagSet("hook uninstall")
+	workspace := fs.String(
+		"workspace",
+		"",
+		"Repo to uninstall from. Defaults to the current directory.",
+	)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	dir, err := git.HooksDirAt(strings.TrimSpace(*workspace))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, name := range managedHooks {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if !isManagedHook(path) {
+			fmt.Fprintf(os.Stderr, "leaving %s alone: not installed by commitcraft\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "could not remove %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "removed %s\n", path)
+		if _, err := os.Stat(path + hookBackupSuffix); err == nil {
+			if err := os.Rename(path+hookBackupSuffix, path); err != nil {
+				fmt.Fprintf(os.Stderr, "could not restore %s: %v\n", path, err)
+				return 1
+			}
+			fmt.Fprintf(os.Stdout, "restored the previous %s\n", name)
+		}
+	}
+	return 0
+}
+
+// runHookRun is what the installed shims exec. It never fails a commit
+// because of its own problems (missing config, DB, API errors): only a
+// commit-msg verify error rejects the commit.
+func runHookRun(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: commitcraft hook run <prepare-commit-msg|commit-msg> <args…>")
+		return 2
+	}
+	switch args[0] {
+	case hookPrepareCommitMsg:
+		return runPrepareCommitMsg(args[1:])
+	case hookCommitMsg:
+		return runCommitMsg(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown hook %q\n", args[0])
+		return 2
+	}
+}
+
+// runPrepareCommitMsg pre-fills the message file for a plain
+// `git commit` (no -m/-F/template/merge/squash/amend source). The
+// message is the newest draft in this workspace whose stored diff
+// matches the staged diff; without one, and with [hooks].generate on,
+// the pipeline runs and its result is saved as a draft. git's comment
+// block stays below the message.
+func runPrepareCommitMsg(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "prepare-commit-msg: missing message file argument")
+		return 2
+	}
+	msgFile := args[0]
+	if len(args) > 1 && args[1] != "" {
+		return 0
+	}
+	existing, err := os.ReadFile(msgFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: %v\n", err)
+		return 0
+	}
+	if strings.TrimSpace(stripCommitComments(string(existing))) != "" {
+		return 0
+	}
+
+	bs, err := loadBootstrap()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: %v\n", err)
+		return 0
+	}
+	defer bs.db.Close()
+
+	diff, err := git.GetStagedDiffSummary(bs.cfg.Prompts.ChangeAnalyzerMaxDiffSize)
+	if err != nil || strings.TrimSpace(diff) == "" {
+		return 0
+	}
+
+	msg, source := matchingDraftMessage(bs, diff)
+	if msg == "" && bs.cfg.Hooks.Generate {
+		msg, source = generateHookMessage(bs, diff)
+	}
+	if msg == "" {
+		return 0
+	}
+	if commentChar, strips := git.CommentCleanupAt(""); strips {
+		var moved int
+		if msg, moved = protectCommentLines(msg, commentChar); moved > 0 {
+			fmt.Fprintf(os.Stderr,
+				"commitcraft: indented %d line(s) starting with %q so git does not strip them\n",
+				moved, commentChar)
+		}
+	}
+	if err := os.WriteFile(msgFile, []byte(msg+"\n\n"+string(existing)), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: %v\n", err)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "commitcraft: message pre-filled from %s\n", source)
+	return 0
+}
+
+// protectCommentLines indents by one space every line of msg starting
+// with commentChar, which commit.cleanup=strip would otherwise drop
+// from the recorded message (a Markdown heading, a "#123" reference).
+// Returns the message and the number of lines indented.
+func protectCommentLines(msg, commentChar string) (string, int) {
+	lines := strings.Split(msg, "\n")
+	moved := 0
+	for i, line := range lines {
+		if strings.HasPrefix(line, commentChar) {
+			lines[i] = " " + line
+			moved++
+		}
+	}
+	return strings.Join(lines, "\n"), moved
+}
+
+// matchingDraftMessage returns the final message of the newest draft in
+// the worktree whose diff snapshot equals diff, plus a label naming it.
+// Git runs hooks from the top level, so drafts saved from a
+// subdirectory are found through the worktree identity.
+func matchingDraftMessage(bs *bootstrap, diff string) (string, string) {
+	drafts, err := listCommits(bs, "draft", false)
+	if err != nil {
+		return "", ""
+	}
+	for _, d := range drafts {
+		if d.MessageEN == "" || strings.TrimSpace(d.Diff_code) != strings.TrimSpace(diff) {
+			continue
+		}
+		final, err := composeCommitFinalMessage(d, bs.cfg.CommitFormat.MessageFormat())
+		if err != nil {
+			continue
+		}
+		return final, fmt.Sprintf("draft %d", d.ID)
+	}
+	return "", ""
+}
+
+// generateHookMessage runs the pipeline for the staged diff with the
+// configured default tag, the inferred scope of the staged paths and the
+// file list as keypoints, then saves the result as a draft (source
+// "hook") so it shows up in the TUI history. Failures only warn.
+func generateHookMessage(bs *bootstrap, diff string) (string, string) {
+	tag := strings.TrimSpace(bs.cfg.Hooks.DefaultTag)
+	if !tagIsKnown(tag, bs.finalCommitTypes) {
+		fmt.Fprintf(os.Stderr, "commitcraft: [hooks].default_tag %q is not a known tag\n", tag)
+		return "", ""
+	}
+	files, err := git.StagedFilesAt("", false)
+	if err != nil || len(files) == 0 {
+		return "", ""
+	}
+	in := aiengine.Input{
+		KeyPoints: hookKeyPoints(files),
+		Type:      tag,
+		Scope:     hookScope(bs, files),
+		Diff:      diff,
+		LiveDiff:  true,
+	}
+
+	fmt.Fprintln(os.Stderr, "commitcraft: generating a commit message…")
+	ctx, stop := signalContext()
+	defer stop()
+	out, err := aiengine.Run(ctx, aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}, in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: generation failed: %v\n", err)
+		return "", ""
+	}
+
+	c := storage.Commit{
+		Type:        in.Type,
+		Scope:       in.Scope,
+		KeyPoints:   in.KeyPoints,
+		Workspace:   bs.pwd,
+		Diff_code:   diff,
+		IaSummary:   out.Summary,
+		IaCommitRaw: out.Body,
+		IaTitle:     out.Title,
+		MessageEN:   out.FinalMessage,
+		Source:      "hook",
+	}
+	final, err := composeCommitFinalMessage(c, bs.cfg.CommitFormat.MessageFormat())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: %v\n", err)
+		return "", ""
+	}
+	if err := bs.db.SaveDraft(&c); err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: saving the draft failed: %v\n", err)
+		return final, "a generated message"
+	}
+	if err := persistAICalls(bs.db, c.ID, out.Stages); err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: ai_calls persistence failed: %v\n", err)
+	}
+	return final, fmt.Sprintf("generated draft %d", c.ID)
+}
+
+// hookMaxKeyPointFiles caps the file list fed as keypoints so a large
+// commit doesn't crowd the prompt; the diff carries the detail anyway.
+const hookMaxKeyPointFiles = 20
+
+// hookKeyPoints stands in for the user's keypoints, which a hook cannot
+// ask for: one line naming the staged files.
+func hookKeyPoints(files []string) []string {
+	shown := files
+	suffix := ""
+	if len(files) > hookMaxKeyPointFiles {
+		shown = files[:hookMaxKeyPointFiles]
+		suffix = fmt.Sprintf(" (+%d more)", len(files)-hookMaxKeyPointFiles)
+	}
+	return []string{"Describe the staged changes to " + strings.Join(shown, ", ") + suffix}
+}
+
+// hookScope picks a scope from the staged paths: the inferred scope
+// (see inferStagedScope), or when inference fails their shared top-level
+// directory, the file name for a single root-level file, or the repo
+// directory name when the changes span the tree.
+func hookScope(bs *bootstrap, files []string) string {
+	if inf, err := inferStagedScope(bs); err == nil {
+		return inf.Scope
+	}
+	pwd := bs.pwd
+	top := ""
+	for i, f := range files {
+		dir, _, found := strings.Cut(filepath.ToSlash(f), "/")
+		if !found {
+			dir = ""
+		}
+		if i == 0 {
+			top = dir
+		} else if dir != top {
+			top = ""
+			break
+		}
+	}
+	switch {
+	case top != "":
+		return top
+	case len(files) == 1:
+		return strings.TrimSuffix(filepath.Base(files[0]), filepath.Ext(files[0]))
+	default:
+		return filepath.Base(pwd)
+	}
+}
+
+// runCommitMsg verifies the message git is about to record and rejects
+// the commit when the report has errors. Merge, revert and autosquash
+// messages are written by git itself and skipped.
+func runCommitMsg(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "commit-msg: missing message file argument")
+		return 2
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commitcraft: %v\n", err)
+		return 0
+	}
+	msg := strings.TrimSpace(stripCommitComments(string(data)))
+	if msg == "" || isGitGeneratedMessage(msg) {
+		return 0
+	}
+
+	showWarnings := true
+	profile := ""
+	if bs, err := loadBootstrap(); err == nil {
+		showWarnings = bs.cfg.Hooks.VerifyWarnings
+		profile = bs.cfg.CommitFormat.Profile
+		bs.db.Close()
+	}
+
+	report := aiengine.VerifyFinalMessageFor(profile, msg)
+	for _, f := range report.Findings {
+		if f.Severity != "error" && !showWarnings {
+			continue
+		}
+		loc := ""
+		if f.Location != "" {
+			loc = " (" + f.Location + ")"
+		}
+		fmt.Fprintf(os.Stderr, "commitcraft: %s %s%s: %s\n", f.Severity, f.Rule, loc, f.Message)
+	}
+	if report.HasErrors {
+		fmt.Fprintln(os.Stderr,
+			"commitcraft: commit rejected; fix the message or bypass with `git commit --no-verify`")
+		return 1
+	}
+	return 0
+}
+
+// gitGeneratedPrefixes are subjects git (or `git commit --fixup`) writes
+// on its own; they never follow the project's title format.
+var gitGeneratedPrefixes = []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "}
+
+func isGitGeneratedMessage(msg string) bool {
+	for _, p := range gitGeneratedPrefixes {
+		if strings.HasPrefix(msg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCommitComments drops git's comment lines ("#", "# …", "#\t…") and
+// everything below the `--verbose` scissors line, mirroring what
+// `--cleanup=strip` leaves. Markdown headings ("## …") are kept.
+func stripCommitComments(msg string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, "# ") && strings.Contains(line, ">8") {
+			break
+		}
+		if line == "#" || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "#\t") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
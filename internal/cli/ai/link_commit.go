@@ -115,7 +115,7 @@ func runLinkCommit(args []string) int {
 		cj, ferr := commitToJSON(
 			saved,
 			loadStagesForCommit(boot.db, saved.ID),
-			boot.cfg.CommitFormat.TypeFormat,
+			boot.cfg.CommitFormat.MessageFormat(),
 		)
 		if ferr != nil {
 			printErrorJSON("format_error", ferr.Error())
@@ -133,7 +133,7 @@ func runLinkCommit(args []string) int {
 			saved = *res.Release
 			saved.CommitHash = fullHash
 		}
-		cj, ferr := releaseToJSON(saved, boot.cfg.CommitFormat.TypeFormat)
+		cj, ferr := releaseToJSON(saved, boot.cfg.CommitFormat.MessageFormat())
 		if ferr != nil {
 			printErrorJSON("format_error", ferr.Error())
 			return 1
@@ -8,6 +8,9 @@ import (
 	"os"
 	"sort"
 	"strings"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
 )
 
 // listEntry is a compact summary returned by `ai list`. Useful for
@@ -24,13 +27,22 @@ type listEntry struct {
 	Source       string `json:"source,omitempty"`
 	TitleSnippet string `json:"title_snippet"`
 	CreatedAt    string `json:"created_at"`
+	// Worktree is the checkout a commit draft was written in; set for
+	// commit rows once its identity has been resolved.
+	Worktree string `json:"worktree,omitempty"`
 }
 
 // runList prints a JSON array of drafts/commits in the current
-// workspace, optionally filtered by status.
+// worktree (or, with --all-worktrees, every worktree of the repository),
+// optionally filtered by status.
 func runList(args []string) int {
 	fs := flagSet("ai list")
 	status := fs.String("status", "draft", "Filter by status (draft | completed)")
+	allWorktrees := fs.Bool(
+		"all-worktrees",
+		false,
+		"List commit drafts from every worktree of this repository, not just the current one",
+	)
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return 0
@@ -51,7 +63,7 @@ func runList(args []string) int {
 	}
 	defer bs.db.Close()
 
-	commits, err := bs.db.GetCommits(bs.pwd, *status)
+	commits, err := listCommits(bs, *status, *allWorktrees)
 	if err != nil {
 		printErrorJSON("db_error", err.Error())
 		return 1
@@ -76,6 +88,7 @@ func runList(args []string) int {
 			Source:       c.Source,
 			TitleSnippet: title,
 			CreatedAt:    c.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Worktree:     c.Worktree,
 		})
 	}
 	for _, r := range releases {
@@ -106,3 +119,21 @@ func runList(args []string) int {
 	_ = enc.Encode(out)
 	return 0
 }
+
+// listCommits returns the commits of the current checkout: every row
+// written inside its worktree, or inside any worktree of the same
+// repository with all. Outside git it falls back to the rows written
+// from pwd itself.
+func listCommits(bs *bootstrap, status string, all bool) ([]storage.Commit, error) {
+	if err := bs.db.ResolveWorkspaceIDs(git.WorkspaceKeys); err != nil {
+		return nil, err
+	}
+	id, err := git.ResolveWorkspace(bs.pwd)
+	if err != nil {
+		return bs.db.GetCommits(bs.pwd, status)
+	}
+	if all {
+		return bs.db.GetCommitsByRepo(id.RepoID, status)
+	}
+	return bs.db.GetCommitsByWorktree(id.Worktree, status)
+}
@@ -100,6 +100,8 @@ func runMerge(args []string) int {
 
 	in := aiengine.ReleaseInput{Commits: projectToReleaseCommits(commits)}
 	deps := aiengine.Deps{Cfg: boot.cfg, DB: boot.db, Log: boot.log, Pwd: ws}
+	ctx, stop := signalContext()
+	defer stop()
 
 	delegate, derr := resolveAgentMode(boot.cfg, af)
 	if derr != nil {
@@ -110,7 +112,8 @@ func runMerge(args []string) int {
 		// Delegate mode: emit a [MERGE] release bundle. The agent returns the
 		// note via `ai submit` with kind:"release". The commit list is carried
 		// in the prompt; the agent copies it back as commit_list. No Groq call.
-		b := aiengine.BuildReleaseBundle(
+		b, err := aiengine.BuildReleaseBundle(
+			ctx,
 			deps,
 			in,
 			"MERGE",
@@ -121,11 +124,15 @@ func runMerge(args []string) int {
 			"",
 			serializeCommitRange(commits),
 		)
+		if err != nil {
+			printAIRunError(boot, err)
+			return 1
+		}
 		printJSON(b)
 		return 0
 	}
 
-	out, err := aiengine.RunRelease(deps, in)
+	out, err := aiengine.RunRelease(ctx, deps, in)
 	if err != nil {
 		printAIRunError(boot, err)
 		return 1
@@ -152,7 +159,7 @@ func runMerge(args []string) int {
 		saved = r
 	}
 
-	cj, err := releaseToJSON(saved, boot.cfg.CommitFormat.TypeFormat)
+	cj, err := releaseToJSON(saved, boot.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("format_error", err.Error())
 		return 1
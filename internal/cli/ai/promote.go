@@ -13,8 +13,8 @@ import (
 
 // runPromote flips a draft to status='completed' via FinalizeCommit.
 // It does not execute `git commit` — the caller is expected to take
-// the printed final_message and commit it themselves (or re-run the
-// TUI flow). Idempotent: promoting an already-completed row is a
+// the printed final_message and commit it themselves, or use
+// `ai commit` to do both in one step. Idempotent: promoting an already-completed row is a
 // no-op that still prints the latest JSON.
 func runPromote(args []string) int {
 	fs := flagSet("ai promote")
@@ -63,12 +63,36 @@ func runPromote(args []string) int {
 	}
 
 	c := *res.Commit
+	if code := promoteCommit(bs, c, *noChangelogWrite); code != 0 {
+		return code
+	}
+
+	saved, err := bs.db.GetCommitByID(c.ID)
+	if err != nil {
+		saved = c
+		saved.Status = "completed"
+	}
+	stages := loadStagesForCommit(bs.db, saved.ID)
+	cj, err := commitToJSON(saved, stages, bs.cfg.CommitFormat.MessageFormat())
+	if err != nil {
+		printErrorJSON("incomplete_commit", err.Error())
+		return 1
+	}
+	printCommitJSON(cj)
+	return 0
+}
+
+// promoteCommit finalizes a commit draft and, when the changelog is
+// enabled, prepends and stages its entry. Errors are printed as JSON;
+// the return value is the exit code (0 on success). Shared with
+// `ai commit`, which promotes before running git commit.
+func promoteCommit(bs *bootstrap, c storage.Commit, noChangelogWrite bool) int {
 	if c.MessageEN == "" {
 		printErrorJSON(
 			"invalid_input",
 			fmt.Sprintf(
 				"draft id=%d has no final_message yet — run `ai generate` or `ai regenerate` first",
-				*id,
+				c.ID,
 			),
 		)
 		return 1
@@ -83,7 +107,7 @@ func runPromote(args []string) int {
 	// the draft is promoted. Re-detect the path here (workspace + config)
 	// instead of persisting it — same logic the TUI uses, idempotent on
 	// re-promote, and avoids a schema migration.
-	if bs.cfg.Changelog.Enabled && c.IaChangelog != "" && !*noChangelogWrite {
+	if bs.cfg.Changelog.Enabled && c.IaChangelog != "" && !noChangelogWrite {
 		info, derr := changelog.Detect(c.Workspace, bs.cfg.Changelog.Path)
 		if derr != nil || info == nil || info.Path == "" {
 			msg := "changelog target not found"
@@ -102,19 +126,6 @@ func runPromote(args []string) int {
 			return 1
 		}
 	}
-
-	saved, err := bs.db.GetCommitByID(c.ID)
-	if err != nil {
-		saved = c
-		saved.Status = "completed"
-	}
-	stages := loadStagesForCommit(bs.db, saved.ID)
-	cj, err := commitToJSON(saved, stages, bs.cfg.CommitFormat.TypeFormat)
-	if err != nil {
-		printErrorJSON("incomplete_commit", err.Error())
-		return 1
-	}
-	printCommitJSON(cj)
 	return 0
 }
 
@@ -142,7 +153,7 @@ func promoteRelease(bs *bootstrap, r storage.Release) int {
 		saved = r
 		saved.Status = "completed"
 	}
-	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_release", err.Error())
 		return 1
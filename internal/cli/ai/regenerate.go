@@ -136,6 +136,8 @@ func runRegenerate(args []string) int {
 		pwd = bs.pwd
 	}
 	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: pwd}
+	ctx, stop := signalContext()
+	defer stop()
 	changelogActive := !*noChangelog && bs.cfg.Changelog.Enabled
 
 	delegate, derr := resolveAgentMode(bs.cfg, af)
@@ -154,9 +156,14 @@ func runRegenerate(args []string) int {
 			Diff:            c.Diff_code,
 			ChangelogActive: changelogActive,
 		}
-		printJSON(
-			aiengine.BuildCommitBundle(deps, in, resolveStrategy(bs.cfg, af), "regenerate", c.ID),
+		b, err := aiengine.BuildCommitBundle(
+			ctx, deps, in, resolveStrategy(bs.cfg, af), "regenerate", c.ID,
 		)
+		if err != nil {
+			printAIRunError(bs, err)
+			return 1
+		}
+		printJSON(b)
 		return 0
 	}
 
@@ -171,14 +178,16 @@ func runRegenerate(args []string) int {
 			Diff:            c.Diff_code,
 			ChangelogActive: changelogActive,
 		}
-		out, err = aiengine.Run(deps, in)
+		out, err = aiengine.Run(ctx, deps, in)
 		if err != nil {
+			persistFailedAttempts(bs.db, c.ID, out.Stages)
 			printAIRunError(bs, err)
 			return 1
 		}
 	case "body", "title", "changelog":
-		out, err = runStagePartial(deps, c, *stage, changelogActive)
+		out, err = runStagePartial(ctx, deps, c, *stage, changelogActive)
 		if err != nil {
+			persistFailedAttempts(bs.db, c.ID, out.Stages)
 			printAIRunError(bs, err)
 			return 1
 		}
@@ -205,7 +214,7 @@ func runRegenerate(args []string) int {
 	if err != nil {
 		saved = c
 	}
-	cj, err := commitToJSON(saved, out.Stages, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := commitToJSON(saved, out.Stages, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_commit", err.Error())
 		return 1
@@ -19,16 +19,19 @@ import (
 // normal storage.Commit row with Type="RELEASE" and Scope=<version>,
 // so ai edit / ai show / ai verify / ai promote all work on it.
 //
-// This subcommand only DRAFTS the release notes. Publishing (gh
-// release create, tag push, binary upload) stays a follow-up
-// (`ai release publish`) so the agent can stop at promote without
-// needing GH credentials.
+// This subcommand only DRAFTS the release notes. Publishing (tag,
+// opt-in tag push, hosted release with the binary assets) is the separate
+// `ai release publish --id N` action (runReleasePublish) so the agent
+// can stop at promote without needing GH credentials.
 //
 // Storage caveat: the TUI persists release runs to a separate
 // `releases` table (storage.Release); the headless flow writes to
 // `commits` instead. The two surfaces don't see each other's drafts
 // today. A future unit can unify them.
 func runRelease(args []string) int {
+	if len(args) > 0 && args[0] == "publish" {
+		return runReleasePublish(args[1:])
+	}
 	fs := flagSet("ai release")
 	version := fs.String(
 		"version",
@@ -117,6 +120,8 @@ func runRelease(args []string) int {
 
 	in := aiengine.ReleaseInput{Commits: projectToReleaseCommits(commits)}
 	deps := aiengine.Deps{Cfg: boot.cfg, DB: boot.db, Log: boot.log, Pwd: ws}
+	ctx, stop := signalContext()
+	defer stop()
 
 	delegate, derr := resolveAgentMode(boot.cfg, af)
 	if derr != nil {
@@ -126,7 +131,8 @@ func runRelease(args []string) int {
 	if delegate {
 		// Delegate mode: emit a [RELEASE] release bundle. The agent returns the
 		// note via `ai submit` with kind:"release". No Groq call.
-		b := aiengine.BuildReleaseBundle(
+		b, err := aiengine.BuildReleaseBundle(
+			ctx,
 			deps,
 			in,
 			"RELEASE",
@@ -137,11 +143,15 @@ func runRelease(args []string) int {
 			versionStr,
 			serializeCommitRange(commits),
 		)
+		if err != nil {
+			printAIRunError(boot, err)
+			return 1
+		}
 		printJSON(b)
 		return 0
 	}
 
-	out, err := aiengine.RunRelease(deps, in)
+	out, err := aiengine.RunRelease(ctx, deps, in)
 	if err != nil {
 		printAIRunError(boot, err)
 		return 1
@@ -168,7 +178,7 @@ func runRelease(args []string) int {
 		saved = r
 	}
 
-	cj, err := releaseToJSON(saved, boot.cfg.CommitFormat.TypeFormat)
+	cj, err := releaseToJSON(saved, boot.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("format_error", err.Error())
 		return 1
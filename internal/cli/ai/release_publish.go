@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"commit_craft_reborn/internal/release"
+)
+
+// publishJSON is the wire shape of `ai release publish`. Result and
+// Release are omitted on a dry run; Release is the row after it was
+// marked completed and linked to the tagged commit.
+type publishJSON struct {
+	DryRun  bool            `json:"dry_run"`
+	Plan    release.Plan    `json:"plan"`
+	Result  *release.Result `json:"result,omitempty"`
+	Release *commitJSON     `json:"release,omitempty"`
+}
+
+// runReleasePublish ships a stored release end to end: tag the target
+// commit (unless the tag exists), push the tag when --push is given,
+// and create the hosted release with the stored body and the [release_config] assets. The
+// host (GitHub, GitLab or Gitea) comes from release.NewHost. On
+// success the row is marked completed and its commit_hash set to the
+// tagged commit. --dry-run prints the plan and touches nothing.
+func runReleasePublish(args []string) int {
+	fs := flagSet("ai release publish")
+	id := fs.Int("id", 0, "Release id to publish (from 'ai release' or 'ai list'). Required.")
+	tag := fs.String("tag", "", "Tag name. Defaults to the release's version, then [release_config].version.")
+	target := fs.String("target", "HEAD", "Commit to tag when the tag does not exist yet.")
+	remote := fs.String("remote", "origin", "Remote the tag is on (or is pushed to with --push).")
+	push := fs.Bool("push", false, "Push the tag to --remote before creating the hosted release. Off by default: push the tag yourself.")
+	repo := fs.String("repo", "", "Hosted repository (owner/name). Defaults to [release_config].repository, then the origin URL.")
+	workspace := fs.String("workspace", "", "Repo path. Defaults to the directory the release was drafted in.")
+	dryRun := fs.Bool("dry-run", false, "Print the plan without tagging, pushing or publishing.")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	if *id <= 0 {
+		printErrorJSON("invalid_input", "--id is required")
+		return 2
+	}
+
+	boot, err := loadBootstrap()
+	if err != nil {
+		printErrorJSON("bootstrap_error", err.Error())
+		return 1
+	}
+	defer boot.db.Close()
+
+	r, err := boot.db.GetReleaseByID(*id)
+	if err != nil {
+		printErrorJSON("not_found", fmt.Sprintf("release id=%d: %v", *id, err))
+		return 1
+	}
+	ws := strings.TrimSpace(*workspace)
+	if ws == "" {
+		ws = r.Workspace
+	}
+	host, err := release.NewHost(boot.cfg.ReleaseConfig, ws)
+	if err != nil {
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	plan, err := release.NewPlan(r, boot.cfg.ReleaseConfig, host, release.Options{
+		Workspace:  ws,
+		Tag:        *tag,
+		Target:     *target,
+		Remote:     *remote,
+		Repository: *repo,
+		Push:       *push,
+	})
+	if err != nil {
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	if *dryRun {
+		printJSON(publishJSON{DryRun: true, Plan: plan})
+		return 0
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	res, err := release.Publish(ctx, plan, host)
+	if err != nil {
+		printErrorJSON("publish_error", err.Error())
+		return 1
+	}
+	if err := boot.db.FinalizeRelease(r.ID); err != nil {
+		printErrorJSON("db_error", err.Error())
+		return 1
+	}
+	if err := boot.db.LinkReleaseHash(r.ID, plan.TagCommit); err != nil {
+		printErrorJSON("db_error", err.Error())
+		return 1
+	}
+	out := publishJSON{Plan: plan, Result: &res}
+	if saved, err := boot.db.GetReleaseByID(r.ID); err == nil {
+		if cj, err := releaseToJSON(saved, boot.cfg.CommitFormat.MessageFormat()); err == nil {
+			out.Release = &cj
+		}
+	}
+	printJSON(out)
+	return 0
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/storage"
+)
+
+// searchJSON is the wire shape of `ai search`: the query, the index's
+// embedder and the ranked hits, most similar first.
+type searchJSON struct {
+	Query string `json:"query"`
+	aiengine.SearchResult
+}
+
+// runSearch ranks the stored commits and releases of the workspace by
+// semantic similarity to a free-text query. The query is the first
+// positional argument (or every positional argument after the flags,
+// joined). Returns the process exit code.
+func runSearch(args []string) int {
+	var query []string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		query, args = args[:1], args[1:]
+	}
+	fs := flagSet("ai search")
+	limit := fs.Int("limit", 10, "Maximum number of hits to return (0 = every hit).")
+	kind := fs.String("kind", "all", "What to search: commit, release or all.")
+	allWorktrees := fs.Bool("all-worktrees", false, "Search every worktree of the repository, not just the current one.")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	query = append(query, fs.Args()...)
+	q := strings.TrimSpace(strings.Join(query, " "))
+	if q == "" {
+		printErrorJSON("invalid_input", `a query is required, e.g. commitcraft ai search "flaky parser tests"`)
+		return 2
+	}
+	if *limit < 0 {
+		printErrorJSON("invalid_input", "--limit must be positive")
+		return 2
+	}
+	k := strings.ToLower(strings.TrimSpace(*kind))
+	switch k {
+	case "all":
+		k = ""
+	case storage.EmbeddingKindCommit, storage.EmbeddingKindRelease:
+	default:
+		printErrorJSON("invalid_input", "--kind must be commit, release or all")
+		return 2
+	}
+
+	bs, err := loadBootstrap()
+	if err != nil {
+		printErrorJSON("bootstrap_error", err.Error())
+		return 1
+	}
+	defer bs.db.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}
+	docs, err := aiengine.SearchScopeDocs(deps, k, *allWorktrees)
+	if err != nil {
+		printErrorJSON("db_error", err.Error())
+		return 1
+	}
+	res, err := aiengine.SearchDocs(ctx, deps, docs, q, *limit)
+	if err != nil {
+		printErrorJSON("search_error", err.Error())
+		return 1
+	}
+	if res.Hits == nil {
+		res.Hits = []aiengine.SearchHit{}
+	}
+	printJSON(searchJSON{Query: q, SearchResult: res})
+	return 0
+}
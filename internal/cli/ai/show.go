@@ -68,7 +68,7 @@ func runShow(args []string) int {
 		case 1:
 			c := matches[0]
 			cj, err := commitToJSON(c, loadStagesForCommit(bs.db, c.ID),
-				bs.cfg.CommitFormat.TypeFormat)
+				bs.cfg.CommitFormat.MessageFormat())
 			if err != nil {
 				printErrorJSON("incomplete_commit", err.Error())
 				return 1
@@ -111,14 +111,14 @@ func runShow(args []string) int {
 	case kindCommit:
 		c := *res.Commit
 		cj, err := commitToJSON(c, loadStagesForCommit(bs.db, c.ID),
-			bs.cfg.CommitFormat.TypeFormat)
+			bs.cfg.CommitFormat.MessageFormat())
 		if err != nil {
 			printErrorJSON("incomplete_commit", err.Error())
 			return 1
 		}
 		printCommitJSON(cj)
 	case kindRelease:
-		cj, err := releaseToJSON(*res.Release, bs.cfg.CommitFormat.TypeFormat)
+		cj, err := releaseToJSON(*res.Release, bs.cfg.CommitFormat.MessageFormat())
 		if err != nil {
 			printErrorJSON("incomplete_release", err.Error())
 			return 1
@@ -0,0 +1,240 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/storage"
+)
+
+// splitGroupJSON is one group of `ai split` output, and the shape
+// accepted back through --plan.
+type splitGroupJSON struct {
+	Files     []string `json:"files"`
+	Tag       string   `json:"tag"`
+	Scope     string   `json:"scope"`
+	KeyPoints []string `json:"keypoints"`
+}
+
+type splitPlanJSON struct {
+	Source  string           `json:"source,omitempty"`
+	Warning string           `json:"warning,omitempty"`
+	Model   string           `json:"model,omitempty"`
+	Groups  []splitGroupJSON `json:"groups"`
+	// Drafts is filled by --accept: one draft per group, in plan order.
+	Drafts []commitJSON `json:"drafts,omitempty"`
+}
+
+// runSplit prints the split plan for the staged changes. With --accept
+// it also creates one draft per group: each group is staged on its own
+// while its pipeline runs, and the full index is restored afterwards so
+// `ai commit --id` can commit the groups one by one (each draft records
+// its files and commits only those). --plan replaces the planner with a
+// plan read from a file or stdin, e.g. an edited copy of a previous run.
+func runSplit(args []string) int {
+	fs := flagSet("ai split")
+	accept := fs.Bool(
+		"accept",
+		false,
+		"Create one draft per group (runs the full pipeline once per group)",
+	)
+	planPath := fs.String(
+		"plan",
+		"",
+		"Use this plan JSON ('-' for stdin) instead of running the split planner",
+	)
+	noChangelog := fs.Bool(
+		"no-changelog",
+		false,
+		"Skip the changelog refiner stage for every group even when enabled in config",
+	)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+
+	bs, err := loadBootstrap()
+	if err != nil {
+		printErrorJSON("bootstrap_error", err.Error())
+		return 1
+	}
+	defer bs.db.Close()
+
+	files, err := aiengine.StagedSplitFiles()
+	if err != nil {
+		printErrorJSON("git_error", err.Error())
+		return 1
+	}
+	if len(files) == 0 {
+		printErrorJSON("no_staged_diff", "no staged changes — run `git add` before invoking ai split")
+		return 1
+	}
+
+	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}
+	ctx, stop := signalContext()
+	defer stop()
+
+	var plan aiengine.SplitPlan
+	if *planPath != "" {
+		plan, err = readSplitPlan(*planPath, files, bs)
+		if err != nil {
+			printErrorJSON("invalid_input", err.Error())
+			return 2
+		}
+	} else {
+		diff, err := validateAndStageDiff(bs.cfg.Prompts.ChangeAnalyzerMaxDiffSize)
+		if err != nil {
+			printErrorJSON("no_staged_diff", err.Error())
+			return 1
+		}
+		plan, err = aiengine.PlanSplit(ctx, deps, files, diff, bs.finalCommitTypes)
+		if err != nil {
+			printAIRunError(bs, err)
+			return 1
+		}
+	}
+
+	out := splitPlanToJSON(plan)
+	if !*accept {
+		printJSON(out)
+		return 0
+	}
+
+	changelogActive := !*noChangelog && bs.cfg.Changelog.Enabled
+	fm := bs.cfg.CommitFormat.MessageFormat()
+	err = aiengine.RunSplit(ctx, deps, plan, changelogActive, func(r aiengine.SplitGroupResult) error {
+		c := storage.Commit{
+			Type:        r.Group.Tag,
+			Scope:       r.Group.Scope,
+			KeyPoints:   r.Group.KeyPoints,
+			Workspace:   bs.pwd,
+			Diff_code:   r.Diff,
+			IaSummary:   r.Output.Summary,
+			IaCommitRaw: r.Output.Body,
+			IaTitle:     r.Output.Title,
+			IaChangelog: r.Output.ChangelogEntry,
+			MessageEN:   r.Output.FinalMessage,
+			Files:       r.Group.Files,
+			Source:      "ai",
+		}
+		if err := bs.db.SaveDraft(&c); err != nil {
+			return err
+		}
+		if err := persistAICalls(bs.db, c.ID, r.Output.Stages); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ai_calls persistence failed: %v\n", err)
+		}
+		saved, err := bs.db.GetCommitByID(c.ID)
+		if err != nil {
+			saved = c
+			saved.Status = "draft"
+		}
+		cj, err := commitToJSON(saved, r.Output.Stages, fm)
+		if err != nil {
+			return err
+		}
+		out.Drafts = append(out.Drafts, cj)
+		return nil
+	})
+	if err != nil {
+		// Drafts created before the failure stay; name them so the
+		// caller can resume instead of generating them twice.
+		if len(out.Drafts) > 0 {
+			ids := make([]string, len(out.Drafts))
+			for i, d := range out.Drafts {
+				ids[i] = fmt.Sprint(d.ID)
+			}
+			fmt.Fprintf(os.Stderr, "note: drafts already created: %s\n", strings.Join(ids, ", "))
+		}
+		printAIRunError(bs, err)
+		return 1
+	}
+	printJSON(out)
+	return 0
+}
+
+func splitPlanToJSON(plan aiengine.SplitPlan) splitPlanJSON {
+	out := splitPlanJSON{Source: plan.Source, Warning: plan.Warning, Model: plan.Model}
+	for _, g := range plan.Groups {
+		out.Groups = append(out.Groups, splitGroupJSON{
+			Files:     g.Files,
+			Tag:       g.Tag,
+			Scope:     g.Scope,
+			KeyPoints: g.KeyPoints,
+		})
+	}
+	return out
+}
+
+// readSplitPlan loads a --plan file and checks it against the index:
+// every staged path must appear in exactly one group, every tag must be
+// known and every group needs a scope and at least one key point.
+func readSplitPlan(path string, files []aiengine.SplitFile, bs *bootstrap) (aiengine.SplitPlan, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return aiengine.SplitPlan{}, fmt.Errorf("read plan: %w", err)
+	}
+	var in splitPlanJSON
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return aiengine.SplitPlan{}, fmt.Errorf("parse plan: %w", err)
+	}
+	if len(in.Groups) == 0 {
+		return aiengine.SplitPlan{}, errors.New("plan has no groups")
+	}
+
+	staged := map[string]bool{}
+	for _, f := range files {
+		staged[f.Path] = true
+	}
+	seen := map[string]bool{}
+	plan := aiengine.SplitPlan{Source: aiengine.SplitSourcePlan}
+	for i, g := range in.Groups {
+		n := i + 1
+		if len(g.Files) == 0 {
+			return plan, fmt.Errorf("group %d has no files", n)
+		}
+		for _, p := range g.Files {
+			switch {
+			case !staged[p]:
+				return plan, fmt.Errorf("group %d: %q is not staged", n, p)
+			case seen[p]:
+				return plan, fmt.Errorf("group %d: %q is already in another group", n, p)
+			}
+			seen[p] = true
+		}
+		if !tagIsKnown(g.Tag, bs.finalCommitTypes) {
+			return plan, fmt.Errorf("group %d: unknown tag %q", n, g.Tag)
+		}
+		if strings.TrimSpace(g.Scope) == "" || len(g.KeyPoints) == 0 {
+			return plan, fmt.Errorf("group %d needs a scope and at least one keypoint", n)
+		}
+		plan.Groups = append(plan.Groups, aiengine.SplitGroup{
+			Files:     g.Files,
+			Tag:       g.Tag,
+			Scope:     strings.TrimSpace(g.Scope),
+			KeyPoints: g.KeyPoints,
+		})
+	}
+	for _, f := range files {
+		if !seen[f.Path] {
+			return plan, fmt.Errorf("%q is staged but in no group", f.Path)
+		}
+	}
+	return plan, nil
+}
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"fmt"
 
 	"commit_craft_reborn/internal/aiengine"
@@ -20,6 +21,7 @@ import (
 // that didn't run, by pre-loading from the DB and only overwriting the
 // stages we actually re-execute.
 func runStagePartial(
+	ctx context.Context,
 	deps aiengine.Deps,
 	c storage.Commit,
 	stage string,
@@ -42,11 +44,18 @@ func runStagePartial(
 		}
 		out.Stages = grown
 	}
+	deps.FewShotPaths = aiengine.DiffPaths(c.Diff_code)
 
 	switch stage {
 	case "body":
-		body, stats, err := aiengine.CallCommitBody(deps, c.Type, c.Scope, c.IaSummary)
+		body, stats, err := aiengine.CallCommitBody(ctx, deps, c.Type, c.Scope, c.IaSummary)
 		if err != nil {
+			aiengine.RecordFailedStage(
+				&out,
+				aiengine.StageBody,
+				deps.Cfg.Prompts.CommitBodyGeneratorPromptModel,
+				err,
+			)
 			return out, fmt.Errorf("stage body: %w", err)
 		}
 		aiengine.RecordStage(
@@ -58,8 +67,14 @@ func runStagePartial(
 		out.Body = body
 		fallthrough
 	case "title":
-		title, stats, err := aiengine.CallCommitTitle(deps, c.Type, c.Scope, out.Body)
+		title, stats, err := aiengine.CallCommitTitle(ctx, deps, c.Type, c.Scope, out.Body)
 		if err != nil {
+			aiengine.RecordFailedStage(
+				&out,
+				aiengine.StageTitle,
+				deps.Cfg.Prompts.CommitTitleGeneratorPromptModel,
+				err,
+			)
 			return out, fmt.Errorf("stage title: %w", err)
 		}
 		aiengine.RecordStage(
@@ -72,7 +87,10 @@ func runStagePartial(
 		fallthrough
 	case "changelog":
 		if changelogActive && deps.Cfg.Changelog.Enabled {
-			aiengine.RunChangelogRefiner(deps, &out)
+			aiengine.RunChangelogRefiner(ctx, deps, &out)
+			if err := ctx.Err(); err != nil {
+				return out, fmt.Errorf("stage changelog: %w", err)
+			}
 		} else {
 			// Caller asked to skip the refiner — clear any stale fields
 			// so the final message doesn't carry an old mention line.
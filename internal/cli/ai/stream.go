@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"encoding/json"
+	"os"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+)
+
+// streamEvent is one NDJSON line written by `ai generate --stream`.
+// "chunk" events carry a text delta for a stage as the model produces
+// it; a "retry" event means the stage's attempt failed and the deltas
+// streamed so far for that stage must be discarded. The run ends with
+// exactly one "result" event holding the same commit JSON the
+// non-streaming mode prints. Errors still go to stderr.
+type streamEvent struct {
+	Type    string       `json:"type"`
+	Stage   string       `json:"stage,omitempty"`
+	Delta   string       `json:"delta,omitempty"`
+	Attempt *attemptJSON `json:"attempt,omitempty"`
+	Commit  *commitJSON  `json:"commit,omitempty"`
+}
+
+// streamStageNames maps engine stages onto the names used by the
+// `stages[]` entries of the commit JSON so consumers can correlate the
+// two. The reduce call of a chunked Change Analyzer run produces the
+// summary, so it streams under the same name.
+var streamStageNames = map[config.ModelStage]string{
+	config.StageChangeAnalyzer:       stageNames[0],
+	config.StageChangeAnalyzerReduce: stageNames[0],
+	config.StageCommitBody:           stageNames[1],
+	config.StageCommitTitle:          stageNames[2],
+	config.StageChangelog:            stageNames[3],
+}
+
+// printStreamEvent writes ev as a single compact JSON line on stdout.
+func printStreamEvent(ev streamEvent) {
+	_ = json.NewEncoder(os.Stdout).Encode(ev)
+}
+
+func streamStageName(stage config.ModelStage) string {
+	if name, ok := streamStageNames[stage]; ok {
+		return name
+	}
+	return string(stage)
+}
+
+// streamChunkSink is the aiengine.Deps.OnChunk used by --stream.
+func streamChunkSink(stage config.ModelStage, delta string) {
+	printStreamEvent(streamEvent{Type: "chunk", Stage: streamStageName(stage), Delta: delta})
+}
+
+// streamRetrySink is the aiengine.Deps.OnRetry used by --stream.
+func streamRetrySink(stage config.ModelStage, attempt api.CallAttempt) {
+	aj := attemptsToJSON([]api.CallAttempt{attempt})[0]
+	printStreamEvent(streamEvent{Type: "retry", Stage: streamStageName(stage), Attempt: &aj})
+}
+
+// printCommitResult emits the final commit JSON, either pretty-printed
+// (default) or as the closing "result" event of an NDJSON stream.
+func printCommitResult(cj commitJSON, stream bool) {
+	if stream {
+		printStreamEvent(streamEvent{Type: "result", Commit: &cj})
+		return
+	}
+	printCommitJSON(cj)
+}
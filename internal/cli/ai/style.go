@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"commit_craft_reborn/internal/aiengine"
+)
+
+const styleUsage = `Usage: commitcraft ai style <action> [flags]
+
+Actions:
+  show              Print the stored style profile of the current repository
+                    and the guide it adds to the body/title prompts. Builds
+                    the profile first when there is none yet.
+  refresh           Re-mine the recent git history and store the profile.
+                    Flag: --commits N (default [style].commits).
+
+With no action, behaves like 'show'.
+`
+
+// styleJSON is the wire shape of `ai style show|refresh`. The guides are
+// the exact text appended to each stage's system prompt ("" when the
+// history is too thin or [style].enabled is off).
+type styleJSON struct {
+	Enabled    bool                  `json:"enabled"`
+	Profile    aiengine.StyleProfile `json:"profile"`
+	BodyGuide  string                `json:"body_guide"`
+	TitleGuide string                `json:"title_guide"`
+}
+
+// runStyle dispatches the `commitcraft ai style` actions. Returns the
+// process exit code (0 ok, 1 runtime error, 2 usage error).
+func runStyle(args []string) int {
+	action := "show"
+	rest := args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, rest = args[0], args[1:]
+	}
+	switch action {
+	case "show":
+		return runStyleShow(rest, false)
+	case "refresh":
+		return runStyleShow(rest, true)
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, styleUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown style action %q\n\n%s", action, styleUsage)
+		return 2
+	}
+}
+
+// runStyleShow prints the stored profile, mining it first when refresh
+// is set or when the repository has none yet.
+func runStyleShow(args []string, refresh bool) int {
+	fs := flagSet("ai style")
+	commits := fs.Int("commits", 0, "Number of recent commits to mine (refresh only). Defaults to [style].commits.")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		printErrorJSON("invalid_input", err.Error())
+		return 2
+	}
+	if *commits < 0 {
+		printErrorJSON("invalid_input", "--commits must be positive")
+		return 2
+	}
+
+	bs, err := loadBootstrap()
+	if err != nil {
+		printErrorJSON("bootstrap_error", err.Error())
+		return 1
+	}
+	defer bs.db.Close()
+
+	deps := aiengine.Deps{Cfg: bs.cfg, DB: bs.db, Log: bs.log, Pwd: bs.pwd}
+	if *commits > 0 {
+		deps.Cfg.Style.Commits = *commits
+	}
+	var p aiengine.StyleProfile
+	found := false
+	if !refresh {
+		p, found, err = aiengine.LoadStyleProfile(deps)
+	}
+	if err == nil && !found {
+		p, err = aiengine.RefreshStyleProfile(deps)
+	}
+	if err != nil {
+		printErrorJSON("style_error", err.Error())
+		return 1
+	}
+	printJSON(styleJSON{
+		Enabled:    bs.cfg.Style.Enabled,
+		Profile:    p,
+		BodyGuide:  p.Guide(aiengine.StageBody),
+		TitleGuide: p.Guide(aiengine.StageTitle),
+	})
+	return 0
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"commit_craft_reborn/internal/aiengine"
-	"commit_craft_reborn/internal/commit"
 	"commit_craft_reborn/internal/storage"
 )
 
@@ -244,17 +243,15 @@ func submitCommit(bs *bootstrap, in submitInput) int {
 		saved = c
 		saved.Status = "draft"
 	}
-	cj, err := commitToJSON(saved, nil, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := commitToJSON(saved, nil, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("incomplete_commit", err.Error())
 		return 1
 	}
 
-	final, ferr := commit.FormatFinalMessage(
-		bs.cfg.CommitFormat.TypeFormat, saved.Type, saved.Scope, saved.MessageEN,
-	)
+	final, ferr := composeCommitFinalMessage(saved, bs.cfg.CommitFormat.MessageFormat())
 	if ferr == nil {
-		report := aiengine.VerifyFinalMessage(final)
+		report := aiengine.VerifyFinalMessageFor(bs.cfg.CommitFormat.Profile, final)
 		cj.Verify = &report
 	}
 
@@ -305,14 +302,14 @@ func submitRelease(bs *bootstrap, in submitInput) int {
 	if err != nil {
 		saved = r
 	}
-	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.TypeFormat)
+	cj, err := releaseToJSON(saved, bs.cfg.CommitFormat.MessageFormat())
 	if err != nil {
 		printErrorJSON("format_error", err.Error())
 		return 1
 	}
 
-	if final, ferr := composeReleaseFinalMessage(saved, bs.cfg.CommitFormat.TypeFormat); ferr == nil {
-		report := aiengine.VerifyFinalMessage(final)
+	if final, ferr := composeReleaseFinalMessage(saved, bs.cfg.CommitFormat.MessageFormat()); ferr == nil {
+		report := aiengine.VerifyFinalMessageFor(bs.cfg.CommitFormat.Profile, final)
 		cj.Verify = &report
 	}
 
@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"commit_craft_reborn/internal/aiengine"
-	"commit_craft_reborn/internal/commit"
 )
 
 // runVerify runs the deterministic rule set in
@@ -67,14 +66,11 @@ func runVerify(args []string) int {
 	switch res.Kind {
 	case kindCommit:
 		c := *res.Commit
-		final, err = commit.FormatFinalMessage(
-			boot.cfg.CommitFormat.TypeFormat,
-			c.Type, c.Scope, c.MessageEN,
-		)
+		final, err = composeCommitFinalMessage(c, boot.cfg.CommitFormat.MessageFormat())
 	case kindRelease:
 		final, err = composeReleaseFinalMessage(
 			*res.Release,
-			boot.cfg.CommitFormat.TypeFormat,
+			boot.cfg.CommitFormat.MessageFormat(),
 		)
 	default:
 		printErrorJSON("not_found", fmt.Sprintf("no commit or release with id=%d", *id))
@@ -85,7 +81,7 @@ func runVerify(args []string) int {
 		return 1
 	}
 
-	report := aiengine.VerifyFinalMessage(final)
+	report := aiengine.VerifyFinalMessageFor(boot.cfg.CommitFormat.Profile, final)
 
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
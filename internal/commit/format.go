@@ -12,7 +12,7 @@ import (
 const DefaultTypeFormat = "[%s]"
 
 // ErrIncompleteCommit is returned by FormatFinalMessage when tag, scope
-// or message is empty. The pipeline guarantees all three are populated
+// or message is empty (the conventional profile allows an empty scope). The pipeline guarantees all three are populated
 // before assembling the final header, so an empty value signals a
 // programmer error (e.g. forgetting to run a stage) rather than a
 // user-recoverable condition.
@@ -0,0 +1,167 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Message-format profiles selectable through CommitFormatConfig.Profile.
+// ProfileBracket is the historical `[TAG] scope: title` shape and the
+// default for an empty profile; ProfileConventional produces
+// Conventional Commits headers (`type(scope)!: title`).
+const (
+	ProfileBracket      = "bracket"
+	ProfileConventional = "conventional"
+)
+
+// MessageFormat bundles everything needed to turn tag + scope + message
+// into a commit header under one profile. TypeFormat only applies to
+// the bracket profile; TypeMap overrides the built-in tag → type table
+// of the conventional profile (keys are upper-case tags).
+type MessageFormat struct {
+	Profile    string
+	TypeFormat string
+	TypeMap    map[string]string
+}
+
+// IsConventional reports whether f produces Conventional Commits.
+func (f MessageFormat) IsConventional() bool {
+	return strings.EqualFold(strings.TrimSpace(f.Profile), ProfileConventional)
+}
+
+// FinalMessage is the profile-aware FormatFinalMessage. The bracket
+// profile delegates to it unchanged. The conventional profile renders
+// `type(scope): message`, maps tag through ConventionalType, joins
+// multi-line scopes with commas, and adds the `!` marker when the body
+// carries a BREAKING CHANGE footer. The scope is optional there, as in
+// the spec: an empty one renders `type: message`. Returns
+// ErrIncompleteCommit when tag or message is empty (and, for the
+// bracket profile, scope).
+func (f MessageFormat) FinalMessage(tag, scope, message string) (string, error) {
+	if !f.IsConventional() {
+		return FormatFinalMessage(f.TypeFormat, tag, scope, message)
+	}
+	tag = strings.TrimSpace(tag)
+	if tag == "" || message == "" {
+		return "", fmt.Errorf("%w (tag=%q scope=%q message-empty=%t)",
+			ErrIncompleteCommit, tag, strings.TrimSpace(scope), message == "")
+	}
+	header := ConventionalType(tag, f.TypeMap)
+	if scope = conventionalScope(scope); scope != "" {
+		header += "(" + scope + ")"
+	}
+	if HasBreakingChange(message) {
+		header += "!"
+	}
+	return header + ": " + message, nil
+}
+
+// builtinConventionalTypes maps the default commit-type tags (see
+// GetDefaultCommitTypes) onto the Conventional Commits vocabulary. Tags
+// missing here fall back to their lower-cased name.
+var builtinConventionalTypes = map[string]string{
+	"ADD":      "feat",
+	"IMP":      "feat",
+	"UI":       "feat",
+	"I18N":     "feat",
+	"FIX":      "fix",
+	"SEC":      "fix",
+	"REM":      "refactor",
+	"REF":      "refactor",
+	"MOV":      "refactor",
+	"REL":      "chore",
+	"WIP":      "chore",
+	"CHORE":    "chore",
+	"DOC":      "docs",
+	"TEST":     "test",
+	"PERF":     "perf",
+	"CI":       "ci",
+	"BUILD":    "build",
+	"REVERT":   "revert",
+	"STYLE":    "style",
+	"MERGE":    "merge",
+	"RELEASE":  "release",
+	"FEAT":     "feat",
+	"DOCS":     "docs",
+	"REFACTOR": "refactor",
+}
+
+// ConventionalType returns the Conventional Commits type for tag:
+// overrides first (keys compared upper-case), then the built-in table,
+// then the lower-cased tag itself.
+func ConventionalType(tag string, overrides map[string]string) string {
+	key := strings.ToUpper(strings.TrimSpace(tag))
+	for k, v := range overrides {
+		if strings.ToUpper(k) == key && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	if t, ok := builtinConventionalTypes[key]; ok {
+		return t
+	}
+	return strings.ToLower(key)
+}
+
+// conventionalScope flattens the newline-joined multi-scope value the
+// pipeline stores into the comma list Conventional Commits tools accept.
+func conventionalScope(scope string) string {
+	var parts []string
+	for _, s := range strings.FieldsFunc(scope, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// breakingFooterPattern matches the footer tokens the spec reserves for
+// breaking changes. The spec requires them upper-case.
+var breakingFooterPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: \S`)
+
+// HasBreakingChange reports whether message carries a `BREAKING CHANGE:`
+// (or `BREAKING-CHANGE:`) footer line.
+func HasBreakingChange(message string) bool {
+	return breakingFooterPattern.MatchString(message)
+}
+
+// Header is a commit subject split into its parts. Type is the bracket
+// tag ("ADD") or the conventional type ("feat"); Profile says which
+// shape matched.
+type Header struct {
+	Profile     string
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+var (
+	bracketHeaderPattern      = regexp.MustCompile(`^\s*\[([A-Z][A-Z0-9]*)\]\s*(.*)$`)
+	bracketScopePattern       = regexp.MustCompile(`^(\S+):\s+(.*)$`)
+	conventionalHeaderPattern = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?:\s+(.*)$`)
+)
+
+// ParseHeader splits subject under either profile: `[TAG] scope: text`
+// first, then `type(scope)!: text`. ok is false when neither matches.
+// A bracket subject without `scope:` and a conventional one without
+// `(scope)` (`feat: text`, `feat!: text`) yield an empty Scope.
+func ParseHeader(subject string) (h Header, ok bool) {
+	if m := bracketHeaderPattern.FindStringSubmatch(subject); m != nil {
+		h = Header{Profile: ProfileBracket, Type: m[1], Description: m[2]}
+		if sm := bracketScopePattern.FindStringSubmatch(m[2]); sm != nil {
+			h.Scope, h.Description = sm[1], sm[2]
+		}
+		return h, true
+	}
+	if m := conventionalHeaderPattern.FindStringSubmatch(subject); m != nil {
+		return Header{
+			Profile:     ProfileConventional,
+			Type:        m[1],
+			Scope:       m[2],
+			Breaking:    m[3] == "!",
+			Description: m[4],
+		}, true
+	}
+	return Header{}, false
+}
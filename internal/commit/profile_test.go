@@ -0,0 +1,62 @@
+package commit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFinalMessageConventional(t *testing.T) {
+	f := MessageFormat{Profile: ProfileConventional, TypeMap: map[string]string{"ui": "style"}}
+	cases := []struct {
+		tag, scope, message string
+		want                string
+		wantErr             bool
+	}{
+		{"ADD", "api", "accept batch uploads", "feat(api): accept batch uploads", false},
+		{"FIX", "api\ncli", "handle empty input", "fix(api,cli): handle empty input", false},
+		{"ADD", "", "accept batch uploads", "feat: accept batch uploads", false},
+		{"ADD", " , \n", "accept batch uploads", "feat: accept batch uploads", false},
+		{"REF", "", "drop v1\n\nBREAKING CHANGE: v1 is gone", "refactor!: drop v1\n\nBREAKING CHANGE: v1 is gone", false},
+		{"UI", "theme", "darker help", "style(theme): darker help", false},
+		{"", "api", "msg", "", true},
+		{"ADD", "api", "", "", true},
+	}
+	for _, c := range cases {
+		got, err := f.FinalMessage(c.tag, c.scope, c.message)
+		if c.wantErr {
+			if !errors.Is(err, ErrIncompleteCommit) {
+				t.Errorf("FinalMessage(%q, %q, %q) err = %v, want ErrIncompleteCommit", c.tag, c.scope, c.message, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("FinalMessage(%q, %q, %q) = %q, %v; want %q", c.tag, c.scope, c.message, got, err, c.want)
+		}
+	}
+	if _, err := (MessageFormat{}).FinalMessage("ADD", "", "msg"); !errors.Is(err, ErrIncompleteCommit) {
+		t.Errorf("bracket profile with an empty scope: err = %v, want ErrIncompleteCommit", err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	cases := []struct {
+		subject string
+		want    Header
+		ok      bool
+	}{
+		{"[ADD] api: accept uploads", Header{ProfileBracket, "ADD", "api", false, "accept uploads"}, true},
+		{"[FIX] handle empty input", Header{ProfileBracket, "FIX", "", false, "handle empty input"}, true},
+		{"feat(api): accept uploads", Header{ProfileConventional, "feat", "api", false, "accept uploads"}, true},
+		{"feat(api)!: drop v1", Header{ProfileConventional, "feat", "api", true, "drop v1"}, true},
+		{"feat: accept uploads", Header{ProfileConventional, "feat", "", false, "accept uploads"}, true},
+		{"feat!: drop v1", Header{ProfileConventional, "feat", "", true, "drop v1"}, true},
+		{"just some words", Header{}, false},
+		{"feat:missing space", Header{}, false},
+	}
+	for _, c := range cases {
+		got, ok := ParseHeader(c.subject)
+		if ok != c.ok || got != c.want {
+			t.Errorf("ParseHeader(%q) = %+v, %v; want %+v, %v", c.subject, got, ok, c.want, c.ok)
+		}
+	}
+}
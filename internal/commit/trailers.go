@@ -0,0 +1,127 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Trailer tokens CommitCraft writes itself. Any other `Token: value`
+// line is accepted as-is.
+const (
+	TrailerCoAuthor = "Co-authored-by"
+	TrailerSignOff  = "Signed-off-by"
+	TrailerRefs     = "Refs"
+)
+
+// Trailer is one git trailer line, `Token: value`.
+type Trailer struct {
+	Token string
+	Value string
+}
+
+func (t Trailer) String() string { return t.Token + ": " + t.Value }
+
+// trailerLinePattern is the shape git's interpret-trailers recognises:
+// a token without spaces (BREAKING CHANGE is the one exception the
+// Conventional Commits spec adds), a colon and a non-empty value.
+var trailerLinePattern = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*):\s*(\S.*)$`)
+
+// identityValuePattern is the `Name <email>` shape identity trailers
+// (Co-authored-by, Signed-off-by, Reviewed-by, …) need for GitHub and
+// git shortlog to attribute them.
+var identityValuePattern = regexp.MustCompile(`^[^<>]*[^<>\s][^<>]* <[^<>\s@]+@[^<>\s]+>$`)
+
+// identityTrailerTokens are compared lower-case.
+var identityTrailerTokens = map[string]bool{
+	"co-authored-by": true,
+	"signed-off-by":  true,
+	"reviewed-by":    true,
+	"acked-by":       true,
+	"tested-by":      true,
+	"reported-by":    true,
+	"suggested-by":   true,
+	"helped-by":      true,
+}
+
+// ParseTrailer splits a single `Token: value` line. ok is false when
+// the line is not trailer-shaped.
+func ParseTrailer(line string) (t Trailer, ok bool) {
+	m := trailerLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Trailer{}, false
+	}
+	return Trailer{Token: m[1], Value: strings.TrimSpace(m[2])}, true
+}
+
+// IsIdentityTrailer reports whether token names a person and therefore
+// needs a `Name <email>` value.
+func IsIdentityTrailer(token string) bool {
+	return identityTrailerTokens[strings.ToLower(token)]
+}
+
+// IsIdentityValue reports whether v is `Name <email>`.
+func IsIdentityValue(v string) bool {
+	return identityValuePattern.MatchString(strings.TrimSpace(v))
+}
+
+// ValidateTrailer parses line and, for identity tokens, checks the
+// value. Returns the normalized trailer (`Token: value` with a single
+// space) or a descriptive error.
+func ValidateTrailer(line string) (Trailer, error) {
+	t, ok := ParseTrailer(line)
+	if !ok {
+		return Trailer{}, fmt.Errorf("%q is not a `Token: value` trailer", strings.TrimSpace(line))
+	}
+	if IsIdentityTrailer(t.Token) && !IsIdentityValue(t.Value) {
+		return Trailer{}, fmt.Errorf("%s needs a `Name <email>` value, got %q", t.Token, t.Value)
+	}
+	return t, nil
+}
+
+// AppendTrailers adds trailers below message in git's trailer format:
+// separated by a blank line, or joined to the message's last paragraph
+// when that paragraph already is a trailer block (so they form one
+// block git can parse). Blank entries and trailers the message already
+// carries (same token, case-insensitive, and value) are skipped.
+func AppendTrailers(message string, trailers []string) string {
+	message = strings.TrimRight(message, " \t\n")
+	existing := map[string]bool{}
+	lastParagraph := message
+	if i := strings.LastIndex(message, "\n\n"); i >= 0 {
+		lastParagraph = message[i+2:]
+	}
+	inBlock := strings.Contains(message, "\n\n")
+	for _, line := range strings.Split(lastParagraph, "\n") {
+		t, ok := ParseTrailer(line)
+		if !ok {
+			inBlock = false
+			continue
+		}
+		existing[trailerKey(t)] = true
+	}
+
+	var add []string
+	for _, raw := range trailers {
+		t, ok := ParseTrailer(raw)
+		if !ok {
+			continue
+		}
+		if k := trailerKey(t); !existing[k] {
+			existing[k] = true
+			add = append(add, t.String())
+		}
+	}
+	if len(add) == 0 {
+		return message
+	}
+	sep := "\n\n"
+	if inBlock {
+		sep = "\n"
+	}
+	return message + sep + strings.Join(add, "\n")
+}
+
+func trailerKey(t Trailer) string {
+	return strings.ToLower(t.Token) + "\x00" + t.Value
+}
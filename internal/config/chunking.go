@@ -0,0 +1,37 @@
+package config
+
+import "strings"
+
+// Change Analyzer chunking modes (PromptsConfig.ChangeAnalyzerChunking)
+// and groupings (PromptsConfig.ChangeAnalyzerChunkBy). See
+// aiengine.RunChangeAnalyzer for how they are applied.
+const (
+	ChunkingAuto     = "auto"
+	ChunkingAlways   = "always"
+	ChunkingOff      = "off"
+	ChunkByDirectory = "directory"
+	ChunkByFile      = "file"
+)
+
+// NormalizeChunking maps arbitrary input to a valid chunking mode,
+// defaulting to "auto" so unset or unknown values only chunk diffs that
+// would not fit the model's context window.
+func NormalizeChunking(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case ChunkingAlways:
+		return ChunkingAlways
+	case ChunkingOff:
+		return ChunkingOff
+	}
+	return ChunkingAuto
+}
+
+// NormalizeChunkBy maps arbitrary input to a valid grouping, defaulting
+// to "directory". Only the literal "file" (case-insensitive) gives every
+// file its own chunk.
+func NormalizeChunkBy(by string) string {
+	if strings.EqualFold(strings.TrimSpace(by), ChunkByFile) {
+		return ChunkByFile
+	}
+	return ChunkByDirectory
+}
@@ -35,6 +35,9 @@ func HasLocalConfig(pwd string) bool {
 //go:embed prompts/change_analyzer.prompt.tmpl
 var defaultChangeAnalyzerPrompt string
 
+//go:embed prompts/change_analyzer_reduce.prompt.tmpl
+var defaultChangeAnalyzerReducePrompt string
+
 //go:embed prompts/commit_body_generator.prompt.tmpl
 var defaultCommitBodyGeneratorPrompt string
 
@@ -53,6 +56,12 @@ var defaultReleaseTitlePrompt string
 //go:embed prompts/release_refine.prompt.tmpl
 var defaultReleaseRefinePrompt string
 
+//go:embed prompts/split_planner.prompt.tmpl
+var defaultSplitPlannerPrompt string
+
+//go:embed prompts/tag_classifier.prompt.tmpl
+var defaultTagClassifierPrompt string
+
 //go:embed prompts/changelog_refiner.prompt.tmpl
 var defaultChangelogRefinerPrompt string
 
@@ -96,6 +105,8 @@ func createOrLoadPromptFile(configDir string, fullPath string) (string, error) {
 		switch promptName {
 		case "change_analyzer":
 			defaultPromptContent = defaultChangeAnalyzerPrompt
+		case "change_analyzer_reduce":
+			defaultPromptContent = defaultChangeAnalyzerReducePrompt
 		case "commit_body_generator":
 			defaultPromptContent = defaultCommitBodyGeneratorPrompt
 		case "commit_title_generator":
@@ -108,6 +119,10 @@ func createOrLoadPromptFile(configDir string, fullPath string) (string, error) {
 			defaultPromptContent = defaultReleaseTitlePrompt
 		case "release_refine":
 			defaultPromptContent = defaultReleaseRefinePrompt
+		case "split_planner":
+			defaultPromptContent = defaultSplitPlannerPrompt
+		case "tag_classifier":
+			defaultPromptContent = defaultTagClassifierPrompt
 		case "changelog_refiner":
 			defaultPromptContent = defaultChangelogRefinerPrompt
 		case "agent_commit":
@@ -146,6 +161,14 @@ func loadIaPrompts(
 		return err
 	}
 
+	changeAnalyzerReducePrompt, err := createOrLoadPromptFile(
+		configDir,
+		globalConfig.Prompts.ChangeAnalyzerReducePromptFile,
+	)
+	if err != nil {
+		return err
+	}
+
 	commitBodyGeneratorPrompt, err := createOrLoadPromptFile(
 		configDir,
 		globalConfig.Prompts.CommitBodyGeneratorPromptFile,
@@ -194,6 +217,22 @@ func loadIaPrompts(
 		return err
 	}
 
+	splitPlannerPrompt, err := createOrLoadPromptFile(
+		configDir,
+		globalConfig.Prompts.SplitPlannerPromptFile,
+	)
+	if err != nil {
+		return err
+	}
+
+	tagClassifierPrompt, err := createOrLoadPromptFile(
+		configDir,
+		globalConfig.Prompts.TagClassifierPromptFile,
+	)
+	if err != nil {
+		return err
+	}
+
 	agentCommitPrompt, err := createOrLoadPromptFile(
 		configDir,
 		globalConfig.Prompts.AgentCommitPromptFile,
@@ -211,12 +250,15 @@ func loadIaPrompts(
 	}
 
 	globalConfig.Prompts.ChangeAnalyzerPrompt = changeAnalyzerPrompt
+	globalConfig.Prompts.ChangeAnalyzerReducePrompt = changeAnalyzerReducePrompt
 	globalConfig.Prompts.CommitBodyGeneratorPrompt = commitBodyGeneratorPrompt
 	globalConfig.Prompts.CommitTitleGeneratorPrompt = commitTitleGeneratorPrompt
 	globalConfig.Prompts.OnlyTranslatePrompt = onlyTranslatePrompt
 	globalConfig.Prompts.ReleaseBodyPrompt = releaseBodyPrompt
 	globalConfig.Prompts.ReleaseTitlePrompt = releaseTitlePrompt
 	globalConfig.Prompts.ReleaseRefinePrompt = releaseRefinePrompt
+	globalConfig.Prompts.SplitPlannerPrompt = splitPlannerPrompt
+	globalConfig.Prompts.TagClassifierPrompt = tagClassifierPrompt
 	globalConfig.Prompts.AgentCommitPrompt = agentCommitPrompt
 	globalConfig.Prompts.AgentReleasePrompt = agentReleasePrompt
 
@@ -272,6 +314,11 @@ func LoadConfigs() (globalCfg, localCfg Config, err error) {
 		return Config{}, Config{}, err
 	}
 
+	globalCfg.Tokenizer.ResolvedDir = globalCfg.Tokenizer.Dir
+	if globalCfg.Tokenizer.Dir != "" && !filepath.IsAbs(globalCfg.Tokenizer.Dir) {
+		globalCfg.Tokenizer.ResolvedDir = filepath.Join(globalDir, globalCfg.Tokenizer.Dir)
+	}
+
 	envPath := filepath.Join(globalDir, ".env")
 	_ = godotenv.Load(envPath)
 
@@ -284,15 +331,20 @@ func LoadConfigs() (globalCfg, localCfg Config, err error) {
 	userKey := os.Getenv(EnvGroqUserKey)
 	aiKey := os.Getenv(EnvGroqAIKey)
 	active := NormalizeKeySlot(os.Getenv(EnvGroqActive))
-	chosen := userKey
+	chosen, other := userKey, aiKey
 	if active == KeySlotAI {
-		chosen = aiKey
+		chosen, other = aiKey, userKey
 	}
 	globalCfg.TUI.ActiveKeySlot = active
 	globalCfg.TUI.UserKeySet = userKey != ""
 	globalCfg.TUI.AIKeySet = aiKey != ""
 	globalCfg.TUI.GroqAPIKey = chosen
+	globalCfg.TUI.GroqInactiveAPIKey = other
 	globalCfg.TUI.IsAPIKeySet = chosen != ""
+	globalCfg.Providers.OpenAI.APIKey = os.Getenv(EnvOpenAIKey)
+	globalCfg.Providers.Anthropic.APIKey = os.Getenv(EnvAnthropicKey)
+	globalCfg.ReleaseConfig.GitLabToken = os.Getenv(EnvGitLabToken)
+	globalCfg.ReleaseConfig.GiteaToken = os.Getenv(EnvGiteaToken)
 
 	// GH_TOKEN was previously persisted as a field inside each
 	// .commitcraft.toml. Per-repo configs were ending up committed to
@@ -496,18 +548,56 @@ func ResolveCommitTypes(
 func ResolveReleaseConfig(
 	globalCfg *Config, localCfg Config,
 ) {
+	// The host tokens are loaded from the environment into the global
+	// config only; keep them across the local override so the release
+	// host can authenticate.
+	prev := globalCfg.ReleaseConfig
 	globalCfg.ReleaseConfig = localCfg.ReleaseConfig
 	rc := &globalCfg.ReleaseConfig
+	rc.GhToken, rc.IsGhTokenSet = prev.GhToken, prev.IsGhTokenSet
+	rc.GitLabToken, rc.GiteaToken = prev.GitLabToken, prev.GiteaToken
+	switch rc.Archive {
+	case "", ReleaseArchiveAuto, ReleaseArchiveTarGz, ReleaseArchiveZip:
+	default:
+		fmt.Fprintf(
+			os.Stderr,
+			"warning: release_config.archive=%q is not supported (auto, tar.gz or zip); uploading raw files\n",
+			rc.Archive,
+		)
+		rc.Archive = ""
+	}
+	switch rc.Sign {
+	case "", ReleaseSignMinisign, ReleaseSignSSH:
+	default:
+		fmt.Fprintf(
+			os.Stderr,
+			"warning: release_config.sign=%q is not supported (minisign or ssh); not signing\n",
+			rc.Sign,
+		)
+		rc.Sign = ""
+	}
 	if !rc.AutoBuild {
 		return
 	}
 	if rc.BuildTool == "" {
-		rc.BuildTool = "make"
-	}
-	if rc.BuildTool != "make" {
+		rc.BuildTool = ReleaseBuildMake
+	}
+	switch rc.BuildTool {
+	case ReleaseBuildMake:
+	case ReleaseBuildGo:
+		if len(rc.Targets) == 0 {
+			fmt.Fprintln(
+				os.Stderr,
+				"warning: release_config.build_tool=\"go\" but targets is empty; disabling auto_build",
+			)
+			rc.AutoBuild = false
+		}
+		// build_target is the package to build; empty builds ".".
+		return
+	default:
 		fmt.Fprintf(
 			os.Stderr,
-			"warning: release_config.build_tool=%q is not supported (only \"make\"); disabling auto_build\n",
+			"warning: release_config.build_tool=%q is not supported (\"make\" or \"go\"); disabling auto_build\n",
 			rc.BuildTool,
 		)
 		rc.AutoBuild = false
@@ -530,3 +620,124 @@ func ResolveTUIConfig(globalCfg *Config, localCfg Config) {
 		globalCfg.TUI.Theme = localCfg.TUI.Theme
 	}
 }
+
+// ResolveGitCommitConfig merges the local [git_commit] section over the
+// global one. SignOff can only be switched on locally (a repo that
+// requires DCO sign-off enables it for everyone); Sign and SigningKey
+// override when non-empty. NoVerify is never taken from the local file:
+// a cloned repository must not be able to turn off the user's hooks, so
+// only the global config or --no-verify skips them.
+func ResolveGitCommitConfig(globalCfg *Config, localCfg Config) {
+	gc := &globalCfg.GitCommit
+	local := localCfg.GitCommit
+	gc.SignOff = gc.SignOff || local.SignOff
+	if local.NoVerify && !gc.NoVerify {
+		fmt.Fprintln(
+			os.Stderr,
+			"warning: git_commit.no_verify in .commitcraft.toml is ignored; set it in the global config or pass --no-verify",
+		)
+	}
+	if local.Sign != "" {
+		gc.Sign = local.Sign
+	}
+	if local.SigningKey != "" {
+		gc.SigningKey = local.SigningKey
+	}
+}
+
+// ResolveHooksConfig merges the local [hooks] section over the global
+// one, with the same rules as ResolveGitCommitConfig: a repo can switch
+// generation on and pick its own default tag.
+func ResolveHooksConfig(globalCfg *Config, localCfg Config) {
+	h := &globalCfg.Hooks
+	local := localCfg.Hooks
+	h.Generate = h.Generate || local.Generate
+	if local.DefaultTag != "" {
+		h.DefaultTag = local.DefaultTag
+	}
+}
+
+// ResolveTrailersConfig appends the local roster to the global one; a
+// local entry replaces a global one with the same alias so a repo can
+// pin a teammate's work address. A local refs_token wins.
+func ResolveTrailersConfig(globalCfg *Config, localCfg Config) {
+	t := &globalCfg.Trailers
+	local := localCfg.Trailers
+	for _, a := range local.CoAuthors {
+		replaced := false
+		for i := range t.CoAuthors {
+			if strings.EqualFold(t.CoAuthors[i].Alias, a.Alias) {
+				t.CoAuthors[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			t.CoAuthors = append(t.CoAuthors, a)
+		}
+	}
+	if local.RefsToken != "" {
+		t.RefsToken = local.RefsToken
+	}
+}
+
+// ResolveDiffConfig appends the local [diff] ignore and keep globs to
+// the global ones. The built-in rules and gitattributes switches stay
+// global; a repo that needs a lockfile's diff lists it under keep.
+func ResolveDiffConfig(globalCfg *Config, localCfg Config) {
+	d := &globalCfg.Diff
+	d.Ignore = append(d.Ignore, localCfg.Diff.Ignore...)
+	d.Keep = append(d.Keep, localCfg.Diff.Keep...)
+}
+
+// ResolveScopeConfig adds the local [scope.map] entries to the global
+// map; a local entry replaces a global one for the same glob.
+func ResolveScopeConfig(globalCfg *Config, localCfg Config) {
+	if len(localCfg.Scope.Map) == 0 {
+		return
+	}
+	if globalCfg.Scope.Map == nil {
+		globalCfg.Scope.Map = map[string]string{}
+	}
+	for glob, scope := range localCfg.Scope.Map {
+		globalCfg.Scope.Map[glob] = scope
+	}
+}
+
+// ResolveFewShotConfig lets a repository's .commitcraft.toml switch the
+// few-shot examples on or off and tighten or widen their budget.
+func ResolveFewShotConfig(globalCfg *Config, localCfg Config) {
+	f := &globalCfg.FewShot
+	local := localCfg.FewShot
+	if local.Mode != "" {
+		f.Mode = local.Mode
+	}
+	if local.MaxExamples > 0 {
+		f.MaxExamples = local.MaxExamples
+	}
+	if local.MaxTokens > 0 {
+		f.MaxTokens = local.MaxTokens
+	}
+}
+
+// ResolveCommitFormatConfig lets a repo's .commitcraft.toml pick its own
+// message profile and extend the conventional type table, so one user
+// can work on bracket-style and Conventional Commits repos alike.
+// type_format stays global: generated local files carry the default.
+func ResolveCommitFormatConfig(globalCfg *Config, localCfg Config) {
+	cf := &globalCfg.CommitFormat
+	local := localCfg.CommitFormat
+	if local.Profile != "" {
+		cf.Profile = local.Profile
+	}
+	if len(local.ConventionalTypes) > 0 {
+		merged := make(map[string]string, len(cf.ConventionalTypes)+len(local.ConventionalTypes))
+		for k, v := range cf.ConventionalTypes {
+			merged[k] = v
+		}
+		for k, v := range local.ConventionalTypes {
+			merged[k] = v
+		}
+		cf.ConventionalTypes = merged
+	}
+}
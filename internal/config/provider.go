@@ -0,0 +1,38 @@
+package config
+
+// Env var names for the non-Groq provider keys. They live in the global
+// `.env` beside the Groq slots and GH_TOKEN; Ollama needs no key.
+const (
+	EnvOpenAIKey    = "OPENAI_API_KEY"
+	EnvAnthropicKey = "ANTHROPIC_API_KEY"
+)
+
+// ProviderForStage returns the raw provider name configured for stage,
+// or "" when unset (callers treat "" as Groq).
+func ProviderForStage(cfg Config, stage ModelStage) string {
+	switch stage {
+	case StageChangeAnalyzer:
+		return cfg.Prompts.ChangeAnalyzerPromptProvider
+	case StageChangeAnalyzerReduce:
+		return cfg.Prompts.ChangeAnalyzerReducePromptProvider
+	case StageCommitBody:
+		return cfg.Prompts.CommitBodyGeneratorPromptProvider
+	case StageCommitTitle:
+		return cfg.Prompts.CommitTitleGeneratorPromptProvider
+	case StageOnlyTranslate:
+		return cfg.Prompts.OnlyTranslatePromptProvider
+	case StageReleaseBody:
+		return cfg.Prompts.ReleaseBodyPromptProvider
+	case StageReleaseTitle:
+		return cfg.Prompts.ReleaseTitlePromptProvider
+	case StageReleaseRefine:
+		return cfg.Prompts.ReleaseRefinePromptProvider
+	case StageSplitPlanner:
+		return cfg.Prompts.SplitPlannerPromptProvider
+	case StageTagClassifier:
+		return cfg.Prompts.TagClassifierPromptProvider
+	case StageChangelog:
+		return cfg.Changelog.PromptProvider
+	}
+	return ""
+}
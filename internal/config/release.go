@@ -0,0 +1,55 @@
+package config
+
+// Release hosts accepted by release_config.host. An empty value lets the
+// publisher pick one from the origin remote's URL.
+const (
+	ReleaseHostGitHub = "github"
+	ReleaseHostGitLab = "gitlab"
+	ReleaseHostGitea  = "gitea"
+)
+
+// Env var names for the release host tokens. They live in the global
+// `.env` beside the provider keys, never in a `.commitcraft.toml`.
+const (
+	EnvGitHubToken = "GH_TOKEN"
+	EnvGitLabToken = "GITLAB_TOKEN"
+	EnvGiteaToken  = "GITEA_TOKEN"
+)
+
+// ReleaseTokenEnv returns the env var holding the API token for host
+// (GH_TOKEN for an unknown host, the historical default).
+func ReleaseTokenEnv(host string) string {
+	switch host {
+	case ReleaseHostGitLab:
+		return EnvGitLabToken
+	case ReleaseHostGitea:
+		return EnvGiteaToken
+	}
+	return EnvGitHubToken
+}
+
+// Token returns the API token loaded for host, or "" when unset.
+func (rc ReleaseConfig) Token(host string) string {
+	switch host {
+	case ReleaseHostGitLab:
+		return rc.GitLabToken
+	case ReleaseHostGitea:
+		return rc.GiteaToken
+	}
+	return rc.GhToken
+}
+
+// Values accepted by release_config.archive and release_config.sign.
+const (
+	ReleaseArchiveAuto  = "auto"
+	ReleaseArchiveTarGz = "tar.gz"
+	ReleaseArchiveZip   = "zip"
+	ReleaseSignMinisign = "minisign"
+	ReleaseSignSSH      = "ssh"
+)
+
+// Values accepted by release_config.build_tool.
+const (
+	ReleaseBuildMake = "make"
+	ReleaseBuildGo   = "go"
+)
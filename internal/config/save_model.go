@@ -24,14 +24,17 @@ const (
 type ModelStage string
 
 const (
-	StageChangeAnalyzer ModelStage = "change_analyzer"
-	StageCommitBody     ModelStage = "commit_body"
-	StageCommitTitle    ModelStage = "commit_title"
-	StageOnlyTranslate  ModelStage = "only_translate"
-	StageReleaseBody    ModelStage = "release_body"
-	StageReleaseTitle   ModelStage = "release_title"
-	StageReleaseRefine  ModelStage = "release_refine"
-	StageChangelog      ModelStage = "changelog"
+	StageChangeAnalyzer       ModelStage = "change_analyzer"
+	StageChangeAnalyzerReduce ModelStage = "change_analyzer_reduce"
+	StageCommitBody           ModelStage = "commit_body"
+	StageCommitTitle          ModelStage = "commit_title"
+	StageOnlyTranslate        ModelStage = "only_translate"
+	StageReleaseBody          ModelStage = "release_body"
+	StageReleaseTitle         ModelStage = "release_title"
+	StageReleaseRefine        ModelStage = "release_refine"
+	StageSplitPlanner         ModelStage = "split_planner"
+	StageTagClassifier        ModelStage = "tag_classifier"
+	StageChangelog            ModelStage = "changelog"
 )
 
 type stageMapping struct {
@@ -40,14 +43,17 @@ type stageMapping struct {
 }
 
 var stageMappings = map[ModelStage]stageMapping{
-	StageChangeAnalyzer: {"prompts", "change_analyzer_prompt_model"},
-	StageCommitBody:     {"prompts", "commit_body_generator_prompt_model"},
-	StageCommitTitle:    {"prompts", "commit_title_generator_prompt_model"},
-	StageOnlyTranslate:  {"prompts", "only_translate_prompt_model"},
-	StageReleaseBody:    {"prompts", "release_body_prompt_model"},
-	StageReleaseTitle:   {"prompts", "release_title_prompt_model"},
-	StageReleaseRefine:  {"prompts", "release_refine_prompt_model"},
-	StageChangelog:      {"changelog", "prompt_model"},
+	StageChangeAnalyzer:       {"prompts", "change_analyzer_prompt_model"},
+	StageChangeAnalyzerReduce: {"prompts", "change_analyzer_reduce_prompt_model"},
+	StageCommitBody:           {"prompts", "commit_body_generator_prompt_model"},
+	StageCommitTitle:          {"prompts", "commit_title_generator_prompt_model"},
+	StageOnlyTranslate:        {"prompts", "only_translate_prompt_model"},
+	StageReleaseBody:          {"prompts", "release_body_prompt_model"},
+	StageReleaseTitle:         {"prompts", "release_title_prompt_model"},
+	StageReleaseRefine:        {"prompts", "release_refine_prompt_model"},
+	StageSplitPlanner:         {"prompts", "split_planner_prompt_model"},
+	StageTagClassifier:        {"prompts", "tag_classifier_prompt_model"},
+	StageChangelog:            {"changelog", "prompt_model"},
 }
 
 // SaveModelForStage rewrites the model id for the given stage in the
@@ -101,6 +107,8 @@ func ApplyModelToConfig(cfg *Config, stage ModelStage, modelID string) {
 	switch stage {
 	case StageChangeAnalyzer:
 		cfg.Prompts.ChangeAnalyzerPromptModel = modelID
+	case StageChangeAnalyzerReduce:
+		cfg.Prompts.ChangeAnalyzerReducePromptModel = modelID
 	case StageCommitBody:
 		cfg.Prompts.CommitBodyGeneratorPromptModel = modelID
 	case StageCommitTitle:
@@ -113,6 +121,10 @@ func ApplyModelToConfig(cfg *Config, stage ModelStage, modelID string) {
 		cfg.Prompts.ReleaseTitlePromptModel = modelID
 	case StageReleaseRefine:
 		cfg.Prompts.ReleaseRefinePromptModel = modelID
+	case StageSplitPlanner:
+		cfg.Prompts.SplitPlannerPromptModel = modelID
+	case StageTagClassifier:
+		cfg.Prompts.TagClassifierPromptModel = modelID
 	case StageChangelog:
 		cfg.Changelog.PromptModel = modelID
 	}
@@ -124,6 +136,8 @@ func CurrentModelForStage(cfg Config, stage ModelStage) string {
 	switch stage {
 	case StageChangeAnalyzer:
 		return cfg.Prompts.ChangeAnalyzerPromptModel
+	case StageChangeAnalyzerReduce:
+		return cfg.Prompts.ChangeAnalyzerReducePromptModel
 	case StageCommitBody:
 		return cfg.Prompts.CommitBodyGeneratorPromptModel
 	case StageCommitTitle:
@@ -136,6 +150,10 @@ func CurrentModelForStage(cfg Config, stage ModelStage) string {
 		return cfg.Prompts.ReleaseTitlePromptModel
 	case StageReleaseRefine:
 		return cfg.Prompts.ReleaseRefinePromptModel
+	case StageSplitPlanner:
+		return cfg.Prompts.SplitPlannerPromptModel
+	case StageTagClassifier:
+		return cfg.Prompts.TagClassifierPromptModel
 	case StageChangelog:
 		return cfg.Changelog.PromptModel
 	}
@@ -1,32 +1,69 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"commit_craft_reborn/internal/commit"
 )
 
+// PromptsConfig holds the per-stage prompt files and model ids. Each
+// stage's `*_prompt_provider` key selects the LLM backend serving it
+// ("groq", "openai", "ollama", "anthropic"); empty means Groq so configs
+// written before providers existed keep their behavior.
+//
+// ChangeAnalyzerChunking controls map-reduce analysis of diffs that do
+// not fit the change analyzer model's context window: "auto" (default)
+// chunks only when the estimate exceeds the window, "always" chunks
+// every run and "off" keeps the single budgeted call. ChangeAnalyzerChunkBy
+// groups the diff by "directory" (default) or "file". The reduce call
+// that merges the partial summaries is a stage of its own.
 type PromptsConfig struct {
-	ChangeAnalyzerPromptFile        string `toml:"change_analyzer_prompt_file"`
-	ChangeAnalyzerPromptModel       string `toml:"change_analyzer_prompt_model"`
-	ChangeAnalyzerMaxDiffSize       int    `toml:"change_analyzer_max_diff_size"`
-	ChangeAnalyzerPrompt            string `toml:"-"`
-	CommitBodyGeneratorPromptFile   string `toml:"commit_body_generator_prompt_file"`
-	CommitBodyGeneratorPromptModel  string `toml:"commit_body_generator_prompt_model"`
-	CommitBodyGeneratorPrompt       string `toml:"-"`
-	CommitTitleGeneratorPromptFile  string `toml:"commit_title_generator_prompt_file"`
-	CommitTitleGeneratorPromptModel string `toml:"commit_title_generator_prompt_model"`
-	CommitTitleGeneratorPrompt      string `toml:"-"`
-	OnlyTranslatePromptFile         string `toml:"only_translate_prompt_file"`
-	OnlyTranslatePromptModel        string `toml:"only_translate_prompt_model"`
-	OnlyTranslatePrompt             string `toml:"-"`
-	ReleaseBodyPromptFile           string `toml:"release_body_prompt_file"`
-	ReleaseBodyPromptModel          string `toml:"release_body_prompt_model"`
-	ReleaseBodyPrompt               string `toml:"-"`
-	ReleaseTitlePromptFile          string `toml:"release_title_prompt_file"`
-	ReleaseTitlePromptModel         string `toml:"release_title_prompt_model"`
-	ReleaseTitlePrompt              string `toml:"-"`
-	ReleaseRefinePromptFile         string `toml:"release_refine_prompt_file"`
-	ReleaseRefinePromptModel        string `toml:"release_refine_prompt_model"`
-	ReleaseRefinePrompt             string `toml:"-"`
+	ChangeAnalyzerPromptFile           string `toml:"change_analyzer_prompt_file"`
+	ChangeAnalyzerPromptModel          string `toml:"change_analyzer_prompt_model"`
+	ChangeAnalyzerPromptProvider       string `toml:"change_analyzer_prompt_provider,omitempty"`
+	ChangeAnalyzerMaxDiffSize          int    `toml:"change_analyzer_max_diff_size"`
+	ChangeAnalyzerPrompt               string `toml:"-"`
+	ChangeAnalyzerChunking             string `toml:"change_analyzer_chunking"`
+	ChangeAnalyzerChunkBy              string `toml:"change_analyzer_chunk_by"`
+	ChangeAnalyzerReducePromptFile     string `toml:"change_analyzer_reduce_prompt_file"`
+	ChangeAnalyzerReducePromptModel    string `toml:"change_analyzer_reduce_prompt_model"`
+	ChangeAnalyzerReducePromptProvider string `toml:"change_analyzer_reduce_prompt_provider,omitempty"`
+	ChangeAnalyzerReducePrompt         string `toml:"-"`
+	CommitBodyGeneratorPromptFile      string `toml:"commit_body_generator_prompt_file"`
+	CommitBodyGeneratorPromptModel     string `toml:"commit_body_generator_prompt_model"`
+	CommitBodyGeneratorPromptProvider  string `toml:"commit_body_generator_prompt_provider,omitempty"`
+	CommitBodyGeneratorPrompt          string `toml:"-"`
+	CommitTitleGeneratorPromptFile     string `toml:"commit_title_generator_prompt_file"`
+	CommitTitleGeneratorPromptModel    string `toml:"commit_title_generator_prompt_model"`
+	CommitTitleGeneratorPromptProvider string `toml:"commit_title_generator_prompt_provider,omitempty"`
+	CommitTitleGeneratorPrompt         string `toml:"-"`
+	OnlyTranslatePromptFile            string `toml:"only_translate_prompt_file"`
+	OnlyTranslatePromptModel           string `toml:"only_translate_prompt_model"`
+	OnlyTranslatePromptProvider        string `toml:"only_translate_prompt_provider,omitempty"`
+	OnlyTranslatePrompt                string `toml:"-"`
+	ReleaseBodyPromptFile              string `toml:"release_body_prompt_file"`
+	ReleaseBodyPromptModel             string `toml:"release_body_prompt_model"`
+	ReleaseBodyPromptProvider          string `toml:"release_body_prompt_provider,omitempty"`
+	ReleaseBodyPrompt                  string `toml:"-"`
+	ReleaseTitlePromptFile             string `toml:"release_title_prompt_file"`
+	ReleaseTitlePromptModel            string `toml:"release_title_prompt_model"`
+	ReleaseTitlePromptProvider         string `toml:"release_title_prompt_provider,omitempty"`
+	ReleaseTitlePrompt                 string `toml:"-"`
+	ReleaseRefinePromptFile            string `toml:"release_refine_prompt_file"`
+	ReleaseRefinePromptModel           string `toml:"release_refine_prompt_model"`
+	ReleaseRefinePromptProvider        string `toml:"release_refine_prompt_provider,omitempty"`
+	ReleaseRefinePrompt                string `toml:"-"`
+	SplitPlannerPromptFile             string `toml:"split_planner_prompt_file"`
+	SplitPlannerPromptModel            string `toml:"split_planner_prompt_model"`
+	SplitPlannerPromptProvider         string `toml:"split_planner_prompt_provider,omitempty"`
+	SplitPlannerPrompt                 string `toml:"-"`
+	TagClassifierPromptFile            string `toml:"tag_classifier_prompt_file"`
+	TagClassifierPromptModel           string `toml:"tag_classifier_prompt_model"`
+	TagClassifierPromptProvider        string `toml:"tag_classifier_prompt_provider,omitempty"`
+	TagClassifierPrompt                string `toml:"-"`
 	// AgentCommit / AgentRelease are the unified single-pass prompts used by
 	// delegate mode (see AgentConfig). They merge the per-stage prompts into
 	// one coherent instruction so a capable agent produces the whole message
@@ -53,14 +90,20 @@ type TUIConfig struct {
 	Theme        string `toml:"theme,omitempty"`
 	// GroqAPIKey is the resolved key for the active slot (see
 	// GROQ_ACTIVE_KEY). ActiveKeySlot / UserKeySet / AIKeySet describe the
-	// two-slot state behind it. All derived from the global `.env` at load
-	// time, never serialized.
-	GroqAPIKey    string               `toml:"-"`
-	IsAPIKeySet   bool                 `toml:"-"`
-	ActiveKeySlot string               `toml:"-"` // "user" | "ai"
-	UserKeySet    bool                 `toml:"-"`
-	AIKeySet      bool                 `toml:"-"`
-	Pipeline      PipelineLayoutConfig `toml:"pipeline,omitempty"`
+	// two-slot state behind it, and GroqInactiveAPIKey holds the other
+	// slot's key for the retry policy's key-slot fallback. All derived
+	// from the global `.env` at load time, never serialized.
+	GroqAPIKey         string               `toml:"-"`
+	GroqInactiveAPIKey string               `toml:"-"`
+	IsAPIKeySet        bool                 `toml:"-"`
+	ActiveKeySlot      string               `toml:"-"` // "user" | "ai"
+	UserKeySet         bool                 `toml:"-"`
+	AIKeySet           bool                 `toml:"-"`
+	Pipeline           PipelineLayoutConfig `toml:"pipeline,omitempty"`
+	// StreamResponses makes the Pipeline tab request streamed completions
+	// so each stage card fills in as tokens arrive. Defaults to true;
+	// turn it off for gateways that don't implement streaming.
+	StreamResponses bool `toml:"stream_responses"`
 }
 
 // PipelineLayoutConfig controls the per-stage card heights on the
@@ -84,15 +127,59 @@ type ReleaseConfig struct {
 	// migrateLegacyGhToken in loader.go.
 	GhToken          string `toml:"-"`
 	BinaryAssetsPath string `toml:"binary_assets_path"`
+	// Host is the forge releases are published on: "github", "gitlab"
+	// or "gitea". Empty picks one from the origin remote's URL and
+	// falls back to GitHub.
+	Host string `toml:"host,omitempty"`
+	// APIURL is the release host's API root. Empty means
+	// https://api.github.com on GitHub and <origin>/api/v4 (GitLab) or
+	// <origin>/api/v1 (Gitea) otherwise. Set it for GitHub Enterprise
+	// (https://ghe.example.com/api/v3) or a local test server.
+	APIURL string `toml:"api_url,omitempty"`
+	// GitLabToken and GiteaToken are GH_TOKEN's counterparts for the
+	// other hosts, read from GITLAB_TOKEN and GITEA_TOKEN in the .env.
+	GitLabToken string `toml:"-"`
+	GiteaToken  string `toml:"-"`
 
 	// IsGhTokenSet is true when GH_TOKEN was found via env (or migrated
 	// from a legacy TOML). Used by the release config popup to render
 	// "configured" vs "missing" without ever echoing the token itself.
 	IsGhTokenSet bool `toml:"-"`
 
-	AutoBuild   bool   `toml:"auto_build"`
+	AutoBuild bool `toml:"auto_build"`
+	// BuildTool is "make" (run `make <build_target>`) or "go" (the
+	// cross-compile matrix below, with build_target as the package).
 	BuildTool   string `toml:"build_tool"`
 	BuildTarget string `toml:"build_target"`
+
+	// Targets is the GOOS/GOARCH matrix of the "go" build tool, e.g.
+	// ["linux/amd64", "darwin/arm64", "windows/amd64"]. Each target is
+	// built concurrently, BuildJobs at a time (default: one per CPU),
+	// into BinaryAssetsPath.
+	Targets   []string `toml:"targets,omitempty"`
+	BuildJobs int      `toml:"build_jobs,omitempty"`
+	// LDFlags and OutputName are text/template strings over .Project,
+	// .Version, .OS, .Arch and .Ext, e.g. "-s -w -X main.version={{.Version}}"
+	// and the default "{{.Project}}-{{.OS}}-{{.Arch}}{{.Ext}}".
+	LDFlags    string `toml:"ldflags,omitempty"`
+	OutputName string `toml:"output_name,omitempty"`
+
+	// Archive packs the binaries found under BinaryAssetsPath into one
+	// archive per OS/arch before upload: "auto" (zip for windows,
+	// tar.gz otherwise), "tar.gz" or "zip". Empty uploads the raw
+	// files.
+	Archive string `toml:"archive,omitempty"`
+	// Checksums writes a SHA256SUMS manifest of the uploaded assets and
+	// uploads it with them.
+	Checksums bool `toml:"checksums,omitempty"`
+	// ChecksumTable appends a table of the assets' SHA-256 sums to the
+	// release body.
+	ChecksumTable bool `toml:"checksum_table,omitempty"`
+	// Sign signs SHA256SUMS with SignKey: "minisign" (SHA256SUMS.minisig)
+	// or "ssh" (ssh-keygen -Y sign, SHA256SUMS.sig). Implies Checksums.
+	// The key must not need a passphrase prompt.
+	Sign    string `toml:"sign,omitempty"`
+	SignKey string `toml:"sign_key,omitempty"`
 }
 
 // ChangelogConfig drives the optional post-pipeline step that detects the
@@ -104,7 +191,30 @@ type ChangelogConfig struct {
 	BumpStrategy string `toml:"bump_strategy"`
 	PromptFile   string `toml:"prompt_file"`
 	PromptModel  string `toml:"prompt_model"`
-	Prompt       string `toml:"-"`
+	// PromptProvider selects the backend for the refiner stage; same
+	// vocabulary and default as the PromptsConfig provider keys.
+	PromptProvider string `toml:"prompt_provider,omitempty"`
+	Prompt         string `toml:"-"`
+}
+
+// ProvidersConfig holds the connection settings for every LLM backend a
+// stage can be routed to. Only base URLs live in the TOML; API keys are
+// read from the global `.env` (OPENAI_API_KEY, ANTHROPIC_API_KEY) next to
+// the Groq slots so they never end up committed in a
+// `.commitcraft.toml`. Groq's key keeps coming from the two-slot
+// resolution in TUIConfig.
+type ProvidersConfig struct {
+	Groq      ProviderEndpoint `toml:"groq,omitempty"`
+	OpenAI    ProviderEndpoint `toml:"openai,omitempty"`
+	Ollama    ProviderEndpoint `toml:"ollama,omitempty"`
+	Anthropic ProviderEndpoint `toml:"anthropic,omitempty"`
+}
+
+// ProviderEndpoint is one backend's base URL plus its runtime-resolved
+// API key. An empty BaseURL keeps the backend's public default.
+type ProviderEndpoint struct {
+	BaseURL string `toml:"base_url,omitempty"`
+	APIKey  string `toml:"-"`
 }
 
 type Config struct {
@@ -115,10 +225,278 @@ type Config struct {
 	ReleaseConfig ReleaseConfig      `toml:"release_config,omitempty"`
 	Changelog     ChangelogConfig    `toml:"changelog,omitempty"`
 	Agent         AgentConfig        `toml:"agent,omitempty"`
+	Providers     ProvidersConfig    `toml:"providers,omitempty"`
+	Retry         RetryConfig        `toml:"retry,omitempty"`
+	Timeouts      TimeoutsConfig     `toml:"timeouts,omitempty"`
+	GitCommit     GitCommitConfig    `toml:"git_commit,omitempty"`
+	Hooks         HooksConfig        `toml:"hooks,omitempty"`
+	Trailers      TrailersConfig     `toml:"trailers,omitempty"`
+	Tokenizer     TokenizerConfig    `toml:"tokenizer,omitempty"`
+	Diff          DiffConfig         `toml:"diff,omitempty"`
+	Scope         ScopeConfig        `toml:"scope,omitempty"`
+	TagInference  TagInferenceConfig `toml:"tag_inference,omitempty"`
+	Style         StyleConfig        `toml:"style,omitempty"`
+	FewShot       FewShotConfig      `toml:"few_shot,omitempty"`
+	Embeddings    EmbeddingsConfig   `toml:"embeddings,omitempty"`
+}
+
+// EmbeddingsProviderHash is the built-in embeddings provider: feature
+// hashing of the words in a commit, computed locally with no network and
+// no model. It is the default and the fallback when a remote provider
+// fails.
+const EmbeddingsProviderHash = "hash"
+
+// EmbeddingsConfig drives the vector index behind `ai search` and the
+// History tab's semantic filter. Provider is "hash" (or empty) for the
+// local hashing embedder, or "openai"/"ollama" to call that backend's
+// embeddings endpoint with Model, reusing its [providers] base_url and
+// key. Dims sizes the hashing embedder's vectors. Vectors are cached in
+// the database per provider and model and recomputed when a commit or
+// release changes.
+type EmbeddingsConfig struct {
+	Provider string `toml:"provider"`
+	Model    string `toml:"model,omitempty"`
+	Dims     int    `toml:"dims"`
+}
+
+// FewShot modes. A repository's .commitcraft.toml sets its own mode to
+// opt in or out regardless of the global one.
+const (
+	FewShotOn  = "on"
+	FewShotOff = "off"
+)
+
+// FewShotConfig drives the few-shot examples added to the body and title
+// prompts: the completed commits of the same workspace most similar to
+// the one being written (touched paths, tag, scope). At most MaxExamples
+// are used and together they stay under MaxTokens, counted with the
+// stage model's tokenizer.
+type FewShotConfig struct {
+	Mode        string `toml:"mode"`
+	MaxExamples int    `toml:"max_examples"`
+	MaxTokens   int    `toml:"max_tokens"`
+}
+
+// Enabled reports whether few-shot examples are on; an empty mode
+// counts as on.
+func (f FewShotConfig) Enabled() bool {
+	return f.Mode != FewShotOff
+}
+
+// StyleConfig drives the repository style profile: the conventions mined
+// from the last Commits commits of `git log` (tags, scopes, title length,
+// bullet style, language). With Enabled on, a compact guide built from
+// the stored profile is appended to the body and title prompts; the
+// profile is built on first use and rebuilt by `ai style refresh`.
+type StyleConfig struct {
+	Enabled bool `toml:"enabled"`
+	Commits int  `toml:"commits"`
+}
+
+// TagInferenceConfig drives commit-type suggestions (`ai generate --tag
+// auto` and the TUI's pre-selected type). Rules on the staged files
+// (only docs, only tests, only deletions, …) always run; UseModel adds
+// the tag classifier stage on top. Suggestions below MinConfidence are
+// shown but not pre-selected.
+type TagInferenceConfig struct {
+	UseModel      bool    `toml:"use_model"`
+	MinConfidence float64 `toml:"min_confidence"`
+}
+
+// ScopeConfig drives scope inference (`ai generate --scope auto` and the
+// TUI's suggested scope). With Manifests on, the staged files are
+// attributed to the packages declared by go.work / go.mod, package.json
+// workspaces and Cargo workspaces. Map sends .gitignore-style globs to a
+// fixed scope and wins over the manifests; a repo's .commitcraft.toml
+// adds to it.
+type ScopeConfig struct {
+	Manifests bool              `toml:"manifests"`
+	Map       map[string]string `toml:"map,omitempty"`
+}
+
+// DiffConfig is the ignore layer for the diff sent to the Change
+// Analyzer. Files matching Ignore (.gitignore-style globs) are sent as a
+// one-line stat entry instead of their diff, as are lockfiles, vendored
+// and minified/generated files when BuiltinRules is on and files marked
+// `linguist-generated`, `linguist-vendored`, `-diff` or `binary` when
+// GitAttributes is on. Keep globs are never filtered. A repo's
+// .commitcraft.toml adds its own globs to the global lists.
+type DiffConfig struct {
+	Ignore        []string `toml:"ignore,omitempty"`
+	Keep          []string `toml:"keep,omitempty"`
+	BuiltinRules  bool     `toml:"builtin_rules"`
+	GitAttributes bool     `toml:"gitattributes"`
+}
+
+// TokenizerConfig locates the BPE vocabularies behind token estimates.
+// Dir (default "tokenizers", relative paths resolve against the global
+// config directory) holds one file per model family: llama3, llama4,
+// qwen or gpt-oss, each as `.json` (Hugging Face tokenizer.json) or
+// `.tiktoken`. Models maps a model id to a specific file for models the
+// family table does not cover. Models without a vocabulary fall back to
+// the chars/4 heuristic. ResolvedDir is Dir made absolute at load time.
+type TokenizerConfig struct {
+	Dir         string            `toml:"dir"`
+	Models      map[string]string `toml:"models,omitempty"`
+	ResolvedDir string            `toml:"-"`
+}
+
+// TrailersConfig is the team roster and shorthands behind draft
+// trailers. CoAuthors lets `Co-authored-by: ana` (CLI) or a toggle in
+// the compose panel expand to the full identity; RefsToken is the token
+// a bare issue reference ("#42", "PROJ-7") is written under.
+type TrailersConfig struct {
+	CoAuthors []CoAuthor `toml:"co_authors,omitempty"`
+	RefsToken string     `toml:"refs_token,omitempty"`
+}
+
+// CoAuthor is one roster entry. Alias is the short handle used on the
+// command line; Name and Email form the trailer value.
+type CoAuthor struct {
+	Alias string `toml:"alias"`
+	Name  string `toml:"name"`
+	Email string `toml:"email"`
+}
+
+// Identity renders the `Name <email>` trailer value.
+func (a CoAuthor) Identity() string {
+	return fmt.Sprintf("%s <%s>", strings.TrimSpace(a.Name), strings.TrimSpace(a.Email))
+}
+
+// Trailer renders the full Co-authored-by line for a.
+func (a CoAuthor) Trailer() string {
+	return commit.Trailer{Token: commit.TrailerCoAuthor, Value: a.Identity()}.String()
+}
+
+// CoAuthorByAlias finds a roster entry by alias (case-insensitive).
+func (c TrailersConfig) CoAuthorByAlias(alias string) (CoAuthor, bool) {
+	alias = strings.TrimPrefix(strings.TrimSpace(alias), "@")
+	for _, a := range c.CoAuthors {
+		if strings.EqualFold(a.Alias, alias) {
+			return a, true
+		}
+	}
+	return CoAuthor{}, false
+}
+
+// ExpandTrailer turns user input into a validated trailer line. Accepted
+// shapes, in order:
+//   - "signoff" / "sign-off": Signed-off-by with signOffIdentity (the
+//     committer's `Name <email>`; an error when empty);
+//   - "#42" or "PROJ-7": an issue reference under RefsToken;
+//   - "Token: value" or "Token=value" (git's --trailer syntax), where a
+//     Co-authored-by value naming a roster alias expands to its identity.
+func (c TrailersConfig) ExpandTrailer(raw, signOffIdentity string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToLower(raw) {
+	case "":
+		return "", fmt.Errorf("empty trailer")
+	case "signoff", "sign-off":
+		if signOffIdentity == "" {
+			return "", fmt.Errorf("no git identity for Signed-off-by (set user.name and user.email)")
+		}
+		return commit.Trailer{Token: commit.TrailerSignOff, Value: signOffIdentity}.String(), nil
+	}
+	if issueRefPattern.MatchString(raw) {
+		return commit.Trailer{Token: c.refsToken(), Value: raw}.String(), nil
+	}
+	if i := strings.IndexAny(raw, ":="); i > 0 && raw[i] == '=' {
+		raw = raw[:i] + ": " + raw[i+1:]
+	}
+	if t, ok := commit.ParseTrailer(raw); ok && strings.EqualFold(t.Token, commit.TrailerCoAuthor) {
+		if a, found := c.CoAuthorByAlias(t.Value); found {
+			return a.Trailer(), nil
+		}
+	}
+	t, err := commit.ValidateTrailer(raw)
+	if err != nil {
+		return "", err
+	}
+	return t.String(), nil
+}
+
+func (c TrailersConfig) refsToken() string {
+	if t := strings.TrimSpace(c.RefsToken); t != "" {
+		return t
+	}
+	return commit.TrailerRefs
+}
+
+// issueRefPattern matches bare issue references: "#42", "GH-12",
+// "PROJ-7", "owner/repo#3".
+var issueRefPattern = regexp.MustCompile(`^([\w.-]+/[\w.-]+)?#\d+$|^[A-Z][A-Z0-9]+-\d+$`)
+
+// HooksConfig drives `commitcraft hook run`. When no draft matches the
+// staged diff, prepare-commit-msg only runs the AI pipeline with
+// Generate on; DefaultTag is the commit type used for those messages
+// since a hook has no way to ask. VerifyWarnings makes commit-msg print
+// warnings as well as errors (errors always reject the commit).
+type HooksConfig struct {
+	Generate       bool   `toml:"generate"`
+	DefaultTag     string `toml:"default_tag,omitempty"`
+	VerifyWarnings bool   `toml:"verify_warnings"`
+}
+
+// GitCommitConfig holds the defaults for the commit action (Output
+// screen and `ai commit`). Sign is "" (off), "gpg" or "ssh";
+// SigningKey overrides git's user.signingkey. The local
+// .commitcraft.toml may turn sign-off on or change the signing setup,
+// but not NoVerify; see ResolveGitCommitConfig.
+type GitCommitConfig struct {
+	SignOff    bool   `toml:"sign_off"`
+	Sign       string `toml:"sign,omitempty"`
+	SigningKey string `toml:"signing_key,omitempty"`
+	NoVerify   bool   `toml:"no_verify"`
+}
+
+// RetryConfig is the policy applied to every AI stage call. A 429 waits
+// for the parsed rate-limit reset (capped at MaxWaitSeconds) and 5xx /
+// network errors back off exponentially from BaseBackoffMs up to
+// MaxBackoffMs, each for up to MaxAttempts tries per model. After that
+// the stage falls through FallbackModels (keyed by ModelStage, e.g.
+// "commit_body") and, with UseOtherKeySlot, repeats the chain on the
+// inactive Groq key slot. MaxAttempts = 1 with no fallbacks restores
+// the old fail-fast behavior.
+type RetryConfig struct {
+	MaxAttempts     int                 `toml:"max_attempts"`
+	BaseBackoffMs   int                 `toml:"base_backoff_ms"`
+	MaxBackoffMs    int                 `toml:"max_backoff_ms"`
+	MaxWaitSeconds  int                 `toml:"max_wait_seconds"`
+	UseOtherKeySlot bool                `toml:"use_other_key_slot"`
+	FallbackModels  map[string][]string `toml:"fallback_models,omitempty"`
+}
+
+// TimeoutsConfig bounds every single AI request. StageSeconds is the
+// default limit for one attempt of any stage; Stages overrides it per
+// ModelStage (e.g. "change_analyzer" = 180 for large diffs). A timed-out
+// attempt counts as a transient failure under RetryConfig. Zero or
+// negative values disable the limit for that stage.
+type TimeoutsConfig struct {
+	StageSeconds int            `toml:"stage_seconds"`
+	Stages       map[string]int `toml:"stages,omitempty"`
+}
+
+// ForStage returns the per-attempt timeout of stage, or 0 when disabled.
+func (t TimeoutsConfig) ForStage(stage ModelStage) time.Duration {
+	secs := t.StageSeconds
+	if v, ok := t.Stages[string(stage)]; ok {
+		secs = v
+	}
+	if secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
 }
 
 type CommitFormatConfig struct {
 	TypeFormat string `toml:"type_format"`
+	// Profile selects the message format: "bracket" (default,
+	// `[TAG] scope: title`) or "conventional" (`type(scope)!: title`
+	// with BREAKING CHANGE footers). It drives header formatting, the
+	// verify rules and the stage prompts. ConventionalTypes overrides
+	// the built-in tag → type table (ADD → feat, FIX → fix, …).
+	Profile           string            `toml:"profile,omitempty"`
+	ConventionalTypes map[string]string `toml:"conventional_types,omitempty"`
 	// CommitTypePalettes holds the per-tag four-color overrides resolved
 	// from `[[commit_types.types]]`. Populated at startup by
 	// `PopulateCommitTypePalettes` and forwarded to the styles package
@@ -126,6 +504,16 @@ type CommitFormatConfig struct {
 	CommitTypePalettes map[string]CommitTypePalette `toml:"-"`
 }
 
+// MessageFormat projects the config onto the commit package's
+// profile-aware formatter.
+func (c CommitFormatConfig) MessageFormat() commit.MessageFormat {
+	return commit.MessageFormat{
+		Profile:    c.Profile,
+		TypeFormat: c.TypeFormat,
+		TypeMap:    c.ConventionalTypes,
+	}
+}
+
 // CommitTypePalette is the wire-format mirror of `styles.CommitTypeColors`:
 // raw hex strings for the chip background/foreground (`bg_block`/`fg_block`)
 // and the message-row background/foreground (`bg_msg`/`fg_msg`). Kept here
@@ -162,8 +550,49 @@ func NewDefaultConfig() Config {
 			Behavior: "append",
 			Types:    []CustomCommitType{},
 		},
+		Retry: RetryConfig{
+			MaxAttempts:    2,
+			BaseBackoffMs:  1000,
+			MaxBackoffMs:   8000,
+			MaxWaitSeconds: 20,
+		},
+		Timeouts: TimeoutsConfig{
+			StageSeconds: 120,
+		},
+		Tokenizer: TokenizerConfig{
+			Dir: "tokenizers",
+		},
+		Diff: DiffConfig{
+			BuiltinRules:  true,
+			GitAttributes: true,
+		},
+		Scope: ScopeConfig{
+			Manifests: true,
+		},
+		TagInference: TagInferenceConfig{
+			UseModel:      true,
+			MinConfidence: 0.5,
+		},
+		Style: StyleConfig{
+			Enabled: true,
+			Commits: 200,
+		},
+		FewShot: FewShotConfig{
+			Mode:        FewShotOn,
+			MaxExamples: 3,
+			MaxTokens:   1200,
+		},
+		Embeddings: EmbeddingsConfig{
+			Provider: EmbeddingsProviderHash,
+			Dims:     512,
+		},
+		Hooks: HooksConfig{
+			DefaultTag:     "IMP",
+			VerifyWarnings: true,
+		},
 		TUI: TUIConfig{
-			UseNerdFonts: true,
+			UseNerdFonts:    true,
+			StreamResponses: true,
 			Pipeline: PipelineLayoutConfig{
 				StageDefaultHeight: 4,
 				StageFocusedHeight: 8,
@@ -174,6 +603,10 @@ func NewDefaultConfig() Config {
 			ChangeAnalyzerPromptFile:        "prompts/change_analyzer.prompt",
 			ChangeAnalyzerPromptModel:       "meta-llama/llama-4-scout-17b-16e-instruct",
 			ChangeAnalyzerMaxDiffSize:       80000,
+			ChangeAnalyzerChunking:          ChunkingAuto,
+			ChangeAnalyzerChunkBy:           ChunkByDirectory,
+			ChangeAnalyzerReducePromptFile:  "prompts/change_analyzer_reduce.prompt",
+			ChangeAnalyzerReducePromptModel: "meta-llama/llama-4-scout-17b-16e-instruct",
 			CommitBodyGeneratorPromptFile:   "prompts/commit_body_generator.prompt",
 			CommitBodyGeneratorPromptModel:  "llama-3.1-8b-instant",
 			CommitTitleGeneratorPromptFile:  "prompts/commit_title_generator.prompt",
@@ -186,6 +619,10 @@ func NewDefaultConfig() Config {
 			ReleaseTitlePromptModel:         "llama-3.1-8b-instant",
 			ReleaseRefinePromptFile:         "prompts/release_refine.prompt",
 			ReleaseRefinePromptModel:        "llama-3.1-8b-instant",
+			SplitPlannerPromptFile:          "prompts/split_planner.prompt",
+			SplitPlannerPromptModel:         "llama-3.1-8b-instant",
+			TagClassifierPromptFile:         "prompts/tag_classifier.prompt",
+			TagClassifierPromptModel:        "llama-3.1-8b-instant",
 			AgentCommitPromptFile:           "prompts/agent_commit.prompt",
 			AgentReleasePromptFile:          "prompts/agent_release.prompt",
 		},
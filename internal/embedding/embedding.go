@@ -0,0 +1,210 @@
+// Package embedding turns commit and release texts into vectors for the
+// semantic search index. Remote embedders call a provider's embeddings
+// endpoint; Hash is the built-in fallback: signed feature hashing of
+// words, word pairs and character trigrams, deterministic and offline.
+//
+// Hash vectors only capture shared vocabulary ("tokenizer" finds commits
+// that mention tokens or tokenizers), not synonyms; a remote model is
+// needed for that.
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"commit_craft_reborn/internal/api"
+)
+
+// Embedder maps texts to vectors of a fixed length, one per text.
+type Embedder interface {
+	// Name identifies the embedder and its vector space, e.g.
+	// "hash-512" or "ollama:nomic-embed-text". Vectors stored under one
+	// name are never compared with another's.
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// DefaultDims is the Hash vector length used when none is configured.
+const DefaultDims = 512
+
+// Hash is the offline embedder. Dims <= 0 uses DefaultDims.
+type Hash struct {
+	Dims int
+}
+
+func (h Hash) dims() int {
+	if h.Dims <= 0 {
+		return DefaultDims
+	}
+	return h.Dims
+}
+
+func (h Hash) Name() string { return fmt.Sprintf("hash-%d", h.dims()) }
+
+// Feature weights: whole words carry the meaning, word pairs reward
+// matching phrases, and trigrams let word forms ("tokenize",
+// "tokenizer") and typos still meet.
+const (
+	hashWordWeight    = 1.0
+	hashBigramWeight  = 0.5
+	hashTrigramWeight = 0.25
+)
+
+func (h Hash) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = h.Vector(t)
+	}
+	return out, nil
+}
+
+// Vector embeds a single text. The result is L2-normalised, or all zeros
+// when text has no words.
+func (h Hash) Vector(text string) []float32 {
+	v := make([]float32, h.dims())
+	words := Words(text)
+	for i, w := range words {
+		h.add(v, "w:"+w, hashWordWeight)
+		if i > 0 {
+			h.add(v, "b:"+words[i-1]+" "+w, hashBigramWeight)
+		}
+		padded := "^" + w + "$"
+		for j := 0; j+3 <= len(padded); j++ {
+			h.add(v, "t:"+padded[j:j+3], hashTrigramWeight)
+		}
+	}
+	normalize(v)
+	return v
+}
+
+// add hashes feature into one dimension; a second hash bit picks the
+// sign so collisions cancel out on average instead of piling up.
+func (h Hash) add(v []float32, feature string, weight float32) {
+	f := fnv.New64a()
+	f.Write([]byte(feature))
+	sum := f.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(len(v))] += weight
+}
+
+// stopWords are dropped before hashing: they appear in most commits and
+// would make every pair of texts look alike.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"so": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"when": true, "with": true, "now": true, "no": true, "not": true,
+}
+
+// Words splits text into lower-case word stems: runs of letters and
+// digits, stop words and single characters dropped, and a trailing
+// "ing", "ed", "es" or "s" removed when at least three letters remain.
+func Words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || stopWords[f] {
+			continue
+		}
+		out = append(out, stem(f))
+	}
+	return out
+}
+
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(w, suffix) && len(w)-len(suffix) >= 3 {
+			return strings.TrimSuffix(w, suffix)
+		}
+	}
+	return w
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+}
+
+// Remote embeds through a provider's embeddings endpoint.
+type Remote struct {
+	Provider api.Provider
+	APIKey   string
+	Model    string
+}
+
+func (r Remote) Name() string { return r.Provider.Name() + ":" + r.Model }
+
+// remoteBatch caps the inputs sent per request; both OpenAI and Ollama
+// accept far more, but small batches keep a slow local model responsive
+// and a failure cheap.
+const remoteBatch = 64
+
+func (r Remote) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += remoteBatch {
+		end := min(start+remoteBatch, len(texts))
+		vectors, err := api.Embed(ctx, r.Provider, r.APIKey, r.Model, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vectors {
+			normalize(v)
+		}
+		out = append(out, vectors...)
+	}
+	return out, nil
+}
+
+// Cosine is the cosine similarity of a and b, which the embedders
+// normalise, so it is their dot product. Vectors of different lengths
+// score 0.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// Encode packs v as little-endian float32s for storage.
+func Encode(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+// Decode is the inverse of Encode.
+func Decode(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("vector blob has %d bytes, not a multiple of 4", len(buf))
+	}
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,25 @@
+package embedding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashEmbedderDeterministic(t *testing.T) {
+	h := Hash{Dims: 256}
+	a := h.Vector("Cache the tokenizer vocabularies")
+	b := h.Vector("Cache the tokenizer vocabularies")
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same text gave different vectors")
+	}
+	if got := Cosine(a, b); got < 0.999 {
+		t.Errorf("self similarity = %v, want 1", got)
+	}
+	if got := Cosine(a, h.Vector("the and of")); got != 0 {
+		t.Errorf("stop words only should embed to zero, similarity = %v", got)
+	}
+	v, err := Decode(Encode(a))
+	if err != nil || !reflect.DeepEqual(v, a) {
+		t.Errorf("encode/decode round trip failed: %v", err)
+	}
+}
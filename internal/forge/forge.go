@@ -0,0 +1,177 @@
+// Package forge holds the HTTP plumbing the forge clients (github,
+// gitlab, gitea) share: JSON calls with a deadline, the mapping of
+// non-2xx replies to APIError, and upload bodies that report progress.
+// Each client describes its own headers and error body through an API
+// value; the endpoints and payloads stay in the client packages.
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// APITimeout bounds every JSON call. Uploads have no deadline of their
+// own (a large binary on a slow link can take minutes); the caller's
+// context ends them.
+const APITimeout = 60 * time.Second
+
+// APIError is returned for any non-2xx response. Forge names the API in
+// the message ("GitHub", "GitLab", "Gitea"); Message is the reply's
+// error text, or the raw body when it has none.
+type APIError struct {
+	Forge   string
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API returned %d: %s", e.Forge, e.Status, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from a forge API.
+func IsNotFound(err error) bool {
+	var ae *APIError
+	return errors.As(err, &ae) && ae.Status == http.StatusNotFound
+}
+
+// ProgressFunc receives the bytes of an upload sent so far and its size.
+type ProgressFunc func(sent, total int64)
+
+// API is one forge's calling convention. Header sets the Accept and
+// auth headers on every request. Message extracts the error text from a
+// non-2xx body; nil reads a top-level `message` string.
+type API struct {
+	Forge   string
+	HTTP    *http.Client
+	Header  func(h http.Header)
+	Message func(body []byte) string
+}
+
+// DoJSON sends payload (nil for none) and decodes the reply into out
+// (nil to discard it), within APITimeout.
+func (a API) DoJSON(ctx context.Context, method, target string, payload, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, APITimeout)
+	defer cancel()
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return a.Send(req, out)
+}
+
+// Send adds the forge's headers, runs req and maps the reply.
+func (a API) Send(req *http.Request, out any) error {
+	if a.Header != nil {
+		a.Header(req.Header)
+	}
+	client := a.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := ""
+		if a.Message != nil {
+			msg = a.Message(data)
+		} else {
+			msg = messageField(data)
+		}
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+		}
+		return &APIError{Forge: a.Forge, Status: resp.StatusCode, Message: msg}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding response JSON: %w", err)
+	}
+	return nil
+}
+
+// messageField returns the `message` string of a JSON error body, or "".
+func messageField(data []byte) string {
+	var parsed struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &parsed) != nil {
+		return ""
+	}
+	return parsed.Message
+}
+
+// Upload is a file opened for upload. Body reads the file and reports
+// progress when a ProgressFunc was given; Close closes the file.
+type Upload struct {
+	Body io.Reader
+	Size int64
+	f    *os.File
+}
+
+// OpenUpload opens path for upload. progress, when set, is called with
+// 0 right away and then as the transport reads Body, ending with
+// sent == Size.
+func OpenUpload(path string, progress ProgressFunc) (*Upload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	u := &Upload{Body: f, Size: info.Size(), f: f}
+	if progress != nil {
+		progress(0, u.Size)
+		u.Body = &progressReader{r: f, total: u.Size, fn: progress}
+	}
+	return u, nil
+}
+
+func (u *Upload) Close() error { return u.f.Close() }
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,130 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"bad token"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/ok":
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("Content-Type") != "application/json" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			fmt.Fprintf(w, `{"echo":%s}`, body)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/raw":
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "upstream down\n")
+		case "/custom":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"error":"name taken"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+	defer srv.Close()
+	api := API{Forge: "Test", Header: func(h http.Header) { h.Set("X-Token", "tok") }}
+	ctx := context.Background()
+
+	var out struct {
+		Echo struct {
+			N int `json:"n"`
+		} `json:"echo"`
+	}
+	if err := api.DoJSON(ctx, http.MethodPost, srv.URL+"/ok", map[string]int{"n": 7}, &out); err != nil || out.Echo.N != 7 {
+		t.Errorf("DoJSON = %+v, %v", out, err)
+	}
+	if err := api.DoJSON(ctx, http.MethodDelete, srv.URL+"/empty", nil, &out); err != nil {
+		t.Errorf("empty 204 reply: %v", err)
+	}
+
+	custom := api
+	custom.Message = func(body []byte) string {
+		if strings.Contains(string(body), "name taken") {
+			return "the name is taken"
+		}
+		return ""
+	}
+	cases := []struct {
+		name     string
+		api      API
+		path     string
+		want     string
+		notFound bool
+	}{
+		{"message field", api, "/missing", "Test API returned 404: Not Found", true},
+		{"raw body", api, "/raw", "Test API returned 502: upstream down", false},
+		{"custom message", custom, "/custom", "Test API returned 422: the name is taken", false},
+		{"custom falls back to raw", custom, "/raw", "Test API returned 502: upstream down", false},
+		{"headers applied", API{Forge: "Test"}, "/ok", "Test API returned 401: bad token", false},
+	}
+	for _, c := range cases {
+		err := c.api.DoJSON(ctx, http.MethodGet, srv.URL+c.path, nil, nil)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("%s: err = %v, want %q", c.name, err, c.want)
+		}
+		if IsNotFound(err) != c.notFound {
+			t.Errorf("%s: IsNotFound = %v, want %v", c.name, IsNotFound(err), c.notFound)
+		}
+	}
+	if IsNotFound(fmt.Errorf("wrapped: %w", &APIError{Status: http.StatusNotFound})) != true {
+		t.Error("IsNotFound misses a wrapped 404")
+	}
+}
+
+func TestOpenUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asset.bin")
+	data := strings.Repeat("x", 70000)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var reports [][2]int64
+	up, err := OpenUpload(path, func(sent, total int64) { reports = append(reports, [2]int64{sent, total}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.Size != int64(len(data)) {
+		t.Errorf("size = %d, want %d", up.Size, len(data))
+	}
+	got, err := io.ReadAll(up.Body)
+	if err != nil || string(got) != data {
+		t.Fatalf("body read %d bytes, %v", len(got), err)
+	}
+	if err := up.Close(); err != nil {
+		t.Error(err)
+	}
+	size := int64(len(data))
+	if len(reports) < 2 || reports[0] != [2]int64{0, size} || reports[len(reports)-1] != [2]int64{size, size} {
+		t.Errorf("progress = %v, want 0/%d first and %d/%d last", reports, size, size, size)
+	}
+
+	up, err = OpenUpload(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer up.Close()
+	if _, ok := up.Body.(*os.File); !ok {
+		t.Errorf("body without progress = %T, want the file itself", up.Body)
+	}
+	if _, err := OpenUpload(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("missing file opened")
+	}
+}
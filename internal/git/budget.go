@@ -0,0 +1,394 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Diff budgeting for the Change Analyzer input. Instead of cutting the
+// staged diff at the first file that does not fit, files are ranked by
+// how much they tell the model (source before tests before docs and
+// config, generated files, lockfiles and binaries last; smaller diffs
+// first within a rank) and packed until the budget is spent. Whatever
+// does not fit is listed after the diff blocks as one "path +N -M
+// (reason)" line per file, so the model knows it exists and callers can
+// report exactly what was left out.
+
+// OmittedDiffHeader opens the omitted-files section appended to a
+// budgeted diff. ParseOmittedFiles and SplitOmittedSection look for it.
+const OmittedDiffHeader = "=== omitted: name + numstat only ==="
+
+// Reasons recorded on OmittedFile.
+const (
+	OmitBudget    = "budget"
+	OmitLockfile  = "lockfile"
+	OmitGenerated = "generated"
+	OmitBinary    = "binary"
+)
+
+// OmittedFile is a staged file whose diff was left out of a budgeted
+// summary. Adds/Dels follow FileNumstat (-1 for binary files). Reason
+// is OmitBudget for regular files that did not fit, otherwise the rank
+// that pushed the file to the back of the queue.
+type OmittedFile struct {
+	Path   string `json:"path"`
+	Adds   int    `json:"adds"`
+	Dels   int    `json:"dels"`
+	Reason string `json:"reason"`
+}
+
+// DiffBudget is the result of BudgetStagedDiffAt. Diff is the text fed
+// to the Change Analyzer (the same "=== path ===" blocks as before, in
+// path order, followed by the omitted section when anything was left
+// out). Included lists the paths whose diff is in Diff.
+type DiffBudget struct {
+	Diff     string
+	Included []string
+	Omitted  []OmittedFile
+	MaxChars int
+}
+
+// Truncated reports whether any staged file was left out.
+func (b DiffBudget) Truncated() bool { return len(b.Omitted) > 0 }
+
+// Diff ranks, lowest first.
+const (
+	rankSource = iota
+	rankTest
+	rankDocs
+	rankGenerated
+	rankLockfile
+	rankBinary
+)
+
+var lockfileNames = map[string]bool{
+	"go.sum":              true,
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"Cargo.lock":          true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"uv.lock":             true,
+	"Gemfile.lock":        true,
+	"composer.lock":       true,
+	"mix.lock":            true,
+	"flake.lock":          true,
+	"Podfile.lock":        true,
+	"pubspec.lock":        true,
+}
+
+// generatedSuffixes and generatedDirs rank files that usually hold
+// generated or vendored code after tests and docs. They only order the
+// budget: the DiffFilter's built-in rules filter lockfiles, vendoredDirs
+// and Go files with a generated header, not these names.
+var generatedSuffixes = []string{
+	".pb.go", ".pb.gw.go", "_gen.go", ".gen.go", "_generated.go", "_string.go",
+	".min.js", ".min.css", ".map", ".snap", "_pb2.py", ".g.dart", ".freezed.dart",
+}
+
+var generatedDirs = []string{"dist/", "build/", "third_party/"}
+
+// vendoredDirs hold third-party code the built-in filter rule drops.
+var vendoredDirs = []string{"vendor/", "node_modules/"}
+
+// isLockfile reports whether p is a dependency lockfile.
+func isLockfile(p string) bool {
+	base := path.Base(p)
+	return lockfileNames[base] || strings.HasSuffix(base, ".lock")
+}
+
+// isVendored reports whether p lies under a vendoredDirs directory.
+func isVendored(p string) bool {
+	return underDir(strings.ToLower(p), vendoredDirs)
+}
+
+// underDir reports whether the lower-cased path lies under one of dirs
+// at any depth.
+func underDir(lower string, dirs []string) bool {
+	for _, d := range dirs {
+		if strings.HasPrefix(lower, d) || strings.Contains(lower, "/"+d) {
+			return true
+		}
+	}
+	return false
+}
+
+var docsExts = map[string]bool{
+	".md": true, ".rst": true, ".txt": true, ".adoc": true,
+	".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true,
+	".cfg": true, ".conf": true, ".xml": true, ".csv": true, ".svg": true,
+}
+
+// rankDiffFile classifies path (and its numstat) into a diff rank.
+func rankDiffFile(p string, ns FileNumstat) (int, string) {
+	base := path.Base(p)
+	lower := strings.ToLower(p)
+	switch {
+	case ns.Adds < 0 || ns.Dels < 0:
+		return rankBinary, OmitBinary
+	case isLockfile(p):
+		return rankLockfile, OmitLockfile
+	case underDir(lower, vendoredDirs):
+		return rankGenerated, OmitVendored
+	case underDir(lower, generatedDirs):
+		return rankGenerated, OmitGenerated
+	}
+	for _, s := range generatedSuffixes {
+		if strings.HasSuffix(lower, s) {
+			return rankGenerated, OmitGenerated
+		}
+	}
+	if strings.HasPrefix(base, "zz_generated") {
+		return rankGenerated, OmitGenerated
+	}
+	if isTestFile(lower) {
+		return rankTest, OmitBudget
+	}
+	if docsExts[path.Ext(lower)] || strings.HasPrefix(lower, "docs/") {
+		return rankDocs, OmitBudget
+	}
+	return rankSource, OmitBudget
+}
+
+func isTestFile(lower string) bool {
+	base := path.Base(lower)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") ||
+		strings.HasPrefix(lower, "test/") || strings.HasPrefix(lower, "tests/") ||
+		strings.Contains(lower, "/test/") || strings.Contains(lower, "/tests/") ||
+		strings.Contains(lower, "/testdata/")
+}
+
+// omittedLine renders one line of the omitted section.
+func omittedLine(f OmittedFile) string {
+	adds, dels := fmt.Sprintf("+%d", f.Adds), fmt.Sprintf("-%d", f.Dels)
+	if f.Adds < 0 {
+		adds = "+bin"
+	}
+	if f.Dels < 0 {
+		dels = "-bin"
+	}
+	return fmt.Sprintf("%s %s %s (%s)\n", f.Path, adds, dels, f.Reason)
+}
+
+// BudgetStagedDiff is BudgetStagedDiffAt for the current directory.
+func BudgetStagedDiff(maxChars int) (DiffBudget, error) {
+	return BudgetStagedDiffAt("", maxChars)
+}
+
+// BudgetStagedDiffAt builds the Change Analyzer input for the staged
+// changes of workspace within maxChars characters (no limit when
+// maxChars <= 0). A staged submodule bump is expanded into the diff of
+// the submodule between the two recorded commits instead of git's bare
+// "Subproject commit" lines. The omitted section counts against the
+// budget too, so len(Diff) <= maxChars unless the omitted lines alone
+// exceed it.
+func BudgetStagedDiffAt(workspace string, maxChars int) (DiffBudget, error) {
+	out := DiffBudget{MaxChars: maxChars}
+	numstat, err := numstatAt(workspace, "diff", "--cached", "--numstat", "--no-renames")
+	if err != nil {
+		return out, err
+	}
+	if len(numstat) == 0 {
+		return out, nil
+	}
+
+	filtered := ActiveDiffFilter().filterFiles(workspace, "--cached", numstat)
+	files := make([]budgetFile, 0, len(numstat))
+	for p, ns := range numstat {
+		if r := filtered[p]; r != "" {
+			files = append(files, budgetFile{path: p, numstat: ns, filtered: r})
+			continue
+		}
+		diff, err := runGitIndex(workspace, "", "diff", "--cached", "--unified=0", "--no-color",
+			"--no-ext-diff", "--no-renames", "--submodule=diff", "--", p)
+		if err != nil {
+			return out, fmt.Errorf("failed to get diff for file %s: %w", p, err)
+		}
+		files = append(files, budgetFile{path: p, numstat: ns, diff: diff})
+	}
+	return packDiffBudget(files, maxChars), nil
+}
+
+// budgetFile is one file handed to packDiffBudget. filtered is the
+// reason the DiffFilter keeps the file stat-only ("" to let the budget
+// decide); filtered files have no diff.
+type budgetFile struct {
+	path     string
+	numstat  FileNumstat
+	diff     string
+	filtered string
+}
+
+// packDiffBudget ranks files, packs as many "=== path ===" blocks as fit
+// in maxChars and lists the rest, filtered files included, in the
+// omitted section.
+func packDiffBudget(files []budgetFile, maxChars int) DiffBudget {
+	out := DiffBudget{MaxChars: maxChars}
+	type candidate struct {
+		OmittedFile
+		rank     int
+		block    string
+		filtered bool
+	}
+	cands := make([]*candidate, 0, len(files))
+	for _, f := range files {
+		rank, reason := rankDiffFile(f.path, f.numstat)
+		if f.filtered != "" {
+			reason = f.filtered
+		}
+		cands = append(cands, &candidate{
+			OmittedFile: OmittedFile{
+				Path:   f.path,
+				Adds:   f.numstat.Adds,
+				Dels:   f.numstat.Dels,
+				Reason: reason,
+			},
+			rank:     rank,
+			block:    fmt.Sprintf("=== %s ===\n%s\n", f.path, f.diff),
+			filtered: f.filtered != "",
+		})
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if len(a.block) != len(b.block) {
+			return len(a.block) < len(b.block)
+		}
+		return a.Path < b.Path
+	})
+
+	// pending is the size of the omitted section if every file not yet
+	// packed ended up in it; a file is packed only when the blocks so
+	// far, its own block and the section for the rest still fit.
+	pending := 0
+	for _, c := range cands {
+		pending += len(omittedLine(c.OmittedFile))
+	}
+	header := len(OmittedDiffHeader) + 1
+	used := 0
+	included := map[string]bool{}
+	for _, c := range cands {
+		if c.filtered {
+			continue
+		}
+		rest := pending - len(omittedLine(c.OmittedFile))
+		cost := used + len(c.block)
+		if rest > 0 {
+			cost += header + rest
+		}
+		if maxChars > 0 && cost > maxChars {
+			continue
+		}
+		included[c.Path] = true
+		used += len(c.block)
+		pending = rest
+	}
+
+	sort.Slice(cands, func(i, j int) bool { return cands[i].Path < cands[j].Path })
+	var b strings.Builder
+	for _, c := range cands {
+		if included[c.Path] {
+			b.WriteString(c.block)
+			out.Included = append(out.Included, c.Path)
+		}
+	}
+	for _, c := range cands {
+		if !included[c.Path] {
+			out.Omitted = append(out.Omitted, c.OmittedFile)
+		}
+	}
+	if len(out.Omitted) > 0 {
+		b.WriteString(OmittedDiffHeader + "\n")
+		for _, f := range out.Omitted {
+			b.WriteString(omittedLine(f))
+		}
+	}
+	out.Diff = b.String()
+	return out
+}
+
+// SplitOmittedSection separates a budgeted diff into its diff blocks
+// and the omitted files listed after OmittedDiffHeader. Diffs without
+// the section (including snapshots saved before budgeting existed) come
+// back unchanged with no omitted files.
+func SplitOmittedSection(diff string) (string, []OmittedFile) {
+	i := strings.Index(diff, OmittedDiffHeader+"\n")
+	if i < 0 {
+		return diff, nil
+	}
+	var omitted []OmittedFile
+	for _, line := range strings.Split(diff[i+len(OmittedDiffHeader)+1:], "\n") {
+		if f, ok := parseOmittedLine(line); ok {
+			omitted = append(omitted, f)
+		}
+	}
+	return diff[:i], omitted
+}
+
+// ParseOmittedFiles returns the omitted files recorded in a budgeted
+// diff, or nil when nothing was left out.
+func ParseOmittedFiles(diff string) []OmittedFile {
+	_, omitted := SplitOmittedSection(diff)
+	return omitted
+}
+
+// parseOmittedLine reads "path +N -M (reason)". The path may contain
+// spaces, so the line is parsed from the right.
+func parseOmittedLine(line string) (OmittedFile, bool) {
+	line = strings.TrimSpace(line)
+	open := strings.LastIndex(line, " (")
+	if open < 0 || !strings.HasSuffix(line, ")") {
+		return OmittedFile{}, false
+	}
+	f := OmittedFile{Reason: line[open+2 : len(line)-1]}
+	fields := strings.Fields(line[:open])
+	if len(fields) < 3 {
+		return OmittedFile{}, false
+	}
+	n := len(fields)
+	f.Adds = parseNumstatCount(strings.TrimPrefix(fields[n-2], "+"))
+	f.Dels = parseNumstatCount(strings.TrimPrefix(fields[n-1], "-"))
+	f.Path = strings.TrimSpace(strings.TrimSuffix(line[:open], fields[n-2]+" "+fields[n-1]))
+	return f, f.Path != ""
+}
+
+func parseNumstatCount(s string) int {
+	if s == "bin" {
+		return -1
+	}
+	return atoiDefault(s, 0)
+}
+
+// numstatAt runs a --numstat git command from the repository root of
+// workspace and parses it like GetStagedNumstat.
+func numstatAt(workspace string, args ...string) (map[string]FileNumstat, error) {
+	out, err := runGitIndex(workspace, "", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get numstat: %w", err)
+	}
+	result := map[string]FileNumstat{}
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		ns := FileNumstat{Adds: -1, Dels: -1}
+		if parts[0] != "-" {
+			ns.Adds = atoiDefault(parts[0], 0)
+		}
+		if parts[1] != "-" {
+			ns.Dels = atoiDefault(parts[1], 0)
+		}
+		result[parts[2]] = ns
+	}
+	return result, nil
+}
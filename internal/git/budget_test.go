@@ -0,0 +1,223 @@
+package git
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// diffFile returns a budgetFile for path whose diff adds n lines.
+func diffFile(path string, n int) budgetFile {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -0,0 +1,%d @@\n", n)
+	for i := range n {
+		fmt.Fprintf(&b, "+%s line %d\n", path, i)
+	}
+	return budgetFile{path: path, numstat: FileNumstat{Adds: n}, diff: b.String()}
+}
+
+// blockLen is the size of f's "=== path ===" block in a budgeted diff.
+func blockLen(f budgetFile) int {
+	return len(fmt.Sprintf("=== %s ===\n%s\n", f.path, f.diff))
+}
+
+// sectionLen is the size of the omitted section listing files.
+func sectionLen(files ...budgetFile) int {
+	n := len(OmittedDiffHeader) + 1
+	for _, f := range files {
+		_, reason := rankDiffFile(f.path, f.numstat)
+		if f.filtered != "" {
+			reason = f.filtered
+		}
+		n += len(omittedLine(OmittedFile{Path: f.path, Adds: f.numstat.Adds, Dels: f.numstat.Dels, Reason: reason}))
+	}
+	return n
+}
+
+func TestPackDiffBudget(t *testing.T) {
+	src := diffFile("cmd/main.go", 20)
+	test := diffFile("cmd/main_test.go", 10)
+	docs := diffFile("README.md", 10)
+	smallDocs := diffFile("docs/a.md", 1)
+	huge := diffFile("big.go", 200)
+	lock := diffFile("go.sum", 5)
+	vendored := budgetFile{path: "vendor/x/x.go", numstat: FileNumstat{Adds: 3}, filtered: OmitVendored}
+
+	cases := []struct {
+		name     string
+		files    []budgetFile
+		maxChars int
+		included []string
+		omitted  []string
+	}{
+		{
+			name:     "no limit packs everything but filtered files",
+			files:    []budgetFile{src, docs, vendored},
+			included: []string{"README.md", "cmd/main.go"},
+			omitted:  []string{"vendor/x/x.go"},
+		},
+		{
+			name:     "source beats a smaller docs file",
+			files:    []budgetFile{docs, src},
+			maxChars: blockLen(src) + sectionLen(docs),
+			included: []string{"cmd/main.go"},
+			omitted:  []string{"README.md"},
+		},
+		{
+			name:     "tests beat docs",
+			files:    []budgetFile{src, test, docs},
+			maxChars: blockLen(src) + blockLen(test) + sectionLen(docs),
+			included: []string{"cmd/main.go", "cmd/main_test.go"},
+			omitted:  []string{"README.md"},
+		},
+		{
+			name:     "smaller files first within a rank",
+			files:    []budgetFile{docs, smallDocs},
+			maxChars: blockLen(smallDocs) + sectionLen(docs),
+			included: []string{"docs/a.md"},
+			omitted:  []string{"README.md"},
+		},
+		{
+			name:     "a file larger than the budget is omitted, not cut",
+			files:    []budgetFile{huge, smallDocs},
+			maxChars: blockLen(huge) - 1,
+			included: []string{"docs/a.md"},
+			omitted:  []string{"big.go"},
+		},
+		{
+			name:     "a smaller lockfile still goes after docs",
+			files:    []budgetFile{lock, docs},
+			maxChars: blockLen(docs) + sectionLen(lock),
+			included: []string{"README.md"},
+			omitted:  []string{"go.sum"},
+		},
+		{
+			name:     "a smaller file fills the room a larger one left",
+			files:    []budgetFile{huge, smallDocs, src},
+			maxChars: blockLen(src) + blockLen(smallDocs) + sectionLen(huge),
+			included: []string{"cmd/main.go", "docs/a.md"},
+			omitted:  []string{"big.go"},
+		},
+		{
+			name:     "the omitted section alone fills the budget",
+			files:    []budgetFile{src, docs},
+			maxChars: sectionLen(src, docs),
+			omitted:  []string{"README.md", "cmd/main.go"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := packDiffBudget(c.files, c.maxChars)
+			var omitted []string
+			for _, f := range b.Omitted {
+				omitted = append(omitted, f.Path)
+			}
+			if !reflect.DeepEqual(b.Included, c.included) || !reflect.DeepEqual(omitted, c.omitted) {
+				t.Errorf("included %v, omitted %v; want %v, %v", b.Included, omitted, c.included, c.omitted)
+			}
+			if c.maxChars > 0 && len(b.Diff) > c.maxChars {
+				t.Errorf("len(Diff) = %d, over the %d budget", len(b.Diff), c.maxChars)
+			}
+			for _, f := range c.files {
+				block := fmt.Sprintf("=== %s ===\n", f.path)
+				if included := strings.Contains(b.Diff, block); included != slices.Contains(c.included, f.path) {
+					t.Errorf("%s block present = %v", f.path, included)
+				}
+				if !slices.Contains(c.included, f.path) && f.diff != "" && strings.Contains(b.Diff, f.diff) {
+					t.Errorf("part of the omitted %s diff leaked into the output", f.path)
+				}
+			}
+			if b.Truncated() != (len(c.omitted) > 0) {
+				t.Errorf("Truncated = %v", b.Truncated())
+			}
+		})
+	}
+}
+
+func TestPackDiffBudgetNeverExceedsMax(t *testing.T) {
+	// Each pkg/fN.go has a docs/fN.md twin of the same size, so a docs
+	// file can only be packed when its higher-ranked twin was.
+	var files []budgetFile
+	for i, n := range []int{1, 3, 7, 12, 25, 40} {
+		files = append(files, diffFile(fmt.Sprintf("pkg/f%d.go", i), n))
+		files = append(files, diffFile(fmt.Sprintf("docs/f%d.md", i), n))
+	}
+	floor := sectionLen(files...)
+	total := floor
+	for _, f := range files {
+		total += blockLen(f)
+	}
+	for maxChars := floor; maxChars <= total; maxChars += 37 {
+		b := packDiffBudget(files, maxChars)
+		if len(b.Diff) > maxChars {
+			t.Fatalf("maxChars %d: len(Diff) = %d", maxChars, len(b.Diff))
+		}
+		if len(b.Included)+len(b.Omitted) != len(files) {
+			t.Fatalf("maxChars %d: %d included + %d omitted of %d files",
+				maxChars, len(b.Included), len(b.Omitted), len(files))
+		}
+		for _, p := range b.Included {
+			name, ok := strings.CutPrefix(p, "docs/")
+			if twin := "pkg/" + strings.TrimSuffix(name, ".md") + ".go"; ok && !slices.Contains(b.Included, twin) {
+				t.Fatalf("maxChars %d: %s packed while %s was omitted", maxChars, p, twin)
+			}
+		}
+	}
+}
+
+func TestSplitOmittedSectionRoundTrip(t *testing.T) {
+	files := []budgetFile{
+		diffFile("main.go", 30),
+		diffFile("dir with spaces/notes (draft).md", 4),
+		{path: "assets/logo.png", numstat: FileNumstat{Adds: -1, Dels: -1}},
+		{path: "go.sum", numstat: FileNumstat{Adds: 12, Dels: 3}, diff: "@@ -1 +1 @@\n-a\n+b\n"},
+		{path: "vendor/lib.go", numstat: FileNumstat{Adds: 5, Dels: 0}, filtered: OmitVendored},
+		diffFile("tiny.go", 1),
+	}
+	for _, maxChars := range []int{0, 150, 400, 1000} {
+		b := packDiffBudget(files, maxChars)
+		blocks, omitted := SplitOmittedSection(b.Diff)
+		if !reflect.DeepEqual(omitted, b.Omitted) {
+			t.Errorf("maxChars %d: round trip = %+v, want %+v", maxChars, omitted, b.Omitted)
+		}
+		if !reflect.DeepEqual(ParseOmittedFiles(b.Diff), b.Omitted) {
+			t.Errorf("maxChars %d: ParseOmittedFiles disagrees with SplitOmittedSection", maxChars)
+		}
+		if strings.Contains(blocks, OmittedDiffHeader) || !strings.HasPrefix(b.Diff, blocks) {
+			t.Errorf("maxChars %d: blocks = %q", maxChars, blocks)
+		}
+	}
+
+	plain := "=== a.go ===\n@@ -1 +1 @@\n-a\n+b\n\n"
+	if blocks, omitted := SplitOmittedSection(plain); blocks != plain || omitted != nil {
+		t.Errorf("diff without a section = %q, %v", blocks, omitted)
+	}
+}
+
+func TestParseOmittedLine(t *testing.T) {
+	cases := []struct {
+		line string
+		want OmittedFile
+		ok   bool
+	}{
+		{"main.go +3 -1 (budget)", OmittedFile{"main.go", 3, 1, OmitBudget}, true},
+		{"a b/c (d).md +0 -7 (budget)\n", OmittedFile{"a b/c (d).md", 0, 7, OmitBudget}, true},
+		{"logo.png +bin -bin (binary)", OmittedFile{"logo.png", -1, -1, OmitBinary}, true},
+		{"+1 -1 (budget)", OmittedFile{}, false},
+		{"main.go +3 -1", OmittedFile{}, false},
+		{"", OmittedFile{}, false},
+	}
+	for _, c := range cases {
+		got, ok := parseOmittedLine(c.line)
+		if ok != c.ok || (ok && got != c.want) {
+			t.Errorf("parseOmittedLine(%q) = %+v, %v; want %+v, %v", c.line, got, ok, c.want, c.ok)
+		}
+		if c.ok {
+			if back, _ := parseOmittedLine(omittedLine(got)); back != got {
+				t.Errorf("omittedLine(%+v) does not parse back: %+v", got, back)
+			}
+		}
+	}
+}
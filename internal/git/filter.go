@@ -0,0 +1,394 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Diff filtering. Some staged files carry nothing the Change Analyzer can
+// use — lockfiles, vendored code, generated sources — and can easily be
+// the bulk of a diff. The filter keeps their diffs out of the model input
+// altogether: they are listed in the omitted section as one "path +N -M
+// (reason)" line like files that did not fit the budget, so the model
+// still knows they changed.
+//
+// A file is filtered by, in order: a Keep glob (never filtered), an
+// Ignore glob ("ignored"), its gitattributes when Attributes is on
+// (`-diff` / `binary` → "binary", `linguist-generated` /
+// `linguist-vendored` → "generated") and, when Builtin is on, the
+// built-in rules: lockfiles ("lockfile"), files under a `vendor/` or
+// `node_modules/` directory ("vendored") and Go files whose header
+// carries the `// Code generated … DO NOT EDIT.` line ("generated").
+// Other generated-looking names (`*.pb.go`, `*.min.js`, `dist/`, …) are
+// not filtered; they only rank low in the budget. Binary files are
+// always stat-only: git has no text diff for them.
+
+// Reasons the filter adds to the budget's (see OmittedFile).
+const (
+	OmitIgnored  = "ignored"
+	OmitVendored = "vendored"
+)
+
+// DiffFilter is the ignore layer applied by BudgetStagedDiffAt (and so
+// GetStagedDiffSummaryAt) and GetCommitDiffSummary. Globs follow
+// .gitignore conventions: a pattern without a slash matches a file or
+// directory name at any depth, one with a slash matches from the
+// repository root, `**` spans directories and a trailing slash matches
+// directories only.
+type DiffFilter struct {
+	Ignore     []string
+	Keep       []string
+	Builtin    bool
+	Attributes bool
+}
+
+// Validate reports the first Ignore or Keep glob that does not compile.
+func (f DiffFilter) Validate() error {
+	for _, pat := range append(append([]string(nil), f.Ignore...), f.Keep...) {
+		if err := ValidateGlob(pat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultDiffFilter has the built-in rules and gitattributes on and no
+// globs.
+func DefaultDiffFilter() DiffFilter {
+	return DiffFilter{Builtin: true, Attributes: true}
+}
+
+var (
+	activeFilterMu sync.RWMutex
+	activeFilter   = DefaultDiffFilter()
+)
+
+// SetDiffFilter replaces the filter used by the diff summary functions.
+// Called once at startup with the resolved [diff] config; a glob that
+// does not compile is an error and leaves the active filter unchanged.
+func SetDiffFilter(f DiffFilter) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	activeFilterMu.Lock()
+	defer activeFilterMu.Unlock()
+	activeFilter = f
+	return nil
+}
+
+// ActiveDiffFilter returns the filter set by SetDiffFilter.
+func ActiveDiffFilter() DiffFilter {
+	activeFilterMu.RLock()
+	defer activeFilterMu.RUnlock()
+	return activeFilter
+}
+
+// fileAttrs is what the filter knows about a file beyond its path and
+// numstat: the gitattributes it reads and, for Go files, whether the
+// header marks the file as generated.
+type fileAttrs struct {
+	noDiff          bool
+	generated       bool
+	generatedHeader bool
+}
+
+// reason returns why f keeps p's diff out of the model input, or "" to
+// let the budget decide.
+func (f DiffFilter) reason(p string, ns FileNumstat, attrs fileAttrs) string {
+	if matchAnyGlob(f.Keep, p) {
+		return ""
+	}
+	if ns.Adds < 0 || ns.Dels < 0 {
+		return OmitBinary
+	}
+	if matchAnyGlob(f.Ignore, p) {
+		return OmitIgnored
+	}
+	if f.Attributes {
+		switch {
+		case attrs.noDiff:
+			return OmitBinary
+		case attrs.generated:
+			return OmitGenerated
+		}
+	}
+	if f.Builtin {
+		switch {
+		case isLockfile(p):
+			return OmitLockfile
+		case isVendored(p):
+			return OmitVendored
+		case attrs.generatedHeader:
+			return OmitGenerated
+		}
+	}
+	return ""
+}
+
+// filterFiles maps every file of numstat the filter keeps out to its
+// reason. attrSource is passed to readAttrs and names where Go headers
+// are read from: "--cached" for the index, otherwise a commit.
+func (f DiffFilter) filterFiles(workspace, attrSource string, numstat map[string]FileNumstat) map[string]string {
+	paths := make([]string, 0, len(numstat))
+	for p := range numstat {
+		paths = append(paths, p)
+	}
+	var attrs map[string]fileAttrs
+	if f.Attributes {
+		attrs = readAttrs(workspace, attrSource, paths)
+	}
+	var generated map[string]bool
+	if f.Builtin {
+		rev := ""
+		if attrSource != "--cached" {
+			rev = attrSource
+		}
+		generated = generatedGoFiles(workspace, rev, paths)
+	}
+	out := map[string]string{}
+	for p, ns := range numstat {
+		a := attrs[p]
+		a.generatedHeader = generated[p]
+		if r := f.reason(p, ns, a); r != "" {
+			out[p] = r
+		}
+	}
+	return out
+}
+
+// generatedHeaderRe is the line Go tooling writes atop generated files
+// (see https://go.dev/s/generatedcode).
+var generatedHeaderRe = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedGoFiles reports which .go files among paths carry the
+// generated-code line before their package clause, reading them from
+// rev (the index when empty) in a single `git cat-file --batch`. Errors
+// leave every file unmarked.
+func generatedGoFiles(workspace, rev string, paths []string) map[string]bool {
+	var goPaths []string
+	var stdin strings.Builder
+	for _, p := range paths {
+		if !strings.HasSuffix(p, ".go") || strings.Contains(p, "\n") {
+			continue
+		}
+		goPaths = append(goPaths, p)
+		stdin.WriteString(rev + ":" + p + "\n")
+	}
+	if len(goPaths) == 0 {
+		return nil
+	}
+	out, err := runGitIndex(workspace, stdin.String(), "cat-file", "--batch")
+	if err != nil {
+		return nil
+	}
+	generated := map[string]bool{}
+	for _, p := range goPaths {
+		// Each object is "<oid> <type> <size>\n<content>\n", or
+		// "<name> missing\n" for a path rev does not have.
+		header, rest, ok := strings.Cut(out, "\n")
+		if !ok {
+			break
+		}
+		out = rest
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size+1 > len(out) {
+			break
+		}
+		generated[p] = hasGeneratedHeader(out[:size])
+		out = out[size+1:]
+	}
+	return generated
+}
+
+// hasGeneratedHeader reports whether src has the generated-code line
+// before its package clause.
+func hasGeneratedHeader(src string) bool {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+		if generatedHeaderRe.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// readAttrs runs `git check-attr` for paths. attrSource is "--cached"
+// for staged changes (the index's .gitattributes) or a commit to read
+// them from; when git cannot resolve it, the working tree's attributes
+// are used. Errors leave every file without attributes.
+func readAttrs(workspace, attrSource string, paths []string) map[string]fileAttrs {
+	if len(paths) == 0 {
+		return nil
+	}
+	args := []string{"check-attr", "-z", "--stdin"}
+	switch {
+	case attrSource == "--cached":
+		args = append(args, "--cached")
+	case attrSource != "":
+		args = append(args, "--source="+attrSource)
+	}
+	args = append(args, "diff", "binary", "linguist-generated", "linguist-vendored")
+	stdin := strings.Join(paths, "\x00") + "\x00"
+	out, err := runGitIndex(workspace, stdin, args...)
+	if err != nil && attrSource != "" && attrSource != "--cached" {
+		out, err = runGitIndex(workspace, stdin, "check-attr", "-z", "--stdin",
+			"diff", "binary", "linguist-generated", "linguist-vendored")
+	}
+	if err != nil {
+		return nil
+	}
+	attrs := map[string]fileAttrs{}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		p, name, value := fields[i], fields[i+1], fields[i+2]
+		a := attrs[p]
+		switch name {
+		case "diff":
+			a.noDiff = a.noDiff || value == "unset"
+		case "binary":
+			a.noDiff = a.noDiff || value == "set"
+		case "linguist-generated", "linguist-vendored":
+			a.generated = a.generated || value == "set" || value == "true"
+		}
+		attrs[p] = a
+	}
+	return attrs
+}
+
+var (
+	globCacheMu sync.Mutex
+	globCache   = map[string]*regexp.Regexp{}
+)
+
+func matchAnyGlob(patterns []string, p string) bool {
+	for _, pat := range patterns {
+		if MatchGlob(pat, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether the slash-separated path p matches the
+// .gitignore-style pattern (see DiffFilter for the syntax). A pattern
+// ValidateGlob rejects matches nothing.
+func MatchGlob(pattern, p string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+	globCacheMu.Lock()
+	re, ok := globCache[pattern]
+	if !ok {
+		re, _ = compileGlob(pattern)
+		globCache[pattern] = re
+	}
+	globCacheMu.Unlock()
+	return re != nil && re.MatchString(path.Clean(p))
+}
+
+// ValidateGlob reports whether pattern compiles: an unterminated `[`
+// class is an error.
+func ValidateGlob(pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil
+	}
+	_, err := compileGlob(pattern)
+	return err
+}
+
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	glob := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			class, n, err := globClass(glob[i:])
+			if err != nil {
+				return nil, fmt.Errorf("glob %q: %w", pattern, err)
+			}
+			b.WriteString(class)
+			i += n - 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("glob %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// globClass translates the bracket expression at the start of s
+// ("[abc]", "[a-z]", "[!0-9]") into a regexp class that never matches
+// "/", returning it and the number of bytes of s it consumed. A `]`
+// right after the opening bracket (or its `!`/`^`) is a literal.
+func globClass(s string) (string, int, error) {
+	i := 1
+	negate := i < len(s) && (s[i] == '!' || s[i] == '^')
+	if negate {
+		i++
+	}
+	start := i
+	if i < len(s) && s[i] == ']' {
+		i++
+	}
+	end := strings.IndexByte(s[i:], ']')
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated [ class")
+	}
+	end += i
+
+	var b strings.Builder
+	b.WriteString("[")
+	if negate {
+		b.WriteString("^/")
+	}
+	for _, r := range s[start:end] {
+		switch r {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString("]")
+	return b.String(), end + 1, nil
+}
@@ -0,0 +1,128 @@
+package git
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.snap", "ui/__snapshots__/view.snap", true},
+		{"docs/api/**", "docs/api/v1/index.md", true},
+		{"docs/api/**", "internal/docs/api/x.md", false},
+		{"dist/", "web/dist/app.js", true},
+		{"dist/", "dist", false},
+		{"file[0-9].go", "pkg/file7.go", true},
+		{"file[0-9].go", "pkg/fileX.go", false},
+		{"file[!0-9].go", "pkg/fileX.go", true},
+		{"file[!0-9].go", "pkg/file7.go", false},
+		{"a[!x]b", "a/b", false},
+		{"[]]x", "]x", true},
+		{"v[.]go", "v.go", true},
+		{"v[.]go", "vXgo", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.path); got != tt.want {
+				t.Fatalf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	for _, pat := range []string{"", "*.go", "src/[a-z]*.ts", "[!.]*"} {
+		if err := ValidateGlob(pat); err != nil {
+			t.Errorf("ValidateGlob(%q) = %v, want nil", pat, err)
+		}
+	}
+	for _, pat := range []string{"file[0-9.go", "[", "a[!"} {
+		if err := ValidateGlob(pat); err == nil {
+			t.Errorf("ValidateGlob(%q) = nil, want an error", pat)
+		}
+	}
+	if err := SetDiffFilter(DiffFilter{Ignore: []string{"x["}}); err == nil {
+		t.Error("SetDiffFilter accepted an unterminated class")
+	}
+}
+
+func TestDiffFilterReason(t *testing.T) {
+	f := DiffFilter{Builtin: true, Attributes: true, Keep: []string{"keep.pb.go"}}
+	text := FileNumstat{Adds: 3, Dels: 1}
+	tests := []struct {
+		name  string
+		path  string
+		ns    FileNumstat
+		attrs fileAttrs
+		want  string
+	}{
+		{"lockfile", "web/package-lock.json", text, fileAttrs{}, OmitLockfile},
+		{"vendored", "vendor/github.com/x/y.go", text, fileAttrs{}, OmitVendored},
+		{"nested node_modules", "web/node_modules/a/index.js", text, fileAttrs{}, OmitVendored},
+		{"generated header", "api/types.go", text, fileAttrs{generatedHeader: true}, OmitGenerated},
+		{"stringer name only ranks", "kind_string.go", text, fileAttrs{}, ""},
+		{"protobuf name only ranks", "api/v1/api.pb.go", text, fileAttrs{}, ""},
+		{"source map only ranks", "web/app.js.map", text, fileAttrs{}, ""},
+		{"snapshot only ranks", "ui/view.snap", text, fileAttrs{}, ""},
+		{"build dir only ranks", "build/out.js", text, fileAttrs{}, ""},
+		{"dist dir only ranks", "dist/app.js", text, fileAttrs{}, ""},
+		{"third_party only ranks", "third_party/lib/a.c", text, fileAttrs{}, ""},
+		{"keep wins", "keep.pb.go", text, fileAttrs{generatedHeader: true}, ""},
+		{"binary numstat", "logo.png", FileNumstat{Adds: -1, Dels: -1}, fileAttrs{}, OmitBinary},
+		{"linguist-generated", "gen/x.ts", text, fileAttrs{generated: true}, OmitGenerated},
+		{"source", "internal/git/budget.go", text, fileAttrs{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.reason(tt.path, tt.ns, tt.attrs); got != tt.want {
+				t.Fatalf("reason(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	off := DiffFilter{}
+	if got := off.reason("go.sum", text, fileAttrs{generatedHeader: true}); got != "" {
+		t.Errorf("builtin rules off: reason = %q, want none", got)
+	}
+}
+
+func TestRankDiffFile(t *testing.T) {
+	tests := []struct {
+		path string
+		rank int
+	}{
+		{"internal/git/budget.go", rankSource},
+		{"internal/git/budget_test.go", rankTest},
+		{"README.md", rankDocs},
+		{"kind_string.go", rankGenerated},
+		{"dist/app.js", rankGenerated},
+		{"vendor/x/y.go", rankGenerated},
+		{"go.sum", rankLockfile},
+	}
+	for _, tt := range tests {
+		if got, _ := rankDiffFile(tt.path, FileNumstat{Adds: 1}); got != tt.rank {
+			t.Errorf("rankDiffFile(%q) = %d, want %d", tt.path, got, tt.rank)
+		}
+	}
+}
+
+func TestHasGeneratedHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"stringer", "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage x\n", true},
+		{"after a build tag", "//go:build linux\n\n// Code generated by mkall.go. DO NOT EDIT.\r\npackage x\n", true},
+		{"after the package clause", "package x\n\n// Code generated by hand. DO NOT EDIT.\n", false},
+		{"missing the period", "// Code generated by x. DO NOT EDIT\npackage x\n", false},
+		{"plain source", "// Package x does things.\npackage x\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasGeneratedHeader(tt.src); got != tt.want {
+				t.Fatalf("hasGeneratedHeader = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -75,75 +76,46 @@ func GetGitDiffStat() (string, error) {
 }
 
 // GetStagedDiffSummary builds a single string with the staged diff of every
-// changed file, capped at maxDiffChars total characters. Used as input for
-// the AI change analyzer. Operates on the current working directory.
+// changed file, fitted into maxDiffChars total characters by
+// BudgetStagedDiffAt. Used as input for the AI change analyzer. Operates on
+// the current working directory.
 func GetStagedDiffSummary(maxDiffChars int) (string, error) {
 	return GetStagedDiffSummaryAt("", maxDiffChars)
 }
 
 // GetStagedDiffSummaryAt is the path-aware variant: when workspace is
-// non-empty, the underlying git invocations are routed through `-C`
-// so the diff is read from that repo rather than the caller's cwd.
-// An empty workspace falls back to cwd, matching GetStagedDiffSummary.
-// Used by `ai regenerate --refresh-diff` so refreshing the snapshot
-// works from any directory while the commit's stored Workspace is
-// the source of truth for which repo to inspect.
+// non-empty, the diff is read from that repo rather than the caller's
+// cwd. An empty workspace falls back to cwd, matching
+// GetStagedDiffSummary. Used by `ai regenerate --refresh-diff` so
+// refreshing the snapshot works from any directory while the commit's
+// stored Workspace is the source of truth for which repo to inspect.
+// Files that do not fit are listed by name and numstat after the diff
+// blocks; use BudgetStagedDiffAt to get them as data.
 func GetStagedDiffSummaryAt(workspace string, maxDiffChars int) (string, error) {
-	gitArgs := func(rest ...string) []string {
-		if workspace == "" {
-			return rest
-		}
-		return append([]string{"-C", workspace}, rest...)
-	}
-
-	cmdFiles := exec.Command("git", gitArgs("diff", "--cached", "--name-only")...)
-	stagedFilesBytes, err := cmdFiles.Output()
+	b, err := BudgetStagedDiffAt(workspace, maxDiffChars)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("git command failed: %s", string(exitErr.Stderr))
-		}
-		return "", fmt.Errorf("failed to get staged files: %w", err)
-	}
-
-	if len(stagedFilesBytes) == 0 {
-		return "", nil
-	}
-
-	stagedFiles := strings.Split(strings.TrimSpace(string(stagedFilesBytes)), "\n")
-
-	var resultBuilder strings.Builder
-	currentChars := 0
-
-	for _, file := range stagedFiles {
-		if file == "" {
-			continue
-		}
-
-		cmdDiff := exec.Command("git", gitArgs("diff", "--cached", "--unified=0", "--", file)...)
-		diffBytes, err := cmdDiff.Output()
-		if err != nil {
-			return "", fmt.Errorf("failed to get diff for file %s: %w", file, err)
-		}
-
-		block := fmt.Sprintf("=== %s ===\n%s\n", file, string(diffBytes))
-		blockChars := len(block)
-
-		if currentChars+blockChars > maxDiffChars {
-			break
-		}
-
-		resultBuilder.WriteString(block)
-		currentChars += blockChars
+		return "", err
 	}
-
-	return resultBuilder.String(), nil
+	return b.Diff, nil
 }
 
 // GetGitDiffNameStatus returns the staged file → status code map (A, M, D,
-// R…). An empty repo or no staged changes returns an empty map (not an
-// error).
+// T…). Renames are reported as a delete plus an add so every key is a
+// real path. An empty repo or no staged changes returns an empty map
+// (not an error).
 func GetGitDiffNameStatus() (map[string]string, error) {
-	cmd := exec.Command("git", "diff", "--staged", "--name-status")
+	return GetGitDiffNameStatusAt("")
+}
+
+// GetGitDiffNameStatusAt is GetGitDiffNameStatus for the repository at
+// workspace (cwd when empty).
+func GetGitDiffNameStatusAt(workspace string) (map[string]string, error) {
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args, "diff", "--staged", "--name-status", "--no-renames")
+	cmd := exec.Command("git", args...)
 	outputBytes, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) == 0 {
@@ -179,9 +151,10 @@ type FileNumstat struct {
 
 // GetStagedNumstat parses `git diff --staged --numstat` into a map keyed
 // by file path. Used by the Pipeline tab to render `+N -M` next to each
-// changed file and aggregate totals at the bottom.
+// changed file and aggregate totals at the bottom. Renames are split
+// like in GetGitDiffNameStatus so both maps share their keys.
 func GetStagedNumstat() (map[string]FileNumstat, error) {
-	cmd := exec.Command("git", "diff", "--staged", "--numstat")
+	cmd := exec.Command("git", "diff", "--staged", "--numstat", "--no-renames")
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get numstat: %w", err)
@@ -224,53 +197,40 @@ func GetStagedFileDiff(filePath string) (string, error) {
 
 // GetCommitDiffSummary returns the diff of a specific commit, structured per
 // file, with the same format as GetStagedDiffSummary so it can be fed to the
-// same AI prompt. Falls back to diff-tree for the initial commit.
+// same AI prompt, filtered by the same DiffFilter (gitattributes read from
+// the commit itself) and fitted into maxDiffChars by the same budget. Falls
+// back to diff-tree for the initial commit.
 func GetCommitDiffSummary(hash string, maxDiffChars int) (string, error) {
-	cmdFiles := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", hash)
-	filesOutput, err := cmdFiles.Output()
+	numstat, err := numstatAt("", "diff-tree", "--no-commit-id", "--numstat", "--no-renames",
+		"--root", "-r", hash)
 	if err != nil {
 		return "", fmt.Errorf("failed to get commit file list: %w", err)
 	}
-
-	if len(filesOutput) == 0 {
+	if len(numstat) == 0 {
 		return "", nil
 	}
 
-	files := strings.Split(strings.TrimSpace(string(filesOutput)), "\n")
-	var resultBuilder strings.Builder
-	currentChars := 0
-
-	for _, file := range files {
-		if file == "" {
+	filtered := ActiveDiffFilter().filterFiles("", hash, numstat)
+	files := make([]budgetFile, 0, len(numstat))
+	for file, ns := range numstat {
+		if r := filtered[file]; r != "" {
+			files = append(files, budgetFile{path: file, numstat: ns, filtered: r})
 			continue
 		}
-
-		cmdDiff := exec.Command("git", "diff", "--unified=0", hash+"^", hash, "--", file)
-		diffBytes, err := cmdDiff.Output()
+		diff, err := runGitIndex("", "", "diff", "--unified=0", "--no-color", "--submodule=diff",
+			hash+"^", hash, "--", file)
 		if err != nil {
-			cmdDiff = exec.Command(
-				"git", "diff-tree",
-				"-p", "--unified=0", "--no-commit-id", "-r", hash,
+			diff, err = runGitIndex("", "",
+				"diff-tree", "-p", "--unified=0", "--no-color", "--submodule=diff", "--no-commit-id", "--root", "-r", hash,
 				"--", file,
 			)
-			diffBytes, err = cmdDiff.Output()
 			if err != nil {
 				continue
 			}
 		}
-
-		block := fmt.Sprintf("=== %s ===\n%s\n", file, string(diffBytes))
-		blockLen := len(block)
-
-		if maxDiffChars > 0 && currentChars+blockLen > maxDiffChars {
-			break
-		}
-
-		resultBuilder.WriteString(block)
-		currentChars += blockLen
+		files = append(files, budgetFile{path: file, numstat: ns, diff: diff})
 	}
-
-	return resultBuilder.String(), nil
+	return packDiffBudget(files, maxDiffChars).Diff, nil
 }
 
 // ResolveCommitHash expands a partial hash (or any rev-spec git accepts) to a
@@ -442,6 +402,48 @@ func GetCommitsBetween(workspace, target, source string) ([]CommitRange, error)
 	return out, nil
 }
 
+// RecentCommitMessages returns the subject and body of the last n
+// non-merge commits reachable from HEAD in workspace, newest first. An
+// empty repository (no HEAD yet) yields no messages and no error. Same
+// NUL / US framing as GetCommitsBetween.
+func RecentCommitMessages(workspace string, n int) ([]CommitMessage, error) {
+	if err := VerifyRev(workspace, "HEAD"); err != nil {
+		return nil, nil
+	}
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args,
+		"log",
+		"--no-merges",
+		"-n", strconv.Itoa(n),
+		"--pretty=format:%s%x00%b%x1f",
+	)
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log: %s", strings.TrimSpace(stderr.String()))
+	}
+	raw := strings.TrimRight(stdout.String(), "\x1f\n")
+	if raw == "" {
+		return nil, nil
+	}
+	records := strings.Split(raw, "\x1f")
+	out := make([]CommitMessage, 0, len(records))
+	for _, rec := range records {
+		rec = strings.TrimLeft(rec, "\n")
+		if rec == "" {
+			continue
+		}
+		subject, body, _ := strings.Cut(rec, "\x00")
+		out = append(out, CommitMessage{Subject: subject, Body: strings.TrimSpace(body)})
+	}
+	return out, nil
+}
+
 // GetLastGitTag returns the most recent tag using natural-version sort order.
 // Empty string + nil error means the repo has no tags yet.
 func GetLastGitTag() (string, error) {
@@ -493,6 +495,187 @@ func StageFile(path string) error {
 	return nil
 }
 
+// Signing modes accepted by CommitOptions.Sign.
+const (
+	SignNone = ""
+	SignGPG  = "gpg"
+	SignSSH  = "ssh"
+)
+
+// CommitOptions are the `git commit` switches CommitCraft exposes. Sign
+// selects the signature format (SignGPG / SignSSH) and SigningKey, when
+// set, overrides user.signingkey for this commit only. Signing is
+// non-interactive: the key must be unlocked in gpg-agent / ssh-agent.
+type CommitOptions struct {
+	SignOff    bool
+	Sign       string
+	SigningKey string
+	NoVerify   bool
+}
+
+// args renders the options as the git argv that follows the global
+// `-C <workspace>` switch.
+func (o CommitOptions) args() ([]string, error) {
+	var args []string
+	switch o.Sign {
+	case SignNone:
+	case SignGPG:
+		args = append(args, "-c", "gpg.format=openpgp")
+	case SignSSH:
+		args = append(args, "-c", "gpg.format=ssh")
+	default:
+		return nil, fmt.Errorf("unknown signing mode %q (want %q or %q)", o.Sign, SignGPG, SignSSH)
+	}
+	// --cleanup=whitespace keeps markdown headings in the body: the
+	// default "strip" mode would drop every line starting with '#'.
+	args = append(args, "commit", "--file=-", "--cleanup=whitespace")
+	if o.SignOff {
+		args = append(args, "--signoff")
+	}
+	switch {
+	case o.Sign != SignNone && o.SigningKey != "":
+		args = append(args, "--gpg-sign="+o.SigningKey)
+	case o.Sign != SignNone:
+		args = append(args, "--gpg-sign")
+	}
+	if o.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	return args, nil
+}
+
+// CommitAt runs `git commit` with message in workspace (cwd when empty)
+// and returns the full hash of the new commit. The message goes through
+// stdin so no temp file is needed. On failure the error carries git's
+// combined output, which includes hook and signing diagnostics.
+func CommitAt(workspace, message string, opts CommitOptions) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("empty commit message")
+	}
+	commitArgs, err := opts.args()
+	if err != nil {
+		return "", err
+	}
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	cmd := exec.Command("git", append(args, commitArgs...)...)
+	cmd.Stdin = strings.NewReader(message)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return "", fmt.Errorf("git commit: %w\n%s", err, msg)
+		}
+		return "", fmt.Errorf("git commit: %w", err)
+	}
+	return ResolveCommitHashAt(workspace, "HEAD")
+}
+
+// HooksDirAt returns the absolute directory git runs hooks from in
+// workspace (cwd when empty). Honors core.hooksPath and resolves to the
+// common git dir for linked worktrees, which is where `git commit`
+// actually looks.
+func HooksDirAt(workspace string) (string, error) {
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git rev-parse --git-path hooks: %s",
+				strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git rev-parse --git-path hooks: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommentCleanupAt reports how `git commit` in workspace (cwd when
+// empty) treats the comment lines of a message opened in the editor:
+// the comment character (core.commentChar, "#" by default and for
+// "auto") and whether commit.cleanup drops the lines starting with it,
+// as "strip" and the default mode do.
+func CommentCleanupAt(workspace string) (commentChar string, strips bool) {
+	get := func(key string) string {
+		args := []string{}
+		if workspace != "" {
+			args = append(args, "-C", workspace)
+		}
+		out, err := exec.Command("git", append(args, "config", "--get", key)...).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commentChar = get("core.commentChar")
+	if commentChar == "" || commentChar == "auto" {
+		commentChar = "#"
+	}
+	switch strings.ToLower(get("commit.cleanup")) {
+	case "", "default", "strip":
+		return commentChar, true
+	default:
+		return commentChar, false
+	}
+}
+
+// StagedFilesAt lists the staged paths in workspace (cwd when empty),
+// relative to the repository root. With splitRenames a rename is listed
+// as its delete and add sides (the keys GetGitDiffNameStatus and
+// StagedNumstatAt use); otherwise only the new name is.
+func StagedFilesAt(workspace string, splitRenames bool) ([]string, error) {
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args, "diff", "--cached", "--name-only")
+	if splitRenames {
+		args = append(args, "--no-renames")
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if f := strings.TrimSpace(line); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// IdentityAt returns the committer identity git would record in
+// workspace (cwd when empty) as `Name <email>`, honoring user.name /
+// user.email and the GIT_COMMITTER_* environment. Used for
+// Signed-off-by trailers.
+func IdentityAt(workspace string) (string, error) {
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args, "var", "GIT_COMMITTER_IDENT")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git var GIT_COMMITTER_IDENT: %s",
+				strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git var GIT_COMMITTER_IDENT: %w", err)
+	}
+	// "Name <email> 1700000000 +0100": drop the timestamp and zone.
+	ident := strings.TrimSpace(string(out))
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		ident = ident[:i+1]
+	}
+	return ident, nil
+}
+
 // isMergeCommit reports whether hash refers to a merge commit (a commit with
 // two or more parents). Used by RewordCommit to pick a topology-preserving
 // rebase strategy when rewording historical merges.
@@ -0,0 +1,249 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hunk-level staging for the Pipeline tab: a file's staged and unstaged
+// diffs are split into hunks, and a hunk (or a subset of its changed
+// lines) is turned back into a one-hunk patch for `git apply --cached`.
+// Staging applies a slice of the index→worktree diff; unstaging applies
+// a slice of the HEAD→index diff in reverse. The working tree is never
+// touched.
+
+// Hunk is one `@@` block of a unified diff. Lines keep their ' ', '+',
+// '-' or '\' prefix.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Section is the function context git prints after the second @@.
+	Section string
+	Lines   []string
+}
+
+// Changed reports whether line i adds or removes content, i.e. whether
+// it can be selected on its own.
+func (h Hunk) Changed(i int) bool {
+	if i < 0 || i >= len(h.Lines) || h.Lines[i] == "" {
+		return false
+	}
+	return h.Lines[i][0] == '+' || h.Lines[i][0] == '-'
+}
+
+// FileDiff is the diff of a single path split into its header lines
+// (`diff --git`, mode, index, ---/+++) and hunks. Binary diffs and
+// mode-only changes have no hunks.
+type FileDiff struct {
+	Path   string
+	Header []string
+	Hunks  []Hunk
+	Binary bool
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseFileDiff splits the unified diff of one path into a FileDiff.
+// Anything after a second `diff --git` line is ignored.
+func ParseFileDiff(path, diff string) FileDiff {
+	fd := FileDiff{Path: path}
+	var cur *Hunk
+	seenDiff := false
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			if seenDiff {
+				break
+			}
+			seenDiff = true
+		}
+		if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+			fd.Hunks = append(fd.Hunks, Hunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Section:  m[5],
+			})
+			cur = &fd.Hunks[len(fd.Hunks)-1]
+			continue
+		}
+		if cur == nil {
+			if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+				fd.Binary = true
+			}
+			if line != "" {
+				fd.Header = append(fd.Header, line)
+			}
+			continue
+		}
+		cur.Lines = append(cur.Lines, line)
+	}
+	return fd
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// UnstagedFileDiffAt returns the index→worktree diff of path (relative
+// to the repository root).
+func UnstagedFileDiffAt(workspace, path string) (FileDiff, error) {
+	out, err := runGitIndex(workspace, "",
+		"diff", "--no-color", "--no-ext-diff", "--no-renames", "--", path)
+	if err != nil {
+		return FileDiff{}, err
+	}
+	return ParseFileDiff(path, out), nil
+}
+
+// StagedFileDiffAt returns the HEAD→index diff of path (relative to the
+// repository root).
+func StagedFileDiffAt(workspace, path string) (FileDiff, error) {
+	out, err := runGitIndex(workspace, "",
+		"diff", "--cached", "--no-color", "--no-ext-diff", "--no-renames", "--", path)
+	if err != nil {
+		return FileDiff{}, err
+	}
+	return ParseFileDiff(path, out), nil
+}
+
+// HunkPatch renders hunk i of fd as a standalone patch. lines selects
+// the changed lines to keep by index into Hunk.Lines; nil keeps the
+// whole hunk. reverse says the patch will be applied with --reverse
+// (unstaging), which flips what an unselected line turns into: when
+// staging, an unselected '-' stays as context and an unselected '+' is
+// dropped; when unstaging it is the other way round.
+func (fd FileDiff) HunkPatch(i int, lines map[int]bool, reverse bool) (string, error) {
+	if i < 0 || i >= len(fd.Hunks) {
+		return "", fmt.Errorf("%s: no hunk %d", fd.Path, i+1)
+	}
+	h := fd.Hunks[i]
+	drop := byte('+')
+	if reverse {
+		drop = '-'
+	}
+
+	var body []string
+	oldN, newN, changes := 0, 0, 0
+	partial := false
+	lastKept := false
+	// noEOL is the body index of a context line that ends the old file
+	// without a newline (an unselected '-' line followed by `\ No
+	// newline`), or -1. Such a line cannot stay context once anything
+	// is kept after it, so it is then rewritten as a removal plus an
+	// addition that restores the newline; the counts do not change.
+	noEOL := -1
+	for j, line := range h.Lines {
+		if line == "" {
+			// A blank context line some tools strip the space from.
+			line = " "
+		}
+		switch line[0] {
+		case '\\':
+			if lastKept {
+				body = append(body, line)
+			}
+			continue
+		case '+', '-':
+			if lines != nil && !lines[j] {
+				partial = true
+				if line[0] == drop {
+					lastKept = false
+					continue
+				}
+				line = " " + line[1:]
+				if j+1 < len(h.Lines) && strings.HasPrefix(h.Lines[j+1], "\\") {
+					noEOL = len(body)
+				}
+			} else {
+				changes++
+			}
+		}
+		if noEOL >= 0 && noEOL < len(body) {
+			ctx := body[noEOL][1:]
+			body = append(body[:noEOL], append([]string{"-" + ctx, body[noEOL+1], "+" + ctx}, body[noEOL+2:]...)...)
+			noEOL = -1
+		}
+		switch line[0] {
+		case ' ':
+			oldN++
+			newN++
+		case '-':
+			oldN++
+		case '+':
+			newN++
+		}
+		body = append(body, line)
+		lastKept = true
+	}
+	if changes == 0 {
+		return "", fmt.Errorf("%s: nothing selected in hunk %d", fd.Path, i+1)
+	}
+
+	oldStart, newStart := h.OldStart, h.NewStart
+	if oldN > 0 && oldStart == 0 {
+		oldStart = 1
+	}
+	if newN > 0 && newStart == 0 {
+		newStart = 1
+	}
+
+	header := fd.Header
+	if partial {
+		// A partial hunk of a created or deleted file leaves the file
+		// in place on both sides, so the patch must describe a plain
+		// modification instead.
+		header = []string{
+			fmt.Sprintf("diff --git a/%s b/%s", fd.Path, fd.Path),
+			"--- a/" + fd.Path,
+			"+++ b/" + fd.Path,
+		}
+	}
+
+	var b strings.Builder
+	for _, l := range header {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@", oldStart, oldN, newStart, newN)
+	if h.Section != "" {
+		b.WriteString(" " + h.Section)
+	}
+	b.WriteByte('\n')
+	for _, l := range body {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// StageHunkAt stages hunk i of an unstaged FileDiff (all of it when
+// lines is nil, otherwise only the selected changed lines).
+func StageHunkAt(workspace string, fd FileDiff, i int, lines map[int]bool) error {
+	patch, err := fd.HunkPatch(i, lines, false)
+	if err != nil {
+		return err
+	}
+	_, err = runGitIndex(workspace, patch, "apply", "--cached", "--recount", "-")
+	return err
+}
+
+// UnstageHunkAt moves hunk i of a staged FileDiff back out of the index
+// (all of it when lines is nil, otherwise only the selected lines).
+func UnstageHunkAt(workspace string, fd FileDiff, i int, lines map[int]bool) error {
+	patch, err := fd.HunkPatch(i, lines, true)
+	if err != nil {
+		return err
+	}
+	_, err = runGitIndex(workspace, patch, "apply", "--cached", "--reverse", "--recount", "-")
+	return err
+}
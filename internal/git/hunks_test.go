@@ -0,0 +1,244 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHunkPatchApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	cases := []struct {
+		name string
+		// head is committed, index staged on top and worktree left
+		// unstaged; an empty index means nothing extra is staged.
+		head, index, worktree string
+		unstage               bool
+		hunk                  int
+		// pick selects the changed lines (by their text) to keep; nil
+		// keeps the whole hunk.
+		pick      []string
+		wantIndex string
+		wantErr   string
+	}{
+		{
+			name:      "whole hunk",
+			head:      "a\nb\nc\n",
+			worktree:  "a\nB\nc\n",
+			wantIndex: "a\nB\nc\n",
+		},
+		{
+			name:      "selected lines only",
+			head:      "a\nb\nc\nd\n",
+			worktree:  "a\nx\nc\ny\nd\n",
+			pick:      []string{"+x"},
+			wantIndex: "a\nb\nx\nc\nd\n",
+		},
+		{
+			name:      "selected removal keeps unselected additions out",
+			head:      "a\nb\nc\nd\n",
+			worktree:  "a\nx\nc\ny\nd\n",
+			pick:      []string{"-b", "+y"},
+			wantIndex: "a\nc\ny\nd\n",
+		},
+		{
+			name:      "whole hunk in reverse",
+			head:      "a\nb\nc\n",
+			index:     "a\nB\nc\nD\n",
+			unstage:   true,
+			wantIndex: "a\nb\nc\n",
+		},
+		{
+			name:      "selected lines in reverse",
+			head:      "a\nb\nc\n",
+			index:     "a\nB\nc\nD\n",
+			unstage:   true,
+			pick:      []string{"+D"},
+			wantIndex: "a\nB\nc\n",
+		},
+		{
+			name:      "reverse removal",
+			head:      "a\nb\nc\n",
+			index:     "a\nB\nc\nD\n",
+			unstage:   true,
+			pick:      []string{"-b", "+B"},
+			wantIndex: "a\nb\nc\nD\n",
+		},
+		{
+			name:      "no trailing newline, whole hunk",
+			head:      "a\nb",
+			worktree:  "a\nb\nc",
+			wantIndex: "a\nb\nc",
+		},
+		{
+			name:      "no trailing newline, newline fix only",
+			head:      "a\nb",
+			worktree:  "a\nb\nc",
+			pick:      []string{"-b", "+b"},
+			wantIndex: "a\nb\n",
+		},
+		{
+			name:      "no trailing newline, addition only",
+			head:      "a\nb",
+			worktree:  "a\nb\nc",
+			pick:      []string{"+c"},
+			wantIndex: "a\nb\nc",
+		},
+		{
+			name:      "no trailing newline in reverse",
+			head:      "a\nb",
+			index:     "a\nb\nc",
+			unstage:   true,
+			wantIndex: "a\nb",
+		},
+		{
+			name:      "no trailing newline in reverse, addition only",
+			head:      "a\nb",
+			index:     "a\nb\nc",
+			unstage:   true,
+			pick:      []string{"+c"},
+			wantIndex: "a\nb\n",
+		},
+		{
+			name:     "hunk out of range",
+			head:     "a\nb\nc\n",
+			worktree: "a\nB\nc\n",
+			hunk:     1,
+			wantErr:  "no hunk 2",
+		},
+		{
+			name:     "negative hunk",
+			head:     "a\nb\nc\n",
+			worktree: "a\nB\nc\n",
+			hunk:     -1,
+			wantErr:  "no hunk 0",
+		},
+		{
+			name:     "nothing selected",
+			head:     "a\nb\nc\n",
+			worktree: "a\nB\nc\n",
+			pick:     []string{" a"},
+			wantErr:  "nothing selected in hunk 1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "f.txt")
+			write := func(data string) {
+				if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			gitRun(t, dir, "init", "-q", "-b", "main")
+			write(c.head)
+			gitRun(t, dir, "add", "f.txt")
+			gitRun(t, dir, "commit", "-q", "-m", "init")
+			if c.index != "" {
+				write(c.index)
+				gitRun(t, dir, "add", "f.txt")
+			}
+			worktree := c.worktree
+			if worktree == "" {
+				worktree = c.index
+			}
+			write(worktree)
+			before := showIndex(t, dir)
+
+			diffAt, apply := UnstagedFileDiffAt, StageHunkAt
+			if c.unstage {
+				diffAt, apply = StagedFileDiffAt, UnstageHunkAt
+			}
+			fd, err := diffAt(dir, "f.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines map[int]bool
+			if c.pick != nil && c.hunk >= 0 && c.hunk < len(fd.Hunks) {
+				lines = map[int]bool{}
+				for j, l := range fd.Hunks[c.hunk].Lines {
+					lines[j] = slices.Contains(c.pick, l)
+				}
+			}
+			err = apply(dir, fd, c.hunk, lines)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, c.wantErr)
+				}
+				if got := showIndex(t, dir); got != before {
+					t.Errorf("index changed on error: %q -> %q", before, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v\nhunks: %+v", err, fd.Hunks)
+			}
+			if got := showIndex(t, dir); got != c.wantIndex {
+				t.Errorf("index = %q, want %q", got, c.wantIndex)
+			}
+			if got, _ := os.ReadFile(file); string(got) != worktree {
+				t.Errorf("worktree changed to %q", got)
+			}
+		})
+	}
+}
+
+func TestParseFileDiff(t *testing.T) {
+	diff := "diff --git a/f.txt b/f.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/f.txt\n" +
+		"+++ b/f.txt\n" +
+		"@@ -1,2 +1,2 @@ func main() {\n" +
+		" a\n" +
+		"-b\n" +
+		"\\ No newline at end of file\n" +
+		"+b\n" +
+		"@@ -10 +10,0 @@\n" +
+		"-z\n" +
+		"diff --git a/other b/other\n" +
+		"@@ -1 +1 @@\n" +
+		"-x\n"
+	fd := ParseFileDiff("f.txt", diff)
+	if len(fd.Header) != 4 || fd.Binary {
+		t.Errorf("header = %q, binary = %v", fd.Header, fd.Binary)
+	}
+	want := []Hunk{
+		{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Section: "func main() {",
+			Lines: []string{" a", "-b", "\\ No newline at end of file", "+b"}},
+		{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 0, Lines: []string{"-z"}},
+	}
+	if len(fd.Hunks) != len(want) {
+		t.Fatalf("hunks = %+v, want %+v", fd.Hunks, want)
+	}
+	for i := range want {
+		got := fd.Hunks[i]
+		if got.OldStart != want[i].OldStart || got.OldLines != want[i].OldLines ||
+			got.NewStart != want[i].NewStart || got.NewLines != want[i].NewLines ||
+			got.Section != want[i].Section || !slices.Equal(got.Lines, want[i].Lines) {
+			t.Errorf("hunk %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if !fd.Hunks[0].Changed(1) || fd.Hunks[0].Changed(0) || fd.Hunks[0].Changed(2) || fd.Hunks[0].Changed(9) {
+		t.Error("Changed misreports the hunk lines")
+	}
+	bin := ParseFileDiff("img.png", "diff --git a/img.png b/img.png\nBinary files a/img.png and b/img.png differ\n")
+	if !bin.Binary || len(bin.Hunks) != 0 {
+		t.Errorf("binary diff = %+v", bin)
+	}
+}
+
+// showIndex returns the staged content of f.txt.
+func showIndex(t *testing.T, dir string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "show", ":f.txt").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Index helpers used by the split planner: they move staged changes in
+// and out of the index as patches so partially staged files keep
+// exactly what the user staged, and the working tree is never touched.
+// Every command runs from the repository root because `git apply`
+// silently skips paths outside the current directory.
+
+// runGitIndex runs git at the top level of workspace (cwd when empty)
+// with stdin, returning stdout. Errors carry git's stderr.
+func runGitIndex(workspace, stdin string, args ...string) (string, error) {
+	top, err := toplevelAt(workspace)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command("git", append([]string{"-C", top}, args...)...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+func toplevelAt(workspace string) (string, error) {
+	args := []string{}
+	if workspace != "" {
+		args = append(args, "-C", workspace)
+	}
+	args = append(args, "rev-parse", "--show-toplevel")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse --show-toplevel: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// hasHeadAt reports whether the repository already has a commit.
+func hasHeadAt(workspace string) bool {
+	_, err := runGitIndex(workspace, "", "rev-parse", "--verify", "-q", "HEAD")
+	return err == nil
+}
+
+// StagedNumstatAt returns the `+N -M` counts of the staged files of
+// workspace, keyed like StagedFilesAt with split renames. Binary files report -1 for both.
+func StagedNumstatAt(workspace string) (map[string]FileNumstat, error) {
+	return numstatAt(workspace, "diff", "--cached", "--numstat", "--no-renames")
+}
+
+// StagedPatchAt returns the staged changes of paths (every staged path
+// when paths is empty) as a binary-safe patch that ApplyCachedPatchAt
+// can replay. Paths are relative to the repository root.
+func StagedPatchAt(workspace string, paths []string) (string, error) {
+	args := []string{"diff", "--cached", "--binary", "--no-renames", "--no-color", "--full-index"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return runGitIndex(workspace, "", args...)
+}
+
+// ApplyCachedPatchAt applies patch to the index only (`git apply
+// --cached`). An empty patch is a no-op.
+func ApplyCachedPatchAt(workspace, patch string) error {
+	if strings.TrimSpace(patch) == "" {
+		return nil
+	}
+	_, err := runGitIndex(workspace, patch, "apply", "--cached", "--binary", "-")
+	return err
+}
+
+// ResetIndexAt unstages everything: the index goes back to HEAD (or to
+// empty before the first commit) and the working tree is untouched.
+func ResetIndexAt(workspace string) error {
+	if hasHeadAt(workspace) {
+		_, err := runGitIndex(workspace, "", "reset", "-q")
+		return err
+	}
+	_, err := runGitIndex(workspace, "", "read-tree", "--empty")
+	return err
+}
+
+// IsolateStagedAt narrows the index to the staged changes under keep and
+// returns the patch of everything it unstaged, so the caller can put it
+// back with ApplyCachedPatchAt once the commit is made. keep entries may
+// be absolute (e.g. the CHANGELOG path) or relative to the repository
+// root. Returns "" when nothing had to be unstaged.
+func IsolateStagedAt(workspace string, keep []string) (string, error) {
+	top, err := toplevelAt(workspace)
+	if err != nil {
+		return "", err
+	}
+	kept := map[string]bool{}
+	for _, p := range keep {
+		if filepath.IsAbs(p) {
+			rel, err := filepath.Rel(top, p)
+			if err != nil {
+				continue
+			}
+			p = rel
+		}
+		kept[filepath.ToSlash(filepath.Clean(p))] = true
+	}
+
+	staged, err := StagedFilesAt(workspace, true)
+	if err != nil {
+		return "", err
+	}
+	var others []string
+	for _, p := range staged {
+		if !kept[p] {
+			others = append(others, p)
+		}
+	}
+	if len(others) == 0 {
+		return "", nil
+	}
+	if len(others) == len(staged) {
+		return "", fmt.Errorf("none of the %d staged paths belong to this commit", len(staged))
+	}
+
+	patch, err := StagedPatchAt(workspace, others)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"rm", "--cached", "-q", "--"}
+	if hasHeadAt(workspace) {
+		args = []string{"reset", "-q", "--"}
+	}
+	if _, err := runGitIndex(workspace, "", append(args, others...)...); err != nil {
+		return "", err
+	}
+	return patch, nil
+}
+
+// CommitPathsAt is CommitAt restricted to the staged changes under
+// paths: everything else is unstaged for the commit and re-staged
+// afterwards, whether or not the commit succeeded. An empty paths
+// commits the whole index.
+func CommitPathsAt(workspace, message string, opts CommitOptions, paths []string) (string, error) {
+	if len(paths) == 0 {
+		return CommitAt(workspace, message, opts)
+	}
+	rest, err := IsolateStagedAt(workspace, paths)
+	if err != nil {
+		return "", fmt.Errorf("isolating staged paths: %w", err)
+	}
+	hash, commitErr := CommitAt(workspace, message, opts)
+	if err := ApplyCachedPatchAt(workspace, rest); err != nil {
+		if commitErr != nil {
+			return "", fmt.Errorf("%w (re-staging the other changes also failed: %v)", commitErr, err)
+		}
+		return hash, fmt.Errorf("committed %s but re-staging the other changes failed: %w", hash, err)
+	}
+	return hash, commitErr
+}
@@ -0,0 +1,50 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagCommitAt returns the commit the tag points at in workspace, or ""
+// with a nil error when the tag does not exist.
+func TagCommitAt(workspace, tag string) (string, error) {
+	out, err := runGitIndex(workspace, "", "tag", "--list", tag)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", nil
+	}
+	out, err = runGitIndex(workspace, "", "rev-parse", "--verify", "refs/tags/"+tag+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CreateTagAt creates the annotated tag at target (any rev-spec) with
+// message as its annotation. It fails when the tag already exists.
+func CreateTagAt(workspace, tag, target, message string) error {
+	if strings.TrimSpace(tag) == "" {
+		return fmt.Errorf("empty tag name")
+	}
+	_, err := runGitIndex(workspace, message, "tag", "--annotate", "--file=-", tag, target)
+	return err
+}
+
+// PushTagAt pushes the tag to remote. Pushing a tag the remote already
+// has at the same commit is a no-op. This is CommitCraft's only `git
+// push`; it runs for `ai release publish --push` and nothing else.
+func PushTagAt(workspace, remote, tag string) error {
+	_, err := runGitIndex(workspace, "", "push", remote, "refs/tags/"+tag)
+	return err
+}
+
+// RemoteURLAt returns the fetch URL of remote in workspace.
+func RemoteURLAt(workspace, remote string) (string, error) {
+	out, err := runGitIndex(workspace, "", "remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WorkspaceID identifies the checkout a directory belongs to. Worktree is
+// its top-level directory; RepoID is the absolute git common dir, the
+// same for every linked worktree of a repository and distinct for each
+// submodule (whose objects live under the superproject's
+// .git/modules/). Superproject is the top level of the enclosing
+// repository when the checkout is a submodule, empty otherwise.
+type WorkspaceID struct {
+	Worktree     string
+	RepoID       string
+	Superproject string
+}
+
+// ResolveWorkspace resolves the checkout of dir (cwd when empty) from
+// `git rev-parse`. Outside a repository it returns an error; callers
+// then fall back to the directory itself as its own identity.
+func ResolveWorkspace(dir string) (WorkspaceID, error) {
+	args := []string{}
+	if dir != "" {
+		args = append(args, "-C", dir)
+	}
+	args = append(args, "rev-parse", "--path-format=absolute",
+		"--show-toplevel", "--git-common-dir", "--show-superproject-working-tree")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return WorkspaceID{}, fmt.Errorf("git rev-parse: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 2 {
+		return WorkspaceID{}, fmt.Errorf("git rev-parse: unexpected output %q", out)
+	}
+	id := WorkspaceID{
+		Worktree: filepath.Clean(lines[0]),
+		RepoID:   filepath.Clean(lines[1]),
+	}
+	if len(lines) > 2 {
+		id.Superproject = filepath.Clean(lines[2])
+	}
+	return id, nil
+}
+
+// WorktreePaths lists the top-level directories of every worktree of the
+// repository containing dir, main worktree first.
+func WorktreePaths(dir string) ([]string, error) {
+	out, err := runGitIndex(dir, "", "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			paths = append(paths, filepath.Clean(p))
+		}
+	}
+	return paths, nil
+}
+
+// WorkspaceKeys is ResolveWorkspace reduced to the (worktree, repoID)
+// pair storage.ResolveWorkspaceIDs expects; both are empty when dir is
+// not inside a repository (or no longer exists).
+func WorkspaceKeys(dir string) (worktree, repoID string) {
+	id, err := ResolveWorkspace(dir)
+	if err != nil {
+		return "", ""
+	}
+	return id.Worktree, id.RepoID
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// gitRun runs git in dir with a throwaway identity and no user config.
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// initRepo creates a repository at dir with one commit.
+func initRepo(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "a.go"), []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "init")
+}
+
+func TestResolveWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainDir := filepath.Join(root, "main")
+	lib := filepath.Join(root, "lib")
+	linked := filepath.Join(root, "linked")
+	initRepo(t, mainDir)
+	initRepo(t, lib)
+	gitRun(t, mainDir, "worktree", "add", "-q", linked)
+	gitRun(t, mainDir, "-c", "protocol.file.allow=always", "submodule", "add", "-q", lib, "sub")
+
+	common := filepath.Join(mainDir, ".git")
+	tests := []struct {
+		name string
+		dir  string
+		want WorkspaceID
+	}{
+		{"main worktree", mainDir, WorkspaceID{Worktree: mainDir, RepoID: common}},
+		{"subdirectory", filepath.Join(mainDir, "pkg"), WorkspaceID{Worktree: mainDir, RepoID: common}},
+		{"linked worktree", linked, WorkspaceID{Worktree: linked, RepoID: common}},
+		{"submodule", filepath.Join(mainDir, "sub"), WorkspaceID{
+			Worktree:     filepath.Join(mainDir, "sub"),
+			RepoID:       filepath.Join(common, "modules", "sub"),
+			Superproject: mainDir,
+		}},
+		{"submodule subdirectory", filepath.Join(mainDir, "sub", "pkg"), WorkspaceID{
+			Worktree:     filepath.Join(mainDir, "sub"),
+			RepoID:       filepath.Join(common, "modules", "sub"),
+			Superproject: mainDir,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveWorkspace(tt.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ResolveWorkspace = %+v, want %+v", got, tt.want)
+			}
+			worktree, repoID := WorkspaceKeys(tt.dir)
+			if worktree != tt.want.Worktree || repoID != tt.want.RepoID {
+				t.Fatalf("WorkspaceKeys = (%q, %q)", worktree, repoID)
+			}
+		})
+	}
+
+	outside := filepath.Join(root, "plain")
+	if err := os.Mkdir(outside, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveWorkspace(outside); err == nil {
+		t.Error("ResolveWorkspace outside a repository: want an error")
+	}
+	if worktree, repoID := WorkspaceKeys(outside); worktree != "" || repoID != "" {
+		t.Errorf("WorkspaceKeys outside a repository = (%q, %q), want empty", worktree, repoID)
+	}
+
+	paths, err := WorktreePaths(filepath.Join(linked, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{mainDir, linked}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("WorktreePaths = %v, want %v", paths, want)
+	}
+}
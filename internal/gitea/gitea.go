@@ -0,0 +1,172 @@
+// Package gitea is a small client for the Gitea (and Forgejo) REST API
+// endpoints release publishing needs: look up tags and releases, create
+// or update a release, and upload or replace its attachments with
+// progress reporting.
+//
+// The API is GitHub-like but not identical: attachments are uploaded as
+// multipart forms to the API host itself and are deleted through their
+// release.
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"commit_craft_reborn/internal/forge"
+)
+
+// Client talks to one Gitea API root (https://gitea.example.com/api/v1)
+// with one token. There is no public default instance.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		Token:   token,
+		HTTP:    &http.Client{},
+	}
+}
+
+// Release is the subset of Gitea's release object the publisher uses.
+type Release struct {
+	ID         int64        `json:"id"`
+	TagName    string       `json:"tag_name"`
+	Name       string       `json:"name"`
+	Body       string       `json:"body"`
+	Draft      bool         `json:"draft"`
+	Prerelease bool         `json:"prerelease"`
+	HTMLURL    string       `json:"html_url"`
+	Assets     []Attachment `json:"assets"`
+}
+
+// Attachment is one file attached to a release.
+type Attachment struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// ReleaseInput is the body of the create and update calls.
+type ReleaseInput struct {
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish,omitempty"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// TagExists reports whether repo ("owner/name") has the tag.
+func (c *Client) TagExists(ctx context.Context, repo, tag string) (bool, error) {
+	err := c.api().DoJSON(ctx, http.MethodGet, c.repoURL(repo, "tags/"+url.PathEscape(tag)), nil, nil)
+	if forge.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseByTag returns the release of tag. found is false when the tag
+// has no release.
+func (c *Client) ReleaseByTag(ctx context.Context, repo, tag string) (rel Release, found bool, err error) {
+	err = c.api().DoJSON(ctx, http.MethodGet, c.repoURL(repo, "releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	if forge.IsNotFound(err) {
+		return Release{}, false, nil
+	}
+	return rel, err == nil, err
+}
+
+// CreateRelease creates a release.
+func (c *Client) CreateRelease(ctx context.Context, repo string, in ReleaseInput) (Release, error) {
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPost, c.repoURL(repo, "releases"), in, &rel)
+	return rel, err
+}
+
+// UpdateRelease replaces the tag, name, body and flags of release id.
+func (c *Client) UpdateRelease(ctx context.Context, repo string, id int64, in ReleaseInput) (Release, error) {
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPatch, c.repoURL(repo, fmt.Sprintf("releases/%d", id)), in, &rel)
+	return rel, err
+}
+
+// DeleteAttachment removes an attachment from release id, so a file of
+// the same name can be uploaded again.
+func (c *Client) DeleteAttachment(ctx context.Context, repo string, id, attachmentID int64) error {
+	return c.api().DoJSON(ctx, http.MethodDelete,
+		c.repoURL(repo, fmt.Sprintf("releases/%d/assets/%d", id, attachmentID)), nil, nil)
+}
+
+// UploadAttachment streams the file at path to release id under its
+// base name. progress, when set, is called as the file is read by the
+// transport, ending with sent == total.
+func (c *Client) UploadAttachment(
+	ctx context.Context,
+	repo string,
+	id int64,
+	path string,
+	progress forge.ProgressFunc,
+) (Attachment, error) {
+	up, err := forge.OpenUpload(path, progress)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer up.Close()
+	name := filepath.Base(path)
+
+	// Render the multipart envelope around the file up front so the
+	// request has a known length and the file streams straight from
+	// disk.
+	var envelope bytes.Buffer
+	mw := multipart.NewWriter(&envelope)
+	if _, err := mw.CreateFormFile("attachment", name); err != nil {
+		return Attachment{}, err
+	}
+	head := append([]byte(nil), envelope.Bytes()...)
+	envelope.Reset()
+	if err := mw.Close(); err != nil {
+		return Attachment{}, err
+	}
+	tail := envelope.Bytes()
+
+	body := io.MultiReader(bytes.NewReader(head), up.Body, bytes.NewReader(tail))
+	target := c.repoURL(repo, fmt.Sprintf("releases/%d/assets?name=%s", id, url.QueryEscape(name)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, body)
+	if err != nil {
+		return Attachment{}, err
+	}
+	req.ContentLength = int64(len(head)) + up.Size + int64(len(tail))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	var att Attachment
+	err = c.api().Send(req, &att)
+	return att, err
+}
+
+func (c *Client) repoURL(repo, path string) string {
+	return c.BaseURL + "/repos/" + strings.Trim(repo, "/") + "/" + path
+}
+
+// api is the client's calling convention for the forge helpers.
+func (c *Client) api() forge.API {
+	return forge.API{Forge: "Gitea", HTTP: c.HTTP, Header: c.header}
+}
+
+// header adds the token header.
+func (c *Client) header(h http.Header) {
+	h.Set("Accept", "application/json")
+	if c.Token != "" {
+		h.Set("Authorization", "token "+c.Token)
+	}
+}
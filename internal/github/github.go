@@ -0,0 +1,152 @@
+// Package github is a small client for the GitHub REST API endpoints
+// release publishing needs: look up tags and releases, create or update
+// a release, and upload or replace its assets with progress reporting.
+//
+// BaseURL points at https://api.github.com by default; GitHub
+// Enterprise (https://ghe.example.com/api/v3) and local test servers
+// work by overriding it. Asset uploads go to the upload_url the API
+// returns with each release, so they follow the same host.
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"commit_craft_reborn/internal/forge"
+)
+
+// DefaultBaseURL is the public GitHub API root.
+const DefaultBaseURL = "https://api.github.com"
+
+// Client talks to one GitHub API root with one token.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL ("" for DefaultBaseURL).
+func NewClient(baseURL, token string) *Client {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{BaseURL: baseURL, Token: token, HTTP: &http.Client{}}
+}
+
+// Release is the subset of GitHub's release object the publisher uses.
+type Release struct {
+	ID         int64   `json:"id"`
+	TagName    string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Body       string  `json:"body"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	HTMLURL    string  `json:"html_url"`
+	UploadURL  string  `json:"upload_url"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is one file attached to a release.
+type Asset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// ReleaseInput is the body of the create and update calls.
+type ReleaseInput struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+// TagExists reports whether repo ("owner/name") has the tag.
+func (c *Client) TagExists(ctx context.Context, repo, tag string) (bool, error) {
+	err := c.api().DoJSON(ctx, http.MethodGet, c.repoURL(repo, "git/ref/tags/"+url.PathEscape(tag)), nil, nil)
+	if forge.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseByTag returns the release of tag. found is false when the tag
+// has no release (drafts are not visible to this endpoint).
+func (c *Client) ReleaseByTag(ctx context.Context, repo, tag string) (rel Release, found bool, err error) {
+	err = c.api().DoJSON(ctx, http.MethodGet, c.repoURL(repo, "releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	if forge.IsNotFound(err) {
+		return Release{}, false, nil
+	}
+	return rel, err == nil, err
+}
+
+// CreateRelease creates a release.
+func (c *Client) CreateRelease(ctx context.Context, repo string, in ReleaseInput) (Release, error) {
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPost, c.repoURL(repo, "releases"), in, &rel)
+	return rel, err
+}
+
+// UpdateRelease replaces the tag, name, body and flags of release id.
+func (c *Client) UpdateRelease(ctx context.Context, repo string, id int64, in ReleaseInput) (Release, error) {
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPatch, c.repoURL(repo, fmt.Sprintf("releases/%d", id)), in, &rel)
+	return rel, err
+}
+
+// DeleteAsset removes an asset, so a file of the same name can be
+// uploaded again.
+func (c *Client) DeleteAsset(ctx context.Context, repo string, id int64) error {
+	return c.api().DoJSON(ctx, http.MethodDelete, c.repoURL(repo, fmt.Sprintf("releases/assets/%d", id)), nil, nil)
+}
+
+// UploadAsset streams the file at path to rel under its base name.
+// progress, when set, is called as the body is read by the transport,
+// ending with sent == total.
+func (c *Client) UploadAsset(ctx context.Context, rel Release, path string, progress forge.ProgressFunc) (Asset, error) {
+	target, _, _ := strings.Cut(rel.UploadURL, "{")
+	if target == "" {
+		return Asset{}, fmt.Errorf("release %d has no upload URL", rel.ID)
+	}
+	up, err := forge.OpenUpload(path, progress)
+	if err != nil {
+		return Asset{}, err
+	}
+	defer up.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		target+"?name="+url.QueryEscape(filepath.Base(path)), up.Body)
+	if err != nil {
+		return Asset{}, err
+	}
+	req.ContentLength = up.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	var asset Asset
+	err = c.api().Send(req, &asset)
+	return asset, err
+}
+
+func (c *Client) repoURL(repo, path string) string {
+	return c.BaseURL + "/repos/" + strings.Trim(repo, "/") + "/" + path
+}
+
+// api is the client's calling convention for the forge helpers.
+func (c *Client) api() forge.API {
+	return forge.API{Forge: "GitHub", HTTP: c.HTTP, Header: c.header}
+}
+
+// header adds the API version and token headers.
+func (c *Client) header(h http.Header) {
+	h.Set("Accept", "application/vnd.github+json")
+	h.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		h.Set("Authorization", "Bearer "+c.Token)
+	}
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientLookups(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get("X-GitHub-Api-Version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/o/r/git/ref/tags/v1":
+			w.Write([]byte(`{"ref":"refs/tags/v1"}`))
+		case "/repos/o/r/releases/tags/v1":
+			w.Write([]byte(`{"id":5,"tag_name":"v1","assets":[{"id":9,"name":"a.zip"}]}`))
+		case "/repos/o/r/releases/tags/boom":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream down\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL+"/", "tok")
+	ctx := context.Background()
+
+	if ok, err := c.TagExists(ctx, "o/r", "v1"); !ok || err != nil {
+		t.Errorf("TagExists(v1) = %v, %v", ok, err)
+	}
+	if ok, err := c.TagExists(ctx, "o/r", "v2"); ok || err != nil {
+		t.Errorf("TagExists(v2) = %v, %v; want false without an error", ok, err)
+	}
+	rel, found, err := c.ReleaseByTag(ctx, "o/r", "v1")
+	if err != nil || !found || rel.ID != 5 || len(rel.Assets) != 1 || rel.Assets[0].ID != 9 {
+		t.Errorf("ReleaseByTag(v1) = %+v, %v, %v", rel, found, err)
+	}
+	if _, found, err := c.ReleaseByTag(ctx, "o/r", "v2"); found || err != nil {
+		t.Errorf("ReleaseByTag(v2) = %v, %v; want not found without an error", found, err)
+	}
+	_, _, err = c.ReleaseByTag(ctx, "o/r", "boom")
+	if err == nil || !strings.Contains(err.Error(), "GitHub API returned 502: upstream down") {
+		t.Errorf("err = %v, want the raw body as the message", err)
+	}
+	if _, err := NewClient(srv.URL, "bad").CreateRelease(ctx, "o/r", ReleaseInput{}); err == nil {
+		t.Error("unauthorized create succeeded")
+	}
+}
+
+func TestUploadAssetNeedsUploadURL(t *testing.T) {
+	_, err := NewClient("", "tok").UploadAsset(context.Background(), Release{ID: 3}, "missing.zip", nil)
+	if err == nil || !strings.Contains(err.Error(), "no upload URL") {
+		t.Errorf("err = %v, want a missing upload URL error", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package gitlab is a small client for the GitLab REST API (v4)
+// endpoints release publishing needs: look up tags and releases, create
+// or update a release, upload files to the generic package registry and
+// link them from the release.
+//
+// GitLab releases have no attachments of their own. Asset files go to
+// the project's generic package registry and the release gets a link
+// to each one.
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"commit_craft_reborn/internal/forge"
+)
+
+// DefaultBaseURL is the API root of gitlab.com.
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Client talks to one GitLab API root with one token.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL ("" for DefaultBaseURL).
+func NewClient(baseURL, token string) *Client {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{BaseURL: baseURL, Token: token, HTTP: &http.Client{}}
+}
+
+// Release is the subset of GitLab's release object the publisher uses.
+type Release struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Links       struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []Link `json:"links"`
+	} `json:"assets"`
+}
+
+// Link is one asset link of a release.
+type Link struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
+
+// ReleaseInput is the body of the create and update calls. TagName and
+// Ref are dropped on update (the tag identifies the release). Ref is the
+// branch or commit GitLab creates the tag at when it does not exist.
+type ReleaseInput struct {
+	TagName     string `json:"tag_name,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TagExists reports whether project ("group/name", subgroups allowed)
+// has the tag.
+func (c *Client) TagExists(ctx context.Context, project, tag string) (bool, error) {
+	err := c.api().DoJSON(ctx, http.MethodGet, c.projectURL(project, "repository/tags/"+url.PathEscape(tag)), nil, nil)
+	if forge.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseByTag returns the release of tag. found is false when the tag
+// has no release.
+func (c *Client) ReleaseByTag(ctx context.Context, project, tag string) (rel Release, found bool, err error) {
+	err = c.api().DoJSON(ctx, http.MethodGet, c.projectURL(project, "releases/"+url.PathEscape(tag)), nil, &rel)
+	if forge.IsNotFound(err) {
+		return Release{}, false, nil
+	}
+	return rel, err == nil, err
+}
+
+// CreateRelease creates a release for in.TagName.
+func (c *Client) CreateRelease(ctx context.Context, project string, in ReleaseInput) (Release, error) {
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPost, c.projectURL(project, "releases"), in, &rel)
+	return rel, err
+}
+
+// UpdateRelease replaces the name and description of tag's release.
+func (c *Client) UpdateRelease(ctx context.Context, project, tag string, in ReleaseInput) (Release, error) {
+	in.TagName, in.Ref = "", ""
+	var rel Release
+	err := c.api().DoJSON(ctx, http.MethodPut, c.projectURL(project, "releases/"+url.PathEscape(tag)), in, &rel)
+	return rel, err
+}
+
+// CreateLink adds an asset link to tag's release.
+func (c *Client) CreateLink(ctx context.Context, project, tag string, link Link) (Link, error) {
+	var out Link
+	err := c.api().DoJSON(ctx, http.MethodPost,
+		c.projectURL(project, "releases/"+url.PathEscape(tag)+"/assets/links"), link, &out)
+	return out, err
+}
+
+// DeleteLink removes an asset link from tag's release.
+func (c *Client) DeleteLink(ctx context.Context, project, tag string, id int64) error {
+	return c.api().DoJSON(ctx, http.MethodDelete,
+		c.projectURL(project, fmt.Sprintf("releases/%s/assets/links/%d", url.PathEscape(tag), id)), nil, nil)
+}
+
+// UploadPackageFile streams the file at path into the generic package
+// pkg at version, under its base name, and returns the file's download
+// URL. progress, when set, is called as the body is read by the
+// transport, ending with sent == total.
+func (c *Client) UploadPackageFile(
+	ctx context.Context,
+	project, pkg, version, path string,
+	progress forge.ProgressFunc,
+) (string, error) {
+	up, err := forge.OpenUpload(path, progress)
+	if err != nil {
+		return "", err
+	}
+	defer up.Close()
+	target := c.projectURL(project, fmt.Sprintf("packages/generic/%s/%s/%s",
+		url.PathEscape(pkg), url.PathEscape(version), url.PathEscape(filepath.Base(path))))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, up.Body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = up.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := c.api().Send(req, nil); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// projectURL addresses project by its URL-encoded path, which GitLab
+// accepts wherever it takes a numeric project id.
+func (c *Client) projectURL(project, path string) string {
+	return c.BaseURL + "/projects/" + url.PathEscape(strings.Trim(project, "/")) + "/" + path
+}
+
+// api is the client's calling convention for the forge helpers.
+func (c *Client) api() forge.API {
+	return forge.API{Forge: "GitLab", HTTP: c.HTTP, Header: c.header, Message: errorMessage}
+}
+
+// header adds the token header.
+func (c *Client) header(h http.Header) {
+	h.Set("Accept", "application/json")
+	if c.Token != "" {
+		h.Set("PRIVATE-TOKEN", c.Token)
+	}
+}
+
+// errorMessage reads GitLab's `message` (or `error`) field from an
+// error body; "" keeps the raw body.
+func errorMessage(data []byte) string {
+	var parsed struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(data, &parsed) != nil {
+		return ""
+	}
+	switch m := parsed.Message.(type) {
+	case string:
+		return m
+	case nil:
+		return parsed.Error
+	default:
+		// Validation failures come back as a field -> errors map.
+		if b, err := json.Marshal(m); err == nil {
+			return string(b)
+		}
+	}
+	return ""
+}
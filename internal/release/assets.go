@@ -0,0 +1,39 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CollectAssets lists the files under dir (recursively, sorted) to attach
+// to a release. A relative dir resolves against workspace. An empty dir,
+// or one that does not exist or is not a directory, gives no assets and
+// no error: the release then ships notes only.
+func CollectAssets(workspace, dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workspace, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	var files []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
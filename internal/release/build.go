@@ -0,0 +1,252 @@
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"commit_craft_reborn/internal/config"
+)
+
+// DefaultOutputName names each build when release_config.output_name is
+// empty.
+const DefaultOutputName = "{{.Project}}-{{.OS}}-{{.Arch}}{{.Ext}}"
+
+// Platform is one GOOS/GOARCH pair of the build matrix.
+type Platform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+func (p Platform) String() string { return p.OS + "/" + p.Arch }
+
+// BuildVars are the fields the ldflags and output_name templates see.
+// Ext is ".exe" on Windows and empty elsewhere.
+type BuildVars struct {
+	Project string
+	Version string
+	OS      string
+	Arch    string
+	Ext     string
+}
+
+// BuildMatrix cross-compiles one Go package for every platform of
+// [release_config].targets into the assets directory, Jobs at a time.
+type BuildMatrix struct {
+	Workspace string
+	Package   string
+	OutDir    string
+	Project   string
+	Version   string
+	Targets   []Platform
+	Jobs      int
+	ldflags   *template.Template
+	output    *template.Template
+}
+
+// BuildResult is the outcome of one platform's build. Log holds its
+// combined output; Err is nil when the binary was written to Output.
+type BuildResult struct {
+	Target   Platform
+	Output   string
+	Log      string
+	Duration time.Duration
+	Err      error
+}
+
+// BuildError reports the platforms that failed, each with its own log,
+// so callers can show them per target instead of as one blob.
+type BuildError struct {
+	Failed []BuildResult
+	Total  int
+}
+
+func (e *BuildError) Error() string {
+	names := make([]string, len(e.Failed))
+	for i, r := range e.Failed {
+		names[i] = r.Target.String()
+	}
+	return fmt.Sprintf("%d of %d build target(s) failed: %s", len(e.Failed), e.Total, strings.Join(names, ", "))
+}
+
+// NewBuildMatrix reads the matrix from cfg: build_target is the package
+// (default "."), binary_assets_path the output directory (default
+// "dist"), targets the "os/arch" list, and ldflags and output_name the
+// templates, which are validated here. Two targets may not name the same
+// platform or render the same output path: their builds would overwrite
+// each other.
+func NewBuildMatrix(cfg config.ReleaseConfig, workspace string) (*BuildMatrix, error) {
+	m := &BuildMatrix{
+		Workspace: workspace,
+		Package:   firstNonEmpty(cfg.BuildTarget, "."),
+		OutDir:    firstNonEmpty(cfg.BinaryAssetsPath, "dist"),
+		Project:   ProjectName(cfg.Repository, workspace),
+		Version:   cfg.Version,
+		Jobs:      cfg.BuildJobs,
+	}
+	if !filepath.IsAbs(m.OutDir) {
+		m.OutDir = filepath.Join(workspace, m.OutDir)
+	}
+	if m.Jobs <= 0 {
+		m.Jobs = runtime.NumCPU()
+	}
+	seen := map[Platform]string{}
+	for _, t := range cfg.Targets {
+		goos, goarch, ok := strings.Cut(strings.TrimSpace(t), "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("release_config.targets: %q is not os/arch", t)
+		}
+		p := Platform{OS: goos, Arch: goarch}
+		if prev, ok := seen[p]; ok {
+			return nil, fmt.Errorf("release_config.targets: %q and %q are the same target", prev, t)
+		}
+		seen[p] = t
+		m.Targets = append(m.Targets, p)
+	}
+	if len(m.Targets) == 0 {
+		return nil, fmt.Errorf("release_config.targets is empty")
+	}
+	var err error
+	if m.ldflags, err = template.New("ldflags").Option("missingkey=error").Parse(cfg.LDFlags); err != nil {
+		return nil, fmt.Errorf("release_config.ldflags: %w", err)
+	}
+	if m.output, err = template.New("output_name").Option("missingkey=error").
+		Parse(firstNonEmpty(cfg.OutputName, DefaultOutputName)); err != nil {
+		return nil, fmt.Errorf("release_config.output_name: %w", err)
+	}
+	// Render every target up front so a bad field name or a clash fails
+	// before any build.
+	outputs := map[string]Platform{}
+	for _, p := range m.Targets {
+		_, output, err := m.render(p)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := outputs[output]; ok {
+			return nil, fmt.Errorf("release_config.output_name: targets %s and %s both build %s", prev, p, output)
+		}
+		outputs[output] = p
+	}
+	return m, nil
+}
+
+func (m *BuildMatrix) render(p Platform) (ldflags, output string, err error) {
+	vars := BuildVars{Project: m.Project, Version: m.Version, OS: p.OS, Arch: p.Arch}
+	if p.OS == "windows" {
+		vars.Ext = ".exe"
+	}
+	var b bytes.Buffer
+	if err := m.ldflags.Execute(&b, vars); err != nil {
+		return "", "", fmt.Errorf("release_config.ldflags: %w", err)
+	}
+	ldflags = b.String()
+	b.Reset()
+	if err := m.output.Execute(&b, vars); err != nil {
+		return "", "", fmt.Errorf("release_config.output_name: %w", err)
+	}
+	return ldflags, filepath.Join(m.OutDir, b.String()), nil
+}
+
+// BuildObserver follows a matrix run. Every func is optional and is
+// called from the build goroutines, several at a time: Start when a
+// target's build begins, Line for each line it prints, Done with its
+// result.
+type BuildObserver struct {
+	Start func(p Platform)
+	Line  func(p Platform, line string)
+	Done  func(r BuildResult)
+}
+
+// Run builds every target, Jobs at a time, and returns the results in
+// target order. The error is a *BuildError when any target failed.
+func (m *BuildMatrix) Run(ctx context.Context, obs BuildObserver) ([]BuildResult, error) {
+	if err := os.MkdirAll(m.OutDir, 0o755); err != nil {
+		return nil, err
+	}
+	results := make([]BuildResult, len(m.Targets))
+	sem := make(chan struct{}, m.Jobs)
+	var wg sync.WaitGroup
+	for i, t := range m.Targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if obs.Start != nil {
+				obs.Start(t)
+			}
+			results[i] = m.build(ctx, t, obs.Line)
+			if obs.Done != nil {
+				obs.Done(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+	var failed []BuildResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BuildError{Failed: failed, Total: len(results)}
+	}
+	return results, nil
+}
+
+func (m *BuildMatrix) build(ctx context.Context, p Platform, onLine func(Platform, string)) (res BuildResult) {
+	res.Target = p
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+	ldflags, output, err := m.render(p)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Output = output
+	args := []string{"build", "-trimpath"}
+	if ldflags != "" {
+		args = append(args, "-ldflags", ldflags)
+	}
+	args = append(args, "-o", output, m.Package)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = m.Workspace
+	cmd.Env = append(os.Environ(), "GOOS="+p.OS, "GOARCH="+p.Arch, "CGO_ENABLED=0")
+
+	// stdout and stderr share one pipe so lines keep their order.
+	pr, pw := io.Pipe()
+	cmd.Stdout, cmd.Stderr = pw, pw
+	var log strings.Builder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(pr)
+		for sc.Scan() {
+			line := sc.Text()
+			log.WriteString(line + "\n")
+			if onLine != nil {
+				onLine(p, line)
+			}
+		}
+		// Drain whatever the scanner gave up on (over-long lines).
+		io.Copy(io.Discard, pr)
+	}()
+	err = cmd.Run()
+	pw.Close()
+	<-done
+	res.Log = log.String()
+	if err != nil {
+		res.Err = fmt.Errorf("go build %s: %w", p, err)
+	}
+	return res
+}
@@ -0,0 +1,220 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/config"
+)
+
+func TestNewBuildMatrix(t *testing.T) {
+	targets := []string{"linux/amd64", " windows/arm64 "}
+	cases := []struct {
+		name    string
+		cfg     config.ReleaseConfig
+		wantErr string
+	}{
+		{"valid", config.ReleaseConfig{Targets: targets, LDFlags: "-X main.version={{.Version}}"}, ""},
+		{"no targets", config.ReleaseConfig{}, "release_config.targets is empty"},
+		{"target without arch", config.ReleaseConfig{Targets: []string{"linux"}}, `"linux" is not os/arch`},
+		{"empty os", config.ReleaseConfig{Targets: []string{"/amd64"}}, `"/amd64" is not os/arch`},
+		{"empty arch", config.ReleaseConfig{Targets: []string{"linux/amd64", "darwin/"}}, `"darwin/" is not os/arch`},
+		{
+			"ldflags does not parse",
+			config.ReleaseConfig{Targets: targets, LDFlags: "-X main.version={{.Version"},
+			"release_config.ldflags: template:",
+		},
+		{
+			"output_name does not parse",
+			config.ReleaseConfig{Targets: targets, OutputName: "{{if .OS}}app"},
+			"release_config.output_name: template:",
+		},
+		{
+			"unknown ldflags field",
+			config.ReleaseConfig{Targets: targets, LDFlags: "-X main.commit={{.Commit}}"},
+			"release_config.ldflags:",
+		},
+		{
+			"duplicate target",
+			config.ReleaseConfig{Targets: []string{"linux/amd64", "darwin/arm64", " linux/amd64"}},
+			`release_config.targets: "linux/amd64" and " linux/amd64" are the same target`,
+		},
+		{
+			"output_name without the platform",
+			config.ReleaseConfig{Targets: targets, OutputName: "{{.Project}}"},
+			"release_config.output_name: targets linux/amd64 and windows/arm64 both build /work/app/dist/app",
+		},
+		{
+			"output_name without the arch",
+			config.ReleaseConfig{Targets: []string{"linux/amd64", "linux/arm64", "windows/arm64"}, OutputName: "{{.Project}}-{{.OS}}{{.Ext}}"},
+			"targets linux/amd64 and linux/arm64 both build /work/app/dist/app-linux",
+		},
+		{"output_name with the ext only", config.ReleaseConfig{Targets: targets, OutputName: "app{{.Ext}}"}, ""},
+		{
+			"unknown output_name field",
+			config.ReleaseConfig{Targets: targets, OutputName: "{{.Name}}-{{.OS}}"},
+			"release_config.output_name:",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := NewBuildMatrix(c.cfg, "/work/app")
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []Platform{{"linux", "amd64"}, {"windows", "arm64"}}
+			if !slices.Equal(m.Targets, want) {
+				t.Errorf("targets = %v, want %v", m.Targets, want)
+			}
+		})
+	}
+}
+
+func TestNewBuildMatrixDefaults(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     config.ReleaseConfig
+		pkg     string
+		outDir  string
+		project string
+		jobs    int
+	}{
+		{"defaults", config.ReleaseConfig{}, ".", "/work/app/dist", "app", runtime.NumCPU()},
+		{
+			"configured",
+			config.ReleaseConfig{BuildTarget: "./cmd/cli", BinaryAssetsPath: "out/bin", Repository: "owner/tool", BuildJobs: 2},
+			"./cmd/cli", "/work/app/out/bin", "tool", 2,
+		},
+		{"absolute output dir", config.ReleaseConfig{BinaryAssetsPath: "/tmp/bin", BuildJobs: -1}, ".", "/tmp/bin", "app", runtime.NumCPU()},
+	}
+	for _, c := range cases {
+		c.cfg.Targets = []string{"linux/amd64"}
+		m, err := NewBuildMatrix(c.cfg, "/work/app")
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if m.Package != c.pkg || m.OutDir != c.outDir || m.Project != c.project || m.Jobs != c.jobs {
+			t.Errorf("%s: matrix = %q %q %q %d, want %q %q %q %d",
+				c.name, m.Package, m.OutDir, m.Project, m.Jobs, c.pkg, c.outDir, c.project, c.jobs)
+		}
+	}
+}
+
+func TestBuildMatrixRender(t *testing.T) {
+	cfg := config.ReleaseConfig{
+		Targets:    []string{"linux/amd64", "windows/386"},
+		Version:    "v1.2.0",
+		LDFlags:    "-s -X main.version={{.Version}} -X main.platform={{.OS}}_{{.Arch}}",
+		Repository: "owner/tool",
+	}
+	cases := []struct {
+		outputName string
+		target     Platform
+		ldflags    string
+		output     string
+	}{
+		{"", Platform{"linux", "amd64"}, "-s -X main.version=v1.2.0 -X main.platform=linux_amd64", "tool-linux-amd64"},
+		{"", Platform{"windows", "386"}, "-s -X main.version=v1.2.0 -X main.platform=windows_386", "tool-windows-386.exe"},
+		{"{{.Project}}_{{.Version}}_{{.OS}}{{.Ext}}", Platform{"windows", "386"}, "-s -X main.version=v1.2.0 -X main.platform=windows_386", "tool_v1.2.0_windows.exe"},
+	}
+	for _, c := range cases {
+		cfg := cfg
+		cfg.OutputName = c.outputName
+		m, err := NewBuildMatrix(cfg, "/work/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ldflags, output, err := m.render(c.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ldflags != c.ldflags {
+			t.Errorf("%s ldflags = %q, want %q", c.target, ldflags, c.ldflags)
+		}
+		if want := filepath.Join("/work/app/dist", c.output); output != want {
+			t.Errorf("%s output = %q, want %q", c.target, output, want)
+		}
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	cases := []struct {
+		failed []Platform
+		total  int
+		want   string
+	}{
+		{[]Platform{{"linux", "amd64"}}, 1, "1 of 1 build target(s) failed: linux/amd64"},
+		{
+			[]Platform{{"linux", "arm64"}, {"windows", "amd64"}}, 4,
+			"2 of 4 build target(s) failed: linux/arm64, windows/amd64",
+		},
+	}
+	for _, c := range cases {
+		e := &BuildError{Total: c.total}
+		for _, p := range c.failed {
+			e.Failed = append(e.Failed, BuildResult{Target: p, Err: errors.New("boom")})
+		}
+		if got := e.Error(); got != c.want {
+			t.Errorf("Error() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestBuildMatrixRunCollectsFailures(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go build")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	host := runtime.GOOS + "/" + runtime.GOARCH
+	m, err := NewBuildMatrix(config.ReleaseConfig{Targets: []string{host, "plan9/nope", "linux/nope"}, BuildJobs: 2}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	results, err := m.Run(context.Background(), BuildObserver{})
+	for _, r := range results {
+		order = append(order, r.Target.String())
+	}
+	if want := []string{host, "plan9/nope", "linux/nope"}; !slices.Equal(order, want) {
+		t.Errorf("results = %v, want target order %v", order, want)
+	}
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want a *BuildError", err)
+	}
+	if be.Total != 3 || len(be.Failed) != 2 {
+		t.Fatalf("BuildError = %d of %d, want 2 of 3", len(be.Failed), be.Total)
+	}
+	for _, r := range be.Failed {
+		if r.Target.Arch != "nope" || r.Log == "" {
+			t.Errorf("failed %s with log %q, want the bad arch and its go build output", r.Target, r.Log)
+		}
+	}
+	if results[0].Err != nil {
+		t.Errorf("%s: %v\n%s", host, results[0].Err, results[0].Log)
+	}
+	if _, err := os.Stat(results[0].Output); err != nil {
+		t.Errorf("host binary missing: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRelease is the release a fakeForge holds, in a shape shared by
+// every forge; the forge's routes translate it to and from its JSON.
+type fakeRelease struct {
+	ID     int64
+	Tag    string
+	Title  string
+	Notes  string
+	Assets []fakeAsset
+}
+
+// fakeAsset is one attached file (a link, on GitLab).
+type fakeAsset struct {
+	ID       int64
+	Name     string
+	URL      string
+	LinkType string
+}
+
+// forgeRoutes is what a forge's tests supply to the fake: how requests
+// authenticate, where tags are looked up and the release endpoints.
+type forgeRoutes struct {
+	// authHeader must carry authValue; other requests get a 401 whose
+	// message is authError.
+	authHeader, authValue, authError string
+	// tagPrefix is the escaped path tag lookups start with; the tag
+	// follows it.
+	tagPrefix string
+	// serve answers one authenticated request, holding f.mu; false when
+	// no route matches, which the fake answers with a 404.
+	serve func(f *fakeForge, w http.ResponseWriter, r *http.Request, path string) bool
+}
+
+// fakeForge serves one repository's release API from memory and records
+// every call as "METHOD escaped-path", in order.
+type fakeForge struct {
+	mu      sync.Mutex
+	srv     *httptest.Server
+	routes  forgeRoutes
+	tags    map[string]bool
+	release *fakeRelease
+	// target is the branch or ref the last create or update asked for.
+	target  string
+	nextID  int64
+	uploads map[string]string
+	calls   []string
+}
+
+func newFakeForge(t *testing.T, routes forgeRoutes) *fakeForge {
+	t.Helper()
+	f := &fakeForge{routes: routes, tags: map[string]bool{}, nextID: 100, uploads: map[string]string{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeForge) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.EscapedPath()
+	f.calls = append(f.calls, r.Method+" "+path)
+	if r.Header.Get(f.routes.authHeader) != f.routes.authValue {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": f.routes.authError})
+		return
+	}
+	if tag, ok := strings.CutPrefix(path, f.routes.tagPrefix); ok && r.Method == http.MethodGet {
+		if !f.tags[tag] {
+			notFound(w)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+		return
+	}
+	if !f.routes.serve(f, w, r, path) {
+		notFound(w)
+	}
+}
+
+// addAsset attaches a, under a fresh id, to the release.
+func (f *fakeForge) addAsset(a fakeAsset) fakeAsset {
+	f.nextID++
+	a.ID = f.nextID
+	f.release.Assets = append(f.release.Assets, a)
+	return a
+}
+
+func (f *fakeForge) deleteAsset(id int64) {
+	f.release.Assets = slices.DeleteFunc(f.release.Assets, func(a fakeAsset) bool { return a.ID == id })
+}
+
+// assetNames returns the release's file names, sorted.
+func (f *fakeForge) assetNames() []string {
+	var names []string
+	for _, a := range f.release.Assets {
+		names = append(names, a.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkCalls reports the calls in want that were not made and the made
+// calls that start with an entry of absent. A trailing space in absent
+// anchors the end of the call, so "POST /x " only matches "POST /x".
+func (f *fakeForge) checkCalls(t *testing.T, want, absent []string) {
+	t.Helper()
+	for _, w := range want {
+		if !slices.Contains(f.calls, w) {
+			t.Errorf("missing call %q in %v", w, f.calls)
+		}
+	}
+	for _, bad := range absent {
+		for _, call := range f.calls {
+			if strings.HasPrefix(call+" ", bad) {
+				t.Errorf("unexpected call %q", call)
+			}
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+// writeAssets creates the named files under a temp dir, each holding
+// its own name, and returns their paths.
+func writeAssets(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, n := range names {
+		paths[i] = filepath.Join(dir, n)
+		if err := os.WriteFile(paths[i], []byte(strings.Repeat(n, 100)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// forgeHost describes one forge to TestHostCreateRelease: its routes,
+// the Host under test and the calls the shared scenarios make.
+type forgeHost struct {
+	name       string
+	routes     forgeRoutes
+	repository string
+	newHost    func(baseURL, token string) Host
+	// url is the release page of v1.0.0.
+	url string
+	// The recorded calls that check the tag, create and update the
+	// release, upload app.tar.gz and delete the asset with an id.
+	tagCall, createCall, updateCall, uploadCall string
+	deleteCall                                  func(id int64) string
+	// dropsTargetOnUpdate is set when an update does not send the
+	// target; it only matters when the host creates the tag.
+	dropsTargetOnUpdate bool
+	// tokenErr is the error without a token; "" when the host does not
+	// require one.
+	tokenErr string
+	// authErr is the error a wrong token gets back.
+	authErr string
+}
+
+var forgeHosts = []forgeHost{githubHost, gitlabHost, giteaHost}
+
+// testSpec is the release every host test publishes.
+func testSpec(t *testing.T, repository string) Spec {
+	return Spec{
+		Repository: repository,
+		Tag:        "v1.0.0",
+		Title:      "Release v1.0.0: Speed",
+		Notes:      "- faster",
+		Assets:     writeAssets(t, "app.tar.gz", "SHA256SUMS"),
+		Target:     "main",
+	}
+}
+
+// TestHostCreateRelease runs the scenarios every forge shares.
+func TestHostCreateRelease(t *testing.T) {
+	cases := []struct {
+		name      string
+		existing  bool
+		verify    bool
+		tagOnHost bool
+		noToken   bool
+		badToken  bool
+		wantErr   func(h forgeHost) string
+		wantCalls func(h forgeHost) []string
+		absent    func(h forgeHost) []string
+		assets    []string
+	}{
+		{
+			name:      "creates the release and uploads",
+			wantCalls: func(h forgeHost) []string { return []string{h.createCall, h.uploadCall} },
+			absent:    func(h forgeHost) []string { return []string{h.updateCall + " ", "DELETE"} },
+			assets:    []string{"SHA256SUMS", "app.tar.gz"},
+		},
+		{
+			name:     "updates the release and replaces same-named assets",
+			existing: true,
+			wantCalls: func(h forgeHost) []string {
+				return []string{h.updateCall, h.uploadCall, h.deleteCall(7)}
+			},
+			absent: func(h forgeHost) []string {
+				return []string{h.createCall + " ", h.deleteCall(8) + " "}
+			},
+			assets: []string{"SHA256SUMS", "app.tar.gz", "notes.txt"},
+		},
+		{
+			name:      "verified tag on the host",
+			verify:    true,
+			tagOnHost: true,
+			wantCalls: func(h forgeHost) []string { return []string{h.tagCall, h.createCall} },
+			assets:    []string{"SHA256SUMS", "app.tar.gz"},
+		},
+		{
+			name:      "verified tag missing",
+			verify:    true,
+			wantErr:   func(forgeHost) string { return "push it first" },
+			wantCalls: func(h forgeHost) []string { return []string{h.tagCall} },
+			absent:    func(forgeHost) []string { return []string{"POST", "PUT", "PATCH"} },
+		},
+		{
+			name:    "no token",
+			noToken: true,
+			wantErr: func(h forgeHost) string { return h.tokenErr },
+			absent:  func(forgeHost) []string { return []string{"GET", "POST", "PUT", "PATCH"} },
+		},
+		{
+			name:     "wrong token",
+			badToken: true,
+			wantErr:  func(h forgeHost) string { return h.authErr },
+			absent:   func(forgeHost) []string { return []string{"POST", "PUT", "PATCH"} },
+		},
+	}
+	for _, h := range forgeHosts {
+		for _, c := range cases {
+			t.Run(h.name+"/"+c.name, func(t *testing.T) {
+				if c.noToken && h.tokenErr == "" {
+					t.Skip("the host does not require a token")
+				}
+				f := newFakeForge(t, h.routes)
+				f.tags["v1.0.0"] = c.tagOnHost
+				if c.existing {
+					f.release = &fakeRelease{
+						ID: 1, Tag: "v1.0.0", Title: "old",
+						Assets: []fakeAsset{{ID: 7, Name: "app.tar.gz"}, {ID: 8, Name: "notes.txt"}},
+					}
+				}
+				token := "tok"
+				if c.noToken {
+					token = ""
+				} else if c.badToken {
+					token = "wrong"
+				}
+				spec := testSpec(t, h.repository)
+				spec.VerifyTag = c.verify
+				url, err := h.newHost(f.srv.URL, token).CreateRelease(context.Background(), spec)
+				var wantErr string
+				if c.wantErr != nil {
+					wantErr = c.wantErr(h)
+				}
+				if wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), wantErr) {
+						t.Fatalf("err = %v, want it to mention %q", err, wantErr)
+					}
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				var want, absent []string
+				if c.wantCalls != nil {
+					want = c.wantCalls(h)
+				}
+				if c.absent != nil {
+					absent = c.absent(h)
+				}
+				f.checkCalls(t, want, absent)
+				if wantErr != "" {
+					return
+				}
+				if url != h.url {
+					t.Errorf("url = %q, want %q", url, h.url)
+				}
+				if f.release.Title != spec.Title || f.release.Notes != spec.Notes {
+					t.Errorf("release = %q / %q, want the spec's title and notes", f.release.Title, f.release.Notes)
+				}
+				wantTarget := "main"
+				if c.verify || (c.existing && h.dropsTargetOnUpdate) {
+					wantTarget = ""
+				}
+				if f.target != wantTarget {
+					t.Errorf("target = %q, want %q", f.target, wantTarget)
+				}
+				if names := f.assetNames(); !slices.Equal(names, c.assets) {
+					t.Errorf("assets = %v, want %v", names, c.assets)
+				}
+				if got := f.uploads["app.tar.gz"]; got != strings.Repeat("app.tar.gz", 100) {
+					t.Errorf("uploaded app.tar.gz has %d bytes", len(got))
+				}
+			})
+		}
+	}
+}
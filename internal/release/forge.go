@@ -0,0 +1,139 @@
+package release
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/gitea"
+	"commit_craft_reborn/internal/github"
+	"commit_craft_reborn/internal/gitlab"
+)
+
+// Remote is a git remote URL split into what the release hosts need.
+// Host is the forge guessed from Domain ("" when the name gives no
+// hint); BaseURL is the forge's web root, which the API roots hang off.
+type Remote struct {
+	Host       string
+	Domain     string
+	BaseURL    string
+	Repository string
+}
+
+// scpRemote matches the scp-like SSH form, e.g. git@gitlab.com:group/app.git.
+var scpRemote = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ParseRemote parses an HTTP(S), ssh:// or scp-like remote URL. The
+// repository keeps every path segment (GitLab subgroups) minus ".git".
+// Local paths and URLs without an owner/name path report false.
+func ParseRemote(raw string) (Remote, bool) {
+	raw = strings.TrimSpace(raw)
+	var r Remote
+	var path string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return Remote{}, false
+		}
+		r.Domain = strings.ToLower(u.Hostname())
+		switch u.Scheme {
+		case "http", "https":
+			// Keep the port: a forge on http://127.0.0.1:3000 serves
+			// its API on the same port.
+			r.BaseURL = u.Scheme + "://" + u.Host
+		default:
+			r.BaseURL = "https://" + r.Domain
+		}
+		path = u.Path
+	} else if m := scpRemote.FindStringSubmatch(raw); m != nil {
+		r.Domain = strings.ToLower(m[1])
+		r.BaseURL = "https://" + r.Domain
+		path = m[2]
+	} else {
+		return Remote{}, false
+	}
+	r.Repository = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if !strings.Contains(r.Repository, "/") {
+		return Remote{}, false
+	}
+	r.Host = guessHost(r.Domain)
+	return r, true
+}
+
+// guessHost names the forge a domain belongs to from well-known names.
+// Self-hosted instances on neutral domains need release_config.host.
+func guessHost(domain string) string {
+	switch {
+	case strings.Contains(domain, "github"):
+		return config.ReleaseHostGitHub
+	case strings.Contains(domain, "gitlab"):
+		return config.ReleaseHostGitLab
+	case strings.Contains(domain, "gitea"),
+		strings.Contains(domain, "forgejo"),
+		domain == "codeberg.org":
+		return config.ReleaseHostGitea
+	}
+	return ""
+}
+
+// OriginRemote parses the origin remote of workspace. It reports false
+// when there is no origin or its URL is not a forge URL.
+func OriginRemote(workspace string) (Remote, bool) {
+	raw, err := git.RemoteURLAt(workspace, "origin")
+	if err != nil {
+		return Remote{}, false
+	}
+	return ParseRemote(raw)
+}
+
+// ResolveHost names the forge cfg publishes to: release_config.host
+// when set, else the one origin points at, else GitHub.
+func ResolveHost(cfg config.ReleaseConfig, origin Remote) string {
+	if h := strings.ToLower(strings.TrimSpace(cfg.Host)); h != "" {
+		return h
+	}
+	if origin.Host != "" {
+		return origin.Host
+	}
+	return config.ReleaseHostGitHub
+}
+
+// NewHost picks the release host for cfg in workspace. GitLab and
+// Gitea talk to release_config.api_url or, when it is empty, the API
+// root of the origin's forge. GitHub uses the REST API when GH_TOKEN is
+// set and the `gh` CLI with its own login otherwise.
+func NewHost(cfg config.ReleaseConfig, workspace string) (Host, error) {
+	origin, _ := OriginRemote(workspace)
+	api := strings.TrimSpace(cfg.APIURL)
+	switch host := ResolveHost(cfg, origin); host {
+	case config.ReleaseHostGitLab:
+		if api == "" && origin.BaseURL != "" {
+			api = origin.BaseURL + "/api/v4"
+		}
+		return GitLab{Client: gitlab.NewClient(api, cfg.GitLabToken)}, nil
+	case config.ReleaseHostGitea:
+		if api == "" && origin.BaseURL != "" {
+			api = origin.BaseURL + "/api/v1"
+		}
+		if api == "" {
+			return nil, fmt.Errorf("release_config.api_url is required for Gitea when origin is not a Gitea URL")
+		}
+		return Gitea{Client: gitea.NewClient(api, cfg.GiteaToken)}, nil
+	case config.ReleaseHostGitHub:
+		if cfg.GhToken == "" {
+			return GhCLI{}, nil
+		}
+		// GitHub Enterprise serves the API under /api/v3 of its web root.
+		if api == "" && origin.BaseURL != "" && origin.Domain != "github.com" &&
+			(origin.Host == config.ReleaseHostGitHub || cfg.Host != "") {
+			api = origin.BaseURL + "/api/v3"
+		}
+		return GitHub{Client: github.NewClient(api, cfg.GhToken)}, nil
+	default:
+		return nil, fmt.Errorf(
+			"release_config.host=%q is not supported (github, gitlab or gitea)", cfg.Host)
+	}
+}
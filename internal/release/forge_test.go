@@ -0,0 +1,133 @@
+package release
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/config"
+)
+
+func TestParseRemote(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want Remote
+		ok   bool
+	}{
+		{"https://github.com/owner/app.git", Remote{config.ReleaseHostGitHub, "github.com", "https://github.com", "owner/app"}, true},
+		{"git@gitlab.com:group/sub/app.git", Remote{config.ReleaseHostGitLab, "gitlab.com", "https://gitlab.com", "group/sub/app"}, true},
+		{"ssh://git@codeberg.org/owner/app", Remote{config.ReleaseHostGitea, "codeberg.org", "https://codeberg.org", "owner/app"}, true},
+		{"http://127.0.0.1:3000/owner/app.git", Remote{"", "127.0.0.1", "http://127.0.0.1:3000", "owner/app"}, true},
+		{"https://GHE.Example.com/org/app/", Remote{"", "ghe.example.com", "https://GHE.Example.com", "org/app"}, true},
+		{"/srv/git/app.git", Remote{}, false},
+		{"https://github.com/app", Remote{}, false},
+		{"", Remote{}, false},
+	}
+	for _, c := range cases {
+		got, ok := ParseRemote(c.raw)
+		if ok != c.ok || got != c.want {
+			t.Errorf("ParseRemote(%q) = %+v, %v; want %+v, %v", c.raw, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	cases := []struct {
+		cfgHost, originHost, want string
+	}{
+		{"", "", config.ReleaseHostGitHub},
+		{"", config.ReleaseHostGitLab, config.ReleaseHostGitLab},
+		{" Gitea ", config.ReleaseHostGitLab, config.ReleaseHostGitea},
+		{"bitbucket", "", "bitbucket"},
+	}
+	for _, c := range cases {
+		got := ResolveHost(config.ReleaseConfig{Host: c.cfgHost}, Remote{Host: c.originHost})
+		if got != c.want {
+			t.Errorf("ResolveHost(%q, %q) = %q, want %q", c.cfgHost, c.originHost, got, c.want)
+		}
+	}
+}
+
+// repoWithOrigin returns a fresh repository whose origin is url ("" for
+// no origin).
+func repoWithOrigin(t *testing.T, url string) string {
+	t.Helper()
+	dir := t.TempDir()
+	cmds := [][]string{{"init", "-q"}}
+	if url != "" {
+		cmds = append(cmds, []string{"remote", "add", "origin", url})
+	}
+	for _, args := range cmds {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestNewHost(t *testing.T) {
+	cases := []struct {
+		name    string
+		origin  string
+		cfg     config.ReleaseConfig
+		host    string
+		api     string
+		wantErr string
+	}{
+		{"github token", "git@github.com:owner/app.git", config.ReleaseConfig{GhToken: "t"}, "github", "https://api.github.com", ""},
+		{"github without token uses gh", "git@github.com:owner/app.git", config.ReleaseConfig{}, "gh", "", ""},
+		{"no origin defaults to github", "", config.ReleaseConfig{GhToken: "t"}, "github", "https://api.github.com", ""},
+		{
+			"github enterprise", "https://ghe.example.com/org/app.git",
+			config.ReleaseConfig{Host: "github", GhToken: "t"}, "github", "https://ghe.example.com/api/v3", "",
+		},
+		{"gitlab.com", "https://gitlab.com/group/sub/app.git", config.ReleaseConfig{}, "gitlab", "https://gitlab.com/api/v4", ""},
+		{
+			"self-hosted gitlab", "git@code.example.com:group/app.git",
+			config.ReleaseConfig{Host: "gitlab"}, "gitlab", "https://code.example.com/api/v4", "",
+		},
+		{"codeberg", "https://codeberg.org/owner/app", config.ReleaseConfig{}, "gitea", "https://codeberg.org/api/v1", ""},
+		{
+			"gitea on a port", "http://127.0.0.1:3000/owner/app.git",
+			config.ReleaseConfig{Host: "gitea"}, "gitea", "http://127.0.0.1:3000/api/v1", "",
+		},
+		{
+			"api_url wins", "https://gitlab.com/group/app.git",
+			config.ReleaseConfig{APIURL: " https://gl.internal/api/v4/ "}, "gitlab", "https://gl.internal/api/v4", "",
+		},
+		{"gitea needs an API root", "", config.ReleaseConfig{Host: "gitea"}, "", "", "api_url is required"},
+		{"unknown host", "", config.ReleaseConfig{Host: "bitbucket"}, "", "", "not supported"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h, err := NewHost(c.cfg, repoWithOrigin(t, c.origin))
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kind, api string
+			switch h := h.(type) {
+			case GitHub:
+				kind, api = "github", h.Client.BaseURL
+			case GhCLI:
+				kind = "gh"
+			case GitLab:
+				kind, api = "gitlab", h.Client.BaseURL
+			case Gitea:
+				kind, api = "gitea", h.Client.BaseURL
+			default:
+				t.Fatalf("unexpected host %T", h)
+			}
+			if kind != c.host || api != c.api {
+				t.Errorf("host = %s at %q, want %s at %q", kind, api, c.host, c.api)
+			}
+		})
+	}
+}
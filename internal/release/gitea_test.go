@@ -0,0 +1,119 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/gitea"
+)
+
+// giteaRoutes serve the release and attachment endpoints of owner/repo.
+var giteaRoutes = forgeRoutes{
+	authHeader: "Authorization",
+	authValue:  "token tok",
+	authError:  "token is required",
+	tagPrefix:  "/repos/owner/repo/tags/",
+	serve: func(f *fakeForge, w http.ResponseWriter, r *http.Request, path string) bool {
+		const repo = "/repos/owner/repo/"
+		switch {
+		case r.Method == http.MethodGet && path == repo+"releases/tags/v1.0.0":
+			if f.release == nil {
+				notFound(w)
+				return true
+			}
+			writeJSON(w, http.StatusOK, giteaRelease(f))
+		case r.Method == http.MethodPost && path == repo+"releases":
+			var in gitea.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release = &fakeRelease{ID: 1, Tag: in.TagName, Title: in.Name, Notes: in.Body}
+			f.target = in.Target
+			writeJSON(w, http.StatusCreated, giteaRelease(f))
+		case r.Method == http.MethodPatch && path == repo+"releases/1":
+			var in gitea.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release.Title, f.release.Notes = in.Name, in.Body
+			f.target = in.Target
+			writeJSON(w, http.StatusOK, giteaRelease(f))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, repo+"releases/1/assets/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, repo+"releases/1/assets/"), 10, 64)
+			f.deleteAsset(id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && path == repo+"releases/1/assets":
+			file, header, err := r.FormFile("attachment")
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return true
+			}
+			data, _ := io.ReadAll(file)
+			name := r.URL.Query().Get("name")
+			if header.Filename != name {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"message": "name mismatch"})
+				return true
+			}
+			f.uploads[name] = string(data)
+			a := f.addAsset(fakeAsset{Name: name})
+			writeJSON(w, http.StatusCreated, gitea.Attachment{ID: a.ID, Name: name, Size: int64(len(data))})
+		default:
+			return false
+		}
+		return true
+	},
+}
+
+// giteaRelease renders the fake's release as the API returns it.
+func giteaRelease(f *fakeForge) gitea.Release {
+	rel := gitea.Release{
+		ID:      f.release.ID,
+		TagName: f.release.Tag,
+		Name:    f.release.Title,
+		Body:    f.release.Notes,
+		HTMLURL: "https://gitea.test/owner/repo/releases/tag/" + f.release.Tag,
+	}
+	for _, a := range f.release.Assets {
+		rel.Assets = append(rel.Assets, gitea.Attachment{ID: a.ID, Name: a.Name})
+	}
+	return rel
+}
+
+var giteaHost = forgeHost{
+	name:       "gitea",
+	routes:     giteaRoutes,
+	repository: "owner/repo",
+	newHost:    func(baseURL, token string) Host { return Gitea{Client: gitea.NewClient(baseURL, token)} },
+	url:        "https://gitea.test/owner/repo/releases/tag/v1.0.0",
+	tagCall:    "GET /repos/owner/repo/tags/v1.0.0",
+	createCall: "POST /repos/owner/repo/releases",
+	updateCall: "PATCH /repos/owner/repo/releases/1",
+	uploadCall: "POST /repos/owner/repo/releases/1/assets",
+	deleteCall: func(id int64) string { return fmt.Sprintf("DELETE /repos/owner/repo/releases/1/assets/%d", id) },
+	tokenErr:   "GITEA_TOKEN is not set",
+	authErr:    "Gitea API returned 401: token is required",
+}
+
+// TestGiteaUploadProgress checks that progress counts the file's bytes,
+// not the multipart body wrapped around them.
+func TestGiteaUploadProgress(t *testing.T) {
+	f := newFakeForge(t, giteaRoutes)
+	var sent []int64
+	spec := testSpec(t, "owner/repo")
+	spec.Progress = func(name string, s, total int64) {
+		if name == "app.tar.gz" {
+			sent = append(sent, s)
+		}
+	}
+	if _, err := giteaHost.newHost(f.srv.URL, "tok").CreateRelease(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) < 2 || sent[0] != 0 || sent[len(sent)-1] != 1000 {
+		t.Errorf("app.tar.gz progress = %v, want 0 first and 1000 last", sent)
+	}
+	if got := f.uploads["app.tar.gz"]; got != strings.Repeat("app.tar.gz", 100) {
+		t.Errorf("uploaded app.tar.gz has %d bytes", len(got))
+	}
+}
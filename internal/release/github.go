@@ -0,0 +1,75 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"commit_craft_reborn/internal/forge"
+	"commit_craft_reborn/internal/github"
+)
+
+// GitHub publishes through the GitHub REST API. A release that already
+// exists for the tag is updated in place, and assets it already has
+// under the same name are replaced.
+type GitHub struct {
+	Client *github.Client
+}
+
+func (GitHub) Name() string { return "github" }
+
+func (g GitHub) Describe(spec Spec) string {
+	return fmt.Sprintf("create or update the release of %s on %s/repos/%s, upload %d asset(s)",
+		spec.Tag, g.Client.BaseURL, spec.Repository, len(spec.Assets))
+}
+
+func (g GitHub) CreateRelease(ctx context.Context, spec Spec) (string, error) {
+	if spec.Repository == "" {
+		return "", fmt.Errorf("release_config.repository (owner/name) is not set")
+	}
+	if spec.VerifyTag {
+		ok, err := g.Client.TagExists(ctx, spec.Repository, spec.Tag)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("tag %s is not on %s; push it first", spec.Tag, spec.Repository)
+		}
+	}
+	in := github.ReleaseInput{TagName: spec.Tag, Name: spec.Title, Body: spec.Notes}
+	if !spec.VerifyTag {
+		in.TargetCommitish = spec.Target
+	}
+	rel, found, err := g.Client.ReleaseByTag(ctx, spec.Repository, spec.Tag)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		rel, err = g.Client.UpdateRelease(ctx, spec.Repository, rel.ID, in)
+	} else {
+		rel, err = g.Client.CreateRelease(ctx, spec.Repository, in)
+	}
+	if err != nil {
+		return "", err
+	}
+	existing := map[string]int64{}
+	for _, a := range rel.Assets {
+		existing[a.Name] = a.ID
+	}
+	for _, path := range spec.Assets {
+		name := filepath.Base(path)
+		if id, ok := existing[name]; ok {
+			if err := g.Client.DeleteAsset(ctx, spec.Repository, id); err != nil {
+				return "", fmt.Errorf("replace asset %s: %w", name, err)
+			}
+		}
+		var progress forge.ProgressFunc
+		if spec.Progress != nil {
+			progress = func(sent, total int64) { spec.Progress(name, sent, total) }
+		}
+		if _, err := g.Client.UploadAsset(ctx, rel, path, progress); err != nil {
+			return "", fmt.Errorf("upload asset %s: %w", name, err)
+		}
+	}
+	return rel.HTMLURL, nil
+}
@@ -0,0 +1,118 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/github"
+)
+
+// githubRoutes serve the release endpoints of owner/repo.
+var githubRoutes = forgeRoutes{
+	authHeader: "Authorization",
+	authValue:  "Bearer tok",
+	authError:  "Bad credentials",
+	tagPrefix:  "/repos/owner/repo/git/ref/tags/",
+	serve: func(f *fakeForge, w http.ResponseWriter, r *http.Request, path string) bool {
+		const repo = "/repos/owner/repo/"
+		switch {
+		case r.Method == http.MethodGet && path == repo+"releases/tags/v1.0.0":
+			if f.release == nil {
+				notFound(w)
+				return true
+			}
+			writeJSON(w, http.StatusOK, githubRelease(f))
+		case r.Method == http.MethodPost && path == repo+"releases":
+			var in github.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release = &fakeRelease{ID: 1, Tag: in.TagName, Title: in.Name, Notes: in.Body}
+			f.target = in.TargetCommitish
+			writeJSON(w, http.StatusCreated, githubRelease(f))
+		case r.Method == http.MethodPatch && path == repo+"releases/1":
+			var in github.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release.Title, f.release.Notes = in.Name, in.Body
+			f.target = in.TargetCommitish
+			writeJSON(w, http.StatusOK, githubRelease(f))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, repo+"releases/assets/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, repo+"releases/assets/"), 10, 64)
+			f.deleteAsset(id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && path == "/uploads/owner/repo/releases/1/assets":
+			name := r.URL.Query().Get("name")
+			data, _ := io.ReadAll(r.Body)
+			f.uploads[name] = string(data)
+			a := f.addAsset(fakeAsset{Name: name})
+			writeJSON(w, http.StatusCreated, github.Asset{ID: a.ID, Name: name, Size: int64(len(data))})
+		default:
+			return false
+		}
+		return true
+	},
+}
+
+// githubRelease renders the fake's release as the API returns it.
+func githubRelease(f *fakeForge) github.Release {
+	rel := github.Release{
+		ID:        f.release.ID,
+		TagName:   f.release.Tag,
+		Name:      f.release.Title,
+		Body:      f.release.Notes,
+		HTMLURL:   "https://github.test/owner/repo/releases/" + f.release.Tag,
+		UploadURL: f.srv.URL + "/uploads/owner/repo/releases/1/assets{?name,label}",
+	}
+	for _, a := range f.release.Assets {
+		rel.Assets = append(rel.Assets, github.Asset{ID: a.ID, Name: a.Name})
+	}
+	return rel
+}
+
+var githubHost = forgeHost{
+	name:       "github",
+	routes:     githubRoutes,
+	repository: "owner/repo",
+	newHost:    func(baseURL, token string) Host { return GitHub{Client: github.NewClient(baseURL, token)} },
+	url:        "https://github.test/owner/repo/releases/v1.0.0",
+	tagCall:    "GET /repos/owner/repo/git/ref/tags/v1.0.0",
+	createCall: "POST /repos/owner/repo/releases",
+	updateCall: "PATCH /repos/owner/repo/releases/1",
+	uploadCall: "POST /uploads/owner/repo/releases/1/assets",
+	deleteCall: func(id int64) string { return fmt.Sprintf("DELETE /repos/owner/repo/releases/assets/%d", id) },
+	authErr:    "GitHub API returned 401: Bad credentials",
+}
+
+func TestGitHubCreateReleaseProgress(t *testing.T) {
+	f := newFakeForge(t, githubRoutes)
+	type report struct{ sent, total int64 }
+	seen := map[string][]report{}
+	spec := testSpec(t, "owner/repo")
+	spec.Progress = func(name string, sent, total int64) {
+		seen[name] = append(seen[name], report{sent, total})
+	}
+	if _, err := githubHost.newHost(f.srv.URL, "tok").CreateRelease(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range spec.Assets {
+		name := filepath.Base(path)
+		size := int64(len(name) * 100)
+		got := seen[name]
+		if len(got) < 2 {
+			t.Fatalf("%s: %d progress reports, want a start and an end", name, len(got))
+		}
+		if got[0] != (report{0, size}) || got[len(got)-1] != (report{size, size}) {
+			t.Errorf("%s: progress %v, want 0/%d first and %d/%d last", name, got, size, size, size)
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i].sent < got[i-1].sent {
+				t.Errorf("%s: progress went backwards: %v", name, got)
+			}
+		}
+	}
+}
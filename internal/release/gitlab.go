@@ -0,0 +1,97 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"commit_craft_reborn/internal/forge"
+	"commit_craft_reborn/internal/gitlab"
+)
+
+// GitLab publishes through the GitLab REST API. Assets are uploaded to
+// the project's generic package registry, in a package named after the
+// project and versioned by the tag, and linked from the release. An
+// existing release for the tag is updated in place, and links it
+// already has under the same name are replaced.
+type GitLab struct {
+	Client *gitlab.Client
+}
+
+func (GitLab) Name() string { return "gitlab" }
+
+func (g GitLab) Describe(spec Spec) string {
+	return fmt.Sprintf(
+		"create or update the release of %s on %s/projects/%s, upload %d asset(s) to package %s/%s",
+		spec.Tag, g.Client.BaseURL, spec.Repository, len(spec.Assets),
+		packageName(spec.Repository), spec.Tag)
+}
+
+func (g GitLab) CreateRelease(ctx context.Context, spec Spec) (string, error) {
+	if spec.Repository == "" {
+		return "", fmt.Errorf("release_config.repository (group/name) is not set")
+	}
+	if g.Client.Token == "" {
+		return "", fmt.Errorf("GITLAB_TOKEN is not set")
+	}
+	if spec.VerifyTag {
+		ok, err := g.Client.TagExists(ctx, spec.Repository, spec.Tag)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("tag %s is not on %s; push it first", spec.Tag, spec.Repository)
+		}
+	}
+	in := gitlab.ReleaseInput{TagName: spec.Tag, Name: spec.Title, Description: spec.Notes}
+	if !spec.VerifyTag {
+		in.Ref = spec.Target
+	}
+	rel, found, err := g.Client.ReleaseByTag(ctx, spec.Repository, spec.Tag)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		rel, err = g.Client.UpdateRelease(ctx, spec.Repository, spec.Tag, in)
+	} else {
+		rel, err = g.Client.CreateRelease(ctx, spec.Repository, in)
+	}
+	if err != nil {
+		return "", err
+	}
+	existing := map[string]int64{}
+	for _, l := range rel.Assets.Links {
+		existing[l.Name] = l.ID
+	}
+	pkg := packageName(spec.Repository)
+	for _, p := range spec.Assets {
+		name := filepath.Base(p)
+		var progress forge.ProgressFunc
+		if spec.Progress != nil {
+			progress = func(sent, total int64) { spec.Progress(name, sent, total) }
+		}
+		url, err := g.Client.UploadPackageFile(ctx, spec.Repository, pkg, spec.Tag, p, progress)
+		if err != nil {
+			return "", fmt.Errorf("upload asset %s: %w", name, err)
+		}
+		// Swap the link only once the new file is up, so a failed
+		// upload leaves the previous asset reachable.
+		if id, ok := existing[name]; ok {
+			if err := g.Client.DeleteLink(ctx, spec.Repository, spec.Tag, id); err != nil {
+				return "", fmt.Errorf("replace asset %s: %w", name, err)
+			}
+		}
+		link := gitlab.Link{Name: name, URL: url, LinkType: "package"}
+		if _, err := g.Client.CreateLink(ctx, spec.Repository, spec.Tag, link); err != nil {
+			return "", fmt.Errorf("link asset %s: %w", name, err)
+		}
+	}
+	return rel.Links.Self, nil
+}
+
+// packageName is the generic package the assets go to: the project's
+// own name, which already satisfies GitLab's package name rules.
+func packageName(project string) string {
+	return path.Base(project)
+}
@@ -0,0 +1,136 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"commit_craft_reborn/internal/gitlab"
+)
+
+// gitlabProject is the escaped API path of the project group/sub/app.
+const gitlabProject = "/projects/group%2Fsub%2Fapp/"
+
+// gitlabRoutes serve the release, link and generic package endpoints of
+// group/sub/app.
+var gitlabRoutes = forgeRoutes{
+	authHeader: "PRIVATE-TOKEN",
+	authValue:  "tok",
+	authError:  "401 Unauthorized",
+	tagPrefix:  gitlabProject + "repository/tags/",
+	serve: func(f *fakeForge, w http.ResponseWriter, r *http.Request, path string) bool {
+		const packages = gitlabProject + "packages/generic/app/v1.0.0/"
+		links := gitlabProject + "releases/v1.0.0/assets/links"
+		switch {
+		case r.Method == http.MethodGet && path == gitlabProject+"releases/v1.0.0":
+			if f.release == nil {
+				notFound(w)
+				return true
+			}
+			writeJSON(w, http.StatusOK, gitlabRelease(f))
+		case r.Method == http.MethodPost && path == gitlabProject+"releases":
+			var in gitlab.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release = &fakeRelease{ID: 1, Tag: in.TagName, Title: in.Name, Notes: in.Description}
+			f.target = in.Ref
+			writeJSON(w, http.StatusCreated, gitlabRelease(f))
+		case r.Method == http.MethodPut && path == gitlabProject+"releases/v1.0.0":
+			var in gitlab.ReleaseInput
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			f.release.Title, f.release.Notes = in.Name, in.Description
+			f.target = in.Ref
+			writeJSON(w, http.StatusOK, gitlabRelease(f))
+		case r.Method == http.MethodPut && strings.HasPrefix(path, packages):
+			data, _ := io.ReadAll(r.Body)
+			f.uploads[strings.TrimPrefix(path, packages)] = string(data)
+			writeJSON(w, http.StatusCreated, map[string]string{"message": "201 Created"})
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, links+"/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, links+"/"), 10, 64)
+			f.deleteAsset(id)
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodPost && path == links:
+			var l gitlab.Link
+			_ = json.NewDecoder(r.Body).Decode(&l)
+			if slices.ContainsFunc(f.release.Assets, func(a fakeAsset) bool { return a.Name == l.Name }) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"message":{"name":["has already been taken"]}}`)
+				return true
+			}
+			a := f.addAsset(fakeAsset{Name: l.Name, URL: l.URL, LinkType: l.LinkType})
+			l.ID = a.ID
+			writeJSON(w, http.StatusCreated, l)
+		default:
+			return false
+		}
+		return true
+	},
+}
+
+// gitlabRelease renders the fake's release as the API returns it.
+func gitlabRelease(f *fakeForge) gitlab.Release {
+	rel := gitlab.Release{TagName: f.release.Tag, Name: f.release.Title, Description: f.release.Notes}
+	rel.Links.Self = "https://gitlab.test/group/sub/app/-/releases/" + f.release.Tag
+	for _, a := range f.release.Assets {
+		rel.Assets.Links = append(rel.Assets.Links, gitlab.Link{ID: a.ID, Name: a.Name, URL: a.URL, LinkType: a.LinkType})
+	}
+	return rel
+}
+
+var gitlabHost = forgeHost{
+	name:       "gitlab",
+	routes:     gitlabRoutes,
+	repository: "group/sub/app",
+	newHost:    func(baseURL, token string) Host { return GitLab{Client: gitlab.NewClient(baseURL, token)} },
+	url:        "https://gitlab.test/group/sub/app/-/releases/v1.0.0",
+	tagCall:    "GET " + gitlabProject + "repository/tags/v1.0.0",
+	createCall: "POST " + gitlabProject + "releases",
+	updateCall: "PUT " + gitlabProject + "releases/v1.0.0",
+	uploadCall: "PUT " + gitlabProject + "packages/generic/app/v1.0.0/app.tar.gz",
+	deleteCall: func(id int64) string {
+		return "DELETE " + gitlabProject + "releases/v1.0.0/assets/links/" + strconv.FormatInt(id, 10)
+	},
+	dropsTargetOnUpdate: true,
+	tokenErr:            "GITLAB_TOKEN is not set",
+	authErr:             "GitLab API returned 401: 401 Unauthorized",
+}
+
+// TestGitLabReplaceLink checks what only GitLab does: assets are package
+// uploads linked from the release, and a same-named link is only
+// deleted once its replacement file is uploaded.
+func TestGitLabReplaceLink(t *testing.T) {
+	f := newFakeForge(t, gitlabRoutes)
+	f.release = &fakeRelease{ID: 1, Tag: "v1.0.0", Assets: []fakeAsset{{ID: 7, Name: "app.tar.gz"}}}
+	spec := testSpec(t, "group/sub/app")
+	if _, err := gitlabHost.newHost(f.srv.URL, "tok").CreateRelease(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range f.release.Assets {
+		if a.LinkType != "package" || a.URL != f.srv.URL+gitlabProject+"packages/generic/app/v1.0.0/"+a.Name {
+			t.Errorf("link %s = %+v, want a package link to the uploaded file", a.Name, a)
+		}
+	}
+	upload := slices.Index(f.calls, gitlabHost.uploadCall)
+	if del := slices.Index(f.calls, gitlabHost.deleteCall(7)); del < 0 || del < upload {
+		t.Errorf("calls = %v, want the old link deleted after the new file was uploaded", f.calls)
+	}
+}
+
+func TestGitLabAPIErrorMessages(t *testing.T) {
+	f := newFakeForge(t, gitlabRoutes)
+	f.release = &fakeRelease{ID: 1, Tag: "v1.0.0"}
+	c := gitlab.NewClient(f.srv.URL, "tok")
+	_, err := c.CreateLink(context.Background(), "group/sub/app", "v1.0.0", gitlab.Link{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.CreateLink(context.Background(), "group/sub/app", "v1.0.0", gitlab.Link{Name: "a"})
+	if err == nil || !strings.Contains(err.Error(), `GitLab API returned 400: {"name":["has already been taken"]}`) {
+		t.Errorf("err = %v, want the validation map as the message", err)
+	}
+}
@@ -0,0 +1,127 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Spec is one hosted release to create: the tag it points at, its title
+// and notes, and the asset files to attach.
+type Spec struct {
+	Workspace  string
+	Repository string
+	Tag        string
+	Title      string
+	Notes      string
+	Assets     []string
+	// VerifyTag requires the tag to exist on the host already (it was
+	// pushed first). Without it the host may create the tag itself, at
+	// Target or, when Target is empty, at its default branch.
+	VerifyTag bool
+	Target    string
+	// Progress, when set, receives each asset's upload progress (base
+	// name, bytes sent, size). Hosts that cannot report progress call it
+	// once per asset when it is done.
+	Progress func(asset string, sent, total int64)
+}
+
+// Host publishes releases on a code-hosting service.
+type Host interface {
+	// Name identifies the host in plans and results, e.g. "github".
+	Name() string
+	// Describe renders what CreateRelease would run for spec, for dry
+	// runs and logs. It never includes credentials.
+	Describe(spec Spec) string
+	// CreateRelease creates the hosted release and returns its URL
+	// ("" when the host does not report one).
+	CreateRelease(ctx context.Context, spec Spec) (string, error)
+}
+
+// GhCLI creates GitHub releases through the `gh` command-line tool.
+// Token is passed as GH_TOKEN when set; otherwise gh uses its own login.
+type GhCLI struct {
+	Token string
+}
+
+func (GhCLI) Name() string { return "github" }
+
+func (GhCLI) args(spec Spec, notesFile string) []string {
+	args := []string{"release", "create", spec.Tag, "--title", spec.Title, "--notes-file", notesFile}
+	if spec.Repository != "" {
+		args = append(args, "--repo", spec.Repository)
+	}
+	if spec.VerifyTag {
+		args = append(args, "--verify-tag")
+	} else if spec.Target != "" {
+		args = append(args, "--target", spec.Target)
+	}
+	return append(args, spec.Assets...)
+}
+
+func (g GhCLI) Describe(spec Spec) string {
+	return "gh " + shellJoin(g.args(spec, "NOTES_FILE"))
+}
+
+// shellJoin joins args for display, quoting the ones with spaces.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, " \t\n\"'") {
+			a = strconv.Quote(a)
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (g GhCLI) CreateRelease(ctx context.Context, spec Spec) (string, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("the GitHub CLI (gh) is not available on the system")
+	}
+	notes, err := os.CreateTemp("", "release-notes-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file for release notes: %w", err)
+	}
+	defer func() {
+		notes.Close()
+		os.Remove(notes.Name())
+	}()
+	if _, err := notes.WriteString(spec.Notes); err != nil {
+		return "", fmt.Errorf("failed to write release notes to temporary file: %w", err)
+	}
+	notes.Sync()
+
+	cmd := exec.CommandContext(ctx, "gh", g.args(spec, notes.Name())...)
+	cmd.Dir = spec.Workspace
+	cmd.Env = os.Environ()
+	if g.Token != "" {
+		cmd.Env = append(cmd.Env, "GH_TOKEN="+g.Token)
+	}
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf(
+			"error running command: stdout: %s, stderr: %s, err: %w",
+			outb.String(),
+			errb.String(),
+			err,
+		)
+	}
+	if spec.Progress != nil {
+		for _, a := range spec.Assets {
+			if info, err := os.Stat(a); err == nil {
+				spec.Progress(filepath.Base(a), info.Size(), info.Size())
+			}
+		}
+	}
+	// gh prints the new release's URL as its last line.
+	lines := strings.Split(strings.TrimSpace(outb.String()), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
@@ -0,0 +1,484 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"commit_craft_reborn/internal/config"
+)
+
+// ManifestName is the checksum manifest uploaded with the assets, in
+// the `sha256sum` format.
+const ManifestName = "SHA256SUMS"
+
+// Packaging is the asset preparation step between collecting the files
+// and uploading them, resolved from [release_config]. Project and
+// Version name the archives: <project>_<version>_<os>_<arch>.<ext>.
+type Packaging struct {
+	Archive       string `json:"archive,omitempty"`
+	Checksums     bool   `json:"checksums,omitempty"`
+	ChecksumTable bool   `json:"checksum_table,omitempty"`
+	Sign          string `json:"sign,omitempty"`
+	SignKey       string `json:"sign_key,omitempty"`
+	Project       string `json:"project"`
+	Version       string `json:"version"`
+}
+
+// NewPackaging reads the packaging settings of cfg for a release of
+// project at version. It returns nil when every step is off, so callers
+// upload the collected files untouched.
+func NewPackaging(cfg config.ReleaseConfig, project, version string) *Packaging {
+	p := &Packaging{
+		Archive:       cfg.Archive,
+		Checksums:     cfg.Checksums || cfg.Sign != "",
+		ChecksumTable: cfg.ChecksumTable,
+		Sign:          cfg.Sign,
+		SignKey:       cfg.SignKey,
+		Project:       project,
+		Version:       version,
+	}
+	if p.Archive == "" && !p.Checksums && !p.ChecksumTable {
+		return nil
+	}
+	return p
+}
+
+// Checksum is one asset's SHA-256 sum.
+type Checksum struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Prepared is the result of Prepare: the files to upload (archives,
+// passthrough files, the manifest and its signature) in a temporary
+// directory that Cleanup removes.
+type Prepared struct {
+	Dir       string
+	Assets    []string
+	Checksums []Checksum
+}
+
+// Cleanup removes the temporary directory.
+func (p *Prepared) Cleanup() {
+	if p != nil && p.Dir != "" {
+		os.RemoveAll(p.Dir)
+	}
+}
+
+// bundle is one upload: an archive of files, or a single file passed
+// through untouched (archive == "").
+type bundle struct {
+	name    string
+	archive string
+	files   []string
+}
+
+// Uploads lists the names Prepare would upload for files, without
+// touching disk, for dry runs.
+func (p Packaging) Uploads(files []string) []string {
+	var names []string
+	for _, b := range p.bundles(files) {
+		names = append(names, b.name)
+	}
+	if p.Checksums {
+		names = append(names, ManifestName)
+		if sig := p.signatureName(); sig != "" {
+			names = append(names, sig)
+		}
+	}
+	return names
+}
+
+// Describe renders the packaging step for plans and logs.
+func (p Packaging) Describe(files []string) string {
+	var parts []string
+	if p.Archive != "" {
+		parts = append(parts, fmt.Sprintf("pack binaries per OS/arch (archive=%s)", p.Archive))
+	}
+	if p.Checksums {
+		parts = append(parts, "write "+ManifestName)
+	}
+	if p.Sign != "" {
+		parts = append(parts, fmt.Sprintf("sign it with %s (%s)", p.Sign, p.SignKey))
+	}
+	if p.ChecksumTable {
+		parts = append(parts, "add a checksum table to the notes")
+	}
+	return fmt.Sprintf("prepare %d asset(s): %s", len(files), strings.Join(parts, ", "))
+}
+
+// Prepare packs, checksums and signs files into a new temporary
+// directory. Files that are already archives, and files without an
+// OS/arch in their name, are copied through as they are.
+func (p Packaging) Prepare(files []string) (*Prepared, error) {
+	dir, err := os.MkdirTemp("", "commitcraft-release-*")
+	if err != nil {
+		return nil, err
+	}
+	out := &Prepared{Dir: dir}
+	fail := func(err error) (*Prepared, error) {
+		out.Cleanup()
+		return nil, err
+	}
+	for _, b := range p.bundles(files) {
+		dst := filepath.Join(dir, b.name)
+		switch b.archive {
+		case config.ReleaseArchiveZip:
+			err = writeZip(dst, b.files)
+		case config.ReleaseArchiveTarGz:
+			err = writeTarGz(dst, b.files)
+		default:
+			err = copyFile(dst, b.files[0])
+		}
+		if err != nil {
+			return fail(fmt.Errorf("package %s: %w", b.name, err))
+		}
+		out.Assets = append(out.Assets, dst)
+	}
+	if !p.Checksums && !p.ChecksumTable {
+		return out, nil
+	}
+	var manifest strings.Builder
+	for _, a := range out.Assets {
+		sum, err := sha256File(a)
+		if err != nil {
+			return fail(err)
+		}
+		name := filepath.Base(a)
+		out.Checksums = append(out.Checksums, Checksum{Name: name, SHA256: sum})
+		fmt.Fprintf(&manifest, "%s  %s\n", sum, name)
+	}
+	if !p.Checksums {
+		return out, nil
+	}
+	manifestPath := filepath.Join(dir, ManifestName)
+	if err := os.WriteFile(manifestPath, []byte(manifest.String()), 0o644); err != nil {
+		return fail(err)
+	}
+	out.Assets = append(out.Assets, manifestPath)
+	if p.Sign != "" {
+		sig, err := p.sign(manifestPath)
+		if err != nil {
+			return fail(err)
+		}
+		out.Assets = append(out.Assets, sig)
+	}
+	return out, nil
+}
+
+// ChecksumTable renders checksums as a Markdown table for release notes.
+func ChecksumTable(checksums []Checksum) string {
+	var b strings.Builder
+	b.WriteString("### Checksums (SHA-256)\n\n| File | SHA-256 |\n| --- | --- |\n")
+	for _, c := range checksums {
+		fmt.Fprintf(&b, "| `%s` | `%s` |\n", c.Name, c.SHA256)
+	}
+	return b.String()
+}
+
+// PrepareSpec runs pkg over spec's assets and returns spec pointing at
+// the prepared files, with the checksum table appended to its notes
+// when enabled. A nil pkg returns spec unchanged. The caller must
+// Cleanup the returned Prepared once the upload is done.
+func PrepareSpec(spec Spec, pkg *Packaging) (Spec, *Prepared, error) {
+	if pkg == nil || len(spec.Assets) == 0 {
+		return spec, nil, nil
+	}
+	prepared, err := pkg.Prepare(spec.Assets)
+	if err != nil {
+		return spec, nil, err
+	}
+	spec.Assets = prepared.Assets
+	if pkg.ChecksumTable && len(prepared.Checksums) > 0 {
+		spec.Notes = strings.TrimRight(spec.Notes, "\n") + "\n\n" + ChecksumTable(prepared.Checksums)
+	}
+	return spec, prepared, nil
+}
+
+// bundles groups files by the OS/arch in their names, one archive per
+// pair. Without Archive every file is its own passthrough bundle.
+func (p Packaging) bundles(files []string) []bundle {
+	var out []bundle
+	index := map[string]int{}
+	for _, f := range files {
+		base := filepath.Base(f)
+		goos, goarch := platformOf(base)
+		if p.Archive == "" || goos == "" || isArchive(base) {
+			out = append(out, bundle{name: base, files: []string{f}})
+			continue
+		}
+		key := goos + "_" + goarch
+		if i, ok := index[key]; ok {
+			out[i].files = append(out[i].files, f)
+			continue
+		}
+		format := p.Archive
+		if format == config.ReleaseArchiveAuto {
+			format = config.ReleaseArchiveTarGz
+			if goos == "windows" {
+				format = config.ReleaseArchiveZip
+			}
+		}
+		index[key] = len(out)
+		out = append(out, bundle{
+			name:    fmt.Sprintf("%s_%s_%s.%s", p.Project, p.Version, key, format),
+			archive: format,
+			files:   []string{f},
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+func (p Packaging) signatureName() string {
+	switch p.Sign {
+	case config.ReleaseSignMinisign:
+		return ManifestName + ".minisig"
+	case config.ReleaseSignSSH:
+		return ManifestName + ".sig"
+	}
+	return ""
+}
+
+// sign writes the detached signature of manifest next to it and
+// returns its path.
+func (p Packaging) sign(manifest string) (string, error) {
+	if p.SignKey == "" {
+		return "", fmt.Errorf("release_config.sign_key is required to sign with %s", p.Sign)
+	}
+	key := expandHome(p.SignKey)
+	sig := filepath.Join(filepath.Dir(manifest), p.signatureName())
+	var cmd *exec.Cmd
+	switch p.Sign {
+	case config.ReleaseSignMinisign:
+		cmd = exec.Command("minisign", "-S", "-s", key, "-m", manifest, "-x", sig)
+	case config.ReleaseSignSSH:
+		// ssh-keygen writes <manifest>.sig itself.
+		cmd = exec.Command("ssh-keygen", "-Y", "sign", "-f", key, "-n", "file", manifest)
+	default:
+		return "", fmt.Errorf("release_config.sign=%q is not supported (minisign or ssh)", p.Sign)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("sign %s with %s: %s: %w", ManifestName, p.Sign, strings.TrimSpace(string(out)), err)
+	}
+	return sig, nil
+}
+
+var (
+	goosNames = map[string]string{
+		"linux": "linux", "darwin": "darwin", "macos": "darwin", "windows": "windows",
+		"freebsd": "freebsd", "openbsd": "openbsd", "netbsd": "netbsd",
+		"dragonfly": "dragonfly", "solaris": "solaris", "illumos": "illumos",
+		"aix": "aix", "android": "android", "plan9": "plan9",
+	}
+	// The arm variants keep their version: each is its own build
+	// (GOARM), so they must not share an archive.
+	goarchNames = map[string]string{
+		"amd64": "amd64", "x86_64": "amd64", "x86-64": "amd64", "arm64": "arm64", "aarch64": "arm64",
+		"386": "386", "i386": "386", "arm": "arm", "armv5": "armv5", "armv6": "armv6", "armv7": "armv7",
+		"ppc64": "ppc64", "ppc64le": "ppc64le", "s390x": "s390x", "riscv64": "riscv64",
+		"mips": "mips", "mipsle": "mipsle", "mips64": "mips64", "mips64le": "mips64le",
+		"loong64": "loong64",
+	}
+	nameSeparators = regexp.MustCompile(`[-_.]+`)
+	// archPhrases matches the goarchNames keys that contain a separator
+	// (x86_64) as whole words of the lower-cased name, since splitting
+	// would cut them in two.
+	archPhrases = phrasePattern(goarchNames)
+)
+
+// phrasePattern builds one regexp matching any key of names that
+// contains a separator, bounded by separators or the ends of the name.
+// The first group is the key.
+func phrasePattern(names map[string]string) *regexp.Regexp {
+	var keys []string
+	for k := range names {
+		if nameSeparators.MatchString(k) {
+			keys = append(keys, regexp.QuoteMeta(k))
+		}
+	}
+	// Longest first, so a phrase never loses to one of its prefixes.
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return regexp.MustCompile(`(?:^|[-_.])(` + strings.Join(keys, "|") + `)(?:$|[-_.])`)
+}
+
+// platformOf finds the GOOS and GOARCH in a file name such as
+// app-linux-amd64 or app_windows_x86_64.exe. goos is "" when the name
+// has no OS; goarch defaults to "all" when only the OS is present.
+func platformOf(name string) (goos, goarch string) {
+	lower := strings.ToLower(name)
+	if m := archPhrases.FindStringSubmatch(lower); m != nil {
+		goarch = goarchNames[m[1]]
+	}
+	for _, tok := range nameSeparators.Split(lower, -1) {
+		if v, ok := goosNames[tok]; ok && goos == "" {
+			goos = v
+		}
+		if v, ok := goarchNames[tok]; ok && goarch == "" {
+			goarch = v
+		}
+	}
+	if goos != "" && goarch == "" {
+		goarch = "all"
+	}
+	return goos, goarch
+}
+
+func isArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip", ".tar.xz", ".tar.zst"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTarGz(dst string, files []string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, src := range files {
+		if err := addToTar(tw, src); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addToTar(tw *tar.Writer, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, in)
+	return err
+}
+
+func writeZip(dst string, files []string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, src := range files {
+		if err := addToZip(zw, src); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addToZip(zw *zip.Writer, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Method = zip.Deflate
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func sha256File(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
+
+// ProjectName is the archive name prefix: the repository's own name,
+// else the workspace directory's.
+func ProjectName(repository, workspace string) string {
+	if repository != "" {
+		return path.Base(repository)
+	}
+	return filepath.Base(workspace)
+}
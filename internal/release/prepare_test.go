@@ -0,0 +1,57 @@
+package release
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlatformOf(t *testing.T) {
+	cases := []struct {
+		name, goos, goarch string
+	}{
+		{"app-linux-amd64", "linux", "amd64"},
+		{"app_windows_x86_64.exe", "windows", "amd64"},
+		{"App-Darwin-X86_64.tar.gz", "darwin", "amd64"},
+		{"x86_64-linux-app", "linux", "amd64"},
+		{"app-linux-x86-64", "linux", "amd64"},
+		{"app_linux_aarch64", "linux", "arm64"},
+		{"app-linux-i386", "linux", "386"},
+		{"app-linux-arm", "linux", "arm"},
+		{"app-linux-armv6", "linux", "armv6"},
+		{"app-linux-armv7", "linux", "armv7"},
+		{"app-linux", "linux", "all"},
+		{"app-amd64", "", "amd64"},
+		{"app_x86_64_extra", "", "amd64"},
+		{"notes.txt", "", ""},
+		{"appx86_64-linux", "linux", "all"},
+	}
+	for _, c := range cases {
+		goos, goarch := platformOf(c.name)
+		if goos != c.goos || goarch != c.goarch {
+			t.Errorf("platformOf(%q) = %q, %q; want %q, %q", c.name, goos, goarch, c.goos, c.goarch)
+		}
+	}
+}
+
+func TestBundlesKeepPlatformsApart(t *testing.T) {
+	p := Packaging{Archive: "auto", Project: "app", Version: "v1.0.0"}
+	files := []string{
+		"dist/app-linux-amd64", "dist/app-linux-x86_64.sig",
+		"dist/app-linux-armv6", "dist/app-linux-armv7",
+		"dist/app-windows-amd64.exe", "dist/notes.txt",
+	}
+	var got []string
+	for _, b := range p.bundles(files) {
+		got = append(got, b.name)
+	}
+	want := []string{
+		"app_v1.0.0_linux_amd64.tar.gz",
+		"app_v1.0.0_linux_armv6.tar.gz",
+		"app_v1.0.0_linux_armv7.tar.gz",
+		"app_v1.0.0_windows_amd64.zip",
+		"notes.txt",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("bundles = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,190 @@
+// Package release publishes stored releases: it tags the release commit,
+// pushes the tag when asked to and creates the hosted release with the
+// stored notes and the configured assets. It has no UI; `commitcraft ai
+// release publish` and the TUI's upload step both drive it.
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// Options overrides what NewPlan would otherwise take from the stored
+// release and [release_config]. Empty fields keep the defaults: the
+// release's version (or release_config.version) as the tag, HEAD as the
+// target, "origin" as the remote and release_config.repository (else
+// the origin URL's path) as the hosted repository. Push is the only
+// way CommitCraft runs `git push`: without it the tag must already be
+// on the remote.
+type Options struct {
+	Workspace  string
+	Tag        string
+	Target     string
+	Remote     string
+	Repository string
+	Push       bool
+}
+
+// Plan is everything Publish will do for one release, resolved up front
+// so a dry run can print it and a real run cannot drift from it.
+// TagCommit is the commit the tag points at: the existing tag's commit
+// when TagExists, otherwise the resolved target. Packaging is nil when
+// the assets are uploaded as collected; otherwise Uploads names the
+// files the host receives instead.
+type Plan struct {
+	ReleaseID  int        `json:"release_id"`
+	Workspace  string     `json:"workspace"`
+	Host       string     `json:"host"`
+	Repository string     `json:"repository,omitempty"`
+	Remote     string     `json:"remote"`
+	Push       bool       `json:"push"`
+	Tag        string     `json:"tag"`
+	TagExists  bool       `json:"tag_exists"`
+	TagCommit  string     `json:"tag_commit"`
+	Title      string     `json:"title"`
+	Notes      string     `json:"notes"`
+	Assets     []string   `json:"assets"`
+	Packaging  *Packaging `json:"packaging,omitempty"`
+	Uploads    []string   `json:"uploads,omitempty"`
+	Steps      []string   `json:"steps"`
+}
+
+// Result reports a finished publish. URL is the hosted release's page
+// when the host returns one.
+type Result struct {
+	TagCreated bool   `json:"tag_created"`
+	URL        string `json:"url,omitempty"`
+}
+
+// NewPlan resolves the tag, its commit and the assets for publishing r
+// on host. It fails when there is no tag name, the target does not
+// resolve or r has no notes to publish.
+func NewPlan(r storage.Release, cfg config.ReleaseConfig, host Host, opts Options) (Plan, error) {
+	ws := opts.Workspace
+	if ws == "" {
+		ws = r.Workspace
+	}
+	if strings.TrimSpace(r.Title) == "" && strings.TrimSpace(r.Body) == "" {
+		return Plan{}, fmt.Errorf("release id=%d has no title or body to publish", r.ID)
+	}
+	tag := firstNonEmpty(opts.Tag, r.Version, cfg.Version)
+	if tag == "" {
+		return Plan{}, fmt.Errorf("release id=%d has no version; pass a tag explicitly", r.ID)
+	}
+	origin, _ := OriginRemote(ws)
+	p := Plan{
+		ReleaseID:  r.ID,
+		Workspace:  ws,
+		Host:       host.Name(),
+		Repository: firstNonEmpty(opts.Repository, cfg.Repository, origin.Repository),
+		Remote:     firstNonEmpty(opts.Remote, "origin"),
+		Push:       opts.Push,
+		Tag:        tag,
+		Title:      fmt.Sprintf("Release %s: %s", tag, r.Title),
+		Notes:      r.Body,
+	}
+	existing, err := git.TagCommitAt(ws, tag)
+	if err != nil {
+		return Plan{}, err
+	}
+	if existing != "" {
+		p.TagExists = true
+		p.TagCommit = existing
+	} else {
+		target := firstNonEmpty(opts.Target, "HEAD")
+		if p.TagCommit, err = git.ResolveCommitHashAt(ws, target); err != nil {
+			return Plan{}, err
+		}
+	}
+	if p.Assets, err = CollectAssets(ws, cfg.BinaryAssetsPath); err != nil {
+		return Plan{}, fmt.Errorf("collect assets: %w", err)
+	}
+
+	if len(p.Assets) > 0 {
+		p.Packaging = NewPackaging(cfg, ProjectName(p.Repository, ws), tag)
+	}
+	if p.Packaging != nil {
+		p.Uploads = p.Packaging.Uploads(p.Assets)
+		p.Steps = append(p.Steps, p.Packaging.Describe(p.Assets))
+	}
+	if p.TagExists {
+		p.Steps = append(p.Steps, fmt.Sprintf("keep existing tag %s at %s", tag, short(p.TagCommit)))
+	} else {
+		p.Steps = append(p.Steps, fmt.Sprintf("git tag --annotate %s %s", tag, short(p.TagCommit)))
+	}
+	if p.Push {
+		p.Steps = append(p.Steps, fmt.Sprintf("git push %s refs/tags/%s", p.Remote, tag))
+	} else {
+		p.Steps = append(p.Steps, fmt.Sprintf("expect refs/tags/%s on %s (not pushed)", tag, p.Remote))
+	}
+	p.Steps = append(p.Steps, host.Describe(p.spec()))
+	return p, nil
+}
+
+func (p Plan) spec() Spec {
+	return Spec{
+		Workspace:  p.Workspace,
+		Repository: p.Repository,
+		Tag:        p.Tag,
+		Title:      p.Title,
+		Notes:      p.Notes,
+		Assets:     p.Assets,
+		VerifyTag:  true,
+	}
+}
+
+// Publish runs the plan: prepare the assets, create the tag unless it
+// exists, push it when the plan says so, and create the hosted release.
+// Without Push the host checks the tag is already there. The assets are
+// prepared first so a packaging or signing failure leaves git
+// untouched. Steps already done by an earlier, failed run (tag created
+// or pushed) are no-ops, so a failed publish can simply be retried.
+func Publish(ctx context.Context, p Plan, host Host) (Result, error) {
+	var res Result
+	spec, prepared, err := PrepareSpec(p.spec(), p.Packaging)
+	if err != nil {
+		return res, fmt.Errorf("prepare assets: %w", err)
+	}
+	defer prepared.Cleanup()
+	if !p.TagExists {
+		if err := git.CreateTagAt(p.Workspace, p.Tag, p.TagCommit, p.Title); err != nil {
+			return res, fmt.Errorf("create tag: %w", err)
+		}
+		res.TagCreated = true
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	if p.Push {
+		if err := git.PushTagAt(p.Workspace, p.Remote, p.Tag); err != nil {
+			return res, fmt.Errorf("push tag: %w", err)
+		}
+	}
+	url, err := host.CreateRelease(ctx, spec)
+	if err != nil {
+		return res, fmt.Errorf("create %s release: %w", host.Name(), err)
+	}
+	res.URL = url
+	return res, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,165 @@
+package scope
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"commit_craft_reborn/internal/git"
+)
+
+// File is one staged path (relative to the repository root) with its
+// changed line count; binary files count as one line.
+type File struct {
+	Path    string
+	Changes int
+}
+
+// Candidate is one package touched by the staged files.
+type Candidate struct {
+	Scope   string `json:"scope"`
+	Kind    string `json:"kind"`
+	Dir     string `json:"dir"`
+	Files   int    `json:"files"`
+	Changes int    `json:"changes"`
+}
+
+// Inference is the proposed scope for a set of files. Scope is the
+// candidate with the most changed lines; Multi flags commits spanning
+// more than one package, which usually want `ai split`. Candidates is
+// sorted by changed lines, ties by file count then scope.
+type Inference struct {
+	Scope      string      `json:"scope"`
+	Kind       string      `json:"kind"`
+	Multi      bool        `json:"multi_package"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Summary is a one-line description for status bars and warnings.
+func (inf Inference) Summary() string {
+	if inf.Scope == "" {
+		return "no staged files"
+	}
+	if !inf.Multi {
+		return fmt.Sprintf("%s (%s)", inf.Scope, inf.Kind)
+	}
+	names := make([]string, len(inf.Candidates))
+	for i, c := range inf.Candidates {
+		names[i] = c.Scope
+	}
+	return fmt.Sprintf("%s · %d packages: %s", inf.Scope, len(names), strings.Join(names, ", "))
+}
+
+// supportFiles are manifests and lockfiles: they follow the code they
+// belong to and only pick the scope of a commit that has nothing else.
+var supportFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.toml": true, "Cargo.lock": true, "CHANGELOG.md": true,
+}
+
+// isSupport reports whether file only accompanies a change: manifests,
+// lockfiles and documentation.
+func isSupport(file string) bool {
+	base := path.Base(file)
+	return supportFiles[base] || strings.EqualFold(path.Ext(base), ".md") ||
+		strings.HasPrefix(file, "docs/")
+}
+
+// candidateFor attributes file to a package: the scope map first, then
+// the deepest manifest package (refined to the Go package directory for
+// Go modules), then the directory fallback.
+func (l *Layout) candidateFor(file string) Candidate {
+	for _, glob := range l.mapGlobs {
+		if git.MatchGlob(glob, file) {
+			return Candidate{Scope: l.scopeMap[glob], Kind: KindMap, Dir: glob}
+		}
+	}
+	if p, ok := l.owner(file); ok {
+		c := Candidate{Scope: p.Name, Kind: p.Kind, Dir: p.Dir}
+		if p.Kind == KindGo {
+			if dir := l.goPackageDir(file, p.Dir); dir != p.Dir {
+				c.Scope, c.Dir = path.Base(dir), dir
+			}
+		}
+		return c
+	}
+	dir := path.Dir(file)
+	if dir == "." {
+		base := path.Base(file)
+		return Candidate{Scope: strings.TrimSuffix(base, path.Ext(base)), Kind: KindDirectory}
+	}
+	segs := strings.Split(dir, "/")
+	if ContainerDirs[segs[0]] && len(segs) > 1 {
+		return Candidate{Scope: segs[1], Kind: KindDirectory, Dir: segs[0] + "/" + segs[1]}
+	}
+	return Candidate{Scope: segs[0], Kind: KindDirectory, Dir: segs[0]}
+}
+
+// Infer groups files by package and proposes the scope. Support files
+// (manifests, lockfiles, docs) are left out unless nothing else is
+// staged.
+func (l *Layout) Infer(files []File) Inference {
+	var code []File
+	for _, f := range files {
+		if !isSupport(f.Path) {
+			code = append(code, f)
+		}
+	}
+	if len(code) == 0 {
+		code = files
+	}
+	byKey := map[string]*Candidate{}
+	var cands []*Candidate
+	for _, f := range code {
+		c := l.candidateFor(f.Path)
+		key := c.Kind + "\x00" + c.Dir + "\x00" + c.Scope
+		agg, ok := byKey[key]
+		if !ok {
+			agg = &c
+			byKey[key] = agg
+			cands = append(cands, agg)
+		}
+		agg.Files++
+		agg.Changes += max(f.Changes, 1)
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return a.Scope < b.Scope
+	})
+	inf := Inference{Candidates: make([]Candidate, len(cands))}
+	for i, c := range cands {
+		inf.Candidates[i] = *c
+	}
+	if len(cands) > 0 {
+		inf.Scope, inf.Kind = cands[0].Scope, cands[0].Kind
+		inf.Multi = len(cands) > 1
+	}
+	return inf
+}
+
+// Staged infers the scope of the changes staged in the repository
+// containing dir (cwd when empty).
+func Staged(dir string, opts Options) (Inference, error) {
+	id, err := git.ResolveWorkspace(dir)
+	if err != nil {
+		return Inference{}, err
+	}
+	numstat, err := git.StagedNumstatAt(id.Worktree)
+	if err != nil {
+		return Inference{}, err
+	}
+	files := make([]File, 0, len(numstat))
+	for p, ns := range numstat {
+		files = append(files, File{Path: p, Changes: max(ns.Adds, 0) + max(ns.Dels, 0)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return LoadLayout(id.Worktree, opts).Infer(files), nil
+}
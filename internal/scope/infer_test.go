@@ -0,0 +1,207 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files (slash-separated path → content) under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	goModule := map[string]string{
+		"go.mod":                  "module example.com/app\n\ngo 1.25\n",
+		"internal/git/git.go":     "package git\n",
+		"internal/tui/view.go":    "package tui\n",
+		"internal/tui/sub/sub.go": "package sub\n",
+	}
+	tests := []struct {
+		name      string
+		tree      map[string]string
+		opts      Options
+		files     []File
+		wantScope string
+		wantKind  string
+		wantMulti bool
+	}{
+		{
+			name:      "go package directory",
+			tree:      goModule,
+			opts:      Options{Manifests: true},
+			files:     []File{{"internal/git/git.go", 10}},
+			wantScope: "git", wantKind: KindGo,
+		},
+		{
+			name: "deleted go directory walks up to the package",
+			tree: goModule,
+			opts: Options{Manifests: true},
+			files: []File{
+				{"internal/tui/gone/old.go", 4},
+				{"internal/tui/view.go", 2},
+			},
+			wantScope: "tui", wantKind: KindGo,
+		},
+		{
+			name:      "go module root file",
+			tree:      goModule,
+			opts:      Options{Manifests: true},
+			files:     []File{{"main.go", 3}},
+			wantScope: "app", wantKind: KindGo,
+		},
+		{
+			name: "go.work module without the major version",
+			tree: map[string]string{
+				"go.work":            "go 1.25\n\nuse (\n\t./svc/api // the API\n\t./tools\n)\n",
+				"svc/api/go.mod":     "module example.com/api/v2\n",
+				"svc/api/handler.go": "package api\n",
+				"tools/go.mod":       "module example.com/tools\n",
+			},
+			opts:      Options{Manifests: true},
+			files:     []File{{"svc/api/handler.go", 5}},
+			wantScope: "api", wantKind: KindGo,
+		},
+		{
+			name: "npm workspace drops the organisation",
+			tree: map[string]string{
+				"package.json":              `{"name": "root", "workspaces": ["packages/*"]}`,
+				"packages/web/package.json": `{"name": "@acme/web-ui"}`,
+			},
+			opts:      Options{Manifests: true},
+			files:     []File{{"packages/web/src/App.tsx", 12}},
+			wantScope: "web-ui", wantKind: KindNPM,
+		},
+		{
+			name: "yarn workspaces object",
+			tree: map[string]string{
+				"package.json":            `{"workspaces": {"packages": ["apps/*"]}}`,
+				"apps/admin/package.json": `{"name": "admin"}`,
+			},
+			opts:      Options{Manifests: true},
+			files:     []File{{"apps/admin/index.js", 1}},
+			wantScope: "admin", wantKind: KindNPM,
+		},
+		{
+			name: "cargo workspace member",
+			tree: map[string]string{
+				"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/old\"]\n",
+				"crates/core/Cargo.toml": "[package]\nname = \"core-lib\"\n",
+				"crates/old/Cargo.toml":  "[package]\nname = \"old\"\n",
+			},
+			opts:      Options{Manifests: true},
+			files:     []File{{"crates/core/src/lib.rs", 7}},
+			wantScope: "core-lib", wantKind: KindCargo,
+		},
+		{
+			name: "cargo exclude falls back to the directory",
+			tree: map[string]string{
+				"Cargo.toml":            "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/old\"]\n",
+				"crates/old/Cargo.toml": "[package]\nname = \"old\"\n",
+			},
+			opts:      Options{Manifests: true},
+			files:     []File{{"crates/old/src/lib.rs", 7}},
+			wantScope: "crates", wantKind: KindDirectory,
+		},
+		{
+			name: "scope map wins, longest glob first",
+			tree: goModule,
+			opts: Options{Manifests: true, Map: map[string]string{
+				"internal/**":     "core",
+				"internal/tui/**": "ui",
+			}},
+			files:     []File{{"internal/tui/view.go", 3}},
+			wantScope: "ui", wantKind: KindMap,
+		},
+		{
+			name:      "manifests off uses the directory fallback",
+			tree:      goModule,
+			opts:      Options{},
+			files:     []File{{"internal/git/git.go", 10}},
+			wantScope: "git", wantKind: KindDirectory,
+		},
+		{
+			name:      "root file without manifests",
+			tree:      nil,
+			opts:      Options{},
+			files:     []File{{"Makefile", 2}},
+			wantScope: "Makefile", wantKind: KindDirectory,
+		},
+		{
+			name: "support files follow the code",
+			tree: goModule,
+			opts: Options{Manifests: true},
+			files: []File{
+				{"go.sum", 400},
+				{"docs/usage.md", 50},
+				{"internal/git/git.go", 3},
+			},
+			wantScope: "git", wantKind: KindGo,
+		},
+		{
+			name:      "only support files",
+			tree:      nil,
+			opts:      Options{},
+			files:     []File{{"CHANGELOG.md", 20}},
+			wantScope: "CHANGELOG", wantKind: KindDirectory,
+		},
+		{
+			name: "most changed lines wins a multi-package commit",
+			tree: goModule,
+			opts: Options{Manifests: true},
+			files: []File{
+				{"internal/git/git.go", 5},
+				{"internal/tui/view.go", 30},
+				{"internal/tui/sub/sub.go", 1},
+			},
+			wantScope: "tui", wantKind: KindGo, wantMulti: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.tree)
+			inf := LoadLayout(root, tt.opts).Infer(tt.files)
+			if inf.Scope != tt.wantScope || inf.Kind != tt.wantKind || inf.Multi != tt.wantMulti {
+				t.Fatalf("Infer = %q (%s, multi %v), want %q (%s, multi %v); candidates %+v",
+					inf.Scope, inf.Kind, inf.Multi, tt.wantScope, tt.wantKind, tt.wantMulti,
+					inf.Candidates)
+			}
+		})
+	}
+}
+
+func TestInferCandidatesOrder(t *testing.T) {
+	inf := LoadLayout(t.TempDir(), Options{}).Infer([]File{
+		{"pkg/b/x.go", 2},
+		{"pkg/a/x.go", 2},
+		{"pkg/a/y.go", 0},
+		{"web/app.js", 2},
+	})
+	want := []Candidate{
+		{Scope: "a", Kind: KindDirectory, Dir: "pkg/a", Files: 2, Changes: 3},
+		{Scope: "b", Kind: KindDirectory, Dir: "pkg/b", Files: 1, Changes: 2},
+		{Scope: "web", Kind: KindDirectory, Dir: "web", Files: 1, Changes: 2},
+	}
+	if len(inf.Candidates) != len(want) {
+		t.Fatalf("candidates = %+v", inf.Candidates)
+	}
+	for i := range want {
+		if inf.Candidates[i] != want[i] {
+			t.Errorf("candidate %d = %+v, want %+v", i, inf.Candidates[i], want[i])
+		}
+	}
+	if got := inf.Summary(); got != "a · 3 packages: a, b, web" {
+		t.Errorf("Summary = %q", got)
+	}
+}
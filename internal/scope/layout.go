@@ -0,0 +1,339 @@
+// Package scope infers a commit scope from the staged files and the
+// repository's package layout: Go workspaces and modules, npm workspaces,
+// Cargo workspaces and a configured path → scope map.
+package scope
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Package kinds. KindMap and KindDirectory are not read from manifests:
+// they tag candidates picked by the scope map and by the directory
+// fallback for files outside every package.
+const (
+	KindGo        = "go"
+	KindNPM       = "npm"
+	KindCargo     = "cargo"
+	KindMap       = "map"
+	KindDirectory = "directory"
+)
+
+// ContainerDirs hold one module per child directory, so the directory
+// fallback (and the split clustering) goes one level deeper under them.
+var ContainerDirs = map[string]bool{
+	"internal": true, "pkg": true, "cmd": true, "src": true, "lib": true,
+	"apps": true, "packages": true, "services": true, "modules": true,
+}
+
+// Package is one unit of the repository layout. Dir is slash-separated
+// and relative to the repository root ("" for the root itself); Name is
+// the scope proposed for files inside it.
+type Package struct {
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+	Kind string `json:"kind"`
+}
+
+// Options configures LoadLayout. Manifests turns the manifest readers
+// on; Map maps .gitignore-style globs to a scope and wins over every
+// manifest (the longest matching glob when several match).
+type Options struct {
+	Manifests bool
+	Map       map[string]string
+}
+
+// Layout is the package layout of one repository.
+type Layout struct {
+	Root     string
+	Packages []Package
+	mapGlobs []string
+	scopeMap map[string]string
+	// goDirs caches whether a directory holds .go files.
+	goDirs map[string]bool
+}
+
+// LoadLayout reads the workspace manifests at root: go.work (or a root
+// go.mod), the `workspaces` of package.json and the `[workspace]`
+// members of Cargo.toml. A missing or malformed manifest contributes no
+// packages; it never fails the layout.
+func LoadLayout(root string, opts Options) *Layout {
+	l := &Layout{Root: root, scopeMap: opts.Map, goDirs: map[string]bool{}}
+	for glob := range opts.Map {
+		l.mapGlobs = append(l.mapGlobs, glob)
+	}
+	sort.Slice(l.mapGlobs, func(i, j int) bool {
+		if len(l.mapGlobs[i]) != len(l.mapGlobs[j]) {
+			return len(l.mapGlobs[i]) > len(l.mapGlobs[j])
+		}
+		return l.mapGlobs[i] < l.mapGlobs[j]
+	})
+	if opts.Manifests {
+		l.add(readGoWork(root)...)
+		l.add(readNPMWorkspaces(root)...)
+		l.add(readCargoWorkspace(root)...)
+	}
+	// Deepest package first, so the first Dir prefix match is the owner.
+	sort.SliceStable(l.Packages, func(i, j int) bool {
+		return len(l.Packages[i].Dir) > len(l.Packages[j].Dir)
+	})
+	return l
+}
+
+// add appends packages whose Dir is not taken yet: a directory that is
+// both a Go module and an npm package keeps the first reader's entry.
+func (l *Layout) add(pkgs ...Package) {
+	for _, p := range pkgs {
+		taken := false
+		for _, q := range l.Packages {
+			if q.Dir == p.Dir {
+				taken = true
+				break
+			}
+		}
+		if !taken && p.Name != "" {
+			l.Packages = append(l.Packages, p)
+		}
+	}
+}
+
+// owner returns the deepest package containing file.
+func (l *Layout) owner(file string) (Package, bool) {
+	for _, p := range l.Packages {
+		if p.Dir == "" || file == p.Dir || strings.HasPrefix(file, p.Dir+"/") {
+			return p, true
+		}
+	}
+	return Package{}, false
+}
+
+// goPackageDir returns the deepest directory between file's directory
+// and the module directory that holds .go files on disk; deleted
+// directories are walked past.
+func (l *Layout) goPackageDir(file, moduleDir string) string {
+	for dir := path.Dir(file); dir != "." && dir != moduleDir; dir = path.Dir(dir) {
+		has, ok := l.goDirs[dir]
+		if !ok {
+			matches, _ := filepath.Glob(filepath.Join(l.Root, filepath.FromSlash(dir), "*.go"))
+			has = len(matches) > 0
+			l.goDirs[dir] = has
+		}
+		if has {
+			return dir
+		}
+	}
+	return moduleDir
+}
+
+// relDir turns a manifest-relative directory into the layout's form.
+func relDir(dir string) string {
+	dir = path.Clean(filepath.ToSlash(dir))
+	dir = strings.TrimPrefix(dir, "./")
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// readGoWork lists the modules of go.work's `use` directives, or the
+// root module when there is no go.work.
+func readGoWork(root string) []Package {
+	f, err := os.Open(filepath.Join(root, "go.work"))
+	if err != nil {
+		if name := goModuleName(filepath.Join(root, "go.mod")); name != "" {
+			return []Package{{Name: name, Kind: KindGo}}
+		}
+		return nil
+	}
+	defer f.Close()
+	var pkgs []Package
+	inUse := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "//")
+		line = strings.TrimSpace(line)
+		var dir string
+		switch {
+		case inUse && line == ")":
+			inUse = false
+			continue
+		case inUse:
+			dir = line
+		case line == "use (":
+			inUse = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			dir = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+		dir = strings.Trim(dir, `"`)
+		if dir == "" {
+			continue
+		}
+		name := goModuleName(filepath.Join(root, filepath.FromSlash(dir), "go.mod"))
+		pkgs = append(pkgs, Package{Name: name, Dir: relDir(dir), Kind: KindGo})
+	}
+	return pkgs
+}
+
+// goModuleName reads the module path of a go.mod and returns its last
+// element, without a /vN major-version suffix.
+func goModuleName(goMod string) string {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		mod, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module ")
+		if !ok {
+			continue
+		}
+		mod = strings.Trim(strings.TrimSpace(mod), `"`)
+		base := path.Base(mod)
+		if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+			base = path.Base(path.Dir(mod))
+		}
+		return base
+	}
+	return ""
+}
+
+type packageJSON struct {
+	Name       string          `json:"name"`
+	Workspaces json.RawMessage `json:"workspaces"`
+}
+
+func readPackageJSON(file string) (packageJSON, bool) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return packageJSON{}, false
+	}
+	var pj packageJSON
+	if json.Unmarshal(raw, &pj) != nil {
+		return packageJSON{}, false
+	}
+	return pj, true
+}
+
+// npmName drops the npm organisation: "@acme/web-ui" → "web-ui".
+func npmName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// readNPMWorkspaces lists the packages matched by package.json's
+// `workspaces` (either an array of globs or Yarn's {packages: [...]}),
+// plus the root package itself.
+func readNPMWorkspaces(root string) []Package {
+	pj, ok := readPackageJSON(filepath.Join(root, "package.json"))
+	if !ok {
+		return nil
+	}
+	var globs []string
+	if json.Unmarshal(pj.Workspaces, &globs) != nil {
+		var yarn struct {
+			Packages []string `json:"packages"`
+		}
+		_ = json.Unmarshal(pj.Workspaces, &yarn)
+		globs = yarn.Packages
+	}
+	var pkgs []Package
+	for _, dir := range expandMembers(root, globs, nil) {
+		if member, ok := readPackageJSON(filepath.Join(root, filepath.FromSlash(dir), "package.json")); ok {
+			pkgs = append(pkgs, Package{Name: npmName(member.Name), Dir: dir, Kind: KindNPM})
+		}
+	}
+	if pj.Name != "" {
+		pkgs = append(pkgs, Package{Name: npmName(pj.Name), Kind: KindNPM})
+	}
+	return pkgs
+}
+
+type cargoManifest struct {
+	Package struct {
+		Name string `toml:"name"`
+	} `toml:"package"`
+	Workspace struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+	} `toml:"workspace"`
+}
+
+func readCargoManifest(file string) (cargoManifest, bool) {
+	var m cargoManifest
+	if _, err := toml.DecodeFile(file, &m); err != nil {
+		return cargoManifest{}, false
+	}
+	return m, true
+}
+
+// readCargoWorkspace lists the crates of Cargo.toml's `[workspace]`
+// members, plus the root crate when the manifest has a `[package]`.
+func readCargoWorkspace(root string) []Package {
+	m, ok := readCargoManifest(filepath.Join(root, "Cargo.toml"))
+	if !ok {
+		return nil
+	}
+	var pkgs []Package
+	for _, dir := range expandMembers(root, m.Workspace.Members, m.Workspace.Exclude) {
+		if member, ok := readCargoManifest(filepath.Join(root, filepath.FromSlash(dir), "Cargo.toml")); ok {
+			pkgs = append(pkgs, Package{Name: member.Package.Name, Dir: dir, Kind: KindCargo})
+		}
+	}
+	if m.Package.Name != "" {
+		pkgs = append(pkgs, Package{Name: m.Package.Name, Kind: KindCargo})
+	}
+	return pkgs
+}
+
+// expandMembers resolves workspace member globs to the directories they
+// match under root. `!`-prefixed globs and exclude remove directories;
+// `**` is matched one level deep like `*`.
+func expandMembers(root string, globs, exclude []string) []string {
+	skip := map[string]bool{}
+	for _, g := range exclude {
+		skip[relDir(g)] = true
+	}
+	var dirs []string
+	seen := map[string]bool{}
+	for _, g := range globs {
+		if neg, ok := strings.CutPrefix(g, "!"); ok {
+			skip[relDir(neg)] = true
+			continue
+		}
+		g = strings.ReplaceAll(relDir(g), "**", "*")
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(g)))
+		for _, m := range matches {
+			if st, err := os.Stat(m); err != nil || !st.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(root, m)
+			if err != nil {
+				continue
+			}
+			if dir := relDir(rel); !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	out := dirs[:0]
+	for _, d := range dirs {
+		if !skip[d] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
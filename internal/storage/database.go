@@ -63,6 +63,14 @@ func InitDB() (*DB, error) {
 		return nil, errors.Wrap(err, "failed to create model_rate_limits table")
 	}
 
+	if err := createStyleProfilesTable(sqlDB); err != nil {
+		return nil, errors.Wrap(err, "failed to create style_profiles table")
+	}
+
+	if err := createEmbeddingsTable(sqlDB); err != nil {
+		return nil, errors.Wrap(err, "failed to create embeddings table")
+	}
+
 	// Migrations run after every CREATE TABLE so the alterations slice can
 	// freely target child tables (e.g. ai_calls.tpm_limit_at_call).
 	if err := applySchemaMigrations(sqlDB); err != nil {
@@ -74,7 +82,8 @@ func InitDB() (*DB, error) {
 
 // createModelRateLimitsTable persists the latest x-ratelimit-* snapshot
 // per model id so the in-memory cache can be hydrated on every startup.
-// One row per model — UPSERTed on every API call.
+// One row per provider and model — UPSERTed on every API call. The
+// original model_id key is widened by migrateModelRateLimitsKey.
 func createModelRateLimitsTable(db *sql.DB) error {
 	_, err := db.Exec(`
         CREATE TABLE IF NOT EXISTS model_rate_limits (
@@ -168,6 +177,41 @@ func createModelsCacheTable(db *sql.DB) error {
 	return err
 }
 
+// createStyleProfilesTable bootstraps the per-repository style profiles.
+// One row per repository identity (git.WorkspaceKeys' repoID), so every
+// worktree of a repository shares the profile mined from its history.
+func createStyleProfilesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS style_profiles (
+            repo_id TEXT PRIMARY KEY,
+            profile TEXT NOT NULL,
+            commits INTEGER NOT NULL DEFAULT 0,
+            head TEXT NOT NULL DEFAULT '',
+            updated_at TEXT NOT NULL
+        );
+    `)
+	return err
+}
+
+// createEmbeddingsTable bootstraps the semantic search index. Rows are
+// keyed by item and embedder so switching providers keeps the other
+// provider's vectors for when it comes back. Rows of deleted items are
+// left behind; searches only look up the ids they are asked about.
+func createEmbeddingsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS embeddings (
+            kind TEXT NOT NULL,
+            item_id INTEGER NOT NULL,
+            embedder TEXT NOT NULL,
+            content_hash TEXT NOT NULL,
+            vector BLOB NOT NULL,
+            updated_at TEXT NOT NULL,
+            PRIMARY KEY (kind, item_id, embedder)
+        );
+    `)
+	return err
+}
+
 func applySchemaMigrations(db *sql.DB) error {
 	alterations := []columnAlteration{
 		{
@@ -272,6 +316,96 @@ func applySchemaMigrations(db *sql.DB) error {
 			columnType:   "TEXT",
 			defaultValue: "''",
 		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "provider",
+			columnType:   "TEXT",
+			defaultValue: "'groq'",
+		},
+		{
+			tableName:    "release_ai_calls",
+			columnName:   "provider",
+			columnType:   "TEXT",
+			defaultValue: "'groq'",
+		},
+		{
+			tableName:    "model_rate_limits",
+			columnName:   "provider",
+			columnType:   "TEXT",
+			defaultValue: "'groq'",
+		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "attempt",
+			columnType:   "INTEGER",
+			defaultValue: "0",
+		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "outcome",
+			columnType:   "TEXT",
+			defaultValue: "'ok'",
+		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "error",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "release_ai_calls",
+			columnName:   "attempt",
+			columnType:   "INTEGER",
+			defaultValue: "0",
+		},
+		{
+			tableName:    "release_ai_calls",
+			columnName:   "outcome",
+			columnType:   "TEXT",
+			defaultValue: "'ok'",
+		},
+		{
+			tableName:    "release_ai_calls",
+			columnName:   "error",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "commits",
+			columnName:   "trailers",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "commits",
+			columnName:   "files",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "chunk_index",
+			columnType:   "INTEGER",
+			defaultValue: "0",
+		},
+		{
+			tableName:    "ai_calls",
+			columnName:   "chunk_label",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "commits",
+			columnName:   "worktree",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
+		{
+			tableName:    "commits",
+			columnName:   "repo_id",
+			columnType:   "TEXT",
+			defaultValue: "''",
+		},
 	}
 
 	for _, alt := range alterations {
@@ -297,7 +431,71 @@ func applySchemaMigrations(db *sql.DB) error {
 		}
 	}
 
-	return nil
+	return migrateModelRateLimitsKey(db)
+}
+
+// migrateModelRateLimitsKey rebuilds model_rate_limits keyed on
+// (provider, model_id). The table predates providers and was keyed on
+// model_id alone, so the same model id served by two providers overwrote
+// each other's row. SQLite can't alter a primary key, hence the copy;
+// a table already keyed on both columns is left alone.
+func migrateModelRateLimitsKey(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(model_rate_limits)")
+	if err != nil {
+		return errors.Wrap(err, "failed to inspect model_rate_limits")
+	}
+	keyColumns := 0
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan model_rate_limits columns")
+		}
+		if pk > 0 {
+			keyColumns++
+		}
+	}
+	rows.Close()
+	if keyColumns != 1 {
+		return nil
+	}
+
+	const columns = "model_id, provider, limit_requests, remaining_requests, reset_requests_ms, limit_tokens, remaining_tokens, reset_tokens_ms, captured_at, requests_parsed, tokens_parsed, requests_today, requests_day"
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		`CREATE TABLE model_rate_limits_new (
+            model_id TEXT NOT NULL,
+            provider TEXT NOT NULL DEFAULT 'groq',
+            limit_requests INTEGER NOT NULL DEFAULT 0,
+            remaining_requests INTEGER NOT NULL DEFAULT 0,
+            reset_requests_ms INTEGER NOT NULL DEFAULT 0,
+            limit_tokens INTEGER NOT NULL DEFAULT 0,
+            remaining_tokens INTEGER NOT NULL DEFAULT 0,
+            reset_tokens_ms INTEGER NOT NULL DEFAULT 0,
+            captured_at TEXT NOT NULL,
+            requests_parsed INTEGER NOT NULL DEFAULT 0,
+            tokens_parsed INTEGER NOT NULL DEFAULT 0,
+            requests_today INTEGER NOT NULL DEFAULT 0,
+            requests_day TEXT NOT NULL DEFAULT '',
+            PRIMARY KEY (provider, model_id)
+        );`,
+		"INSERT INTO model_rate_limits_new (" + columns + ") SELECT " + columns + " FROM model_rate_limits",
+		"DROP TABLE model_rate_limits",
+		"ALTER TABLE model_rate_limits_new RENAME TO model_rate_limits",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return errors.Wrap(err, "failed to re-key model_rate_limits")
+		}
+	}
+	return tx.Commit()
 }
 
 // createTables ensures the necessary tables exist in the database.
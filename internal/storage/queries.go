@@ -19,35 +19,138 @@ func splitKeyPoints(s string) []string {
 	return strings.Split(s, "\n")
 }
 
-// GetCommits retrieves commits from the database based on a status.
+// commitSelect is the column list every commit query reads back with
+// scanCommit.
+const commitSelect = "SELECT id, type, scope, message_es, message_en, workspace, worktree, repo_id, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, trailers, files, source, commit_hash, created_at FROM commits"
+
+// rowScanner is the Scan method *sql.Row and *sql.Rows share.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanCommit reads one commitSelect row, decoding the newline-joined
+// lists and converting created_at to local time. A failed Scan keeps
+// its cause, so errors.Is still finds sql.ErrNoRows.
+func scanCommit(row rowScanner) (Commit, error) {
+	var c Commit
+	var createdAt, messageES, trailers, files string
+	if err := row.Scan(
+		&c.ID, &c.Type, &c.Scope, &messageES, &c.MessageEN, &c.Workspace, &c.Worktree, &c.RepoID,
+		&c.Diff_code, &c.Status, &c.IaSummary, &c.IaCommitRaw, &c.IaTitle, &c.IaChangelog,
+		&trailers, &files, &c.Source, &c.CommitHash, &createdAt,
+	); err != nil {
+		return c, errors.Wrap(err, "failed to scan commit row")
+	}
+	c.KeyPoints = splitKeyPoints(messageES)
+	c.Trailers = splitKeyPoints(trailers)
+	c.Files = splitKeyPoints(files)
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return c, errors.Wrap(err, "failed to parse created_at: "+createdAt)
+	}
+	c.CreatedAt = t.Local() // Convert to local time for display
+	return c, nil
+}
+
+// scanCommits reads every row of a commitSelect query and closes rows.
+func scanCommits(rows *sql.Rows) ([]Commit, error) {
+	defer rows.Close()
+	var commits []Commit
+	for rows.Next() {
+		c, err := scanCommit(rows)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+	return commits, rows.Err()
+}
+
+// GetCommits retrieves the commits written from the directory pwd with
+// the given status. GetCommitsByWorktree and GetCommitsByRepo scope by
+// checkout instead.
 func (db *DB) GetCommits(pwd string, status string) ([]Commit, error) {
 	rows, err := db.Query(
-		"SELECT id, type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, source, commit_hash, created_at FROM commits WHERE workspace = ? AND status = ? ORDER BY created_at DESC",
+		commitSelect+" WHERE workspace = ? AND status = ? ORDER BY created_at DESC",
 		pwd,
 		status,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query commits")
 	}
-	defer rows.Close()
+	return scanCommits(rows)
+}
 
-	var commits []Commit
+// GetCommitsByWorktree returns the commits with status written anywhere
+// inside the checkout whose top level is worktree (its subdirectories
+// included, nested submodules excluded: they are checkouts of their own).
+func (db *DB) GetCommitsByWorktree(worktree, status string) ([]Commit, error) {
+	rows, err := db.Query(
+		commitSelect+" WHERE worktree = ? AND status = ? ORDER BY created_at DESC",
+		worktree,
+		status,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query commits by worktree")
+	}
+	return scanCommits(rows)
+}
+
+// GetCommitsByRepo returns the commits with status written from any
+// worktree of the repository identified by repoID (its git common dir).
+func (db *DB) GetCommitsByRepo(repoID, status string) ([]Commit, error) {
+	rows, err := db.Query(
+		commitSelect+" WHERE repo_id = ? AND status = ? ORDER BY created_at DESC",
+		repoID,
+		status,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query commits by repository")
+	}
+	return scanCommits(rows)
+}
+
+// ResolveWorkspaceIDs fills worktree and repo_id on every commit that
+// does not have them yet (rows written before the columns existed, and
+// new rows, which are inserted with the workspace path only). resolve
+// maps a workspace directory to its checkout; it is called once per
+// distinct directory, so the usual cost is a single git call for the
+// draft just saved. Directories resolve identifies as outside git (or
+// gone) should come back as the workspace itself.
+func (db *DB) ResolveWorkspaceIDs(resolve func(workspace string) (worktree, repoID string)) error {
+	rows, err := db.Query("SELECT DISTINCT workspace FROM commits WHERE repo_id = ''")
+	if err != nil {
+		return errors.Wrap(err, "failed to query unresolved workspaces")
+	}
+	var workspaces []string
 	for rows.Next() {
-		var c Commit
-		var createdAt, messageES string
-		if err := rows.Scan(&c.ID, &c.Type, &c.Scope, &messageES, &c.MessageEN, &c.Workspace, &c.Diff_code, &c.Status, &c.IaSummary, &c.IaCommitRaw, &c.IaTitle, &c.IaChangelog, &c.Source, &c.CommitHash, &createdAt); err != nil {
-			return nil, errors.Wrap(err, "failed to scan commit row")
+		var ws string
+		if err := rows.Scan(&ws); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan workspace")
 		}
-		c.KeyPoints = splitKeyPoints(messageES)
-
-		t, err := time.Parse(time.RFC3339, createdAt)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse created_at: "+createdAt)
+		workspaces = append(workspaces, ws)
+	}
+	rows.Close()
+	for _, ws := range workspaces {
+		if ws == "" {
+			continue
+		}
+		worktree, repoID := resolve(ws)
+		if worktree == "" {
+			worktree = ws
+		}
+		if repoID == "" {
+			repoID = worktree
+		}
+		if _, err := db.Exec(
+			"UPDATE commits SET worktree = ?, repo_id = ? WHERE workspace = ? AND repo_id = ''",
+			worktree, repoID, ws,
+		); err != nil {
+			return errors.Wrap(err, "failed to store workspace identity")
 		}
-		c.CreatedAt = t.Local() // Convert to local time for display
-		commits = append(commits, c)
 	}
-	return commits, nil
+	return nil
 }
 
 // GetCommitByID returns the commit row matching id, with key points
@@ -55,28 +158,14 @@ func (db *DB) GetCommits(pwd string, status string) ([]Commit, error) {
 // doesn't exist so callers can branch on errors.Is(err, sql.ErrNoRows).
 func (db *DB) GetCommitByID(id int) (Commit, error) {
 	row := db.QueryRow(
-		"SELECT id, type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, source, commit_hash, created_at FROM commits WHERE id = ?",
+		commitSelect+" WHERE id = ?",
 		id,
 	)
-	var c Commit
-	var createdAt, messageES string
-	if err := row.Scan(
-		&c.ID, &c.Type, &c.Scope, &messageES, &c.MessageEN, &c.Workspace,
-		&c.Diff_code, &c.Status, &c.IaSummary, &c.IaCommitRaw, &c.IaTitle, &c.IaChangelog,
-		&c.Source, &c.CommitHash, &createdAt,
-	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return c, errors.Wrap(err, "commit not found")
-		}
-		return c, errors.Wrap(err, "failed to scan commit row")
-	}
-	c.KeyPoints = splitKeyPoints(messageES)
-	t, err := time.Parse(time.RFC3339, createdAt)
-	if err != nil {
-		return c, errors.Wrap(err, "failed to parse created_at: "+createdAt)
+	c, err := scanCommit(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, errors.Wrap(err, "commit not found")
 	}
-	c.CreatedAt = t.Local()
-	return c, nil
+	return c, err
 }
 
 // CreateCommit adds a new commit to the database and writes the new row
@@ -89,7 +178,7 @@ func (db *DB) CreateCommit(c *Commit) error {
 	}
 
 	res, err := db.Exec(
-		"INSERT INTO commits (type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO commits (type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, trailers, files, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		c.Type,
 		c.Scope,
 		joinKeyPoints(c.KeyPoints),
@@ -101,6 +190,8 @@ func (db *DB) CreateCommit(c *Commit) error {
 		c.IaCommitRaw,
 		c.IaTitle,
 		c.IaChangelog,
+		joinKeyPoints(c.Trailers),
+		joinKeyPoints(c.Files),
 		c.Source,
 		createdAt,
 	)
@@ -371,7 +462,7 @@ func (db *DB) SaveDraft(c *Commit) error {
 		}
 
 		res, err := db.Exec(
-			"INSERT INTO commits (type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			"INSERT INTO commits (type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, trailers, files, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 			c.Type,
 			c.Scope,
 			joinKeyPoints(c.KeyPoints),
@@ -383,6 +474,8 @@ func (db *DB) SaveDraft(c *Commit) error {
 			c.IaCommitRaw,
 			c.IaTitle,
 			c.IaChangelog,
+			joinKeyPoints(c.Trailers),
+			joinKeyPoints(c.Files),
 			c.Source,
 			createdAt,
 		)
@@ -399,7 +492,7 @@ func (db *DB) SaveDraft(c *Commit) error {
 
 	// If ID is not 0, it's an existing draft, so we UPDATE.
 	_, err := db.Exec(
-		"UPDATE commits SET type = ?, scope = ?, message_es = ?, message_en = ?, diff_code = ?, ia_summary = ?, ia_commit_raw = ?, ia_title = ?, ia_changelog = ? WHERE id = ?",
+		"UPDATE commits SET type = ?, scope = ?, message_es = ?, message_en = ?, diff_code = ?, ia_summary = ?, ia_commit_raw = ?, ia_title = ?, ia_changelog = ?, trailers = ? WHERE id = ?",
 		c.Type,
 		c.Scope,
 		joinKeyPoints(c.KeyPoints),
@@ -409,6 +502,7 @@ func (db *DB) SaveDraft(c *Commit) error {
 		c.IaCommitRaw,
 		c.IaTitle,
 		c.IaChangelog,
+		joinKeyPoints(c.Trailers),
 		c.ID,
 	)
 	return errors.Wrap(err, "failed to update draft commit")
@@ -419,9 +513,10 @@ func (db *DB) SaveDraft(c *Commit) error {
 func (db *DB) CreateAICall(call AICall) (int64, error) {
 	createdAt := time.Now().UTC().Format(time.RFC3339)
 	res, err := db.Exec(
-		"INSERT INTO ai_calls (commit_id, stage, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO ai_calls (commit_id, stage, provider, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, attempt, outcome, error, chunk_index, chunk_label, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		call.CommitID,
 		call.Stage,
+		providerOrDefault(call.Provider),
 		call.Model,
 		call.PromptTokens,
 		call.CompletionTokens,
@@ -432,6 +527,11 @@ func (db *DB) CreateAICall(call AICall) (int64, error) {
 		call.TotalTimeMs,
 		call.RequestID,
 		call.TPMLimitAtCall,
+		call.Attempt,
+		outcomeOrDefault(call.Outcome),
+		call.Error,
+		call.ChunkIndex,
+		call.ChunkLabel,
 		createdAt,
 	)
 	if err != nil {
@@ -448,7 +548,7 @@ func (db *DB) CreateAICall(call AICall) (int64, error) {
 // insertion order. Empty slice + nil error when the commit has no calls.
 func (db *DB) GetAICallsByCommitID(commitID int) ([]AICall, error) {
 	rows, err := db.Query(
-		"SELECT id, commit_id, stage, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, created_at FROM ai_calls WHERE commit_id = ? ORDER BY id ASC",
+		"SELECT id, commit_id, stage, provider, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, attempt, outcome, error, chunk_index, chunk_label, created_at FROM ai_calls WHERE commit_id = ? ORDER BY id ASC",
 		commitID,
 	)
 	if err != nil {
@@ -461,10 +561,11 @@ func (db *DB) GetAICallsByCommitID(commitID int) ([]AICall, error) {
 		var c AICall
 		var createdAt string
 		if err := rows.Scan(
-			&c.ID, &c.CommitID, &c.Stage, &c.Model,
+			&c.ID, &c.CommitID, &c.Stage, &c.Provider, &c.Model,
 			&c.PromptTokens, &c.CompletionTokens, &c.TotalTokens,
 			&c.QueueTimeMs, &c.PromptTimeMs, &c.CompletionTimeMs, &c.TotalTimeMs,
-			&c.RequestID, &c.TPMLimitAtCall, &createdAt,
+			&c.RequestID, &c.TPMLimitAtCall,
+			&c.Attempt, &c.Outcome, &c.Error, &c.ChunkIndex, &c.ChunkLabel, &createdAt,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to scan ai_call row")
 		}
@@ -492,9 +593,10 @@ func (db *DB) DeleteAICallsByCommitID(commitID int) error {
 func (db *DB) CreateReleaseAICall(call AICall) (int64, error) {
 	createdAt := time.Now().UTC().Format(time.RFC3339)
 	res, err := db.Exec(
-		"INSERT INTO release_ai_calls (release_id, stage, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO release_ai_calls (release_id, stage, provider, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, attempt, outcome, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		call.CommitID,
 		call.Stage,
+		providerOrDefault(call.Provider),
 		call.Model,
 		call.PromptTokens,
 		call.CompletionTokens,
@@ -505,6 +607,9 @@ func (db *DB) CreateReleaseAICall(call AICall) (int64, error) {
 		call.TotalTimeMs,
 		call.RequestID,
 		call.TPMLimitAtCall,
+		call.Attempt,
+		outcomeOrDefault(call.Outcome),
+		call.Error,
 		createdAt,
 	)
 	if err != nil {
@@ -521,7 +626,7 @@ func (db *DB) CreateReleaseAICall(call AICall) (int64, error) {
 // releaseID, in insertion order. Empty slice + nil error when none.
 func (db *DB) GetAICallsByReleaseID(releaseID int) ([]AICall, error) {
 	rows, err := db.Query(
-		"SELECT id, release_id, stage, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, created_at FROM release_ai_calls WHERE release_id = ? ORDER BY id ASC",
+		"SELECT id, release_id, stage, provider, model, prompt_tokens, completion_tokens, total_tokens, queue_time_ms, prompt_time_ms, completion_time_ms, total_time_ms, request_id, tpm_limit_at_call, attempt, outcome, error, created_at FROM release_ai_calls WHERE release_id = ? ORDER BY id ASC",
 		releaseID,
 	)
 	if err != nil {
@@ -534,10 +639,11 @@ func (db *DB) GetAICallsByReleaseID(releaseID int) ([]AICall, error) {
 		var c AICall
 		var createdAt string
 		if err := rows.Scan(
-			&c.ID, &c.CommitID, &c.Stage, &c.Model,
+			&c.ID, &c.CommitID, &c.Stage, &c.Provider, &c.Model,
 			&c.PromptTokens, &c.CompletionTokens, &c.TotalTokens,
 			&c.QueueTimeMs, &c.PromptTimeMs, &c.CompletionTimeMs, &c.TotalTimeMs,
-			&c.RequestID, &c.TPMLimitAtCall, &createdAt,
+			&c.RequestID, &c.TPMLimitAtCall,
+			&c.Attempt, &c.Outcome, &c.Error, &createdAt,
 		); err != nil {
 			return nil, errors.Wrap(err, "failed to scan release_ai_call row")
 		}
@@ -560,8 +666,9 @@ func (db *DB) DeleteAICallsByReleaseID(releaseID int) error {
 }
 
 // SaveModelRateLimits UPSERTs the latest rate-limit snapshot for one
-// model. captured_at is overwritten on every call so freshness checks
-// at render time can decide when the bucket has refilled.
+// provider's model. captured_at is overwritten on every call so
+// freshness checks at render time can decide when the bucket has
+// refilled.
 func (db *DB) SaveModelRateLimits(rl ModelRateLimits) error {
 	if rl.ModelID == "" {
 		return nil
@@ -571,8 +678,9 @@ func (db *DB) SaveModelRateLimits(rl ModelRateLimits) error {
 		capturedAt = time.Now()
 	}
 	_, err := db.Exec(
-		"INSERT INTO model_rate_limits (model_id, limit_requests, remaining_requests, reset_requests_ms, limit_tokens, remaining_tokens, reset_tokens_ms, captured_at, requests_parsed, tokens_parsed, requests_today, requests_day) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(model_id) DO UPDATE SET limit_requests=excluded.limit_requests, remaining_requests=excluded.remaining_requests, reset_requests_ms=excluded.reset_requests_ms, limit_tokens=excluded.limit_tokens, remaining_tokens=excluded.remaining_tokens, reset_tokens_ms=excluded.reset_tokens_ms, captured_at=excluded.captured_at, requests_parsed=excluded.requests_parsed, tokens_parsed=excluded.tokens_parsed, requests_today=excluded.requests_today, requests_day=excluded.requests_day",
+		"INSERT INTO model_rate_limits (model_id, provider, limit_requests, remaining_requests, reset_requests_ms, limit_tokens, remaining_tokens, reset_tokens_ms, captured_at, requests_parsed, tokens_parsed, requests_today, requests_day) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(provider, model_id) DO UPDATE SET limit_requests=excluded.limit_requests, remaining_requests=excluded.remaining_requests, reset_requests_ms=excluded.reset_requests_ms, limit_tokens=excluded.limit_tokens, remaining_tokens=excluded.remaining_tokens, reset_tokens_ms=excluded.reset_tokens_ms, captured_at=excluded.captured_at, requests_parsed=excluded.requests_parsed, tokens_parsed=excluded.tokens_parsed, requests_today=excluded.requests_today, requests_day=excluded.requests_day",
 		rl.ModelID,
+		providerOrDefault(rl.Provider),
 		rl.LimitRequests,
 		rl.RemainingRequests,
 		rl.ResetRequestsMs,
@@ -588,6 +696,23 @@ func (db *DB) SaveModelRateLimits(rl ModelRateLimits) error {
 	return errors.Wrap(err, "failed to upsert model_rate_limits")
 }
 
+// providerOrDefault keeps rows written by callers that predate the
+// provider column consistent with the column default.
+func providerOrDefault(p string) string {
+	if p == "" {
+		return "groq"
+	}
+	return p
+}
+
+// outcomeOrDefault stamps rows without an explicit outcome as served.
+func outcomeOrDefault(o string) string {
+	if o == "" {
+		return AICallOutcomeOK
+	}
+	return o
+}
+
 // boolToInt maps a Go bool to the 0/1 SQLite integer convention used by
 // the rate-limit table's parsed flags.
 func boolToInt(b bool) int {
@@ -598,10 +723,11 @@ func boolToInt(b bool) int {
 }
 
 // LoadAllModelRateLimits returns every persisted rate-limit row so the
-// in-memory cache can be hydrated at startup.
+// in-memory cache can be hydrated at startup. Oldest first: the cache is
+// keyed by model id alone, so the freshest provider's row wins.
 func (db *DB) LoadAllModelRateLimits() ([]ModelRateLimits, error) {
 	rows, err := db.Query(
-		"SELECT model_id, limit_requests, remaining_requests, reset_requests_ms, limit_tokens, remaining_tokens, reset_tokens_ms, captured_at, requests_parsed, tokens_parsed, requests_today, requests_day FROM model_rate_limits",
+		"SELECT model_id, provider, limit_requests, remaining_requests, reset_requests_ms, limit_tokens, remaining_tokens, reset_tokens_ms, captured_at, requests_parsed, tokens_parsed, requests_today, requests_day FROM model_rate_limits ORDER BY captured_at ASC",
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query model_rate_limits")
@@ -614,7 +740,7 @@ func (db *DB) LoadAllModelRateLimits() ([]ModelRateLimits, error) {
 		var capturedAt string
 		var requestsParsed, tokensParsed int
 		if err := rows.Scan(
-			&r.ModelID,
+			&r.ModelID, &r.Provider,
 			&r.LimitRequests, &r.RemainingRequests, &r.ResetRequestsMs,
 			&r.LimitTokens, &r.RemainingTokens, &r.ResetTokensMs,
 			&capturedAt,
@@ -641,7 +767,7 @@ func (db *DB) LoadAllModelRateLimits() ([]ModelRateLimits, error) {
 // FinalizeCommit updates a commit to set its status to 'completed' and saves final data.
 func (db *DB) FinalizeCommit(c Commit) error {
 	_, err := db.Exec(
-		"UPDATE commits SET type = ?, scope = ?, message_es = ?, message_en = ?, diff_code = ?, ia_summary = ?, ia_commit_raw = ?, ia_title = ?, ia_changelog = ?, status = 'completed' WHERE id = ?",
+		"UPDATE commits SET type = ?, scope = ?, message_es = ?, message_en = ?, diff_code = ?, ia_summary = ?, ia_commit_raw = ?, ia_title = ?, ia_changelog = ?, trailers = ?, status = 'completed' WHERE id = ?",
 		c.Type,
 		c.Scope,
 		joinKeyPoints(c.KeyPoints),
@@ -651,6 +777,7 @@ func (db *DB) FinalizeCommit(c Commit) error {
 		c.IaCommitRaw,
 		c.IaTitle,
 		c.IaChangelog,
+		joinKeyPoints(c.Trailers),
 		c.ID,
 	)
 	return errors.Wrap(err, "failed to finalize commit")
@@ -694,32 +821,109 @@ func (db *DB) GetCommitsByHashPrefix(prefix string) ([]Commit, error) {
 		return nil, errors.New("hash prefix must be at least 4 characters")
 	}
 	rows, err := db.Query(
-		"SELECT id, type, scope, message_es, message_en, workspace, diff_code, status, ia_summary, ia_commit_raw, ia_title, ia_changelog, source, commit_hash, created_at FROM commits WHERE commit_hash != '' AND commit_hash LIKE ? ORDER BY created_at DESC",
+		commitSelect+" WHERE commit_hash != '' AND commit_hash LIKE ? ORDER BY created_at DESC",
 		prefix+"%",
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query commits by hash")
 	}
-	defer rows.Close()
+	return scanCommits(rows)
+}
 
-	var commits []Commit
+// SaveStyleProfile inserts or replaces the profile of p.RepoID and
+// stamps UpdatedAt with the current time.
+func (db *DB) SaveStyleProfile(p *StyleProfile) error {
+	p.UpdatedAt = time.Now()
+	_, err := db.Exec(`
+        INSERT INTO style_profiles (repo_id, profile, commits, head, updated_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT(repo_id) DO UPDATE SET
+            profile = excluded.profile,
+            commits = excluded.commits,
+            head = excluded.head,
+            updated_at = excluded.updated_at`,
+		p.RepoID, p.Profile, p.Commits, p.Head, p.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	return errors.Wrap(err, "failed to save style profile")
+}
+
+// GetStyleProfile returns the stored profile of repoID. found is false
+// when the repository has no profile yet.
+func (db *DB) GetStyleProfile(repoID string) (p StyleProfile, found bool, err error) {
+	var updatedAt string
+	err = db.QueryRow(
+		"SELECT repo_id, profile, commits, head, updated_at FROM style_profiles WHERE repo_id = ?",
+		repoID,
+	).Scan(&p.RepoID, &p.Profile, &p.Commits, &p.Head, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StyleProfile{}, false, nil
+	}
+	if err != nil {
+		return StyleProfile{}, false, errors.Wrap(err, "failed to query style profile")
+	}
+	t, err := time.Parse(time.RFC3339, updatedAt)
+	if err != nil {
+		return StyleProfile{}, false, errors.Wrap(err, "failed to parse updated_at: "+updatedAt)
+	}
+	p.UpdatedAt = t.Local()
+	return p, true, nil
+}
+
+// GetEmbeddings returns every stored vector of kind for embedder, keyed
+// by item id.
+func (db *DB) GetEmbeddings(kind, embedder string) (map[int]Embedding, error) {
+	rows, err := db.Query(
+		"SELECT item_id, content_hash, vector, updated_at FROM embeddings WHERE kind = ? AND embedder = ?",
+		kind, embedder,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query embeddings")
+	}
+	defer rows.Close()
+	out := map[int]Embedding{}
 	for rows.Next() {
-		var c Commit
-		var createdAt, messageES string
-		if err := rows.Scan(
-			&c.ID, &c.Type, &c.Scope, &messageES, &c.MessageEN, &c.Workspace,
-			&c.Diff_code, &c.Status, &c.IaSummary, &c.IaCommitRaw, &c.IaTitle, &c.IaChangelog,
-			&c.Source, &c.CommitHash, &createdAt,
-		); err != nil {
-			return nil, errors.Wrap(err, "failed to scan commit row")
+		e := Embedding{Kind: kind, Embedder: embedder}
+		var updatedAt string
+		if err := rows.Scan(&e.ItemID, &e.ContentHash, &e.Vector, &updatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan embedding row")
 		}
-		c.KeyPoints = splitKeyPoints(messageES)
-		t, err := time.Parse(time.RFC3339, createdAt)
+		t, err := time.Parse(time.RFC3339, updatedAt)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse created_at: "+createdAt)
+			return nil, errors.Wrap(err, "failed to parse updated_at: "+updatedAt)
+		}
+		e.UpdatedAt = t.Local()
+		out[e.ItemID] = e
+	}
+	return out, rows.Err()
+}
+
+// SaveEmbeddings inserts or replaces rows in one transaction, stamping
+// them with the current time.
+func (db *DB) SaveEmbeddings(rows []Embedding) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+        INSERT INTO embeddings (kind, item_id, embedder, content_hash, vector, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT(kind, item_id, embedder) DO UPDATE SET
+            content_hash = excluded.content_hash,
+            vector = excluded.vector,
+            updated_at = excluded.updated_at`)
+	if err != nil {
+		return errors.Wrap(err, "prepare embedding insert")
+	}
+	defer stmt.Close()
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range rows {
+		if _, err := stmt.Exec(e.Kind, e.ItemID, e.Embedder, e.ContentHash, e.Vector, now); err != nil {
+			return errors.Wrap(err, "failed to save embedding")
 		}
-		c.CreatedAt = t.Local()
-		commits = append(commits, c)
 	}
-	return commits, nil
+	return errors.Wrap(tx.Commit(), "commit embeddings")
 }
@@ -3,6 +3,15 @@ package storage
 import "time"
 
 // Commit represents a single commit record in the database.
+//
+// Trailers are git trailer lines ("Co-authored-by: Ana <ana@x.dev>",
+// "Refs: #42") appended below the message when it is composed; they are
+// stored newline-joined like KeyPoints and never part of MessageEN.
+//
+// Workspace is the directory the draft was written from. Worktree (the
+// git top level) and RepoID (the git common dir, shared by every
+// worktree of a repository) identify its checkout; they are filled in
+// by ResolveWorkspaceIDs and fall back to Workspace outside git.
 type Commit struct {
 	ID          int
 	Type        string
@@ -10,30 +19,48 @@ type Commit struct {
 	KeyPoints   []string
 	MessageEN   string
 	Workspace   string
+	Worktree    string
+	RepoID      string
 	Diff_code   string
 	Status      string
 	IaSummary   string
 	IaCommitRaw string
 	IaTitle     string
 	IaChangelog string
+	Trailers    []string
+	Files       []string // split-plan group paths; empty commits the whole index
 	Source      string
 	CommitHash  string
 	CreatedAt   time.Time
 }
 
-// AICall stores per-stage telemetry from a single Groq chat completion
-// linked to a Commit row. Tokens come from the API's `usage` block; time
-// fields are stored as integer milliseconds for compact storage and easy
-// formatting (we never need sub-ms precision in the UI).
+// AICall stores per-stage telemetry from a single chat completion
+// linked to a Commit row. Provider names the backend that served the
+// call (groq, openai, ollama, anthropic). Tokens come from the API's
+// `usage` block; time fields are stored as integer milliseconds for
+// compact storage and easy formatting (we never need sub-ms precision in
+// the UI).
 //
 // TPMLimitAtCall is the model's per-minute token budget at the moment the
 // call was made (`x-ratelimit-limit-tokens`). Stored alongside the call
 // so the per-stage TPM-consumption bar in the pipeline view stays stable
 // across reloads even if Groq later changes the model's limit.
+//
+// A stage served after retries or fallbacks stores one extra row per
+// failed try: Attempt numbers the tries of that stage from 1, Outcome is
+// the failure class (api.Outcome* values) and Error its message. The row
+// that actually served the stage has Outcome "ok" and comes last. Rows
+// written before retries existed have Attempt 0.
+//
+// A chunked Change Analyzer run (diff larger than the model's context
+// window) stores one row per map call under AICallStageSummaryChunk,
+// numbered by ChunkIndex from 1 and labelled with the directory or file
+// the chunk covers; the reduce call is the regular "summary" row.
 type AICall struct {
 	ID               int
 	CommitID         int
 	Stage            string
+	Provider         string
 	Model            string
 	PromptTokens     int
 	CompletionTokens int
@@ -44,13 +71,33 @@ type AICall struct {
 	TotalTimeMs      int
 	RequestID        string
 	TPMLimitAtCall   int
+	Attempt          int
+	Outcome          string
+	Error            string
+	ChunkIndex       int
+	ChunkLabel       string
 	CreatedAt        time.Time
 }
 
+// AICallOutcomeOK marks the ai_calls row that served its stage.
+const AICallOutcomeOK = "ok"
+
+// AICallStageSummaryChunk is the stage label of the per-chunk rows of a
+// chunked Change Analyzer run. Readers that only know the four pipeline
+// stages skip it like any other unknown label.
+const AICallStageSummaryChunk = "summary_chunk"
+
+// Served reports whether c is the row that served its stage (as opposed
+// to a failed retry attempt).
+func (c AICall) Served() bool {
+	return c.Outcome == "" || c.Outcome == AICallOutcomeOK
+}
+
 // ModelRateLimits mirrors the latest `x-ratelimit-*` snapshot we have for
-// a given Groq model. Persisted so the in-memory cache can be hydrated on
-// startup (the bars in compose / picker would otherwise show "no data
-// yet" for any model not called in the current session).
+// a given model. Provider records which backend the snapshot came from.
+// Persisted so the in-memory cache can be hydrated on startup (the bars
+// in compose / picker would otherwise show "no data yet" for any model
+// not called in the current session).
 //
 // RequestsParsed/TokensParsed flag whether both halves of the bucket
 // (limit + remaining) were actually present in the API response — see
@@ -63,6 +110,7 @@ type AICall struct {
 // (`LimitRequests`) is still used as the denominator.
 type ModelRateLimits struct {
 	ModelID           string
+	Provider          string
 	LimitRequests     int
 	RemainingRequests int
 	ResetRequestsMs   int
@@ -91,3 +139,35 @@ type Release struct {
 	CommitHash string
 	CreatedAt  time.Time
 }
+
+// StyleProfile is the stored style profile of one repository. Profile is
+// the JSON the aiengine builder produced; storage keeps it opaque so the
+// profile shape can grow without a migration. Head is the commit the
+// profile was mined at.
+type StyleProfile struct {
+	RepoID    string
+	Profile   string
+	Commits   int
+	Head      string
+	UpdatedAt time.Time
+}
+
+// Kinds of rows the embeddings index covers.
+const (
+	EmbeddingKindCommit  = "commit"
+	EmbeddingKindRelease = "release"
+)
+
+// Embedding is the cached vector of one commit or release for one
+// embedder (its name, e.g. "hash-512" or "ollama:nomic-embed-text").
+// ContentHash fingerprints the text that was embedded, so an edited row
+// (or a reused id) is embedded again. Vector is opaque to storage: the
+// embedding package encodes and decodes it.
+type Embedding struct {
+	Kind        string
+	ItemID      int
+	Embedder    string
+	ContentHash string
+	Vector      []byte
+	UpdatedAt   time.Time
+}
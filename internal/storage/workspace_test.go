@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestResolveWorkspaceIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db, err := InitDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Two directories of the main worktree, a linked worktree of the same
+	// repository, a submodule and a directory outside git.
+	checkouts := map[string][2]string{
+		"/src/app":        {"/src/app", "/src/app/.git"},
+		"/src/app/pkg":    {"/src/app", "/src/app/.git"},
+		"/src/app-wt":     {"/src/app-wt", "/src/app/.git"},
+		"/src/app/vendor": {"/src/app/vendor", "/src/app/.git/modules/vendor"},
+		"/tmp/notes":      {"", ""},
+	}
+	for ws := range checkouts {
+		if err := db.SaveDraft(&Commit{Type: "ADD", Scope: "x", Workspace: ws}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	calls := map[string]int{}
+	resolve := func(ws string) (string, string) {
+		calls[ws]++
+		c := checkouts[ws]
+		return c[0], c[1]
+	}
+	if err := db.ResolveWorkspaceIDs(resolve); err != nil {
+		t.Fatal(err)
+	}
+	// Resolved rows are not looked up again.
+	if err := db.ResolveWorkspaceIDs(resolve); err != nil {
+		t.Fatal(err)
+	}
+	for ws, n := range calls {
+		if n != 1 {
+			t.Errorf("workspace %q resolved %d times, want 1", ws, n)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query func() ([]Commit, error)
+		want  []string
+	}{
+		{
+			"worktree keeps subdirectories, not submodules",
+			func() ([]Commit, error) { return db.GetCommitsByWorktree("/src/app", "draft") },
+			[]string{"/src/app", "/src/app/pkg"},
+		},
+		{
+			"repository spans linked worktrees",
+			func() ([]Commit, error) { return db.GetCommitsByRepo("/src/app/.git", "draft") },
+			[]string{"/src/app", "/src/app-wt", "/src/app/pkg"},
+		},
+		{
+			"submodule is its own repository",
+			func() ([]Commit, error) { return db.GetCommitsByRepo("/src/app/.git/modules/vendor", "draft") },
+			[]string{"/src/app/vendor"},
+		},
+		{
+			"outside git falls back to the directory",
+			func() ([]Commit, error) { return db.GetCommitsByRepo("/tmp/notes", "draft") },
+			[]string{"/tmp/notes"},
+		},
+		{
+			"status filters",
+			func() ([]Commit, error) { return db.GetCommitsByWorktree("/src/app", "completed") },
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := tt.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]bool{}
+			for _, c := range commits {
+				got[c.Workspace] = true
+			}
+			if len(got) != len(tt.want) || len(commits) != len(tt.want) {
+				t.Fatalf("got workspaces %v, want %v", got, tt.want)
+			}
+			for _, ws := range tt.want {
+				if !got[ws] {
+					t.Fatalf("got workspaces %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BPE is a byte-level BPE tokenizer. ranks maps a token's raw bytes to
+// its merge rank (lower merges first); for tiktoken files that is the
+// listed rank, for Hugging Face files the position of the merge that
+// produces the token, after the 256 single bytes.
+type BPE struct {
+	name   string
+	ranks  map[string]int
+	split  splitRules
+	mu     sync.Mutex
+	pieces map[string]int
+}
+
+// maxPieceCache bounds the per-piece count cache; it is dropped and
+// rebuilt when full.
+const maxPieceCache = 1 << 16
+
+// maxPieceBytes caps the piece the quadratic merge loop sees; longer
+// pieces (minified lines, long symbol runs) are counted in slices.
+const maxPieceBytes = 512
+
+func (b *BPE) Name() string { return b.name }
+
+// Count pre-tokenizes text and sums the BPE token count of every piece.
+func (b *BPE) Count(text string) int {
+	n := 0
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, piece := range b.split.pieces(text) {
+		n += b.countPiece(piece)
+	}
+	return n
+}
+
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	if c, ok := b.pieces[piece]; ok {
+		return c
+	}
+	c := 0
+	for rest := piece; rest != ""; {
+		n := min(len(rest), maxPieceBytes)
+		c += b.merge(rest[:n])
+		rest = rest[n:]
+	}
+	if len(b.pieces) >= maxPieceCache {
+		b.pieces = map[string]int{}
+	}
+	b.pieces[piece] = c
+	return c
+}
+
+// merge runs the BPE merges over piece, starting from single bytes, and
+// returns how many tokens are left.
+func (b *BPE) merge(piece string) int {
+	// bounds[i] is the start offset of part i; the last entry is len(piece).
+	bounds := make([]int, 0, len(piece)+1)
+	for i := 0; i <= len(piece); i++ {
+		bounds = append(bounds, i)
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, int(^uint(0)>>1)
+		for i := 0; i+2 < len(bounds); i++ {
+			if r, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// LoadFile reads a vocabulary: Hugging Face `tokenizer.json` files are
+// recognised by their .json extension, anything else is read as a
+// tiktoken rank file ("<base64 token> <rank>" per line, the format of
+// Llama 3's tokenizer.model and OpenAI's .tiktoken files). family picks
+// the pre-tokenizer; a tokenizer.json's own split pattern wins when it
+// is recognised.
+func LoadFile(path, family string) (*BPE, error) {
+	name := family
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	b := &BPE{name: "bpe:" + name, split: familyRules(family), pieces: map[string]int{}}
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = b.loadHF(path)
+	} else {
+		err = b.loadTiktoken(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer %s: %w", path, err)
+	}
+	if len(b.ranks) < 256 {
+		return nil, fmt.Errorf("tokenizer %s: only %d tokens, not a byte-level vocabulary", path, len(b.ranks))
+	}
+	return b, nil
+}
+
+func (b *BPE) loadTiktoken(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b.ranks = map[string]int{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: expected a base64 token and its rank", line)
+		}
+		tok, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		b.ranks[string(tok)] = rank
+	}
+	return sc.Err()
+}
+
+// hfTokenizer is the part of a Hugging Face tokenizer.json we read.
+type hfTokenizer struct {
+	Model struct {
+		Type  string         `json:"type"`
+		Vocab map[string]int `json:"vocab"`
+		// Merges lists the pair merges in priority order, either as
+		// "left right" strings or as [left, right] pairs.
+		Merges []json.RawMessage `json:"merges"`
+	} `json:"model"`
+	PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+}
+
+func (b *BPE) loadHF(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var hf hfTokenizer
+	if err := json.Unmarshal(raw, &hf); err != nil {
+		return err
+	}
+	if hf.Model.Type != "BPE" {
+		return fmt.Errorf("model type %q is not BPE", hf.Model.Type)
+	}
+	if len(hf.Model.Merges) == 0 {
+		return fmt.Errorf("model has no merges")
+	}
+	decode := byteLevelDecoder()
+	// Token ids need not follow merge order, so only the single bytes
+	// come from the vocabulary; every longer token is ranked by the
+	// merge that produces it.
+	b.ranks = make(map[string]int, len(hf.Model.Merges)+256)
+	for tok := range hf.Model.Vocab {
+		if raw, ok := decodeByteLevel(tok, decode); ok && len(raw) == 1 {
+			b.ranks[raw] = int(raw[0])
+		}
+	}
+	for i, m := range hf.Model.Merges {
+		left, right, err := parseMerge(m)
+		if err != nil {
+			return fmt.Errorf("merge %d: %w", i, err)
+		}
+		l, okL := decodeByteLevel(left, decode)
+		r, okR := decodeByteLevel(right, decode)
+		if !okL || !okR {
+			continue
+		}
+		if _, ok := b.ranks[l+r]; !ok {
+			b.ranks[l+r] = 256 + i
+		}
+	}
+	if rules, ok := rulesFromPattern(string(hf.PreTokenizer)); ok {
+		b.split = rules
+	}
+	return nil
+}
+
+// parseMerge reads one tokenizer.json merge, "left right" in older
+// files and ["left", "right"] in newer ones.
+func parseMerge(m json.RawMessage) (string, string, error) {
+	var pair []string
+	if err := json.Unmarshal(m, &pair); err == nil {
+		if len(pair) != 2 {
+			return "", "", fmt.Errorf("expected 2 tokens, got %d", len(pair))
+		}
+		return pair[0], pair[1], nil
+	}
+	var s string
+	if err := json.Unmarshal(m, &s); err != nil {
+		return "", "", fmt.Errorf("expected a string or a token pair: %s", m)
+	}
+	left, right, ok := strings.Cut(s, " ")
+	if !ok {
+		return "", "", fmt.Errorf("%q is not a token pair", s)
+	}
+	return left, right, nil
+}
+
+// byteLevelDecoder inverts GPT-2's bytes_to_unicode table: printable
+// Latin-1 bytes map to themselves, the other 68 bytes to U+0100 onward.
+func byteLevelDecoder() map[rune]byte {
+	dec := make(map[rune]byte, 256)
+	next := rune(256)
+	for i := 0; i < 256; i++ {
+		c := byte(i)
+		if (c >= '!' && c <= '~') || (c >= 0xA1 && c <= 0xAC) || c >= 0xAE {
+			dec[rune(i)] = c
+			continue
+		}
+		dec[next] = c
+		next++
+	}
+	return dec
+}
+
+// decodeByteLevel turns a byte-level vocabulary entry back into raw
+// bytes; false for entries outside the byte alphabet (special tokens).
+func decodeByteLevel(tok string, dec map[rune]byte) (string, bool) {
+	var sb strings.Builder
+	for _, r := range tok {
+		c, ok := dec[r]
+		if !ok {
+			return "", false
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), true
+}
@@ -0,0 +1,210 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitRules parameterises the pre-tokenizer shared by the supported
+// families. They all follow the cl100k-style pattern
+//
+//	contractions | [^\r\n\p{L}\p{N}]?\p{L}+ | \p{N}{1,maxDigits} |
+//	 ?[^\s\p{L}\p{N}]+[\r\n]* | \s*[\r\n]+ | \s+(?!\S) | \s+
+//
+// and differ in how many digits group together and whether letter runs
+// split at case changes (o200k, used by GPT-OSS and Llama 4). Go's
+// regexp has no lookahead, so the pattern is matched by hand.
+type splitRules struct {
+	maxDigits int
+	caseSplit bool
+}
+
+func familyRules(family string) splitRules {
+	switch family {
+	case FamilyQwen:
+		return splitRules{maxDigits: 1}
+	case FamilyLlama4, FamilyGPTOSS:
+		return splitRules{maxDigits: 3, caseSplit: true}
+	}
+	return splitRules{maxDigits: 3}
+}
+
+// rulesFromPattern reads the split regex of a tokenizer.json
+// pre_tokenizer block; false when it does not look like one of the
+// supported patterns.
+func rulesFromPattern(pre string) (splitRules, bool) {
+	if !strings.Contains(pre, `\\p{L}`) && !strings.Contains(pre, `\\p{Lu}`) {
+		return splitRules{}, false
+	}
+	rules := splitRules{maxDigits: 1}
+	if strings.Contains(pre, `\\p{N}{1,3}`) {
+		rules.maxDigits = 3
+	}
+	rules.caseSplit = strings.Contains(pre, `\\p{Lu}`)
+	return rules, true
+}
+
+var contractions = []string{"'s", "'t", "'re", "'ve", "'m", "'ll", "'d"}
+
+func isLetter(r rune) bool { return unicode.IsLetter(r) || unicode.Is(unicode.M, r) }
+
+func isNewline(r rune) bool { return r == '\r' || r == '\n' }
+
+// isOther is [^\s\p{L}\p{N}].
+func isOther(r rune) bool { return !unicode.IsSpace(r) && !isLetter(r) && !unicode.IsNumber(r) }
+
+// pieces splits text into pre-tokens. Every byte of text ends up in
+// exactly one piece.
+func (s splitRules) pieces(text string) []string {
+	var out []string
+	for i := 0; i < len(text); {
+		n := s.next(text[i:])
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(text[i:])
+		}
+		out = append(out, text[i:i+n])
+		i += n
+	}
+	return out
+}
+
+// next returns the length of the pre-token at the start of t, trying
+// the pattern's alternatives in order.
+func (s splitRules) next(t string) int {
+	if !s.caseSplit {
+		if n := contraction(t); n > 0 {
+			return n
+		}
+	}
+	if n := s.word(t); n > 0 {
+		return n
+	}
+	if n := digits(t, s.maxDigits); n > 0 {
+		return n
+	}
+	if n := punctuation(t, s.caseSplit); n > 0 {
+		return n
+	}
+	return whitespace(t)
+}
+
+func contraction(t string) int {
+	if len(t) < 2 || t[0] != '\'' {
+		return 0
+	}
+	lower := strings.ToLower(t[:min(len(t), 3)])
+	for _, c := range contractions {
+		if strings.HasPrefix(lower, c) {
+			return len(c)
+		}
+	}
+	return 0
+}
+
+// word matches [^\r\n\p{L}\p{N}]?\p{L}+, or with caseSplit the o200k
+// variant that ends a word where lower case turns back into upper case
+// (Upper* Lower+ | Upper+ Lower*) and keeps a trailing contraction.
+func (s splitRules) word(t string) int {
+	i := 0
+	if r, n := utf8.DecodeRuneInString(t); !isLetter(r) && !unicode.IsNumber(r) && !isNewline(r) {
+		i = n
+	}
+	start := i
+	if !s.caseSplit {
+		for i < len(t) {
+			r, n := utf8.DecodeRuneInString(t[i:])
+			if !isLetter(r) {
+				break
+			}
+			i += n
+		}
+	} else {
+		for i < len(t) {
+			r, n := utf8.DecodeRuneInString(t[i:])
+			if !isLetter(r) || unicode.IsLower(r) {
+				break
+			}
+			i += n
+		}
+		for i < len(t) {
+			r, n := utf8.DecodeRuneInString(t[i:])
+			if !isLetter(r) || unicode.IsUpper(r) || unicode.IsTitle(r) {
+				break
+			}
+			i += n
+		}
+		if i > start {
+			i += contraction(t[i:])
+		}
+	}
+	if i == start {
+		return 0
+	}
+	return i
+}
+
+func digits(t string, maxDigits int) int {
+	i, count := 0, 0
+	for i < len(t) && count < maxDigits {
+		r, n := utf8.DecodeRuneInString(t[i:])
+		if !unicode.IsNumber(r) {
+			break
+		}
+		i += n
+		count++
+	}
+	return i
+}
+
+// punctuation matches " ?[^\s\p{L}\p{N}]+[\r\n]*" (o200k also swallows
+// trailing slashes: "[\r\n/]*").
+func punctuation(t string, slash bool) int {
+	i := 0
+	if strings.HasPrefix(t, " ") {
+		i = 1
+	}
+	start := i
+	for i < len(t) {
+		r, n := utf8.DecodeRuneInString(t[i:])
+		if !isOther(r) {
+			break
+		}
+		i += n
+	}
+	if i == start {
+		return 0
+	}
+	for i < len(t) && (t[i] == '\r' || t[i] == '\n' || (slash && t[i] == '/')) {
+		i++
+	}
+	return i
+}
+
+// whitespace matches "\s*[\r\n]+ | \s+(?!\S) | \s+".
+func whitespace(t string) int {
+	end, lastNewline := 0, -1
+	for end < len(t) {
+		r, n := utf8.DecodeRuneInString(t[end:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		if isNewline(r) {
+			lastNewline = end + n
+		}
+		end += n
+	}
+	if end == 0 {
+		return 0
+	}
+	if lastNewline > 0 {
+		// \s*[\r\n]+ stops after the last newline of the run.
+		return lastNewline
+	}
+	if end < len(t) && end > 1 {
+		// \s+(?!\S): leave the last space to prefix the next word.
+		_, n := utf8.DecodeLastRuneInString(t[:end])
+		return end - n
+	}
+	return end
+}
@@ -0,0 +1,247 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cl100kCases are token counts from OpenAI's cl100k_base (0 when not
+// pinned); their pieces are what the cl100k pre-tokenizer splits them
+// into.
+var cl100kCases = []struct {
+	text   string
+	pieces []string
+	count  int
+}{
+	{"tiktoken is great!", []string{"tiktoken", " is", " great", "!"}, 6},
+	{"I'm sure it's fine", []string{"I", "'m", " sure", " it", "'s", " fine"}, 6},
+	{"DON'T", []string{"DON", "'T"}, 2},
+	{"1234567", []string{"123", "456", "7"}, 3},
+	{"2026-10-18", []string{"202", "6", "-", "10", "-", "18"}, 6},
+	{"x = 42;\n", []string{"x", " =", " ", "42", ";\n"}, 5},
+	{"a\n\n  b", []string{"a", "\n\n", " ", " b"}, 4},
+	{"    return x", []string{"   ", " return", " x"}, 3},
+	{"a  ", []string{"a", "  "}, 2},
+	{"你好", []string{"你好"}, 2},
+	{"你好，世界", []string{"你好", "，世界"}, 0},
+}
+
+func TestPiecesCL100K(t *testing.T) {
+	rules := familyRules(FamilyLlama3)
+	for _, c := range cl100kCases {
+		if got := rules.pieces(c.text); !slices.Equal(got, c.pieces) {
+			t.Errorf("pieces(%q) = %q, want %q", c.text, got, c.pieces)
+		}
+	}
+}
+
+func TestPiecesFamilies(t *testing.T) {
+	cases := []struct {
+		family string
+		text   string
+		want   []string
+	}{
+		{FamilyQwen, "v2026", []string{"v", "2", "0", "2", "6"}},
+		{FamilyGPTOSS, "parseHTTPServer's", []string{"parse", "HTTPServer's"}},
+		{FamilyLlama4, "camelCase", []string{"camel", "Case"}},
+		{FamilyLlama4, "a//\n", []string{"a", "//\n"}},
+	}
+	for _, c := range cases {
+		if got := familyRules(c.family).pieces(c.text); !slices.Equal(got, c.want) {
+			t.Errorf("%s pieces(%q) = %q, want %q", c.family, c.text, got, c.want)
+		}
+	}
+}
+
+// TestCountCL100K needs the real vocabulary, which is not vendored: set
+// CL100K_BASE to a cl100k_base.tiktoken file to run it.
+func TestCountCL100K(t *testing.T) {
+	path := os.Getenv("CL100K_BASE")
+	if path == "" {
+		t.Skip("CL100K_BASE is not set")
+	}
+	b, err := LoadFile(path, FamilyLlama3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cl100kCases {
+		if c.count == 0 {
+			continue
+		}
+		if got := b.Count(c.text); got != c.count {
+			t.Errorf("Count(%q) = %d, want %d", c.text, got, c.count)
+		}
+	}
+}
+
+// smallMerges and smallIDs make a byte-level vocabulary whose ids do not follow merge
+// order: "ab" has the lower id but "bc" merges first.
+var (
+	smallMerges = [][2]string{{"b", "c"}, {"a", "b"}, {"ab", "ab"}, {" ", "a"}}
+	smallIDs    = map[string]int{"ab": 256, "bc": 257, "abab": 258, " a": 259}
+)
+
+// byteLevelEncoder is the inverse of byteLevelDecoder.
+func byteLevelEncoder() map[byte]rune {
+	enc := map[byte]rune{}
+	for r, c := range byteLevelDecoder() {
+		enc[c] = r
+	}
+	return enc
+}
+
+func encodeByteLevel(s string, enc map[byte]rune) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteRune(enc[s[i]])
+	}
+	return sb.String()
+}
+
+func writeTiktoken(t *testing.T, path string) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range smallMerges {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m[0]+m[1])), 256+i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeHF(t *testing.T, path string, pairs bool) {
+	t.Helper()
+	enc := byteLevelEncoder()
+	vocab := map[string]int{"<|endoftext|>": 1000}
+	for i := 0; i < 256; i++ {
+		vocab[encodeByteLevel(string([]byte{byte(i)}), enc)] = i
+	}
+	for tok, id := range smallIDs {
+		vocab[encodeByteLevel(tok, enc)] = id
+	}
+	var merges []any
+	for _, m := range smallMerges {
+		l, r := encodeByteLevel(m[0], enc), encodeByteLevel(m[1], enc)
+		if pairs {
+			merges = append(merges, []string{l, r})
+		} else {
+			merges = append(merges, l+" "+r)
+		}
+	}
+	doc := map[string]any{
+		"model": map[string]any{"type": "BPE", "vocab": vocab, "merges": merges},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]func(string){
+		"small.tiktoken":   func(p string) { writeTiktoken(t, p) },
+		"small.json":       func(p string) { writeHF(t, p, false) },
+		"small-pairs.json": func(p string) { writeHF(t, p, true) },
+	}
+	cases := []struct {
+		text  string
+		count int
+	}{
+		{"abab", 1},
+		// Merge order gives a, b, a, bc; id order would give abab, c.
+		{"ababc", 3},
+		{"abcd", 3},
+		{"x ab", 3},
+		{"x a", 2},
+		{"", 0},
+	}
+	for name, write := range files {
+		path := filepath.Join(dir, name)
+		write(path)
+		b, err := LoadFile(path, FamilyLlama3)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if b.Name() != "bpe:llama3" {
+			t.Errorf("%s: Name = %q", name, b.Name())
+		}
+		if len(b.ranks) != 256+len(smallMerges) {
+			t.Errorf("%s: %d ranks, want %d", name, len(b.ranks), 256+len(smallMerges))
+		}
+		for _, c := range cases {
+			if got := b.Count(c.text); got != c.count {
+				t.Errorf("%s: Count(%q) = %d, want %d", name, c.text, got, c.count)
+			}
+		}
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name, data, wantErr string
+	}{
+		{"short.tiktoken", "YQ== 0\n", "not a byte-level vocabulary"},
+		{"bad.tiktoken", "YQ==\n", "line 1: expected a base64 token and its rank"},
+		{"wordpiece.json", `{"model":{"type":"WordPiece"}}`, `model type "WordPiece" is not BPE`},
+		{"nomerges.json", `{"model":{"type":"BPE","vocab":{"a":0}}}`, "model has no merges"},
+		{"badmerge.json", `{"model":{"type":"BPE","merges":["ab"]}}`, `merge 0: "ab" is not a token pair`},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if err := os.WriteFile(path, []byte(c.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path, ""); err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: err = %v, want it to mention %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeTiktoken(t, filepath.Join(dir, "llama3.tiktoken"))
+	if err := os.WriteFile(filepath.Join(dir, "broken.tiktoken"), []byte("nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		dir     string
+		models  map[string]string
+		modelID string
+		want    string
+		wantErr bool
+	}{
+		{"unknown model", dir, nil, "mystery-7b", HeuristicName, false},
+		{"no tokenizers dir", "", nil, "llama-3.3-70b-versatile", HeuristicName, false},
+		{"family without a vocabulary", dir, nil, "qwen/qwen3-32b", HeuristicName, false},
+		{"family vocabulary", dir, nil, "meta-llama/Llama-3.1-8B", "bpe:llama3", false},
+		{"explicit mapping", dir, map[string]string{"mystery-7b": "llama3.tiktoken"}, "mystery-7b", "bpe:llama3", false},
+		{"unreadable vocabulary", dir, map[string]string{"mystery-7b": "broken.tiktoken"}, "mystery-7b", HeuristicName, true},
+	}
+	for _, c := range cases {
+		tok, err := Lookup(c.dir, c.models, c.modelID)
+		if (err != nil) != c.wantErr || tok.Name() != c.want {
+			t.Errorf("%s: Lookup = %s, %v; want %s, error %v", c.name, tok.Name(), err, c.want, c.wantErr)
+		}
+		if got := For(c.dir, c.models, c.modelID); got.Name() != c.want {
+			t.Errorf("%s: For = %s, want %s", c.name, got.Name(), c.want)
+		}
+	}
+	if got := For(dir, nil, "mystery-7b").Count("twelve chars"); got != 3 {
+		t.Errorf("heuristic Count = %d, want 3", got)
+	}
+}
@@ -7,10 +7,17 @@
 // per-stage helpers, and copies their results back onto the model so
 // the existing per-stage retry commands and pipeline cards keep
 // working unchanged.
+//
+// Every entry point takes the run's context (pipelineModel.beginRun);
+// once it is cancelled the helpers stop writing back into the model so
+// an aborted run leaves no partial output behind.
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/api"
@@ -20,14 +27,23 @@ import (
 
 // engineDeps is a small adapter that converts a TUI *Model into the
 // dependency bundle that aiengine functions expect. The pipeline only
-// reads from cfg/db/log/pwd, so this stays a pure projection.
+// reads from cfg/db/log/pwd, so this stays a pure projection; with
+// streaming on, the deltas land in the pipeline's stream buffer and a
+// retried attempt wipes its stage's partial text.
 func engineDeps(model *Model) aiengine.Deps {
-	return aiengine.Deps{
+	deps := aiengine.Deps{
 		Cfg: model.globalConfig,
 		DB:  model.db,
 		Log: model.log,
 		Pwd: model.pwd,
+		// Per-stage retries run on the diff the pipeline already read.
+		FewShotPaths: aiengine.DiffPaths(model.diffCode),
+	}
+	if model.globalConfig.TUI.StreamResponses {
+		deps.OnChunk = model.pipeline.stream.onChunk
+		deps.OnRetry = model.pipeline.stream.onRetry
 	}
+	return deps
 }
 
 // recordStageStats copies a CallStats into the per-stage record on the
@@ -52,25 +68,67 @@ func recordStageStats(model *Model, id stageID, stats *api.CallStats) {
 	st.APITotalTime = stats.TotalTime
 	st.RequestID = stats.RequestID
 	st.StatsModel = stats.Model
+	st.Provider = stats.Provider
 	st.TPMLimitAtCall = stats.RateLimits.LimitTokens
+	st.Attempts = stats.Attempts
+}
+
+// recordStageFailure keeps the attempts of a stage no call served, as
+// carried by err, so the card lists them and the persistence layer can
+// write them to ai_calls. HasStats stays false. No-op when err carries
+// no attempts or the run was cancelled.
+func recordStageFailure(ctx context.Context, model *Model, id stageID, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	provider, attempts := aiengine.FailedAttempts(err)
+	recordStageAttempts(model, id, provider, attempts)
 }
 
-func iaCallCommitBodyGenerator(model *Model, summaryParagraphs string) (string, error) {
+func recordStageAttempts(model *Model, id stageID, provider string, attempts []api.CallAttempt) {
+	if model == nil || len(attempts) == 0 {
+		return
+	}
+	if int(id) < 0 || int(id) >= len(model.pipeline.stages) {
+		return
+	}
+	st := &model.pipeline.stages[id]
+	st.Provider = provider
+	st.Attempts = attempts
+}
+
+func iaCallCommitBodyGenerator(
+	ctx context.Context,
+	model *Model,
+	summaryParagraphs string,
+) (string, error) {
 	result, stats, err := aiengine.CallCommitBody(
-		engineDeps(model), model.commitType, model.commitScope, summaryParagraphs,
+		ctx, engineDeps(model), model.commitType, model.commitScope, summaryParagraphs,
 	)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
+		recordStageFailure(ctx, model, stageBody, err)
 		return "", fmt.Errorf("stage 2 (commit body): %w", err)
 	}
 	recordStageStats(model, stageBody, stats)
 	return result, nil
 }
 
-func iaCallCommitTitleGenerator(model *Model, commitBody string) (string, error) {
+func iaCallCommitTitleGenerator(
+	ctx context.Context,
+	model *Model,
+	commitBody string,
+) (string, error) {
 	result, stats, err := aiengine.CallCommitTitle(
-		engineDeps(model), model.commitType, model.commitScope, commitBody,
+		ctx, engineDeps(model), model.commitType, model.commitScope, commitBody,
 	)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
+		recordStageFailure(ctx, model, stageTitle, err)
 		return "", fmt.Errorf("stage 3 (commit title): %w", err)
 	}
 	recordStageStats(model, stageTitle, stats)
@@ -81,42 +139,75 @@ func iaCallCommitTitleGenerator(model *Model, commitBody string) (string, error)
 // the changelogActive flag is the single source of truth (set by
 // pipelineStartFullRun, which already evaluated cfg.Enabled plus the
 // dirty-file safeguard) so we re-check it here to preserve that gate.
-func runChangelogRefiner(model *Model) {
+// Returns ctx.Err() when the run was cancelled, leaving the changelog
+// fields untouched.
+func runChangelogRefiner(ctx context.Context, model *Model) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	model.iaChangelogEntry = ""
 	model.iaChangelogMentionLine = ""
 	if !model.changelogActive {
-		return
+		return nil
 	}
 	partial := aiengine.Output{
 		Body:   model.iaCommitRawOutput,
 		Title:  model.iaTitleRawOutput,
 		Stages: make([]aiengine.StageStats, 4),
 	}
-	aiengine.RunChangelogRefiner(engineDeps(model), &partial)
-	if partial.Stages[aiengine.StageChangelog].HasStats {
-		recordStageStats(
-			model,
-			stageChangelog,
-			stageStatsToCallStats(partial.Stages[aiengine.StageChangelog]),
-		)
+	aiengine.RunChangelogRefiner(ctx, engineDeps(model), &partial)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("stage 4 (changelog refiner): %w", err)
+	}
+	if cl := partial.Stages[aiengine.StageChangelog]; cl.HasStats {
+		recordStageStats(model, stageChangelog, stageStatsToCallStats(cl))
+	} else {
+		recordStageAttempts(model, stageChangelog, cl.Provider, cl.Attempts)
 	}
 	model.iaChangelogEntry = partial.ChangelogEntry
 	model.iaChangelogMentionLine = partial.ChangelogMentionLine
 	model.iaChangelogTargetPath = partial.ChangelogTargetPath
 	model.iaChangelogSuggestedVersion = partial.ChangelogSuggestedVersion
+	return nil
 }
 
 func assembleCommitMessage(titleText, commitBody string) string {
 	return fmt.Sprintf("%s\n\n%s", titleText, commitBody)
 }
 
+// assembleOutputCommitMessage is the message `git commit` receives: the
+// profile-formatted header + MessageEN, then the row's trailers.
 func assembleOutputCommitMessage(model *Model, c storage.Commit) (string, error) {
-	return commit.FormatFinalMessage(
-		model.globalConfig.CommitFormat.TypeFormat,
+	final, err := model.globalConfig.CommitFormat.MessageFormat().FinalMessage(
 		c.Type,
 		c.Scope,
 		c.MessageEN,
 	)
+	if err != nil {
+		return "", err
+	}
+	return commit.AppendTrailers(final, c.Trailers), nil
+}
+
+// releaseFinalMessage composes a release row's commit message under the
+// configured message-format profile: the branch takes the scope slot
+// and the body, when present, follows a blank line. Falls back to the
+// raw title/body when a field is missing so callers always get text.
+func releaseFinalMessage(model *Model, releaseType, branch, title, body string) string {
+	msg := strings.TrimSpace(title)
+	if body = strings.TrimSpace(body); body != "" {
+		msg += "\n\n" + body
+	}
+	final, err := model.globalConfig.CommitFormat.MessageFormat().FinalMessage(
+		releaseType,
+		branch,
+		msg,
+	)
+	if err != nil {
+		model.log.Warn("release message incomplete; using raw text", "error", err)
+		return msg
+	}
+	return final
 }
 
 // outputCommitMessageOrFallback wraps assembleOutputCommitMessage for
@@ -148,7 +239,7 @@ func composeFinalCommitMessage(model *Model) string {
 // ia_commit_builder runs the full 3-stage pipeline (plus optional
 // stage 4) end-to-end. Used by Ctrl+W on the writing-message screen
 // and by the full-pipeline retry command.
-func ia_commit_builder(model *Model) error {
+func ia_commit_builder(ctx context.Context, model *Model) error {
 	deps := engineDeps(model)
 	in := aiengine.Input{
 		KeyPoints:       model.keyPoints,
@@ -160,8 +251,24 @@ func ia_commit_builder(model *Model) error {
 		in.Diff = model.diffCode
 	}
 
-	out, err := aiengine.Run(deps, in)
+	out, err := aiengine.Run(ctx, deps, in)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		// Run records the attempts of the stage that failed; the stages
+		// before it served and are kept so the cards still read right.
+		for i := range out.Stages {
+			s := out.Stages[i]
+			if s.HasStats {
+				recordStageStats(model, stageID(i), stageStatsToCallStats(s))
+				continue
+			}
+			recordStageAttempts(model, stageID(i), s.Provider, s.Attempts)
+		}
 		return err
 	}
 
@@ -181,6 +288,7 @@ func ia_commit_builder(model *Model) error {
 		}
 		recordStageStats(model, stageID(i), stageStatsToCallStats(out.Stages[i]))
 	}
+	model.pipeline.stages[stageSummary].Chunks = out.Stages[aiengine.StageSummary].Chunks
 	model.log.Debug("Final commit message", "commitTranslate", model.commitTranslate)
 	return nil
 }
@@ -199,16 +307,18 @@ func stageStatsToCallStats(s aiengine.StageStats) *api.CallStats {
 		CompletionTime:   s.CompletionTime,
 		TotalTime:        s.APITotalTime,
 		RequestID:        s.RequestID,
+		Provider:         s.Provider,
 		Model:            s.StatsModel,
 		RateLimits:       api.RateLimits{LimitTokens: s.TPMLimitAtCall},
+		Attempts:         s.Attempts,
 	}
 }
 
 // iaReleaseBuilder runs the full 3-stage release pipeline. Thin
-// wrapper around iaReleaseCascade(model, stageSummary) preserved so
-// the existing initial-launch dispatch path stays unchanged.
-func iaReleaseBuilder(model *Model) (body, title, final string, err error) {
-	return iaReleaseCascade(model, stageSummary)
+// wrapper around iaReleaseCascade(ctx, model, stageSummary) preserved
+// so the existing initial-launch dispatch path stays unchanged.
+func iaReleaseBuilder(ctx context.Context, model *Model) (body, title, final string, err error) {
+	return iaReleaseCascade(ctx, model, stageSummary)
 }
 
 // iaReleaseCascade runs the release pipeline starting at `from` and
@@ -224,7 +334,11 @@ func iaReleaseBuilder(model *Model) (body, title, final string, err error) {
 // so the result can be applied to the model on the Bubble Tea main
 // goroutine — avoiding a race where View() reads the model field
 // before the cmd goroutine's mutation is published.
-func iaReleaseCascade(model *Model, from stageID) (body, title, final string, err error) {
+func iaReleaseCascade(
+	ctx context.Context,
+	model *Model,
+	from stageID,
+) (body, title, final string, err error) {
 	deps := engineDeps(model)
 	commits := make([]aiengine.ReleaseCommit, 0, len(model.selectedCommitList))
 	for _, item := range model.selectedCommitList {
@@ -241,8 +355,12 @@ func iaReleaseCascade(model *Model, from stageID) (body, title, final string, er
 	title = model.releaseTitleOutput
 
 	if from <= stageSummary {
-		text, stats, runErr := aiengine.RunReleaseBody(deps, in)
+		text, stats, runErr := aiengine.RunReleaseBody(ctx, deps, in)
+		if runErr == nil {
+			runErr = ctx.Err()
+		}
 		if runErr != nil {
+			recordStageFailure(ctx, model, stageSummary, runErr)
 			return "", "", "", wrapReleaseErr(model, runErr)
 		}
 		body = text
@@ -250,8 +368,12 @@ func iaReleaseCascade(model *Model, from stageID) (body, title, final string, er
 	}
 
 	if from <= stageBody {
-		text, stats, runErr := aiengine.RunReleaseTitle(deps, body, in)
+		text, stats, runErr := aiengine.RunReleaseTitle(ctx, deps, body, in)
+		if runErr == nil {
+			runErr = ctx.Err()
+		}
 		if runErr != nil {
+			recordStageFailure(ctx, model, stageBody, runErr)
 			return "", "", "", wrapReleaseErr(model, runErr)
 		}
 		title = text
@@ -261,8 +383,12 @@ func iaReleaseCascade(model *Model, from stageID) (body, title, final string, er
 	// Refine always runs — it's the cheapest stage and the user-visible
 	// output of the cascade lives in the returned `final` string, which
 	// the Update handler writes into model.releaseFinalOutput.
-	refined, stats, runErr := aiengine.RunReleaseRefine(deps, body, title)
+	refined, stats, runErr := aiengine.RunReleaseRefine(ctx, deps, body, title)
+	if runErr == nil {
+		runErr = ctx.Err()
+	}
 	if runErr != nil {
+		recordStageFailure(ctx, model, stageTitle, runErr)
 		return "", "", "", wrapReleaseErr(model, runErr)
 	}
 	final = refined
@@ -273,8 +399,12 @@ func iaReleaseCascade(model *Model, from stageID) (body, title, final string, er
 
 // wrapReleaseErr funnels per-stage errors into the same log + status
 // shape iaReleaseBuilder used to produce, so callers and the Update
-// handler don't need to special-case partial-cascade failures.
+// handler don't need to special-case partial-cascade failures. A
+// cancelled run is passed through untouched: nobody reads its result.
 func wrapReleaseErr(model *Model, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
 	model.log.Error(
 		fmt.Sprintf("An error occurred while trying to generate the release output.\n%s", err),
 	)
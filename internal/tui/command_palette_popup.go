@@ -34,6 +34,7 @@ const (
 	cmdShowTagPalette      = "tags.show"
 	cmdConfigureRelease    = "release.configure"
 	cmdConfigureChangelog  = "changelog.configure"
+	cmdSplitStaged         = "pipeline.split"
 )
 
 // builtinCommands is the seed registry. Add entries here as new actions
@@ -56,7 +57,7 @@ func builtinCommands(theme *styles.Theme) []paletteCommand {
 		{
 			ID:          cmdConfigureRelease,
 			Title:       "Configure release",
-			Description: "Set repository, branch, version, assets path and GH_TOKEN",
+			Description: "Set repository, branch, version, assets path and host token",
 			Icon:        sym.ConfigureRelease,
 		},
 		{
@@ -65,6 +66,12 @@ func builtinCommands(theme *styles.Theme) []paletteCommand {
 			Description: "Toggle and tune the post-pipeline CHANGELOG entry",
 			Icon:        sym.ConfigureChangelog,
 		},
+		{
+			ID:          cmdSplitStaged,
+			Title:       "Split staged changes",
+			Description: "Plan one commit per logical group and create a draft for each",
+			Icon:        sym.SplitPlan,
+		},
 	}
 }
 
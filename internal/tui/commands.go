@@ -7,48 +7,56 @@ import (
 )
 
 func callIaCommitBuilderCmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		err := ia_commit_builder(model)
-		return IaCommitBuilderResultMsg{Err: err}
+		err := ia_commit_builder(ctx, model)
+		return IaCommitBuilderResultMsg{Err: err, RunID: runID}
 	}
 }
 
 func callIaSummaryCmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		err := ia_commit_builder(model)
-		return IaSummaryResultMsg{Err: err}
+		err := ia_commit_builder(ctx, model)
+		return IaSummaryResultMsg{Err: err, RunID: runID}
 	}
 }
 
 func callIaCommitBuilderStage2Cmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		commitBody, err := iaCallCommitBodyGenerator(model, model.iaSummaryOutput)
+		commitBody, err := iaCallCommitBodyGenerator(ctx, model, model.iaSummaryOutput)
 		if err != nil {
-			return IaCommitRawResultMsg{Err: err}
+			return IaCommitRawResultMsg{Err: err, RunID: runID}
 		}
 		model.iaCommitRawOutput = commitBody
 
-		titleText, err := iaCallCommitTitleGenerator(model, commitBody)
+		titleText, err := iaCallCommitTitleGenerator(ctx, model, commitBody)
 		if err != nil {
-			return IaCommitRawResultMsg{Err: err}
+			return IaCommitRawResultMsg{Err: err, RunID: runID}
 		}
 		model.iaTitleRawOutput = titleText
-		runChangelogRefiner(model)
+		if err := runChangelogRefiner(ctx, model); err != nil {
+			return IaCommitRawResultMsg{Err: err, RunID: runID}
+		}
 		model.commitTranslate = composeFinalCommitMessage(model)
-		return IaCommitRawResultMsg{Err: nil}
+		return IaCommitRawResultMsg{Err: nil, RunID: runID}
 	}
 }
 
 func callIaOutputFormatCmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		titleText, err := iaCallCommitTitleGenerator(model, model.iaCommitRawOutput)
+		titleText, err := iaCallCommitTitleGenerator(ctx, model, model.iaCommitRawOutput)
 		if err != nil {
-			return IaOutputFormatResultMsg{Err: err}
+			return IaOutputFormatResultMsg{Err: err, RunID: runID}
 		}
 		model.iaTitleRawOutput = titleText
-		runChangelogRefiner(model)
+		if err := runChangelogRefiner(ctx, model); err != nil {
+			return IaOutputFormatResultMsg{Err: err, RunID: runID}
+		}
 		model.commitTranslate = composeFinalCommitMessage(model)
-		return IaOutputFormatResultMsg{Err: nil}
+		return IaOutputFormatResultMsg{Err: nil, RunID: runID}
 	}
 }
 
@@ -57,18 +65,23 @@ func callIaOutputFormatCmd(model *Model) tea.Cmd {
 // shortcut so the user can iterate on the entry without re-spending tokens
 // on the upstream stages.
 func callIaChangelogOnlyCmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		runChangelogRefiner(model)
+		if err := runChangelogRefiner(ctx, model); err != nil {
+			return IaChangelogResultMsg{Err: err, RunID: runID}
+		}
 		model.commitTranslate = composeFinalCommitMessage(model)
-		return IaChangelogResultMsg{Err: nil}
+		return IaChangelogResultMsg{Err: nil, RunID: runID}
 	}
 }
 
 func callIaReleaseBuilderCmd(model *Model) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		body, title, final, err := iaReleaseBuilder(model)
+		body, title, final, err := iaReleaseBuilder(ctx, model)
 		return IaReleaseBuilderResultMsg{
 			Err:   err,
+			RunID: runID,
 			From:  stageSummary,
 			Body:  body,
 			Title: title,
@@ -81,10 +94,12 @@ func callIaReleaseBuilderCmd(model *Model) tea.Cmd {
 // `from` (stageSummary / stageBody / stageTitle). Used by the
 // per-stage retry shortcuts in updateReleaseBuildingText.
 func callIaReleaseCascadeCmd(model *Model, from stageID) tea.Cmd {
+	ctx, runID := model.pipeline.beginRun()
 	return func() tea.Msg {
-		body, title, final, err := iaReleaseCascade(model, from)
+		body, title, final, err := iaReleaseCascade(ctx, model, from)
 		return IaReleaseBuilderResultMsg{
 			Err:   err,
+			RunID: runID,
 			From:  from,
 			Body:  body,
 			Title: title,
@@ -123,7 +138,7 @@ func openReleaseConfigPopup(model *Model, autoOpen bool) releaseConfigPopupModel
 		BuildTool:   model.globalConfig.ReleaseConfig.BuildTool,
 		BuildTarget: model.globalConfig.ReleaseConfig.BuildTarget,
 	}
-	detected := DetectRelease(model.pwd)
+	detected := DetectRelease(model.pwd, model.globalConfig.ReleaseConfig.Host)
 	tools := ListBuildTools()
 	targets := ListMakefileTargets(model.pwd)
 	return newReleaseConfigPopup(
@@ -52,7 +52,8 @@ func styleMentions(s string, textStyle lipgloss.Style) string {
 
 // renderComposeTypeRow renders the "commit type" section as a single
 // horizontal line: the section label followed by the chip of the
-// currently selected commit type. Other types are not displayed here —
+// currently selected commit type, plus the inferred type and its
+// confidence when there is one. Other types are not displayed here —
 // the picker popup is the place to switch.
 func (model *Model) renderComposeTypeRow(width int, focused bool) string {
 	theme := model.Theme
@@ -66,6 +67,10 @@ func (model *Model) renderComposeTypeRow(width int, focused bool) string {
 	}
 
 	chip := model.commitTypeChip(model.commitType, "", true)
+	if hint := model.tagSuggestionLabel(); hint != "" {
+		muted := base.Foreground(theme.Muted).Render(hint)
+		return lipgloss.JoinHorizontal(lipgloss.Center, label, " ", chip, "  ", muted)
+	}
 	return lipgloss.JoinHorizontal(lipgloss.Center, label, " ", chip)
 }
 
@@ -175,6 +180,43 @@ func (model *Model) scopeEditButton(highlighted bool) string {
 		Render("edit")
 }
 
+// renderComposeTrailersRow renders the "trailers" section on one line:
+// label, a muted pill per trailer (the one under the cursor highlighted
+// while the section has focus) and the "edit" affordance. Pills that
+// don't fit are summarised as "+N".
+func (model *Model) renderComposeTrailersRow(width int, focused bool) string {
+	theme := model.Theme
+	base := theme.AppStyles().Base
+
+	label := theme.SectionPill(focused).Render("trailers")
+	trailers := model.currentCommit.Trailers
+	edit := model.scopeEditButton(focused && len(trailers) == 0)
+
+	budget := width - lipgloss.Width(label) - lipgloss.Width(edit) - 2
+	cells := []string{label}
+	if len(trailers) == 0 {
+		cells = append(cells, " ", base.Foreground(theme.Muted).Italic(true).Render("none"))
+	}
+	for i, line := range trailers {
+		bg, fg := theme.Surface, theme.FG
+		if focused && i == model.trailerIndex {
+			bg, fg = theme.Primary, theme.BG
+		}
+		pill := base.Background(bg).Foreground(fg).Padding(0, 1).Render(trailerChipLabel(line))
+		rest := len(trailers) - i
+		more := base.Foreground(theme.Muted).Render(fmt.Sprintf("+%d", rest))
+		if lipgloss.Width(pill)+1 > budget || (rest > 1 && lipgloss.Width(pill)+lipgloss.Width(more)+2 > budget) {
+			cells = append(cells, " ", more)
+			break
+		}
+		cells = append(cells, " ", pill)
+		budget -= lipgloss.Width(pill) + 1
+	}
+	cells = append(cells, " ", edit)
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, cells...)
+}
+
 // pickCommitTypeTag deterministically maps a string (typically a file
 // path) to one of the commit-type palette keys, so chips for the same
 // file always look identical between renders while different files get
@@ -300,7 +342,9 @@ func (model *Model) renderComposePipelineModelsArea(width int, focused bool) str
 	// Each stage gets 3 lines: the model label, an RPD bar, and a TPM
 	// bar. Bars are fed by the in-memory rate-limit cache that
 	// ratelimit_cache.go hydrates from x-ratelimit-* response headers
-	// on every Groq call.
+	// on every Groq call. The Change Analyzer adds a CTX bar: the
+	// tokenizer-backed estimate of its payload (key points + staged
+	// diff) against the model's context window.
 	rows := make([]string, 0, len(stages)*3)
 	barWidth := width - 2
 	if barWidth < 24 {
@@ -358,6 +402,11 @@ func (model *Model) renderComposePipelineModelsArea(width int, focused bool) str
 			tpm = renderThinQuotaBar(theme, "TPM", 0, 0, barWidth)
 		}
 		rows = append(rows, "    "+rpd, "    "+tpm)
+		if s.stage == config.StageChangeAnalyzer && modelName != "" {
+			used, _ := composeContextEstimate(model, modelName)
+			rows = append(rows, "    "+renderThinQuotaBar(theme, "CTX",
+				used, lookupModelContext(model, modelName), barWidth))
+		}
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left,
@@ -3,9 +3,12 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/tui/statusbar"
@@ -61,6 +64,10 @@ func composeBottomBarContent(
 		body := composeScopeBody(model)
 		return statusbar.LevelChangelog, "SCOPE", body, ""
 
+	case focusComposeTrailers:
+		body := composeTrailersBody(model)
+		return statusbar.LevelInfo, "TRAILERS", body, ""
+
 	case focusComposeSummary, focusMsgInput:
 		chars := composeCharCount(model)
 		body := fmt.Sprintf("%d / %d chars", chars, composeMaxChars)
@@ -142,6 +149,17 @@ func composeScopeBody(model *Model) string {
 	return fmt.Sprintf("%d %s staged · +%d −%d", len(numstat), noun, adds, dels)
 }
 
+// composeTrailersBody shows the highlighted trailer in full (the row
+// only has room for short pills), or how to add one.
+func composeTrailersBody(model *Model) string {
+	trailers := model.currentCommit.Trailers
+	if len(trailers) == 0 {
+		return "no trailers · press e to add co-authors, refs or a sign-off"
+	}
+	i := min(max(0, model.trailerIndex), len(trailers)-1)
+	return fmt.Sprintf("%d/%d · %s", i+1, len(trailers), trailers[i])
+}
+
 // commitTypeDescription returns the description of the currently
 // selected commit type, looked up against the resolved type list.
 func commitTypeDescription(model *Model, tag string) string {
@@ -154,8 +172,9 @@ func commitTypeDescription(model *Model, tag string) string {
 }
 
 // composePipelineModelBody returns "<model id> · ctx Nk" for the stage
-// currently under the pipeline-models cursor. Falls back to "(unset)"
-// when no model is configured for the stage.
+// currently under the pipeline-models cursor, plus the estimated payload
+// and the tokenizer that counted it for the Change Analyzer. Falls back
+// to "(unset)" when no model is configured for the stage.
 func composePipelineModelBody(model *Model) string {
 	stages := composePipelineStages(model)
 	if len(stages) == 0 {
@@ -170,11 +189,16 @@ func composePipelineModelBody(model *Model) string {
 	if modelID == "" {
 		return stage.label + " · (unset)"
 	}
-	ctx := lookupModelContext(model, modelID)
-	if ctx == 0 {
-		return fmt.Sprintf("%s · %s", stage.label, modelID)
+	body := stage.label + " · " + modelID
+	if ctx := lookupModelContext(model, modelID); ctx > 0 {
+		body += fmt.Sprintf(" · %dk ctx", ctx/1000)
 	}
-	return fmt.Sprintf("%s · %s · %dk ctx", stage.label, modelID, ctx/1000)
+	if stage.stage == config.StageChangeAnalyzer {
+		if tokens, tok := composeContextEstimate(model, modelID); tokens > 0 {
+			body += fmt.Sprintf(" · ~%s tok (%s)", formatQuantity(tokens), tok)
+		}
+	}
+	return body
 }
 
 // lookupModelContext returns the cached context window for modelID, or 0
@@ -237,6 +261,15 @@ func helpEntriesForFocus(f focusableElement) []helpEntry {
 			{"esc", "back"},
 			{"?", "help"},
 		}
+	case focusComposeTrailers:
+		return []helpEntry{
+			{"e/↵", "edit trailers"},
+			{"← →", "select"},
+			{"x", "remove"},
+			{"tab", "next section"},
+			{"esc", "back"},
+			{"?", "help"},
+		}
 	case focusComposeSummary, focusMsgInput:
 		return []helpEntry{
 			{"^W", "generate"},
@@ -333,6 +366,7 @@ func helpEntriesForState(s appState, mode appMode) []helpEntry {
 			{"→", "enter dir"},
 			{"←", "parent"},
 			{"↵", "select"},
+			{"^o", "suggested scope"},
 			{"/", "filter"},
 			{"esc", "back"},
 		}
@@ -500,3 +534,102 @@ func (model *Model) renderHelpEntries(entries []helpEntry) string {
 	}
 	return lipgloss.JoinHorizontal(lipgloss.Top, parts...)
 }
+
+// composeCtxEstimate is the cached stage-1 payload estimate for the
+// compose CTX bar. Update refreshes it through composeCtxEstimateCmd and
+// View only reads it. inputs fingerprints the model and key points, key
+// adds the staged numstat; checkedAt throttles the numstat probe and
+// pending keeps a single refresh in flight.
+type composeCtxEstimate struct {
+	modelID   string
+	inputs    string
+	key       string
+	tokens    int
+	tokenizer string
+	checkedAt time.Time
+	pending   bool
+}
+
+// composeCtxEstimateMsg is the result of composeCtxEstimateCmd. keep
+// says the cached tokens still hold: the staged numstat did not change,
+// or the diff could not be read and key stays put so the next probe
+// retries.
+type composeCtxEstimateMsg struct {
+	modelID   string
+	inputs    string
+	key       string
+	tokens    int
+	tokenizer string
+	keep      bool
+}
+
+// composeCtxEstimateTTL bounds how often the compose screen re-reads the
+// staged numstat to notice new `git add`s.
+const composeCtxEstimateTTL = 2 * time.Second
+
+// composeCtxEstimateCmd estimates the Change Analyzer payload, in
+// tokens, for the current key points and the budgeted staged diff,
+// counted with the model's tokenizer (heuristic without a vocabulary).
+// It returns nil while a refresh is in flight, or when the model and
+// key points are unchanged and the last probe is younger than the TTL.
+// The diff is only re-read and re-tokenized when the staged numstat
+// moved as well.
+func composeCtxEstimateCmd(model *Model) tea.Cmd {
+	est := &model.ctxEstimate
+	modelID := config.CurrentModelForStage(model.globalConfig, config.StageChangeAnalyzer)
+	if modelID == "" || est.pending {
+		return nil
+	}
+	inputs := modelID + "\x00" + strings.Join(model.keyPoints, "\n")
+	if inputs == est.inputs && time.Since(est.checkedAt) < composeCtxEstimateTTL {
+		return nil
+	}
+	est.pending = true
+	prevKey := est.key
+	cfg := model.globalConfig
+	cfg.Prompts.ChangeAnalyzerPromptModel = modelID
+	keyPoints := append([]string(nil), model.keyPoints...)
+	return func() tea.Msg {
+		msg := composeCtxEstimateMsg{modelID: modelID, inputs: inputs}
+		numstat, err := git.GetStagedNumstat()
+		if err != nil || len(numstat) == 0 {
+			return msg
+		}
+		// fmt prints maps with sorted keys, so the fingerprint is stable.
+		msg.key = inputs + "\x00" + fmt.Sprint(numstat)
+		if msg.key == prevKey {
+			msg.keep = true
+			return msg
+		}
+		budget, err := git.BudgetStagedDiff(cfg.Prompts.ChangeAnalyzerMaxDiffSize)
+		if err != nil {
+			msg.key, msg.keep = prevKey, true
+			return msg
+		}
+		e := aiengine.EstimateChangeAnalyzer(cfg, budget.Diff, keyPoints)
+		msg.tokens, msg.tokenizer = e.EstTokens, e.Tokenizer
+		return msg
+	}
+}
+
+// applyComposeCtxEstimate stores a composeCtxEstimateMsg in the cache.
+func applyComposeCtxEstimate(model *Model, msg composeCtxEstimateMsg) {
+	est := &model.ctxEstimate
+	est.pending = false
+	est.modelID, est.inputs, est.key = msg.modelID, msg.inputs, msg.key
+	est.checkedAt = time.Now()
+	if !msg.keep {
+		est.tokens, est.tokenizer = msg.tokens, msg.tokenizer
+	}
+}
+
+// composeContextEstimate returns the cached Change Analyzer payload
+// estimate for modelID; 0 when nothing is staged or the cache was built
+// for another model.
+func composeContextEstimate(model *Model, modelID string) (int, string) {
+	est := model.ctxEstimate
+	if est.modelID != modelID {
+		return 0, ""
+	}
+	return est.tokens, est.tokenizer
+}
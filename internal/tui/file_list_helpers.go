@@ -27,7 +27,7 @@ type UpdateFileListFunc func(pwd string, l *list.Model, gitData git.StatusData)
 // toggle across reloads (for instance after a delete) instead of
 // silently snapping back to "completed".
 func UpdateCommitList(
-	pwd string,
+	scope historyScope,
 	db *storage.DB,
 	log *logger.Logger,
 	l *list.Model,
@@ -41,18 +41,14 @@ func UpdateCommitList(
 		if status == "" {
 			status = "completed"
 		}
-		workspaceCommits, err := db.GetCommits(pwd, status)
+		workspaceCommits, err := scope.commits(db, status)
 		if err != nil {
 			log.Error("Error reloading the list of commits", "error", err)
 			return err
 		}
-
-		items = make([]list.Item, len(workspaceCommits))
-		for i, c := range workspaceCommits {
-			items[i] = HistoryCommitItem{commit: c}
-		}
+		items = scope.items(workspaceCommits)
 	case releaseDb:
-		workspaceReleases, err := db.GetReleases(pwd)
+		workspaceReleases, err := db.GetReleases(scope.pwd)
 		if err != nil {
 			log.Error("Error reloading the list of releases", "error", err)
 			return err
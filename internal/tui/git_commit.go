@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/tui/statusbar"
+)
+
+// gitCommitResultMsg reports the outcome of the Output screen's commit
+// action. Hash is the full hash of the new commit when Err is nil.
+type gitCommitResultMsg struct {
+	ID   int
+	Hash string
+	Err  error
+}
+
+// gitCommitOptions projects the resolved [git_commit] config onto the
+// git package options.
+func gitCommitOptions(model *Model) git.CommitOptions {
+	gc := model.globalConfig.GitCommit
+	return git.CommitOptions{
+		SignOff:    gc.SignOff,
+		Sign:       gc.Sign,
+		SigningKey: gc.SigningKey,
+		NoVerify:   gc.NoVerify,
+	}
+}
+
+// startGitCommit validates that the Output screen can commit and kicks
+// off execGitCommit. Reword sessions keep their post-TUI amend/rebase
+// path, and a row that is already linked to a hash is not committed a
+// second time.
+func startGitCommit(model *Model) tea.Cmd {
+	switch {
+	case model.gitCommitting:
+		return nil
+	case model.RewordHash != "":
+		return model.WritingStatusBar.ShowMessageForDuration(
+			"Reword session · enter applies the new message to the original commit",
+			statusbar.LevelWarning,
+			3*time.Second,
+		)
+	case model.currentCommit.CommitHash != "":
+		return model.WritingStatusBar.ShowMessageForDuration(
+			fmt.Sprintf("Already committed as %s", shortCommitHash(model.currentCommit.CommitHash)),
+			statusbar.LevelWarning,
+			3*time.Second,
+		)
+	}
+	model.gitCommitting = true
+	model.WritingStatusBar.Level = statusbar.LevelWarning
+	model.WritingStatusBar.Content = "Running git commit…"
+	return tea.Batch(model.WritingStatusBar.StartSpinner(), execGitCommit(model))
+}
+
+// execGitCommit runs `git commit` in the workspace with the assembled
+// final message. The message and options are captured on the main
+// goroutine so the command never reads the Model concurrently. Drafts
+// from a split plan commit only their files (plus the CHANGELOG the
+// finalize step staged); the other groups stay staged.
+func execGitCommit(model *Model) tea.Cmd {
+	id := model.currentCommit.ID
+	message := outputCommitMessageOrFallback(model, model.currentCommit)
+	workspace := model.pwd
+	opts := gitCommitOptions(model)
+	var paths []string
+	if files := model.currentCommit.Files; len(files) > 0 {
+		paths = append(paths, files...)
+		if model.iaChangelogTargetPath != "" {
+			paths = append(paths, model.iaChangelogTargetPath)
+		}
+	}
+	return func() tea.Msg {
+		hash, err := git.CommitPathsAt(workspace, message, opts, paths)
+		return gitCommitResultMsg{ID: id, Hash: hash, Err: err}
+	}
+}
+
+// handleGitCommitResult links the new hash to the row (the TUI
+// equivalent of `ai link-commit`) and exits, letting main.go report the
+// commit. Failures stay on the Output screen with a one-line summary in
+// the status bar; the full git output goes to the logs.
+func handleGitCommitResult(model *Model, msg gitCommitResultMsg) (tea.Model, tea.Cmd) {
+	model.gitCommitting = false
+	stop := model.WritingStatusBar.StopSpinner()
+	if msg.Err != nil {
+		model.log.Error("git commit failed", "error", msg.Err)
+		return model, tea.Batch(stop, model.WritingStatusBar.ShowMessageForDuration(
+			fmt.Sprintf("git commit failed: %s", gitErrorSummary(msg.Err)),
+			statusbar.LevelError,
+			5*time.Second,
+		))
+	}
+	if msg.ID != 0 {
+		if err := model.db.LinkCommitHash(msg.ID, msg.Hash); err != nil {
+			model.log.Error("linking commit hash failed", "error", err, "hash", msg.Hash)
+		}
+	}
+	model.currentCommit.CommitHash = msg.Hash
+	model.CommittedHash = msg.Hash
+	_, quit := quitWithAutodraft(model)
+	return model, tea.Batch(stop, quit)
+}
+
+// gitErrorSummary picks the first line of git's own output from a
+// CommitAt error ("error: gpg failed to sign the data", a hook's
+// message…), falling back to the wrapped exit status.
+func gitErrorSummary(err error) string {
+	lines := strings.Split(strings.TrimSpace(err.Error()), "\n")
+	for _, l := range lines[1:] {
+		if l = strings.TrimSpace(l); l != "" {
+			return l
+		}
+	}
+	return lines[0]
+}
+
+func shortCommitHash(h string) string {
+	if len(h) > 7 {
+		return h[:7]
+	}
+	return h
+}
@@ -8,6 +8,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/storage"
 	"commit_craft_reborn/internal/tui/styles"
 )
@@ -131,8 +132,13 @@ func (p *HistoryDualPanel) SetCommit(c storage.Commit, calls []storage.AICall) {
 			continue
 		}
 		st := &p.stageStats[id]
+		if !call.Served() {
+			st.Attempts = append(st.Attempts, aiengine.AttemptFromCall(call))
+			continue
+		}
 		st.HasStats = true
 		st.StatsModel = call.Model
+		st.Provider = call.Provider
 		st.Model = call.Model
 		st.PromptTokens = call.PromptTokens
 		st.CompletionTokens = call.CompletionTokens
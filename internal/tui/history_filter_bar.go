@@ -21,6 +21,10 @@ const (
 	FilterModeID
 	FilterModeType
 	FilterModeScope
+	// FilterModeSemantic ranks commits by meaning instead of substring:
+	// the query and each commit are embedded (see history_semantic.go)
+	// and the list shows the closest ones first.
+	FilterModeSemantic
 )
 
 // mainFilterModeOrder is the cycle order used by CycleMode and the
@@ -30,6 +34,7 @@ var mainFilterModeOrder = []MainFilterMode{
 	FilterModeID,
 	FilterModeType,
 	FilterModeScope,
+	FilterModeSemantic,
 }
 
 // mainFilterModeMeta stores the visible label and the commit-type
@@ -40,10 +45,11 @@ var mainFilterModeMeta = map[MainFilterMode]struct {
 	label string
 	tag   string
 }{
-	FilterModeTitle: {"TITLE", "ADD"},  // greenish
-	FilterModeID:    {"ID", "WIP"},     // amber
-	FilterModeType:  {"TYPE", "STYLE"}, // purple
-	FilterModeScope: {"SCOPE", "SEC"},  // pink/red
+	FilterModeTitle:    {"TITLE", "ADD"},    // greenish
+	FilterModeID:       {"ID", "WIP"},       // amber
+	FilterModeType:     {"TYPE", "STYLE"},   // purple
+	FilterModeScope:    {"SCOPE", "SEC"},    // pink/red
+	FilterModeSemantic: {"SEMANTIC", "DOC"}, // blue
 }
 
 // currentMainFilterMode is read by HistoryCommitItem.FilterValue so
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"charm.land/bubbles/v2/list"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+)
+
+// historyScope picks the commit rows the History list shows: everything
+// written inside the current worktree (subdirectories included, nested
+// submodules excluded), or with allWorktrees everything written in any
+// worktree of the same repository. Outside git the rows written from
+// pwd itself, as before worktree identities existed.
+type historyScope struct {
+	pwd          string
+	workspace    git.WorkspaceID
+	allWorktrees bool
+}
+
+func (model *Model) historyScope() historyScope {
+	return historyScope{
+		pwd:          model.pwd,
+		workspace:    model.workspaceID,
+		allWorktrees: model.historyAllWorktrees,
+	}
+}
+
+// commits resolves the identity of rows saved since the last call, then
+// loads the scope's rows with status.
+func (s historyScope) commits(db *storage.DB, status string) ([]storage.Commit, error) {
+	if err := db.ResolveWorkspaceIDs(git.WorkspaceKeys); err != nil {
+		return nil, err
+	}
+	switch {
+	case s.workspace.Worktree == "":
+		return db.GetCommits(s.pwd, status)
+	case s.allWorktrees:
+		return db.GetCommitsByRepo(s.workspace.RepoID, status)
+	}
+	return db.GetCommitsByWorktree(s.workspace.Worktree, status)
+}
+
+// items wraps commits as History rows. Across worktrees, rows written in
+// another checkout carry its directory name so the list tells them apart.
+func (s historyScope) items(commits []storage.Commit) []list.Item {
+	items := make([]list.Item, len(commits))
+	for i, c := range commits {
+		item := HistoryCommitItem{commit: c}
+		if s.allWorktrees && c.Worktree != "" && c.Worktree != s.workspace.Worktree {
+			item.worktree = filepath.Base(c.Worktree)
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// label describes the scope for the History status message.
+func (s historyScope) label() string {
+	if !s.allWorktrees || s.workspace.Worktree == "" {
+		return "this worktree"
+	}
+	if paths, err := git.WorktreePaths(s.workspace.Worktree); err == nil && len(paths) > 1 {
+		return fmt.Sprintf("all %d worktrees", len(paths))
+	}
+	return "all worktrees"
+}
+
+// reloadHistory reloads the commit list for the current draft toggle and
+// scope and titles it accordingly.
+func (model *Model) reloadHistory() error {
+	status := "completed"
+	msg := "Showing completed commits"
+	if model.draftMode {
+		status = "draft"
+		msg = "Showing drafts"
+	}
+	scope := model.historyScope()
+	commits, err := scope.commits(model.db, status)
+	if err != nil {
+		return err
+	}
+	items := scope.items(commits)
+	model.mainList.SetItems(items)
+	// Ensure the viewport is updated
+	if len(items) > 0 {
+		model.mainList.Select(0)
+	}
+	if model.workspaceID.Worktree != "" {
+		msg += " · " + scope.label()
+	}
+	model.mainList.Title = msg
+	return nil
+}
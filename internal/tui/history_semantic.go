@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+
+	"commit_craft_reborn/internal/aiengine"
+)
+
+// semanticDebounce is how long the filter waits after the last keystroke
+// before embedding the query, so a remote embeddings provider is called
+// once per pause rather than once per key.
+const semanticDebounce = 250 * time.Millisecond
+
+// semanticRanks holds the scores of the last finished semantic search,
+// keyed by commit id. historyListFilter reads it the same way FilterValue
+// reads currentMainFilterMode: the bubbles list calls the filter func
+// without access to the model. nil means no search has finished yet.
+var semanticRanks map[int]float64
+
+// semanticDebounceMsg fires semanticDebounce after a filter change; seq
+// drops it when another change came in meanwhile.
+type semanticDebounceMsg struct{ seq int }
+
+// semanticResultMsg carries a finished search back to Update.
+type semanticResultMsg struct {
+	seq    int
+	result aiengine.SearchResult
+	err    error
+}
+
+// historyListFilter is the History list's filter func. Outside semantic
+// mode it is list.DefaultFilter; in semantic mode the targets are commit
+// ids (see HistoryCommitItem.FilterValue) and the ranks come from the
+// last semantic search, best first. Until a search has finished every
+// commit is kept in its original order.
+func historyListFilter(term string, targets []string) []list.Rank {
+	if CurrentMainFilterMode() != FilterModeSemantic {
+		return list.DefaultFilter(term, targets)
+	}
+	type scored struct {
+		index int
+		score float64
+	}
+	var kept []scored
+	for i, t := range targets {
+		id, _ := strconv.Atoi(t)
+		score, ok := semanticRanks[id]
+		if semanticRanks != nil && !ok {
+			continue
+		}
+		kept = append(kept, scored{i, score})
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].score > kept[j].score })
+	ranks := make([]list.Rank, len(kept))
+	for i, k := range kept {
+		ranks[i] = list.Rank{Index: k.index}
+	}
+	return ranks
+}
+
+// scheduleSemanticSearch starts the debounce for the current filter text
+// when the semantic mode is active. An empty query clears the ranks.
+func (model *Model) scheduleSemanticSearch() tea.Cmd {
+	model.semanticSeq++
+	if CurrentMainFilterMode() != FilterModeSemantic || model.historyView.FilterValue() == "" {
+		semanticRanks = nil
+		return nil
+	}
+	seq := model.semanticSeq
+	return tea.Tick(semanticDebounce, func(time.Time) tea.Msg {
+		return semanticDebounceMsg{seq: seq}
+	})
+}
+
+// runSemanticSearch embeds the filter text and the listed commits off
+// the main goroutine. Inputs are captured here.
+func (model *Model) runSemanticSearch(msg semanticDebounceMsg) tea.Cmd {
+	if msg.seq != model.semanticSeq {
+		return nil
+	}
+	items := model.mainList.Items()
+	docs := make([]aiengine.SearchDoc, 0, len(items))
+	for _, it := range items {
+		if hci, ok := it.(HistoryCommitItem); ok {
+			docs = append(docs, aiengine.CommitSearchDoc(hci.commit))
+		}
+	}
+	deps := splitDeps(model)
+	query := model.historyView.FilterValue()
+	return func() tea.Msg {
+		res, err := aiengine.SearchDocs(context.Background(), deps, docs, query, 0)
+		return semanticResultMsg{seq: msg.seq, result: res, err: err}
+	}
+}
+
+func handleSemanticResult(model *Model, msg semanticResultMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != model.semanticSeq {
+		return model, nil
+	}
+	if msg.err != nil {
+		model.log.Warn("semantic search failed", "error", msg.err)
+		return model, nil
+	}
+	if msg.result.Warning != "" {
+		model.log.Warn("semantic search fallback", "warning", msg.result.Warning)
+	}
+	semanticRanks = make(map[int]float64, len(msg.result.Hits))
+	for _, h := range msg.result.Hits {
+		semanticRanks[h.ID] = h.Score
+	}
+	if val := model.historyView.FilterValue(); val != "" {
+		// Reset+set re-runs historyListFilter with the new ranks.
+		model.mainList.SetFilterText("")
+		model.mainList.SetFilterText(val)
+		model.mainList.SetFilterState(list.Filtering)
+		syncHistoryViewSelection(model)
+	}
+	return model, nil
+}
@@ -292,7 +292,7 @@ func workspaceKeybindings(k KeyMap) []keybindingGroup {
 				{bindKeys(k.Up, k.Down), "move cursor"},
 				{bindKeys(k.Enter), "open commit"},
 				{bindKeys(k.Filter), "filter"},
-				{bindKeys(k.CycleFilterMode), "cycle filter mode"},
+				{bindKeys(k.CycleFilterMode), "cycle filter mode (TITLE/ID/TYPE/SCOPE/SEMANTIC)"},
 			},
 		},
 		{
@@ -312,6 +312,7 @@ func workspaceKeybindings(k KeyMap) []keybindingGroup {
 				{bindKeys(k.ReleaseCommit), "create release"},
 				{bindKeys(k.Delete), "delete"},
 				{bindKeys(k.ToggleDrafts), "toggle drafts view"},
+				{bindKeys(k.ToggleWorktrees), "this worktree / all worktrees"},
 			},
 		},
 		{
@@ -25,6 +25,7 @@ type KeyMap struct {
 	Quit            key.Binding
 	GlobalQuit      key.Binding
 	Toggle          key.Binding
+	SuggestedScope  key.Binding
 	Help            key.Binding
 	History         key.Binding
 	Esc             key.Binding
@@ -40,9 +41,11 @@ type KeyMap struct {
 	EditIaCommit    key.Binding
 	ReleaseCommit   key.Binding
 	ToggleDrafts    key.Binding
+	ToggleWorktrees key.Binding
 	SwapMode        key.Binding
 	CycleNext       key.Binding
 	CyclePrev       key.Binding
+	GitCommit       key.Binding
 
 	// TextArea
 	insertLine      key.Binding
@@ -62,6 +65,7 @@ type KeyMap struct {
 	RerunStage4 key.Binding
 	FileUp      key.Binding
 	FileDown    key.Binding
+	HunkMode    key.Binding
 }
 
 func writingMessageKeys() KeyMap {
@@ -157,6 +161,7 @@ func outputViewKeys() KeyMap {
 			key.WithHelp("shift+tab", "switch panel"),
 		),
 		Enter:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "print to stdout")),
+		GitCommit:  key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "git commit")),
 		Esc:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back to history")),
 		GlobalQuit: key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "quit")),
 	}
@@ -174,6 +179,7 @@ func fileListKeys() KeyMap {
 			key.WithKeys("right", "tab"),
 			key.WithHelp("→/tab", "Enter to dir"),
 		),
+		SuggestedScope: keyUseSuggestedScope,
 		Toggle: key.NewBinding(
 			key.WithKeys("ctrl+r"),
 			key.WithHelp("ctrl+r", "Toggle show only Modified files"),
@@ -227,6 +233,10 @@ func mainListKeys() KeyMap {
 			key.WithKeys("ctrl+d"),
 			key.WithHelp("ctrl+d", "Toggle drafts view"),
 		),
+		ToggleWorktrees: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "Toggle all worktrees"),
+		),
 		SwitchMode: key.NewBinding(
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "Switch Mode"),
@@ -494,6 +504,9 @@ func (k KeyMap) ShortHelp() []key.Binding {
 	if k.Enter.Enabled() {
 		b = append(b, k.Enter)
 	}
+	if k.GitCommit.Enabled() {
+		b = append(b, k.GitCommit)
+	}
 	if k.Left.Enabled() {
 		b = append(b, k.Left)
 	}
@@ -545,6 +558,9 @@ func (k KeyMap) ShortHelp() []key.Binding {
 	if k.RerunStage3.Enabled() {
 		b = append(b, k.RerunStage3)
 	}
+	if k.HunkMode.Enabled() {
+		b = append(b, k.HunkMode)
+	}
 	return b
 }
 
@@ -574,6 +590,9 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	if k.Enter.Enabled() {
 		b = append(b, k.Enter)
 	}
+	if k.GitCommit.Enabled() {
+		b = append(b, k.GitCommit)
+	}
 	if k.Delete.Enabled() {
 		b = append(b, k.Delete)
 	}
@@ -646,5 +665,8 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	if k.History.Enabled() {
 		b = append(b, k.History)
 	}
+	if k.HunkMode.Enabled() {
+		b = append(b, k.HunkMode)
+	}
 	return [][]key.Binding{b}
 }
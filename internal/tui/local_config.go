@@ -42,8 +42,8 @@ func UpdateLocalConfigVersion(version string) error {
 }
 
 // UpdateLocalConfigRelease writes the user-facing release fields into
-// the repo's `.commitcraft.toml`. GH_TOKEN is never serialized here —
-// it lives in ~/.config/CommitCraft/.env via SaveGhTokenToEnv. The
+// the repo's `.commitcraft.toml`. The host token is never serialized
+// here — it lives in ~/.config/CommitCraft/.env via SaveReleaseTokenToEnv. The
 // file is created from the default template on first call so the user
 // doesn't have to bootstrap it manually.
 func UpdateLocalConfigRelease(
@@ -168,9 +168,16 @@ func saveAPIKeyToEnv(key string) error {
 	return saveEnvVar(config.EnvGroqUserKey, key)
 }
 
-// SaveGhTokenToEnv persists the GitHub personal-access token in the
-// global `.env`. Exported because the release config popup lives in a
-// different file and needs to call it after the user finishes the form.
-func SaveGhTokenToEnv(token string) error {
-	return saveEnvVar("GH_TOKEN", token)
+// SaveReleaseTokenToEnv persists the release host's access token in the
+// global `.env` under the host's env var (GH_TOKEN, GITLAB_TOKEN or
+// GITEA_TOKEN) and exports it to this process, so the rest of the
+// session sees it without a restart. Exported because the release
+// config popup lives in a different file and needs to call it after
+// the user finishes the form.
+func SaveReleaseTokenToEnv(host, token string) error {
+	name := config.ReleaseTokenEnv(host)
+	if err := saveEnvVar(name, token); err != nil {
+		return err
+	}
+	return os.Setenv(name, token)
 }
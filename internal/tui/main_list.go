@@ -17,6 +17,9 @@ import (
 // HistoryCommitItem
 type HistoryCommitItem struct {
 	commit storage.Commit
+	// worktree names the checkout the commit was written in when the
+	// History list spans every worktree and it is not the current one.
+	worktree string
 }
 
 func (hci HistoryCommitItem) Title() string {
@@ -29,12 +32,13 @@ func (hci HistoryCommitItem) Description() string {
 
 // FilterValue feeds the bubbles list's filter pass with whatever the
 // user has chosen as the active scan target. ctrl+f cycles through
-// title/id/type/scope from the filter bar; the package-level mode is
-// read here so changing it re-evaluates the filter without rebuilding
-// items.
+// title/id/type/scope/semantic from the filter bar; the package-level
+// mode is read here so changing it re-evaluates the filter without
+// rebuilding items. The semantic mode matches by id: historyListFilter
+// looks the scores up by commit id.
 func (hci HistoryCommitItem) FilterValue() string {
 	switch CurrentMainFilterMode() {
-	case FilterModeID:
+	case FilterModeID, FilterModeSemantic:
 		return fmt.Sprintf("%d", hci.commit.ID)
 	case FilterModeType:
 		return hci.commit.Type
@@ -146,6 +150,10 @@ func (d HistoryCommitDelegate) Render(w io.Writer, m list.Model, index int, list
 
 	srcStyle, srcLabel := sourcePillStyle(commit.Source)
 	srcPill := srcStyle.Render(srcLabel)
+	if it.worktree != "" {
+		wtLabel := lipgloss.NewStyle().Foreground(d.Theme.Muted).Render("⎇ " + it.worktree)
+		srcPill = lipgloss.JoinHorizontal(lipgloss.Top, wtLabel, " ", srcPill)
+	}
 
 	dateStr := commit.CreatedAt.Format("01-02 15:04")
 	var dateStyle lipgloss.Style
@@ -267,6 +275,7 @@ func NewHistoryCommitList(
 	historyList.SetShowHelp(false)
 	historyList.SetShowStatusBar(false)
 	historyList.SetFilteringEnabled(true)
+	historyList.Filter = historyListFilter
 	historyList.StatusMessageLifetime = 5 * time.Second
 	return historyList
 }
@@ -14,10 +14,12 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/commit"
 	configpkg "commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/logger"
+	"commit_craft_reborn/internal/scope"
 	"commit_craft_reborn/internal/storage"
 	"commit_craft_reborn/internal/tui/statusbar"
 	"commit_craft_reborn/internal/tui/styles"
@@ -42,6 +44,7 @@ const (
 	// New compose sections (Tab cycles through these in stateWritingMessage)
 	focusComposeType
 	focusComposeScope
+	focusComposeTrailers
 	focusComposeSummary
 	focusComposeKeypoints
 	focusComposePipelineModels
@@ -209,7 +212,20 @@ type Model struct {
 	// releaseLoading || releaseUploading so a stale releaseLoading flag
 	// (e.g. when the upload path doesn't get to run the history sync)
 	// can't strand the user on "Loading releases…" after upload finishes.
-	releaseUploading        bool
+	releaseUploading bool
+	// releaseUpload is the per-asset progress of the running upload,
+	// written from the upload goroutine and read by renderReleaseLoading.
+	releaseUpload *releaseUploadProgress
+	// releaseBuild is the per-target progress of a running `go` build
+	// matrix, written from the build goroutines.
+	releaseBuild *releaseBuildProgress
+	// gitCommitting is set while the Output screen's `git commit` runs so
+	// a second keypress can't create a duplicate commit.
+	gitCommitting bool
+	// splitRunning is set while the split planner or the per-group
+	// pipeline runs (command palette → Split staged changes) so the
+	// command can't be started twice.
+	splitRunning            bool
 	selectedCommitList      []WorkspaceCommitItem
 	commitLivePreview       string
 	commitTypeList          list.Model
@@ -234,6 +250,9 @@ type Model struct {
 	// scopeChipIndex is the cursor inside the scope chip row when the
 	// scope section has focus. Used so x/delete removes the right chip.
 	scopeChipIndex int
+	// scopeSuggestion is the inferred scope of the staged files, computed
+	// when the scope picker opens and accepted with keyUseSuggestedScope.
+	scopeSuggestion scope.Inference
 	// keypointIndex is the cursor inside the key-points list when the
 	// keypoints section has focus, used by the per-section delete keys.
 	keypointIndex int
@@ -241,12 +260,18 @@ type Model struct {
 	// row when that section has focus, picking which stage Enter opens
 	// the model picker for.
 	pipelineModelStageIndex int
-	commitMsg               string
-	commitTranslate         string
-	diffCode                string
-	iaSummaryOutput         string
-	iaCommitRawOutput       string
-	iaTitleRawOutput        string
+	// ctxEstimate caches the Change Analyzer payload estimate behind the
+	// compose CTX bar; Update refreshes it via composeCtxEstimateCmd.
+	ctxEstimate composeCtxEstimate
+	// trailerIndex is the highlighted pill in the compose trailers row
+	// (index into currentCommit.Trailers).
+	trailerIndex      int
+	commitMsg         string
+	commitTranslate   string
+	diffCode          string
+	iaSummaryOutput   string
+	iaCommitRawOutput string
+	iaTitleRawOutput  string
 	// iaChangelogEntry holds the markdown block the refiner produced for the
 	// CHANGELOG. Empty when the feature is disabled, the file is missing, or
 	// the AI call failed. Persisted to disk in createCommit().
@@ -307,6 +332,10 @@ type Model struct {
 	// AutodraftedTab is the human-readable tab label ("Compose" / "Pipeline")
 	// the user was on when the autodraft fired. Empty when no autodraft ran.
 	AutodraftedTab string
+	// CommittedHash is the hash of the commit created from the Output
+	// screen (empty when the user printed or quit instead). main.go
+	// reports it after the TUI tears down.
+	CommittedHash string
 	// hasLocalConfig caches whether a .commitcraft.toml file existed in
 	// pwd at startup. Drives the persistent local-config pill in the
 	// status bar; we don't re-stat on every render because the file
@@ -352,6 +381,20 @@ type Model struct {
 	globalConfig         configpkg.Config
 	Version              string
 	themeName            string
+	// workspaceID is the checkout pwd belongs to (zero outside git);
+	// historyAllWorktrees widens the History list from this worktree to
+	// every worktree of the repository. See historyScope.
+	workspaceID         git.WorkspaceID
+	historyAllWorktrees bool
+	// tagSuggestion is the inferred commit type of the staged changes,
+	// shown next to the type chip; tagPicked is set once the user picks
+	// a type so a late classifier answer never overrides it.
+	tagSuggestion    aiengine.TagSuggestion
+	tagPicked        bool
+	tagSuggestionSeq int
+	// semanticSeq numbers History filter changes so a debounce tick or a
+	// search result for an older query is dropped.
+	semanticSeq int
 	// currentBranch is the git branch the TUI was launched from. Cached
 	// so the persistent CWD/branch pill in the top tab bar doesn't shell
 	// out on every render.
@@ -413,7 +456,8 @@ func NewModel(
 	}
 
 	commitTypesList := NewCommitTypeList(finalCommitTypes, config.CommitFormat.TypeFormat, theme)
-	workspaceCommits, err := database.GetCommits(pwd, "completed")
+	workspaceID, _ := git.ResolveWorkspace(pwd)
+	workspaceCommits, err := historyScope{pwd: pwd, workspace: workspaceID}.commits(database, "completed")
 	workspaceCommitsList := NewHistoryCommitList(
 		workspaceCommits,
 		pwd,
@@ -561,6 +605,7 @@ func NewModel(
 		finalCommitTypes:         finalCommitTypes,
 		log:                      log,
 		pwd:                      pwd,
+		workspaceID:              workspaceID,
 		currentBranch:            branch,
 		db:                       database,
 		apiKeyInput:              apiKeyInput,
@@ -577,6 +622,8 @@ func NewModel(
 		releaseDiffViewport:      releaseDiffViewport,
 		releaseFileDiffsByCommit: map[string]map[string]string{},
 		releaseViewState:         &releaseViewState{selecting: false, releaseCreated: false},
+		releaseUpload:            &releaseUploadProgress{},
+		releaseBuild:             &releaseBuildProgress{},
 		commitTypeList:           commitTypesList,
 		iaViewport:               vp,
 		outputReportViewport:     viewport.New(),
@@ -5,6 +5,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/api"
 	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/storage"
@@ -17,10 +18,12 @@ const modelsCacheTTL = 24 * time.Hour
 
 // modelPickerOpenedMsg carries the list the parent will hand to the popup
 // constructor. Always emitted from openModelPickerCmd, even when the fetch
-// fails — `err` is set so the parent can warn the user.
+// fails — `err` is set so the parent can warn the user. provider is the
+// stage's provider id, used in the status-bar copy.
 type modelPickerOpenedMsg struct {
 	stage    config.ModelStage
 	label    string
+	provider string
 	current  string
 	models   []storage.CachedModel
 	cachedAt time.Time
@@ -52,27 +55,41 @@ func composePipelineStages(m *Model) []composeStageEntry {
 	return stages
 }
 
-// openModelPickerCmd loads the cached catalogue (refreshing it from the
-// API when stale or empty) and emits modelPickerOpenedMsg so the Update
-// loop can build the popup with fresh data.
+// openModelPickerCmd lists the models of the stage's provider and emits
+// modelPickerOpenedMsg so the Update loop can build the popup with fresh
+// data. Groq's catalogue goes through the SQLite cache (refreshed when
+// stale or empty); the other providers are listed live on every open.
 func openModelPickerCmd(model *Model, stage config.ModelStage, label string) tea.Cmd {
-	apiKey := model.globalConfig.TUI.GroqAPIKey
-	current := config.CurrentModelForStage(model.globalConfig, stage)
+	cfg := model.globalConfig
+	current := config.CurrentModelForStage(cfg, stage)
 	db := model.db
 	return func() tea.Msg {
+		msg := modelPickerOpenedMsg{
+			stage: stage, label: label, current: current,
+			provider: api.NormalizeProvider(config.ProviderForStage(cfg, stage)),
+		}
+		provider, apiKey, err := aiengine.StageProvider(cfg, stage)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		if provider.Name() != api.ProviderGroq {
+			msg.models, msg.err = listProviderModels(provider, apiKey)
+			msg.cachedAt = time.Now()
+			return msg
+		}
+
 		cached, fetchedAt, err := db.LoadModelsCache()
 		if err != nil {
-			return modelPickerOpenedMsg{
-				stage: stage, label: label, current: current, err: err,
-			}
+			msg.err = err
+			return msg
 		}
 
 		if storage.IsModelsCacheStale(fetchedAt, modelsCacheTTL) {
-			fresh, ferr := fetchAndCacheModels(db, apiKey)
+			fresh, ferr := fetchAndCacheModels(db, provider, apiKey)
 			if ferr != nil && len(cached) == 0 {
-				return modelPickerOpenedMsg{
-					stage: stage, label: label, current: current, err: ferr,
-				}
+				msg.err = ferr
+				return msg
 			}
 			if ferr == nil {
 				cached = fresh
@@ -80,41 +97,49 @@ func openModelPickerCmd(model *Model, stage config.ModelStage, label string) tea
 			}
 		}
 
-		return modelPickerOpenedMsg{
-			stage:    stage,
-			label:    label,
-			current:  current,
-			models:   cached,
-			cachedAt: fetchedAt,
-		}
+		msg.models, msg.cachedAt = cached, fetchedAt
+		return msg
 	}
 }
 
 // refreshModelPickerCmd forces a fresh fetch (ignoring cache age) and
 // emits modelPickerOpenedMsg so the popup can be rebuilt in place.
 func refreshModelPickerCmd(model *Model, stage config.ModelStage, label string) tea.Cmd {
-	apiKey := model.globalConfig.TUI.GroqAPIKey
-	current := config.CurrentModelForStage(model.globalConfig, stage)
+	cfg := model.globalConfig
+	current := config.CurrentModelForStage(cfg, stage)
 	db := model.db
 	return func() tea.Msg {
-		fresh, err := fetchAndCacheModels(db, apiKey)
+		msg := modelPickerOpenedMsg{
+			stage: stage, label: label, current: current,
+			provider: api.NormalizeProvider(config.ProviderForStage(cfg, stage)),
+		}
+		provider, apiKey, err := aiengine.StageProvider(cfg, stage)
 		if err != nil {
-			cached, fetchedAt, _ := db.LoadModelsCache()
-			return modelPickerOpenedMsg{
-				stage: stage, label: label, current: current,
-				models: cached, cachedAt: fetchedAt, err: err,
-			}
+			msg.err = err
+			return msg
+		}
+		if provider.Name() != api.ProviderGroq {
+			msg.models, msg.err = listProviderModels(provider, apiKey)
+			msg.cachedAt = time.Now()
+			return msg
 		}
-		return modelPickerOpenedMsg{
-			stage:    stage,
-			label:    label,
-			current:  current,
-			models:   fresh,
-			cachedAt: time.Now(),
+		fresh, err := fetchAndCacheModels(db, provider, apiKey)
+		if err != nil {
+			msg.models, msg.cachedAt, _ = db.LoadModelsCache()
+			msg.err = err
+			return msg
 		}
+		msg.models, msg.cachedAt = fresh, time.Now()
+		return msg
 	}
 }
 
+// stageProviderName is the provider id configured for stage ("groq"
+// when unset), for status-bar copy.
+func stageProviderName(m *Model, stage config.ModelStage) string {
+	return api.NormalizeProvider(config.ProviderForStage(m.globalConfig, stage))
+}
+
 // stageLabelFor returns the human-readable label of stage as it appears
 // on the Compose tab; falls back to the bare ModelStage id when the
 // stage is not part of the visible row (e.g. release/translate).
@@ -155,11 +180,15 @@ func applyPipelineModelsToStages(m *Model) {
 	m.pipeline.stages[stageChangelog].Model = m.globalConfig.Changelog.PromptModel
 }
 
-// fetchAndCacheModels hits the Groq /models endpoint, filters the result
-// against the curated free-tier allowlist and writes the survivors into
-// the SQLite cache.
-func fetchAndCacheModels(db *storage.DB, apiKey string) ([]storage.CachedModel, error) {
-	models, err := api.ListGroqModels(apiKey)
+// fetchAndCacheModels lists the Groq catalogue through provider,
+// filters the result against the curated free-tier allowlist and writes
+// the survivors into the SQLite cache.
+func fetchAndCacheModels(
+	db *storage.DB,
+	provider api.Provider,
+	apiKey string,
+) ([]storage.CachedModel, error) {
+	models, err := provider.ListModels(apiKey)
 	if err != nil {
 		return nil, err
 	}
@@ -182,3 +211,25 @@ func fetchAndCacheModels(db *storage.DB, apiKey string) ([]storage.CachedModel,
 	}
 	return out, nil
 }
+
+// listProviderModels lists a non-Groq provider's catalogue. The Groq
+// free-tier allowlist and the cache table don't apply: every active
+// model the key can address is offered.
+func listProviderModels(provider api.Provider, apiKey string) ([]storage.CachedModel, error) {
+	models, err := provider.ListModels(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]storage.CachedModel, 0, len(models))
+	for _, m := range models {
+		if !m.Active {
+			continue
+		}
+		out = append(out, storage.CachedModel{
+			ID:            m.ID,
+			OwnedBy:       m.OwnedBy,
+			ContextWindow: m.ContextWindow,
+		})
+	}
+	return out, nil
+}
@@ -16,10 +16,10 @@ import (
 )
 
 // refreshPipelineNumstat reloads the cached numstat map from `git diff
-// --staged --numstat`. Cheap (one fork+exec); we call it on tab enter
+// --staged --numstat` in model.pwd. Cheap (one fork+exec); we call it on tab enter
 // and after every pipeline re-run so per-row counts stay accurate.
 func refreshPipelineNumstat(model *Model) {
-	ns, err := git.GetStagedNumstat()
+	ns, err := git.StagedNumstatAt(model.pwd)
 	if err != nil {
 		model.log.Debug("pipeline: failed to read numstat", "err", err)
 		model.pipeline.numstat = nil
@@ -31,7 +31,16 @@ func refreshPipelineNumstat(model *Model) {
 // setDiffFromSelectedFile pushes the staged diff for the currently
 // highlighted entry in pipelineDiffList into pipeline.diffViewport,
 // pre-coloured per `+`/`-`/`@@` line. Called whenever the cursor moves.
+// In hunk-staging mode the selected file's hunks are shown instead; a
+// history-loaded diff turns that mode off.
 func setDiffFromSelectedFile(model *Model) {
+	if model.pipeline.hunks.active {
+		if !model.usePreloadedDiff {
+			loadPipelineHunks(model)
+			return
+		}
+		model.pipeline.hunks = pipelineHunks{}
+	}
 	it, ok := model.pipelineDiffList.SelectedItem().(DiffFileItem)
 	if !ok {
 		model.pipeline.diffViewport.SetContent("")
@@ -44,6 +53,14 @@ func setDiffFromSelectedFile(model *Model) {
 	} else {
 		diff, err = git.GetStagedFileDiff(it.FilePath)
 	}
+	if reason, ok := model.pipeline.omitted[it.FilePath]; ok && model.usePreloadedDiff {
+		model.pipeline.diffViewport.SetContent(
+			lipgloss.NewStyle().
+				Foreground(model.Theme.Muted).
+				Render("(not sent to the model · " + reason + " · name and numstat only)"),
+		)
+		return
+	}
 	if err != nil || strings.TrimSpace(diff) == "" {
 		model.pipeline.diffViewport.SetContent(
 			lipgloss.NewStyle().
@@ -85,6 +102,10 @@ func (model *Model) pipelineFilesFooter() string {
 		addStyle.Render("+" + strconv.Itoa(adds)),
 		delStyle.Render("-" + strconv.Itoa(dels)),
 	}
+	if n := len(model.pipeline.omitted); n > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(theme.Warning).
+			Render(strconv.Itoa(n)+" not sent"))
+	}
 	return strings.Join(parts, "  ")
 }
 
@@ -92,8 +113,11 @@ func (model *Model) pipelineFilesFooter() string {
 // changed-files panel: file path on top row, "+N -M" counters underneath.
 // Rebuild + SetDelegate on the list whenever numstat refreshes so each
 // row shows up-to-date counts (the delegate captures the map by value).
+// omitted maps the paths the diff budget left out of the Change Analyzer
+// input to the reason, shown after the counters.
 type pipelineFilesDelegate struct {
 	numstat map[string]git.FileNumstat
+	omitted map[string]string
 }
 
 func (d pipelineFilesDelegate) Height() int  { return 2 }
@@ -155,18 +179,24 @@ func (d pipelineFilesDelegate) Render(w io.Writer, m list.Model, index int, list
 		delsText = "-bin"
 	}
 	row2 := "      " + mutedStyle.Render(addStyle.Render(addsText)+" "+delStyle.Render(delsText))
+	if reason, ok := d.omitted[it.FilePath]; ok {
+		row2 += mutedStyle.Italic(true).Render("  not sent · " + reason)
+	}
 
 	fmt.Fprintf(w, "%s\n%s", row1, row2)
 }
 
 // parseDbDiff extracts files, numstats, and per-file diff text from the
 // Diff_code blob persisted by GetStagedDiffSummary (format:
-// "=== <path> ===\n<diff bytes>\n=== <next path> ===\n..."). Returns
-// items sorted alphabetically so the list stays stable across reloads.
+// "=== <path> ===\n<diff bytes>\n=== <next path> ===\n..."). Files the
+// diff budget left out are listed too, with the numstat recorded in the
+// omitted section and no diff text. Returns items sorted alphabetically
+// so the list stays stable across reloads.
 func parseDbDiff(diffCode string) ([]list.Item, map[string]git.FileNumstat, map[string]string) {
 	if strings.TrimSpace(diffCode) == "" {
 		return nil, nil, nil
 	}
+	diffCode, omitted := git.SplitOmittedSection(diffCode)
 	type block struct {
 		path string
 		body strings.Builder
@@ -185,12 +215,16 @@ func parseDbDiff(diffCode string) ([]list.Item, map[string]git.FileNumstat, map[
 			cur.body.WriteByte('\n')
 		}
 	}
-	if len(blocks) == 0 {
+	if len(blocks) == 0 && len(omitted) == 0 {
 		return nil, nil, nil
 	}
-	paths := make([]string, 0, len(blocks))
+	paths := make([]string, 0, len(blocks)+len(omitted))
 	bodies := make(map[string]string, len(blocks))
-	numstat := make(map[string]git.FileNumstat, len(blocks))
+	numstat := make(map[string]git.FileNumstat, len(blocks)+len(omitted))
+	for _, f := range omitted {
+		paths = append(paths, f.Path)
+		numstat[f.Path] = git.FileNumstat{Adds: f.Adds, Dels: f.Dels}
+	}
 	for _, b := range blocks {
 		body := strings.TrimRight(b.body.String(), "\n")
 		bodies[b.path] = body
@@ -236,11 +270,21 @@ func loadPipelineFilesFromDb(model *Model, diffCode string) {
 }
 
 // applyPipelineFilesDelegate swaps the list delegate with a fresh
-// pipelineFilesDelegate that captures the latest numstat map. Called
-// after refreshPipelineNumstat so the per-row counts re-render.
+// pipelineFilesDelegate that captures the latest numstat map and the
+// files the diff budget kept from the model (read from model.diffCode).
+// Called after refreshPipelineNumstat and whenever diffCode changes so
+// the per-row counts and "not sent" markers re-render.
 func applyPipelineFilesDelegate(model *Model) {
+	model.pipeline.omitted = nil
+	for _, f := range git.ParseOmittedFiles(model.diffCode) {
+		if model.pipeline.omitted == nil {
+			model.pipeline.omitted = map[string]string{}
+		}
+		model.pipeline.omitted[f.Path] = f.Reason
+	}
 	model.pipelineDiffList.SetDelegate(pipelineFilesDelegate{
 		numstat: model.pipeline.numstat,
+		omitted: model.pipeline.omitted,
 	})
 }
 
@@ -0,0 +1,362 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/tui/statusbar"
+)
+
+// pipelineHunks is the hunk-staging mode of the Pipeline diff sub-block
+// (toggled with `s`). Instead of the read-only staged diff it lists the
+// selected file's staged hunks followed by its unstaged ones; space
+// moves the hunk under the cursor to the other side. Line mode (`v`)
+// narrows that to the changed lines marked with space, applied on
+// enter. Every move goes through `git apply --cached`, so the working
+// tree is never touched.
+type pipelineHunks struct {
+	active   bool
+	path     string
+	staged   git.FileDiff
+	unstaged git.FileDiff
+	entries  []hunkRef
+	cursor   int
+	// lineMode, lineCursor and marked drive line selection inside the
+	// hunk under the cursor. lineCursor indexes Hunk.Lines and only ever
+	// rests on changed lines.
+	lineMode   bool
+	lineCursor int
+	marked     map[int]bool
+}
+
+// hunkRef points into pipelineHunks.staged or .unstaged.
+type hunkRef struct {
+	staged bool
+	index  int
+}
+
+// current returns the diff and hunk under the cursor.
+func (h *pipelineHunks) current() (git.FileDiff, git.Hunk, hunkRef, bool) {
+	if h.cursor < 0 || h.cursor >= len(h.entries) {
+		return git.FileDiff{}, git.Hunk{}, hunkRef{}, false
+	}
+	ref := h.entries[h.cursor]
+	fd := h.unstaged
+	if ref.staged {
+		fd = h.staged
+	}
+	return fd, fd.Hunks[ref.index], ref, true
+}
+
+func (h *pipelineHunks) leaveLineMode() {
+	h.lineMode = false
+	h.lineCursor = 0
+	h.marked = nil
+}
+
+// moveLineCursor steps the line cursor by dir over the changed lines of
+// the current hunk, staying put at either end.
+func (h *pipelineHunks) moveLineCursor(dir int) {
+	_, hunk, _, ok := h.current()
+	if !ok {
+		return
+	}
+	for i := h.lineCursor + dir; i >= 0 && i < len(hunk.Lines); i += dir {
+		if hunk.Changed(i) {
+			h.lineCursor = i
+			return
+		}
+	}
+}
+
+// togglePipelineHunkMode switches the diff sub-block between the plain
+// staged diff and hunk staging. History-loaded diffs have no live index
+// behind them, so the mode is refused there.
+func togglePipelineHunkMode(model *Model) tea.Cmd {
+	if model.pipeline.hunks.active {
+		model.pipeline.hunks = pipelineHunks{}
+		setDiffFromSelectedFile(model)
+		return nil
+	}
+	if model.usePreloadedDiff {
+		return model.WritingStatusBar.ShowMessageForDuration(
+			"Hunk staging needs the live index · this diff was loaded from history",
+			statusbar.LevelWarning,
+			2*time.Second,
+		)
+	}
+	if _, ok := model.pipelineDiffList.SelectedItem().(DiffFileItem); !ok {
+		return model.WritingStatusBar.ShowMessageForDuration(
+			"No file selected",
+			statusbar.LevelWarning,
+			2*time.Second,
+		)
+	}
+	model.pipeline.hunks = pipelineHunks{active: true}
+	loadPipelineHunks(model)
+	return nil
+}
+
+// loadPipelineHunks reads the selected file's staged and unstaged diffs
+// and redraws the diff sub-block. The hunk cursor is kept (clamped) when
+// the path did not change, so staging a hunk leaves the cursor on its
+// neighbour.
+func loadPipelineHunks(model *Model) {
+	h := &model.pipeline.hunks
+	it, ok := model.pipelineDiffList.SelectedItem().(DiffFileItem)
+	if !ok {
+		*h = pipelineHunks{active: true}
+		renderPipelineHunks(model)
+		return
+	}
+	if it.FilePath != h.path {
+		h.path = it.FilePath
+		h.cursor = 0
+	}
+	h.leaveLineMode()
+
+	var err error
+	if h.staged, err = git.StagedFileDiffAt(model.pwd, it.FilePath); err != nil {
+		model.log.Debug("pipeline: failed to read staged hunks", "path", it.FilePath, "err", err)
+	}
+	if h.unstaged, err = git.UnstagedFileDiffAt(model.pwd, it.FilePath); err != nil {
+		model.log.Debug("pipeline: failed to read unstaged hunks", "path", it.FilePath, "err", err)
+	}
+	h.entries = h.entries[:0]
+	for i := range h.staged.Hunks {
+		h.entries = append(h.entries, hunkRef{staged: true, index: i})
+	}
+	for i := range h.unstaged.Hunks {
+		h.entries = append(h.entries, hunkRef{staged: false, index: i})
+	}
+	h.cursor = min(h.cursor, max(0, len(h.entries)-1))
+	renderPipelineHunks(model)
+}
+
+// renderPipelineHunks writes the hunk list into pipeline.diffViewport and
+// scrolls so the cursor (hunk header, or the line cursor in line mode)
+// stays visible.
+func renderPipelineHunks(model *Model) {
+	h := &model.pipeline.hunks
+	theme := model.Theme
+	muted := lipgloss.NewStyle().Foreground(theme.Muted)
+	section := lipgloss.NewStyle().Foreground(theme.Secondary).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true)
+
+	var out []string
+	target := 0
+	writeSection := func(title string, fd git.FileDiff, staged bool) {
+		out = append(out, section.Render(fmt.Sprintf("%s · %s", title, plural(len(fd.Hunks), "hunk", "hunks"))))
+		if fd.Binary {
+			out = append(out, muted.Render("  (binary file · stage it as a whole)"))
+		}
+		if len(fd.Hunks) == 0 && !fd.Binary {
+			out = append(out, muted.Render("  (none)"))
+		}
+		for i, hunk := range fd.Hunks {
+			selected := false
+			if h.cursor < len(h.entries) {
+				ref := h.entries[h.cursor]
+				selected = ref.staged == staged && ref.index == i
+			}
+			head := fmt.Sprintf("@@ -%d,%d +%d,%d @@ %s",
+				hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines, hunk.Section)
+			prefix := "  "
+			if selected {
+				prefix = cursorStyle.Render("❯ ")
+				target = len(out)
+			}
+			out = append(out, prefix+colorizeDiffLine(strings.TrimRight(head, " ")))
+			for j, line := range hunk.Lines {
+				gutter := "  "
+				if selected && h.lineMode {
+					switch {
+					case j == h.lineCursor && h.marked[j]:
+						gutter = cursorStyle.Render("▸●")
+						target = len(out)
+					case j == h.lineCursor:
+						gutter = cursorStyle.Render("▸ ")
+						target = len(out)
+					case h.marked[j]:
+						gutter = cursorStyle.Render(" ●")
+					}
+				}
+				out = append(out, gutter+colorizeDiffLine(line))
+			}
+		}
+	}
+	if h.path == "" {
+		out = append(out, muted.Render("(no file selected)"))
+	} else {
+		writeSection("staged", h.staged, true)
+		out = append(out, "")
+		writeSection("unstaged", h.unstaged, false)
+	}
+
+	vp := &model.pipeline.diffViewport
+	vp.SetContent(strings.Join(out, "\n"))
+	if height := vp.Height(); height > 0 {
+		switch {
+		case target < vp.YOffset():
+			vp.SetYOffset(target)
+		case target >= vp.YOffset()+height:
+			vp.SetYOffset(target - height + 1)
+		}
+	}
+}
+
+// updatePipelineHunks handles the keys hunk mode owns. handled is false
+// for everything else so the regular Pipeline bindings (file cursor,
+// retries, stage focus) keep working while the mode is on.
+func updatePipelineHunks(model *Model, msg tea.KeyMsg) (bool, tea.Cmd) {
+	h := &model.pipeline.hunks
+	switch msg.String() {
+	case "up":
+		if h.lineMode {
+			h.moveLineCursor(-1)
+		} else if h.cursor > 0 {
+			h.cursor--
+		}
+	case "down":
+		if h.lineMode {
+			h.moveLineCursor(1)
+		} else if h.cursor < len(h.entries)-1 {
+			h.cursor++
+		}
+	case "v":
+		if h.lineMode {
+			h.leaveLineMode()
+			break
+		}
+		if _, _, _, ok := h.current(); !ok {
+			return true, nil
+		}
+		h.lineMode = true
+		h.marked = map[int]bool{}
+		h.lineCursor = -1
+		h.moveLineCursor(1)
+	case "space", " ":
+		if !h.lineMode {
+			return true, applyPipelineHunk(model, nil)
+		}
+		if h.marked[h.lineCursor] {
+			delete(h.marked, h.lineCursor)
+		} else {
+			h.marked[h.lineCursor] = true
+		}
+	case "enter":
+		if !h.lineMode {
+			return true, applyPipelineHunk(model, nil)
+		}
+		if len(h.marked) == 0 {
+			return true, model.WritingStatusBar.ShowMessageForDuration(
+				"Mark lines with space first",
+				statusbar.LevelWarning,
+				2*time.Second,
+			)
+		}
+		return true, applyPipelineHunk(model, h.marked)
+	case "esc":
+		if h.lineMode {
+			h.leaveLineMode()
+			break
+		}
+		return true, togglePipelineHunkMode(model)
+	default:
+		return false, nil
+	}
+	renderPipelineHunks(model)
+	return true, nil
+}
+
+// applyPipelineHunk stages (or unstages, for a staged hunk) the hunk
+// under the cursor, or only the lines in lines when non-nil, then
+// refreshes everything derived from the index.
+func applyPipelineHunk(model *Model, lines map[int]bool) tea.Cmd {
+	h := &model.pipeline.hunks
+	fd, _, ref, ok := h.current()
+	if !ok {
+		return nil
+	}
+	apply, verb, action := git.StageHunkAt, "Staged", "Staging"
+	if ref.staged {
+		apply, verb, action = git.UnstageHunkAt, "Unstaged", "Unstaging"
+	}
+	if err := apply(model.pwd, fd, ref.index, lines); err != nil {
+		model.log.Error("pipeline: hunk apply failed", "path", fd.Path, "error", err)
+		return model.WritingStatusBar.ShowMessageForDuration(
+			fmt.Sprintf("%s failed: %s", action, err),
+			statusbar.LevelError,
+			3*time.Second,
+		)
+	}
+	refreshPipelineIndex(model)
+
+	what := "hunk"
+	if lines != nil {
+		what = plural(len(lines), "line", "lines")
+	}
+	msg := fmt.Sprintf("%s %s of %s", verb, what, fd.Path)
+	if model.pipeline.stages[stageSummary].Status == statusDone {
+		msg += " · press 1 to re-run the Change Analyzer"
+	}
+	return model.WritingStatusBar.ShowMessageForDuration(msg, statusbar.LevelSuccess, 2*time.Second)
+}
+
+// refreshPipelineIndex re-reads everything the Pipeline tab derives from
+// the index after hunk staging: the file list, the numstat counters and
+// footer, the diff snapshot the Change Analyzer reads and the hunks of
+// the selected file. Everything is read from model.pwd, the repository
+// the hunks were staged in. A file whose last staged hunk was just unstaged
+// stays listed (unmarked) so it can be staged back without leaving the
+// mode.
+func refreshPipelineIndex(model *Model) {
+	selected := ""
+	if it, ok := model.pipelineDiffList.SelectedItem().(DiffFileItem); ok {
+		selected = it.FilePath
+	}
+	status, err := git.GetGitDiffNameStatusAt(model.pwd)
+	if err != nil {
+		model.log.Debug("pipeline: failed to reload staged files", "err", err)
+		status = map[string]string{}
+	}
+	paths := make([]string, 0, len(status)+1)
+	for p := range status {
+		paths = append(paths, p)
+	}
+	if _, ok := status[selected]; selected != "" && !ok {
+		paths = append(paths, selected)
+	}
+	sort.Strings(paths)
+	items := make([]list.Item, len(paths))
+	cursor := 0
+	for i, p := range paths {
+		st, ok := status[p]
+		if !ok {
+			st = " "
+		}
+		items[i] = DiffFileItem{FilePath: p, Status: st}
+		if p == selected {
+			cursor = i
+		}
+	}
+	model.pipelineDiffList.SetItems(items)
+	model.pipelineDiffList.Select(cursor)
+
+	refreshPipelineNumstat(model)
+	applyPipelineFilesDelegate(model)
+
+	if diff, err := git.GetStagedDiffSummaryAt(
+		model.pwd, model.globalConfig.Prompts.ChangeAnalyzerMaxDiffSize,
+	); err == nil {
+		model.diffCode = diff
+	}
+	loadPipelineHunks(model)
+}
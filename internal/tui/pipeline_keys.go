@@ -11,6 +11,9 @@ import "charm.land/bubbles/v2/key"
 //   - `pgup/pgdn` — scroll the focused stage's viewport
 //   - `↑`/`↓`     — scroll the diff sub-block (always, regardless of focus)
 //   - `j`/`k`     — move the changed-files cursor (loads its diff)
+//   - `s`         — toggle hunk staging in the diff sub-block; while on,
+//     `↑`/`↓` pick a hunk, space/enter stage or unstage it, `v` switches
+//     to line selection (space marks, enter applies) and `esc` leaves
 //   - `enter`     — accept the assembled commit (only when allDone)
 //   - `esc`       — cancel a running run
 func pipelineKeys() KeyMap {
@@ -27,6 +30,7 @@ func pipelineKeys() KeyMap {
 		),
 		FileUp:    key.NewBinding(key.WithKeys("k"), key.WithHelp("k", "prev file")),
 		FileDown:  key.NewBinding(key.WithKeys("j"), key.WithHelp("j", "next file")),
+		HunkMode:  key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "stage hunks")),
 		NextField: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "cycle stage focus")),
 		Enter:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "accept commit")),
 		Esc:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel / back")),
@@ -3,6 +3,7 @@ package tui
 import (
 	"time"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/storage"
 )
 
@@ -29,7 +30,10 @@ func stageIDFromDBLabel(label string) (stageID, bool) {
 // persistPipelineAICalls flushes the per-stage telemetry currently held in
 // model.pipeline.stages to the ai_calls table for commitID. Existing rows
 // for the commit are removed first so a draft saved repeatedly never
-// accumulates orphan stage records.
+// accumulates orphan stage records. Failed attempts of a stage land
+// right before the row of the call that served it (a stage no call
+// served keeps only its attempts), and the map calls of a chunked
+// Change Analyzer run before the stage 1 row.
 func persistPipelineAICalls(model *Model, commitID int) {
 	if commitID <= 0 || model == nil || model.db == nil {
 		return
@@ -40,13 +44,28 @@ func persistPipelineAICalls(model *Model, commitID int) {
 	}
 	for i := range model.pipeline.stages {
 		st := &model.pipeline.stages[i]
-		if !st.HasStats {
-			continue
-		}
 		label, ok := stageDBLabel[st.ID]
 		if !ok {
 			continue
 		}
+		if !st.HasStats {
+			err := aiengine.CreateFailedStageCalls(
+				model.db.CreateAICall, commitID, label, st.Provider, st.Attempts,
+			)
+			if err != nil {
+				model.log.Warn(
+					"ai_calls insert failed",
+					"commit_id", commitID, "stage", label, "error", err,
+				)
+			}
+			continue
+		}
+		if err := aiengine.CreateChunkCalls(model.db.CreateAICall, commitID, st.Chunks); err != nil {
+			model.log.Warn(
+				"ai_calls chunk insert failed",
+				"commit_id", commitID, "stage", label, "error", err,
+			)
+		}
 		modelName := st.StatsModel
 		if modelName == "" {
 			modelName = st.Model
@@ -54,6 +73,7 @@ func persistPipelineAICalls(model *Model, commitID int) {
 		call := storage.AICall{
 			CommitID:         commitID,
 			Stage:            label,
+			Provider:         st.Provider,
 			Model:            modelName,
 			PromptTokens:     st.PromptTokens,
 			CompletionTokens: st.CompletionTokens,
@@ -65,7 +85,7 @@ func persistPipelineAICalls(model *Model, commitID int) {
 			RequestID:        st.RequestID,
 			TPMLimitAtCall:   st.TPMLimitAtCall,
 		}
-		if _, err := model.db.CreateAICall(call); err != nil {
+		if err := aiengine.CreateStageCalls(model.db.CreateAICall, call, st.Attempts); err != nil {
 			model.log.Warn(
 				"ai_calls insert failed",
 				"commit_id", commitID, "stage", label, "error", err,
@@ -76,7 +96,9 @@ func persistPipelineAICalls(model *Model, commitID int) {
 
 // loadPipelineAICalls rehydrates model.pipeline.stages with telemetry
 // previously persisted for commitID. Stages without a stored row keep
-// their zero values so the UI shows them as empty.
+// their zero values so the UI shows them as empty; failed-attempt rows
+// are gathered into the stage's Attempts and chunk rows into stage 1's
+// Chunks.
 func loadPipelineAICalls(model *Model, commitID int) {
 	if commitID <= 0 || model == nil || model.db == nil {
 		return
@@ -86,6 +108,11 @@ func loadPipelineAICalls(model *Model, commitID int) {
 		model.log.Warn("ai_calls load failed", "commit_id", commitID, "error", err)
 		return
 	}
+	for i := range model.pipeline.stages {
+		model.pipeline.stages[i].Attempts = nil
+		model.pipeline.stages[i].Chunks = nil
+	}
+	model.pipeline.stages[stageSummary].Chunks = aiengine.ChunksFromCalls(calls)
 	for _, c := range calls {
 		id, ok := stageIDFromDBLabel(c.Stage)
 		if !ok {
@@ -95,8 +122,13 @@ func loadPipelineAICalls(model *Model, commitID int) {
 			continue
 		}
 		st := &model.pipeline.stages[id]
+		if !c.Served() {
+			st.Attempts = append(st.Attempts, aiengine.AttemptFromCall(c))
+			continue
+		}
 		st.HasStats = true
 		st.StatsModel = c.Model
+		st.Provider = c.Provider
 		st.PromptTokens = c.PromptTokens
 		st.CompletionTokens = c.CompletionTokens
 		st.TotalTokens = c.TotalTokens
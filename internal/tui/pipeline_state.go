@@ -1,12 +1,15 @@
 package tui
 
 import (
+	"context"
 	"time"
 
 	"charm.land/bubbles/v2/progress"
 	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/viewport"
 
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/api"
 	"commit_craft_reborn/internal/git"
 )
 
@@ -71,8 +74,16 @@ type pipelineStage struct {
 	APITotalTime     time.Duration
 	RequestID        string
 	StatsModel       string
+	Provider         string
 	HasStats         bool
 	TPMLimitAtCall   int
+	// Attempts are the failed tries (retries / fallbacks) that preceded
+	// the call whose stats are shown above, oldest first.
+	Attempts []api.CallAttempt
+	// Chunks are the map calls of a chunked Change Analyzer run (stage 1
+	// only), listed as sub-stages at the top of the card; the stats above
+	// are then the reduce call's.
+	Chunks []aiengine.ChunkStats
 	// History keeps every successful AI response for this stage during
 	// the current session so the user can compare alternatives via the
 	// stage history popup (key `H`). Append-only; cleared on commit
@@ -87,6 +98,7 @@ type pipelineStage struct {
 // entry was captured. Lives only in memory; never persisted to SQLite.
 type stageHistoryEntry struct {
 	Text             string
+	Provider         string
 	Model            string
 	PromptTokens     int
 	CompletionTokens int
@@ -97,6 +109,7 @@ type stageHistoryEntry struct {
 	APITotalTime     time.Duration
 	RequestID        string
 	TPMLimitAtCall   int
+	Attempts         []api.CallAttempt
 	CapturedAt       time.Time
 }
 
@@ -119,8 +132,14 @@ type pipelineModel struct {
 	// path, refreshed on tab enter / pipeline re-run. Used by the file
 	// list rows and the footer totals.
 	numstat map[string]git.FileNumstat
-	width   int
-	height  int
+	// omitted holds the files the diff budget left out of the last
+	// Change Analyzer input (path → reason), parsed from diffCode.
+	omitted map[string]string
+	// hunks is the diff sub-block's hunk-staging mode (`s`); zero
+	// value means the plain staged diff is shown.
+	hunks  pipelineHunks
+	width  int
+	height int
 	// focusedStage tracks which of the 3 stage cards is currently grown
 	// (config.StageFocusedHeight). Tab cycles s1 → s2 → s3 → s1.
 	focusedStage stageID
@@ -139,6 +158,16 @@ type pipelineModel struct {
 	// final-commit card slot. Only meaningful while the final card is
 	// visible (allDone + commitTranslate set).
 	focusedFinal bool
+	// stream holds the partial output of running stages when
+	// TUIConfig.StreamResponses is on. Pointer so the mutex survives the
+	// value copies of pipelineModel.
+	stream *pipelineStream
+	// runID identifies the AI run whose result the Update loop is
+	// waiting for; runCancel aborts it. A result message carrying any
+	// other id belongs to a cancelled or superseded run and is dropped,
+	// so a late response can never overwrite the cards.
+	runID     int
+	runCancel context.CancelFunc
 }
 
 // newPipelineModel builds the Pipeline tab's initial state. It does not
@@ -149,6 +178,7 @@ func newPipelineModel() pipelineModel {
 		preset:       pipelinePresetCommit,
 		focusedStage: stageSummary,
 		activeStages: 3,
+		stream:       &pipelineStream{},
 	}
 	titles := pipelinePresetTitles(pm.preset)
 	for i := 0; i < len(pm.stages); i++ {
@@ -262,12 +292,65 @@ func (pm *pipelineModel) cycleFocusBackward(showFinal bool) {
 	}
 }
 
+// beginRun aborts any AI run still in flight and starts a new one,
+// returning the context to hand to the engine and the id its result
+// message must carry. Called on the main goroutine when the tea.Cmd is
+// built, never from inside it.
+func (pm *pipelineModel) beginRun() (context.Context, int) {
+	pm.abortRun()
+	ctx, cancel := context.WithCancel(context.Background())
+	pm.runCancel = cancel
+	return ctx, pm.runID
+}
+
+// abortRun cancels the run in flight (if any) and retires its id.
+func (pm *pipelineModel) abortRun() {
+	if pm.runCancel != nil {
+		pm.runCancel()
+		pm.runCancel = nil
+	}
+	pm.runID++
+}
+
+// finishRun reports whether a result tagged with id belongs to the
+// current run and, when it does, releases the run's context.
+func (pm *pipelineModel) finishRun(id int) bool {
+	if id != pm.runID {
+		return false
+	}
+	if pm.runCancel != nil {
+		pm.runCancel()
+		pm.runCancel = nil
+	}
+	return true
+}
+
+// cancelRunning aborts the run in flight and flips every Running stage
+// to Cancelled, dropping its streamed partial text. Returns the ids of
+// the stages it stopped, in order.
+func (pm *pipelineModel) cancelRunning() []stageID {
+	pm.abortRun()
+	pm.cancelling = true
+	var stopped []stageID
+	for i := range pm.stages {
+		if pm.stages[i].Status != statusRunning {
+			continue
+		}
+		pm.stages[i].Status = statusCancelled
+		pm.stages[i].Progress = 0
+		pm.stream.clearStage(stageID(i))
+		stopped = append(stopped, stageID(i))
+	}
+	return stopped
+}
+
 // resetAll marks every stage as Running with progress 0 and clears
 // per-stage flashes/errors. Used by the full re-run shortcut (`r`).
 func (pm *pipelineModel) resetAll(now time.Time) {
 	pm.fadeFrame = 0
 	pm.cancelling = false
 	pm.focusedFinal = false
+	pm.stream.clearFrom(stageSummary)
 	for i := range pm.stages {
 		if i >= pm.activeStages {
 			// Inactive stages (e.g. the changelog refiner when no
@@ -297,7 +380,10 @@ func (pm *pipelineModel) resetAll(now time.Time) {
 		pm.stages[i].APITotalTime = 0
 		pm.stages[i].RequestID = ""
 		pm.stages[i].StatsModel = ""
+		pm.stages[i].Provider = ""
 		pm.stages[i].TPMLimitAtCall = 0
+		pm.stages[i].Attempts = nil
+		pm.stages[i].Chunks = nil
 	}
 }
 
@@ -308,6 +394,7 @@ func (pm *pipelineModel) resetAll(now time.Time) {
 func (pm *pipelineModel) resetFrom(from stageID, now time.Time) {
 	pm.fadeFrame = 0
 	pm.cancelling = false
+	pm.stream.clearFrom(from)
 	for i := int(from); i < len(pm.stages); i++ {
 		if i >= pm.activeStages {
 			pm.stages[i].Status = statusIdle
@@ -334,7 +421,10 @@ func (pm *pipelineModel) resetFrom(from stageID, now time.Time) {
 		pm.stages[i].APITotalTime = 0
 		pm.stages[i].RequestID = ""
 		pm.stages[i].StatsModel = ""
+		pm.stages[i].Provider = ""
 		pm.stages[i].TPMLimitAtCall = 0
+		pm.stages[i].Attempts = nil
+		pm.stages[i].Chunks = nil
 	}
 }
 
@@ -352,6 +442,7 @@ func (pm *pipelineModel) pushStageHistory(id stageID, text string) {
 	st := &pm.stages[id]
 	st.History = append(st.History, stageHistoryEntry{
 		Text:             text,
+		Provider:         st.Provider,
 		Model:            st.StatsModel,
 		PromptTokens:     st.PromptTokens,
 		CompletionTokens: st.CompletionTokens,
@@ -362,6 +453,7 @@ func (pm *pipelineModel) pushStageHistory(id stageID, text string) {
 		APITotalTime:     st.APITotalTime,
 		RequestID:        st.RequestID,
 		TPMLimitAtCall:   st.TPMLimitAtCall,
+		Attempts:         st.Attempts,
 		CapturedAt:       time.Now(),
 	})
 	st.ActiveHistoryIndex = len(st.History) - 1
@@ -397,7 +489,9 @@ func (pm *pipelineModel) applyStageHistoryEntry(id stageID, index int) (stageHis
 	st.APITotalTime = entry.APITotalTime
 	st.RequestID = entry.RequestID
 	st.StatsModel = entry.Model
+	st.Provider = entry.Provider
 	st.TPMLimitAtCall = entry.TPMLimitAtCall
+	st.Attempts = entry.Attempts
 	st.HasStats = true
 	st.ActiveHistoryIndex = index
 	return entry, true
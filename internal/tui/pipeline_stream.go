@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+
+	"commit_craft_reborn/internal/api"
+	"commit_craft_reborn/internal/config"
+)
+
+// pipelineStream accumulates the streamed text of each stage while the
+// pipeline runs on its tea.Cmd goroutine. The cards read it on every
+// frame (the pulse tick already re-renders ~12 times a second while a
+// stage is running), so no extra message plumbing is needed. The mutex
+// is the only shared-state guard between the two goroutines.
+type pipelineStream struct {
+	mu   sync.Mutex
+	text [4]strings.Builder
+}
+
+// streamStageSlots maps an engine stage onto the card slot it fills.
+// Release stages reuse the first three slots (body / title / refine),
+// mirroring pipelinePresetTitles.
+var streamStageSlots = map[config.ModelStage]stageID{
+	config.StageChangeAnalyzer:       stageSummary,
+	config.StageChangeAnalyzerReduce: stageSummary,
+	config.StageCommitBody:           stageBody,
+	config.StageCommitTitle:          stageTitle,
+	config.StageChangelog:            stageChangelog,
+	config.StageReleaseBody:          stageSummary,
+	config.StageReleaseTitle:         stageBody,
+	config.StageReleaseRefine:        stageTitle,
+}
+
+// onChunk is the aiengine.Deps.OnChunk sink.
+func (s *pipelineStream) onChunk(stage config.ModelStage, delta string) {
+	id, ok := streamStageSlots[stage]
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	s.text[id].WriteString(delta)
+	s.mu.Unlock()
+}
+
+// onRetry is the aiengine.Deps.OnRetry sink: the failed attempt's
+// partial text is dropped so the next attempt streams into a clean card.
+func (s *pipelineStream) onRetry(stage config.ModelStage, _ api.CallAttempt) {
+	id, ok := streamStageSlots[stage]
+	if !ok {
+		return
+	}
+	s.clearStage(id)
+}
+
+// clearStage drops the streamed text of a single stage.
+func (s *pipelineStream) clearStage(id stageID) {
+	if int(id) < 0 || int(id) >= len(s.text) {
+		return
+	}
+	s.mu.Lock()
+	s.text[id].Reset()
+	s.mu.Unlock()
+}
+
+// get returns the text streamed so far for id.
+func (s *pipelineStream) get(id stageID) string {
+	if int(id) < 0 || int(id) >= len(s.text) {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.text[id].String()
+}
+
+// clearFrom drops the streamed text of `from` and every later stage.
+// Called by resetAll / resetFrom so a retry never shows the previous
+// run's partial output.
+func (s *pipelineStream) clearFrom(from stageID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := int(from); i < len(s.text); i++ {
+		s.text[i].Reset()
+	}
+}
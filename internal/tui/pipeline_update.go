@@ -116,6 +116,14 @@ func updatePipeline(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 
 	switch m := msg.(type) {
 	case tea.KeyMsg:
+		if model.pipeline.hunks.active {
+			if handled, cmd := updatePipelineHunks(model, m); handled {
+				return model, cmd
+			}
+		}
+		if key.Matches(m, model.keys.HunkMode) {
+			return model, togglePipelineHunkMode(model)
+		}
 		switch {
 		case key.Matches(m, model.keys.Toggle): // r — full retry
 			return model, model.pipelineStartFullRun()
@@ -313,6 +321,11 @@ func (model *Model) pipelineRetryStage(from stageID) tea.Cmd {
 		model.WritingStatusBar.Content = "At least one key point is required before requesting the AI."
 		return nil
 	}
+	// A retry supersedes the run in flight; upstream stages it was
+	// still working on end up Cancelled rather than spinning forever.
+	if model.pipeline.anyRunning() {
+		model.pipeline.cancelRunning()
+	}
 	model.pipeline.resetFrom(from, time.Now())
 	model.commitTranslate = ""
 
@@ -380,6 +393,11 @@ func (model *Model) pipelineReleaseRetryStage(from stageID) tea.Cmd {
 		return nil
 	}
 
+	// A retry supersedes the run in flight; upstream stages it was
+	// still working on end up Cancelled rather than spinning forever.
+	if model.pipeline.anyRunning() {
+		model.pipeline.cancelRunning()
+	}
 	model.pipeline.resetFrom(from, time.Now())
 
 	switch from {
@@ -408,20 +426,18 @@ func (model *Model) pipelineReleaseRetryStage(from stageID) tea.Cmd {
 	)
 }
 
-// pipelineCancel marks running stages as Cancelled and drains their
-// progress bars to 0% over ~250ms (built-in easing).
+// pipelineCancel aborts the AI request in flight, marks running stages
+// as Cancelled and drains their progress bars to 0% over ~250ms
+// (built-in easing). The aborted run's result message is discarded when
+// it arrives, so the cards keep the cancelled state.
 func (model *Model) pipelineCancel() tea.Cmd {
-	model.pipeline.cancelling = true
+	stopped := model.pipeline.cancelRunning()
 	cancelledIdx := -1
 	cmds := make([]tea.Cmd, 0, 4)
-	for i := range model.pipeline.stages {
-		if model.pipeline.stages[i].Status == statusRunning {
-			model.pipeline.stages[i].Status = statusCancelled
-			model.pipeline.stages[i].Progress = 0
-			cmds = append(cmds, model.pipeline.progress[i].SetPercent(0))
-			if cancelledIdx < 0 {
-				cancelledIdx = i
-			}
+	for _, id := range stopped {
+		cmds = append(cmds, model.pipeline.progress[id].SetPercent(0))
+		if cancelledIdx < 0 {
+			cancelledIdx = int(id)
 		}
 	}
 	model.WritingStatusBar.Content = fmt.Sprintf(
@@ -300,8 +300,15 @@ func (model *Model) renderPipelinePanel(width, height int) string {
 
 // stageRawOutput returns the model field that backs each stage's viewport.
 // Used by the renderer so the per-stage content stays a pure projection of
-// the model with no extra cache to keep in sync.
+// the model with no extra cache to keep in sync. While a stage is running
+// the partial streamed text stands in for the not-yet-written field.
 func (model *Model) stageRawOutput(id stageID) string {
+	if int(id) >= 0 && int(id) < len(model.pipeline.stages) &&
+		model.pipeline.stages[id].Status == statusRunning {
+		if partial := model.pipeline.stream.get(id); partial != "" {
+			return partial
+		}
+	}
 	if model.pipeline.preset == pipelinePresetRelease {
 		switch id {
 		case stageSummary:
@@ -557,7 +564,16 @@ func (model *Model) renderStageCard(idx, width, height, bodyRows int) string {
 	// Content is rendered fresh each frame so it adapts to width changes
 	// (panel resize, focus growth) and stays in sync with the latest model
 	// outputs without a separate cache.
-	vp.SetContent(model.renderStageContent(stageID(idx), innerW))
+	stageContent := model.renderStageContent(stageID(idx), innerW)
+	if chunks := renderStageChunks(theme, st, innerW); chunks != "" {
+		stageContent = chunks + "\n\n" + stageContent
+	}
+	vp.SetContent(stageContent)
+	if st.Status == statusRunning {
+		// Follow the streamed tail; applyPipelineResult scrolls back to
+		// the top once the stage completes.
+		vp.GotoBottom()
+	}
 	bodyRendered := vp.View()
 
 	// Some viewports return fewer rows than requested when the source is
@@ -724,6 +740,14 @@ func (model *Model) renderDiffSubBlock(width, height int) string {
 				lipgloss.NewStyle().Foreground(theme.Del).Render(neg)
 		}
 	}
+	if hk := model.pipeline.hunks; hk.active {
+		title = "hunks · " + hk.path
+		hint = "space stage/unstage · v lines · esc done"
+		if hk.lineMode {
+			hint = fmt.Sprintf("space mark · enter apply %d · esc back", len(hk.marked))
+		}
+		hint = lipgloss.NewStyle().Foreground(theme.Muted).Render(hint)
+	}
 
 	return renderTitledPanel(titledPanelOpts{
 		title:       title,
@@ -790,6 +814,49 @@ func renderStageHistoryHint(theme *styles.Theme, st *pipelineStage, width int) s
 	return line
 }
 
+// renderStageChunks lists the map calls of a chunked Change Analyzer run
+// as sub-stage rows (index, label, file count, tokens, duration, retries)
+// above the stage 1 output, which is then the reduce call's merge. ""
+// when the stage was not chunked.
+func renderStageChunks(theme *styles.Theme, st *pipelineStage, width int) string {
+	if len(st.Chunks) == 0 {
+		return ""
+	}
+	base := theme.AppStyles().Base
+	labelStyle := base.Foreground(theme.FG)
+	descStyle := base.Foreground(theme.Muted)
+	sepStyle := base.Foreground(theme.Subtle)
+	warnStyle := base.Foreground(theme.Warning)
+	sep := sepStyle.Render(" · ")
+
+	lines := []string{descStyle.Render(fmt.Sprintf(
+		"map-reduce · %d chunks merged by the reduce call", len(st.Chunks)))}
+	for _, c := range st.Chunks {
+		parts := []string{
+			sepStyle.Render("└ ") + descStyle.Render(fmt.Sprintf("%d/%d ", c.Index, len(st.Chunks))) +
+				labelStyle.Render(c.Label),
+		}
+		if n := len(c.Files); n > 0 {
+			parts = append(parts, descStyle.Render(plural(n, "file", "files")))
+		}
+		if c.Stats.TotalTokens > 0 {
+			parts = append(parts, descStyle.Render(formatTokenCount(c.Stats.TotalTokens)+" tok"))
+		}
+		if c.Stats.APITotalTime > 0 {
+			parts = append(parts, descStyle.Render(formatStageDuration(c.Stats.APITotalTime)))
+		}
+		if n := len(c.Stats.Attempts); n > 0 {
+			parts = append(parts, warnStyle.Render("↻ "+plural(n, "retry", "retries")))
+		}
+		line := strings.Join(parts, sep)
+		if width > 0 && ansi.StringWidth(line) > width {
+			line = ansi.Truncate(line, width, "…")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // renderStageTelemetry is the title-row variant of the per-stage stats —
 // same content as renderStageStatsLine but rendered without a width cap
 // so the caller (renderTitledPanel) can decide how to lay it out and
@@ -895,6 +962,20 @@ func renderStageStatsLine(theme *styles.Theme, st *pipelineStage, width int, dim
 		line += sep + bar + " " + pctText
 	}
 
+	// A stage served after retries / fallbacks says so; the attempt
+	// list itself lives in the Output screen's telemetry section.
+	if n := len(st.Attempts); n > 0 {
+		retryColor := theme.Warning
+		if dim {
+			retryColor = theme.Muted
+		}
+		label := fmt.Sprintf("↻ %d retries", n)
+		if n == 1 {
+			label = "↻ 1 retry"
+		}
+		line += sep + base.Foreground(retryColor).Render(label)
+	}
+
 	// (The "vN/M" badge that used to live here moved to the hint line
 	// rendered under the stage bar — see renderStageHistoryHint.)
 
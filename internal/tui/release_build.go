@@ -1,28 +1,141 @@
 package tui
 
 import (
+	"context"
 	"os/exec"
+	"sync"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+
+	"commit_craft_reborn/internal/config"
+	"commit_craft_reborn/internal/release"
 )
 
 // releaseBuildResultMsg reports the outcome of running the configured
-// pre-release build command (e.g. `make build_release`).
+// pre-release build command (e.g. `make build_release`). Results is
+// set for the `go` matrix, one entry per target; Err is then a
+// *release.BuildError when any of them failed.
 type releaseBuildResultMsg struct {
-	Err    error
-	Output string
+	Err     error
+	Output  string
+	Results []release.BuildResult
 }
 
-// execReleaseBuild runs the build command declared in
-// ReleaseConfig (currently only `make <target>`) before kicking off the
-// GitHub release upload. The caller must have validated that AutoBuild is
-// enabled and BuildTool/BuildTarget are populated.
+// execReleaseBuild runs the build declared in ReleaseConfig before
+// kicking off the release upload: `make <target>`, or the `go`
+// cross-compilation matrix, whose per-target progress lands in
+// model.releaseBuild for the loading panel. The caller must have
+// validated that AutoBuild is enabled and BuildTool/BuildTarget are
+// populated.
 func execReleaseBuild(model *Model) tea.Cmd {
+	cfg := model.globalConfig.ReleaseConfig
+	if cfg.BuildTool == config.ReleaseBuildGo {
+		matrix, err := release.NewBuildMatrix(cfg, model.pwd)
+		if err != nil {
+			return func() tea.Msg { return releaseBuildResultMsg{Err: err} }
+		}
+		// Start before returning so the first frame of the panel
+		// already lists every target.
+		model.releaseBuild.start(matrix.Targets)
+		return func() tea.Msg {
+			results, err := matrix.Run(context.Background(), model.releaseBuild.observer())
+			return releaseBuildResultMsg{Err: err, Results: results}
+		}
+	}
 	return func() tea.Msg {
-		cfg := model.globalConfig.ReleaseConfig
 		cmd := exec.Command(cfg.BuildTool, cfg.BuildTarget)
 		cmd.Dir = model.pwd
 		out, err := cmd.CombinedOutput()
 		return releaseBuildResultMsg{Err: err, Output: string(out)}
 	}
 }
+
+// releaseBuildProgress tracks each target of a running `go` build
+// matrix. Like releaseUploadProgress it is written from the build
+// goroutines and read by the loading panel on every spinner tick.
+type releaseBuildProgress struct {
+	mu      sync.Mutex
+	active  bool
+	targets []buildTargetProgress
+}
+
+type buildTargetState int
+
+const (
+	buildPending buildTargetState = iota
+	buildRunning
+	buildOK
+	buildFailed
+)
+
+type buildTargetProgress struct {
+	name     string
+	state    buildTargetState
+	lastLine string
+	started  time.Time
+	took     time.Duration
+}
+
+// start lists the targets about to be built, none started yet.
+func (p *releaseBuildProgress) start(targets []release.Platform) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = true
+	p.targets = p.targets[:0]
+	for _, t := range targets {
+		p.targets = append(p.targets, buildTargetProgress{name: t.String()})
+	}
+}
+
+// finish ends the build phase; the panel goes back to the upload copy.
+func (p *releaseBuildProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = false
+}
+
+// observer is the release.BuildObserver that feeds the progress.
+func (p *releaseBuildProgress) observer() release.BuildObserver {
+	return release.BuildObserver{
+		Start: func(t release.Platform) {
+			p.set(t, func(b *buildTargetProgress) {
+				b.state, b.started = buildRunning, time.Now()
+			})
+		},
+		Line: func(t release.Platform, line string) {
+			p.set(t, func(b *buildTargetProgress) { b.lastLine = line })
+		},
+		Done: func(r release.BuildResult) {
+			p.set(r.Target, func(b *buildTargetProgress) {
+				b.state, b.took = buildOK, r.Duration
+				if r.Err != nil {
+					b.state = buildFailed
+				}
+			})
+		},
+	}
+}
+
+func (p *releaseBuildProgress) set(t release.Platform, fn func(*buildTargetProgress)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name := t.String()
+	for i := range p.targets {
+		if p.targets[i].name == name {
+			fn(&p.targets[i])
+			return
+		}
+	}
+}
+
+// snapshot copies the targets for rendering; ok is false when no
+// matrix build is running.
+func (p *releaseBuildProgress) snapshot() (targets []buildTargetProgress, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.active {
+		return nil, false
+	}
+	return append([]buildTargetProgress(nil), p.targets...), true
+}
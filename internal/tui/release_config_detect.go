@@ -2,12 +2,13 @@ package tui
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/release"
 )
 
 // ReleaseDetect holds the auto-detected defaults that pre-fill the release
@@ -15,25 +16,32 @@ import (
 // best-effort and returns an empty value on failure, so the popup keeps
 // working on git-less or freshly-cloned trees.
 type ReleaseDetect struct {
-	Repository       string // owner/repo, parsed from `git remote get-url origin`
+	Repository       string // owner/repo (or group/sub/repo), parsed from the origin URL
+	Host             string // forge releases go to: release_config.host, else guessed from origin
+	TokenEnv         string // env var holding Host's token (GH_TOKEN, GITLAB_TOKEN, GITEA_TOKEN)
 	Branch           string // current branch's short name
 	LastTag          string // most recent tag, if any
 	SuggestedVersion string // BumpVersionPatch(LastTag) or "v0.1.0"
 	AssetsPath       string // first of "bin", "build", "dist" that exists, else ""
-	GhTokenSet       bool   // does the env already have GH_TOKEN?
+	TokenSet         bool   // does the env already have TokenEnv?
 	BuildTool        string // "make" when a Makefile is present, else ""
 	BuildTarget      string // detected Makefile target (build_release / build / release)
 }
 
 // DetectRelease runs the read-only detection probes against `pwd` and
-// returns whatever it could find. Never errors — empty strings indicate
-// "couldn't detect; ask the user to fill it in".
-func DetectRelease(pwd string) ReleaseDetect {
+// returns whatever it could find. hostOverride is release_config.host;
+// when empty the forge is guessed from the origin URL. Never errors —
+// empty strings indicate "couldn't detect; ask the user to fill it in".
+func DetectRelease(pwd, hostOverride string) ReleaseDetect {
+	origin, _ := release.OriginRemote(pwd)
+	host := release.ResolveHost(config.ReleaseConfig{Host: hostOverride}, origin)
 	d := ReleaseDetect{
-		Repository: detectGithubRepository(pwd),
+		Repository: origin.Repository,
+		Host:       host,
+		TokenEnv:   config.ReleaseTokenEnv(host),
 		AssetsPath: detectAssetsDir(pwd),
-		GhTokenSet: os.Getenv("GH_TOKEN") != "",
 	}
+	d.TokenSet = os.Getenv(d.TokenEnv) != ""
 	if branch, err := git.GetCurrentGitBranch(); err == nil {
 		d.Branch = branch
 	}
@@ -107,36 +115,12 @@ func ListMakefileTargets(pwd string) []string {
 	return out
 }
 
-// ListBuildTools returns the build tools CommitCraft can drive today.
-// The release config popup opens this list on Enter for the build_tool
-// field so the user gets discoverability even though `make` is the
-// only entry — future tools land here without UI changes.
+// ListBuildTools returns the build tools CommitCraft can drive today:
+// `make <build_target>`, or the `go` cross-compilation matrix over
+// release_config.targets. The release config popup opens this list on
+// Enter for the build_tool field.
 func ListBuildTools() []string {
-	return []string{"make"}
-}
-
-// repoRegex captures the trailing `owner/repo` portion of any github URL,
-// stripping a leading `git@github.com:` SSH prefix, an `https://github.com/`
-// HTTP prefix, and the optional `.git` suffix. Only github.com is matched
-// — the upload helper only knows how to talk to gh, so other hosts get
-// no auto-detect.
-var repoRegex = regexp.MustCompile(`(?i)github\.com[:/]+([^/\s]+/[^/\s]+?)(?:\.git)?$`)
-
-// detectGithubRepository runs `git -C pwd remote get-url origin` and
-// parses the URL into `owner/repo`. Returns "" if there is no remote,
-// the remote is not on github.com, or the URL format is unrecognised.
-func detectGithubRepository(pwd string) string {
-	cmd := exec.Command("git", "-C", pwd, "remote", "get-url", "origin")
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	url := strings.TrimSpace(string(out))
-	m := repoRegex.FindStringSubmatch(url)
-	if len(m) < 2 {
-		return ""
-	}
-	return m[1]
+	return []string{config.ReleaseBuildMake, config.ReleaseBuildGo}
 }
 
 // detectAssetsDir returns the first directory among ("bin", "build",
@@ -107,19 +107,19 @@ func newReleaseConfigPopup(
 	m.labels[releaseFieldAutoBuild] = sym.BuildTool + "  Auto build (true/false)"
 	m.labels[releaseFieldBuildTool] = sym.BuildTool + "  Build tool"
 	m.labels[releaseFieldBuildTarget] = sym.BuildTool + "  Build target"
-	m.labels[releaseFieldToken] = sym.TokenIcon + "  GH_TOKEN"
+	m.labels[releaseFieldToken] = sym.TokenIcon + "  " + detected.TokenEnv
 
-	m.hints[releaseFieldRepository] = formatHint("Detected", detected.Repository)
+	m.hints[releaseFieldRepository] = formatHint("Detected on "+releaseHostLabel(detected.Host), detected.Repository)
 	m.hints[releaseFieldBranch] = formatHint("Current branch", detected.Branch)
 	m.hints[releaseFieldVersion] = formatVersionHint(detected.LastTag, detected.SuggestedVersion)
 	m.hints[releaseFieldAssets] = formatHint("Detected", detected.AssetsPath)
 	m.hints[releaseFieldAutoBuild] = "space to toggle · runs the configured build target before upload"
 	m.hints[releaseFieldBuildTool] = formatHint("Detected", detected.BuildTool)
 	m.hints[releaseFieldBuildTarget] = formatHint("Detected", detected.BuildTarget)
-	if detected.GhTokenSet {
+	if detected.TokenSet {
 		m.hints[releaseFieldToken] = "stored in ~/.config/CommitCraft/.env — leave blank to keep current"
 	} else {
-		m.hints[releaseFieldToken] = "not configured — required to upload to GitHub"
+		m.hints[releaseFieldToken] = "not configured — required to upload to " + releaseHostLabel(detected.Host)
 	}
 
 	autoBuildPre := "false"
@@ -318,6 +318,7 @@ func (m releaseConfigPopupModel) save() tea.Cmd {
 	buildTool := strings.TrimSpace(m.inputs[releaseFieldBuildTool].Value())
 	buildTarget := strings.TrimSpace(m.inputs[releaseFieldBuildTarget].Value())
 	token := m.inputs[releaseFieldToken].Value()
+	host := m.detected.Host
 	autoOpen := m.autoOpen
 
 	return func() tea.Msg {
@@ -327,7 +328,7 @@ func (m releaseConfigPopupModel) save() tea.Cmd {
 			return releaseConfigSavedMsg{err: err, fromAutoOpen: autoOpen}
 		}
 		if strings.TrimSpace(token) != "" {
-			if err := SaveGhTokenToEnv(strings.TrimSpace(token)); err != nil {
+			if err := SaveReleaseTokenToEnv(host, strings.TrimSpace(token)); err != nil {
 				return releaseConfigSavedMsg{err: err, fromAutoOpen: autoOpen}
 			}
 		}
@@ -392,7 +393,7 @@ func (m releaseConfigPopupModel) renderHelpFooter() string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, parts...)
 }
 
-// renderTokenConfiguredRow paints the GH_TOKEN "configured" indicator
+// renderTokenConfiguredRow paints the host token "configured" indicator
 // shown when the env already has a stored value and the user hasn't
 // typed a replacement yet. Renders as a single line that mirrors the
 // textinput's prompt-and-content layout so the rest of the popup
@@ -455,13 +456,13 @@ func (m releaseConfigPopupModel) View() tea.View {
 		if m.pickerActive && i == m.pickerField {
 			body = m.renderFieldPicker()
 		}
-		// GH_TOKEN row: when the token is already stored in .env and
+		// Token row: when the token is already stored in .env and
 		// the user hasn't started typing a replacement, paint a clear
 		// "configured" indicator instead of an empty masked input.
 		// Otherwise the popup looks like the field is unset, which
 		// confused the user reviewing v0.54.0.
 		if i == releaseFieldToken &&
-			m.detected.GhTokenSet &&
+			m.detected.TokenSet &&
 			strings.TrimSpace(m.inputs[i].Value()) == "" &&
 			!(m.pickerActive && i == m.pickerField) {
 			body = m.renderTokenConfiguredRow()
@@ -9,6 +9,8 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/commit"
 	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/storage"
 	"commit_craft_reborn/internal/tui/styles"
@@ -24,14 +26,25 @@ const releaseEntryHashLen = 7
 // matched so a shell-style `[$VAR]` doesn't get mistaken for a type chip.
 var releaseTagPattern = regexp.MustCompile(`^\s*\[([A-Z]+)\]\s*(.*)$`)
 
-// extractCommitTag splits `[TAG] rest` into ("TAG", "rest"). Returns
-// ("", subject) when the leading bracketed token isn't present.
+// extractCommitTag splits `[TAG] rest` into ("TAG", "rest"). Conventional
+// subjects (`feat(api): rest`) yield the upper-cased type and
+// "api: rest" so both profiles render the same chip + text row. Returns
+// ("", subject) when neither shape is present; capitalised prose like
+// "Note: …" is not taken for a conventional type.
 func extractCommitTag(subject string) (string, string) {
 	m := releaseTagPattern.FindStringSubmatch(subject)
 	if len(m) == 3 {
 		return m[1], m[2]
 	}
-	return "", subject
+	h, ok := commit.ParseHeader(subject)
+	if !ok || h.Profile != commit.ProfileConventional || h.Type != strings.ToLower(h.Type) {
+		return "", subject
+	}
+	rest := h.Description
+	if h.Scope != "" {
+		rest = h.Scope + ": " + rest
+	}
+	return strings.ToUpper(h.Type), rest
 }
 
 // releaseInspectEntry is one row in the left list of the Commits/Body
@@ -190,8 +203,13 @@ func (p *ReleaseDualPanel) SetRelease(
 			continue
 		}
 		st := &p.stageStats[id]
+		if !call.Served() {
+			st.Attempts = append(st.Attempts, aiengine.AttemptFromCall(call))
+			continue
+		}
 		st.HasStats = true
 		st.StatsModel = call.Model
+		st.Provider = call.Provider
 		st.Model = call.Model
 		st.PromptTokens = call.PromptTokens
 		st.CompletionTokens = call.CompletionTokens
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"time"
 
 	"charm.land/lipgloss/v2"
 )
@@ -37,10 +38,21 @@ func (model *Model) renderReleaseLoading(width, height int) string {
 	// Upload phase wins when both flags are set: the history sync is a
 	// non-blocking background lookup, while the upload is what the user
 	// just confirmed, so its copy is the one they expect to see.
+	var assets []assetProgress
 	if model.releaseUploading {
-		titleText = "Uploading release to GitHub"
+		var host string
+		host, assets = model.releaseUpload.snapshot()
+		titleText = "Uploading release to " + releaseHostLabel(host)
 		subtitleText = "building & pushing assets…"
 	}
+	// A running `go` build matrix comes before the upload, so its
+	// per-target rows replace the (not yet reset) asset rows.
+	targets, building := model.releaseBuild.snapshot()
+	if model.releaseUploading && building {
+		assets = nil
+		titleText = "Building release assets"
+		subtitleText = fmt.Sprintf("cross-compiling %d target(s)…", len(targets))
+	}
 	title := titleStyle.Render(titleText)
 	subtitle := subtitleStyle.Render(subtitleText)
 	hint := hintStyle.Render(fmt.Sprintf("workspace: %s", TruncatePath(model.pwd, 2)))
@@ -48,7 +60,21 @@ func (model *Model) renderReleaseLoading(width, height int) string {
 	// Two-column body: spinner glyph on the left, stacked text on the
 	// right. JoinHorizontal aligns at the top so the spinner sits next
 	// to the title row.
-	textCol := lipgloss.JoinVertical(lipgloss.Left, title, subtitle, "", hint)
+	rows := []string{title, subtitle}
+	if len(assets) > 0 {
+		rows = append(rows, "")
+		for _, a := range assets {
+			rows = append(rows, renderAssetProgress(model, a))
+		}
+	}
+	if len(targets) > 0 && building {
+		rows = append(rows, "")
+		for _, t := range targets {
+			rows = append(rows, renderBuildTarget(model, t))
+		}
+	}
+	rows = append(rows, "", hint)
+	textCol := lipgloss.JoinVertical(lipgloss.Left, rows...)
 	body := lipgloss.JoinHorizontal(lipgloss.Top, spinnerGlyph+"  ", textCol)
 
 	box := lipgloss.NewStyle().
@@ -67,3 +93,86 @@ func (model *Model) renderReleaseLoading(width, height int) string {
 	}
 	return lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, box)
 }
+
+// renderAssetProgress is one upload row of the loading panel: the asset
+// name, a braille bar and the bytes sent. Assets the host has not
+// reported on yet render an empty track.
+//
+//	app_linux_amd64.tar.gz  ⣿⣿⣿⣿⣿⣿⡇⠀⠀⠀⠀⠀  58%  2.9 MB / 5.0 MB
+func renderAssetProgress(model *Model, a assetProgress) string {
+	theme := model.Theme
+	base := theme.AppStyles().Base
+	const nameWidth, barWidth = 24, 16
+
+	name := a.name
+	if lipgloss.Width(name) > nameWidth {
+		name = name[:nameWidth-1] + "…"
+	}
+	nameStyled := base.Foreground(theme.Secondary).Render(fmt.Sprintf("%-*s", nameWidth, name))
+
+	pct := 0
+	if a.total > 0 {
+		pct = int(a.sent * 100 / a.total)
+	}
+	fill := theme.Primary
+	if a.total > 0 && a.sent >= a.total {
+		fill = theme.Success
+	}
+	bar := renderBrailleRampWithEmpty(pct, 100, barWidth, base, fill, theme.Subtle, braille8Levels[1])
+	usage := "waiting"
+	if a.total > 0 {
+		usage = fmt.Sprintf("%3d%%  %s / %s", pct, formatBytes(a.sent), formatBytes(a.total))
+	}
+	return nameStyled + "  " + bar + "  " + base.Foreground(theme.Muted).Render(usage)
+}
+
+// renderBuildTarget is one build row of the loading panel: the
+// target, a status glyph and either how long it took or, while it
+// runs, the last line `go build` printed.
+//
+//	linux/arm64      ⠋  github.com/x/y/internal/api
+//	windows/amd64    ✓  4.2s
+func renderBuildTarget(model *Model, t buildTargetProgress) string {
+	theme := model.Theme
+	base := theme.AppStyles().Base
+	const nameWidth, lineWidth = 16, 40
+
+	nameStyled := base.Foreground(theme.Secondary).Render(fmt.Sprintf("%-*s", nameWidth, t.name))
+	glyph, color, detail := "·", theme.Subtle, "waiting"
+	switch t.state {
+	case buildRunning:
+		glyph, color = model.spinner.View(), theme.Primary
+		detail = fmt.Sprintf("%.0fs", time.Since(t.started).Seconds())
+		if t.lastLine != "" {
+			detail = t.lastLine
+		}
+	case buildOK:
+		glyph, color = "✓", theme.Success
+		detail = fmt.Sprintf("%.1fs", t.took.Seconds())
+	case buildFailed:
+		glyph, color = "✗", theme.Error
+		detail = "failed · see logs"
+		if t.lastLine != "" {
+			detail = t.lastLine
+		}
+	}
+	if lipgloss.Width(detail) > lineWidth {
+		detail = string([]rune(detail)[:lineWidth-1]) + "…"
+	}
+	return nameStyled + "  " + base.Foreground(color).Render(glyph) + "  " +
+		base.Foreground(theme.Muted).Render(detail)
+}
+
+// formatBytes prints a size with a decimal unit, e.g. "2.9 MB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -1,22 +1,28 @@
 package tui
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"sync"
 
 	tea "charm.land/bubbletea/v2"
 
 	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/logger"
+	"commit_craft_reborn/internal/release"
 )
 
-// UploadReleaseToGithub publishes a release to GitHub using the `gh` CLI.
-// The tag, repository, and binary asset path are sourced from
+// UploadReleaseToGithub publishes a release through release.NewHost:
+// GitHub (REST API with GH_TOKEN, the `gh` CLI otherwise), GitLab or
+// Gitea, picked from release_config.host or the origin URL. The tag,
+// repository, branch and binary asset path are sourced from
 // config.ReleaseConfig; the body comes from the selected stored release.
+// The host creates the tag from the configured branch (else its default
+// branch) when it does not exist yet; `ai release publish` tags and
+// pushes first. Assets are packed, checksummed and signed first when
+// [release_config] asks for it. progress receives each asset's upload
+// progress.
 //
 // Returns noAssets=true when the release was created without attaching any
 // files (either because BinaryAssetsPath was empty/missing or the
@@ -29,83 +35,111 @@ func UploadReleaseToGithub(
 	config *config.Config,
 	logger *logger.Logger,
 	tools Tools,
+	progress *releaseUploadProgress,
 ) (noAssets bool, err error) {
-	if !tools.gh.available {
-		return false, fmt.Errorf("The Github CLI is not available on the system")
+	host, err := release.NewHost(config.ReleaseConfig, pwd)
+	if err != nil {
+		return false, err
 	}
-
-	var files []string
-	assetsPath := config.ReleaseConfig.BinaryAssetsPath
-	if assetsPath != "" {
-		assetPath := filepath.Join(pwd, assetsPath)
-		if info, statErr := os.Stat(assetPath); statErr == nil && info.IsDir() {
-			walkErr := filepath.Walk(
-				assetPath,
-				func(path string, info os.FileInfo, err error) error {
-					if err != nil {
-						return err
-					}
-					if !info.IsDir() {
-						files = append(files, path)
-					}
-					return nil
-				},
-			)
-			if walkErr != nil {
-				return false, walkErr
-			}
-		}
+	if _, isCLI := host.(release.GhCLI); isCLI && !tools.gh.available {
+		return false, fmt.Errorf("The Github CLI is not available on the system and GH_TOKEN is not set")
 	}
 
-	tmpFile, err := os.CreateTemp("", "release-notes-*.md")
+	files, err := release.CollectAssets(pwd, config.ReleaseConfig.BinaryAssetsPath)
 	if err != nil {
-		return false, fmt.Errorf("failed to create temporary file for release notes: %w", err)
+		return false, err
 	}
-	defer func() {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-	}()
-
-	token := config.ReleaseConfig.GhToken
 	tag := config.ReleaseConfig.Version
-	repository := config.ReleaseConfig.Repository
-	title := fmt.Sprintf("Release %s: %s", tag, selectedItem.release.Title)
-
-	_, err = tmpFile.WriteString(selectedItem.release.Body)
+	spec := release.Spec{
+		Workspace:  pwd,
+		Repository: config.ReleaseConfig.Repository,
+		Tag:        tag,
+		Title:      fmt.Sprintf("Release %s: %s", tag, selectedItem.release.Title),
+		Notes:      selectedItem.release.Body,
+		Assets:     files,
+		Target:     config.ReleaseConfig.Branch,
+		Progress:   progress.update,
+	}
+	pkg := release.NewPackaging(config.ReleaseConfig, release.ProjectName(spec.Repository, pwd), tag)
+	spec, prepared, err := release.PrepareSpec(spec, pkg)
 	if err != nil {
-		return false, fmt.Errorf("failed to write release notes to temporary file: %w", err)
+		return false, fmt.Errorf("prepare assets: %w", err)
 	}
-	tmpFile.Sync()
-	notesFilePath := tmpFile.Name()
+	defer prepared.Cleanup()
+	progress.reset(host.Name(), spec.Assets)
+	logger.Debug(host.Describe(spec))
 
-	args := []string{
-		"release", "create", tag,
-		"--repo", repository,
-		"--title", title,
-		"--notes-file", notesFilePath,
+	if _, err := host.CreateRelease(context.Background(), spec); err != nil {
+		logger.Debug(err.Error())
+		return false, err
 	}
-	args = append(args, files...)
 
-	logger.Debug(fmt.Sprintf("gh %s", strings.Join(args, " ")))
+	return len(files) == 0, nil
+}
 
-	cmd := exec.Command("gh", args...)
-	cmd.Env = append(os.Environ(), fmt.Sprintf("GH_TOKEN=%s", token))
-	var outb, errb bytes.Buffer
-	cmd.Stdout = &outb
-	cmd.Stderr = &errb
+// releaseUploadProgress tracks the upload of each asset while the
+// upload runs on its tea.Cmd goroutine. The loading panel reads it on
+// every spinner tick, the same way the pipeline cards read
+// pipelineStream, so no message plumbing is needed.
+type releaseUploadProgress struct {
+	mu     sync.Mutex
+	host   string
+	assets []assetProgress
+}
 
-	if err := cmd.Run(); err != nil {
-		logger.Debug(err.Error())
-		logger.Debug(errb.String())
-		return false, fmt.Errorf(
-			"error running command: stdout: %s, stderr: %s, err: %w",
-			outb.String(),
-			errb.String(),
-			err,
-		)
+type assetProgress struct {
+	name        string
+	sent, total int64
+}
+
+// reset lists the assets about to be uploaded to host, none sent yet.
+func (p *releaseUploadProgress) reset(host string, paths []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.host = host
+	p.assets = p.assets[:0]
+	for _, path := range paths {
+		p.assets = append(p.assets, assetProgress{name: filepath.Base(path)})
 	}
+}
 
-	return len(files) == 0, nil
+// update is the release.Spec.Progress sink.
+func (p *releaseUploadProgress) update(name string, sent, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.assets {
+		if p.assets[i].name == name {
+			p.assets[i].sent, p.assets[i].total = sent, total
+			return
+		}
+	}
+}
+
+// snapshot copies the current host and progress for rendering.
+func (p *releaseUploadProgress) snapshot() (string, []assetProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.host, append([]assetProgress(nil), p.assets...)
+}
+
+// releaseHostLabel is the display name of a release host.
+func releaseHostLabel(host string) string {
+	switch host {
+	case config.ReleaseHostGitLab:
+		return "GitLab"
+	case config.ReleaseHostGitea:
+		return "Gitea"
+	}
+	return "GitHub"
+}
+
+// releaseHostTokenSet reports whether the token of the host the
+// workspace's releases go to is loaded. Part of the upload pre-flight:
+// without it the config popup opens first.
+func releaseHostTokenSet(model *Model) bool {
+	rc := model.globalConfig.ReleaseConfig
+	origin, _ := release.OriginRemote(model.pwd)
+	return rc.Token(release.ResolveHost(rc, origin)) != ""
 }
 
 // execUploadRelease wraps UploadReleaseToGithub as a tea.Cmd for use inside
@@ -118,6 +152,7 @@ func execUploadRelease(releaseItem HistoryReleaseItem, model *Model) tea.Cmd {
 			&model.globalConfig,
 			model.log,
 			model.ToolsInfo,
+			model.releaseUpload,
 		)
 		return releaseUpdloadResultMsg{Err: err, NoAssets: noAssets}
 	}
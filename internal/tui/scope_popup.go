@@ -11,6 +11,7 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/scope"
 	"commit_craft_reborn/internal/tui/styles"
 )
 
@@ -32,11 +33,15 @@ type scopePopupModel struct {
 	useNerdFonts  bool
 	width, height int
 	theme         *styles.Theme
+	// suggestion is the inferred scope shown in the header; empty when
+	// nothing is staged or inference failed.
+	suggestion scope.Inference
 }
 
 func newScopePopup(
 	startPwd string,
 	gitData git.StatusData,
+	suggestion scope.Inference,
 	useNerdFonts bool,
 	width, height int,
 	theme *styles.Theme,
@@ -80,6 +85,7 @@ func newScopePopup(
 		width:        width,
 		height:       height,
 		theme:        theme,
+		suggestion:   suggestion,
 	}
 }
 
@@ -87,6 +93,9 @@ func (m scopePopupModel) Init() tea.Cmd { return nil }
 
 func (m scopePopupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if km, ok := msg.(tea.KeyMsg); ok {
+		if key.Matches(km, keyUseSuggestedScope) && m.suggestion.Scope != "" {
+			return m, func() tea.Msg { return setScopeMsg{scope: m.suggestion.Scope} }
+		}
 		switch km.String() {
 		case "enter":
 			if item, ok := m.list.SelectedItem().(FileItem); ok {
@@ -151,6 +160,9 @@ func (m scopePopupModel) View() tea.View {
 		Foreground(m.theme.Muted).
 		Render(fmt.Sprintf("· %s", TruncatePath(m.pwd, 3)))
 	header := lipgloss.JoinHorizontal(lipgloss.Top, title, " ", pathLabel)
+	if line := scopeSuggestionLine(m.theme, m.suggestion); line != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, line)
+	}
 
 	helpStyles := m.theme.AppStyles().Help
 	hintPairs := [][2]string{
@@ -158,6 +170,7 @@ func (m scopePopupModel) View() tea.View {
 		{"↑↓", "nav"},
 		{"←/→ tab/shift+tab", "dirs"},
 		{"ctrl+r", "modified-only"},
+		{"ctrl+o", "suggested"},
 		{"enter", "pick"},
 		{"esc", "clear/close"},
 	}
@@ -214,9 +227,48 @@ func (m *scopePopupModel) refreshList() {
 	}
 }
 
+// scopeSuggestionLine renders the inferred scope for the picker headers:
+// the scope and what it was read from, plus a warning when the staged
+// files span several packages.
+func scopeSuggestionLine(theme *styles.Theme, inf scope.Inference) string {
+	if inf.Scope == "" {
+		return ""
+	}
+	base := theme.AppStyles().Base
+	line := base.Foreground(theme.Muted).Render("suggested ") +
+		base.Foreground(theme.Tertiary).Bold(true).Render(inf.Scope) +
+		base.Foreground(theme.Muted).Render(" ("+inf.Kind+")")
+	if inf.Multi {
+		line += base.Foreground(theme.Warning).
+			Render(fmt.Sprintf("  ⚠ %d packages staged, consider splitting", len(inf.Candidates)))
+	}
+	return line
+}
+
+// inferScope proposes a scope for the staged files from the repository
+// layout and the [scope] config. Failures are logged and leave the
+// suggestion empty.
+func (model *Model) inferScope() scope.Inference {
+	inf, err := scope.Staged(model.pwd, scope.Options{
+		Manifests: model.globalConfig.Scope.Manifests,
+		Map:       model.globalConfig.Scope.Map,
+	})
+	if err != nil {
+		model.log.Debug("scope inference failed", "error", err)
+	}
+	return inf
+}
+
 // keyScopePopup opens the scope file picker from inside the
 // writing-message state.
 var keyScopePopup = key.NewBinding(
 	key.WithKeys("ctrl+p"),
 	key.WithHelp("ctrl+p", "Edit scope"),
 )
+
+// keyUseSuggestedScope accepts the inferred scope in the scope picker
+// and the scope popup.
+var keyUseSuggestedScope = key.NewBinding(
+	key.WithKeys("ctrl+o"),
+	key.WithHelp("ctrl+o", "Use the suggested scope"),
+)
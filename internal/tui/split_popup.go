@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/storage"
+	"commit_craft_reborn/internal/tui/statusbar"
+	"commit_craft_reborn/internal/tui/styles"
+)
+
+// splitPlanReadyMsg carries the split planner's result back to Update.
+type splitPlanReadyMsg struct {
+	plan aiengine.SplitPlan
+	err  error
+}
+
+// closeSplitPopupMsg dismisses the plan without creating drafts.
+type closeSplitPopupMsg struct{}
+
+// splitAcceptMsg is fired when the user accepts the plan shown in the
+// popup.
+type splitAcceptMsg struct {
+	plan aiengine.SplitPlan
+}
+
+// splitDoneMsg reports the drafts created by an accepted plan. ids
+// holds the drafts saved before err, if any.
+type splitDoneMsg struct {
+	ids []int
+	err error
+}
+
+// splitDeps is engineDeps without the pipeline stream sinks: the split
+// run has no pipeline cards to stream into.
+func splitDeps(model *Model) aiengine.Deps {
+	return aiengine.Deps{
+		Cfg: model.globalConfig,
+		DB:  model.db,
+		Log: model.log,
+		Pwd: model.pwd,
+	}
+}
+
+// startSplitPlan runs the split planner against the staged changes and
+// emits splitPlanReadyMsg. Inputs are captured on the main goroutine.
+func startSplitPlan(model *Model) tea.Cmd {
+	if model.splitRunning {
+		return model.WritingStatusBar.ShowMessageForDuration(
+			"A split is already running",
+			statusbar.LevelWarning,
+			2*time.Second,
+		)
+	}
+	deps := splitDeps(model)
+	types := model.finalCommitTypes
+	maxDiff := model.globalConfig.Prompts.ChangeAnalyzerMaxDiffSize
+	model.splitRunning = true
+	model.WritingStatusBar.Level = statusbar.LevelWarning
+	model.WritingStatusBar.Content = "Planning the split…"
+	return tea.Batch(model.WritingStatusBar.StartSpinner(), func() tea.Msg {
+		files, err := aiengine.StagedSplitFiles()
+		if err != nil {
+			return splitPlanReadyMsg{err: err}
+		}
+		if len(files) == 0 {
+			return splitPlanReadyMsg{err: fmt.Errorf("no staged changes to split")}
+		}
+		diff, err := git.GetStagedDiffSummary(maxDiff)
+		if err != nil {
+			return splitPlanReadyMsg{err: err}
+		}
+		plan, err := aiengine.PlanSplit(context.Background(), deps, files, diff, types)
+		return splitPlanReadyMsg{plan: plan, err: err}
+	})
+}
+
+func handleSplitPlanReady(model *Model, msg splitPlanReadyMsg) (tea.Model, tea.Cmd) {
+	model.splitRunning = false
+	stop := model.WritingStatusBar.StopSpinner()
+	if msg.err != nil {
+		model.log.Error("split planner failed", "error", msg.err)
+		return model, tea.Batch(stop, model.WritingStatusBar.ShowMessageForDuration(
+			fmt.Sprintf("Split failed: %s", msg.err),
+			statusbar.LevelError,
+			3*time.Second,
+		))
+	}
+	if msg.plan.Warning != "" {
+		model.log.Warn("split planner fallback", "warning", msg.plan.Warning)
+	}
+	w := min(max(70, model.width*3/4), model.width-2)
+	model.popup = newSplitPopup(w, model.height-2, model.Theme, msg.plan)
+	return model, stop
+}
+
+// startSplitRun accepts plan: aiengine.RunSplit stages each group in
+// turn, runs the pipeline and the callback saves one draft per group.
+// The changelog refiner follows the Compose toggle.
+func startSplitRun(model *Model, plan aiengine.SplitPlan) tea.Cmd {
+	deps := splitDeps(model)
+	db := model.db
+	pwd := model.pwd
+	changelogActive := model.changelogActive
+	model.splitRunning = true
+	model.WritingStatusBar.Level = statusbar.LevelWarning
+	model.WritingStatusBar.Content = fmt.Sprintf("Generating %d split drafts…", len(plan.Groups))
+	return tea.Batch(model.WritingStatusBar.StartSpinner(), func() tea.Msg {
+		var ids []int
+		err := aiengine.RunSplit(context.Background(), deps, plan, changelogActive,
+			func(r aiengine.SplitGroupResult) error {
+				c := storage.Commit{
+					Type:        r.Group.Tag,
+					Scope:       r.Group.Scope,
+					KeyPoints:   r.Group.KeyPoints,
+					Workspace:   pwd,
+					Diff_code:   r.Diff,
+					IaSummary:   r.Output.Summary,
+					IaCommitRaw: r.Output.Body,
+					IaTitle:     r.Output.Title,
+					IaChangelog: r.Output.ChangelogEntry,
+					MessageEN:   r.Output.FinalMessage,
+					Files:       r.Group.Files,
+				}
+				if err := db.SaveDraft(&c); err != nil {
+					return err
+				}
+				ids = append(ids, c.ID)
+				return nil
+			})
+		return splitDoneMsg{ids: ids, err: err}
+	})
+}
+
+// handleSplitDone switches the history list to drafts so the new rows
+// are visible right away.
+func handleSplitDone(model *Model, msg splitDoneMsg) (tea.Model, tea.Cmd) {
+	model.splitRunning = false
+	stop := model.WritingStatusBar.StopSpinner()
+	if len(msg.ids) > 0 {
+		model.draftMode = true
+		model.mainList.Title = "Showing drafts"
+		UpdateCommitList(model.historyScope(), model.db, model.log, &model.mainList, commitDb, "draft")
+	}
+	if msg.err != nil {
+		model.log.Error("split run failed", "error", msg.err, "drafts", msg.ids)
+		return model, tea.Batch(stop, model.WritingStatusBar.ShowMessageForDuration(
+			fmt.Sprintf("Split stopped after %d drafts: %s", len(msg.ids), msg.err),
+			statusbar.LevelError,
+			4*time.Second,
+		))
+	}
+	return model, tea.Batch(stop, model.WritingStatusBar.ShowMessageForDuration(
+		fmt.Sprintf("Created %d drafts · commit them one by one from the drafts list", len(msg.ids)),
+		statusbar.LevelSuccess,
+		3*time.Second,
+	))
+}
+
+// splitPopupModel previews a split plan: one block per group with its
+// tag, scope, key points and files. Enter accepts the whole plan.
+type splitPopupModel struct {
+	width, height int
+	theme         *styles.Theme
+	plan          aiengine.SplitPlan
+	cursor        int
+}
+
+func newSplitPopup(width, height int, theme *styles.Theme, plan aiengine.SplitPlan) splitPopupModel {
+	return splitPopupModel{width: width, height: height, theme: theme, plan: plan}
+}
+
+func (m splitPopupModel) Init() tea.Cmd { return nil }
+
+func (m splitPopupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch km.String() {
+	case "esc", "q":
+		return m, func() tea.Msg { return closeSplitPopupMsg{} }
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.plan.Groups)-1 {
+			m.cursor++
+		}
+	case "enter":
+		plan := m.plan
+		return m, func() tea.Msg { return splitAcceptMsg{plan: plan} }
+	}
+	return m, nil
+}
+
+func (m splitPopupModel) View() tea.View {
+	base := m.theme.AppStyles().Base
+	help := m.theme.AppStyles().Help
+	muted := base.Foreground(m.theme.Muted)
+	accent := base.Foreground(m.theme.Accent).Bold(true)
+
+	title := base.Foreground(m.theme.Secondary).Bold(true).
+		Render(m.theme.AppSymbols().SplitPlan + " Split plan")
+	subtitle := fmt.Sprintf("%d commits · planned by %s", len(m.plan.Groups), m.plan.Source)
+	if m.plan.Warning != "" {
+		subtitle += " · " + m.plan.Warning
+	}
+
+	inner := max(20, m.width-6)
+	var blocks []string
+	for i, g := range m.plan.Groups {
+		head := fmt.Sprintf("%d. %s %s", i+1, accent.Render("["+g.Tag+"]"), g.Scope)
+		head += muted.Render(fmt.Sprintf("  %d files", len(g.Files)))
+		if i == m.cursor {
+			head = lipgloss.NewStyle().Background(m.theme.Surface).Bold(true).Render(head)
+		}
+		lines := []string{head}
+		for _, kp := range g.KeyPoints {
+			lines = append(lines, base.Foreground(m.theme.FG).Render("   • "+truncate(kp, inner-5)))
+		}
+		// The selected group lists every file; the others only the
+		// first few so long plans still fit.
+		shown := g.Files
+		if i != m.cursor && len(shown) > 3 {
+			shown = shown[:3]
+		}
+		for _, f := range shown {
+			lines = append(lines, muted.Render("     "+truncate(f, inner-7)))
+		}
+		if len(shown) < len(g.Files) {
+			lines = append(lines, muted.Italic(true).
+				Render(fmt.Sprintf("     … %d more", len(g.Files)-len(shown))))
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	hintPairs := [][2]string{
+		{"↑↓", "navigate"},
+		{"↵", "create drafts"},
+		{"esc", "cancel"},
+	}
+	parts := make([]string, 0, len(hintPairs)*2-1)
+	for i, p := range hintPairs {
+		if i > 0 {
+			parts = append(parts, help.ShortSeparator.Render(" · "))
+		}
+		parts = append(parts, help.ShortKey.Render(p[0])+" "+help.ShortDesc.Render(p[1]))
+	}
+
+	body := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		base.Foreground(m.theme.FgMuted).Render(truncate(subtitle, inner)),
+		"",
+		strings.Join(blocks, "\n\n"),
+		"",
+		strings.Join(parts, ""),
+	)
+
+	boxStyle := lipgloss.NewStyle().
+		Width(m.width).
+		MaxHeight(m.height).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Primary)
+
+	return tea.NewView(boxStyle.Render(body))
+}
@@ -35,6 +35,7 @@ func NerdFontSymbols() *Symbols {
 		BuildTool:           "\uf0ad", // nf-fa-wrench
 		TokenIcon:           "\uf084", // nf-fa-key
 		BranchIcon:          "\uf126", // nf-fa-code_branch
+		SplitPlan:           "\ueb56", // nf-cod-split_horizontal
 	}
 }
 
@@ -67,6 +68,7 @@ func DefaultSymbols() *Symbols {
 		BuildTool:           "~",
 		TokenIcon:           "@",
 		BranchIcon:          "%",
+		SplitPlan:           "÷",
 	}
 }
 
@@ -145,6 +145,9 @@ type Symbols struct {
 	// BranchIcon (nf-fa-code_branch, U+F126) labels the Branch and
 	// repository rows in the release config popup. Falls back to "%".
 	BranchIcon string
+	// SplitPlan (nf-cod-split_horizontal, U+EB56) is the command-palette
+	// and popup-title icon for the split planner. Falls back to "÷".
+	SplitPlan string
 }
 
 type Styles struct {
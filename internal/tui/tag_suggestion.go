@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+
+	"commit_craft_reborn/internal/aiengine"
+	"commit_craft_reborn/internal/git"
+)
+
+// tagSuggestionMsg carries the tag classifier's answer back to Update.
+// seq ties it to the compose session that asked; a reply for an earlier
+// session is dropped.
+type tagSuggestionMsg struct {
+	seq        int
+	suggestion aiengine.TagSuggestion
+	err        error
+}
+
+// suggestCommitType runs the file rules against the staged changes when
+// a new commit is started and pre-selects their tag when it clears
+// [tag_inference] min_confidence. With use_model on it returns the cmd
+// that asks the tag classifier; its answer replaces the suggestion
+// unless the user has picked a type meanwhile.
+func (model *Model) suggestCommitType() tea.Cmd {
+	model.tagSuggestionSeq++
+	model.tagSuggestion = aiengine.TagSuggestion{}
+	model.tagPicked = false
+	files, err := aiengine.StagedSplitFiles()
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			model.log.Debug("tag inference skipped", "error", err)
+		}
+		return nil
+	}
+	model.applyTagSuggestion(aiengine.HeuristicTag(files, model.finalCommitTypes))
+	if !model.globalConfig.TagInference.UseModel {
+		return nil
+	}
+	deps := splitDeps(model)
+	types := model.finalCommitTypes
+	maxDiff := model.globalConfig.Prompts.ChangeAnalyzerMaxDiffSize
+	seq := model.tagSuggestionSeq
+	return func() tea.Msg {
+		diff, err := git.GetStagedDiffSummary(maxDiff)
+		if err != nil {
+			return tagSuggestionMsg{seq: seq, err: err}
+		}
+		sug, err := aiengine.ClassifyTag(context.Background(), deps, files, diff, nil, types)
+		return tagSuggestionMsg{seq: seq, suggestion: sug, err: err}
+	}
+}
+
+// applyTagSuggestion records sug and, when it is confident enough,
+// makes it the selected commit type.
+func (model *Model) applyTagSuggestion(sug aiengine.TagSuggestion) {
+	model.tagSuggestion = sug
+	if sug.Tag != "" && sug.Confidence >= model.globalConfig.TagInference.MinConfidence {
+		model.commitType = sug.Tag
+	}
+}
+
+func handleTagSuggestion(model *Model, msg tagSuggestionMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != model.tagSuggestionSeq {
+		return model, nil
+	}
+	if msg.err != nil {
+		model.log.Debug("tag classifier failed", "error", msg.err)
+		return model, nil
+	}
+	if msg.suggestion.Warning != "" {
+		model.log.Warn("tag classifier fallback", "warning", msg.suggestion.Warning)
+	}
+	if model.tagPicked || model.state != stateWritingMessage {
+		// Keep the answer for the chip hint and the popup, but never
+		// override a type the user chose.
+		model.tagSuggestion = msg.suggestion
+		return model, nil
+	}
+	model.applyTagSuggestion(msg.suggestion)
+	return model, nil
+}
+
+// tagSuggestionLabel is the muted hint next to the commit-type chip,
+// e.g. "suggested FIX · 82% (ai)". Empty when there is no suggestion.
+func (model *Model) tagSuggestionLabel() string {
+	sug := model.tagSuggestion
+	if sug.Tag == "" {
+		return ""
+	}
+	return fmt.Sprintf("suggested %s · %d%% (%s)", sug.Tag, sug.Percent(), sug.Source)
+}
@@ -0,0 +1,274 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"commit_craft_reborn/internal/commit"
+	"commit_craft_reborn/internal/tui/styles"
+)
+
+// closeTrailersPopupMsg dismisses the trailers popup without applying.
+type closeTrailersPopupMsg struct{}
+
+// trailersAppliedMsg carries the selected trailer lines, in row order,
+// back to the compose view.
+type trailersAppliedMsg struct {
+	trailers []string
+}
+
+// trailersPopupModel edits a draft's git trailers: a checklist seeded
+// with the team roster, the user's own Signed-off-by and whatever the
+// draft already carries, plus an input that adds free-form trailers.
+// Expansion of shorthands ("#42", "Co-authored-by: ana") is delegated
+// to expand so the popup never imports the config package.
+type trailersPopupModel struct {
+	width, height int
+	theme         *styles.Theme
+	rows          []trailerPopupRow
+	cursor        int
+	input         textinput.Model
+	inputFocused  bool
+	expand        func(string) (string, error)
+	errMsg        string
+}
+
+type trailerPopupRow struct {
+	line     string
+	selected bool
+}
+
+// newTrailersPopup builds the checklist. roster holds the full
+// Co-authored-by lines of the configured co-authors; signOff is the
+// user's Signed-off-by line ("" when git has no identity).
+func newTrailersPopup(
+	width, height int,
+	theme *styles.Theme,
+	current, roster []string,
+	signOff string,
+	expand func(string) (string, error),
+) trailersPopupModel {
+	var rows []trailerPopupRow
+	seen := map[string]bool{}
+	add := func(line string) {
+		if line == "" || seen[line] {
+			return
+		}
+		seen[line] = true
+		rows = append(rows, trailerPopupRow{line: line, selected: slices.Contains(current, line)})
+	}
+	for _, line := range roster {
+		add(line)
+	}
+	add(signOff)
+	for _, line := range current {
+		add(line)
+	}
+
+	ti := textinput.New()
+	ti.Prompt = "+ "
+	ti.Placeholder = "Token: value · #42 · signoff"
+	ti.SetWidth(max(20, width-10))
+
+	m := trailersPopupModel{
+		width:  width,
+		height: height,
+		theme:  theme,
+		rows:   rows,
+		input:  ti,
+		expand: expand,
+	}
+	if len(rows) == 0 {
+		m.inputFocused = true
+		m.input.Focus()
+	}
+	return m
+}
+
+func (m trailersPopupModel) Init() tea.Cmd { return nil }
+
+func (m trailersPopupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if m.inputFocused {
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	switch km.String() {
+	case "esc":
+		return m, func() tea.Msg { return closeTrailersPopupMsg{} }
+	case "ctrl+s":
+		return m, m.applyCmd()
+	case "tab", "shift+tab":
+		m.inputFocused = !m.inputFocused || len(m.rows) == 0
+		if m.inputFocused {
+			return m, m.input.Focus()
+		}
+		m.input.Blur()
+		return m, nil
+	}
+
+	if m.inputFocused {
+		if km.String() == "enter" {
+			raw := strings.TrimSpace(m.input.Value())
+			if raw == "" {
+				return m, nil
+			}
+			line, err := m.expand(raw)
+			if err != nil {
+				m.errMsg = err.Error()
+				return m, nil
+			}
+			m.errMsg = ""
+			m.input.SetValue("")
+			for i := range m.rows {
+				if m.rows[i].line == line {
+					m.rows[i].selected = true
+					m.cursor = i
+					return m, nil
+				}
+			}
+			m.rows = append(m.rows, trailerPopupRow{line: line, selected: true})
+			m.cursor = len(m.rows) - 1
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch km.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case " ", "space", "x":
+		if len(m.rows) > 0 {
+			m.rows[m.cursor].selected = !m.rows[m.cursor].selected
+		}
+	case "enter":
+		return m, m.applyCmd()
+	}
+	return m, nil
+}
+
+func (m trailersPopupModel) applyCmd() tea.Cmd {
+	picked := make([]string, 0, len(m.rows))
+	for _, r := range m.rows {
+		if r.selected {
+			picked = append(picked, r.line)
+		}
+	}
+	return func() tea.Msg { return trailersAppliedMsg{trailers: picked} }
+}
+
+func (m trailersPopupModel) View() tea.View {
+	base := m.theme.AppStyles().Base
+	help := m.theme.AppStyles().Help
+	muted := base.Foreground(m.theme.Muted)
+	accent := base.Foreground(m.theme.Accent).Bold(true)
+
+	title := base.Foreground(m.theme.Secondary).Bold(true).Render("Commit trailers")
+	subtitle := base.Foreground(m.theme.FgMuted).
+		Render("Checked lines are appended to the message as git trailers.")
+
+	inner := max(20, m.width-6)
+	var rows []string
+	if len(m.rows) == 0 {
+		rows = append(rows, muted.Italic(true).
+			Render("No roster configured — add trailers below ([trailers] co_authors in config)."))
+	}
+	for i, r := range m.rows {
+		check := muted.Render("[ ]")
+		if r.selected {
+			check = accent.Render("[x]")
+		}
+		row := check + "  " + base.Foreground(m.theme.FG).Render(truncate(r.line, inner-5))
+		if i == m.cursor && !m.inputFocused {
+			row = lipgloss.NewStyle().Background(m.theme.Surface).Bold(true).Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	inputLine := m.input.View()
+	if m.errMsg != "" {
+		inputLine = lipgloss.JoinVertical(lipgloss.Left,
+			inputLine,
+			base.Foreground(m.theme.Error).Render(truncate(m.errMsg, inner)),
+		)
+	}
+
+	hintPairs := [][2]string{
+		{"↑↓", "navigate"},
+		{"space", "toggle"},
+		{"tab", "input"},
+		{"↵", "apply"},
+		{"esc", "cancel"},
+	}
+	if m.inputFocused {
+		hintPairs = [][2]string{
+			{"↵", "add"},
+			{"tab", "list"},
+			{"^S", "apply"},
+			{"esc", "cancel"},
+		}
+	}
+	parts := make([]string, 0, len(hintPairs)*2-1)
+	for i, p := range hintPairs {
+		if i > 0 {
+			parts = append(parts, help.ShortSeparator.Render(" · "))
+		}
+		parts = append(parts, help.ShortKey.Render(p[0])+" "+help.ShortDesc.Render(p[1]))
+	}
+
+	body := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		subtitle,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		inputLine,
+		"",
+		strings.Join(parts, ""),
+	)
+
+	boxStyle := lipgloss.NewStyle().
+		Width(m.width).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Primary)
+
+	return tea.NewView(boxStyle.Render(body))
+}
+
+// trailerChipLabel shortens a trailer line for the compose row: identity
+// trailers show their role and the name only, everything else keeps the
+// token and value.
+func trailerChipLabel(line string) string {
+	t, ok := commit.ParseTrailer(line)
+	if !ok {
+		return line
+	}
+	if commit.IsIdentityTrailer(t.Token) {
+		name := t.Value
+		if i := strings.Index(name, " <"); i > 0 {
+			name = name[:i]
+		}
+		role := strings.TrimSuffix(strings.ToLower(t.Token), "-by")
+		return role + " · " + name
+	}
+	return t.Token + " " + t.Value
+}
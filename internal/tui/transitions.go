@@ -7,6 +7,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"commit_craft_reborn/internal/aiengine"
 	"commit_craft_reborn/internal/changelog"
 	"commit_craft_reborn/internal/git"
 	"commit_craft_reborn/internal/storage"
@@ -16,6 +17,13 @@ import (
 func (model *Model) cancelProcess(state appState) (tea.Model, tea.Cmd) {
 	var statusBarMessage string
 	statusBarLevel := statusbar.LevelInfo
+	// Leaving the flow abandons any AI run still in flight; its late
+	// result must not land on the screen we're walking back to.
+	var cmd tea.Cmd
+	if model.pipeline.anyRunning() {
+		model.pipeline.cancelRunning()
+		cmd = model.WritingStatusBar.StopSpinner()
+	}
 
 	switch state {
 	case stateChoosingCommit:
@@ -68,7 +76,7 @@ func (model *Model) cancelProcess(state appState) (tea.Model, tea.Cmd) {
 	model.state = state
 	model.WritingStatusBar.Content = statusBarMessage
 	model.WritingStatusBar.Level = statusBarLevel
-	return model, nil
+	return model, cmd
 }
 
 func createCommit(model *Model) (tea.Model, tea.Cmd) {
@@ -129,7 +137,7 @@ func createCommit(model *Model) (tea.Model, tea.Cmd) {
 
 	persistPipelineAICalls(model, model.currentCommit.ID)
 	model.pipeline.clearAllHistory()
-	UpdateCommitList(model.pwd, model.db, model.log, &model.mainList, commitDb, "completed")
+	UpdateCommitList(model.historyScope(), model.db, model.log, &model.mainList, commitDb, "completed")
 	// The CHANGELOG file likely changed (we just prepended an entry) — refresh
 	// the indicator so the pill flips between auto/passive on the next render.
 	model.refreshChangelogState()
@@ -139,7 +147,7 @@ func createCommit(model *Model) (tea.Model, tea.Cmd) {
 	model.focusedElement = focusOutputContent
 	model.outputReportViewport.GotoTop()
 	model.iaViewport.GotoTop()
-	model.WritingStatusBar.Content = "Review the generated commit · enter to print · esc to history"
+	model.WritingStatusBar.Content = "Review the generated commit · enter to print · ctrl+g to commit · esc to history"
 	cmd := model.WritingStatusBar.ShowMessageForDuration(
 		"Record created in the db successfully",
 		statusbar.LevelSuccess,
@@ -173,11 +181,18 @@ func persistReleaseAICalls(model *Model) {
 	for id, name := range stageNames {
 		st := model.pipeline.stages[id]
 		if !st.HasStats {
+			err := aiengine.CreateFailedStageCalls(
+				model.db.CreateReleaseAICall, r.ID, name, st.Provider, st.Attempts,
+			)
+			if err != nil {
+				model.log.Warn("release_ai_calls insert failed", "stage", name, "error", err)
+			}
 			continue
 		}
 		call := storage.AICall{
 			CommitID:         r.ID,
 			Stage:            name,
+			Provider:         st.Provider,
 			Model:            st.StatsModel,
 			PromptTokens:     st.PromptTokens,
 			CompletionTokens: st.CompletionTokens,
@@ -189,7 +204,7 @@ func persistReleaseAICalls(model *Model) {
 			RequestID:        st.RequestID,
 			TPMLimitAtCall:   st.TPMLimitAtCall,
 		}
-		if _, err := model.db.CreateReleaseAICall(call); err != nil {
+		if err := aiengine.CreateStageCalls(model.db.CreateReleaseAICall, call, st.Attempts); err != nil {
 			model.log.Warn("release_ai_calls insert failed", "stage", name, "error", err)
 		}
 	}
@@ -235,22 +250,20 @@ func createRelease(model *Model) (tea.Model, tea.Cmd) {
 	// back into RewordHash, and quit so main.go's post-TUI hook calls
 	// git.RewordCommit with this message.
 	if model.releaseRewordHash != "" {
-		formattedType := fmt.Sprintf(
-			model.globalConfig.CommitFormat.TypeFormat,
-			newRelease.Type,
-		)
 		model.RewordHash = model.releaseRewordHash
 		model.releaseRewordHash = ""
 		model.syncRewordIndicator()
-		final := fmt.Sprintf("%s %s: %s", formattedType, newRelease.Branch, newRelease.Title)
-		if body != "" {
-			final = final + "\n\n" + body
-		}
-		model.FinalMessage = final
+		model.FinalMessage = releaseFinalMessage(
+			model,
+			newRelease.Type,
+			newRelease.Branch,
+			newRelease.Title,
+			body,
+		)
 		return quitWithAutodraft(model)
 	}
 
-	UpdateCommitList(model.pwd, model.db, model.log, &model.releaseMainList, releaseDb, "")
+	UpdateCommitList(model.historyScope(), model.db, model.log, &model.releaseMainList, releaseDb, "")
 	model.state = stateReleaseMainMenu
 	model.keys = releaseMainListKeys()
 	loadCmd := enterReleaseHistoryLoading(model)
@@ -290,10 +303,11 @@ func switchFocusElement(model *Model) tea.Cmd {
 }
 
 // composeFocusOrder is the canonical Tab ordering for the compose view's
-// 6 focusable sections.
+// 7 focusable sections.
 var composeFocusOrder = []focusableElement{
 	focusComposeType,
 	focusComposeScope,
+	focusComposeTrailers,
 	focusComposeSummary,
 	focusComposeKeypoints,
 	focusComposePipelineModels,
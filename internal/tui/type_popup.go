@@ -25,11 +25,18 @@ type commitTypePopupModel struct {
 	list          list.Model
 	width, height int
 	theme         *styles.Theme
+	// suggestion is the inferred-type hint shown under the title; empty
+	// when nothing was inferred.
+	suggestion string
 }
 
+// newCommitTypePopup opens the picker with the cursor on selected (the
+// current type, which may be the inferred one).
 func newCommitTypePopup(
 	types []commit.CommitType,
 	typeFormat string,
+	selected string,
+	suggestion string,
 	width, height int,
 	theme *styles.Theme,
 ) commitTypePopupModel {
@@ -51,11 +58,18 @@ func newCommitTypePopup(
 	// in FilterApplied where printables are ignored).
 	l.SetFilterText("")
 	l.SetFilterState(list.Filtering)
+	for i, t := range types {
+		if t.Tag == selected {
+			l.Select(i)
+			break
+		}
+	}
 	return commitTypePopupModel{
-		list:   l,
-		width:  width,
-		height: height,
-		theme:  theme,
+		list:       l,
+		width:      width,
+		height:     height,
+		theme:      theme,
+		suggestion: suggestion,
 	}
 }
 
@@ -113,6 +127,10 @@ func (m commitTypePopupModel) View() tea.View {
 		Foreground(m.theme.Secondary).
 		Bold(true).
 		Render("Commit type")
+	if m.suggestion != "" {
+		title = lipgloss.JoinVertical(lipgloss.Left, title,
+			base.Foreground(m.theme.Muted).Render(m.suggestion))
+	}
 
 	keyStyle := base.Foreground(m.theme.Accent)
 	descStyle := base.Foreground(m.theme.Muted)
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 
 	"commit_craft_reborn/internal/config"
 	"commit_craft_reborn/internal/git"
+	"commit_craft_reborn/internal/release"
 	"commit_craft_reborn/internal/tui/statusbar"
 	"commit_craft_reborn/internal/tui/styles"
 )
@@ -28,8 +30,12 @@ type releaseUpdloadResultMsg struct {
 	NoAssets bool
 }
 
+// The Ia*ResultMsg types carry the RunID handed out by
+// pipelineModel.beginRun; results of a cancelled or superseded run are
+// dropped on arrival.
 type IaCommitBuilderResultMsg struct {
-	Err error
+	Err   error
+	RunID int
 }
 
 // IaReleaseBuilderResultMsg carries the outcome of a release pipeline
@@ -46,6 +52,7 @@ type IaCommitBuilderResultMsg struct {
 // before the goroutine's writes were published, leaving it blank.
 type IaReleaseBuilderResultMsg struct {
 	Err   error
+	RunID int
 	From  stageID
 	Body  string
 	Title string
@@ -53,10 +60,22 @@ type IaReleaseBuilderResultMsg struct {
 }
 
 type (
-	IaSummaryResultMsg      struct{ Err error }
-	IaCommitRawResultMsg    struct{ Err error }
-	IaOutputFormatResultMsg struct{ Err error }
-	IaChangelogResultMsg    struct{ Err error }
+	IaSummaryResultMsg struct {
+		Err   error
+		RunID int
+	}
+	IaCommitRawResultMsg struct {
+		Err   error
+		RunID int
+	}
+	IaOutputFormatResultMsg struct {
+		Err   error
+		RunID int
+	}
+	IaChangelogResultMsg struct {
+		Err   error
+		RunID int
+	}
 )
 
 // Main Update Function
@@ -124,7 +143,14 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case setCommitTypeMsg:
 		model.popup = nil
 		model.commitType = msg.tag
+		model.tagPicked = true
 		return model, nil
+	case tagSuggestionMsg:
+		return handleTagSuggestion(model, msg)
+	case semanticDebounceMsg:
+		return model, model.runSemanticSearch(msg)
+	case semanticResultMsg:
+		return handleSemanticResult(model, msg)
 	case closeScopePopupMsg:
 		model.popup = nil
 		return model, nil
@@ -135,6 +161,24 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case closeEditMessagePopupMsg:
 		model.popup = nil
 		return model, nil
+	case closeTrailersPopupMsg:
+		model.popup = nil
+		return model, nil
+	case splitPlanReadyMsg:
+		return handleSplitPlanReady(model, msg)
+	case closeSplitPopupMsg:
+		model.popup = nil
+		return model, nil
+	case splitAcceptMsg:
+		model.popup = nil
+		return model, startSplitRun(model, msg.plan)
+	case splitDoneMsg:
+		return handleSplitDone(model, msg)
+	case trailersAppliedMsg:
+		model.popup = nil
+		model.currentCommit.Trailers = msg.trailers
+		model.trailerIndex = 0
+		return model, nil
 	case themePreviewMsg:
 		model.Theme = styles.GetTheme(msg.name, model.globalConfig.TUI.UseNerdFonts)
 		model.WritingStatusBar.SetTheme(model.Theme)
@@ -257,6 +301,8 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case cmdConfigureChangelog:
 			model.popup = openChangelogConfigPopup(model)
 			return model, nil
+		case cmdSplitStaged:
+			return model, startSplitPlan(model)
 		}
 		return model, nil
 	case releaseHistorySyncMsg:
@@ -333,7 +379,7 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			model.popup = nil
 			model.log.Error("model picker fetch failed", "error", msg.err)
 			cmd := model.WritingStatusBar.ShowMessageForDuration(
-				fmt.Sprintf("Could not load Groq models: %s", msg.err),
+				fmt.Sprintf("Could not load %s models: %s", msg.provider, msg.err),
 				statusbar.LevelError,
 				3*time.Second,
 			)
@@ -364,11 +410,14 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		stage := msg.stage
 		label := stageLabelFor(model, stage)
 		statusCmd := model.WritingStatusBar.ShowMessageForDuration(
-			"Refreshing models from Groq…",
+			fmt.Sprintf("Refreshing models from %s…", stageProviderName(model, stage)),
 			statusbar.LevelInfo,
 			2*time.Second,
 		)
 		return model, tea.Batch(statusCmd, refreshModelPickerCmd(model, stage, label))
+	case composeCtxEstimateMsg:
+		applyComposeCtxEstimate(model, msg)
+		return model, nil
 	case modelPickerResultMsg:
 		model.popup = nil
 		if err := config.SaveModelForStage(msg.stage, msg.modelID, msg.scope); err != nil {
@@ -391,6 +440,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			statusbar.LevelSuccess,
 			3*time.Second,
 		)
+		if model.state == stateWritingMessage {
+			cmd = tea.Batch(cmd, composeCtxEstimateCmd(model))
+		}
 		return model, cmd
 	case editMessageAppliedMsg:
 		model.popup = nil
@@ -436,6 +488,12 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					rc.BuildTool,
 					rc.BuildTarget,
 				)
+				if rc.BuildTool == config.ReleaseBuildGo {
+					model.WritingStatusBar.Content = fmt.Sprintf(
+						"Building release assets (go build, %d targets)…",
+						len(rc.Targets),
+					)
+				}
 				spinnerCmd := model.WritingStatusBar.StartSpinner()
 				return model, tea.Batch(spinnerCmd, execReleaseBuild(model))
 			}
@@ -484,9 +542,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			)
 		}
 		// Update the in-memory config so the rest of this session uses
-		// the freshly saved values without a restart. GhToken is
-		// re-read from the environment because the popup wrote to .env
-		// (godotenv only re-loads on next process boot).
+		// the freshly saved values without a restart. The host tokens
+		// are re-read from the environment, which the popup updated
+		// along with .env (godotenv only re-loads on next process boot).
 		model.globalConfig.ReleaseConfig.Repository = msg.repository
 		model.globalConfig.ReleaseConfig.Branch = msg.branch
 		model.globalConfig.ReleaseConfig.Version = msg.version
@@ -494,10 +552,16 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		model.globalConfig.ReleaseConfig.AutoBuild = msg.autoBuild
 		model.globalConfig.ReleaseConfig.BuildTool = msg.buildTool
 		model.globalConfig.ReleaseConfig.BuildTarget = msg.buildTarget
-		if token := os.Getenv("GH_TOKEN"); token != "" {
+		if token := os.Getenv(config.EnvGitHubToken); token != "" {
 			model.globalConfig.ReleaseConfig.GhToken = token
 			model.globalConfig.ReleaseConfig.IsGhTokenSet = true
 		}
+		if token := os.Getenv(config.EnvGitLabToken); token != "" {
+			model.globalConfig.ReleaseConfig.GitLabToken = token
+		}
+		if token := os.Getenv(config.EnvGiteaToken); token != "" {
+			model.globalConfig.ReleaseConfig.GiteaToken = token
+		}
 		if msg.fromAutoOpen && model.pendingReleaseUpload != nil {
 			// Resume the upload flow: open the version editor so the
 			// user can confirm/bump the freshly saved version and then
@@ -582,8 +646,8 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return model, cmd
 		case "Print in console":
 			if selectedItem, ok := model.releaseMainList.SelectedItem().(HistoryReleaseItem); ok {
-				formattedReleaseType := fmt.Sprintf(model.globalConfig.CommitFormat.TypeFormat, selectedItem.release.Type)
-				model.FinalMessage = fmt.Sprintf("%s %s: %s\n\n%s", formattedReleaseType, selectedItem.release.Branch, selectedItem.release.Title, selectedItem.release.Body)
+				r := selectedItem.release
+				model.FinalMessage = releaseFinalMessage(model, r.Type, r.Branch, r.Title, r.Body)
 			}
 			return quitWithAutodraft(model)
 		case "Output message":
@@ -619,8 +683,8 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "Copy to clipboard":
 			var finalMessage string
 			if selectedItem, ok := model.releaseMainList.SelectedItem().(HistoryReleaseItem); ok {
-				formattedReleaseType := fmt.Sprintf(model.globalConfig.CommitFormat.TypeFormat, selectedItem.release.Type)
-				finalMessage = fmt.Sprintf("%s %s: %s\n%s", formattedReleaseType, selectedItem.release.Branch, selectedItem.release.Title, selectedItem.release.Body)
+				r := selectedItem.release
+				finalMessage = releaseFinalMessage(model, r.Type, r.Branch, r.Title, r.Body)
 			}
 			if model.ToolsInfo.xclip.available {
 				autodraftIfNeeded(model)
@@ -646,7 +710,7 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// config popup first. On save the chain resumes into
 				// the version editor; on cancel it rolls back.
 				rc := model.globalConfig.ReleaseConfig
-				if !hasReleaseEssentials(rc.Repository, rc.IsGhTokenSet) {
+				if !hasReleaseEssentials(rc.Repository, releaseHostTokenSet(model)) {
 					model.popup = openReleaseConfigPopup(model, true)
 					return model, nil
 				}
@@ -712,7 +776,7 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// if repo or token is missing, route through the config
 			// popup first.
 			rc := model.globalConfig.ReleaseConfig
-			if !hasReleaseEssentials(rc.Repository, rc.IsGhTokenSet) {
+			if !hasReleaseEssentials(rc.Repository, releaseHostTokenSet(model)) {
 				model.popup = openReleaseConfigPopup(model, true)
 				return model, loadCmd
 			}
@@ -761,11 +825,14 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if model.draftMode {
 			status = "draft"
 		}
-		UpdateCommitList(model.pwd, model.db, model.log, list, msg.Db, status)
+		UpdateCommitList(model.historyScope(), model.db, model.log, list, msg.Db, status)
 		cmd := model.WritingStatusBar.ShowMessageForDuration("Record deleted from the db", statusbar.LevelSuccess, 2*time.Second)
 		return model, cmd
 
 	case IaCommitBuilderResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 
 		if msg.Err != nil {
@@ -798,6 +865,13 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			touched = append(touched, stageChangelog)
 		}
 		if msg.Err == nil {
+			// The run may have re-read the staged diff; refresh the
+			// "not sent" markers from the budget it used.
+			applyPipelineFilesDelegate(model)
+			if n := len(model.pipeline.omitted); n > 0 {
+				model.WritingStatusBar.Content += fmt.Sprintf(
+					" · %s not sent (diff budget)", plural(n, "file", "files"))
+			}
 			model.pipeline.pushStageHistory(stageSummary, model.iaSummaryOutput)
 			model.pipeline.pushStageHistory(stageBody, model.iaCommitRawOutput)
 			model.pipeline.pushStageHistory(stageTitle, model.iaTitleRawOutput)
@@ -812,6 +886,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, tea.Batch(cmds...)
 
 	case IaSummaryResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 		if msg.Err != nil {
 			model.WritingStatusBar.Content = fmt.Sprintf("Error (Stage 1): %s", msg.Err.Error())
@@ -845,6 +922,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, tea.Batch(cmds...)
 
 	case IaCommitRawResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 		if msg.Err != nil {
 			model.WritingStatusBar.Content = fmt.Sprintf("Error (Stage 2): %s", msg.Err.Error())
@@ -877,6 +957,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, tea.Batch(cmds...)
 
 	case IaOutputFormatResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 		if msg.Err != nil {
 			model.WritingStatusBar.Content = fmt.Sprintf("Error (Stage 3): %s", msg.Err.Error())
@@ -908,6 +991,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, tea.Batch(cmds...)
 
 	case IaChangelogResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 		if msg.Err != nil {
 			model.WritingStatusBar.Content = fmt.Sprintf("Error (Stage 4): %s", msg.Err.Error())
@@ -934,6 +1020,9 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, tea.Batch(cmds...)
 
 	case IaReleaseBuilderResultMsg:
+		if !model.pipeline.finishRun(msg.RunID) {
+			return model, nil
+		}
 		cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 
 		if msg.Err != nil {
@@ -985,21 +1074,46 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		cmds = append(cmds, model.applyPipelineResult(touchedR, msg.Err))
 		return model, tea.Batch(cmds...)
+	case gitCommitResultMsg:
+		return handleGitCommitResult(model, msg)
 	case releaseBuildResultMsg:
+		model.releaseBuild.finish()
 		if msg.Err != nil {
 			// Build failures are recoverable from the user's POV: fix
 			// the underlying issue and trigger another release. Keep
 			// the full diagnostic in the log; the status bar only
-			// hints where to look.
-			model.log.Warn("release build failed", "output", msg.Output, "err", msg.Err)
+			// hints where to look. A matrix build logs each failed
+			// target on its own so the logs don't interleave.
 			model.pendingReleaseUpload = nil
 			model.releaseUploading = false
 			cmds = append(cmds, model.WritingStatusBar.StopSpinner())
 			model.WritingStatusBar.Content = "Build failed · check logs"
+			var buildErr *release.BuildError
+			if errors.As(msg.Err, &buildErr) {
+				names := make([]string, len(buildErr.Failed))
+				for i, r := range buildErr.Failed {
+					names[i] = r.Target.String()
+					model.log.Warn("release build failed",
+						"target", names[i], "output", r.Log, "err", r.Err)
+				}
+				model.WritingStatusBar.Content = fmt.Sprintf(
+					"Build failed for %s · check logs",
+					strings.Join(names, ", "),
+				)
+			} else {
+				model.log.Warn("release build failed", "output", msg.Output, "err", msg.Err)
+			}
 			model.WritingStatusBar.Level = statusbar.LevelError
 			return model, tea.Batch(cmds...)
 		}
-		model.log.Debug("release build ok", "output", msg.Output)
+		if msg.Results != nil {
+			for _, r := range msg.Results {
+				model.log.Debug("release build ok",
+					"target", r.Target.String(), "output", r.Output, "took", r.Duration)
+			}
+		} else {
+			model.log.Debug("release build ok", "output", msg.Output)
+		}
 		pending := model.pendingReleaseUpload
 		model.pendingReleaseUpload = nil
 		if pending == nil {
@@ -1183,6 +1297,11 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	cmds = append(cmds, subCmd)
+	// The compose CTX bar reads a cached estimate; refresh it here, off
+	// the render path, as key points, the model or the staged set move.
+	if model.state == stateWritingMessage {
+		cmds = append(cmds, composeCtxEstimateCmd(model))
+	}
 	// Keep the persistent tab indicator in sync with state transitions
 	// triggered via the regular flow (Esc/Enter), so the user never sees
 	// a "Compose" highlight while looking at the history list.
@@ -72,17 +72,17 @@ func updateChoosingScope(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 				}
 				return model, nil
 			}
+		case key.Matches(msg, model.keys.SuggestedScope):
+			if model.scopeSuggestion.Scope == "" {
+				model.WritingStatusBar.Level = statusbar.LevelWarning
+				model.WritingStatusBar.Content = "No scope could be inferred from the staged files"
+				return model, nil
+			}
+			return model, acceptChosenScope(model, model.scopeSuggestion.Scope)
 		case key.Matches(msg, model.keys.Enter):
 			commitScopeSelected := model.fileList.SelectedItem()
 			if item, ok := commitScopeSelected.(FileItem); ok {
-				model.WritingStatusBar.Level = statusbar.LevelInfo
-				model.WritingStatusBar.Content = "Craft your commit"
-				model.addScope(item.Title())
-				model.state = stateWritingMessage
-				model.focusedElement = focusComposeSummary
-				model.keys = writingMessageKeys()
-				cmd = model.commitsKeysInput.Focus()
-				return model, cmd
+				return model, acceptChosenScope(model, item.Title())
 			}
 			return model, nil
 		}
@@ -92,6 +92,18 @@ func updateChoosingScope(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 	return model, cmd
 }
 
+// acceptChosenScope sets the scope picked in stateChoosingScope and
+// moves on to the compose view.
+func acceptChosenScope(model *Model, scope string) tea.Cmd {
+	model.WritingStatusBar.Level = statusbar.LevelInfo
+	model.WritingStatusBar.Content = "Craft your commit"
+	model.addScope(scope)
+	model.state = stateWritingMessage
+	model.focusedElement = focusComposeSummary
+	model.keys = writingMessageKeys()
+	return model.commitsKeysInput.Focus()
+}
+
 func updateChoosingType(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
@@ -120,6 +132,7 @@ func updateChoosingType(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 					model.log.Error("Error getting git diff status", "error", err)
 				}
 				model.log.Debug("Git Diff Status Map", "map_content", fmt.Sprintf("%v", gitStatusMap))
+				model.scopeSuggestion = model.inferScope()
 				model.WritingStatusBar.Content = fmt.Sprintf("Choose a file or folder for your commit ::: %s", model.Theme.AppStyles().Base.Foreground(model.Theme.Tertiary).SetString(TruncatePath(scopeFilePickerPwd, 2)).String())
 				model.state = stateChoosingScope
 				model.keys = fileListKeys()
@@ -139,11 +152,12 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// ctrl+f cycles the filter mode (title → id → type → scope) at
-		// any time on the workspace view. When the filter bar is empty
-		// it just swaps the mode pill; when there is an active query we
-		// re-apply it so DefaultFilter re-runs against the new
-		// FilterValue strings.
+		// ctrl+f cycles the filter mode (title → id → type → scope →
+		// semantic) at any time on the workspace view. When the filter
+		// bar is empty it just swaps the mode pill; when there is an
+		// active query we re-apply it so DefaultFilter re-runs against
+		// the new FilterValue strings. Entering the semantic mode also
+		// schedules the embedding search.
 		if key.Matches(msg, model.keys.CycleFilterMode) {
 			model.historyView.CycleFilterMode()
 			val := model.historyView.FilterValue()
@@ -159,7 +173,7 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 				model.mainList.SetFilterState(list.Filtering)
 			}
 			syncHistoryViewSelection(model)
-			return model, nil
+			return model, model.scheduleSemanticSearch()
 		}
 		// FilterBar focus: route keys to the textinput. Esc clears + blurs;
 		// Enter blurs without clearing (so the user can navigate the filtered
@@ -172,7 +186,7 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 				model.historyView.BlurFilter()
 				model.mainList.SetFilterText("")
 				model.mainList.SetFilterState(list.Unfiltered)
-				return model, nil
+				return model, model.scheduleSemanticSearch()
 			case key.Matches(msg, model.keys.Enter):
 				model.historyView.BlurFilter()
 				return model, nil
@@ -187,6 +201,7 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 					model.mainList.SetFilterState(list.Filtering)
 				}
 				syncHistoryViewSelection(model)
+				cmd = tea.Batch(cmd, model.scheduleSemanticSearch())
 			}
 			return model, cmd
 		}
@@ -227,7 +242,7 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 			model.keys = writingMessageKeys()
 			model.focusedElement = focusComposeSummary
 			cmd = model.commitsKeysInput.Focus()
-			return model, cmd
+			return model, tea.Batch(cmd, model.suggestCommitType())
 		case key.Matches(msg, model.keys.ReleaseCommit):
 			model.WritingStatusBar.Content = "Select the commits to create a release"
 			model.state = stateReleaseChoosingCommits
@@ -357,29 +372,30 @@ func updateChoosingCommit(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, model.keys.ToggleDrafts):
 			model.draftMode = !model.draftMode
-			status := "completed"
-			msg := "Showing completed commits"
-			if model.draftMode {
-				status = "draft"
-				msg = "Showing drafts"
-			}
-			commits, err := model.db.GetCommits(model.pwd, status)
-			if err != nil {
+			if err := model.reloadHistory(); err != nil {
 				model.err = err
 				return model, nil
 			}
-			items := make([]list.Item, len(commits))
-			for i, c := range commits {
-				items[i] = HistoryCommitItem{commit: c}
+			syncHistoryViewSelection(model)
+			cmd := model.WritingStatusBar.ShowMessageForDuration(model.mainList.Title, statusbar.LevelSuccess, 2*time.Second)
+			// The semantic ranks cover the previous rows; rank the new ones.
+			return model, tea.Batch(cmd, model.scheduleSemanticSearch())
+
+		case key.Matches(msg, model.keys.ToggleWorktrees):
+			if model.workspaceID.Worktree == "" {
+				cmd := model.WritingStatusBar.ShowMessageForDuration(
+					"Not inside a git repository", statusbar.LevelWarning, 2*time.Second)
+				return model, cmd
 			}
-			model.mainList.SetItems(items)
-			// Ensure the viewport is updated
-			if len(items) > 0 {
-				model.mainList.Select(0)
+			model.historyAllWorktrees = !model.historyAllWorktrees
+			if err := model.reloadHistory(); err != nil {
+				model.err = err
+				return model, nil
 			}
-			model.mainList.Title = msg
-			cmd := model.WritingStatusBar.ShowMessageForDuration(msg, statusbar.LevelSuccess, 2*time.Second)
-			return model, cmd
+			syncHistoryViewSelection(model)
+			cmd := model.WritingStatusBar.ShowMessageForDuration(model.mainList.Title, statusbar.LevelSuccess, 2*time.Second)
+			// The semantic ranks cover the previous rows; rank the new ones.
+			return model, tea.Batch(cmd, model.scheduleSemanticSearch())
 		}
 	}
 
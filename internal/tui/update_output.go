@@ -8,7 +8,8 @@ import (
 // updateOutput handles input for the Output review screen. Tab cycles
 // focus between the report (left) and the content viewer (right). Enter
 // prints the assembled final message to stdout (via tea.Quit and
-// cmd/cli/main.go); Esc returns to the history list.
+// cmd/cli/main.go); Ctrl+G runs `git commit` with it and links the new
+// hash to the row; Esc returns to the history list.
 func updateOutput(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 	m, ok := msg.(tea.KeyMsg)
 	if !ok {
@@ -18,6 +19,8 @@ func updateOutput(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 	case key.Matches(m, model.keys.Enter):
 		model.FinalMessage = outputCommitMessageOrFallback(model, model.currentCommit)
 		return quitWithAutodraft(model)
+	case key.Matches(m, model.keys.GitCommit):
+		return model, startGitCommit(model)
 	case key.Matches(m, model.keys.Esc):
 		return model.cancelProcess(stateChoosingCommit)
 	case key.Matches(m, model.keys.NextField):
@@ -94,7 +97,7 @@ func openOutputViewFromHistory(model *Model) (tea.Model, tea.Cmd) {
 	model.focusedElement = focusOutputContent
 	model.outputReportViewport.GotoTop()
 	model.iaViewport.GotoTop()
-	model.WritingStatusBar.Content = "Output review · enter to print · esc to history"
+	model.WritingStatusBar.Content = "Output review · enter to print · ctrl+g to commit · esc to history"
 	return model, nil
 }
 
@@ -274,12 +274,7 @@ func finalizeReleaseFromDbPick(model *Model, action string) (tea.Model, tea.Cmd)
 		return model, nil
 	}
 
-	formattedType := fmt.Sprintf(model.globalConfig.CommitFormat.TypeFormat, picked.Type)
-	final := fmt.Sprintf("%s %s: %s", formattedType, picked.Branch, strings.TrimSpace(picked.Title))
-	body := strings.TrimSpace(picked.Body)
-	if body != "" {
-		final = final + "\n\n" + body
-	}
+	final := releaseFinalMessage(model, picked.Type, picked.Branch, picked.Title, picked.Body)
 
 	model.RewordHash = model.pendingRewordHash
 	model.pendingRewordHash = ""
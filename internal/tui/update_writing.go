@@ -30,6 +30,10 @@ func updateWritingMessage(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 			if handled, m, c := handleScopeSectionKey(model, msg); handled {
 				return m, c
 			}
+		case focusComposeTrailers:
+			if handled, m, c := handleTrailersSectionKey(model, msg); handled {
+				return m, c
+			}
 		case focusComposeKeypoints:
 			if handled, m, c := handleKeypointsSectionKey(model, msg); handled {
 				return m, c
@@ -72,6 +76,8 @@ func updateWritingMessage(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 			model.popup = newCommitTypePopup(
 				model.finalCommitTypes,
 				model.globalConfig.CommitFormat.TypeFormat,
+				model.commitType,
+				model.tagSuggestionLabel(),
 				w, h, model.Theme,
 			)
 			return model, nil
@@ -85,6 +91,7 @@ func updateWritingMessage(msg tea.Msg, model *Model) (tea.Model, tea.Cmd) {
 			model.popup = newScopePopup(
 				startPwd,
 				model.gitStatusData,
+				model.inferScope(),
 				model.globalConfig.TUI.UseNerdFonts,
 				w, h, model.Theme,
 			)
@@ -237,6 +244,7 @@ func handleScopeSectionKey(model *Model, msg tea.KeyMsg) (bool, tea.Model, tea.C
 		model.popup = newScopePopup(
 			startPwd,
 			model.gitStatusData,
+			model.inferScope(),
 			model.globalConfig.TUI.UseNerdFonts,
 			w, h, model.Theme,
 		)
@@ -245,6 +253,64 @@ func handleScopeSectionKey(model *Model, msg tea.KeyMsg) (bool, tea.Model, tea.C
 	return false, model, nil
 }
 
+// handleTrailersSectionKey owns the trailers row: ←/→ move between the
+// trailer pills, x removes the highlighted one and e/Enter opens the
+// trailers popup (same focus-contextual convention as the scope row).
+func handleTrailersSectionKey(model *Model, msg tea.KeyMsg) (bool, tea.Model, tea.Cmd) {
+	trailers := model.currentCommit.Trailers
+	switch {
+	case key.Matches(msg, model.keys.Left) && len(trailers) > 0:
+		model.trailerIndex = (model.trailerIndex - 1 + len(trailers)) % len(trailers)
+		return true, model, nil
+	case key.Matches(msg, model.keys.Right) && len(trailers) > 0:
+		model.trailerIndex = (model.trailerIndex + 1) % len(trailers)
+		return true, model, nil
+	case key.Matches(msg, model.keys.ClearField):
+		i := model.trailerIndex
+		if i < 0 || i >= len(trailers) {
+			return true, model, nil
+		}
+		model.currentCommit.Trailers = append(trailers[:i:i], trailers[i+1:]...)
+		if model.trailerIndex >= len(model.currentCommit.Trailers) {
+			model.trailerIndex = max(0, len(model.currentCommit.Trailers)-1)
+		}
+		return true, model, nil
+	}
+	switch msg.String() {
+	case "e", "enter":
+		openTrailersPopup(model)
+		return true, model, nil
+	}
+	return false, model, nil
+}
+
+// openTrailersPopup seeds the trailers popup with the configured roster
+// and the user's git identity for Signed-off-by.
+func openTrailersPopup(model *Model) {
+	cfg := model.globalConfig.Trailers
+	roster := make([]string, 0, len(cfg.CoAuthors))
+	for _, a := range cfg.CoAuthors {
+		roster = append(roster, a.Trailer())
+	}
+	identity, err := git.IdentityAt(model.pwd)
+	if err != nil {
+		model.log.Debug("no git identity for sign-off", "error", err)
+	}
+	signOff := ""
+	if identity != "" {
+		signOff = commit.Trailer{Token: commit.TrailerSignOff, Value: identity}.String()
+	}
+	w := min(max(60, model.width*3/5), model.width-2)
+	h := min(max(16, model.height*3/5), model.height-2)
+	model.popup = newTrailersPopup(
+		w, h, model.Theme,
+		model.currentCommit.Trailers,
+		roster,
+		signOff,
+		func(raw string) (string, error) { return cfg.ExpandTrailer(raw, identity) },
+	)
+}
+
 // handleKeypointsSectionKey applies inline navigation/removal of saved
 // key points without leaving the compose view. ↑/↓ and ←/→ both move the
 // cursor; x/delete/backspace remove the highlighted point.
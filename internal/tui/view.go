@@ -224,8 +224,14 @@ func (model *Model) View() tea.View {
 		mainContent = model.commitTypeList.View()
 
 	case stateChoosingScope:
-		model.fileList.SetSize(availableWidthForMainContent, availableHeightForMainContent)
-		mainContent = model.fileList.View()
+		suggestion := scopeSuggestionLine(model.Theme, model.scopeSuggestion)
+		if suggestion == "" {
+			model.fileList.SetSize(availableWidthForMainContent, availableHeightForMainContent)
+			mainContent = model.fileList.View()
+			break
+		}
+		model.fileList.SetSize(availableWidthForMainContent, max(1, availableHeightForMainContent-2))
+		mainContent = lipgloss.JoinVertical(lipgloss.Left, suggestion, "", model.fileList.View())
 
 	case stateWritingMessage:
 		mainContent = model.buildWritingMessageView(appStyle)
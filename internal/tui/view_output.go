@@ -226,7 +226,11 @@ func (model *Model) assembleOutputLeftBody(width int) string {
 			title = []string{"Change Analyzer", "Commit Body", "Commit Title", "Changelog Refiner"}[i]
 		}
 		header := value.Render(fmt.Sprintf("Stage %d · %s", i+1, title))
-		modelLine := label.Render("model   ") + " " + value.Render(orDash(st.StatsModel))
+		modelText := orDash(st.StatsModel)
+		if st.Provider != "" {
+			modelText = st.Provider + " · " + modelText
+		}
+		modelLine := label.Render("model   ") + " " + value.Render(modelText)
 		tokens := fmt.Sprintf("%d in / %d out / %d total",
 			st.PromptTokens, st.CompletionTokens, st.TotalTokens)
 		tokensLine := label.Render("tokens  ") + " " + value.Render(tokens)
@@ -246,7 +250,24 @@ func (model *Model) assembleOutputLeftBody(width int) string {
 		) + " " + dim.Render(
 			truncateOutputLine(orDash(st.RequestID), width-12),
 		)
-		lines = append(lines, header, modelLine, tokensLine, barLine, latencyLine, reqLine, "")
+		lines = append(lines, header, modelLine, tokensLine, barLine, latencyLine, reqLine)
+		for n, at := range st.Attempts {
+			attempt := fmt.Sprintf("#%d %s · %s", n+1, orDash(at.Model), at.Outcome)
+			if at.KeySlot != "" {
+				attempt += " · key " + at.KeySlot
+			}
+			if at.Wait > 0 {
+				attempt += " · waited " + fmtDur(at.Wait)
+			}
+			tag := "        "
+			if n == 0 {
+				tag = "retries "
+			}
+			lines = append(lines, label.Render(tag)+" "+dim.Render(
+				truncateOutputLine(attempt, width-12),
+			))
+		}
+		lines = append(lines, "")
 	}
 	if !hasAny {
 		lines = append(lines, dim.Render("(no telemetry recorded)"), "")
@@ -453,10 +453,17 @@ func (model *Model) assembleComposeLeftBody(innerW, innerH int) string {
 		metaRow = lipgloss.JoinVertical(lipgloss.Left, typeRow, "", scopeRow)
 	}
 
+	trailersRow := model.renderComposeTrailersRow(
+		innerW,
+		model.focusedElement == focusComposeTrailers,
+	)
+
 	header := lipgloss.JoinVertical(lipgloss.Left,
 		"",
 		metaRow,
 		"",
+		trailersRow,
+		"",
 		dividerTop,
 	)
 	footer := lipgloss.JoinVertical(lipgloss.Left,
@@ -510,6 +517,7 @@ func isComposeFocus(f focusableElement) bool {
 	switch f {
 	case focusComposeType,
 		focusComposeScope,
+		focusComposeTrailers,
 		focusComposeSummary,
 		focusComposeKeypoints,
 		focusComposePipelineModels,